@@ -0,0 +1,63 @@
+// Command phite is the single entry point to the PHITE tools:
+//
+//	phite score ...        polygenic risk calculator
+//	phite convert ...      SNP spreadsheet converter
+//	phite fit summary ...  Garmin FIT tools (also export, records, compare)
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/JerkyTreats/PHITE/cli/internal/dispatch"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	flags := flag.NewFlagSet("phite", flag.ContinueOnError)
+	flags.Usage = func() { dispatch.Usage(flags.Output()) }
+	logLevel := flags.String("log-level", os.Getenv("PHITE_LOG_LEVEL"), "log level passed to every tool (debug, info, warn, error)")
+	profile := flags.String("profile", os.Getenv("PHITE_PROFILE"), "config profile every tool reads from ~/.phite/config.json")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	args = flags.Args()
+	if len(args) == 0 || args[0] == "help" {
+		dispatch.Usage(os.Stdout)
+		return 0
+	}
+
+	_, tool, toolArgs, err := dispatch.Resolve(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "phite: %v\n\n", err)
+		dispatch.Usage(os.Stderr)
+		return 2
+	}
+
+	opts := dispatch.Options{LogLevel: *logLevel, Profile: *profile, SourceDir: os.Getenv("PHITE_SRC")}
+	if opts.SourceDir == "" {
+		if wd, err := os.Getwd(); err == nil {
+			opts.SourceDir = dispatch.FindSourceDir(wd)
+		}
+	}
+
+	cmd, err := dispatch.Command(tool, toolArgs, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "phite: %v\n", err)
+		return 1
+	}
+	code, err := dispatch.Run(cmd, os.Stdin, os.Stdout, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "phite: %v\n", err)
+	}
+	return code
+}
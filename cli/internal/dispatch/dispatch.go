@@ -0,0 +1,228 @@
+// Package dispatch maps `phite` subcommands to the repo's tools and runs them.
+//
+// Each tool stays its own binary in its own module; phite finds the binary and runs it
+// with the remaining arguments, so every tool keeps its flags and can still be run alone.
+package dispatch
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Tool is a binary phite delegates to.
+type Tool struct {
+	// Binary is the executable name, as installed by `go install` from Module/Package
+	Binary string
+	// Module is the tool's module directory in the repo
+	Module string
+	// Package is the tool's main package, relative to Module
+	Package string
+	// Summary is shown in `phite help`
+	Summary string
+	// LogLevelFlag is the tool's own log level flag, if it has one
+	LogLevelFlag string
+}
+
+// Commands maps subcommand paths (e.g. "fit summary") to tools.
+var Commands = map[string]Tool{
+	"score": {
+		Binary: "risk-calculator", Module: "polygenic-risk-calculator", Package: "./cmd/risk-calculator",
+		Summary: "compute polygenic risk scores from a genotype file",
+	},
+	"convert": {
+		Binary: "converter", Module: "converter", Package: "./cmd/converter",
+		Summary: "convert a curated SNP spreadsheet (TSV) to JSON/Markdown/HTML", LogLevelFlag: "-log-level",
+	},
+	"fit summary": {
+		Binary: "summary", Module: "garmin", Package: "./cmd/summary",
+		Summary: "Garmin Connect-style summary of a FIT activity",
+	},
+	"fit export": {
+		Binary: "export", Module: "garmin", Package: "./cmd/export",
+		Summary: "dump FIT records as CSV or JSON",
+	},
+	"fit records": {
+		Binary: "records", Module: "garmin", Package: "./cmd/records",
+		Summary: "ingest activities and track personal records",
+	},
+	"fit compare": {
+		Binary: "compare", Module: "garmin", Package: "./cmd/compare",
+		Summary: "compare two activities split by split",
+	},
+	"fit fitness": {
+		Binary: "fitness", Module: "garmin", Package: "./cmd/fitness",
+		Summary: "training stress and the fitness, fatigue, and form curve",
+	},
+	"fit wellness": {
+		Binary: "wellness", Module: "garmin", Package: "./cmd/wellness",
+		Summary: "daily summaries of monitoring and wellness FIT files",
+	},
+}
+
+// ErrUnknownCommand is returned for arguments that do not name a subcommand.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Resolve finds the subcommand at the start of args and returns its name, tool, and the
+// arguments for the tool.
+func Resolve(args []string) (string, Tool, []string, error) {
+	if len(args) >= 2 {
+		if tool, ok := Commands[args[0]+" "+args[1]]; ok {
+			return args[0] + " " + args[1], tool, args[2:], nil
+		}
+	}
+	if len(args) >= 1 {
+		if tool, ok := Commands[args[0]]; ok {
+			return args[0], tool, args[1:], nil
+		}
+	}
+	return "", Tool{}, nil, fmt.Errorf("%w: %s", ErrUnknownCommand, strings.Join(args, " "))
+}
+
+// Options are the conventions phite applies to every tool.
+type Options struct {
+	// LogLevel is exported as PHITE_LOG_LEVEL and passed to tools with their own flag
+	LogLevel string
+	// Profile is exported as PHITE_PROFILE, selecting a profile of the shared config file
+	Profile string
+	// SourceDir is a PHITE checkout used to `go run` tools that are not installed
+	SourceDir string
+}
+
+// Command builds the command that runs tool with args.
+//
+// The binary is looked up, in order, from the PHITE_<BINARY>_BIN environment variable,
+// next to the phite executable, and in the `go install` directory. PATH is not searched:
+// tool names such as compare and export are common, so an unrelated program could run in
+// the tool's place. If none is found and a source checkout is known, the tool is run with
+// `go run` from its module.
+func Command(tool Tool, args []string, opts Options) (*exec.Cmd, error) {
+	if opts.LogLevel != "" && tool.LogLevelFlag != "" && !hasFlag(args, tool.LogLevelFlag) {
+		args = append([]string{tool.LogLevelFlag, opts.LogLevel}, args...)
+	}
+
+	var cmd *exec.Cmd
+	if bin, ok := findBinary(tool); ok {
+		cmd = exec.Command(bin, args...)
+	} else if opts.SourceDir != "" {
+		cmd = exec.Command("go", append([]string{"run", tool.Package}, args...)...)
+		cmd.Dir = filepath.Join(opts.SourceDir, tool.Module)
+	} else {
+		return nil, fmt.Errorf("%s not found: install it (go install ./%s/%s), set %s, or run phite from a PHITE checkout",
+			tool.Binary, tool.Module, strings.TrimPrefix(tool.Package, "./"), envVar(tool))
+	}
+
+	cmd.Env = os.Environ()
+	if opts.LogLevel != "" {
+		cmd.Env = append(cmd.Env, "PHITE_LOG_LEVEL="+opts.LogLevel)
+	}
+	if opts.Profile != "" {
+		cmd.Env = append(cmd.Env, "PHITE_PROFILE="+opts.Profile)
+	}
+	return cmd, nil
+}
+
+// Run runs the command with the given standard streams and returns its exit code.
+func Run(cmd *exec.Cmd, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+// FindSourceDir returns the PHITE checkout containing dir (the directory with the
+// converter, garmin, and polygenic-risk-calculator modules), or "".
+func FindSourceDir(dir string) string {
+	for {
+		if isSourceDir(dir) {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func isSourceDir(dir string) bool {
+	for _, module := range []string{"converter", "garmin", "polygenic-risk-calculator"} {
+		if _, err := os.Stat(filepath.Join(dir, module, "go.mod")); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Usage writes the list of subcommands.
+func Usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: phite [-log-level LEVEL] [-profile NAME] <command> [args]\n\nCommands:\n")
+	names := make([]string, 0, len(Commands))
+	for name := range Commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-14s %s (%s)\n", name, Commands[name].Summary, Commands[name].Binary)
+	}
+	fmt.Fprintf(w, "\nRun `phite <command> -h` for a command's flags. All tools read ~/.phite/config.json.\n")
+}
+
+func findBinary(tool Tool) (string, bool) {
+	if bin := os.Getenv(envVar(tool)); bin != "" {
+		return bin, true
+	}
+	if self, err := os.Executable(); err == nil {
+		sibling := filepath.Join(filepath.Dir(self), tool.Binary)
+		if info, err := os.Stat(sibling); err == nil && !info.IsDir() {
+			return sibling, true
+		}
+	}
+	if dir := goInstallDir(); dir != "" {
+		installed := filepath.Join(dir, tool.Binary)
+		if info, err := os.Stat(installed); err == nil && !info.IsDir() {
+			return installed, true
+		}
+	}
+	return "", false
+}
+
+// goInstallDir returns where `go install` puts binaries: GOBIN, else the bin directory of
+// the first GOPATH entry, else ~/go/bin. It reads the environment rather than running go.
+func goInstallDir() string {
+	if dir := os.Getenv("GOBIN"); dir != "" {
+		return dir
+	}
+	if gopath := filepath.SplitList(os.Getenv("GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "bin")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, "go", "bin")
+	}
+	return ""
+}
+
+// envVar returns the override variable for a tool's binary, e.g. PHITE_RISK_CALCULATOR_BIN.
+func envVar(tool Tool) string {
+	return "PHITE_" + strings.ToUpper(strings.ReplaceAll(tool.Binary, "-", "_")) + "_BIN"
+}
+
+func hasFlag(args []string, flag string) bool {
+	for _, a := range args {
+		if a == flag || strings.HasPrefix(a, flag+"=") || a == "-"+flag || strings.HasPrefix(a, "-"+flag+"=") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package dispatch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		args     []string
+		name     string
+		toolArgs []string
+	}{
+		{[]string{"score", "--input", "g.txt"}, "score", []string{"--input", "g.txt"}},
+		{[]string{"convert"}, "convert", []string{}},
+		{[]string{"fit", "summary", "a.fit"}, "fit summary", []string{"a.fit"}},
+		{[]string{"fit", "compare", "a.fit", "b.fit"}, "fit compare", []string{"a.fit", "b.fit"}},
+		{[]string{"fit", "fitness", "-days", "7"}, "fit fitness", []string{"-days", "7"}},
+		{[]string{"fit", "wellness", "dir"}, "fit wellness", []string{"dir"}},
+	}
+	for _, tt := range tests {
+		name, _, toolArgs, err := Resolve(tt.args)
+		if err != nil {
+			t.Fatalf("Resolve(%v): %v", tt.args, err)
+		}
+		if name != tt.name || !reflect.DeepEqual(toolArgs, tt.toolArgs) {
+			t.Errorf("Resolve(%v) = %q %v, want %q %v", tt.args, name, toolArgs, tt.name, tt.toolArgs)
+		}
+	}
+
+	for _, args := range [][]string{{"fit"}, {"fit", "nope"}, {"bogus"}} {
+		if _, _, _, err := Resolve(args); !errors.Is(err, ErrUnknownCommand) {
+			t.Errorf("Resolve(%v) error = %v, want ErrUnknownCommand", args, err)
+		}
+	}
+}
+
+func TestCommandUsesEnvOverride(t *testing.T) {
+	tool := Commands["convert"]
+	t.Setenv(envVar(tool), "/opt/phite/converter")
+
+	cmd, err := Command(tool, []string{"-input", "x.tsv"}, Options{LogLevel: "debug", Profile: "race"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Path != "/opt/phite/converter" {
+		t.Errorf("Path = %q", cmd.Path)
+	}
+	want := []string{"/opt/phite/converter", "-log-level", "debug", "-input", "x.tsv"}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("Args = %v, want %v", cmd.Args, want)
+	}
+	if !slices.Contains(cmd.Env, "PHITE_LOG_LEVEL=debug") {
+		t.Error("PHITE_LOG_LEVEL not exported")
+	}
+	if !slices.Contains(cmd.Env, "PHITE_PROFILE=race") {
+		t.Error("PHITE_PROFILE not exported")
+	}
+}
+
+func TestCommandKeepsExplicitLogLevel(t *testing.T) {
+	tool := Commands["convert"]
+	t.Setenv(envVar(tool), "/opt/phite/converter")
+
+	cmd, err := Command(tool, []string{"--log-level=error"}, Options{LogLevel: "debug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/opt/phite/converter", "--log-level=error"}; !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("Args = %v, want %v", cmd.Args, want)
+	}
+}
+
+func TestCommandFallsBackToSource(t *testing.T) {
+	t.Setenv("GOBIN", t.TempDir())
+	tool := Commands["fit summary"]
+
+	cmd, err := Command(tool, []string{"a.fit"}, Options{SourceDir: "/src/phite"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Dir != filepath.Join("/src/phite", "garmin") {
+		t.Errorf("Dir = %q", cmd.Dir)
+	}
+	if want := []string{"go", "run", "./cmd/summary", "a.fit"}; !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("Args = %v, want %v", cmd.Args, want)
+	}
+
+	if _, err := Command(tool, nil, Options{}); err == nil {
+		t.Error("expected an error without a binary or source checkout")
+	}
+}
+
+func TestCommandIgnoresPath(t *testing.T) {
+	// An unrelated program sharing a tool's name, such as ImageMagick's compare
+	path := t.TempDir()
+	if err := os.WriteFile(filepath.Join(path, "compare"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", path)
+	t.Setenv("GOBIN", t.TempDir())
+
+	cmd, err := Command(Commands["fit compare"], nil, Options{SourceDir: "/src/phite"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Args[0] != "go" {
+		t.Errorf("Args = %v, want the tool run from source", cmd.Args)
+	}
+}
+
+func TestCommandUsesGoInstallDir(t *testing.T) {
+	gobin := t.TempDir()
+	installed := filepath.Join(gobin, "compare")
+	if err := os.WriteFile(installed, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOBIN", gobin)
+
+	cmd, err := Command(Commands["fit compare"], nil, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Path != installed {
+		t.Errorf("Path = %q, want %q", cmd.Path, installed)
+	}
+}
+
+func TestRunPropagatesExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	tool := Tool{Binary: "fake"}
+	t.Setenv(envVar(tool), "/bin/sh")
+
+	cmd, err := Command(tool, []string{"-c", "exit 3"}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := Run(cmd, nil, os.Stdout, os.Stderr)
+	if err != nil || code != 3 {
+		t.Errorf("Run = %d, %v; want 3, nil", code, err)
+	}
+}
+
+func TestFindSourceDir(t *testing.T) {
+	root := t.TempDir()
+	for _, module := range []string{"converter", "garmin", "polygenic-risk-calculator"} {
+		if err := os.MkdirAll(filepath.Join(root, module), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, module, "go.mod"), []byte("module x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nested := filepath.Join(root, "garmin", "cmd")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := FindSourceDir(nested); got != root {
+		t.Errorf("FindSourceDir = %q, want %q", got, root)
+	}
+	if got := FindSourceDir(t.TempDir()); got != "" {
+		t.Errorf("FindSourceDir outside a checkout = %q", got)
+	}
+}
@@ -0,0 +1,308 @@
+// Package config is the configuration framework shared by the PHITE tools, so the
+// converter, the risk calculator, and the FIT tools read ~/.phite/config.json the same way.
+//
+// Values are looked up by dotted key ("logging.level") in layers, highest first:
+//
+//  1. overrides: Set, and flags applied with BindFlags
+//  2. the environment: PHITE_<SECTION>_<KEY>, e.g. PHITE_GARMIN_FTP_WATTS for key
+//     "ftp_watts" in section "garmin", or PHITE_LOGGING_LEVEL for "logging.level";
+//     with Options.LegacyEnv, then <KEY> for top-level keys
+//  3. the active profile: the file's "profiles.<name>" object, laid over the file
+//  4. the config file
+//  5. defaults registered with SetDefault
+//
+// The profile is chosen by Options.Profile, else PHITE_PROFILE, else the file's "profile"
+// key. Keys are case-insensitive. A tool registers the keys it cannot run without with
+// Require and checks them with Validate.
+//
+//	{
+//	  "logging": {"level": "info"},
+//	  "garmin": {"ftp_watts": 250},
+//	  "profile": "home",
+//	  "profiles": {"race": {"garmin": {"ftp_watts": 270}}}
+//	}
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EnvPrefix prefixes the environment variable of every key.
+const EnvPrefix = "PHITE"
+
+// EnvProfile selects the profile.
+const EnvProfile = EnvPrefix + "_PROFILE"
+
+// Reserved top-level keys of the config file.
+const (
+	ProfileKey  = "profile"
+	ProfilesKey = "profiles"
+)
+
+// DefaultPath returns ~/.phite/config.json.
+func DefaultPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".phite", "config.json")
+}
+
+// Options select what a Config reads.
+type Options struct {
+	// Path is the config file; empty means DefaultPath. A missing file is not an error.
+	Path string
+	// Section is the top-level object the tool's keys live under, e.g. "garmin"; empty
+	// means keys are read from the top level
+	Section string
+	// Profile overrides PHITE_PROFILE and the file's "profile" key
+	Profile string
+	// LegacyEnv also reads top-level keys from their unprefixed variable, e.g. GWAS_DB_PATH
+	// for "gwas_db_path", as tools did before the PHITE_ prefix; the prefixed variable wins
+	LegacyEnv bool
+}
+
+// Config is a layered set of configuration values. It is safe for concurrent use.
+type Config struct {
+	mu        sync.RWMutex
+	opts      Options
+	profile   string
+	file      map[string]any
+	defaults  map[string]any
+	overrides map[string]any
+	required  []string
+}
+
+// New returns an empty Config reading nothing from disk; values come from the environment,
+// defaults, and overrides only.
+func New(opts Options) *Config {
+	return &Config{
+		opts:      opts,
+		file:      map[string]any{},
+		defaults:  map[string]any{},
+		overrides: map[string]any{},
+	}
+}
+
+// Load reads the config file selected by opts. A missing file yields an empty Config; a
+// file that is not valid JSON is an error.
+func Load(opts Options) (*Config, error) {
+	c := New(opts)
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Path returns the config file path.
+func (c *Config) Path() string {
+	if c.opts.Path != "" {
+		return c.opts.Path
+	}
+	return DefaultPath()
+}
+
+// Profile returns the active profile, or "".
+func (c *Config) Profile() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.profile
+}
+
+// Reload re-reads the config file, keeping defaults, overrides, and required keys.
+func (c *Config) Reload() error {
+	root, err := readFile(c.Path())
+	if err != nil {
+		return err
+	}
+
+	profile := c.opts.Profile
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+	}
+	if profile == "" {
+		if p, ok := root[ProfileKey].(string); ok {
+			profile = p
+		}
+	}
+	profile = strings.ToLower(profile)
+	if profile != "" {
+		profiles, _ := root[ProfilesKey].(map[string]any)
+		overlay, ok := profiles[profile].(map[string]any)
+		if !ok {
+			return fmt.Errorf("config profile %q not found in %s", profile, c.Path())
+		}
+		root = merge(root, overlay)
+	}
+	delete(root, ProfileKey)
+	delete(root, ProfilesKey)
+
+	file := root
+	if c.opts.Section != "" {
+		file, _ = lookup(root, split(c.opts.Section)).(map[string]any)
+	}
+	if file == nil {
+		file = map[string]any{}
+	}
+
+	c.mu.Lock()
+	c.file, c.profile = file, profile
+	c.mu.Unlock()
+	return nil
+}
+
+func readFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var root map[string]any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return lowerKeys(root), nil
+}
+
+// SetDefault sets the value used when no other layer has key.
+func (c *Config) SetDefault(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	put(c.defaults, split(key), value)
+}
+
+// Set overrides key for this process. Overrides are not saved.
+func (c *Config) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	put(c.overrides, split(key), value)
+}
+
+// EnvVar returns the environment variable that overrides key.
+func (c *Config) EnvVar(key string) string {
+	name := key
+	if c.opts.Section != "" {
+		name = c.opts.Section + "." + key
+	}
+	name = strings.NewReplacer(".", "_", "-", "_").Replace(strings.ToUpper(name))
+	return EnvPrefix + "_" + name
+}
+
+// Get returns the value of key from the highest layer that has it.
+func (c *Config) Get(key string) (any, bool) {
+	path := split(key)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if v := lookup(c.overrides, path); v != nil {
+		return v, true
+	}
+	if v, ok := os.LookupEnv(c.EnvVar(key)); ok {
+		return v, true
+	}
+	if c.opts.LegacyEnv && c.opts.Section == "" && len(path) == 1 {
+		if v, ok := os.LookupEnv(strings.ToUpper(path[0])); ok {
+			return v, true
+		}
+	}
+	v, d := lookup(c.file, path), lookup(c.defaults, path)
+	if vm, ok := v.(map[string]any); ok {
+		if dm, ok := d.(map[string]any); ok {
+			return merge(dm, vm), true
+		}
+	}
+	if v != nil {
+		return v, true
+	}
+	return d, d != nil
+}
+
+// IsSet reports whether any layer, including defaults, has key.
+func (c *Config) IsSet(key string) bool {
+	_, ok := c.Get(key)
+	return ok
+}
+
+// Unmarshal decodes the object at key ("" for the whole section) into out, as JSON.
+// Overrides are merged over the file and defaults; environment variables apply to leaf
+// keys only and are not seen here.
+func (c *Config) Unmarshal(key string, out any) error {
+	c.mu.RLock()
+	all := merge(merge(c.defaults, c.file), c.overrides)
+	c.mu.RUnlock()
+
+	var v any = all
+	if key != "" {
+		v = lookup(all, split(key))
+	}
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode config %q: %w", key, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode config %q: %w", key, err)
+	}
+	return nil
+}
+
+// Require registers keys that must be set for the tool to run.
+func (c *Config) Require(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if !contains(c.required, key) {
+			c.required = append(c.required, key)
+		}
+	}
+}
+
+// Missing returns the required keys that no layer sets, sorted.
+func (c *Config) Missing() []string {
+	c.mu.RLock()
+	required := append([]string(nil), c.required...)
+	c.mu.RUnlock()
+
+	var missing []string
+	for _, key := range required {
+		if !c.IsSet(key) {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// MissingKeysError lists required keys that are not set.
+type MissingKeysError struct {
+	Keys []string
+	Path string
+}
+
+func (e *MissingKeysError) Error() string {
+	return fmt.Sprintf("missing required configuration keys %s (set them in %s or the environment)",
+		strings.Join(e.Keys, ", "), e.Path)
+}
+
+// Validate returns a *MissingKeysError if any required key is not set.
+func (c *Config) Validate() error {
+	if missing := c.Missing(); len(missing) > 0 {
+		return &MissingKeysError{Keys: missing, Path: c.Path()}
+	}
+	return nil
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLayers(t *testing.T) {
+	path := writeConfig(t, `{"garmin": {"ftp_watts": 250, "user_age": 40, "Max_Heart_Rate": 185}}`)
+	c, err := Load(Options{Path: path, Section: "garmin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetDefault("user_age", 30)
+	c.SetDefault("user_sex", "f")
+
+	if got := c.Float64("ftp_watts"); got != 250 {
+		t.Errorf("ftp_watts = %v, want 250 from the file", got)
+	}
+	if got := c.Int("user_age"); got != 40 {
+		t.Errorf("user_age = %v, want the file over the default", got)
+	}
+	if got := c.String("user_sex"); got != "f" {
+		t.Errorf("user_sex = %q, want the default", got)
+	}
+	if got := c.Int("max_heart_rate"); got != 185 {
+		t.Errorf("keys should be case-insensitive, got %v", got)
+	}
+
+	t.Setenv("PHITE_GARMIN_FTP_WATTS", "260")
+	if got := c.Float64("ftp_watts"); got != 260 {
+		t.Errorf("ftp_watts = %v, want 260 from the environment", got)
+	}
+	c.Set("ftp_watts", 270)
+	if got := c.Float64("ftp_watts"); got != 270 {
+		t.Errorf("ftp_watts = %v, want 270 from the override", got)
+	}
+}
+
+func TestLegacyEnv(t *testing.T) {
+	t.Setenv("GWAS_DB_PATH", "/data/legacy.duckdb")
+	if got := New(Options{}).String("gwas_db_path"); got != "" {
+		t.Errorf("gwas_db_path = %q, want unprefixed variables ignored by default", got)
+	}
+
+	c := New(Options{LegacyEnv: true})
+	if got := c.String("gwas_db_path"); got != "/data/legacy.duckdb" {
+		t.Errorf("gwas_db_path = %q, want the legacy variable", got)
+	}
+	t.Setenv("PHITE_GWAS_DB_PATH", "/data/gwas.duckdb")
+	if got := c.String("gwas_db_path"); got != "/data/gwas.duckdb" {
+		t.Errorf("gwas_db_path = %q, want the prefixed variable over the legacy one", got)
+	}
+
+	t.Setenv("LOGGING_LEVEL", "debug")
+	if got := c.String("logging.level"); got != "" {
+		t.Errorf("logging.level = %q, want legacy variables for top-level keys only", got)
+	}
+}
+
+func TestDottedKeys(t *testing.T) {
+	path := writeConfig(t, `{"logging": {"level": "debug"}, "output.sort_by": "trait"}`)
+	c, err := Load(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String("logging.level"); got != "debug" {
+		t.Errorf("logging.level = %q", got)
+	}
+	if got := c.String("output.sort_by"); got != "trait" {
+		t.Errorf("literal dotted key = %q", got)
+	}
+	if got := c.StringMap("logging"); got["level"] != "debug" {
+		t.Errorf("StringMap = %v", got)
+	}
+	if got := c.String("logging"); got != "" {
+		t.Errorf("String of an object = %q, want empty", got)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	path := writeConfig(t, `{
+		"garmin": {"ftp_watts": 250, "user_age": 40},
+		"profile": "race",
+		"profiles": {
+			"race": {"garmin": {"ftp_watts": 270}},
+			"easy": {"garmin": {"ftp_watts": 200}}
+		}
+	}`)
+
+	c, err := Load(Options{Path: path, Section: "garmin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Profile() != "race" || c.Float64("ftp_watts") != 270 || c.Int("user_age") != 40 {
+		t.Errorf("file profile: profile=%q ftp=%v age=%v", c.Profile(), c.Float64("ftp_watts"), c.Int("user_age"))
+	}
+
+	t.Setenv(EnvProfile, "easy")
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Float64("ftp_watts") != 200 {
+		t.Errorf("PHITE_PROFILE=easy: ftp = %v", c.Float64("ftp_watts"))
+	}
+
+	if _, err := Load(Options{Path: path, Profile: "missing"}); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
+
+func TestMissingFileAndBadJSON(t *testing.T) {
+	c, err := Load(Options{Path: filepath.Join(t.TempDir(), "none.json")})
+	if err != nil {
+		t.Fatalf("missing file: %v", err)
+	}
+	if c.IsSet("anything") {
+		t.Error("empty config has a key")
+	}
+
+	if _, err := Load(Options{Path: writeConfig(t, `{not json`)}); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestRequired(t *testing.T) {
+	c := New(Options{Path: "/nonexistent/config.json"})
+	c.Require("gcp.billing_project", "tables.model_table", "gcp.billing_project")
+	c.Set("tables.model_table", "models")
+
+	err := c.Validate()
+	var missing *MissingKeysError
+	if !errors.As(err, &missing) {
+		t.Fatalf("Validate = %v, want *MissingKeysError", err)
+	}
+	if !reflect.DeepEqual(missing.Keys, []string{"gcp.billing_project"}) {
+		t.Errorf("missing = %v", missing.Keys)
+	}
+
+	t.Setenv("PHITE_GCP_BILLING_PROJECT", "my-project")
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate with env = %v", err)
+	}
+}
+
+func TestConversions(t *testing.T) {
+	c := New(Options{})
+	c.Set("timeout", "90s")
+	c.Set("interval", 30)
+	c.Set("strict", "true")
+	c.Set("panel", []any{"rs1", "rs2"})
+	c.Set("traits", "a, b")
+
+	if c.Duration("timeout") != 90*time.Second || c.Duration("interval") != 30*time.Second {
+		t.Errorf("durations = %v, %v", c.Duration("timeout"), c.Duration("interval"))
+	}
+	if !c.Bool("strict") {
+		t.Error("strict should be true")
+	}
+	if got := c.StringSlice("panel"); !reflect.DeepEqual(got, []string{"rs1", "rs2"}) {
+		t.Errorf("panel = %v", got)
+	}
+	if got := c.StringSlice("traits"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("traits = %v", got)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	path := writeConfig(t, `{"prs": {"score_scales": {"t2d": {"min": 1, "max": 5}}}}`)
+	c, err := Load(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scales map[string]struct{ Min, Max float64 }
+	if err := c.Unmarshal("prs.score_scales", &scales); err != nil {
+		t.Fatal(err)
+	}
+	if scales["t2d"].Max != 5 {
+		t.Errorf("scales = %v", scales)
+	}
+}
+
+func TestBindFlags(t *testing.T) {
+	c := New(Options{})
+	c.SetDefault("output_dir", "output")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("output-dir", "ignored-default", "")
+	fs.Int("workers", 4, "")
+	if err := fs.Parse([]string{"-workers", "8"}); err != nil {
+		t.Fatal(err)
+	}
+	c.BindFlags(fs, map[string]string{"output-dir": "output_dir", "workers": "workers"})
+
+	if c.String("output_dir") != "output" {
+		t.Errorf("unset flag overrode the config: %q", c.String("output_dir"))
+	}
+	if c.Int("workers") != 8 {
+		t.Errorf("workers = %v", c.Int("workers"))
+	}
+}
+
+func TestUpdateKeepsOtherKeys(t *testing.T) {
+	path := writeConfig(t, `{"garmin": {"ftp_watts": 250}, "logging": {"level": "info"}}`)
+	if err := Update(path, map[string]any{"output_dir": "out", "logging.format": "json"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root map[string]any
+	if err := json.Unmarshal(data, &root); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"garmin":     map[string]any{"ftp_watts": 250.0},
+		"logging":    map[string]any{"level": "info", "format": "json"},
+		"output_dir": "out",
+	}
+	if !reflect.DeepEqual(root, want) {
+		t.Errorf("config file = %v, want %v", root, want)
+	}
+}
@@ -0,0 +1,20 @@
+package config
+
+import "flag"
+
+// BindFlags sets an override for every flag in keys (flag name -> config key) that was
+// given on the command line, so flags win over the environment and the file while flag
+// defaults do not. Call it after fs.Parse.
+func (c *Config) BindFlags(fs *flag.FlagSet, keys map[string]string) {
+	fs.Visit(func(f *flag.Flag) {
+		key, ok := keys[f.Name]
+		if !ok {
+			return
+		}
+		if g, ok := f.Value.(flag.Getter); ok {
+			c.Set(key, g.Get())
+			return
+		}
+		c.Set(key, f.Value.String())
+	})
+}
@@ -0,0 +1,3 @@
+module github.com/JerkyTreats/PHITE/config
+
+go 1.24.3
@@ -0,0 +1,77 @@
+package config
+
+import "strings"
+
+// split lower-cases a dotted key into its path.
+func split(key string) []string {
+	return strings.Split(strings.ToLower(key), ".")
+}
+
+// lookup walks path through nested objects. A literal dotted key ("logging.level" at the
+// top level) matches as well as the nested form, longest match first.
+func lookup(m map[string]any, path []string) any {
+	for i := len(path); i >= 1; i-- {
+		v, ok := m[strings.Join(path[:i], ".")]
+		if !ok {
+			continue
+		}
+		if i == len(path) {
+			return v
+		}
+		if sub, ok := v.(map[string]any); ok {
+			if found := lookup(sub, path[i:]); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// put sets path in m, creating (or replacing non-object values with) nested objects.
+func put(m map[string]any, path []string, value any) {
+	for _, k := range path[:len(path)-1] {
+		sub, ok := m[k].(map[string]any)
+		if !ok {
+			sub = map[string]any{}
+			m[k] = sub
+		}
+		m = sub
+	}
+	if sub, ok := value.(map[string]any); ok {
+		value = lowerKeys(sub)
+	}
+	m[path[len(path)-1]] = value
+}
+
+// merge returns a deep copy of base with overlay's values laid over it; nested objects
+// are merged, anything else is replaced.
+func merge(base, overlay map[string]any) map[string]any {
+	out := make(map[string]any, len(base)+len(overlay))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range overlay {
+		if sub, ok := v.(map[string]any); ok {
+			if prev, ok := out[k].(map[string]any); ok {
+				out[k] = merge(prev, sub)
+				continue
+			}
+			out[k] = merge(nil, sub)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// lowerKeys lower-cases object keys recursively, so lookups are case-insensitive.
+func lowerKeys(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		if sub, ok := v.(map[string]any); ok {
+			v = lowerKeys(sub)
+		}
+		out[strings.ToLower(k)] = v
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// String returns key as a string, or "".
+func (c *Config) String(key string) string {
+	v, _ := c.Get(key)
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any, []any:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Int returns key as an int, or 0 if it is unset or not a number.
+func (c *Config) Int(key string) int {
+	return int(c.Float64(key))
+}
+
+// Float64 returns key as a float64, or 0 if it is unset or not a number.
+func (c *Config) Float64(key string) float64 {
+	v, _ := c.Get(key)
+	switch v := v.(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f
+	case bool:
+		if v {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Bool returns key as a bool: true, or a string strconv.ParseBool accepts as true.
+func (c *Config) Bool(key string) bool {
+	v, _ := c.Get(key)
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(strings.TrimSpace(v))
+		return b
+	case float64:
+		return v != 0
+	case int:
+		return v != 0
+	}
+	return false
+}
+
+// Duration returns key as a duration: a string like "90s", or a number of seconds.
+func (c *Config) Duration(key string) time.Duration {
+	v, _ := c.Get(key)
+	switch v := v.(type) {
+	case time.Duration:
+		return v
+	case string:
+		if d, err := time.ParseDuration(strings.TrimSpace(v)); err == nil {
+			return d
+		}
+	}
+	return time.Duration(c.Float64(key) * float64(time.Second))
+}
+
+// StringSlice returns key as a list of strings. A string value, e.g. from the
+// environment, is split on commas.
+func (c *Config) StringSlice(key string) []string {
+	v, _ := c.Get(key)
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, e := range v {
+			out = append(out, fmt.Sprint(e))
+		}
+		return out
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil
+		}
+		parts := strings.Split(v, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		return parts
+	}
+	return nil
+}
+
+// StringMap returns the object at key with its values as strings; it is never nil.
+func (c *Config) StringMap(key string) map[string]string {
+	out := map[string]string{}
+	v, _ := c.Get(key)
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			out[k] = fmt.Sprint(e)
+		}
+	case map[string]string:
+		for k, e := range v {
+			out[strings.ToLower(k)] = e
+		}
+	}
+	return out
+}
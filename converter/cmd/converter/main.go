@@ -1,12 +1,31 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 
 	"github.com/JerkyTreats/PHITE/converter/internal/config"
 	"github.com/JerkyTreats/PHITE/converter/internal/converter"
+	"github.com/JerkyTreats/PHITE/converter/internal/genotype"
+	"github.com/JerkyTreats/PHITE/converter/internal/render"
+	"github.com/JerkyTreats/PHITE/converter/internal/schema"
+	"github.com/JerkyTreats/PHITE/converter/internal/watch"
 	"github.com/JerkyTreats/PHITE/converter/pkg/logger"
+	"github.com/JerkyTreats/PHITE/logging"
+	"github.com/JerkyTreats/PHITE/taxonomy"
+)
+
+// Reports written to the output directory when validation or enrichment finds issues.
+const (
+	taxonomyReportFile = "taxonomy_report.json"
+	genotypeReportFile = "genotype_report.json"
+	conflictReportFile = "conflicts_report.json"
 )
 
 func main() {
@@ -16,20 +35,54 @@ func main() {
 		logger.Fatal(err, "failed to load configuration")
 	}
 
-	inputFile := flag.String("input", "", "path to input TSV file")
+	inputFile := flag.String("input", "", "path to input TSV file (or, with -watch, a directory of TSV files)")
 	outputDir := flag.String("output-dir", config.GetOutputDir(), "directory to save JSON files")
-	logLevel := flag.String("log-level", config.GetLogLevel(), "logging level (debug, info, error, fatal)")
-	groupingMode := flag.String("grouping-mode", "group", "grouping mode: 'group' or 'topic'") // New flag
+	logDefaults := logging.FromEnv(logging.Options{Level: config.GetLogLevel(), Format: config.GetLogFormat(), File: config.GetLogFile()})
+	logLevel := flag.String("log-level", logDefaults.Level, "logging level (debug, info, warn, error, fatal, none)")
+	logFormat := flag.String("log-format", logDefaults.Format, "log output format: 'text' or 'json'")
+	logFile := flag.String("log-file", logDefaults.File, "write logs to this file, rotated by size, instead of stderr")
+	groupingMode := flag.String("grouping-mode", "group", "grouping mode: 'group', 'topic', or 'gene'") // New flag
+	taxonomyFile := flag.String("taxonomy", "", "canonical Topic/Group taxonomy TSV to validate input categories against")
+	validateOnly := flag.Bool("validate-only", false, "check categories against -taxonomy and write the report without converting")
+	autoCorrect := flag.Bool("auto-correct", false, "replace misspelled categories with their closest -taxonomy match")
+	format := flag.String("format", "json", "output format: 'json', or 'markdown' or 'html' for one readable document per topic/group")
+	schemaVersion := flag.String("schema", schema.Default, "schema version of JSON output: "+strings.Join(schema.Versions(), ", "))
+	migrate := flag.Bool("migrate", false, "rewrite the JSON output files given as arguments in the -schema version, into -output-dir, instead of converting -input")
+	locale := flag.String("locale", "en", "language of markdown and html headings and match wording: "+strings.Join(render.LocaleTags(), ", ")+"; regions such as 'de-DE' are accepted")
+	nameTemplate := flag.String("name-template", "", "output file naming template, e.g. '{topic|slug}/{group}.{ext}' (placeholders: topic, group, gene, ext; transforms: slug, lower, upper)")
+	genotypeFile := flag.String("genotype-file", "", "23andMe or AncestryDNA genotype file to fill/override the Subject Genotype column from")
+	onConflict := flag.String("on-conflict", converter.ResolveFirstWins, "how to combine rows repeating an rsid within a group: 'first-wins', 'last-wins', 'merge-notes', or 'error'")
+	watchMode := flag.Bool("watch", false, "keep running and re-convert whenever -input (a TSV file, or a directory of them) changes")
+	debounce := flag.Duration("debounce", watch.DefaultDebounce, "with -watch, how long to wait after the last change before converting")
 	flag.Parse()
 
-	// Set logging level
-	if err := logger.SetLevel(*logLevel); err != nil {
-		logger.Fatal(err, "invalid log level specified")
+	if err := logger.Configure(*logLevel, *logFormat, *logFile); err != nil {
+		logger.Fatal(err, "invalid logging options")
 	}
 
-	if *inputFile == "" {
+	if !schema.Valid(*schemaVersion) {
+		logger.Fatal(nil, "invalid -schema; must be one of "+strings.Join(schema.Versions(), ", "), "schema", *schemaVersion)
+	}
+	if *migrate {
+		if flag.NArg() == 0 {
+			logger.Fatal(nil, "-migrate requires the JSON files to rewrite as arguments")
+		}
+	} else if *inputFile == "" {
 		logger.Fatal(nil, "input file is required")
 	}
+	if (*validateOnly || *autoCorrect) && *taxonomyFile == "" {
+		logger.Fatal(nil, "-validate-only and -auto-correct require -taxonomy")
+	}
+	if !converter.ValidResolution(*onConflict) {
+		logger.Fatal(nil, "invalid -on-conflict; must be 'first-wins', 'last-wins', 'merge-notes', or 'error'", "on-conflict", *onConflict)
+	}
+	loc, err := render.LookupLocale(*locale)
+	if err != nil {
+		logger.Fatal(err, "invalid -locale")
+	}
+	if *validateOnly && *watchMode {
+		logger.Fatal(nil, "-validate-only cannot be combined with -watch")
+	}
 
 	// Create output directory if it doesn't exist
 	absOutputDir, err := filepath.Abs(*outputDir)
@@ -45,23 +98,178 @@ func main() {
 		}
 	}
 
-	parser := converter.NewTSVParser(*inputFile, absOutputDir, *groupingMode)
+	if *migrate {
+		if err := migrateFiles(flag.Args(), absOutputDir, *schemaVersion); err != nil {
+			logger.Fatal(err, "migration failed")
+		}
+		return
+	}
+
+	var tax *taxonomy.Taxonomy
+	if *taxonomyFile != "" {
+		if tax, err = taxonomy.Load(*taxonomyFile); err != nil {
+			logger.Fatal(err, "failed to load taxonomy")
+		}
+	}
+	var calls map[string]string
+	if *genotypeFile != "" {
+		if calls, err = genotype.Load(*genotypeFile); err != nil {
+			logger.Fatal(err, "failed to load genotype file")
+		}
+	}
+
+	newParser := func(input string) (*converter.TSVParser, error) {
+		parser := converter.NewTSVParser(input, absOutputDir, *groupingMode)
+		parser.SetFormat(*format)
+		parser.SetSchema(*schemaVersion)
+		parser.SetLocale(loc)
+		parser.SetConflictResolution(*onConflict)
+		if *nameTemplate != "" {
+			if err := parser.SetNameTemplate(*nameTemplate); err != nil {
+				return nil, fmt.Errorf("invalid naming template: %w", err)
+			}
+		}
+		if tax != nil {
+			parser.SetTaxonomy(tax, *autoCorrect)
+		}
+		if calls != nil {
+			parser.SetGenotypes(calls)
+		}
+		return parser, nil
+	}
+	convert := func(input string) ([]string, error) {
+		parser, err := newParser(input)
+		if err != nil {
+			return nil, err
+		}
+		return runConversion(parser, input, absOutputDir)
+	}
+
+	if *validateOnly {
+		parser, err := newParser(*inputFile)
+		if err != nil {
+			logger.Fatal(err, "failed to configure converter")
+		}
+		report, err := parser.Validate()
+		if err != nil {
+			logger.Fatal(err, "failed to validate TSV")
+		}
+		if err := saveTaxonomyReport(report, absOutputDir); err != nil {
+			logger.Fatal(err, "failed to save taxonomy report")
+		}
+		if report.Unresolved() > 0 {
+			logger.Error(nil, "input has unknown categories", "unresolved", report.Unresolved(), "issues", len(report.Issues))
+			os.Exit(1)
+		}
+		logger.Info("all categories match the taxonomy", "corrected", len(report.Issues))
+		return
+	}
+
+	if *watchMode {
+		watcher, err := watch.New(*inputFile, *debounce, convert)
+		if err != nil {
+			logger.Fatal(err, "failed to start watch mode")
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := watcher.Run(ctx); err != nil {
+			logger.Fatal(err, "watch mode failed")
+		}
+		return
+	}
+
+	if _, err := convert(*inputFile); err != nil {
+		logger.Fatal(err, "conversion failed")
+	}
+}
+
+// runConversion parses one input file, saves any reports, and returns the generated files.
+func runConversion(parser *converter.TSVParser, inputFile, outputDir string) ([]string, error) {
 	outputFiles, errorRecords, err := parser.Parse()
+	if report := parser.ConflictReport(); report != nil && len(report.Conflicts) > 0 {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return nil, err
+		}
+		if err := report.Save(filepath.Join(outputDir, conflictReportFile)); err != nil {
+			return nil, fmt.Errorf("failed to save conflict report: %w", err)
+		}
+		logger.Info("some rsids have conflicting rows", "conflicts", len(report.Conflicts), "duplicates", report.Duplicates)
+	}
 	if err != nil {
-		logger.Fatal(err, "failed to parse TSV")
+		return nil, fmt.Errorf("failed to parse TSV: %w", err)
+	}
+
+	if report := parser.TaxonomyReport(); report != nil {
+		if err := saveTaxonomyReport(report, outputDir); err != nil {
+			return nil, fmt.Errorf("failed to save taxonomy report: %w", err)
+		}
+		if report.Unresolved() > 0 {
+			logger.Info("some categories are not in the taxonomy", "unresolved", report.Unresolved())
+		}
+	}
+	if report := parser.EnrichmentReport(); report != nil && len(report.Overridden) > 0 {
+		if err := report.Save(filepath.Join(outputDir, genotypeReportFile)); err != nil {
+			return nil, fmt.Errorf("failed to save genotype report: %w", err)
+		}
+		logger.Info("sheet genotypes disagreed with the genotype file", "overridden", len(report.Overridden))
 	}
 
 	if len(errorRecords) > 0 {
 		logger.Info("some records were skipped due to invalid format", "errors", len(errorRecords))
 	}
 
-	if len(outputFiles) > 0 {
-		logger.Info("conversion completed successfully", "input", *inputFile, "output-dir", absOutputDir)
-		logger.Info("generated files:", "count", len(outputFiles))
-		for _, file := range outputFiles {
-			logger.Info("generated file", "path", file)
+	if len(outputFiles) == 0 {
+		return nil, fmt.Errorf("no files were generated from %s", inputFile)
+	}
+	logger.Info("conversion completed successfully", "input", inputFile, "output-dir", outputDir)
+	logger.Info("generated files:", "count", len(outputFiles))
+	for _, file := range outputFiles {
+		logger.Info("generated file", "path", file)
+	}
+	return outputFiles, nil
+}
+
+// saveTaxonomyReport writes the report to the output directory when it lists any issues,
+// so corrections and unknown categories can be reviewed.
+func saveTaxonomyReport(report *converter.TaxonomyReport, outputDir string) error {
+	if len(report.Issues) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+	return report.Save(filepath.Join(outputDir, taxonomyReportFile))
+}
+
+// migrateFiles rewrites each converter JSON file in schema version, writing it under the
+// same name into outputDir. Files may be in any registered version; the reports written
+// alongside them are skipped.
+func migrateFiles(files []string, outputDir, version string) error {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+	for _, file := range files {
+		switch filepath.Base(file) {
+		case taxonomyReportFile, genotypeReportFile, conflictReportFile:
+			logger.Info("skipping report", "path", file)
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		from, err := schema.VersionOf(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if data, err = schema.Convert(data, version); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		outPath := filepath.Join(outputDir, filepath.Base(file))
+		if err := os.WriteFile(outPath, data, 0644); err != nil {
+			return err
 		}
-	} else {
-		logger.Fatal(nil, "no files were generated")
+		logger.Info("migrated file", "path", outPath, "from", from, "to", version)
 	}
+	return nil
 }
@@ -3,14 +3,24 @@ module github.com/JerkyTreats/PHITE/converter
 go 1.24.3
 
 require (
-	github.com/joho/godotenv v1.5.1 // indirect
-	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.19 // indirect
-	github.com/openai/openai-go v1.0.0 // indirect
-	github.com/rs/zerolog v1.34.0 // indirect
+	github.com/JerkyTreats/PHITE/config v0.0.0
+	github.com/JerkyTreats/PHITE/logging v0.0.0
+	github.com/JerkyTreats/PHITE/taxonomy v0.0.0
+	github.com/fsnotify/fsnotify v1.8.0
+	github.com/joho/godotenv v1.5.1
+	github.com/openai/openai-go v1.0.0
+)
+
+require (
 	github.com/tidwall/gjson v1.18.0 // indirect
 	github.com/tidwall/match v1.1.1 // indirect
 	github.com/tidwall/pretty v1.2.1 // indirect
 	github.com/tidwall/sjson v1.2.5 // indirect
 	golang.org/x/sys v0.29.0 // indirect
 )
+
+replace github.com/JerkyTreats/PHITE/taxonomy => ../taxonomy
+
+replace github.com/JerkyTreats/PHITE/logging => ../logging
+
+replace github.com/JerkyTreats/PHITE/config => ../config
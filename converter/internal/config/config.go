@@ -1,13 +1,12 @@
 package config
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	phiteconfig "github.com/JerkyTreats/PHITE/config"
 	"github.com/joho/godotenv"
 )
 
@@ -38,6 +37,8 @@ func ValidMatchLevel(level MatchLevel) bool {
 
 type Config interface {
 	GetLogLevel() string
+	GetLogFormat() string
+	GetLogFile() string
 	GetOutputDir() string
 	GetMatchLevel() MatchLevel
 	SetOutputDir(dir string)
@@ -50,6 +51,8 @@ type Config interface {
 
 type DefaultConfig struct {
 	LogLevel   string     `json:"log_level"`
+	LogFormat  string     `json:"log_format,omitempty"`
+	LogFile    string     `json:"log_file,omitempty"`
 	OutputDir  string     `json:"output_dir"`
 	MatchLevel MatchLevel `json:"match_level"`
 }
@@ -68,6 +71,19 @@ func (c *DefaultConfig) GetLogLevel() string {
 	return c.LogLevel
 }
 
+// GetLogFormat returns the log format, "text" or "json"
+func (c *DefaultConfig) GetLogFormat() string {
+	return c.LogFormat
+}
+
+// GetLogFile returns the log file path with ~ expanded, or "" to log to stderr
+func (c *DefaultConfig) GetLogFile() string {
+	if strings.HasPrefix(c.LogFile, "~") {
+		return filepath.Join(os.Getenv("HOME"), strings.TrimPrefix(c.LogFile, "~"))
+	}
+	return c.LogFile
+}
+
 // GetOutputDir returns the current output directory with ~ expanded
 func (c *DefaultConfig) GetOutputDir() string {
 	// Expand ~ to home directory
@@ -87,51 +103,55 @@ func (c *DefaultConfig) SetOutputDir(dir string) {
 	c.OutputDir = dir
 }
 
-// Save saves the configuration to a file
+// Config keys of the converter, at the top level of ~/.phite/config.json. Each can be
+// overridden by PHITE_<KEY>, e.g. PHITE_OUTPUT_DIR.
+const (
+	LogLevelKey   = "log_level"
+	LogFormatKey  = "log_format"
+	LogFileKey    = "log_file"
+	OutputDirKey  = "output_dir"
+	MatchLevelKey = "match_level"
+)
+
+// Save writes the configuration to ~/.phite/config.json, keeping the settings of the
+// other PHITE tools stored in the same file.
 func (c *DefaultConfig) Save() error {
-	configDir := filepath.Join(os.Getenv("HOME"), ".phite")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+	values := map[string]any{
+		LogLevelKey:   c.LogLevel,
+		OutputDirKey:  c.OutputDir,
+		MatchLevelKey: c.MatchLevel,
 	}
-
-	configPath := filepath.Join(configDir, "config.json")
-	configFile, err := os.OpenFile(configPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open config file: %w", err)
+	if c.LogFormat != "" {
+		values[LogFormatKey] = c.LogFormat
 	}
-	defer configFile.Close()
-
-	encoder := json.NewEncoder(configFile)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(c); err != nil {
-		return fmt.Errorf("failed to encode config: %w", err)
+	if c.LogFile != "" {
+		values[LogFileKey] = c.LogFile
+	}
+	if err := phiteconfig.Update(phiteconfig.DefaultPath(), values); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
 	}
-
 	return nil
 }
 
-// LoadConfig loads configuration from a file
+// LoadConfig loads configuration from ~/.phite/config.json, the environment, and the
+// active profile, with NewConfig's values as defaults.
 func LoadConfig() (Config, error) {
-	configDir := filepath.Join(os.Getenv("HOME"), ".phite")
-	configPath := filepath.Join(configDir, "config.json")
-
-	if _, err := os.Stat(configPath); errors.Is(err, os.ErrNotExist) {
-		return NewConfig(), nil
-	}
-
-	configFile, err := os.Open(configPath)
+	c, err := phiteconfig.Load(phiteconfig.Options{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to open config file: %w", err)
-	}
-	defer configFile.Close()
-
-	var config DefaultConfig
-	decoder := json.NewDecoder(configFile)
-	if err := decoder.Decode(&config); err != nil {
-		return nil, fmt.Errorf("failed to decode config: %w", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
+	defaults := NewConfig().(*DefaultConfig)
+	c.SetDefault(LogLevelKey, defaults.LogLevel)
+	c.SetDefault(OutputDirKey, defaults.OutputDir)
+	c.SetDefault(MatchLevelKey, string(defaults.MatchLevel))
 
-	return &config, nil
+	return &DefaultConfig{
+		LogLevel:   c.String(LogLevelKey),
+		LogFormat:  c.String(LogFormatKey),
+		LogFile:    c.String(LogFileKey),
+		OutputDir:  c.String(OutputDirKey),
+		MatchLevel: MatchLevel(c.String(MatchLevelKey)),
+	}, nil
 }
 
 func (c *DefaultConfig) LoadEnv() error {
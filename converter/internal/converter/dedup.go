@@ -0,0 +1,162 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/JerkyTreats/PHITE/converter/pkg/logger"
+)
+
+// Conflict resolutions for rows that repeat an rsid within the same Topic and Group.
+const (
+	// ResolveFirstWins keeps the first row (the default)
+	ResolveFirstWins = "first-wins"
+	// ResolveLastWins keeps the last row, at the position of the first
+	ResolveLastWins = "last-wins"
+	// ResolveMergeNotes keeps the first row with the distinct notes of all rows joined by "; "
+	ResolveMergeNotes = "merge-notes"
+	// ResolveError fails the conversion when any rows conflict
+	ResolveError = "error"
+)
+
+// ValidResolution reports whether resolution is a supported conflict resolution.
+func ValidResolution(resolution string) bool {
+	switch resolution {
+	case ResolveFirstWins, ResolveLastWins, ResolveMergeNotes, ResolveError:
+		return true
+	default:
+		return false
+	}
+}
+
+// conflictFields are the columns compared between duplicate rows, by index.
+var conflictFields = []struct {
+	index int
+	name  string
+}{
+	{2, "Gene"},
+	{4, "Allele"},
+	{5, "Subject Genotype"},
+	{6, "Notes"},
+}
+
+// Conflict is an rsid that appears on several rows of a Topic/Group with differing values.
+type Conflict struct {
+	Topic string `json:"Topic"`
+	Group string `json:"Group"`
+	RSID  string `json:"RSID"`
+	// Lines are the 1-based lines in the input TSV (the header is line 1)
+	Lines []int `json:"Lines"`
+	// Fields are the columns whose values differ between the rows
+	Fields []string `json:"Fields"`
+	// Resolution is how the rows were combined
+	Resolution string `json:"Resolution"`
+}
+
+// ConflictReport lists the duplicate rows found in an input file.
+type ConflictReport struct {
+	// Duplicates counts rows dropped because they repeat the first row for their rsid exactly
+	Duplicates int `json:"Duplicates"`
+	// Conflicts lists rsids whose repeated rows differ
+	Conflicts []Conflict `json:"Conflicts"`
+}
+
+// Save writes the report to the specified file in JSON format.
+func (r *ConflictReport) Save(outputFile string) error {
+	return writeReport(r, outputFile, "conflict report")
+}
+
+// ResolveDuplicates collapses rows (header excluded) that repeat an rsid within the same
+// Topic and Group into one row, using resolution when their values differ. Rows that are
+// exact repeats are dropped silently. Rows with the wrong column count are kept as is so
+// they are still reported as invalid. With ResolveError, any conflict is returned as an
+// error along with the report.
+func ResolveDuplicates(records [][]string, resolution string) ([][]string, *ConflictReport, error) {
+	if !ValidResolution(resolution) {
+		return nil, nil, fmt.Errorf("invalid conflict resolution: %s. Must be 'first-wins', 'last-wins', 'merge-notes', or 'error'", resolution)
+	}
+
+	type entry struct {
+		first    []string
+		index    int   // position in the result
+		lines    []int // lines of the first row and the rows that differ from it
+		fields   []string
+		notes    []string
+		conflict bool
+	}
+	report := &ConflictReport{}
+	entries := make(map[[3]string]*entry)
+	var order [][3]string
+	var result [][]string
+
+	for i, record := range records {
+		if len(record) != 7 {
+			result = append(result, record)
+			continue
+		}
+		line := i + 2
+		key := [3]string{record[0], record[1], record[3]}
+
+		e, exists := entries[key]
+		if !exists {
+			entries[key] = &entry{first: record, index: len(result), lines: []int{line}, notes: nonEmpty(record[6])}
+			order = append(order, key)
+			result = append(result, record)
+			continue
+		}
+
+		if slices.Equal(e.first, record) {
+			report.Duplicates++
+			continue
+		}
+		e.conflict = true
+		e.lines = append(e.lines, line)
+		for _, f := range conflictFields {
+			if e.first[f.index] != record[f.index] && !slices.Contains(e.fields, f.name) {
+				e.fields = append(e.fields, f.name)
+			}
+		}
+		if note := strings.TrimSpace(record[6]); note != "" && !slices.Contains(e.notes, note) {
+			e.notes = append(e.notes, note)
+		}
+
+		switch resolution {
+		case ResolveLastWins:
+			result[e.index] = record
+		case ResolveMergeNotes:
+			merged := slices.Clone(e.first)
+			merged[6] = strings.Join(e.notes, "; ")
+			result[e.index] = merged
+		}
+	}
+
+	for _, key := range order {
+		e := entries[key]
+		if !e.conflict {
+			continue
+		}
+		report.Conflicts = append(report.Conflicts, Conflict{
+			Topic:      key[0],
+			Group:      key[1],
+			RSID:       key[2],
+			Lines:      e.lines,
+			Fields:     e.fields,
+			Resolution: resolution,
+		})
+		logger.Info("conflicting rows for rsid", "topic", key[0], "group", key[1], "rsid", key[2], "lines", e.lines, "fields", e.fields, "resolution", resolution)
+	}
+
+	if resolution == ResolveError && len(report.Conflicts) > 0 {
+		c := report.Conflicts[0]
+		return nil, report, fmt.Errorf("%d conflicting rsids, e.g. %s in %s on lines %v", len(report.Conflicts), c.RSID, c.Group, c.Lines)
+	}
+	return result, report, nil
+}
+
+func nonEmpty(note string) []string {
+	if note = strings.TrimSpace(note); note == "" {
+		return nil
+	}
+	return []string{note}
+}
@@ -0,0 +1,68 @@
+package converter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func duplicateRecords() [][]string {
+	return [][]string{
+		{"Nutrients", "MTHFR", "MTHFR C677T", "rs1801133", "A", "AG", "folate metabolism"},
+		{"Nutrients", "MTHFR", "MTHFR A1298C", "rs1801131", "G", "TT", ""},
+		{"Nutrients", "MTHFR", "MTHFR C677T", "rs1801133", "A", "AG", "folate metabolism"},
+		{"Nutrients", "MTHFR", "MTHFR C677T", "rs1801133", "A", "AA", "reduced enzyme activity"},
+		{"Nutrients", "Folate", "MTHFR C677T", "rs1801133", "A", "AA", ""},
+		{"short", "row"},
+	}
+}
+
+func TestResolveDuplicates(t *testing.T) {
+	tests := []struct {
+		resolution string
+		genotype   string
+		notes      string
+	}{
+		{ResolveFirstWins, "AG", "folate metabolism"},
+		{ResolveLastWins, "AA", "reduced enzyme activity"},
+		{ResolveMergeNotes, "AG", "folate metabolism; reduced enzyme activity"},
+	}
+	for _, tt := range tests {
+		records, report, err := ResolveDuplicates(duplicateRecords(), tt.resolution)
+		if err != nil {
+			t.Fatalf("%s: ResolveDuplicates failed: %v", tt.resolution, err)
+		}
+		if len(records) != 4 {
+			t.Fatalf("%s: expected 4 records (rsid in another group and invalid row kept), got %d", tt.resolution, len(records))
+		}
+		if records[0][5] != tt.genotype || records[0][6] != tt.notes {
+			t.Errorf("%s: got genotype %q notes %q, want %q %q", tt.resolution, records[0][5], records[0][6], tt.genotype, tt.notes)
+		}
+		if report.Duplicates != 1 {
+			t.Errorf("%s: expected 1 exact duplicate, got %d", tt.resolution, report.Duplicates)
+		}
+		want := []Conflict{{
+			Topic: "Nutrients", Group: "MTHFR", RSID: "rs1801133",
+			Lines:      []int{2, 5},
+			Fields:     []string{"Subject Genotype", "Notes"},
+			Resolution: tt.resolution,
+		}}
+		if !reflect.DeepEqual(report.Conflicts, want) {
+			t.Errorf("%s: Conflicts = %+v, want %+v", tt.resolution, report.Conflicts, want)
+		}
+	}
+}
+
+func TestResolveDuplicates_Error(t *testing.T) {
+	_, report, err := ResolveDuplicates(duplicateRecords(), ResolveError)
+	if err == nil || !strings.Contains(err.Error(), "rs1801133") {
+		t.Errorf("Expected conflict error naming rs1801133, got %v", err)
+	}
+	if report == nil || len(report.Conflicts) != 1 {
+		t.Errorf("Expected report with 1 conflict, got %+v", report)
+	}
+
+	if _, _, err := ResolveDuplicates(duplicateRecords(), "newest"); err == nil {
+		t.Error("Expected error for unknown resolution")
+	}
+}
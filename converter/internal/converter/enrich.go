@@ -0,0 +1,85 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/JerkyTreats/PHITE/converter/internal/genotype"
+	"github.com/JerkyTreats/PHITE/converter/pkg/logger"
+)
+
+// GenotypeDiscrepancy is a record whose sheet genotype disagrees with the raw data.
+type GenotypeDiscrepancy struct {
+	// Line is the 1-based line in the input TSV (the header is line 1)
+	Line int `json:"Line"`
+	// RSID is the record's rsid
+	RSID string `json:"RSID"`
+	// Sheet is the Subject Genotype value from the input sheet
+	Sheet string `json:"Sheet"`
+	// Raw is the genotype from the genotype file, which replaced Sheet
+	Raw string `json:"Raw"`
+}
+
+// EnrichmentReport summarizes how the genotype file changed the Subject Genotype column.
+type EnrichmentReport struct {
+	// Filled counts records whose blank or "--" genotype was filled from the raw data
+	Filled int `json:"Filled"`
+	// Overridden lists records where the sheet and raw data disagreed
+	Overridden []GenotypeDiscrepancy `json:"Overridden"`
+	// Missing lists rsids not called in the genotype file; their sheet genotype is kept
+	Missing []string `json:"Missing"`
+}
+
+// Save writes the report to the specified file in JSON format.
+func (r *EnrichmentReport) Save(outputFile string) error {
+	return writeReport(r, outputFile, "genotype report")
+}
+
+// EnrichGenotypes fills or overrides the Subject Genotype of each record (header excluded)
+// from calls (rsid -> genotype). Genotypes that differ only in allele order are left as is.
+func EnrichGenotypes(records [][]string, calls map[string]string) *EnrichmentReport {
+	report := &EnrichmentReport{}
+	missing := make(map[string]bool)
+	for i, record := range records {
+		if len(record) != 7 {
+			continue
+		}
+		line := i + 2
+		rsid, sheet := record[3], record[5]
+
+		raw, ok := calls[rsid]
+		if !ok {
+			if !missing[rsid] {
+				missing[rsid] = true
+				report.Missing = append(report.Missing, rsid)
+			}
+			continue
+		}
+
+		switch {
+		case sheet == "" || sheet == "--":
+			record[5] = raw
+			report.Filled++
+		case !genotype.Equal(sheet, raw):
+			record[5] = raw
+			report.Overridden = append(report.Overridden, GenotypeDiscrepancy{Line: line, RSID: rsid, Sheet: sheet, Raw: raw})
+			logger.Info("sheet genotype disagrees with genotype file", "line", line, "rsid", rsid, "sheet", sheet, "raw", raw)
+		}
+	}
+	logger.Info("enriched genotypes from genotype file", "filled", report.Filled, "overridden", len(report.Overridden), "missing", len(report.Missing))
+	return report
+}
+
+// writeReport writes a report to outputFile as indented JSON.
+func writeReport(report any, outputFile, name string) error {
+	jsonBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s JSON: %w", name, err)
+	}
+	if err := os.WriteFile(outputFile, jsonBytes, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	logger.Info("saved "+name+" to file", "file", outputFile)
+	return nil
+}
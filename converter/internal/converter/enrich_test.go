@@ -0,0 +1,70 @@
+package converter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JerkyTreats/PHITE/converter/internal/models"
+)
+
+func TestEnrichGenotypes(t *testing.T) {
+	records := [][]string{
+		{"Nutrients", "MTHFR", "MTHFR C677T", "rs1801133", "A", "--", ""},
+		{"Nutrients", "MTHFR", "MTHFR A1298C", "rs1801131", "G", "TT", ""},
+		{"Nutrients", "MTHFR", "MTHFR A1298C", "rs1801131", "G", "GT", ""},
+		{"Nutrients", "VDR", "VDR", "rs1544410", "A", "AA", ""},
+	}
+	calls := map[string]string{"rs1801133": "AG", "rs1801131": "TG"}
+
+	report := EnrichGenotypes(records, calls)
+
+	if report.Filled != 1 || records[0][5] != "AG" {
+		t.Errorf("Expected blank genotype filled with AG, got Filled=%d genotype=%s", report.Filled, records[0][5])
+	}
+	want := []GenotypeDiscrepancy{{Line: 3, RSID: "rs1801131", Sheet: "TT", Raw: "TG"}}
+	if !reflect.DeepEqual(report.Overridden, want) {
+		t.Errorf("Overridden = %+v, want %+v", report.Overridden, want)
+	}
+	if records[1][5] != "TG" || records[2][5] != "GT" {
+		t.Errorf("Expected disagreeing genotype overridden and reordered one kept, got %s and %s", records[1][5], records[2][5])
+	}
+	if !reflect.DeepEqual(report.Missing, []string{"rs1544410"}) || records[3][5] != "AA" {
+		t.Errorf("Expected rs1544410 missing with sheet genotype kept, got %v / %s", report.Missing, records[3][5])
+	}
+}
+
+func TestTSVParser_SetGenotypes(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.tsv")
+	testData := "Topic\tGroup\tGene\tRS ID\tAllele\tSubject Genotype\tNotes\n" +
+		"Nutrients\tMTHFR\tMTHFR C677T\trs1801133\tA\t--\tfolate metabolism\n"
+	if err := os.WriteFile(inputFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+
+	parser := NewTSVParser(inputFile, tempDir, "group")
+	parser.SetGenotypes(map[string]string{"rs1801133": "AA"})
+	outputFiles, _, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputFiles[0])
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	var result models.ConversionResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v", err)
+	}
+	subject := result.Grouping.SNP[0].Subject
+	if subject.Genotype != "AA" || subject.Match != "Full" {
+		t.Errorf("Expected enriched genotype AA with Full match, got %+v", subject)
+	}
+	if parser.EnrichmentReport() == nil || parser.EnrichmentReport().Filled != 1 {
+		t.Errorf("Expected enrichment report with 1 filled record, got %+v", parser.EnrichmentReport())
+	}
+}
@@ -0,0 +1,157 @@
+package converter
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// NameTemplate builds output file paths from placeholders such as "{topic}/{group}.json".
+//
+// Placeholders are {topic}, {group}, {gene}, and {ext} (the output format's extension).
+// A placeholder may apply transforms separated by "|": slug, lower, or upper, e.g.
+// "{topic|slug}/{group|lower}.{ext}". Slashes in the template create nested directories;
+// slashes inside values are replaced with "-" so values never add directory levels.
+type NameTemplate struct {
+	raw   string
+	parts []templatePart
+}
+
+type templatePart struct {
+	literal    string
+	field      string // empty for literal parts
+	transforms []string
+}
+
+var placeholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// ParseNameTemplate parses and validates a naming template.
+func ParseNameTemplate(tmpl string) (*NameTemplate, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		return nil, fmt.Errorf("naming template is empty")
+	}
+	if filepath.IsAbs(tmpl) {
+		return nil, fmt.Errorf("naming template must be relative to the output directory: %s", tmpl)
+	}
+
+	t := &NameTemplate{raw: tmpl}
+	last := 0
+	for _, m := range placeholderPattern.FindAllStringSubmatchIndex(tmpl, -1) {
+		if m[0] > last {
+			t.parts = append(t.parts, templatePart{literal: tmpl[last:m[0]]})
+		}
+		spec := strings.Split(tmpl[m[2]:m[3]], "|")
+		part := templatePart{field: strings.TrimSpace(spec[0])}
+		switch part.field {
+		case "topic", "group", "gene", "ext":
+		default:
+			return nil, fmt.Errorf("unknown placeholder {%s} in naming template", part.field)
+		}
+		for _, tr := range spec[1:] {
+			tr = strings.TrimSpace(tr)
+			if _, ok := transforms[tr]; !ok {
+				return nil, fmt.Errorf("unknown transform %q in naming template", tr)
+			}
+			part.transforms = append(part.transforms, tr)
+		}
+		t.parts = append(t.parts, part)
+		last = m[1]
+	}
+	if last < len(tmpl) {
+		t.parts = append(t.parts, templatePart{literal: tmpl[last:]})
+	}
+	if strings.ContainsAny(strings.Join(t.literals(), ""), "{}") {
+		return nil, fmt.Errorf("unbalanced braces in naming template: %s", tmpl)
+	}
+	for _, segment := range strings.Split(filepath.ToSlash(tmpl), "/") {
+		if segment == ".." {
+			return nil, fmt.Errorf("naming template must not leave the output directory: %s", tmpl)
+		}
+	}
+	return t, nil
+}
+
+// String returns the template text.
+func (t *NameTemplate) String() string {
+	return t.raw
+}
+
+// Check returns an error if the template uses a placeholder not in available.
+func (t *NameTemplate) Check(available ...string) error {
+	for _, part := range t.parts {
+		if part.field == "" || part.field == "ext" {
+			continue
+		}
+		found := false
+		for _, a := range available {
+			if part.field == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("placeholder {%s} is not available here; use %s", part.field, placeholders(available))
+		}
+	}
+	return nil
+}
+
+// Execute fills the template from values (placeholder -> value) and returns a relative
+// file path.
+func (t *NameTemplate) Execute(values map[string]string) (string, error) {
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.field == "" {
+			b.WriteString(part.literal)
+			continue
+		}
+		v := strings.ReplaceAll(values[part.field], "/", "-")
+		for _, tr := range part.transforms {
+			v = transforms[tr](v)
+		}
+		if v == "" {
+			return "", fmt.Errorf("naming template %s: {%s} is empty", t.raw, part.field)
+		}
+		b.WriteString(v)
+	}
+	name := filepath.Clean(filepath.FromSlash(b.String()))
+	if name == "." || strings.HasPrefix(name, "..") {
+		return "", fmt.Errorf("naming template %s produced an invalid path: %s", t.raw, name)
+	}
+	return name, nil
+}
+
+func (t *NameTemplate) literals() []string {
+	var out []string
+	for _, part := range t.parts {
+		if part.field == "" {
+			out = append(out, part.literal)
+		}
+	}
+	return out
+}
+
+func placeholders(fields []string) string {
+	out := make([]string, 0, len(fields)+1)
+	for _, f := range append(fields, "ext") {
+		out = append(out, "{"+f+"}")
+	}
+	return strings.Join(out, ", ")
+}
+
+var transforms = map[string]func(string) string{
+	"slug":  Slug,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// Slug lower-cases s and joins its runs of letters and digits with "-",
+// e.g. "Nutrients – Vitamins and Minerals" -> "nutrients-vitamins-and-minerals".
+func Slug(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
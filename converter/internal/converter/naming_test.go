@@ -0,0 +1,94 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestNameTemplate_Execute(t *testing.T) {
+	values := map[string]string{"topic": "Nutrients – Vitamins and Minerals", "group": "B12/Folate", "ext": "json"}
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{"{group}.{ext}", "B12-Folate.json"},
+		{"{topic|slug}/{group|slug}.{ext}", filepath.Join("nutrients-vitamins-and-minerals", "b12-folate.json")},
+		{"reports/{topic|slug|upper}/{group|lower}.md", filepath.Join("reports", "NUTRIENTS-VITAMINS-AND-MINERALS", "b12-folate.md")},
+	}
+	for _, tt := range tests {
+		tmpl, err := ParseNameTemplate(tt.tmpl)
+		if err != nil {
+			t.Fatalf("ParseNameTemplate(%q) failed: %v", tt.tmpl, err)
+		}
+		got, err := tmpl.Execute(values)
+		if err != nil {
+			t.Fatalf("Execute(%q) failed: %v", tt.tmpl, err)
+		}
+		if got != tt.want {
+			t.Errorf("Execute(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestParseNameTemplate_Errors(t *testing.T) {
+	for _, tmpl := range []string{
+		"",
+		"/abs/{group}.json",
+		"../{group}.json",
+		"{rsid}.json",
+		"{group|title}.json",
+		"{group.json",
+	} {
+		if _, err := ParseNameTemplate(tmpl); err == nil {
+			t.Errorf("ParseNameTemplate(%q): expected error", tmpl)
+		}
+	}
+}
+
+func TestNameTemplate_Check(t *testing.T) {
+	tmpl, err := ParseNameTemplate("{topic}/{group}.{ext}")
+	if err != nil {
+		t.Fatalf("ParseNameTemplate failed: %v", err)
+	}
+	if err := tmpl.Check("topic", "group"); err != nil {
+		t.Errorf("Check(topic, group) failed: %v", err)
+	}
+	if err := tmpl.Check("topic"); err == nil {
+		t.Error("Check(topic): expected error for {group}")
+	}
+}
+
+func TestParseWithNameTemplate(t *testing.T) {
+	_, curFilename, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("Could not get caller information")
+	}
+	testFile := filepath.Join(filepath.Dir(curFilename), "testdata", "sample.tsv")
+	tempDir := t.TempDir()
+
+	parser := NewTSVParser(testFile, tempDir, "group")
+	if err := parser.SetNameTemplate("{topic|slug}/{group|lower}.{ext}"); err != nil {
+		t.Fatalf("SetNameTemplate failed: %v", err)
+	}
+	files, _, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := filepath.Join(tempDir, "nutrients-vitamins-and-minerals", "mthfr.json")
+	if len(files) != 1 || files[0] != want {
+		t.Fatalf("Expected %s, got %v", want, files)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("Expected nested output file: %v", err)
+	}
+
+	parser = NewTSVParser(testFile, tempDir, "topic")
+	if err := parser.SetNameTemplate("{topic}/{group}.{ext}"); err != nil {
+		t.Fatalf("SetNameTemplate failed: %v", err)
+	}
+	if _, _, err := parser.Parse(); err == nil {
+		t.Error("Expected error for {group} in topic mode")
+	}
+}
@@ -6,15 +6,18 @@ package converter
 
 import (
 	"encoding/csv"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/JerkyTreats/PHITE/converter/internal/config"
 	"github.com/JerkyTreats/PHITE/converter/internal/models"
+	"github.com/JerkyTreats/PHITE/converter/internal/render"
+	"github.com/JerkyTreats/PHITE/converter/internal/schema"
 	"github.com/JerkyTreats/PHITE/converter/pkg/logger"
+	"github.com/JerkyTreats/PHITE/taxonomy"
 )
 
 // ParseResult contains both valid records and any error records encountered during parsing
@@ -29,7 +32,21 @@ type TSVParser struct {
 	inputFile    string
 	outputDir    string
 	config       config.Config
-	groupingMode string // "group" or "topic"
+	groupingMode string        // "group", "topic", or "gene"
+	format       string        // "json", "markdown", or "html"
+	schema       string        // schema version of JSON output; see the schema package
+	locale       render.Locale // wording of markdown and html documents
+	nameTemplate *NameTemplate // output file naming; defaults per grouping mode when nil
+
+	taxonomy       *taxonomy.Taxonomy // canonical Topic/Group list; validation is skipped when nil
+	autoCorrect    bool               // replace misspelled Topic/Group values with their closest match
+	taxonomyReport *TaxonomyReport    // issues found by the last Parse or Validate
+
+	genotypes        map[string]string // rsid -> genotype from the subject's raw data; enrichment is skipped when nil
+	enrichmentReport *EnrichmentReport // genotype changes made by the last Parse
+
+	resolution     string          // how rows repeating an rsid within a group are combined
+	conflictReport *ConflictReport // duplicates and conflicts found by the last Parse
 }
 
 // SaveResult saves a ConversionResult to the specified output file in JSON format.
@@ -47,8 +64,8 @@ type TSVParser struct {
 //   - os.ErrPermission: If insufficient permissions to write to file
 //   - json.MarshalIndent: If JSON encoding fails
 //   - os.WriteFile: If file write operation fails
-func saveTopicOutput(topicOutput *models.TopicOutput, outputFile string) error {
-	jsonBytes, err := json.MarshalIndent(topicOutput, "", "  ")
+func saveTopicOutput(topicOutput *models.TopicOutput, outputFile, version string) error {
+	jsonBytes, err := schema.Encode(topicOutput, version)
 	if err != nil {
 		logger.Error(err, "failed to marshal TopicOutput JSON")
 		return fmt.Errorf("failed to marshal TopicOutput JSON: %w", err)
@@ -64,8 +81,32 @@ func saveTopicOutput(topicOutput *models.TopicOutput, outputFile string) error {
 	return nil
 }
 
+// saveGeneOutput saves a GeneOutput to the specified output file in JSON format, in
+// schema version.
+func saveGeneOutput(geneOutput *models.GeneOutput, outputFile, version string) error {
+	jsonBytes, err := schema.Encode(geneOutput, version)
+	if err != nil {
+		logger.Error(err, "failed to marshal GeneOutput JSON")
+		return fmt.Errorf("failed to marshal GeneOutput JSON: %w", err)
+	}
+
+	err = os.WriteFile(outputFile, jsonBytes, 0644)
+	if err != nil {
+		logger.Error(err, "failed to write GeneOutput file")
+		return fmt.Errorf("failed to write GeneOutput file: %w", err)
+	}
+
+	logger.Info("saved GeneOutput to file", "file", outputFile)
+	return nil
+}
+
 func SaveResult(result *models.ConversionResult, outputFile string) error {
-	jsonBytes, err := json.MarshalIndent(result, "", "  ")
+	return saveResult(result, outputFile, schema.V1)
+}
+
+// saveResult saves a ConversionResult like SaveResult, in schema version.
+func saveResult(result *models.ConversionResult, outputFile, version string) error {
+	jsonBytes, err := schema.Encode(result, version)
 	if err != nil {
 		logger.Error(err, "failed to marshal JSON")
 		return fmt.Errorf("failed to marshal JSON: %w", err)
@@ -94,7 +135,165 @@ func NewTSVParser(inputFile string, outputDir string, groupingMode string) *TSVP
 		outputDir:    outputDir,
 		config:       cfg,
 		groupingMode: groupingMode,
+		format:       render.FormatJSON,
+		schema:       schema.Default,
+		locale:       render.English,
+		resolution:   ResolveFirstWins,
+	}
+}
+
+// SetFormat sets the output format: "json" (default), or "markdown" or "html" for
+// human-readable documents. Markdown and HTML are supported in the group and topic
+// grouping modes.
+func (p *TSVParser) SetFormat(format string) {
+	p.format = format
+}
+
+// SetSchema sets the schema version of JSON output, schema.Default unless set. Markdown
+// and HTML documents are not affected.
+func (p *TSVParser) SetSchema(version string) {
+	p.schema = version
+}
+
+// SetLocale sets the language of the headings and match wording of Markdown and HTML
+// documents; English by default. JSON output is not affected.
+func (p *TSVParser) SetLocale(loc render.Locale) {
+	p.locale = loc
+}
+
+// defaultNameTemplates reproduce the flat "<name>.<ext>" layout of each grouping mode.
+var defaultNameTemplates = map[string]string{
+	"group": "{group}.{ext}",
+	"topic": "{topic}.{ext}",
+	"gene":  "{gene}.{ext}",
+}
+
+// templateFields lists the naming placeholders each grouping mode can fill.
+var templateFields = map[string][]string{
+	"group": {"topic", "group"},
+	"topic": {"topic"},
+	"gene":  {"gene"},
+}
+
+// SetNameTemplate sets the output file naming template, e.g. "{topic|slug}/{group}.{ext}".
+// See NameTemplate for the supported placeholders and transforms.
+func (p *TSVParser) SetNameTemplate(tmpl string) error {
+	t, err := ParseNameTemplate(tmpl)
+	if err != nil {
+		return err
+	}
+	p.nameTemplate = t
+	return nil
+}
+
+// outputPath fills the naming template and returns the file name relative to the output
+// directory and the full path, creating any nested directories.
+func (p *TSVParser) outputPath(tmpl *NameTemplate, values map[string]string) (string, string, error) {
+	filename, err := tmpl.Execute(values)
+	if err != nil {
+		return "", "", err
+	}
+	outPath := filepath.Join(p.outputDir, filename)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return filename, outPath, nil
+}
+
+// saveDocument renders a topic as a Markdown or HTML document in loc's language.
+func saveDocument(topicOutput *models.TopicOutput, outputFile, format string, loc render.Locale) error {
+	f, err := os.Create(outputFile)
+	if err != nil {
+		logger.Error(err, "failed to create document file")
+		return fmt.Errorf("failed to create document file: %w", err)
 	}
+	defer f.Close()
+
+	if err := render.TopicIn(f, topicOutput, format, loc); err != nil {
+		logger.Error(err, "failed to render document")
+		return fmt.Errorf("failed to render document: %w", err)
+	}
+
+	logger.Info("saved document to file", "file", outputFile, "format", format)
+	return nil
+}
+
+// SetTaxonomy enables validation of Topic and Group values against a canonical taxonomy.
+// With autoCorrect, values that fuzzy-match a canonical entry are corrected before
+// conversion; every correction is listed in the TaxonomyReport.
+func (p *TSVParser) SetTaxonomy(tax *taxonomy.Taxonomy, autoCorrect bool) {
+	p.taxonomy = tax
+	p.autoCorrect = autoCorrect
+}
+
+// TaxonomyReport returns the taxonomy issues found by the last Parse or Validate, or nil
+// if no taxonomy is set.
+func (p *TSVParser) TaxonomyReport() *TaxonomyReport {
+	return p.taxonomyReport
+}
+
+// SetGenotypes enables genotype enrichment: the Subject Genotype column is filled or
+// overridden from calls (rsid -> genotype), and disagreements are listed in the
+// EnrichmentReport.
+func (p *TSVParser) SetGenotypes(calls map[string]string) {
+	p.genotypes = calls
+}
+
+// EnrichmentReport returns the genotype changes made by the last Parse, or nil if no
+// genotypes are set.
+func (p *TSVParser) EnrichmentReport() *EnrichmentReport {
+	return p.enrichmentReport
+}
+
+// SetConflictResolution sets how rows that repeat an rsid within the same Topic and Group
+// are combined: "first-wins" (default), "last-wins", "merge-notes", or "error".
+func (p *TSVParser) SetConflictResolution(resolution string) {
+	p.resolution = resolution
+}
+
+// ConflictReport returns the duplicate and conflicting rows found by the last Parse.
+func (p *TSVParser) ConflictReport() *ConflictReport {
+	return p.conflictReport
+}
+
+// Validate checks the input's Topic and Group values against the taxonomy without
+// writing any output.
+func (p *TSVParser) Validate() (*TaxonomyReport, error) {
+	if p.taxonomy == nil {
+		return nil, fmt.Errorf("no taxonomy set")
+	}
+	records, err := p.readRecords()
+	if err != nil {
+		return nil, err
+	}
+	p.taxonomyReport = ValidateTaxonomy(records, p.taxonomy, p.autoCorrect)
+	return p.taxonomyReport, nil
+}
+
+// readRecords reads the input TSV and returns its records without the header row.
+func (p *TSVParser) readRecords() ([][]string, error) {
+	file, err := os.Open(p.inputFile)
+	if err != nil {
+		logger.Error(err, "failed to open file")
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comma = '\t' // TSV format
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		logger.Error(err, "failed to read TSV")
+		return nil, fmt.Errorf("failed to read TSV: %w", err)
+	}
+
+	// Skip header
+	if len(records) == 0 {
+		logger.Error(nil, "empty file")
+		return nil, fmt.Errorf("empty file")
+	}
+	return records[1:], nil
 }
 
 // Parse reads and parses the TSV file into a structured JSON format.
@@ -104,8 +303,10 @@ func NewTSVParser(inputFile string, outputDir string, groupingMode string) *TSVP
 // The parser performs the following operations:
 // 1. Validates the input file exists and can be read
 // 2. Reads all records from the TSV file
-// 3. Skips the header row
-// 4. Groups SNPs by their Group field
+// 3. Skips the header row and, if a taxonomy is set, validates Topic/Group values;
+//    if genotypes are set, fills or overrides Subject Genotype from them; then collapses
+//    rows that repeat an rsid within a Topic/Group per the conflict resolution
+// 4. Groups SNPs by their Group field (or Topic, or gene symbol, per grouping mode)
 // 5. For each SNP:
 //   - Validates record format (must have 7 columns)
 //   - Skips SNPs with blank or "--" genotypes
@@ -124,37 +325,57 @@ func NewTSVParser(inputFile string, outputDir string, groupingMode string) *TSVP
 // - fmt.Errorf: For invalid record formats or other parsing errors
 func (p *TSVParser) Parse() ([]string, []string, error) {
 	// Validate groupingMode
-	if p.groupingMode != "group" && p.groupingMode != "topic" {
-		errMsg := fmt.Sprintf("invalid grouping mode: %s. Must be 'group' or 'topic'", p.groupingMode)
+	if p.groupingMode != "group" && p.groupingMode != "topic" && p.groupingMode != "gene" {
+		errMsg := fmt.Sprintf("invalid grouping mode: %s. Must be 'group', 'topic', or 'gene'", p.groupingMode)
 		logger.Error(nil, errMsg)
-		return nil, nil, fmt.Errorf("invalid grouping mode: %s. Must be 'group' or 'topic'", p.groupingMode)
+		return nil, nil, fmt.Errorf("invalid grouping mode: %s. Must be 'group', 'topic', or 'gene'", p.groupingMode)
+	}
+	if !render.ValidFormat(p.format) {
+		logger.Error(nil, "invalid output format", "format", p.format)
+		return nil, nil, fmt.Errorf("invalid output format: %s. Must be 'json', 'markdown', or 'html'", p.format)
+	}
+	if !schema.Valid(p.schema) {
+		logger.Error(nil, "invalid schema version", "schema", p.schema)
+		return nil, nil, fmt.Errorf("invalid schema version: %s. Must be one of %s", p.schema, strings.Join(schema.Versions(), ", "))
+	}
+	if !ValidResolution(p.resolution) {
+		logger.Error(nil, "invalid conflict resolution", "resolution", p.resolution)
+		return nil, nil, fmt.Errorf("invalid conflict resolution: %s. Must be 'first-wins', 'last-wins', 'merge-notes', or 'error'", p.resolution)
+	}
+	if p.format != render.FormatJSON && p.groupingMode == "gene" {
+		logger.Error(nil, "document output is not supported in gene mode", "format", p.format)
+		return nil, nil, fmt.Errorf("%s output is not supported with grouping mode 'gene'", p.format)
 	}
+	ext := render.Extension(p.format)
 
-	file, err := os.Open(p.inputFile)
+	tmpl := p.nameTemplate
+	if tmpl == nil {
+		tmpl, _ = ParseNameTemplate(defaultNameTemplates[p.groupingMode])
+	}
+	if err := tmpl.Check(templateFields[p.groupingMode]...); err != nil {
+		logger.Error(err, "invalid naming template for grouping mode", "template", tmpl.String(), "mode", p.groupingMode)
+		return nil, nil, fmt.Errorf("naming template %s in %s mode: %w", tmpl, p.groupingMode, err)
+	}
+
+	records, err := p.readRecords()
 	if err != nil {
-		logger.Error(err, "failed to open file")
-		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, nil, err
 	}
-	defer file.Close()
 
 	// Create map to store filenames for each grouping
 	groupFilenames := make(map[string]string)
 
-	reader := csv.NewReader(file)
-	reader.Comma = '\t' // TSV format
-
-	records, err := reader.ReadAll()
-	if err != nil {
-		logger.Error(err, "failed to read TSV")
-		return nil, nil, fmt.Errorf("failed to read TSV: %w", err)
+	if p.taxonomy != nil {
+		p.taxonomyReport = ValidateTaxonomy(records, p.taxonomy, p.autoCorrect)
 	}
-
-	// Skip header
-	if len(records) == 0 {
-		logger.Error(nil, "empty file")
-		return nil, nil, fmt.Errorf("empty file")
+	if p.genotypes != nil {
+		p.enrichmentReport = EnrichGenotypes(records, p.genotypes)
+	}
+	records, p.conflictReport, err = ResolveDuplicates(records, p.resolution)
+	if err != nil {
+		logger.Error(err, "conflicting rows in input")
+		return nil, nil, fmt.Errorf("conflicting rows in input: %w", err)
 	}
-	records = records[1:]
 
 	var errorRecords []string
 	var outputFiles []string
@@ -208,9 +429,6 @@ func (p *TSVParser) Parse() ([]string, []string, error) {
 
 		// Save each topic's data to its own JSON file
 		for topicName, topicOutputData := range topicsData {
-			filename := fmt.Sprintf("%s.json", strings.ReplaceAll(topicName, "/", "-"))
-			outPath := filepath.Join(p.outputDir, filename)
-
 			// Apply AddIfMatch filtering to each group's SNPs within the topic
 			filteredGroupings := make(map[string][]models.SNP)
 			for groupName, snpList := range topicOutputData.Groupings {
@@ -229,7 +447,16 @@ func (p *TSVParser) Parse() ([]string, []string, error) {
 			    continue
 			}
 
-			if err := saveTopicOutput(topicOutputData, outPath); err != nil {
+			filename, outPath, err := p.outputPath(tmpl, map[string]string{"topic": topicName, "ext": ext})
+			if err != nil {
+				return nil, nil, err
+			}
+
+			save := func(t *models.TopicOutput, path string) error { return saveTopicOutput(t, path, p.schema) }
+			if p.format != render.FormatJSON {
+				save = func(t *models.TopicOutput, path string) error { return saveDocument(t, path, p.format, p.locale) }
+			}
+			if err := save(topicOutputData, outPath); err != nil {
 				logger.Error(err, "failed to save topic output", "topic", topicName)
 				return nil, nil, fmt.Errorf("failed to save topic output %s: %w", topicName, err)
 			}
@@ -237,6 +464,70 @@ func (p *TSVParser) Parse() ([]string, []string, error) {
 			groupFilenames[topicName] = filename // Using topicName as key for consistency in logging
 		}
 
+	} else if p.groupingMode == "gene" {
+		// Data structure for gene mode: map[geneSymbol] -> models.GeneOutput, kept in input order
+		genesData := make(map[string]*models.GeneOutput)
+		var geneOrder []string
+
+		for _, record := range records {
+			logger.Debug("Processing record for gene mode", "record", record)
+			if len(record) != 7 {
+				errorRecords = append(errorRecords, fmt.Sprintf("Record with %d columns: %v", len(record), record))
+				logger.Info("Invalid record format", "record", record)
+				continue
+			}
+
+			snp, err := models.NewSNP(record[2], record[3], record[4], record[6], record[5])
+			if err != nil {
+				errorRecords = append(errorRecords, fmt.Sprintf("Record validation failed: %v", record))
+				logger.Info("Skipping SNP due to validation error", "error", err)
+				continue
+			}
+
+			geneName := models.GeneSymbol(snp.Gene)
+			geneData, exists := genesData[geneName]
+			if !exists {
+				geneData = &models.GeneOutput{Gene: geneName}
+				genesData[geneName] = geneData
+				geneOrder = append(geneOrder, geneName)
+				logger.Debug("New gene entry created", "geneName", geneName)
+			}
+			if !slices.Contains(geneData.Topics, record[0]) {
+				geneData.Topics = append(geneData.Topics, record[0])
+			}
+			if !slices.Contains(geneData.Groups, record[1]) {
+				geneData.Groups = append(geneData.Groups, record[1])
+			}
+			geneData.SNP = append(geneData.SNP, *snp)
+			logger.Debug("SNP added to gene", "geneName", geneName, "snpRSID", snp.RSID)
+		}
+
+		// Save each gene's data to its own JSON file
+		for _, geneName := range geneOrder {
+			geneData := genesData[geneName]
+			var filteredSNPs []models.SNP
+			for _, snp := range geneData.SNP {
+				filteredSNPs = models.AddIfMatch(filteredSNPs, snp, p.config.GetMatchLevel())
+			}
+			if len(filteredSNPs) == 0 && p.config.GetMatchLevel() != config.MatchLevelNone { // Don't save empty files unless match level is None
+				logger.Info("Skipping empty gene output after filtering", "geneName", geneName, "matchLevel", p.config.GetMatchLevel())
+				continue
+			}
+			geneData.SNP = filteredSNPs
+
+			filename, outPath, err := p.outputPath(tmpl, map[string]string{"gene": geneName, "ext": ext})
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if err := saveGeneOutput(geneData, outPath, p.schema); err != nil {
+				logger.Error(err, "failed to save gene output", "gene", geneName)
+				return nil, nil, fmt.Errorf("failed to save gene output %s: %w", geneName, err)
+			}
+			outputFiles = append(outputFiles, outPath)
+			groupFilenames[geneName] = filename
+		}
+
 	} else { // Original logic for groupingMode == "group"
 		groupings := make(map[string]*models.Grouping)
 		for _, record := range records {
@@ -271,9 +562,6 @@ func (p *TSVParser) Parse() ([]string, []string, error) {
 		}
 
 		for groupName, groupingData := range groupings {
-			filename := fmt.Sprintf("%s.json", strings.ReplaceAll(groupName, "/", "-"))
-			outPath := filepath.Join(p.outputDir, filename)
-
 			var filteredSNPs []models.SNP
 			for _, snp := range groupingData.SNP {
 				filteredSNPs = models.AddIfMatch(filteredSNPs, snp, p.config.GetMatchLevel())
@@ -284,11 +572,24 @@ func (p *TSVParser) Parse() ([]string, []string, error) {
 			    continue
 			}
 
-			if err := SaveResult(&models.ConversionResult{Grouping: models.Grouping{
-				Topic: groupingData.Topic,
-				Name:  groupName,
-				SNP:   filteredSNPs,
-			}}, outPath); err != nil {
+			filename, outPath, err := p.outputPath(tmpl, map[string]string{"topic": groupingData.Topic, "group": groupName, "ext": ext})
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if p.format != render.FormatJSON {
+				err = saveDocument(&models.TopicOutput{
+					Topic:     groupingData.Topic,
+					Groupings: map[string][]models.SNP{groupName: filteredSNPs},
+				}, outPath, p.format, p.locale)
+			} else {
+				err = saveResult(&models.ConversionResult{Grouping: models.Grouping{
+					Topic: groupingData.Topic,
+					Name:  groupName,
+					SNP:   filteredSNPs,
+				}}, outPath, p.schema)
+			}
+			if err != nil {
 				logger.Error(err, "failed to save grouping", "group", groupName)
 				return nil, nil, fmt.Errorf("failed to save grouping %s: %w", groupName, err)
 			}
@@ -299,7 +600,10 @@ func (p *TSVParser) Parse() ([]string, []string, error) {
 
 	// Log the filename mappings
 	for groupName, filename := range groupFilenames {
-		if groupName != filename[:len(filename)-5] { // Remove .json extension
+		if p.nameTemplate != nil {
+			break // custom layouts rename on purpose
+		}
+		if groupName != strings.TrimSuffix(filename, "."+ext) {
 			logger.Info("group name contains special characters", "group", groupName, "file", filename)
 		}
 	}
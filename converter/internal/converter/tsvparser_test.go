@@ -8,6 +8,9 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+
+	"github.com/JerkyTreats/PHITE/converter/internal/models"
+	"github.com/JerkyTreats/PHITE/converter/internal/schema"
 )
 
 func TestSaveResult(t *testing.T) {
@@ -415,3 +418,153 @@ func TestInvalidGroupingMode(t *testing.T) {
 		}
 	}
 }
+
+func TestParseGeneMode(t *testing.T) {
+	tempDir := t.TempDir()
+	tsvData := "Topic\tGroup\tGene\tRS ID\tAllele\tSubject Genotype\tNotes\n" +
+		"Nutrients\tMTHFR\tMTHFR C677T\trs1801133\tA\tAG\tfolate\n" +
+		"Methylation\tFolate Cycle\tMTHFR A1298C\trs1801131\tG\tTT\tfolate\n" +
+		"Nutrients\tVitamin D\tVDR\trs1544410\tA\tAA\tvitamin D\n"
+	filePath := filepath.Join(tempDir, "genes.tsv")
+	if err := os.WriteFile(filePath, []byte(tsvData), 0644); err != nil {
+		t.Fatalf("Failed to write test TSV: %v", err)
+	}
+
+	outDir := filepath.Join(tempDir, "out")
+	parser := NewTSVParser(filePath, outDir, "gene")
+	files, _, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse (gene mode) failed: %v", err)
+	}
+	if len(files) != 2 || filepath.Base(files[0]) != "MTHFR.json" || filepath.Base(files[1]) != "VDR.json" {
+		t.Fatalf("Expected MTHFR.json and VDR.json, got %v", files)
+	}
+
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("Failed to read gene output: %v", err)
+	}
+	var gene models.GeneOutput
+	if err := json.Unmarshal(data, &gene); err != nil {
+		t.Fatalf("Failed to unmarshal gene output: %v", err)
+	}
+	if gene.Gene != "MTHFR" || len(gene.SNP) != 2 {
+		t.Errorf("Expected MTHFR with 2 SNPs, got %s with %d", gene.Gene, len(gene.SNP))
+	}
+	if !reflect.DeepEqual(gene.Topics, []string{"Nutrients", "Methylation"}) ||
+		!reflect.DeepEqual(gene.Groups, []string{"MTHFR", "Folate Cycle"}) {
+		t.Errorf("Unexpected topics/groups: %v / %v", gene.Topics, gene.Groups)
+	}
+	if gene.SNP[1].RSID != "rs1801131" || gene.SNP[1].Allele != "G" {
+		t.Errorf("Unexpected second SNP: %+v", gene.SNP[1])
+	}
+}
+
+func TestParseDocumentFormats(t *testing.T) {
+	_, curFilename, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("Could not get caller information")
+	}
+	testFile := filepath.Join(filepath.Dir(curFilename), "testdata", "sample.tsv")
+
+	tests := []struct {
+		mode, format, wantFile string
+	}{
+		{"topic", "markdown", "Nutrients – Vitamins and Minerals.md"},
+		{"group", "html", "MTHFR.html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+tt.format, func(t *testing.T) {
+			parser := NewTSVParser(testFile, t.TempDir(), tt.mode)
+			parser.SetFormat(tt.format)
+			files, _, err := parser.Parse()
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if len(files) != 1 || filepath.Base(files[0]) != tt.wantFile {
+				t.Fatalf("Expected %s, got %v", tt.wantFile, files)
+			}
+			data, err := os.ReadFile(files[0])
+			if err != nil {
+				t.Fatalf("Failed to read document: %v", err)
+			}
+			if !strings.Contains(string(data), "rs1801133") {
+				t.Errorf("Document is missing SNP rows:\n%s", data)
+			}
+		})
+	}
+
+	parser := NewTSVParser(testFile, t.TempDir(), "gene")
+	parser.SetFormat("html")
+	if _, _, err := parser.Parse(); err == nil {
+		t.Error("Expected error for html output in gene mode")
+	}
+}
+
+func TestParseSchemaV2(t *testing.T) {
+	_, curFilename, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("Could not get caller information")
+	}
+	testFile := filepath.Join(filepath.Dir(curFilename), "testdata", "sample.tsv")
+
+	parser := NewTSVParser(testFile, t.TempDir(), "group")
+	parser.SetSchema(schema.V2)
+	files, _, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	var doc schema.DocumentV2
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Failed to unmarshal v2 output: %v", err)
+	}
+	if doc.SchemaVersion != schema.V2 || doc.Kind != schema.KindGroup || len(doc.Groups) != 1 {
+		t.Errorf("Unexpected v2 document: %+v", doc)
+	}
+
+	parser = NewTSVParser(testFile, t.TempDir(), "group")
+	parser.SetSchema("v0")
+	if _, _, err := parser.Parse(); err == nil {
+		t.Error("Expected error for an unknown schema version")
+	}
+}
+
+// FuzzParse feeds arbitrary TSV input through every grouping mode. Malformed input must be
+// rejected with an error or reported in the error records, never panic, and output stays
+// inside the output directory.
+func FuzzParse(f *testing.F) {
+	sample, err := os.ReadFile(filepath.Join("testdata", "sample.tsv"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(sample)
+	f.Add([]byte("Topic\tGroup\tGene\tRS ID\tAllele\tSubject Genotype\tNotes\n"))
+	f.Add([]byte("Topic\tGroup\nA\tB\tC\tD\tE\tF\tG\tH\n"))
+	f.Add([]byte("h\n..\t../..\tGENE\trs1\tA\tAA\t\"unterminated\n"))
+	f.Add([]byte("h\n\t\t\t\t\t\t\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dir := t.TempDir()
+		input := filepath.Join(dir, "input.tsv")
+		if err := os.WriteFile(input, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		outDir := filepath.Join(dir, "out")
+		for _, mode := range []string{"group", "topic", "gene"} {
+			files, _, err := NewTSVParser(input, outDir, mode).Parse()
+			if err != nil {
+				continue
+			}
+			for _, file := range files {
+				rel, err := filepath.Rel(outDir, file)
+				if err != nil || strings.HasPrefix(rel, "..") {
+					t.Fatalf("%s mode wrote %s outside %s", mode, file, outDir)
+				}
+			}
+		}
+	})
+}
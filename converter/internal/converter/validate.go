@@ -0,0 +1,79 @@
+package converter
+
+import (
+	"github.com/JerkyTreats/PHITE/converter/pkg/logger"
+	"github.com/JerkyTreats/PHITE/taxonomy"
+)
+
+// TaxonomyIssue is a Topic or Group value that is not in the canonical taxonomy.
+type TaxonomyIssue struct {
+	// Line is the 1-based line in the input TSV (the header is line 1)
+	Line int `json:"Line"`
+	// Field is "Topic" or "Group"
+	Field string `json:"Field"`
+	// Value is the value found in the input
+	Value string `json:"Value"`
+	// Suggestion is the closest canonical value, if one is near enough to be a misspelling
+	Suggestion string `json:"Suggestion,omitempty"`
+	// Corrected indicates the value was replaced by Suggestion before conversion
+	Corrected bool `json:"Corrected"`
+}
+
+// TaxonomyReport lists the taxonomy issues found in an input file.
+type TaxonomyReport struct {
+	Issues []TaxonomyIssue `json:"Issues"`
+}
+
+// Unresolved returns the number of issues that were not auto-corrected.
+func (r *TaxonomyReport) Unresolved() int {
+	n := 0
+	for _, issue := range r.Issues {
+		if !issue.Corrected {
+			n++
+		}
+	}
+	return n
+}
+
+// Save writes the report to the specified file in JSON format.
+func (r *TaxonomyReport) Save(outputFile string) error {
+	return writeReport(r, outputFile, "taxonomy report")
+}
+
+// ValidateTaxonomy checks the Topic and Group of each record (header excluded) against
+// the canonical taxonomy. A group is only checked once its topic is known or corrected.
+// With autoCorrect, values with a fuzzy-matched suggestion are rewritten in place.
+func ValidateTaxonomy(records [][]string, tax *taxonomy.Taxonomy, autoCorrect bool) *TaxonomyReport {
+	report := &TaxonomyReport{}
+	for i, record := range records {
+		if len(record) < 2 {
+			continue
+		}
+		line := i + 2
+		topic, group := record[0], record[1]
+
+		if !tax.Contains(taxonomy.Category{Topic: topic}) {
+			issue := TaxonomyIssue{Line: line, Field: "Topic", Value: topic}
+			issue.Suggestion, _ = tax.SuggestTopic(topic)
+			if autoCorrect && issue.Suggestion != "" {
+				record[0], topic, issue.Corrected = issue.Suggestion, issue.Suggestion, true
+			}
+			report.Issues = append(report.Issues, issue)
+			logger.Info("unknown topic", "line", line, "topic", issue.Value, "suggestion", issue.Suggestion, "corrected", issue.Corrected)
+			if !issue.Corrected {
+				continue
+			}
+		}
+
+		if !tax.Contains(taxonomy.Category{Topic: topic, Group: group}) {
+			issue := TaxonomyIssue{Line: line, Field: "Group", Value: group}
+			issue.Suggestion, _ = tax.SuggestGroup(topic, group)
+			if autoCorrect && issue.Suggestion != "" {
+				record[1], issue.Corrected = issue.Suggestion, true
+			}
+			report.Issues = append(report.Issues, issue)
+			logger.Info("unknown group", "line", line, "topic", topic, "group", issue.Value, "suggestion", issue.Suggestion, "corrected", issue.Corrected)
+		}
+	}
+	return report
+}
@@ -0,0 +1,82 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JerkyTreats/PHITE/taxonomy"
+)
+
+func testTaxonomy() *taxonomy.Taxonomy {
+	tax := taxonomy.New()
+	tax.Add(taxonomy.Category{Topic: "Nutrients – Vitamins and Minerals", Group: "MTHFR"})
+	tax.Add(taxonomy.Category{Topic: "Metabolic Health", Group: "Diabetes Risk"})
+	return tax
+}
+
+func TestValidateTaxonomy(t *testing.T) {
+	records := [][]string{
+		{"Metabolic Health", "Diabetes Risk", "TCF7L2", "rs7903146", "T", "CT", ""},
+		{"Metabolc Health", "Diabetes Risk", "FTO", "rs9939609", "A", "AA", ""},
+		{"Metabolic Health", "Diabetes Rsk", "FTO", "rs9939609", "A", "AA", ""},
+		{"Cognitive", "Memory", "APOE", "rs429358", "C", "TT", ""},
+	}
+
+	report := ValidateTaxonomy(records, testTaxonomy(), false)
+	want := []TaxonomyIssue{
+		{Line: 3, Field: "Topic", Value: "Metabolc Health", Suggestion: "Metabolic Health"},
+		{Line: 4, Field: "Group", Value: "Diabetes Rsk", Suggestion: "Diabetes Risk"},
+		{Line: 5, Field: "Topic", Value: "Cognitive"},
+	}
+	if !reflect.DeepEqual(report.Issues, want) {
+		t.Errorf("Issues = %+v, want %+v", report.Issues, want)
+	}
+	if report.Unresolved() != 3 {
+		t.Errorf("Unresolved() = %d, want 3", report.Unresolved())
+	}
+	if records[1][0] != "Metabolc Health" {
+		t.Errorf("record changed without auto-correct: %v", records[1])
+	}
+
+	report = ValidateTaxonomy(records, testTaxonomy(), true)
+	if report.Unresolved() != 1 {
+		t.Errorf("Unresolved() with auto-correct = %d, want 1", report.Unresolved())
+	}
+	if records[1][0] != "Metabolic Health" || records[2][1] != "Diabetes Risk" {
+		t.Errorf("records not corrected: %v, %v", records[1], records[2])
+	}
+}
+
+func TestTSVParser_AutoCorrect(t *testing.T) {
+	tempDir := t.TempDir()
+	inputFile := filepath.Join(tempDir, "input.tsv")
+	testData := "Topic\tGroup\tGene\tRS ID\tAllele\tSubject Genotype\tNotes\n" +
+		"Nutrients - Vitamins and Minerals\tmthfr\tMTHFR C677T\trs1801133\tA\tAG\tfolate metabolism\n"
+	if err := os.WriteFile(inputFile, []byte(testData), 0644); err != nil {
+		t.Fatalf("Failed to write test data: %v", err)
+	}
+
+	parser := NewTSVParser(inputFile, filepath.Join(tempDir, "out"), "topic")
+	parser.SetTaxonomy(testTaxonomy(), true)
+	outputFiles, _, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(outputFiles) != 1 || filepath.Base(outputFiles[0]) != "Nutrients – Vitamins and Minerals.json" {
+		t.Errorf("outputFiles = %v, want the corrected topic", outputFiles)
+	}
+
+	report := parser.TaxonomyReport()
+	if report == nil || len(report.Issues) != 2 || report.Unresolved() != 0 {
+		t.Fatalf("TaxonomyReport() = %+v, want 2 corrected issues", report)
+	}
+}
+
+func TestTSVParser_ValidateWithoutTaxonomy(t *testing.T) {
+	parser := NewTSVParser("unused.tsv", t.TempDir(), "group")
+	if _, err := parser.Validate(); err == nil {
+		t.Error("expected error when no taxonomy is set")
+	}
+}
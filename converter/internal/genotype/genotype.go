@@ -0,0 +1,85 @@
+// Package genotype reads raw consumer genotype files (23andMe or AncestryDNA exports)
+// so converter input sheets can be checked and filled from a subject's actual data.
+package genotype
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/JerkyTreats/PHITE/converter/pkg/logger"
+)
+
+// Load reads a genotype file and returns rsid -> genotype for every usable call.
+// 23andMe rows (rsid, chromosome, position, genotype) and AncestryDNA rows (rsid,
+// chromosome, position, allele1, allele2) are both accepted; header and comment lines are
+// skipped. No-calls and calls that are not two A/C/G/T alleles (indels, hemizygous
+// single-allele calls) are left out.
+func Load(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open genotype file: %w", err)
+	}
+	defer f.Close()
+
+	calls := make(map[string]string)
+	skipped := 0
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(strings.ToLower(line), "rsid") {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		var rsid, geno string
+		switch len(cols) {
+		case 4:
+			rsid, geno = cols[0], cols[3]
+		case 5:
+			rsid, geno = cols[0], cols[3]+cols[4]
+		default:
+			return nil, fmt.Errorf("genotype file line %d: expected 4 (23andMe) or 5 (AncestryDNA) columns, got %d", lineNum, len(cols))
+		}
+		geno = strings.ToUpper(strings.TrimSpace(geno))
+		if !Valid(geno) {
+			skipped++
+			continue
+		}
+		calls[strings.TrimSpace(rsid)] = geno
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read genotype file: %w", err)
+	}
+
+	logger.Info("loaded genotype file", "file", path, "calls", len(calls), "skipped", skipped)
+	return calls, nil
+}
+
+// Valid reports whether geno is a two-allele A/C/G/T call.
+func Valid(geno string) bool {
+	if len(geno) != 2 {
+		return false
+	}
+	for _, r := range geno {
+		if !strings.ContainsRune("ACGT", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal reports whether two genotypes carry the same alleles, ignoring order
+// ("AG" equals "GA").
+func Equal(a, b string) bool {
+	return normalize(a) == normalize(b)
+}
+
+func normalize(geno string) string {
+	b := []byte(strings.ToUpper(geno))
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	return string(b)
+}
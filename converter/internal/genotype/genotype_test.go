@@ -0,0 +1,98 @@
+package genotype
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "genome.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write genotype file: %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+	}{
+		{
+			name: "23andMe",
+			content: "# This data file generated by 23andMe\n" +
+				"# rsid\tchromosome\tposition\tgenotype\n" +
+				"rs1801133\t1\t11856378\tAG\n" +
+				"rs1801131\t1\t11854476\tgt\n" +
+				"rs4680\t22\t19951271\t--\n" +
+				"i3000001\tX\t1000\tA\n",
+			want: map[string]string{"rs1801133": "AG", "rs1801131": "GT"},
+		},
+		{
+			name: "AncestryDNA",
+			content: "#AncestryDNA raw data download\n" +
+				"rsid\tchromosome\tposition\tallele1\tallele2\n" +
+				"rs1801133\t1\t11856378\tG\tA\n" +
+				"rs4680\t22\t19951271\t0\t0\n",
+			want: map[string]string{"rs1801133": "GA"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(writeFile(t, tt.content))
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+	if _, err := Load(writeFile(t, "rs1\t1\n")); err == nil {
+		t.Error("Expected error for malformed row")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	if !Equal("AG", "GA") || !Equal("tt", "TT") {
+		t.Error("Equal should ignore allele order and case")
+	}
+	if Equal("AG", "AA") {
+		t.Error("Equal matched different genotypes")
+	}
+}
+
+// FuzzLoad checks that malformed genotype files are rejected with an error rather than a
+// panic, and that every call returned is a valid two-allele genotype.
+func FuzzLoad(f *testing.F) {
+	f.Add([]byte("# 23andMe\nrsid\tchromosome\tposition\tgenotype\nrs1\t1\t100\tAG\n"))
+	f.Add([]byte("rsid\tchromosome\tposition\tallele1\tallele2\nrs1\t1\t100\tA\tG\nrs2\t1\t200\t0\t0\n"))
+	f.Add([]byte("rs1\t1\t100\n"))
+	f.Add([]byte("rs1\t1\t100\tA\tG\tT\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		path := filepath.Join(t.TempDir(), "genotypes.txt")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		calls, err := Load(path)
+		if err != nil {
+			return
+		}
+		for rsid, geno := range calls {
+			if !Valid(geno) {
+				t.Fatalf("Load returned invalid genotype %q for %q", geno, rsid)
+			}
+		}
+	})
+}
@@ -225,6 +225,29 @@ type TopicOutput struct {
 	Groupings map[string][]SNP `json:"Groupings"`
 }
 
+// GeneOutput defines the structure for JSON output when grouping by gene.
+// It lists every SNP of the gene along with the topics and groups it appears under.
+type GeneOutput struct {
+	// Gene is the gene symbol (e.g., "MTHFR")
+	Gene string `json:"Gene"`
+	// Topics lists the topics the gene's SNPs appear under, in input order
+	Topics []string `json:"Topics"`
+	// Groups lists the groups the gene's SNPs appear under, in input order
+	Groups []string `json:"Groups"`
+	// SNP is the list of the gene's SNPs
+	SNP []SNP `json:"SNP"`
+}
+
+// GeneSymbol returns the gene symbol from a Gene column value, which may carry a
+// variant label after the symbol (e.g., "MTHFR C677T" -> "MTHFR").
+func GeneSymbol(gene string) string {
+	fields := strings.Fields(gene)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
 // AddIfMatch appends snp to the slice if it matches the config match level.
 func AddIfMatch(snps []SNP, snp SNP, matchLevel config.MatchLevel) []SNP {
 	match := DetermineMatch(snp.Subject.Genotype, snp.Allele)
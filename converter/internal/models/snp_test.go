@@ -403,3 +403,17 @@ func TestDetermineMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestGeneSymbol(t *testing.T) {
+	tests := map[string]string{
+		"MTHFR C677T": "MTHFR",
+		"VDR":         "VDR",
+		"  APOE e4 ":  "APOE",
+		"":            "",
+	}
+	for input, want := range tests {
+		if got := GeneSymbol(input); got != want {
+			t.Errorf("GeneSymbol(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Locale holds the wording of a rendered document in one language.
+type Locale struct {
+	Tag      string // BCP 47 language tag, used as the HTML lang attribute
+	Title    string // document title prefix, followed by the topic
+	GeneSNP  string // combined gene and rsid column of the HTML table
+	Gene     string
+	RSID     string
+	Allele   string
+	Genotype string
+	Match    string
+	Notes    string
+	Matches  map[string]string // Subject.Match values ("Full", "Partial", "None") to their wording
+}
+
+// MatchLabel returns the wording of a Subject.Match value, or the value itself when the
+// locale has none.
+func (l Locale) MatchLabel(match string) string {
+	if s, ok := l.Matches[match]; ok {
+		return s
+	}
+	return match
+}
+
+// English is the default locale.
+var English = Locale{
+	Tag: "en", Title: "Genetic Insights", GeneSNP: "Gene / SNP",
+	Gene: "Gene", RSID: "RS ID", Allele: "Allele", Genotype: "Genotype", Match: "Match", Notes: "Notes",
+}
+
+var locales = map[string]Locale{
+	"en": English,
+	"de": {
+		Tag: "de", Title: "Genetische Einblicke", GeneSNP: "Gen / SNP",
+		Gene: "Gen", RSID: "RS-ID", Allele: "Allel", Genotype: "Genotyp", Match: "Übereinstimmung", Notes: "Hinweise",
+		Matches: map[string]string{"Full": "Vollständig", "Partial": "Teilweise", "None": "Keine"},
+	},
+	"es": {
+		Tag: "es", Title: "Información genética", GeneSNP: "Gen / SNP",
+		Gene: "Gen", RSID: "ID RS", Allele: "Alelo", Genotype: "Genotipo", Match: "Coincidencia", Notes: "Notas",
+		Matches: map[string]string{"Full": "Completa", "Partial": "Parcial", "None": "Ninguna"},
+	},
+	"fr": {
+		Tag: "fr", Title: "Aperçu génétique", GeneSNP: "Gène / SNP",
+		Gene: "Gène", RSID: "ID RS", Allele: "Allèle", Genotype: "Génotype", Match: "Correspondance", Notes: "Remarques",
+		Matches: map[string]string{"Full": "Complète", "Partial": "Partielle", "None": "Aucune"},
+	},
+}
+
+// LookupLocale returns the locale of a language tag such as "de", "de-DE", or "de_DE";
+// the region is ignored. An empty tag is English.
+func LookupLocale(tag string) (Locale, error) {
+	if tag == "" {
+		return English, nil
+	}
+	var l Locale
+	ok := false
+	if parts := strings.FieldsFunc(tag, func(r rune) bool { return r == '-' || r == '_' }); len(parts) > 0 {
+		l, ok = locales[strings.ToLower(parts[0])]
+	}
+	if !ok {
+		return Locale{}, fmt.Errorf("unsupported locale %q (supported: %s)", tag, strings.Join(LocaleTags(), ", "))
+	}
+	return l, nil
+}
+
+// LocaleTags returns the supported language tags in sorted order.
+func LocaleTags() []string {
+	tags := make([]string, 0, len(locales))
+	for tag := range locales {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
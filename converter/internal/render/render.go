@@ -0,0 +1,147 @@
+// Package render turns converted SNP data into human-readable Markdown or HTML documents,
+// one per topic, with a table of genes, rsids, genotypes, and notes for each group.
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/JerkyTreats/PHITE/converter/internal/models"
+)
+
+// Output formats.
+const (
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// ValidFormat reports whether format is a supported output format.
+func ValidFormat(format string) bool {
+	switch format {
+	case FormatJSON, FormatMarkdown, FormatHTML:
+		return true
+	default:
+		return false
+	}
+}
+
+// Extension returns the file extension, without the dot, for an output format.
+func Extension(format string) string {
+	if format == FormatMarkdown {
+		return "md"
+	}
+	return format
+}
+
+// Topic renders a topic document in the given format (markdown or html), in English.
+// Groups are rendered in name order; SNPs keep their input order.
+func Topic(w io.Writer, topic *models.TopicOutput, format string) error {
+	return TopicIn(w, topic, format, English)
+}
+
+// TopicIn renders a topic document like Topic, with the headings and match wording of loc.
+func TopicIn(w io.Writer, topic *models.TopicOutput, format string, loc Locale) error {
+	doc := document{Topic: topic.Topic, L: loc}
+	names := make([]string, 0, len(topic.Groupings))
+	for name := range topic.Groupings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		doc.Groups = append(doc.Groups, group{Name: name, SNPs: topic.Groupings[name]})
+	}
+
+	switch format {
+	case FormatMarkdown:
+		return markdown(w, doc)
+	case FormatHTML:
+		return htmlTemplate.Execute(w, doc)
+	default:
+		return fmt.Errorf("unsupported render format: %s", format)
+	}
+}
+
+type document struct {
+	Topic  string
+	Groups []group
+	L      Locale
+}
+
+type group struct {
+	Name string
+	SNPs []models.SNP
+}
+
+func markdown(w io.Writer, doc document) error {
+	var b strings.Builder
+	l := doc.L
+	fmt.Fprintf(&b, "# %s\n", doc.Topic)
+	for _, g := range doc.Groups {
+		fmt.Fprintf(&b, "\n## %s\n\n", g.Name)
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n", l.Gene, l.RSID, l.Allele, l.Genotype, l.Match, l.Notes)
+		b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+		for _, snp := range g.SNPs {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+				cell(snp.Gene), cell(snp.RSID), cell(snp.Allele),
+				cell(snp.Subject.Genotype), cell(l.MatchLabel(snp.Subject.Match)), cell(snp.Notes))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// cell escapes a value for use in a Markdown table cell.
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// htmlTemplate follows the layout of the hand-written reports in genetic-reports/.
+var htmlTemplate = template.Must(template.New("topic").Parse(`<!DOCTYPE html>
+<html lang="{{.L.Tag}}">
+<head>
+  <meta charset="UTF-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+  <title>{{.L.Title}}: {{.Topic}}</title>
+  <link rel="stylesheet" href="styles.css">
+</head>
+<body>
+  <div class="container">
+    <h1>{{.L.Title}}: {{.Topic}}</h1>
+{{- $l := .L}}
+{{- range .Groups}}
+
+    <section class="section">
+      <h2>{{.Name}}</h2>
+      <table>
+        <thead>
+          <tr>
+            <th>{{$l.GeneSNP}}</th>
+            <th>{{$l.Allele}}</th>
+            <th>{{$l.Genotype}}</th>
+            <th>{{$l.Match}}</th>
+            <th>{{$l.Notes}}</th>
+          </tr>
+        </thead>
+        <tbody>
+{{- range .SNPs}}
+          <tr>
+            <td>{{.Gene}} / {{.RSID}}</td>
+            <td>{{.Allele}}</td>
+            <td>{{.Subject.Genotype}}</td>
+            <td>{{$l.MatchLabel .Subject.Match}}</td>
+            <td>{{.Notes}}</td>
+          </tr>
+{{- end}}
+        </tbody>
+      </table>
+    </section>
+{{- end}}
+  </div>
+</body>
+</html>
+`))
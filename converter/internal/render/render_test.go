@@ -0,0 +1,104 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/JerkyTreats/PHITE/converter/internal/models"
+)
+
+func testTopic() *models.TopicOutput {
+	return &models.TopicOutput{
+		Topic: "Nutrients – Vitamins and Minerals",
+		Groupings: map[string][]models.SNP{
+			"Vitamin D": {{Gene: "VDR", RSID: "rs1544410", Allele: "A", Notes: "lower <VDR> activity", Subject: models.Subject{Genotype: "AA", Match: "Full"}}},
+			"MTHFR":     {{Gene: "MTHFR C677T", RSID: "rs1801133", Allele: "A", Notes: "folate | B12", Subject: models.Subject{Genotype: "AG", Match: "Partial"}}},
+		},
+	}
+}
+
+func TestTopic_Markdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Topic(&buf, testTopic(), FormatMarkdown); err != nil {
+		t.Fatalf("Topic failed: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "# Nutrients – Vitamins and Minerals\n") {
+		t.Errorf("Expected topic heading, got:\n%s", out)
+	}
+	if strings.Index(out, "## MTHFR") > strings.Index(out, "## Vitamin D") {
+		t.Errorf("Expected groups in name order, got:\n%s", out)
+	}
+	if !strings.Contains(out, "| MTHFR C677T | rs1801133 | A | AG | Partial | folate \\| B12 |") {
+		t.Errorf("Expected escaped MTHFR row, got:\n%s", out)
+	}
+}
+
+func TestTopic_HTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Topic(&buf, testTopic(), FormatHTML); err != nil {
+		t.Fatalf("Topic failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"<title>Genetic Insights: Nutrients – Vitamins and Minerals</title>",
+		"<h2>MTHFR</h2>",
+		"<td>VDR / rs1544410</td>",
+		"<td>lower &lt;VDR&gt; activity</td>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestTopic_UnsupportedFormat(t *testing.T) {
+	if err := Topic(&bytes.Buffer{}, testTopic(), FormatJSON); err == nil {
+		t.Error("Expected error for json format")
+	}
+}
+
+func TestExtension(t *testing.T) {
+	if Extension(FormatMarkdown) != "md" || Extension(FormatHTML) != "html" || Extension(FormatJSON) != "json" {
+		t.Error("Unexpected file extension")
+	}
+}
+
+func TestTopicIn_Locale(t *testing.T) {
+	loc, err := LookupLocale("de_DE")
+	if err != nil {
+		t.Fatalf("LookupLocale failed: %v", err)
+	}
+	var md, html bytes.Buffer
+	if err := TopicIn(&md, testTopic(), FormatMarkdown, loc); err != nil {
+		t.Fatalf("TopicIn failed: %v", err)
+	}
+	if !strings.Contains(md.String(), "| Gen | RS-ID | Allel | Genotyp | Übereinstimmung | Hinweise |") ||
+		!strings.Contains(md.String(), "| AG | Teilweise |") {
+		t.Errorf("Expected German headings and match wording, got:\n%s", md.String())
+	}
+	if err := TopicIn(&html, testTopic(), FormatHTML, loc); err != nil {
+		t.Fatalf("TopicIn failed: %v", err)
+	}
+	for _, want := range []string{`<html lang="de">`, "<th>Gen / SNP</th>", "<td>Vollständig</td>"} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("Expected %q in output:\n%s", want, html.String())
+		}
+	}
+}
+
+func TestLookupLocale(t *testing.T) {
+	for _, tag := range []string{"", "en", "EN-us", "fr", "es_MX"} {
+		if _, err := LookupLocale(tag); err != nil {
+			t.Errorf("LookupLocale(%q) failed: %v", tag, err)
+		}
+	}
+	for _, tag := range []string{"xx", "-"} {
+		if _, err := LookupLocale(tag); err == nil {
+			t.Errorf("Expected error for locale %q", tag)
+		}
+	}
+}
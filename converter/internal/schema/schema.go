@@ -0,0 +1,136 @@
+// Package schema versions the converter's JSON output. Every version is listed in the
+// registry below with the layout of its documents and the converters to its neighbours,
+// so a file written in one version can be rewritten for consumers of another. New
+// versions are appended to the registry; existing entries are never changed.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Schema versions.
+const (
+	V1 = "v1"
+	V2 = "v2"
+
+	// Default is the version written unless another is requested. It stays at the layout
+	// existing frontends read; consumers opt in to newer versions.
+	Default = V1
+)
+
+// Schema is one version of the JSON output.
+type Schema struct {
+	// Version is the name accepted by -schema, e.g. "v2"
+	Version string
+	// Layout describes the documents of the version for each grouping mode
+	Layout string
+
+	upgrade   func([]byte) ([]byte, error) // re-encodes a document in the next version; nil for the latest
+	downgrade func([]byte) ([]byte, error) // re-encodes a document in the previous version; nil for the first
+}
+
+// registry lists every schema version, oldest first.
+var registry = []Schema{
+	{
+		Version: V1,
+		Layout: `The original layout, with Go field names as keys and no version marker.
+  group: {"Grouping": {"Topic", "Name", "SNP": [snp]}}
+  topic: {"Topic", "Groupings": {group name: [snp]}}
+  gene:  {"Gene", "Topics": [topic], "Groups": [group name], "SNP": [snp]}
+  snp:   {"Gene", "RSID", "Allele", "Notes", "Subject": {"Genotype", "Match"}}`,
+		upgrade: upgradeV1,
+	},
+	{
+		Version: V2,
+		Layout: `One envelope for every grouping mode, with snake_case keys and flat SNPs.
+  group: {"schema_version": "v2", "kind": "group", "topic", "groups": [{"name", "snps": [snp]}]} (one group)
+  topic: {"schema_version": "v2", "kind": "topic", "topic", "groups": [{"name", "snps": [snp]}]} (groups by name)
+  gene:  {"schema_version": "v2", "kind": "gene", "gene", "topics": [topic], "group_names": [group name], "snps": [snp]}
+  snp:   {"rsid", "gene", "allele", "genotype", "match", "notes"}`,
+		downgrade: downgradeV2,
+	},
+}
+
+// Versions returns the registered schema versions, oldest first.
+func Versions() []string {
+	versions := make([]string, len(registry))
+	for i, s := range registry {
+		versions[i] = s.Version
+	}
+	return versions
+}
+
+// Lookup returns the registered schema of version.
+func Lookup(version string) (Schema, bool) {
+	i := index(version)
+	if i < 0 {
+		return Schema{}, false
+	}
+	return registry[i], true
+}
+
+// Valid reports whether version is a registered schema version.
+func Valid(version string) bool {
+	return index(version) >= 0
+}
+
+func index(version string) int {
+	return slices.IndexFunc(registry, func(s Schema) bool { return s.Version == version })
+}
+
+// VersionOf returns the schema version of an encoded document: its schema_version, or V1
+// for documents without one.
+func VersionOf(data []byte) (string, error) {
+	var marker struct {
+		SchemaVersion string `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &marker); err != nil {
+		return "", fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if marker.SchemaVersion == "" {
+		return V1, nil
+	}
+	if !Valid(marker.SchemaVersion) {
+		return "", fmt.Errorf("unknown schema version %q", marker.SchemaVersion)
+	}
+	return marker.SchemaVersion, nil
+}
+
+// Encode marshals a v1 document (a models.ConversionResult, TopicOutput, or GeneOutput)
+// as indented JSON in version.
+func Encode(doc any, version string) ([]byte, error) {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if version == V1 {
+		return data, nil
+	}
+	return Convert(data, version)
+}
+
+// Convert re-encodes a document in version to, upgrading or downgrading it one version at
+// a time. A document already in version to is returned as is.
+func Convert(data []byte, to string) ([]byte, error) {
+	from, err := VersionOf(data)
+	if err != nil {
+		return nil, err
+	}
+	target := index(to)
+	if target < 0 {
+		return nil, fmt.Errorf("unknown schema version %q; must be one of %v", to, Versions())
+	}
+	for i := index(from); i != target; {
+		step, next := registry[i].upgrade, i+1
+		if target < i {
+			step, next = registry[i].downgrade, i-1
+		}
+		if data, err = step(data); err != nil {
+			return nil, fmt.Errorf("converting %s to %s: %w", registry[i].Version, registry[next].Version, err)
+		}
+		i = next
+	}
+	return data, nil
+}
@@ -0,0 +1,108 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/JerkyTreats/PHITE/converter/internal/models"
+)
+
+var testSNP = models.SNP{Gene: "MTHFR C677T", RSID: "rs1801133", Allele: "A", Notes: "folate", Subject: models.Subject{Genotype: "AG", Match: "Partial"}}
+
+func testDocuments() map[string]any {
+	return map[string]any{
+		KindGroup: models.ConversionResult{Grouping: models.Grouping{Topic: "Nutrients", Name: "MTHFR", SNP: []models.SNP{testSNP}}},
+		KindTopic: models.TopicOutput{Topic: "Nutrients", Groupings: map[string][]models.SNP{"MTHFR": {testSNP}, "B12": {}}},
+		KindGene:  models.GeneOutput{Gene: "MTHFR", Topics: []string{"Nutrients"}, Groups: []string{"MTHFR"}, SNP: []models.SNP{testSNP}},
+	}
+}
+
+func TestEncode_V1MatchesModels(t *testing.T) {
+	for kind, doc := range testDocuments() {
+		want, _ := json.MarshalIndent(doc, "", "  ")
+		got, err := Encode(doc, V1)
+		if err != nil {
+			t.Fatalf("%s: Encode failed: %v", kind, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: v1 output changed:\n%s", kind, got)
+		}
+	}
+}
+
+func TestConvert_RoundTrip(t *testing.T) {
+	for kind, doc := range testDocuments() {
+		v1, _ := Encode(doc, V1)
+		v2, err := Convert(v1, V2)
+		if err != nil {
+			t.Fatalf("%s: upgrade failed: %v", kind, err)
+		}
+		var decoded DocumentV2
+		if err := json.Unmarshal(v2, &decoded); err != nil {
+			t.Fatalf("%s: v2 is not a DocumentV2: %v", kind, err)
+		}
+		if decoded.SchemaVersion != V2 || decoded.Kind != kind {
+			t.Errorf("%s: got schema_version %q kind %q", kind, decoded.SchemaVersion, decoded.Kind)
+		}
+		if version, _ := VersionOf(v2); version != V2 {
+			t.Errorf("%s: VersionOf = %q, want v2", kind, version)
+		}
+
+		back, err := Convert(v2, V1)
+		if err != nil {
+			t.Fatalf("%s: downgrade failed: %v", kind, err)
+		}
+		if !bytes.Equal(back, v1) {
+			t.Errorf("%s: round trip changed the document:\n%s\nwant:\n%s", kind, back, v1)
+		}
+	}
+}
+
+func TestConvert_V2Layout(t *testing.T) {
+	v2, err := Encode(testDocuments()[KindTopic], V2)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	var doc DocumentV2
+	if err := json.Unmarshal(v2, &doc); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(doc.Groups) != 2 || doc.Groups[0].Name != "B12" || doc.Groups[1].Name != "MTHFR" {
+		t.Fatalf("Expected groups in name order, got %+v", doc.Groups)
+	}
+	want := SNPV2{RSID: "rs1801133", Gene: "MTHFR C677T", Allele: "A", Genotype: "AG", Match: "Partial", Notes: "folate"}
+	if doc.Groups[1].SNPs[0] != want {
+		t.Errorf("Expected flat SNP %+v, got %+v", want, doc.Groups[1].SNPs[0])
+	}
+}
+
+func TestConvert_Errors(t *testing.T) {
+	v1, _ := Encode(testDocuments()[KindGene], V1)
+	if _, err := Convert(v1, "v9"); err == nil || !strings.Contains(err.Error(), "unknown schema version") {
+		t.Errorf("Expected unknown version error, got %v", err)
+	}
+	if _, err := Convert([]byte(`{"schema_version": "v9"}`), V1); err == nil {
+		t.Error("Expected error for a document of an unknown version")
+	}
+	if _, err := Convert([]byte(`{"Unrelated": true}`), V2); err == nil {
+		t.Error("Expected error for a document that is not converter output")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	versions := Versions()
+	if len(versions) == 0 || versions[0] != V1 || !Valid(Default) {
+		t.Fatalf("unexpected registry %v with default %s", versions, Default)
+	}
+	for i, version := range versions {
+		s, ok := Lookup(version)
+		if !ok || s.Layout == "" {
+			t.Errorf("%s: missing layout", version)
+		}
+		if (i < len(versions)-1) != (s.upgrade != nil) || (i > 0) != (s.downgrade != nil) {
+			t.Errorf("%s: converters to neighbouring versions are missing", version)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/JerkyTreats/PHITE/converter/internal/models"
+)
+
+// Document kinds, one per grouping mode.
+const (
+	KindGroup = "group"
+	KindTopic = "topic"
+	KindGene  = "gene"
+)
+
+// DocumentV2 is a v2 document of any grouping mode.
+type DocumentV2 struct {
+	SchemaVersion string `json:"schema_version"`
+	// Kind is the grouping mode that wrote the document: "group", "topic", or "gene"
+	Kind string `json:"kind"`
+	// Topic is the topic of group and topic documents
+	Topic string `json:"topic,omitempty"`
+	// Gene is the gene symbol of gene documents
+	Gene string `json:"gene,omitempty"`
+	// Topics and GroupNames list, in input order, where a gene document's SNPs appear
+	Topics     []string `json:"topics,omitempty"`
+	GroupNames []string `json:"group_names,omitempty"`
+	// Groups holds the SNPs of group and topic documents, by group name
+	Groups []GroupV2 `json:"groups,omitempty"`
+	// SNPs holds the SNPs of gene documents
+	SNPs []SNPV2 `json:"snps,omitempty"`
+}
+
+// GroupV2 is a named group of SNPs.
+type GroupV2 struct {
+	Name string  `json:"name"`
+	SNPs []SNPV2 `json:"snps"`
+}
+
+// SNPV2 is an SNP with the subject's genotype and match inline.
+type SNPV2 struct {
+	RSID     string `json:"rsid"`
+	Gene     string `json:"gene"`
+	Allele   string `json:"allele"`
+	Genotype string `json:"genotype"`
+	Match    string `json:"match"`
+	Notes    string `json:"notes"`
+}
+
+// documentV1 decodes any v1 document; which fields are set tells the grouping mode.
+type documentV1 struct {
+	Grouping  *models.Grouping        `json:"Grouping"`
+	Topic     string                  `json:"Topic"`
+	Groupings map[string][]models.SNP `json:"Groupings"`
+	Gene      string                  `json:"Gene"`
+	Topics    []string                `json:"Topics"`
+	Groups    []string                `json:"Groups"`
+	SNP       []models.SNP            `json:"SNP"`
+}
+
+// upgradeV1 re-encodes a v1 document in v2.
+func upgradeV1(data []byte) ([]byte, error) {
+	var v1 documentV1
+	if err := json.Unmarshal(data, &v1); err != nil {
+		return nil, err
+	}
+	doc := DocumentV2{SchemaVersion: V2}
+	switch {
+	case v1.Grouping != nil:
+		doc.Kind, doc.Topic = KindGroup, v1.Grouping.Topic
+		doc.Groups = []GroupV2{{Name: v1.Grouping.Name, SNPs: snpsV2(v1.Grouping.SNP)}}
+	case v1.Groupings != nil:
+		doc.Kind, doc.Topic = KindTopic, v1.Topic
+		names := make([]string, 0, len(v1.Groupings))
+		for name := range v1.Groupings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		doc.Groups = []GroupV2{}
+		for _, name := range names {
+			doc.Groups = append(doc.Groups, GroupV2{Name: name, SNPs: snpsV2(v1.Groupings[name])})
+		}
+	case v1.Gene != "":
+		doc.Kind, doc.Gene = KindGene, v1.Gene
+		doc.Topics, doc.GroupNames, doc.SNPs = v1.Topics, v1.Groups, snpsV2(v1.SNP)
+	default:
+		return nil, fmt.Errorf("not a v1 group, topic, or gene document")
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// downgradeV2 re-encodes a v2 document in v1.
+func downgradeV2(data []byte) ([]byte, error) {
+	var doc DocumentV2
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var v1 any
+	switch doc.Kind {
+	case KindGroup:
+		if len(doc.Groups) != 1 {
+			return nil, fmt.Errorf("group document has %d groups, want 1", len(doc.Groups))
+		}
+		v1 = models.ConversionResult{Grouping: models.Grouping{
+			Topic: doc.Topic,
+			Name:  doc.Groups[0].Name,
+			SNP:   snpsV1(doc.Groups[0].SNPs),
+		}}
+	case KindTopic:
+		groupings := make(map[string][]models.SNP, len(doc.Groups))
+		for _, g := range doc.Groups {
+			groupings[g.Name] = snpsV1(g.SNPs)
+		}
+		v1 = models.TopicOutput{Topic: doc.Topic, Groupings: groupings}
+	case KindGene:
+		v1 = models.GeneOutput{Gene: doc.Gene, Topics: doc.Topics, Groups: doc.GroupNames, SNP: snpsV1(doc.SNPs)}
+	default:
+		return nil, fmt.Errorf("unknown document kind %q", doc.Kind)
+	}
+	return json.MarshalIndent(v1, "", "  ")
+}
+
+func snpsV2(snps []models.SNP) []SNPV2 {
+	if snps == nil {
+		return nil
+	}
+	out := make([]SNPV2, len(snps))
+	for i, s := range snps {
+		out[i] = SNPV2{RSID: s.RSID, Gene: s.Gene, Allele: s.Allele, Genotype: s.Subject.Genotype, Match: s.Subject.Match, Notes: s.Notes}
+	}
+	return out
+}
+
+func snpsV1(snps []SNPV2) []models.SNP {
+	if snps == nil {
+		return nil
+	}
+	out := make([]models.SNP, len(snps))
+	for i, s := range snps {
+		out[i] = models.SNP{Gene: s.Gene, RSID: s.RSID, Allele: s.Allele, Notes: s.Notes, Subject: models.Subject{Genotype: s.Genotype, Match: s.Match}}
+	}
+	return out
+}
@@ -0,0 +1,200 @@
+// Package watch re-runs a conversion whenever its input TSV changes, so outputs stay
+// current while the source spreadsheet is being curated.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/JerkyTreats/PHITE/converter/pkg/logger"
+)
+
+// DefaultDebounce is how long the watcher waits after the last change before converting.
+// Spreadsheet exports and editors typically write a file in several steps.
+const DefaultDebounce = 500 * time.Millisecond
+
+// ConvertFunc converts one input file and returns the output files it wrote.
+type ConvertFunc func(inputFile string) ([]string, error)
+
+// Watcher converts an input file, or every .tsv file in an input directory, and
+// re-converts each file when it changes.
+//
+// Conversion is incremental: only changed files are converted again, and outputs written
+// by other inputs are left in place. When a file no longer produces an output it wrote
+// previously (e.g. a group was renamed), that stale output is removed.
+type Watcher struct {
+	input    string
+	isDir    bool
+	debounce time.Duration
+	convert  ConvertFunc
+
+	// outputs maps each input file to the outputs its last successful conversion wrote
+	outputs map[string][]string
+}
+
+// New creates a watcher for an input file or directory.
+func New(input string, debounce time.Duration, convert ConvertFunc) (*Watcher, error) {
+	abs, err := filepath.Abs(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for %s: %w", input, err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch %s: %w", input, err)
+	}
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	return &Watcher{
+		input:    abs,
+		isDir:    info.IsDir(),
+		debounce: debounce,
+		convert:  convert,
+		outputs:  make(map[string][]string),
+	}, nil
+}
+
+// Run converts all inputs, then watches for changes until ctx is cancelled.
+// Conversion errors are logged and do not stop the watcher.
+func (w *Watcher) Run(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer fsw.Close()
+
+	// Watch the directory rather than the file: editors often save by writing a
+	// temporary file and renaming it over the original, which drops a file watch.
+	dir := w.input
+	if !w.isDir {
+		dir = filepath.Dir(w.input)
+	}
+	if err := fsw.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	inputs, err := w.inputs()
+	if err != nil {
+		return err
+	}
+	for _, input := range inputs {
+		w.Convert(input)
+	}
+	logger.Info("watching for changes", "input", w.input, "debounce", w.debounce.String())
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if !w.matches(event.Name) || !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				continue
+			}
+			logger.Debug("input changed", "file", event.Name, "op", event.Op.String())
+			pending[event.Name] = true
+			timer.Reset(w.debounce)
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			logger.Error(err, "file watcher error")
+		case <-timer.C:
+			for _, input := range sortedKeys(pending) {
+				if _, err := os.Stat(input); err != nil {
+					logger.Info("input removed; keeping its outputs", "input", input)
+					continue
+				}
+				w.Convert(input)
+			}
+			pending = make(map[string]bool)
+		}
+	}
+}
+
+// Convert converts one input file and removes outputs it wrote previously but no longer
+// produces, unless another input also wrote them. It returns false if conversion failed,
+// in which case existing outputs are kept.
+func (w *Watcher) Convert(input string) bool {
+	outputs, err := w.convert(input)
+	if err != nil {
+		logger.Error(err, "conversion failed; keeping previous outputs", "input", input)
+		return false
+	}
+
+	current := make(map[string]bool, len(outputs))
+	for _, out := range outputs {
+		current[out] = true
+	}
+	for _, out := range w.outputs[input] {
+		if current[out] || w.claimed(out, input) {
+			continue
+		}
+		if err := os.Remove(out); err != nil && !os.IsNotExist(err) {
+			logger.Error(err, "failed to remove stale output", "path", out)
+			continue
+		}
+		logger.Info("removed stale output", "path", out)
+	}
+	w.outputs[input] = outputs
+	logger.Info("converted", "input", input, "outputs", len(outputs))
+	return true
+}
+
+// claimed reports whether an input other than except last wrote output.
+func (w *Watcher) claimed(output, except string) bool {
+	for input, outputs := range w.outputs {
+		if input == except {
+			continue
+		}
+		for _, out := range outputs {
+			if out == output {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inputs lists the files to convert: the input file, or the .tsv files in the input directory.
+func (w *Watcher) inputs() ([]string, error) {
+	if !w.isDir {
+		return []string{w.input}, nil
+	}
+	matches, err := filepath.Glob(filepath.Join(w.input, "*.tsv"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inputs in %s: %w", w.input, err)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// matches reports whether a changed path is one of the watched inputs.
+func (w *Watcher) matches(path string) bool {
+	if !w.isDir {
+		return filepath.Clean(path) == w.input
+	}
+	return filepath.Dir(path) == w.input && filepath.Ext(path) == ".tsv"
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,112 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatcher_Convert_RemovesStaleOutputs(t *testing.T) {
+	dir := t.TempDir()
+	a, b, shared := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json"), filepath.Join(dir, "shared.json")
+	for _, f := range []string{a, b, shared} {
+		if err := os.WriteFile(f, []byte("{}"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", f, err)
+		}
+	}
+
+	outputs := map[string][]string{
+		"one.tsv": {a, shared},
+		"two.tsv": {shared},
+	}
+	w := &Watcher{
+		convert: func(input string) ([]string, error) { return outputs[input], nil },
+		outputs: make(map[string][]string),
+	}
+	w.Convert("one.tsv")
+	w.Convert("two.tsv")
+
+	outputs["one.tsv"] = []string{b}
+	if !w.Convert("one.tsv") {
+		t.Fatal("Convert failed")
+	}
+	if _, err := os.Stat(a); !os.IsNotExist(err) {
+		t.Errorf("Expected stale output %s to be removed", a)
+	}
+	if _, err := os.Stat(shared); err != nil {
+		t.Errorf("Expected output still written by two.tsv to be kept: %v", err)
+	}
+	if _, err := os.Stat(b); err != nil {
+		t.Errorf("Expected current output to be kept: %v", err)
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	dir := t.TempDir()
+	one, two := filepath.Join(dir, "one.tsv"), filepath.Join(dir, "two.tsv")
+	for _, f := range []string{one, two} {
+		if err := os.WriteFile(f, []byte("Topic\tGroup\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", f, err)
+		}
+	}
+
+	var mu sync.Mutex
+	var converted []string
+	convert := func(input string) ([]string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		converted = append(converted, filepath.Base(input))
+		return nil, nil
+	}
+	snapshot := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return strings.Join(converted, ",")
+	}
+
+	w, err := New(dir, 50*time.Millisecond, convert)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	waitFor(t, snapshot, "one.tsv,two.tsv")
+
+	// Several writes in quick succession convert the changed file once.
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(two, []byte("Topic\tGroup\nNutrients\tMTHFR\n"), 0644); err != nil {
+			t.Fatalf("Failed to update %s: %v", two, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644); err != nil {
+		t.Fatalf("Failed to write notes: %v", err)
+	}
+	waitFor(t, snapshot, "one.tsv,two.tsv,two.tsv")
+	time.Sleep(200 * time.Millisecond)
+	if got := snapshot(); got != "one.tsv,two.tsv,two.tsv" {
+		t.Errorf("Expected a single debounced re-conversion of two.tsv, got %s", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run returned error: %v", err)
+	}
+}
+
+func waitFor(t *testing.T, get func() string, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if get() == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for conversions %q, got %q", want, get())
+}
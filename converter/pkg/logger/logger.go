@@ -1,59 +1,85 @@
+// Package logger is the converter's logging facade over the shared PHITE logging
+// package. Records are tagged component=converter.
 package logger
 
 import (
-	"fmt"
 	"os"
 
-	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
+	"github.com/JerkyTreats/PHITE/logging"
 )
 
 const (
-	LevelDebug = "debug"
-	LevelInfo  = "info"
-	LevelError = "error"
-	LevelFatal = "fatal"
+	LevelDebug = logging.LevelDebug
+	LevelInfo  = logging.LevelInfo
+	LevelWarn  = logging.LevelWarn
+	LevelError = logging.LevelError
+	LevelFatal = logging.LevelFatal
 )
 
-func init() {
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+// Component tags the converter's records.
+const Component = "converter"
+
+var log = newDefault()
+
+func newDefault() *logging.Logger {
+	l, err := logging.New(logging.FromEnv(logging.Options{Component: Component}))
+	if err != nil {
+		l, _ = logging.New(logging.Options{Component: Component})
+	}
+	return l
 }
 
 func Debug(msg string, fields ...interface{}) {
-	log.Debug().Fields(fields).Msg(msg)
+	log.Debug(msg, fields...)
 }
 
 func Info(msg string, fields ...interface{}) {
-	log.Info().Fields(fields).Msg(msg)
+	log.Info(msg, fields...)
+}
+
+func Warn(msg string, fields ...interface{}) {
+	log.Warn(msg, fields...)
 }
 
 func Error(err error, msg string, fields ...interface{}) {
-	log.Error().Err(err).Fields(fields).Msg(msg)
+	log.Error(msg, withErr(err, fields)...)
 }
 
 func Fatal(err error, msg string, fields ...interface{}) {
-	log.Fatal().Err(err).Fields(fields).Msg(msg)
+	log.Fatal(msg, withErr(err, fields)...)
 	os.Exit(1)
 }
 
-func WithFields(fields ...interface{}) zerolog.Context {
-	return log.With().Fields(fields)
+// WithFields returns a logger that adds the key/value pairs to every record.
+func WithFields(fields ...interface{}) *logging.Logger {
+	return log.With(fields...)
 }
 
 // SetLevel sets the global logging level
 func SetLevel(level string) error {
-	switch level {
-	case LevelDebug:
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	case LevelInfo:
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
-	case LevelError:
-		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
-	case LevelFatal:
-		zerolog.SetGlobalLevel(zerolog.FatalLevel)
-	default:
-		return fmt.Errorf("invalid log level: %s", level)
+	return log.SetLevel(level)
+}
+
+// Configure replaces the logger with one writing in format ("text" or "json") to file,
+// rotated by size, or to stderr when file is empty.
+func Configure(level, format, file string) error {
+	l, err := logging.New(logging.Options{
+		Level:     level,
+		Format:    format,
+		File:      file,
+		Component: Component,
+	})
+	if err != nil {
+		return err
+	}
+	prev := log
+	log = l
+	return prev.Close()
+}
+
+func withErr(err error, fields []interface{}) []interface{} {
+	if err == nil {
+		return fields
 	}
-	return nil
+	return append([]interface{}{"error", err}, fields...)
 }
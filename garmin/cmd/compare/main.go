@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	garminactivity "garmin/internal/activity"
+	"garmin/internal/compare"
+)
+
+func main() {
+	by := flag.String("by", compare.ByDistance, "align activities by 'distance' or 'time'")
+	split := flag.Float64("split", 0, "split size in meters (distance) or seconds (time); default 1000 m or 300 s")
+	tolerant := flag.Bool("tolerant", false, "recover what can be read from truncated or corrupt FIT files instead of failing")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <a.fit> <b.fit>\n\nCompares two activities split by split; deltas are B - A.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *split == 0 {
+		*split = 1000
+		if *by == compare.ByTime {
+			*split = 300
+		}
+	}
+
+	var activities [2]*garminactivity.Decoded
+	for i, path := range flag.Args() {
+		decoded, err := garminactivity.DecodeFile(path, *tolerant)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			os.Exit(1)
+		}
+		if c := decoded.Corruption; c != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s is corrupt at %s; comparing the records before it\n", path, c)
+		}
+		activities[i] = decoded
+	}
+
+	splits, err := compare.Splits(activities[0].Activity.Records, activities[1].Activity.Records, *by, *split)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if len(splits) == 0 {
+		fmt.Fprintf(os.Stderr, "The activities have no %s in common to compare.\n", *by)
+		os.Exit(1)
+	}
+
+	fmt.Printf("A: %s\nB: %s\n\n", filepath.Base(flag.Arg(0)), filepath.Base(flag.Arg(1)))
+	fmt.Printf("%-15s %9s %9s %8s   %5s %5s %5s   %5s %5s %6s\n", "Split", "Pace A", "Pace B", "Δ", "HR A", "HR B", "Δ", "W A", "W B", "Δ")
+	var totalA, totalB compare.Stats
+	for _, s := range splits {
+		fmt.Printf("%-15s %9s %9s %8s   %5s %5s %5s   %5s %5s %6s\n",
+			label(s, *by),
+			pace(s.A.Pace()), pace(s.B.Pace()), paceDelta(s.B.Pace()-s.A.Pace()),
+			value(s.A.HeartRate), value(s.B.HeartRate), delta(s.B.HeartRate-s.A.HeartRate),
+			value(s.A.Power), value(s.B.Power), delta(s.B.Power-s.A.Power))
+		totalA.Seconds, totalA.Meters = totalA.Seconds+s.A.Seconds, totalA.Meters+s.A.Meters
+		totalB.Seconds, totalB.Meters = totalB.Seconds+s.B.Seconds, totalB.Meters+s.B.Meters
+	}
+	fmt.Printf("\nOverall pace: A %s, B %s (%s /km)\n", pace(totalA.Pace()), pace(totalB.Pace()), paceDelta(totalB.Pace()-totalA.Pace()))
+}
+
+func label(s compare.Split, by string) string {
+	if by == compare.ByTime {
+		return fmt.Sprintf("%s-%s", clock(s.Start), clock(s.End))
+	}
+	return fmt.Sprintf("%.2f-%.2f km", s.Start/1000, s.End/1000)
+}
+
+// pace formats seconds per km as m:ss.
+func pace(secPerKm float64) string {
+	if math.IsNaN(secPerKm) || math.IsInf(secPerKm, 0) {
+		return "-"
+	}
+	return clock(secPerKm)
+}
+
+func paceDelta(d float64) string {
+	if math.IsNaN(d) || math.IsInf(d, 0) {
+		return "-"
+	}
+	sign := "+"
+	if d < 0 {
+		sign = "-"
+	}
+	return sign + clock(math.Abs(d))
+}
+
+func clock(seconds float64) string {
+	s := int(math.Round(seconds))
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+func value(v float64) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f", v)
+}
+
+func delta(v float64) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return fmt.Sprintf("%+.0f", v)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	garminactivity "garmin/internal/activity"
+	"garmin/internal/export"
+)
+
+func main() {
+	format := flag.String("format", export.FormatCSV, "output format: csv or json")
+	output := flag.String("o", "", "output file (default: stdout)")
+	tolerant := flag.Bool("tolerant", false, "recover what can be read from truncated or corrupt FIT files instead of failing")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <activity.fit>\n\nDumps every record (timestamp, lat, lon, alt, hr, cadence, speed, power, distance) as CSV or JSON.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	decoded, err := garminactivity.DecodeFile(flag.Arg(0), *tolerant)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if c := decoded.Corruption; c != nil {
+		fmt.Fprintf(os.Stderr, "Warning: file is corrupt at %s; exporting the %d records before it\n", c, len(decoded.Activity.Records))
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	if err := export.Write(w, decoded.Activity.Records, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to export records: %v\n", err)
+		os.Exit(1)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write records: %v\n", err)
+		os.Exit(1)
+	}
+}
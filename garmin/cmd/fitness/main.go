@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	garminactivity "garmin/internal/activity"
+	"garmin/internal/config"
+	"garmin/internal/fitness"
+	"garmin/internal/records"
+)
+
+func main() {
+	// Config is optional; without ~/.phite/config.json the flags' defaults apply.
+	cfg, err := config.LoadGarminConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; using defaults\n", err)
+		cfg = &config.GarminConfig{}
+	}
+
+	dbPath := flag.String("db", records.DefaultPath(), "activity database shared with the records command")
+	ftp := flag.Float64("ftp", cfg.FTPWatts, "functional threshold power in watts, for power TSS (default: garmin.ftp_watts in config, else each file's threshold power)")
+	thresholdHR := flag.Float64("threshold-hr", float64(cfg.ThresholdHeartRate), "lactate threshold heart rate, for heart rate TSS of activities without power (default: garmin.threshold_heart_rate in config)")
+	days := flag.Int("days", 14, "days of the form curve to print, ending today")
+	format := flag.String("format", "text", "output format: text or json")
+	tolerant := flag.Bool("tolerant", false, "recover what can be read from truncated or corrupt FIT files instead of skipping them")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [activity.fit | dir]...\n\nIngests FIT activities (directories are searched for *.fit), scores every ingested activity's\ntraining stress (TSS), and prints the fitness (CTL), fatigue (ATL), and form (TSB) curve.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *format != "text" && *format != "json" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	store, err := records.Open(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	files, err := records.FitFiles(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	decoded := make(map[string]*garminactivity.Decoded)
+	for _, path := range files {
+		d, err := garminactivity.DecodeFile(path, *tolerant)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", path, err)
+			continue
+		}
+		if _, ok := store.Ingest(d.Activity, path); ok {
+			decoded[path] = d
+		}
+	}
+	if len(files) > 0 {
+		if err := store.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Every ingested activity is rescored, so a changed FTP or threshold applies to history
+	var activities []fitness.Activity
+	unscored, missing := 0, 0
+	for _, source := range store.Sources() {
+		d, ok := decoded[source]
+		if !ok {
+			if d, err = garminactivity.DecodeFile(source, *tolerant); err != nil {
+				missing++
+				continue
+			}
+		}
+		st := garminactivity.TrainingStressOf(d.Activity, *ftp, *thresholdHR)
+		if st.Method == "" {
+			unscored++
+			continue
+		}
+		s, _ := garminactivity.PrimarySession(d.Activity)
+		activities = append(activities, fitness.Activity{Date: s.StartTime, TSS: st.TSS, Method: st.Method, Source: source})
+	}
+	fitness.SortByDate(activities)
+	if missing > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d ingested activities could not be read and are left out\n", missing)
+	}
+	if len(activities) == 0 {
+		fmt.Fprintf(os.Stderr, "No scored activities; ingest FIT files with power (and an FTP) or heart rate (with -threshold-hr).\n")
+		os.Exit(1)
+	}
+
+	series := fitness.Series(activities, time.Now(), time.Local)
+	if *days > 0 && len(series) > *days {
+		series = series[len(series)-*days:]
+	}
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(series); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write form curve: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Printf("Training Load\n-------------\n")
+	fmt.Printf("%d activities scored", len(activities))
+	if unscored > 0 {
+		fmt.Printf(" (%d without power or heart rate threshold skipped)", unscored)
+	}
+	fmt.Printf("\n\n%-10s  %6s  %6s  %6s  %6s\n", "Date", "TSS", "CTL", "ATL", "TSB")
+	for _, d := range series {
+		fmt.Printf("%-10s  %6.0f  %6.1f  %6.1f  %6.1f\n", d.Date.Format(time.DateOnly), d.TSS, d.CTL, d.ATL, d.TSB)
+	}
+	today := series[len(series)-1]
+	fmt.Printf("\nFitness (CTL): %.1f\n", today.CTL)
+	fmt.Printf("Fatigue (ATL): %.1f\n", today.ATL)
+	fmt.Printf("Form (TSB): %.1f (%s)\n", today.TSB, fitness.Form(today.TSB))
+}
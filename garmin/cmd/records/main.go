@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	garminactivity "garmin/internal/activity"
+	"garmin/internal/records"
+)
+
+func main() {
+	dbPath := flag.String("db", records.DefaultPath(), "personal records database")
+	tolerant := flag.Bool("tolerant", false, "recover what can be read from truncated or corrupt FIT files instead of skipping them")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [activity.fit | dir]...\n\nIngests FIT activities (directories are searched for *.fit) and reports new personal records:\nfastest 1K/5K/10K runs, longest ride, and max 20-min power. With no arguments, lists the current records.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	store, err := records.Open(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	files, err := records.FitFiles(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	ingested, failed := 0, 0
+	for _, path := range files {
+		decoded, err := garminactivity.DecodeFile(path, *tolerant)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", path, err)
+			failed++
+			continue
+		}
+		changes, ok := store.Ingest(decoded.Activity, path)
+		if !ok {
+			continue
+		}
+		ingested++
+		for _, c := range changes {
+			if c.Previous != nil {
+				fmt.Printf("New personal record! %s: %s (was %s on %s) in %s\n",
+					c.New.Name, c.New, c.Previous, c.Previous.Date.Format("2006-01-02"), filepath.Base(path))
+			} else {
+				fmt.Printf("New personal record! %s: %s in %s\n", c.New.Name, c.New, filepath.Base(path))
+			}
+		}
+	}
+
+	if len(files) > 0 {
+		if err := store.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Ingested %d new of %d activities (%d skipped)\n\n", ingested, len(files), failed)
+	}
+
+	fmt.Printf("Personal Records\n----------------\n")
+	if len(store.Records) == 0 {
+		fmt.Println("No records yet; pass running or cycling FIT files, or a directory of them, to ingest.")
+	}
+	for _, r := range store.Sorted() {
+		fmt.Printf("%s: %s (%s, %s)\n", r.Name, r, r.Date.Format("2006-01-02"), filepath.Base(r.Activity))
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
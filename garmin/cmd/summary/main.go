@@ -1,27 +1,63 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
-	"github.com/muktihari/fit/decoder"
-	"github.com/muktihari/fit/profile/filedef"
+	garminactivity "garmin/internal/activity"
+	"garmin/internal/config"
+	"garmin/internal/summary"
+
+	"github.com/muktihari/fit/profile/basetype"
+	"github.com/muktihari/fit/profile/mesgdef"
 )
 
 func main() {
-	f, err := os.Open("19313160934_ACTIVITY.fit")
+	// Config is optional; without ~/.phite/config.json the flags' defaults apply.
+	cfg, err := config.LoadGarminConfig()
 	if err != nil {
-		panic(err)
+		fmt.Fprintf(os.Stderr, "Warning: %v; using defaults\n", err)
+		cfg = &config.GarminConfig{}
 	}
-	defer f.Close()
 
-	dec := decoder.New(f)
-	fitFile, err := dec.Decode()
-	if err != nil {
-		panic(err)
+	tolerant := flag.Bool("tolerant", false, "recover what can be read from truncated or corrupt FIT files instead of failing")
+	ftp := flag.Float64("ftp", cfg.FTPWatts, "functional threshold power in watts, for intensity factor and TSS (default: garmin.ftp_watts in config, else the file's threshold power)")
+	elevation := garminactivity.DefaultElevationOptions
+	if cfg.ElevationWindow > 0 {
+		elevation.Window = cfg.ElevationWindow
+	}
+	if cfg.ElevationThresholdM > 0 {
+		elevation.Threshold = cfg.ElevationThresholdM
+	}
+	maxHR := flag.Float64("max-hr", garminactivity.MaxHeartRate(cfg.MaxHeartRate, cfg.UserAge), "max heart rate for intensity minutes (default: garmin.max_heart_rate in config, else 220 - user_age); 0 uses speed-based METs")
+	flag.IntVar(&elevation.Window, "elevation-window", elevation.Window, "altitude smoothing window in samples when computing ascent/descent from records")
+	flag.Float64Var(&elevation.Threshold, "elevation-threshold", elevation.Threshold, "minimum altitude change in meters counted as ascent/descent")
+	flag.Parse()
+
+	for sport, sections := range cfg.SportTemplates {
+		if err := summary.Register(sport, summary.Template{Sections: sections}); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: garmin.sport_templates: %v\n", err)
+		}
 	}
 
-	activity := filedef.NewActivity(fitFile.Messages...)
+	path := "19313160934_ACTIVITY.fit"
+	if flag.NArg() > 0 {
+		path = flag.Arg(0)
+	}
+
+	decoded, err := garminactivity.DecodeFile(path, *tolerant)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		if !*tolerant {
+			fmt.Fprintln(os.Stderr, "The file may be truncated or corrupt; rerun with -tolerant to recover what can be read.")
+		}
+		os.Exit(1)
+	}
+	activity := decoded.Activity
+	if c := decoded.Corruption; c != nil {
+		fmt.Printf("Warning: file is corrupt at %s; recovered %d messages before it\n", c, decoded.Messages)
+	}
 
 	fmt.Printf("Records count: %d\n", len(activity.Records))
 	if len(activity.Records) > 0 {
@@ -38,50 +74,52 @@ func main() {
 		fmt.Println("No records found.")
 	}
 
+	// --- Multi-sport legs (triathlon etc.) ---
+	if len(activity.Sessions) > 1 {
+		fmt.Printf("\nSessions\n--------\n")
+		transitions := 0
+		for i, leg := range garminactivity.SportSessions(activity) {
+			ls := leg.Session
+			name := ls.Sport.String()
+			if leg.IsTransition() {
+				transitions++
+				name = fmt.Sprintf("transition (T%d)", transitions)
+			}
+			fmt.Printf("%d. %s: %.2f min, %.2f km, avg HR %s, %d records\n",
+				i+1, name, garminactivity.Duration(ls).Minutes(), distanceKm(ls), heartRate(ls.AvgHeartRate), len(leg.Records))
+		}
+	}
+
 	// --- Garmin UI-style Summary ---
-	if len(activity.Sessions) > 0 {
-		s := activity.Sessions[0]
+	if len(activity.Sessions) > 0 || len(activity.Records) > 0 {
+		s, derived := garminactivity.PrimarySession(activity)
+		template := summary.For(s)
 		fmt.Printf("\n==== Garmin UI-Style Summary ====")
+		if template.Name != summary.Default.Name {
+			fmt.Printf("\n(%s summary)", template.Name)
+		}
+		if len(activity.Sessions) > 1 {
+			fmt.Printf("\n(combined totals of %d sessions)", len(activity.Sessions))
+		}
+		if derived {
+			fmt.Printf("\n(no session in file; totals derived from %d records)", len(activity.Records))
+		}
 
-		// TIMING
-		fmt.Printf("\n\nTiming\n------\n")
-		fmt.Printf("Total Time: %.2f min\n", float64(s.TotalTimerTime)/60)
-		fmt.Printf("Distance: %.2f km\n", float64(s.TotalDistance)/1000)
-
-		// NUTRITION & HYDRATION
-		fmt.Printf("\nNutrition & Hydration\n----------------------\n")
-		fmt.Printf("Total Calories Burned: %d\n", s.TotalCalories)
-		fmt.Printf("Active Calories: %d\n", s.TotalCalories)
-		fmt.Printf("Resting Calories: (not in file, estimate from BMR × time)\n")
-		fmt.Printf("Calories Consumed: (not in file)\n")
-		fmt.Printf("Calories Net: (not in file, UI: Burned - Consumed)\n")
-		fmt.Printf("Est. Sweat Loss: (not in file)\n")
-		fmt.Printf("Fluid Consumed: (not in file)\n")
-		fmt.Printf("Fluid Net: (not in file)\n")
-
-		// TRAINING EFFECT
-		fmt.Printf("\nTraining Effect\n---------------\n")
-		fmt.Printf("Aerobic: %d\n", s.TotalTrainingEffect)
-		fmt.Printf("Anaerobic: %d\n", s.TotalAnaerobicTrainingEffect)
-		fmt.Printf("Exercise Load: %d\n", s.TrainingLoadPeak)
-		fmt.Printf("Primary Benefit: %s\n", s.SportProfileName)
+		fmt.Println()
+		input := &summary.Input{Activity: activity, Session: s, FTP: *ftp, MaxHR: *maxHR, Elevation: elevation}
+		template.Render(os.Stdout, input)
+	}
 
-		// WORKOUT DETAILS
-		fmt.Printf("\nWorkout Details\n---------------\n")
-		fmt.Printf("Total Reps: (not in file)\n")
-		fmt.Printf("Total Sets: (not in file)\n")
-		fmt.Printf("Volume: (not in file)\n")
+}
 
-		// INTENSITY MINUTES
-		fmt.Printf("\nIntensity Minutes\n-----------------\n")
-		fmt.Printf("Moderate: (not in file, calculated by device/UI)\n")
-		fmt.Printf("Vigorous: (not in file, calculated by device/UI)\n")
-		fmt.Printf("Total: (not in file, calculated by device/UI)\n")
+// distanceKm returns a session's distance in km, or 0 if it is not recorded.
+func distanceKm(s *mesgdef.Session) float64 {
+	return garminactivity.SessionDistance(s) / 1000
+}
 
-		// HEART RATE
-		fmt.Printf("\nHeart Rate\n----------\n")
-		fmt.Printf("Avg HR: %d bpm\n", s.AvgHeartRate)
-		fmt.Printf("Max HR: %d bpm\n", s.MaxHeartRate)
+func heartRate(bpm uint8) string {
+	if bpm == basetype.Uint8Invalid {
+		return "-"
 	}
-
+	return fmt.Sprintf("%d bpm", bpm)
 }
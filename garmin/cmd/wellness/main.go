@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"garmin/internal/wellness"
+)
+
+func main() {
+	format := flag.String("format", "text", "output format: text or json")
+	tolerant := flag.Bool("tolerant", false, "recover what can be read from truncated or corrupt FIT files instead of skipping them")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <wellness.fit | dir>...\n\nReads Garmin monitoring and wellness FIT files (directories are searched for *.fit) and prints\ndaily summaries: steps, intensity minutes, heart rate, sleep stages, HRV, and stress.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 || *format != "text" && *format != "json" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	files, err := fitFiles(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	data := wellness.New()
+	failed := 0
+	for _, path := range files {
+		c, err := data.AddFile(path, *tolerant)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", path, err)
+			failed++
+			continue
+		}
+		if c != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s is corrupt at %s; using the messages before it\n", filepath.Base(path), c)
+		}
+	}
+	if data.Empty() {
+		fmt.Fprintln(os.Stderr, "No monitoring, sleep, HRV, or stress data found; pass the files from the watch's Monitor, Sleep, HRVStatus, or Metrics folders.")
+		os.Exit(1)
+	}
+
+	days := data.Days()
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(days); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write summaries: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		for i, d := range days {
+			if i > 0 {
+				fmt.Println()
+			}
+			printDay(d, data.Location)
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func printDay(d wellness.Day, loc *time.Location) {
+	fmt.Printf("%s\n%s\n", d.Date, strings.Repeat("-", len(d.Date)))
+	if d.Steps > 0 || d.DistanceM > 0 {
+		fmt.Printf("Steps: %d (%.2f km)\n", d.Steps, d.DistanceM/1000)
+	}
+	if d.Calories > 0 {
+		fmt.Printf("Calories: %d kcal\n", d.Calories)
+	}
+	if d.ModerateMinutes > 0 || d.VigorousMinutes > 0 {
+		fmt.Printf("Intensity Minutes: %d moderate, %d vigorous\n", d.ModerateMinutes, d.VigorousMinutes)
+	}
+	if d.AvgHeartRate > 0 {
+		fmt.Printf("Heart Rate: avg %d bpm, min %d bpm\n", d.AvgHeartRate, d.MinHeartRate)
+	}
+	if s := d.Sleep; s != nil {
+		fmt.Printf("Sleep: %s, %s-%s (deep %s, light %s, REM %s, awake %s)\n",
+			minutes(s.AsleepMinutes()), s.Start.In(loc).Format("15:04"), s.End.In(loc).Format("15:04"),
+			minutes(s.DeepMinutes), minutes(s.LightMinutes), minutes(s.REMMinutes), minutes(s.AwakeMinutes))
+	}
+	if h := d.HRV; h != nil {
+		fmt.Printf("HRV: last night %.0f ms", h.LastNightMs)
+		if h.WeeklyMs > 0 {
+			fmt.Printf(", 7-day avg %.0f ms", h.WeeklyMs)
+		}
+		if h.Status != "" {
+			fmt.Printf(" (%s)", h.Status)
+		}
+		fmt.Println()
+	}
+	if s := d.Stress; s != nil {
+		fmt.Printf("Stress: avg %.0f, max %d\n", s.Avg, s.Max)
+	}
+}
+
+// minutes formats a duration in minutes as 7h32m.
+func minutes(m float64) string {
+	total := int(m + 0.5)
+	return fmt.Sprintf("%dh%02dm", total/60, total%60)
+}
+
+// fitFiles expands the arguments into FIT files; directories contribute their *.fit files.
+func fitFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		entries, err := os.ReadDir(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.IsDir() && strings.EqualFold(filepath.Ext(e.Name()), ".fit") {
+				files = append(files, filepath.Join(arg, e.Name()))
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
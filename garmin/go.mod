@@ -2,4 +2,9 @@ module garmin
 
 go 1.24.3
 
-require github.com/muktihari/fit v0.24.5 // indirect
+require (
+	github.com/JerkyTreats/PHITE/config v0.0.0
+	github.com/muktihari/fit v0.24.5
+)
+
+replace github.com/JerkyTreats/PHITE/config => ../config
@@ -0,0 +1,125 @@
+// Package activity decodes Garmin FIT activity files and derives the values shown in
+// the summary that the device or Garmin Connect would otherwise calculate.
+package activity
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/muktihari/fit/decoder"
+	"github.com/muktihari/fit/profile/filedef"
+	"github.com/muktihari/fit/proto"
+)
+
+// Corruption describes where a tolerant decode stopped.
+type Corruption struct {
+	// Offset is the byte position of the first unreadable data, or -1 if unknown
+	Offset int64
+	// Err is the decoder error at that point
+	Err error
+}
+
+func (c *Corruption) String() string {
+	if c.Offset < 0 {
+		return c.Err.Error()
+	}
+	return fmt.Sprintf("byte %d: %v", c.Offset, c.Err)
+}
+
+// Decoded is the result of decoding a FIT activity file.
+type Decoded struct {
+	Activity *filedef.Activity
+	// Messages is the number of messages decoded
+	Messages int
+	// Corruption is set when a tolerant decode recovered from a corrupt or truncated file
+	Corruption *Corruption
+}
+
+// DecodeFile opens and decodes a FIT activity file. See Decode.
+func DecodeFile(path string, tolerant bool) (*Decoded, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open FIT file: %w", err)
+	}
+	defer f.Close()
+	return Decode(f, tolerant)
+}
+
+// Decode decodes a FIT activity. See DecodeMessages for the strict and tolerant modes.
+func Decode(r io.Reader, tolerant bool) (*Decoded, error) {
+	messages, c, err := DecodeMessages(r, tolerant)
+	if err != nil {
+		return nil, err
+	}
+	return &Decoded{Activity: filedef.NewActivity(messages...), Messages: len(messages), Corruption: c}, nil
+}
+
+// DecodeMessages decodes the messages of any FIT file.
+//
+// In strict mode any decoder error is returned. In tolerant mode the checksum is not
+// enforced and every message decoded before the first error is kept, so a truncated or
+// corrupt file still yields its leading messages; the error and its position are returned
+// as the Corruption. Tolerant mode only fails if no message could be decoded.
+func DecodeMessages(r io.Reader, tolerant bool) ([]proto.Message, *Corruption, error) {
+	if !tolerant {
+		fit, err := decoder.New(r).Decode()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode FIT file: %w", err)
+		}
+		return fit.Messages, nil, nil
+	}
+
+	collector := &messageCollector{}
+	dec := decoder.New(r,
+		decoder.WithIgnoreChecksum(),
+		decoder.WithMesgListener(collector),
+		decoder.WithBroadcastMesgCopy(),
+	)
+	err := decodeRecovering(dec)
+	if err != nil && len(collector.messages) == 0 {
+		return nil, nil, fmt.Errorf("failed to decode FIT file: %w", err)
+	}
+	if err != nil {
+		return collector.messages, corruption(err), nil
+	}
+	return collector.messages, nil, nil
+}
+
+// decodeRecovering runs the decoder, turning a panic on malformed data into an error.
+func decodeRecovering(dec *decoder.Decoder) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("decoder panic: %v", r)
+		}
+	}()
+	_, err = dec.Decode()
+	return err
+}
+
+// messageCollector keeps every message as soon as it is decoded.
+type messageCollector struct {
+	messages []proto.Message
+}
+
+func (c *messageCollector) OnMesg(mesg proto.Message) {
+	c.messages = append(c.messages, mesg)
+}
+
+var bytePosPattern = regexp.MustCompile(`byte pos: (\d+)`)
+
+// corruption extracts the byte position the decoder reports in its errors.
+func corruption(err error) *Corruption {
+	m := bytePosPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return &Corruption{Offset: -1, Err: err}
+	}
+	n, _ := strconv.ParseInt(m[1], 10, 64)
+	if inner := errors.Unwrap(err); inner != nil {
+		err = inner // drop the decoder's own position prefix
+	}
+	return &Corruption{Offset: n, Err: err}
+}
@@ -0,0 +1,66 @@
+package activity
+
+import (
+	"math"
+	"time"
+
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+// BestEffort returns the fastest time to cover distance meters anywhere in the records,
+// and false if the records never cover it. The time is interpolated to the exact distance.
+func BestEffort(records []*mesgdef.Record, distance float64) (time.Duration, bool) {
+	type point struct {
+		t time.Time
+		d float64
+	}
+	points := make([]point, 0, len(records))
+	for _, rec := range records {
+		if d := rec.DistanceScaled(); !math.IsNaN(d) {
+			points = append(points, point{rec.Timestamp, d})
+		}
+	}
+
+	best := time.Duration(math.MaxInt64)
+	found := false
+	i := 0
+	for j := range points {
+		// Move the start forward while the window still covers the distance.
+		for i+1 < j && points[j].d-points[i+1].d >= distance {
+			i++
+		}
+		covered := points[j].d - points[i].d
+		if covered < distance || covered <= 0 {
+			continue
+		}
+		elapsed := points[j].t.Sub(points[i].t)
+		if t := time.Duration(float64(elapsed) * distance / covered); t < best {
+			best, found = t, true
+		}
+	}
+	if !found {
+		return 0, false
+	}
+	return best, true
+}
+
+// MaxAveragePower returns the highest average power sustained for window, and false if
+// the records carry less than window of power data.
+func MaxAveragePower(records []*mesgdef.Record, window time.Duration) (float64, bool) {
+	series := powerSeries(records)
+	n := int(window.Seconds())
+	if n <= 0 || len(series) < n {
+		return 0, false
+	}
+	var sum, best float64
+	for i, w := range series {
+		sum += w
+		if i >= n {
+			sum -= series[i-n]
+		}
+		if i >= n-1 {
+			best = math.Max(best, sum/float64(n))
+		}
+	}
+	return best, true
+}
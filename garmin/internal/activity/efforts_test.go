@@ -0,0 +1,66 @@
+package activity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+func TestBestEffort(t *testing.T) {
+	// 100 m in the first 10 s, 150 m in the next 10, then 50 m
+	records := []*mesgdef.Record{
+		record(0).SetDistanceScaled(0),
+		record(10).SetDistanceScaled(100),
+		record(20).SetDistanceScaled(250),
+		record(30).SetDistanceScaled(300),
+	}
+
+	tests := []struct {
+		name     string
+		distance float64
+		want     time.Duration
+		found    bool
+	}{
+		{"exact window", 150, 10 * time.Second, true},
+		// 200 m is 4/5 of the 250 m covered in the first 20 s, faster than 200 m in 20 s from 10 s
+		{"interpolated", 200, 16 * time.Second, true},
+		{"whole activity", 300, 30 * time.Second, true},
+		{"longer than the activity", 400, 0, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, found := BestEffort(records, tc.distance)
+			if got != tc.want || found != tc.found {
+				t.Errorf("BestEffort(%v) = %v, %v, want %v, %v", tc.distance, got, found, tc.want, tc.found)
+			}
+		})
+	}
+}
+
+func TestMaxAveragePower(t *testing.T) {
+	records := powerRecords([2]int{0, 100}, [2]int{1, 200}, [2]int{2, 400}, [2]int{3, 300}, [2]int{60, 500})
+
+	tests := []struct {
+		name   string
+		window time.Duration
+		want   float64
+		found  bool
+	}{
+		{"one second", time.Second, 500, true},
+		{"two seconds", 2 * time.Second, 400, true},
+		// The pause is skipped, so the last effort is 400, 300, 500
+		{"across a pause", 3 * time.Second, 400, true},
+		{"whole series", 5 * time.Second, 300, true},
+		{"longer than the series", 6 * time.Second, 0, false},
+		{"zero window", 0, 0, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, found := MaxAveragePower(records, tc.window)
+			if got != tc.want || found != tc.found {
+				t.Errorf("MaxAveragePower(%v) = %v, %v, want %v, %v", tc.window, got, found, tc.want, tc.found)
+			}
+		})
+	}
+}
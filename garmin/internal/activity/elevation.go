@@ -0,0 +1,107 @@
+package activity
+
+import (
+	"math"
+
+	"github.com/muktihari/fit/profile/basetype"
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+// ElevationOptions control how ascent and descent are computed from altitude records.
+//
+// Raw altitude jitters by a meter or more between samples, so summing every change
+// overstates climbing. Garmin filters barometric altitude before totalling; the defaults
+// approximate that: a centered moving average over Window samples, then a change only
+// counts once the smoothed altitude has moved Threshold meters from the last turning point.
+type ElevationOptions struct {
+	Window    int
+	Threshold float64
+}
+
+// DefaultElevationOptions approximate Garmin's barometric filtering.
+var DefaultElevationOptions = ElevationOptions{Window: 5, Threshold: 2}
+
+// Elevation is the total ascent/descent and altitude range of an activity.
+type Elevation struct {
+	AscentM  float64
+	DescentM float64
+	MinM     float64
+	MaxM     float64
+	// FromSession is true when the totals come from the session instead of the records
+	FromSession bool
+	// Samples is the number of altitude records used
+	Samples int
+}
+
+// ElevationOf returns the session's ascent and descent when the file has them, and
+// otherwise computes them from the altitude records using opts.
+func ElevationOf(s *mesgdef.Session, records []*mesgdef.Record, opts ElevationOptions) Elevation {
+	e := ComputeElevation(records, opts)
+	if s != nil && s.TotalAscent != basetype.Uint16Invalid && s.TotalDescent != basetype.Uint16Invalid {
+		e.AscentM = float64(s.TotalAscent)
+		e.DescentM = float64(s.TotalDescent)
+		e.FromSession = true
+	}
+	return e
+}
+
+// ComputeElevation computes ascent, descent, and the altitude range from records.
+func ComputeElevation(records []*mesgdef.Record, opts ElevationOptions) Elevation {
+	altitudes := make([]float64, 0, len(records))
+	for _, rec := range records {
+		if alt := Altitude(rec); !math.IsNaN(alt) {
+			altitudes = append(altitudes, alt)
+		}
+	}
+	e := Elevation{Samples: len(altitudes)}
+	if len(altitudes) == 0 {
+		return e
+	}
+
+	smoothed := movingAverage(altitudes, opts.Window)
+	e.MinM, e.MaxM = smoothed[0], smoothed[0]
+	anchor := smoothed[0]
+	for _, alt := range smoothed[1:] {
+		e.MinM = math.Min(e.MinM, alt)
+		e.MaxM = math.Max(e.MaxM, alt)
+		switch diff := alt - anchor; {
+		case diff >= opts.Threshold && diff > 0:
+			e.AscentM += diff
+			anchor = alt
+		case -diff >= opts.Threshold && diff < 0:
+			e.DescentM -= diff
+			anchor = alt
+		}
+	}
+	return e
+}
+
+// Altitude returns a record's altitude in meters, preferring the enhanced field, or NaN.
+func Altitude(rec *mesgdef.Record) float64 {
+	if rec.EnhancedAltitude != basetype.Uint32Invalid {
+		return rec.EnhancedAltitudeScaled()
+	}
+	if rec.Altitude != basetype.Uint16Invalid {
+		return rec.AltitudeScaled()
+	}
+	return math.NaN()
+}
+
+// movingAverage returns the centered moving average of values over window samples,
+// shrinking the window at the ends. A window of 1 or less returns values unchanged.
+func movingAverage(values []float64, window int) []float64 {
+	if window <= 1 {
+		return values
+	}
+	half := window / 2
+	out := make([]float64, len(values))
+	for i := range values {
+		lo, hi := max(0, i-half), min(len(values), i+half+1)
+		var sum float64
+		for _, v := range values[lo:hi] {
+			sum += v
+		}
+		out[i] = sum / float64(hi-lo)
+	}
+	return out
+}
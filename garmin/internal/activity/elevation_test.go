@@ -0,0 +1,62 @@
+package activity
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+// altitudeRecords returns 1 Hz records at the given altitudes in meters.
+func altitudeRecords(altitudes ...float64) []*mesgdef.Record {
+	records := make([]*mesgdef.Record, len(altitudes))
+	for i, alt := range altitudes {
+		records[i] = record(i).SetEnhancedAltitudeScaled(alt)
+	}
+	return records
+}
+
+func TestComputeElevation(t *testing.T) {
+	tests := []struct {
+		name      string
+		altitudes []float64
+		opts      ElevationOptions
+		want      Elevation
+	}{
+		{"empty", nil, DefaultElevationOptions, Elevation{}},
+		{"climb and descent", []float64{100, 110, 120, 110, 100}, ElevationOptions{Window: 1, Threshold: 2}, Elevation{AscentM: 20, DescentM: 20, MinM: 100, MaxM: 120, Samples: 5}},
+		// 101 and 102 stay within the threshold of their turning points and are not counted
+		{"jitter below the threshold", []float64{100, 101, 103, 102, 99, 104}, ElevationOptions{Window: 1, Threshold: 2}, Elevation{AscentM: 8, DescentM: 4, MinM: 99, MaxM: 104, Samples: 6}},
+		// Smoothed over three samples a 6 m spike becomes 100, 102, 102, 102, 100
+		{"spike smoothed", []float64{100, 100, 106, 100, 100}, ElevationOptions{Window: 3, Threshold: 2}, Elevation{AscentM: 2, DescentM: 2, MinM: 100, MaxM: 102, Samples: 5}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ComputeElevation(altitudeRecords(tc.altitudes...), tc.opts)
+			if math.Abs(got.AscentM-tc.want.AscentM) > 1e-6 || math.Abs(got.DescentM-tc.want.DescentM) > 1e-6 ||
+				math.Abs(got.MinM-tc.want.MinM) > 1e-6 || math.Abs(got.MaxM-tc.want.MaxM) > 1e-6 || got.Samples != tc.want.Samples {
+				t.Errorf("ComputeElevation = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMovingAverage(t *testing.T) {
+	tests := []struct {
+		name   string
+		window int
+		want   []float64
+	}{
+		{"window of one", 1, []float64{1, 2, 3, 4, 5}},
+		{"window of three", 3, []float64{1.5, 2, 3, 4, 4.5}},
+		{"window of five", 5, []float64{2, 2.5, 3, 3.5, 4}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := movingAverage([]float64{1, 2, 3, 4, 5}, tc.window); !slices.Equal(got, tc.want) {
+				t.Errorf("movingAverage = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
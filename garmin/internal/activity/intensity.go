@@ -0,0 +1,124 @@
+package activity
+
+import (
+	"math"
+
+	"github.com/muktihari/fit/profile/basetype"
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+// Intensity thresholds. Heart rate thresholds are fractions of max HR (the ACSM/CDC
+// moderate and vigorous bands); MET thresholds are the standard 3 and 6 METs.
+const (
+	ModerateHRFraction = 0.64
+	VigorousHRFraction = 0.77
+	ModerateMET        = 3.0
+	VigorousMET        = 6.0
+)
+
+// maxSampleGap is the longest time, in seconds, one record is assumed to cover; longer
+// gaps (pauses, lost signal) only count for this long.
+const maxSampleGap = 10
+
+// Intensity minute methods.
+const (
+	IntensityByHeartRate = "heart rate"
+	IntensityByMET       = "MET (speed)"
+)
+
+// IntensityMinutes are the minutes of moderate and vigorous activity.
+type IntensityMinutes struct {
+	Moderate float64
+	Vigorous float64
+	// Method is IntensityByHeartRate, IntensityByMET, or empty if neither was possible
+	Method string
+}
+
+// Total returns intensity minutes as Garmin counts them: vigorous minutes count double.
+func (m IntensityMinutes) Total() float64 {
+	return m.Moderate + 2*m.Vigorous
+}
+
+// IntensityMinutesOf computes intensity minutes over the records. With a max heart rate
+// and heart rate records, each record's time counts as moderate at 64% of max HR and
+// vigorous at 77%. Otherwise speed is converted to METs with the ACSM walking/running
+// equations, counting moderate at 3 METs and vigorous at 6.
+func IntensityMinutesOf(records []*mesgdef.Record, maxHR float64) IntensityMinutes {
+	if maxHR > 0 && hasHeartRate(records) {
+		m := IntensityMinutes{Method: IntensityByHeartRate}
+		eachSample(records, func(rec *mesgdef.Record, seconds float64) {
+			if rec.HeartRate == basetype.Uint8Invalid {
+				return
+			}
+			switch hr := float64(rec.HeartRate) / maxHR; {
+			case hr >= VigorousHRFraction:
+				m.Vigorous += seconds / 60
+			case hr >= ModerateHRFraction:
+				m.Moderate += seconds / 60
+			}
+		})
+		return m
+	}
+
+	m := IntensityMinutes{}
+	eachSample(records, func(rec *mesgdef.Record, seconds float64) {
+		speed := Speed(rec)
+		if math.IsNaN(speed) {
+			return
+		}
+		m.Method = IntensityByMET
+		switch met := METs(speed); {
+		case met >= VigorousMET:
+			m.Vigorous += seconds / 60
+		case met >= ModerateMET:
+			m.Moderate += seconds / 60
+		}
+	})
+	return m
+}
+
+// METs estimates the metabolic equivalent of moving at speed m/s on flat ground using
+// the ACSM equations: walking below 2.2 m/s (VO2 = 0.1·v + 3.5), running above
+// (VO2 = 0.2·v + 3.5), with v in m/min and 1 MET = 3.5 ml/kg/min.
+func METs(speed float64) float64 {
+	v := speed * 60
+	if speed < 2.2 {
+		return (0.1*v + 3.5) / 3.5
+	}
+	return (0.2*v + 3.5) / 3.5
+}
+
+// MaxHeartRate returns the configured max HR, else the 220 − age estimate, else 0.
+func MaxHeartRate(configured, age int) float64 {
+	switch {
+	case configured > 0:
+		return float64(configured)
+	case age > 0:
+		return float64(220 - age)
+	default:
+		return 0
+	}
+}
+
+func hasHeartRate(records []*mesgdef.Record) bool {
+	for _, rec := range records {
+		if rec.HeartRate != basetype.Uint8Invalid {
+			return true
+		}
+	}
+	return false
+}
+
+// eachSample calls fn with each record and the seconds until the next record, capped at
+// maxSampleGap. The last record covers one second.
+func eachSample(records []*mesgdef.Record, fn func(rec *mesgdef.Record, seconds float64)) {
+	for i, rec := range records {
+		seconds := 1.0
+		if i+1 < len(records) {
+			seconds = math.Min(records[i+1].Timestamp.Sub(rec.Timestamp).Seconds(), maxSampleGap)
+		}
+		if seconds > 0 {
+			fn(rec, seconds)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package activity
+
+import (
+	"math"
+	"testing"
+
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+func TestMETs(t *testing.T) {
+	tests := []struct {
+		name  string
+		speed float64
+		want  float64
+	}{
+		{"standing", 0, 1},
+		// 1.25 m/s is 75 m/min: (0.1·75 + 3.5) / 3.5
+		{"walking", 1.25, 11.0 / 3.5},
+		// 3 m/s is 180 m/min: (0.2·180 + 3.5) / 3.5
+		{"running", 3, 39.5 / 3.5},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := METs(tc.speed); math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("METs(%v) = %v, want %v", tc.speed, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMaxHeartRate(t *testing.T) {
+	tests := []struct {
+		name            string
+		configured, age int
+		want            float64
+	}{
+		{"configured", 185, 40, 185},
+		{"from age", 0, 40, 180},
+		{"unknown", 0, 0, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := MaxHeartRate(tc.configured, tc.age); got != tc.want {
+				t.Errorf("MaxHeartRate = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIntensityMinutesOf(t *testing.T) {
+	hr := func(seconds int, bpm uint8) *mesgdef.Record { return record(seconds).SetHeartRate(bpm) }
+	speed := func(seconds int, v float64) *mesgdef.Record { return record(seconds).SetEnhancedSpeedScaled(v) }
+
+	tests := []struct {
+		name    string
+		records []*mesgdef.Record
+		maxHR   float64
+		want    IntensityMinutes
+	}{
+		// With a 200 bpm max, 120 bpm is 60% (light), 140 is 70% (moderate), 160 is 80% (vigorous)
+		{"heart rate", []*mesgdef.Record{hr(0, 120), hr(6, 140), hr(12, 160), hr(18, 100)}, 200, IntensityMinutes{Moderate: 0.1, Vigorous: 0.1, Method: IntensityByHeartRate}},
+		{"heart rate gap capped", []*mesgdef.Record{hr(0, 160), hr(600, 100)}, 200, IntensityMinutes{Vigorous: maxSampleGap / 60.0, Method: IntensityByHeartRate}},
+		// 1.25 m/s walks at 3.1 METs, 3 m/s runs at 11.3
+		{"speed without max HR", []*mesgdef.Record{speed(0, 1.25), speed(6, 3), speed(12, 0)}, 0, IntensityMinutes{Moderate: 0.1, Vigorous: 0.1, Method: IntensityByMET}},
+		{"no data", []*mesgdef.Record{record(0), record(6)}, 200, IntensityMinutes{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := IntensityMinutesOf(tc.records, tc.maxHR)
+			if math.Abs(got.Moderate-tc.want.Moderate) > 1e-9 || math.Abs(got.Vigorous-tc.want.Vigorous) > 1e-9 || got.Method != tc.want.Method {
+				t.Errorf("IntensityMinutesOf = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package activity
+
+import (
+	"math"
+
+	"github.com/muktihari/fit/profile/basetype"
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+// RunningDynamics are the averages of the running dynamics a Garmin HRM-Pro, RD Pod, or
+// watch records. Zero means the metric is not in the file.
+type RunningDynamics struct {
+	GroundContactTimeMs   float64
+	VerticalOscillationMm float64
+	StrideLengthM         float64
+	VerticalRatioPct      float64
+}
+
+// Present reports whether any running dynamics were recorded.
+func (d RunningDynamics) Present() bool {
+	return d.GroundContactTimeMs > 0 || d.VerticalOscillationMm > 0 || d.StrideLengthM > 0 || d.VerticalRatioPct > 0
+}
+
+// RunningDynamicsOf averages running dynamics over the records, falling back to the
+// session averages for metrics the records do not carry.
+func RunningDynamicsOf(s *mesgdef.Session, records []*mesgdef.Record) RunningDynamics {
+	var gct, vo, stride, ratio average
+	for _, rec := range records {
+		if rec.StanceTime != basetype.Uint16Invalid && rec.StanceTime > 0 {
+			gct.add(rec.StanceTimeScaled())
+		}
+		if rec.VerticalOscillation != basetype.Uint16Invalid && rec.VerticalOscillation > 0 {
+			vo.add(rec.VerticalOscillationScaled())
+		}
+		if rec.StepLength != basetype.Uint16Invalid && rec.StepLength > 0 {
+			stride.add(rec.StepLengthScaled() / 1000)
+		}
+		if rec.VerticalRatio != basetype.Uint16Invalid && rec.VerticalRatio > 0 {
+			ratio.add(rec.VerticalRatioScaled())
+		}
+	}
+
+	d := RunningDynamics{
+		GroundContactTimeMs:   gct.value(),
+		VerticalOscillationMm: vo.value(),
+		StrideLengthM:         stride.value(),
+		VerticalRatioPct:      ratio.value(),
+	}
+	if s == nil {
+		return d
+	}
+	if d.GroundContactTimeMs == 0 && s.AvgStanceTime != basetype.Uint16Invalid {
+		d.GroundContactTimeMs = s.AvgStanceTimeScaled()
+	}
+	if d.VerticalOscillationMm == 0 && s.AvgVerticalOscillation != basetype.Uint16Invalid {
+		d.VerticalOscillationMm = s.AvgVerticalOscillationScaled()
+	}
+	if d.StrideLengthM == 0 && s.AvgStepLength != basetype.Uint16Invalid {
+		d.StrideLengthM = s.AvgStepLengthScaled() / 1000
+	}
+	if d.VerticalRatioPct == 0 && s.AvgVerticalRatio != basetype.Uint16Invalid {
+		d.VerticalRatioPct = s.AvgVerticalRatioScaled()
+	}
+	return d
+}
+
+// Power summarizes cycling or running power. Zero means the value is unavailable.
+type Power struct {
+	AvgWatts        float64
+	MaxWatts        float64
+	NormalizedWatts float64
+	// IntensityFactor is normalized power / functional threshold power (FTP)
+	IntensityFactor float64
+	// TSS is the training stress score: hours × IF² × 100
+	TSS float64
+}
+
+// Present reports whether power was recorded.
+func (p Power) Present() bool {
+	return p.AvgWatts > 0 || p.MaxWatts > 0
+}
+
+// normalizedPowerWindow is the rolling average window of normalized power.
+const normalizedPowerWindow = 30
+
+// PowerOf computes power metrics from the records, falling back to the session's
+// average/max power and normalized power when the records carry none. ftp is the
+// functional threshold power in watts; with ftp <= 0 the session's threshold power is
+// used, and intensity factor and TSS are left at zero if neither is known.
+func PowerOf(s *mesgdef.Session, records []*mesgdef.Record, ftp float64) Power {
+	series := powerSeries(records)
+	var p Power
+	if len(series) > 0 {
+		var sum float64
+		for _, w := range series {
+			sum += w
+			p.MaxWatts = math.Max(p.MaxWatts, w)
+		}
+		p.AvgWatts = sum / float64(len(series))
+		p.NormalizedWatts = NormalizedPower(series)
+	}
+
+	if s != nil {
+		if p.AvgWatts == 0 && s.AvgPower != basetype.Uint16Invalid {
+			p.AvgWatts = float64(s.AvgPower)
+		}
+		if p.MaxWatts == 0 && s.MaxPower != basetype.Uint16Invalid {
+			p.MaxWatts = float64(s.MaxPower)
+		}
+		if p.NormalizedWatts == 0 && s.NormalizedPower != basetype.Uint16Invalid {
+			p.NormalizedWatts = float64(s.NormalizedPower)
+		}
+		if ftp <= 0 && s.ThresholdPower != basetype.Uint16Invalid {
+			ftp = float64(s.ThresholdPower)
+		}
+	}
+
+	if ftp > 0 && p.NormalizedWatts > 0 {
+		p.IntensityFactor = p.NormalizedWatts / ftp
+		seconds := float64(len(series))
+		if seconds == 0 && s != nil {
+			seconds = Duration(s).Seconds()
+		}
+		p.TSS = seconds / 3600 * p.IntensityFactor * p.IntensityFactor * 100
+	}
+	return p
+}
+
+// NormalizedPower is the fourth root of the mean of the fourth powers of the 30-second
+// rolling average of a 1 Hz power series. It is zero for series shorter than 30 seconds.
+func NormalizedPower(series []float64) float64 {
+	if len(series) < normalizedPowerWindow {
+		return 0
+	}
+	var window, sum4 float64
+	n := 0
+	for i, w := range series {
+		window += w
+		if i >= normalizedPowerWindow {
+			window -= series[i-normalizedPowerWindow]
+		}
+		if i >= normalizedPowerWindow-1 {
+			avg := window / normalizedPowerWindow
+			sum4 += avg * avg * avg * avg
+			n++
+		}
+	}
+	return math.Pow(sum4/float64(n), 0.25)
+}
+
+// powerSeries resamples record power to one value per second, holding each value until
+// the next record (smart recording writes records irregularly). Gaps longer than
+// maxSampleGap (pauses) are skipped: the record before one covers a single second, so
+// paused time neither dilutes the averages nor counts towards TSS.
+func powerSeries(records []*mesgdef.Record) []float64 {
+	var series []float64
+	var prev *mesgdef.Record
+	for _, rec := range records {
+		if rec.Power == basetype.Uint16Invalid {
+			continue
+		}
+		if prev != nil {
+			gap := int(rec.Timestamp.Sub(prev.Timestamp).Seconds())
+			if gap > maxSampleGap {
+				gap = 1
+			}
+			for i := 0; i < gap; i++ {
+				series = append(series, float64(prev.Power))
+			}
+		}
+		prev = rec
+	}
+	if prev != nil {
+		series = append(series, float64(prev.Power))
+	}
+	return series
+}
+
+type average struct {
+	sum float64
+	n   int
+}
+
+func (a *average) add(v float64) {
+	a.sum += v
+	a.n++
+}
+
+func (a average) value() float64 {
+	if a.n == 0 {
+		return 0
+	}
+	return a.sum / float64(a.n)
+}
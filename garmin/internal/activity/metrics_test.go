@@ -0,0 +1,115 @@
+package activity
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+var start = time.Date(2025, 6, 1, 7, 0, 0, 0, time.UTC)
+
+// record returns an empty record the given number of seconds into the activity.
+func record(seconds int) *mesgdef.Record {
+	return mesgdef.NewRecord(nil).SetTimestamp(start.Add(time.Duration(seconds) * time.Second))
+}
+
+// powerRecords returns one record per (second, watts) pair.
+func powerRecords(points ...[2]int) []*mesgdef.Record {
+	records := make([]*mesgdef.Record, len(points))
+	for i, p := range points {
+		records[i] = record(p[0]).SetPower(uint16(p[1]))
+	}
+	return records
+}
+
+// constantPower returns 1 Hz records holding watts for the given number of seconds.
+func constantPower(watts, seconds int) []*mesgdef.Record {
+	points := make([][2]int, seconds)
+	for i := range points {
+		points[i] = [2]int{i, watts}
+	}
+	return powerRecords(points...)
+}
+
+func TestPowerSeries(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []*mesgdef.Record
+		want    []float64
+	}{
+		{"empty", nil, nil},
+		{"one per second", powerRecords([2]int{0, 100}, [2]int{1, 200}, [2]int{2, 300}), []float64{100, 200, 300}},
+		{"held until the next record", powerRecords([2]int{0, 100}, [2]int{3, 200}), []float64{100, 100, 100, 200}},
+		{"longest held gap", powerRecords([2]int{0, 100}, [2]int{maxSampleGap, 200}), append(slices.Repeat([]float64{100}, maxSampleGap), 200)},
+		{"pause skipped", powerRecords([2]int{0, 100}, [2]int{1, 200}, [2]int{60, 300}), []float64{100, 200, 300}},
+		{"records without power skipped", []*mesgdef.Record{record(0).SetPower(100), record(1), record(2).SetPower(300)}, []float64{100, 100, 300}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := powerSeries(tc.records); !slices.Equal(got, tc.want) {
+				t.Errorf("powerSeries = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizedPower(t *testing.T) {
+	// 30 s at 0 W then 30 s at 300 W: the 31 rolling averages are 10k W for k = 0..30, and
+	// the sum of k⁴ for k = 1..30 is 5,273,999.
+	ramp := append(make([]float64, 30), slices.Repeat([]float64{300}, 30)...)
+
+	tests := []struct {
+		name   string
+		series []float64
+		want   float64
+	}{
+		{"shorter than the window", slices.Repeat([]float64{200}, 29), 0},
+		{"constant", slices.Repeat([]float64{200}, 60), 200},
+		{"ramp", ramp, math.Pow(1e4*5273999/31, 0.25)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := NormalizedPower(tc.series); math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("NormalizedPower = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPowerOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []*mesgdef.Record
+		ftp     float64
+		want    Power
+	}{
+		{"one hour at FTP", constantPower(250, 3600), 250, Power{AvgWatts: 250, MaxWatts: 250, NormalizedWatts: 250, IntensityFactor: 1, TSS: 100}},
+		{"half an hour at 80% of FTP", constantPower(200, 1800), 250, Power{AvgWatts: 200, MaxWatts: 200, NormalizedWatts: 200, IntensityFactor: 0.8, TSS: 32}},
+		{"no FTP", constantPower(200, 60), 0, Power{AvgWatts: 200, MaxWatts: 200, NormalizedWatts: 200}},
+		{"pause excluded", append(constantPower(200, 1800), record(3600).SetPower(200)), 250, Power{AvgWatts: 200, MaxWatts: 200, NormalizedWatts: 200, IntensityFactor: 0.8, TSS: 32 * 1801.0 / 1800}},
+		{"no power", []*mesgdef.Record{record(0), record(1)}, 250, Power{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := PowerOf(nil, tc.records, tc.ftp)
+			fields := []struct {
+				name      string
+				got, want float64
+			}{
+				{"AvgWatts", got.AvgWatts, tc.want.AvgWatts},
+				{"MaxWatts", got.MaxWatts, tc.want.MaxWatts},
+				{"NormalizedWatts", got.NormalizedWatts, tc.want.NormalizedWatts},
+				{"IntensityFactor", got.IntensityFactor, tc.want.IntensityFactor},
+				{"TSS", got.TSS, tc.want.TSS},
+			}
+			for _, f := range fields {
+				if math.Abs(f.got-f.want) > 1e-9 {
+					t.Errorf("%s = %v, want %v", f.name, f.got, f.want)
+				}
+			}
+		})
+	}
+}
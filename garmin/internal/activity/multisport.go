@@ -0,0 +1,177 @@
+package activity
+
+import (
+	"sort"
+	"time"
+
+	"github.com/muktihari/fit/profile/basetype"
+	"github.com/muktihari/fit/profile/filedef"
+	"github.com/muktihari/fit/profile/mesgdef"
+	"github.com/muktihari/fit/profile/typedef"
+)
+
+// SportSession is one session of an activity with the records recorded during it.
+// A triathlon has one per leg, including the transitions between legs.
+type SportSession struct {
+	Session *mesgdef.Session
+	Records []*mesgdef.Record
+}
+
+// IsTransition reports whether the session is a multi-sport transition (T1, T2).
+func (s SportSession) IsTransition() bool {
+	return s.Session.Sport == typedef.SportTransition
+}
+
+// SportSessions splits an activity into its sessions in start order, assigning each
+// record to the session whose time span contains it.
+func SportSessions(a *filedef.Activity) []SportSession {
+	sessions := make([]SportSession, 0, len(a.Sessions))
+	for _, s := range a.Sessions {
+		sessions = append(sessions, SportSession{Session: s})
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Session.StartTime.Before(sessions[j].Session.StartTime)
+	})
+
+	for _, rec := range a.Records {
+		for i := range sessions {
+			s := sessions[i].Session
+			if !rec.Timestamp.Before(s.StartTime) && !rec.Timestamp.After(s.Timestamp) {
+				sessions[i].Records = append(sessions[i].Records, rec)
+				break
+			}
+		}
+	}
+	return sessions
+}
+
+// CombineSessions builds a single session with the totals of a multi-sport activity.
+//
+// Times, distance, calories, ascent/descent, and training load are summed; average heart
+// rate and power are weighted by each session's timer time; maxima are the highest of any
+// session; training effects are the highest of any session. Average cadence is left unset
+// because it is not comparable across sports. Invalid (missing) fields are skipped.
+func CombineSessions(sessions []*mesgdef.Session) *mesgdef.Session {
+	c := mesgdef.NewSession(nil)
+	if len(sessions) == 0 {
+		return c
+	}
+
+	var elapsed, timer, moving, distance float64
+	var hrWeighted, hrTime, powerWeighted, powerTime float64
+	var calories, ascent, descent uint32
+	var hasCalories, hasAscent, hasDescent bool
+	sport := sessions[0].Sport
+	for _, s := range sessions {
+		if c.StartTime.IsZero() || s.StartTime.Before(c.StartTime) {
+			c.StartTime = s.StartTime
+		}
+		if s.Timestamp.After(c.Timestamp) {
+			c.Timestamp = s.Timestamp
+		}
+		if s.Sport != sport {
+			sport = typedef.SportMultisport
+		}
+
+		t := validScaled(s.TotalTimerTime, basetype.Uint32Invalid, s.TotalTimerTimeScaled)
+		timer += t
+		elapsed += validScaled(s.TotalElapsedTime, basetype.Uint32Invalid, s.TotalElapsedTimeScaled)
+		moving += validScaled(s.TotalMovingTime, basetype.Uint32Invalid, s.TotalMovingTimeScaled)
+		distance += validScaled(s.TotalDistance, basetype.Uint32Invalid, s.TotalDistanceScaled)
+
+		if s.TotalCalories != basetype.Uint16Invalid {
+			calories += uint32(s.TotalCalories)
+			hasCalories = true
+		}
+		if s.TotalAscent != basetype.Uint16Invalid {
+			ascent += uint32(s.TotalAscent)
+			hasAscent = true
+		}
+		if s.TotalDescent != basetype.Uint16Invalid {
+			descent += uint32(s.TotalDescent)
+			hasDescent = true
+		}
+		if s.TrainingLoadPeak != basetype.Sint32Invalid {
+			if c.TrainingLoadPeak == basetype.Sint32Invalid {
+				c.TrainingLoadPeak = 0
+			}
+			c.TrainingLoadPeak += s.TrainingLoadPeak
+		}
+
+		if s.AvgHeartRate != basetype.Uint8Invalid && t > 0 {
+			hrWeighted += float64(s.AvgHeartRate) * t
+			hrTime += t
+		}
+		if s.AvgPower != basetype.Uint16Invalid && t > 0 {
+			powerWeighted += float64(s.AvgPower) * t
+			powerTime += t
+		}
+		c.MaxHeartRate = maxValid(c.MaxHeartRate, s.MaxHeartRate, basetype.Uint8Invalid)
+		c.MaxPower = maxValid(c.MaxPower, s.MaxPower, basetype.Uint16Invalid)
+		c.EnhancedMaxSpeed = maxValid(c.EnhancedMaxSpeed, s.EnhancedMaxSpeed, basetype.Uint32Invalid)
+		c.TotalTrainingEffect = maxValid(c.TotalTrainingEffect, s.TotalTrainingEffect, basetype.Uint8Invalid)
+		c.TotalAnaerobicTrainingEffect = maxValid(c.TotalAnaerobicTrainingEffect, s.TotalAnaerobicTrainingEffect, basetype.Uint8Invalid)
+	}
+
+	c.Sport = sport
+	if sport == typedef.SportMultisport {
+		c.SportProfileName = "Multisport"
+	} else {
+		c.SportProfileName = sessions[0].SportProfileName
+	}
+	c.SetTotalElapsedTimeScaled(elapsed)
+	c.SetTotalTimerTimeScaled(timer)
+	if moving > 0 {
+		c.SetTotalMovingTimeScaled(moving)
+	}
+	c.SetTotalDistanceScaled(distance)
+	if timer > 0 {
+		c.SetEnhancedAvgSpeedScaled(distance / timer)
+	}
+	if hasCalories {
+		c.TotalCalories = uint16(min(calories, uint32(basetype.Uint16Invalid-1)))
+	}
+	if hasAscent {
+		c.TotalAscent = uint16(min(ascent, uint32(basetype.Uint16Invalid-1)))
+	}
+	if hasDescent {
+		c.TotalDescent = uint16(min(descent, uint32(basetype.Uint16Invalid-1)))
+	}
+	if hrTime > 0 {
+		c.AvgHeartRate = uint8(hrWeighted/hrTime + 0.5)
+	}
+	if powerTime > 0 {
+		c.AvgPower = uint16(powerWeighted/powerTime + 0.5)
+	}
+	return c
+}
+
+// Duration returns a session's timer time, excluding pauses.
+func Duration(s *mesgdef.Session) time.Duration {
+	return time.Duration(validScaled(s.TotalTimerTime, basetype.Uint32Invalid, s.TotalTimerTimeScaled) * float64(time.Second))
+}
+
+// SessionDistance returns a session's distance in meters, or 0 if it is not recorded.
+func SessionDistance(s *mesgdef.Session) float64 {
+	return validScaled(s.TotalDistance, basetype.Uint32Invalid, s.TotalDistanceScaled)
+}
+
+// validScaled returns scaled() unless raw is the field's invalid value, in which case 0.
+func validScaled[T comparable](raw, invalid T, scaled func() float64) float64 {
+	if raw == invalid {
+		return 0
+	}
+	return scaled()
+}
+
+// maxValid returns the larger of cur and v, treating invalid as missing.
+func maxValid[T uint8 | uint16 | uint32](cur, v, invalid T) T {
+	switch {
+	case v == invalid:
+		return cur
+	case cur == invalid:
+		return v
+	default:
+		return max(cur, v)
+	}
+}
@@ -0,0 +1,88 @@
+package activity
+
+import (
+	"math"
+
+	"github.com/muktihari/fit/profile/basetype"
+	"github.com/muktihari/fit/profile/filedef"
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+// PrimarySession returns the session that summarizes the whole activity: its only
+// session, or the combined totals of a multi-sport activity (see CombineSessions).
+// Truncated files usually lose the session message written at the end of the activity;
+// in that case a session is derived from the records and derived is true.
+func PrimarySession(a *filedef.Activity) (s *mesgdef.Session, derived bool) {
+	switch len(a.Sessions) {
+	case 0:
+	case 1:
+		return a.Sessions[0], false
+	default:
+		return CombineSessions(a.Sessions), false
+	}
+	return SessionFromRecords(a.Records), true
+}
+
+// SessionFromRecords derives the session totals that can be computed from records:
+// start/end time, elapsed time, distance, speed, heart rate, and cadence.
+// Fields that cannot be derived keep their invalid value.
+func SessionFromRecords(records []*mesgdef.Record) *mesgdef.Session {
+	s := mesgdef.NewSession(nil)
+	if len(records) == 0 {
+		return s
+	}
+
+	first, last := records[0], records[len(records)-1]
+	s.StartTime = first.Timestamp
+	s.Timestamp = last.Timestamp
+	elapsed := last.Timestamp.Sub(first.Timestamp).Seconds()
+	s.SetTotalElapsedTimeScaled(elapsed)
+	s.SetTotalTimerTimeScaled(elapsed)
+
+	var hrSum, hrCount, cadSum, cadCount int
+	var maxHR, maxCad uint8
+	var maxSpeed, distance float64
+	for _, rec := range records {
+		if rec.HeartRate != basetype.Uint8Invalid {
+			hrSum += int(rec.HeartRate)
+			hrCount++
+			maxHR = max(maxHR, rec.HeartRate)
+		}
+		if rec.Cadence != basetype.Uint8Invalid {
+			cadSum += int(rec.Cadence)
+			cadCount++
+			maxCad = max(maxCad, rec.Cadence)
+		}
+		if d := rec.DistanceScaled(); !math.IsNaN(d) {
+			distance = math.Max(distance, d)
+		}
+		if v := Speed(rec); !math.IsNaN(v) {
+			maxSpeed = math.Max(maxSpeed, v)
+		}
+	}
+
+	if hrCount > 0 {
+		s.AvgHeartRate = uint8(hrSum / hrCount)
+		s.MaxHeartRate = maxHR
+	}
+	if cadCount > 0 {
+		s.AvgCadence = uint8(cadSum / cadCount)
+		s.MaxCadence = maxCad
+	}
+	if distance > 0 {
+		s.SetTotalDistanceScaled(distance)
+		if elapsed > 0 {
+			s.SetEnhancedAvgSpeedScaled(distance / elapsed)
+		}
+		s.SetEnhancedMaxSpeedScaled(maxSpeed)
+	}
+	return s
+}
+
+// Speed returns a record's speed in m/s, preferring the enhanced field, or NaN.
+func Speed(rec *mesgdef.Record) float64 {
+	if v := rec.EnhancedSpeedScaled(); !math.IsNaN(v) {
+		return v
+	}
+	return rec.SpeedScaled()
+}
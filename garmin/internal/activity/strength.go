@@ -0,0 +1,44 @@
+package activity
+
+import (
+	"github.com/muktihari/fit/profile/basetype"
+	"github.com/muktihari/fit/profile/filedef"
+	"github.com/muktihari/fit/profile/mesgdef"
+	"github.com/muktihari/fit/profile/typedef"
+)
+
+// Strength summarizes the sets of a strength training activity.
+type Strength struct {
+	Sets int
+	Reps int
+	// VolumeKg is the sum of reps × weight over the sets that record a weight
+	VolumeKg float64
+}
+
+// Present reports whether any sets were recorded.
+func (st Strength) Present() bool {
+	return st.Sets > 0
+}
+
+// StrengthOf totals the active sets of an activity. Set messages are not part of the
+// activity file definition, so they are read from its unrelated messages; rest sets are
+// skipped.
+func StrengthOf(a *filedef.Activity) Strength {
+	var st Strength
+	for i := range a.UnrelatedMessages {
+		if a.UnrelatedMessages[i].Num != typedef.MesgNumSet {
+			continue
+		}
+		set := mesgdef.NewSet(&a.UnrelatedMessages[i])
+		if set.SetType != typedef.SetTypeActive {
+			continue
+		}
+		st.Sets++
+		if set.Repetitions == basetype.Uint16Invalid {
+			continue
+		}
+		st.Reps += int(set.Repetitions)
+		st.VolumeKg += float64(set.Repetitions) * validScaled(set.Weight, basetype.Uint16Invalid, set.WeightScaled)
+	}
+	return st
+}
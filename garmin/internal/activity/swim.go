@@ -0,0 +1,71 @@
+package activity
+
+import (
+	"github.com/muktihari/fit/profile/basetype"
+	"github.com/muktihari/fit/profile/mesgdef"
+	"github.com/muktihari/fit/profile/typedef"
+)
+
+// Swim summarizes a pool swim. Zero means the value is unavailable.
+type Swim struct {
+	PoolLengthM   float64
+	ActiveLengths int
+	// StrokesPerLength is the average stroke count of an active length
+	StrokesPerLength float64
+	// StrokeRate is the average swimming cadence in strokes per minute
+	StrokeRate float64
+	// SWOLF is the average of seconds plus strokes per active length; lower is more efficient
+	SWOLF float64
+}
+
+// Present reports whether any swim metrics were recorded.
+func (w Swim) Present() bool {
+	return w.ActiveLengths > 0 || w.StrokesPerLength > 0 || w.StrokeRate > 0
+}
+
+// SwimOf computes swim metrics from the active lengths, falling back to the session's
+// length count, stroke count, and cadence when the file carries no length messages.
+func SwimOf(s *mesgdef.Session, lengths []*mesgdef.Length) Swim {
+	var w Swim
+	var strokes, rate, swolf average
+	for _, l := range lengths {
+		if l.LengthType != typedef.LengthTypeActive {
+			continue
+		}
+		w.ActiveLengths++
+		seconds := validScaled(l.TotalElapsedTime, basetype.Uint32Invalid, l.TotalElapsedTimeScaled)
+		if l.TotalStrokes != basetype.Uint16Invalid {
+			strokes.add(float64(l.TotalStrokes))
+			if seconds > 0 {
+				swolf.add(seconds + float64(l.TotalStrokes))
+			}
+		}
+		if l.AvgSwimmingCadence != basetype.Uint8Invalid && l.AvgSwimmingCadence > 0 {
+			rate.add(float64(l.AvgSwimmingCadence))
+		}
+	}
+	w.StrokesPerLength = strokes.value()
+	w.StrokeRate = rate.value()
+	w.SWOLF = swolf.value()
+	if s == nil {
+		return w
+	}
+
+	w.PoolLengthM = validScaled(s.PoolLength, basetype.Uint16Invalid, s.PoolLengthScaled)
+	if w.ActiveLengths == 0 && s.NumActiveLengths != basetype.Uint16Invalid {
+		w.ActiveLengths = int(s.NumActiveLengths)
+	}
+	if w.StrokesPerLength == 0 && s.AvgStrokeCount != basetype.Uint32Invalid {
+		w.StrokesPerLength = s.AvgStrokeCountScaled()
+	}
+	if w.StrokeRate == 0 && s.AvgCadence != basetype.Uint8Invalid {
+		w.StrokeRate = float64(s.AvgCadence)
+	}
+	if w.SWOLF == 0 && w.ActiveLengths > 0 && w.StrokesPerLength > 0 {
+		// Without per-length times, split the timer time evenly over the active lengths
+		if seconds := validScaled(s.TotalTimerTime, basetype.Uint32Invalid, s.TotalTimerTimeScaled); seconds > 0 {
+			w.SWOLF = seconds/float64(w.ActiveLengths) + w.StrokesPerLength
+		}
+	}
+	return w
+}
@@ -0,0 +1,71 @@
+package activity
+
+import (
+	"github.com/muktihari/fit/profile/basetype"
+	"github.com/muktihari/fit/profile/filedef"
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+// Training stress methods.
+const (
+	StressByPower     = "power"
+	StressByHeartRate = "heart rate"
+)
+
+// TrainingStress is an activity's training stress score (TSS): 100 is an hour at
+// threshold.
+type TrainingStress struct {
+	TSS float64
+	// Method is StressByPower or StressByHeartRate, whichever scored the activity's first
+	// scored leg, or empty if no leg could be scored
+	Method string
+}
+
+// TrainingStressOf scores each leg of the activity by power when it has power and an FTP
+// (ftp, else the file's threshold power; see PowerOf), otherwise by heart rate against
+// thresholdHR, the lactate threshold heart rate. Legs with neither are not scored.
+func TrainingStressOf(a *filedef.Activity, ftp, thresholdHR float64) TrainingStress {
+	// A single session covers every record, whatever time span it reports
+	legs := SportSessions(a)
+	if len(legs) <= 1 {
+		s, _ := PrimarySession(a)
+		legs = []SportSession{{Session: s, Records: a.Records}}
+	}
+
+	var st TrainingStress
+	for _, leg := range legs {
+		if leg.IsTransition() {
+			continue
+		}
+		tss, method := PowerOf(leg.Session, leg.Records, ftp).TSS, StressByPower
+		if tss == 0 {
+			tss, method = HeartRateTSS(leg.Records, thresholdHR), StressByHeartRate
+		}
+		if tss == 0 {
+			continue
+		}
+		st.TSS += tss
+		if st.Method == "" {
+			st.Method = method
+		}
+	}
+	return st
+}
+
+// HeartRateTSS is the heart rate training stress score (hrTSS): each record's time in
+// hours × (heart rate / threshold heart rate)² × 100. It is zero without a threshold or
+// heart rate records.
+func HeartRateTSS(records []*mesgdef.Record, thresholdHR float64) float64 {
+	if thresholdHR <= 0 {
+		return 0
+	}
+	var tss float64
+	eachSample(records, func(rec *mesgdef.Record, seconds float64) {
+		if rec.HeartRate == basetype.Uint8Invalid || rec.HeartRate == 0 {
+			return
+		}
+		ratio := float64(rec.HeartRate) / thresholdHR
+		tss += seconds / 3600 * ratio * ratio * 100
+	})
+	return tss
+}
@@ -0,0 +1,161 @@
+// Package compare aligns two activities over distance or elapsed time and compares them
+// split by split, e.g. two runs of the same route.
+package compare
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/muktihari/fit/profile/basetype"
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+// Alignment axes.
+const (
+	ByDistance = "distance"
+	ByTime     = "time"
+)
+
+// Stats are one activity's values over a split. Missing HR or power is NaN.
+type Stats struct {
+	Seconds   float64
+	Meters    float64
+	HeartRate float64
+	Power     float64
+}
+
+// Pace returns seconds per km, or NaN if no distance was covered.
+func (s Stats) Pace() float64 {
+	if s.Meters <= 0 {
+		return math.NaN()
+	}
+	return s.Seconds / s.Meters * 1000
+}
+
+// Split compares both activities over the same stretch of distance or time.
+type Split struct {
+	// Start and End are in meters (ByDistance) or seconds from the start (ByTime)
+	Start, End float64
+	A, B       Stats
+}
+
+// sample is a record positioned on both axes relative to the activity's start.
+type sample struct {
+	t, d      float64
+	hr, power float64
+}
+
+// Splits aligns a and b on axis and cuts them into splits of size meters or seconds.
+// Splits stop where the shorter activity ends; the last split may be partial.
+func Splits(a, b []*mesgdef.Record, axis string, size float64) ([]Split, error) {
+	if axis != ByDistance && axis != ByTime {
+		return nil, fmt.Errorf("unknown alignment %q (use distance or time)", axis)
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("split size must be positive")
+	}
+	sa, sb := samples(a), samples(b)
+	if len(sa) < 2 || len(sb) < 2 {
+		return nil, fmt.Errorf("both activities need records with timestamps and distance")
+	}
+
+	extent := math.Min(position(sa[len(sa)-1], axis), position(sb[len(sb)-1], axis))
+	var splits []Split
+	for start := 0.0; start < extent; start += size {
+		end := math.Min(start+size, extent)
+		splits = append(splits, Split{
+			Start: start,
+			End:   end,
+			A:     stats(sa, axis, start, end),
+			B:     stats(sb, axis, start, end),
+		})
+	}
+	return splits, nil
+}
+
+func samples(records []*mesgdef.Record) []sample {
+	var out []sample
+	var start float64
+	for _, rec := range records {
+		d := rec.DistanceScaled()
+		if math.IsNaN(d) || rec.Timestamp.IsZero() {
+			continue
+		}
+		t := float64(rec.Timestamp.Unix())
+		if out == nil {
+			start = t
+		}
+		s := sample{t: t - start, d: d, hr: math.NaN(), power: math.NaN()}
+		if rec.HeartRate != basetype.Uint8Invalid {
+			s.hr = float64(rec.HeartRate)
+		}
+		if rec.Power != basetype.Uint16Invalid {
+			s.power = float64(rec.Power)
+		}
+		out = append(out, s)
+	}
+	// Distance is relative to the first sample so routes started mid-recording align.
+	if len(out) > 0 {
+		d0 := out[0].d
+		for i := range out {
+			out[i].d -= d0
+		}
+	}
+	return out
+}
+
+func position(s sample, axis string) float64 {
+	if axis == ByDistance {
+		return s.d
+	}
+	return s.t
+}
+
+// stats computes one activity's values between start and end on axis.
+func stats(samples []sample, axis string, start, end float64) Stats {
+	t0, d0 := interpolate(samples, axis, start)
+	t1, d1 := interpolate(samples, axis, end)
+	st := Stats{Seconds: t1 - t0, Meters: d1 - d0}
+
+	var hrSum, powerSum float64
+	var hrN, powerN int
+	for _, s := range samples {
+		if p := position(s, axis); p < start || p >= end {
+			continue
+		}
+		if !math.IsNaN(s.hr) {
+			hrSum += s.hr
+			hrN++
+		}
+		if !math.IsNaN(s.power) {
+			powerSum += s.power
+			powerN++
+		}
+	}
+	st.HeartRate, st.Power = math.NaN(), math.NaN()
+	if hrN > 0 {
+		st.HeartRate = hrSum / float64(hrN)
+	}
+	if powerN > 0 {
+		st.Power = powerSum / float64(powerN)
+	}
+	return st
+}
+
+// interpolate returns the time and distance where the activity reaches pos on axis.
+func interpolate(samples []sample, axis string, pos float64) (t, d float64) {
+	for i := 1; i < len(samples); i++ {
+		prev, cur := samples[i-1], samples[i]
+		p0, p1 := position(prev, axis), position(cur, axis)
+		if p1 < pos {
+			continue
+		}
+		if p1 == p0 {
+			return cur.t, cur.d
+		}
+		f := (pos - p0) / (p1 - p0)
+		return prev.t + f*(cur.t-prev.t), prev.d + f*(cur.d-prev.d)
+	}
+	last := samples[len(samples)-1]
+	return last.t, last.d
+}
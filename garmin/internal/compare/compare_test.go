@@ -0,0 +1,119 @@
+package compare
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+var start = time.Date(2025, 6, 1, 7, 0, 0, 0, time.UTC)
+
+// steady returns records every 10 s of an activity at a constant speed in m/s, heart rate,
+// and power, starting offset meters into the recording.
+func steady(speed, offset float64, seconds int, hr uint8, power uint16) []*mesgdef.Record {
+	var records []*mesgdef.Record
+	for t := 0; t <= seconds; t += 10 {
+		records = append(records, mesgdef.NewRecord(nil).
+			SetTimestamp(start.Add(time.Duration(t)*time.Second)).
+			SetDistanceScaled(offset+speed*float64(t)).
+			SetHeartRate(hr).
+			SetPower(power))
+	}
+	return records
+}
+
+func TestSplits(t *testing.T) {
+	// A runs 4 m/s (250 s/km) for 300 s; B runs 5 m/s (200 s/km) for 200 s and started
+	// recording 500 m in, so both are aligned from their first record and stop at B's 1000 m
+	a := steady(4, 0, 300, 150, 250)
+	b := steady(5, 500, 200, 160, 300)
+
+	tests := []struct {
+		name string
+		axis string
+		size float64
+		want []Split
+	}{
+		{"by distance", ByDistance, 500, []Split{
+			{Start: 0, End: 500, A: Stats{Seconds: 125, Meters: 500, HeartRate: 150, Power: 250}, B: Stats{Seconds: 100, Meters: 500, HeartRate: 160, Power: 300}},
+			{Start: 500, End: 1000, A: Stats{Seconds: 125, Meters: 500, HeartRate: 150, Power: 250}, B: Stats{Seconds: 100, Meters: 500, HeartRate: 160, Power: 300}},
+		}},
+		// B ends at 200 s, so the last split is partial
+		{"by time", ByTime, 120, []Split{
+			{Start: 0, End: 120, A: Stats{Seconds: 120, Meters: 480, HeartRate: 150, Power: 250}, B: Stats{Seconds: 120, Meters: 600, HeartRate: 160, Power: 300}},
+			{Start: 120, End: 200, A: Stats{Seconds: 80, Meters: 320, HeartRate: 150, Power: 250}, B: Stats{Seconds: 80, Meters: 400, HeartRate: 160, Power: 300}},
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Splits(a, b, tc.axis, tc.size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %d splits, want %d", len(got), len(tc.want))
+			}
+			for i, want := range tc.want {
+				g := got[i]
+				if g.Start != want.Start || g.End != want.End || !statsEqual(g.A, want.A) || !statsEqual(g.B, want.B) {
+					t.Errorf("split %d = %+v, want %+v", i, g, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSplits_Errors(t *testing.T) {
+	records := steady(4, 0, 60, 150, 250)
+	tests := []struct {
+		name string
+		a, b []*mesgdef.Record
+		axis string
+		size float64
+	}{
+		{"unknown axis", records, records, "laps", 100},
+		{"zero size", records, records, ByDistance, 0},
+		{"too few records", records, records[:1], ByDistance, 100},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Splits(tc.a, tc.b, tc.axis, tc.size); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestStats_Pace(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats Stats
+		want  float64
+	}{
+		{"5 km in 25 min", Stats{Seconds: 1500, Meters: 5000}, 300},
+		{"400 m in 90 s", Stats{Seconds: 90, Meters: 400}, 225},
+		{"no distance", Stats{Seconds: 60}, math.NaN()},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.stats.Pace(); !floatEqual(got, tc.want) {
+				t.Errorf("Pace = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func statsEqual(a, b Stats) bool {
+	return floatEqual(a.Seconds, b.Seconds) && floatEqual(a.Meters, b.Meters) &&
+		floatEqual(a.HeartRate, b.HeartRate) && floatEqual(a.Power, b.Power)
+}
+
+// floatEqual compares to within rounding, treating NaNs as equal.
+func floatEqual(a, b float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	return math.Abs(a-b) < 1e-6
+}
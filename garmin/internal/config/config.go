@@ -1,38 +1,52 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
+
+	phiteconfig "github.com/JerkyTreats/PHITE/config"
 )
 
-// GarminConfig holds all config under the `garmin` object in ~/.phite/config.json
-type GarminConfig struct {
-	// Add Garmin-specific config fields here, e.g.:
-	UserWeightKg   float64 `json:"user_weight_kg"`
-	UserSex        string  `json:"user_sex"`
-	UserAge        int     `json:"user_age"`
-	SweatRateLph   float64 `json:"sweat_rate_lph"`
-	// Add more as needed
-}
+// Section is the object in ~/.phite/config.json holding the garmin settings.
+const Section = "garmin"
 
-type configFile struct {
-	Garmin GarminConfig `json:"garmin"`
+// GarminConfig holds all config under the `garmin` object in ~/.phite/config.json.
+// Each field can be overridden by PHITE_GARMIN_<FIELD> (e.g. PHITE_GARMIN_FTP_WATTS) and
+// by the active profile (see the shared config package).
+type GarminConfig struct {
+	UserWeightKg       float64 `json:"user_weight_kg"`
+	UserSex            string  `json:"user_sex"`
+	UserAge            int     `json:"user_age"`
+	SweatRateLph       float64 `json:"sweat_rate_lph"`
+	MaxHeartRate       int     `json:"max_heart_rate"`       // for intensity minutes; defaults to 220 - user_age
+	FTPWatts           float64 `json:"ftp_watts"`            // functional threshold power, for intensity factor and TSS
+	ThresholdHeartRate int     `json:"threshold_heart_rate"` // lactate threshold heart rate, for TSS without power
+	// Altitude smoothing for ascent/descent computed from records; zero uses the defaults
+	ElevationWindow     int     `json:"elevation_window"`      // moving average window, in samples
+	ElevationThresholdM float64 `json:"elevation_threshold_m"` // minimum climb/drop that counts, in meters
+	// Summary section names by sport or "sport/sub_sport", adding or replacing templates
+	SportTemplates map[string][]string `json:"sport_templates"`
 }
 
-// LoadGarminConfig loads the garmin config from ~/.phite/config.json
+// LoadGarminConfig loads the garmin config from ~/.phite/config.json and the environment.
+// A missing config file yields zero values.
 func LoadGarminConfig() (*GarminConfig, error) {
-	configPath := filepath.Join(os.Getenv("HOME"), ".phite", "config.json")
-	data, err := ioutil.ReadFile(configPath)
+	c, err := phiteconfig.Load(phiteconfig.Options{Section: Section})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
-	var cfg configFile
-	err = json.Unmarshal(data, &cfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	cfg := &GarminConfig{
+		UserWeightKg:        c.Float64("user_weight_kg"),
+		UserSex:             c.String("user_sex"),
+		UserAge:             c.Int("user_age"),
+		SweatRateLph:        c.Float64("sweat_rate_lph"),
+		MaxHeartRate:        c.Int("max_heart_rate"),
+		FTPWatts:            c.Float64("ftp_watts"),
+		ThresholdHeartRate:  c.Int("threshold_heart_rate"),
+		ElevationWindow:     c.Int("elevation_window"),
+		ElevationThresholdM: c.Float64("elevation_threshold_m"),
+	}
+	if err := c.Unmarshal("sport_templates", &cfg.SportTemplates); err != nil {
+		return nil, err
 	}
-	return &cfg.Garmin, nil
+	return cfg, nil
 }
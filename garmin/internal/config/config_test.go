@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadGarminConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string // contents of ~/.phite/config.json; empty for no file
+		env     map[string]string
+		want    GarminConfig
+		wantErr bool
+	}{
+		{name: "no config file", want: GarminConfig{}},
+		{
+			name: "garmin section",
+			file: `{"garmin": {"user_weight_kg": 72.5, "user_sex": "female", "user_age": 41, "ftp_watts": 250, "threshold_heart_rate": 168}}`,
+			want: GarminConfig{UserWeightKg: 72.5, UserSex: "female", UserAge: 41, FTPWatts: 250, ThresholdHeartRate: 168},
+		},
+		{
+			name: "other sections ignored",
+			file: `{"logging": {"level": "debug"}, "ftp_watts": 300, "garmin": {"max_heart_rate": 185}}`,
+			want: GarminConfig{MaxHeartRate: 185},
+		},
+		{
+			name: "environment overrides the file",
+			file: `{"garmin": {"ftp_watts": 250, "elevation_window": 5}}`,
+			env:  map[string]string{"PHITE_GARMIN_FTP_WATTS": "265", "PHITE_GARMIN_ELEVATION_THRESHOLD_M": "2.5"},
+			want: GarminConfig{FTPWatts: 265, ElevationWindow: 5, ElevationThresholdM: 2.5},
+		},
+		{
+			name: "active profile",
+			file: `{"garmin": {"ftp_watts": 250}, "profile": "race", "profiles": {"race": {"garmin": {"ftp_watts": 270}}}}`,
+			want: GarminConfig{FTPWatts: 270},
+		},
+		{
+			name: "sport templates",
+			file: `{"garmin": {"sport_templates": {"rowing": ["timing", "heart_rate"], "cycling/indoor_cycling": ["timing", "power"]}}}`,
+			want: GarminConfig{SportTemplates: map[string][]string{
+				"rowing":                 {"timing", "heart_rate"},
+				"cycling/indoor_cycling": {"timing", "power"},
+			}},
+		},
+		{name: "malformed file", file: `{"garmin": {`, wantErr: true},
+		{name: "malformed sport templates", file: `{"garmin": {"sport_templates": {"rowing": "timing"}}}`, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("PHITE_PROFILE", "")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			if tc.file != "" {
+				dir := filepath.Join(home, ".phite")
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(tc.file), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := LoadGarminConfig()
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("LoadGarminConfig = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadGarminConfig: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tc.want) {
+				t.Errorf("LoadGarminConfig = %+v, want %+v", *got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Package export writes FIT record messages as CSV or JSON for analysis in pandas or a
+// spreadsheet.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	garminactivity "garmin/internal/activity"
+
+	"github.com/muktihari/fit/profile/basetype"
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+// Output formats.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Row is one record. Nil fields were not recorded.
+type Row struct {
+	Timestamp time.Time `json:"timestamp"`
+	Lat       *float64  `json:"lat"`      // degrees
+	Lon       *float64  `json:"lon"`      // degrees
+	Alt       *float64  `json:"alt"`      // meters
+	HeartRate *float64  `json:"hr"`       // bpm
+	Cadence   *float64  `json:"cadence"`  // rpm (spm for running, per leg)
+	Speed     *float64  `json:"speed"`    // m/s
+	Power     *float64  `json:"power"`    // watts
+	Distance  *float64  `json:"distance"` // meters
+}
+
+// columns is the CSV header, in Row field order.
+var columns = []string{"timestamp", "lat", "lon", "alt", "hr", "cadence", "speed", "power", "distance"}
+
+// Rows converts records to rows.
+func Rows(records []*mesgdef.Record) []Row {
+	rows := make([]Row, 0, len(records))
+	for _, rec := range records {
+		row := Row{
+			Timestamp: rec.Timestamp.UTC(),
+			Lat:       number(rec.PositionLatDegrees()),
+			Lon:       number(rec.PositionLongDegrees()),
+			Alt:       number(garminactivity.Altitude(rec)),
+			Speed:     number(garminactivity.Speed(rec)),
+			Distance:  number(rec.DistanceScaled()),
+		}
+		if rec.HeartRate != basetype.Uint8Invalid {
+			row.HeartRate = number(float64(rec.HeartRate))
+		}
+		if rec.Cadence != basetype.Uint8Invalid {
+			row.Cadence = number(float64(rec.Cadence))
+		}
+		if rec.Power != basetype.Uint16Invalid {
+			row.Power = number(float64(rec.Power))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Write writes records to w in the given format.
+func Write(w io.Writer, records []*mesgdef.Record, format string) error {
+	rows := Rows(records)
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, rows)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	default:
+		return fmt.Errorf("unsupported export format %q (use csv or json)", format)
+	}
+}
+
+func writeCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		record := []string{r.Timestamp.Format(time.RFC3339)}
+		for _, v := range []*float64{r.Lat, r.Lon, r.Alt, r.HeartRate, r.Cadence, r.Speed, r.Power, r.Distance} {
+			record = append(record, cell(v))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// cell formats a value for CSV; missing values are empty so pandas reads them as NaN.
+func cell(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func number(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
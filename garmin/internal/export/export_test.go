@@ -0,0 +1,125 @@
+package export
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+var start = time.Date(2025, 6, 1, 7, 0, 0, 0, time.UTC)
+
+// record returns an empty record the given number of seconds into the activity.
+func record(seconds int) *mesgdef.Record {
+	return mesgdef.NewRecord(nil).SetTimestamp(start.Add(time.Duration(seconds) * time.Second))
+}
+
+// full returns a record with every exported field set, to values that survive the FIT
+// scaling exactly.
+func full(seconds int) *mesgdef.Record {
+	return record(seconds).
+		SetPositionLatDegrees(45).
+		SetPositionLongDegrees(-90).
+		SetEnhancedAltitudeScaled(100).
+		SetHeartRate(142).
+		SetCadence(88).
+		SetEnhancedSpeedScaled(3.5).
+		SetPower(210).
+		SetDistanceScaled(1234.5)
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []*mesgdef.Record
+		format  string
+		want    string
+	}{
+		{"csv empty", nil, FormatCSV, "timestamp,lat,lon,alt,hr,cadence,speed,power,distance\n"},
+		{
+			"csv every field",
+			[]*mesgdef.Record{full(0)},
+			FormatCSV,
+			"timestamp,lat,lon,alt,hr,cadence,speed,power,distance\n" +
+				"2025-06-01T07:00:00Z,45,-90,100,142,88,3.5,210,1234.5\n",
+		},
+		{
+			"csv missing values left empty",
+			[]*mesgdef.Record{record(0).SetHeartRate(130), record(1).SetPower(250).SetDistanceScaled(10)},
+			FormatCSV,
+			"timestamp,lat,lon,alt,hr,cadence,speed,power,distance\n" +
+				"2025-06-01T07:00:00Z,,,,130,,,,\n" +
+				"2025-06-01T07:00:01Z,,,,,,,250,10\n",
+		},
+		{
+			"csv pause kept as recorded",
+			[]*mesgdef.Record{record(0).SetPower(200), record(600).SetPower(180)},
+			FormatCSV,
+			"timestamp,lat,lon,alt,hr,cadence,speed,power,distance\n" +
+				"2025-06-01T07:00:00Z,,,,,,,200,\n" +
+				"2025-06-01T07:10:00Z,,,,,,,180,\n",
+		},
+		{"json empty", nil, FormatJSON, "[]\n"},
+		{
+			"json missing values null",
+			[]*mesgdef.Record{record(0).SetHeartRate(130)},
+			FormatJSON,
+			`[
+  {
+    "timestamp": "2025-06-01T07:00:00Z",
+    "lat": null,
+    "lon": null,
+    "alt": null,
+    "hr": 130,
+    "cadence": null,
+    "speed": null,
+    "power": null,
+    "distance": null
+  }
+]
+`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := Write(&out, tc.records, tc.format); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if got := out.String(); got != tc.want {
+				t.Errorf("Write =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWrite_UnsupportedFormat(t *testing.T) {
+	var out strings.Builder
+	if err := Write(&out, []*mesgdef.Record{full(0)}, "xlsx"); err == nil {
+		t.Error("Write with an unsupported format succeeded")
+	}
+	if out.Len() != 0 {
+		t.Errorf("Write with an unsupported format wrote %q", out.String())
+	}
+}
+
+func TestRows_TimestampsInUTC(t *testing.T) {
+	local := time.FixedZone("UTC-7", -7*3600)
+	rec := mesgdef.NewRecord(nil).SetTimestamp(start.In(local))
+	rows := Rows([]*mesgdef.Record{rec})
+	if len(rows) != 1 {
+		t.Fatalf("Rows returned %d rows, want 1", len(rows))
+	}
+	if rows[0].Timestamp.Location() != time.UTC || !rows[0].Timestamp.Equal(start) {
+		t.Errorf("Timestamp = %v, want %v", rows[0].Timestamp, start)
+	}
+	data, err := json.Marshal(rows[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"timestamp":"2025-06-01T07:00:00Z"`) {
+		t.Errorf("JSON = %s, want the UTC timestamp", data)
+	}
+}
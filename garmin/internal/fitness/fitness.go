@@ -0,0 +1,104 @@
+// Package fitness models training load over time from per-activity training stress
+// scores: chronic training load (CTL, fitness), acute training load (ATL, fatigue), and
+// training stress balance (TSB, form).
+package fitness
+
+import (
+	"sort"
+	"time"
+)
+
+// Load time constants in days, the conventional 42-day fitness and 7-day fatigue windows.
+const (
+	CTLDays = 42
+	ATLDays = 7
+)
+
+// Activity is one scored activity.
+type Activity struct {
+	Date   time.Time // start time
+	TSS    float64
+	Method string // how TSS was scored, e.g. "power" or "heart rate"
+	Source string // FIT file
+}
+
+// Day is one point of the form curve.
+type Day struct {
+	Date time.Time `json:"date"`
+	TSS  float64   `json:"tss"` // total TSS of the day's activities
+	CTL  float64   `json:"ctl"` // fitness: exponentially weighted average TSS over CTLDays
+	ATL  float64   `json:"atl"` // fatigue: exponentially weighted average TSS over ATLDays
+	// TSB is form going into the day: the previous day's CTL - ATL
+	TSB float64 `json:"tsb"`
+}
+
+// Form bands of TSB.
+const (
+	FormTransition  = "transition" // well rested; fitness is fading
+	FormFresh       = "fresh"      // ready to race
+	FormNeutral     = "neutral"
+	FormOptimal     = "optimal" // productive training load
+	FormOverreached = "overreaching"
+)
+
+// Form names the band TSB falls in.
+func Form(tsb float64) string {
+	switch {
+	case tsb > 25:
+		return FormTransition
+	case tsb > 5:
+		return FormFresh
+	case tsb > -10:
+		return FormNeutral
+	case tsb > -30:
+		return FormOptimal
+	default:
+		return FormOverreached
+	}
+}
+
+// Series computes the daily form curve from the first activity through until (inclusive),
+// in loc's calendar days. Loads start at zero, so CTL needs several weeks of history to be
+// meaningful. Each day updates load L with L += (TSS - L) / days.
+func Series(activities []Activity, until time.Time, loc *time.Location) []Day {
+	if len(activities) == 0 {
+		return nil
+	}
+	daily := make(map[time.Time]float64)
+	first := time.Time{}
+	for _, a := range activities {
+		d := day(a.Date, loc)
+		daily[d] += a.TSS
+		if first.IsZero() || d.Before(first) {
+			first = d
+		}
+	}
+	last := day(until, loc)
+	for d := range daily {
+		if d.After(last) {
+			last = d
+		}
+	}
+
+	var out []Day
+	var ctl, atl float64
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		tss := daily[d]
+		tsb := ctl - atl
+		ctl += (tss - ctl) / CTLDays
+		atl += (tss - atl) / ATLDays
+		out = append(out, Day{Date: d, TSS: tss, CTL: ctl, ATL: atl, TSB: tsb})
+	}
+	return out
+}
+
+// SortByDate sorts activities by start time.
+func SortByDate(activities []Activity) {
+	sort.SliceStable(activities, func(i, j int) bool { return activities[i].Date.Before(activities[j].Date) })
+}
+
+// day returns midnight of t's calendar day in loc.
+func day(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
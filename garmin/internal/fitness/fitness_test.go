@@ -0,0 +1,152 @@
+package fitness
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var utc = time.UTC
+
+// at returns the given hour of a June 2025 day in loc.
+func at(day, hour int, loc *time.Location) time.Time {
+	return time.Date(2025, 6, day, hour, 0, 0, 0, loc)
+}
+
+func TestForm(t *testing.T) {
+	tests := []struct {
+		tsb  float64
+		want string
+	}{
+		{30, FormTransition},
+		{25, FormFresh},
+		{10, FormFresh},
+		{5, FormNeutral},
+		{0, FormNeutral},
+		{-10, FormOptimal},
+		{-29.9, FormOptimal},
+		{-30, FormOverreached},
+		{-80, FormOverreached},
+	}
+	for _, tc := range tests {
+		if got := Form(tc.tsb); got != tc.want {
+			t.Errorf("Form(%v) = %q, want %q", tc.tsb, got, tc.want)
+		}
+	}
+}
+
+func TestSeries(t *testing.T) {
+	// One 100 TSS day: CTL and ATL each step a 1/CTLDays and 1/ATLDays of the way there
+	ctl1, atl1 := 100.0/CTLDays, 100.0/ATLDays
+	// then decay by (1 - 1/days) on each rest day
+	ctl2, atl2 := ctl1*(1-1.0/CTLDays), atl1*(1-1.0/ATLDays)
+
+	tests := []struct {
+		name       string
+		activities []Activity
+		until      time.Time
+		loc        *time.Location
+		want       []Day
+	}{
+		{"no activities", nil, at(10, 12, utc), utc, nil},
+		{
+			"one activity through its own day",
+			[]Activity{{Date: at(1, 7, utc), TSS: 100}},
+			at(1, 20, utc), utc,
+			[]Day{{Date: at(1, 0, utc), TSS: 100, CTL: ctl1, ATL: atl1}},
+		},
+		{
+			"rest days decay the loads",
+			[]Activity{{Date: at(1, 7, utc), TSS: 100}},
+			at(3, 9, utc), utc,
+			[]Day{
+				{Date: at(1, 0, utc), TSS: 100, CTL: ctl1, ATL: atl1},
+				{Date: at(2, 0, utc), CTL: ctl2, ATL: atl2, TSB: ctl1 - atl1},
+				{Date: at(3, 0, utc), CTL: ctl2 * (1 - 1.0/CTLDays), ATL: atl2 * (1 - 1.0/ATLDays), TSB: ctl2 - atl2},
+			},
+		},
+		{
+			"activities of one day add up",
+			[]Activity{{Date: at(1, 7, utc), TSS: 60}, {Date: at(1, 18, utc), TSS: 40}},
+			at(1, 0, utc), utc,
+			[]Day{{Date: at(1, 0, utc), TSS: 100, CTL: ctl1, ATL: atl1}},
+		},
+		{
+			"until before the last activity",
+			[]Activity{{Date: at(2, 7, utc), TSS: 100}, {Date: at(1, 7, utc), TSS: 0}},
+			at(1, 0, utc), utc,
+			[]Day{
+				{Date: at(1, 0, utc)},
+				{Date: at(2, 0, utc), TSS: 100, CTL: ctl1, ATL: atl1},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Series(tc.activities, tc.until, tc.loc)
+			if len(got) != len(tc.want) {
+				t.Fatalf("Series returned %d days, want %d: %+v", len(got), len(tc.want), got)
+			}
+			for i, want := range tc.want {
+				daysEqual(t, got[i], want)
+			}
+		})
+	}
+}
+
+func TestSeries_LocalDays(t *testing.T) {
+	// 23:30 on June 1 in UTC-7 is 06:30 on June 2 in UTC; the day is the local one
+	pacific := time.FixedZone("UTC-7", -7*3600)
+	late := time.Date(2025, 6, 1, 23, 30, 0, 0, pacific)
+	early := time.Date(2025, 6, 2, 6, 0, 0, 0, pacific)
+	activities := []Activity{{Date: late.UTC(), TSS: 50}, {Date: early.UTC(), TSS: 70}}
+
+	local := Series(activities, early, pacific)
+	if len(local) != 2 {
+		t.Fatalf("Series in UTC-7 returned %d days, want 2: %+v", len(local), local)
+	}
+	if !local[0].Date.Equal(at(1, 0, pacific)) || local[0].TSS != 50 || local[1].TSS != 70 {
+		t.Errorf("Series in UTC-7 = %+v, want 50 TSS on June 1 and 70 on June 2", local)
+	}
+
+	inUTC := Series(activities, early, utc)
+	if len(inUTC) != 1 || inUTC[0].TSS != 120 {
+		t.Errorf("Series in UTC = %+v, want both activities on June 2", inUTC)
+	}
+}
+
+func TestSortByDate(t *testing.T) {
+	activities := []Activity{
+		{Date: at(3, 7, utc), Source: "c.fit"},
+		{Date: at(1, 7, utc), Source: "a.fit"},
+		{Date: at(3, 7, utc), Source: "d.fit"},
+		{Date: at(2, 7, utc), Source: "b.fit"},
+	}
+	SortByDate(activities)
+	for i, want := range []string{"a.fit", "b.fit", "c.fit", "d.fit"} {
+		if activities[i].Source != want {
+			t.Errorf("activities[%d] = %s, want %s", i, activities[i].Source, want)
+		}
+	}
+}
+
+func daysEqual(t *testing.T, got, want Day) {
+	t.Helper()
+	if !got.Date.Equal(want.Date) {
+		t.Errorf("Date = %v, want %v", got.Date, want.Date)
+	}
+	fields := []struct {
+		name      string
+		got, want float64
+	}{
+		{"TSS", got.TSS, want.TSS},
+		{"CTL", got.CTL, want.CTL},
+		{"ATL", got.ATL, want.ATL},
+		{"TSB", got.TSB, want.TSB},
+	}
+	for _, f := range fields {
+		if math.Abs(f.got-f.want) > 1e-9 {
+			t.Errorf("%s on %s = %v, want %v", f.name, want.Date.Format(time.DateOnly), f.got, f.want)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// Package records tracks personal records (PRs) across ingested activities.
+package records
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	garminactivity "garmin/internal/activity"
+
+	"github.com/muktihari/fit/profile/filedef"
+	"github.com/muktihari/fit/profile/typedef"
+)
+
+// Tracked records.
+const (
+	Fastest1K     = "Fastest 1K"
+	Fastest5K     = "Fastest 5K"
+	Fastest10K    = "Fastest 10K"
+	LongestRide   = "Longest ride"
+	Max20MinPower = "Max 20-min power"
+)
+
+// Record units.
+const (
+	unitSeconds = "s"
+	unitMeters  = "m"
+	unitWatts   = "W"
+)
+
+const storeVersion = 1
+
+// runningEfforts are the distances with a fastest-time record, in meters.
+var runningEfforts = []struct {
+	name     string
+	distance float64
+}{
+	{Fastest1K, 1000},
+	{Fastest5K, 5000},
+	{Fastest10K, 10000},
+}
+
+// Record is a personal record and the activity that set it.
+type Record struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+	// LowerIsBetter is true for times
+	LowerIsBetter bool      `json:"lower_is_better"`
+	Activity      string    `json:"activity"`
+	Date          time.Time `json:"date"`
+}
+
+// Better reports whether r beats other.
+func (r Record) Better(other Record) bool {
+	if r.LowerIsBetter {
+		return r.Value < other.Value
+	}
+	return r.Value > other.Value
+}
+
+// String formats the record value: times as h:mm:ss, distances in km, power in watts.
+func (r Record) String() string {
+	switch r.Unit {
+	case unitSeconds:
+		d := time.Duration(r.Value * float64(time.Second)).Round(time.Second)
+		h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+		if h > 0 {
+			return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+		}
+		return fmt.Sprintf("%d:%02d", m, s)
+	case unitMeters:
+		return fmt.Sprintf("%.2f km", r.Value/1000)
+	default:
+		return fmt.Sprintf("%.0f %s", r.Value, r.Unit)
+	}
+}
+
+// Change is a new personal record. Previous is nil for the first record of its kind.
+type Change struct {
+	New      Record
+	Previous *Record
+}
+
+// Store is the ingested activity database: the current records and the activities seen.
+type Store struct {
+	Version    int               `json:"version"`
+	Records    map[string]Record `json:"records"`
+	Activities map[string]string `json:"activities"` // activity key -> source file
+
+	path string
+}
+
+// DefaultPath returns ~/.phite/garmin/records.json, next to the PHITE config.
+func DefaultPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".phite", "garmin", "records.json")
+}
+
+// Open loads the store at path, or returns an empty one if the file does not exist.
+func Open(path string) (*Store, error) {
+	s := &Store{Version: storeVersion, Records: map[string]Record{}, Activities: map[string]string{}, path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read records file: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse records file: %w", err)
+	}
+	if s.Records == nil {
+		s.Records = map[string]Record{}
+	}
+	if s.Activities == nil {
+		s.Activities = map[string]string{}
+	}
+	return s, nil
+}
+
+// Save writes the store back to its file.
+func (s *Store) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create records directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal records: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write records file: %w", err)
+	}
+	return nil
+}
+
+// Sorted returns the current records by name.
+func (s *Store) Sorted() []Record {
+	out := make([]Record, 0, len(s.Records))
+	for _, r := range s.Records {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Sources returns the files of the ingested activities, sorted.
+func (s *Store) Sources() []string {
+	out := make([]string, 0, len(s.Activities))
+	for _, source := range s.Activities {
+		out = append(out, source)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Ingest computes the activity's efforts and updates the records it beats. It returns the
+// new records, and ingested=false if the activity was already in the store.
+func (s *Store) Ingest(a *filedef.Activity, source string) (changes []Change, ingested bool) {
+	key := activityKey(a, source)
+	if _, seen := s.Activities[key]; seen {
+		return nil, false
+	}
+	s.Activities[key] = source
+
+	for _, candidate := range Efforts(a, source) {
+		prev, exists := s.Records[candidate.Name]
+		if exists && !candidate.Better(prev) {
+			continue
+		}
+		change := Change{New: candidate}
+		if exists {
+			change.Previous = &prev
+		}
+		s.Records[candidate.Name] = candidate
+		changes = append(changes, change)
+	}
+	return changes, true
+}
+
+// Efforts computes the activity's candidate records from each of its sessions, so every
+// leg of a multi-sport activity counts for its own sport.
+func Efforts(a *filedef.Activity, source string) []Record {
+	best := map[string]Record{}
+	offer := func(r Record) {
+		if prev, ok := best[r.Name]; !ok || r.Better(prev) {
+			best[r.Name] = r
+		}
+	}
+
+	for _, leg := range garminactivity.SportSessions(a) {
+		date := leg.Session.StartTime
+		switch leg.Session.Sport {
+		case typedef.SportRunning:
+			for _, e := range runningEfforts {
+				if t, ok := garminactivity.BestEffort(leg.Records, e.distance); ok {
+					offer(Record{Name: e.name, Value: t.Seconds(), Unit: unitSeconds, LowerIsBetter: true, Activity: source, Date: date})
+				}
+			}
+		case typedef.SportCycling:
+			if m := garminactivity.SessionDistance(leg.Session); m > 0 {
+				offer(Record{Name: LongestRide, Value: m, Unit: unitMeters, Activity: source, Date: date})
+			}
+			if w, ok := garminactivity.MaxAveragePower(leg.Records, 20*time.Minute); ok && w > 0 {
+				offer(Record{Name: Max20MinPower, Value: w, Unit: unitWatts, Activity: source, Date: date})
+			}
+		}
+	}
+
+	out := make([]Record, 0, len(best))
+	for _, r := range best {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// activityKey identifies an activity independent of its file name, so re-exported or
+// renamed copies are not ingested twice.
+func activityKey(a *filedef.Activity, source string) string {
+	if a.FileId.TimeCreated.IsZero() {
+		return "file:" + source
+	}
+	return fmt.Sprintf("%d/%d/%s", a.FileId.Manufacturer, a.FileId.SerialNumber, a.FileId.TimeCreated.UTC().Format(time.RFC3339))
+}
+
+// FitFiles expands the arguments into FIT files; directories contribute their *.fit files.
+func FitFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, absPath(arg))
+			continue
+		}
+		entries, err := os.ReadDir(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.IsDir() && strings.EqualFold(filepath.Ext(e.Name()), ".fit") {
+				files = append(files, absPath(filepath.Join(arg, e.Name())))
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// absPath records activities by absolute path so the database is usable from any directory.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,270 @@
+package records
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/muktihari/fit/profile/filedef"
+	"github.com/muktihari/fit/profile/mesgdef"
+	"github.com/muktihari/fit/profile/typedef"
+)
+
+var start = time.Date(2025, 6, 1, 7, 0, 0, 0, time.UTC)
+
+// leg is one session of a test activity: a sport held for seconds at a constant speed
+// and, when watts is set, power, from offset seconds into the activity.
+type leg struct {
+	sport         typedef.Sport
+	offset        int
+	seconds       int
+	speed         float64 // m/s
+	watts         uint16
+	sessionMeters float64 // total distance of the session message; 0 leaves it unset
+}
+
+// activity builds an activity of 1 Hz records from its legs.
+func activity(created time.Time, legs ...leg) *filedef.Activity {
+	a := filedef.NewActivity()
+	a.FileId = *mesgdef.NewFileId(nil).SetManufacturer(typedef.ManufacturerGarmin).SetSerialNumber(42).SetTimeCreated(created)
+	for _, l := range legs {
+		from := start.Add(time.Duration(l.offset) * time.Second)
+		s := mesgdef.NewSession(nil).SetSport(l.sport).SetStartTime(from).SetTimestamp(from.Add(time.Duration(l.seconds) * time.Second))
+		if l.sessionMeters > 0 {
+			s.SetTotalDistanceScaled(l.sessionMeters)
+		}
+		a.Sessions = append(a.Sessions, s)
+		for i := 0; i <= l.seconds; i++ {
+			rec := mesgdef.NewRecord(nil).SetTimestamp(from.Add(time.Duration(i) * time.Second))
+			if l.speed > 0 {
+				rec.SetDistanceScaled(l.speed * float64(i))
+			}
+			if l.watts > 0 {
+				rec.SetPower(l.watts)
+			}
+			a.Records = append(a.Records, rec)
+		}
+	}
+	return a
+}
+
+func TestRecordString(t *testing.T) {
+	tests := []struct {
+		name   string
+		record Record
+		want   string
+	}{
+		{"minutes", Record{Value: 245, Unit: unitSeconds}, "4:05"},
+		{"rounded to the second", Record{Value: 1499.6, Unit: unitSeconds}, "25:00"},
+		{"hours", Record{Value: 3*3600 + 7*60 + 9, Unit: unitSeconds}, "3:07:09"},
+		{"meters in km", Record{Value: 80450, Unit: unitMeters}, "80.45 km"},
+		{"watts", Record{Value: 287.4, Unit: unitWatts}, "287 W"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.record.String(); got != tc.want {
+				t.Errorf("String = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRecordBetter(t *testing.T) {
+	tests := []struct {
+		name     string
+		r, other Record
+		want     bool
+	}{
+		{"faster time", Record{Value: 240, LowerIsBetter: true}, Record{Value: 250, LowerIsBetter: true}, true},
+		{"slower time", Record{Value: 260, LowerIsBetter: true}, Record{Value: 250, LowerIsBetter: true}, false},
+		{"higher power", Record{Value: 300}, Record{Value: 280}, true},
+		{"equal", Record{Value: 300}, Record{Value: 300}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.r.Better(tc.other); got != tc.want {
+				t.Errorf("Better = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEfforts(t *testing.T) {
+	type value struct {
+		name  string
+		value float64
+	}
+	tests := []struct {
+		name     string
+		activity *filedef.Activity
+		want     []value
+	}{
+		{"no sessions", activity(start), nil},
+		{
+			"run shorter than 5K",
+			activity(start, leg{sport: typedef.SportRunning, seconds: 1500, speed: 4}),
+			[]value{{Fastest1K, 250}, {Fastest5K, 1250}},
+		},
+		{
+			"run without distance records",
+			activity(start, leg{sport: typedef.SportRunning, seconds: 600}),
+			nil,
+		},
+		{
+			"ride with power",
+			activity(start, leg{sport: typedef.SportCycling, seconds: 1800, speed: 8, watts: 230, sessionMeters: 14400}),
+			[]value{{LongestRide, 14400}, {Max20MinPower, 230}},
+		},
+		{
+			"ride without power",
+			activity(start, leg{sport: typedef.SportCycling, seconds: 1800, speed: 8, sessionMeters: 14400}),
+			[]value{{LongestRide, 14400}},
+		},
+		{
+			"ride too short for 20-min power",
+			activity(start, leg{sport: typedef.SportCycling, seconds: 600, watts: 300, sessionMeters: 5000}),
+			[]value{{LongestRide, 5000}},
+		},
+		{
+			"other sports have no records",
+			activity(start, leg{sport: typedef.SportSwimming, seconds: 1200, speed: 1}),
+			nil,
+		},
+		{
+			"each leg of a multi-sport activity counts for its sport",
+			activity(start,
+				leg{sport: typedef.SportCycling, seconds: 1200, speed: 10, watts: 250, sessionMeters: 12000},
+				leg{sport: typedef.SportTransition, offset: 1201, seconds: 60, speed: 2},
+				leg{sport: typedef.SportRunning, offset: 1262, seconds: 300, speed: 5},
+			),
+			[]value{{Fastest1K, 200}, {LongestRide, 12000}, {Max20MinPower, 250}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Efforts(tc.activity, "a.fit")
+			if len(got) != len(tc.want) {
+				t.Fatalf("Efforts = %+v, want %+v", got, tc.want)
+			}
+			for i, want := range tc.want {
+				if got[i].Name != want.name || got[i].Value != want.value || got[i].Activity != "a.fit" {
+					t.Errorf("Efforts[%d] = %+v, want %s of %v from a.fit", i, got[i], want.name, want.value)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreIngest(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "records.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(created time.Time, speed float64) *filedef.Activity {
+		return activity(created, leg{sport: typedef.SportRunning, seconds: 300, speed: speed})
+	}
+
+	steps := []struct {
+		name     string
+		activity *filedef.Activity
+		source   string
+		ingested bool
+		changes  []string // "name value previous", previous 0 for a first record
+	}{
+		{"first run sets the record", run(start, 4), "a.fit", true, []string{"Fastest 1K 250 0"}},
+		{"same run again", run(start, 4), "a.fit", false, nil},
+		{"renamed copy of the run", run(start, 4), "copy.fit", false, nil},
+		{"slower run", run(start.AddDate(0, 0, 1), 3), "b.fit", true, nil},
+		{"faster run beats the record", run(start.AddDate(0, 0, 2), 5), "c.fit", true, []string{"Fastest 1K 200 250"}},
+		{"files without a creation time are keyed by path", run(time.Time{}, 2), "d.fit", true, nil},
+		{"and skipped by it", run(time.Time{}, 2), "d.fit", false, nil},
+	}
+	for _, step := range steps {
+		changes, ingested := s.Ingest(step.activity, step.source)
+		if ingested != step.ingested {
+			t.Errorf("%s: ingested = %v, want %v", step.name, ingested, step.ingested)
+		}
+		var got []string
+		for _, c := range changes {
+			previous := 0.0
+			if c.Previous != nil {
+				previous = c.Previous.Value
+			}
+			got = append(got, c.New.Name+" "+trim(c.New.Value)+" "+trim(previous))
+		}
+		if !slices.Equal(got, step.changes) {
+			t.Errorf("%s: changes = %q, want %q", step.name, got, step.changes)
+		}
+	}
+	if got, want := s.Sources(), []string{"a.fit", "b.fit", "c.fit", "d.fit"}; !slices.Equal(got, want) {
+		t.Errorf("Sources = %q, want %q", got, want)
+	}
+	if r := s.Records[Fastest1K]; r.Value != 200 || r.Activity != "c.fit" {
+		t.Errorf("Fastest 1K = %+v, want 200 s from c.fit", r)
+	}
+}
+
+func TestStoreSaveAndOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "garmin", "records.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open of a missing file: %v", err)
+	}
+	if len(s.Records) != 0 || len(s.Activities) != 0 {
+		t.Fatalf("Open of a missing file = %+v, want an empty store", s)
+	}
+	s.Ingest(activity(start, leg{sport: typedef.SportRunning, seconds: 300, speed: 4}), "a.fit")
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !reflect.DeepEqual(reopened.Sorted(), s.Sorted()) || !reflect.DeepEqual(reopened.Activities, s.Activities) {
+		t.Errorf("reopened store = %+v, want %+v", reopened, s)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("Open of a corrupt file succeeded")
+	}
+}
+
+func TestFitFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.fit", "a.FIT", "notes.txt", filepath.Join("nested", "c.fit")} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := FitFiles([]string{dir, filepath.Join(dir, "notes.txt")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a.FIT"), filepath.Join(dir, "b.fit"), filepath.Join(dir, "notes.txt")}
+	if !slices.Equal(got, want) {
+		t.Errorf("FitFiles = %q, want %q", got, want)
+	}
+
+	if _, err := FitFiles([]string{filepath.Join(dir, "missing.fit")}); err == nil {
+		t.Error("FitFiles of a missing path succeeded")
+	}
+}
+
+// trim formats v without trailing zeros.
+func trim(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
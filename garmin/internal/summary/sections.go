@@ -0,0 +1,169 @@
+package summary
+
+import (
+	"fmt"
+	"io"
+
+	garminactivity "garmin/internal/activity"
+
+	"github.com/muktihari/fit/profile/basetype"
+)
+
+// Section writes one titled block of the summary. Sections whose data is not in the file
+// either write nothing or say what is missing.
+type Section func(w io.Writer, in *Input)
+
+// sections are the built-in sections by name.
+var sections = map[string]Section{
+	"timing":            timing,
+	"nutrition":         nutrition,
+	"training_effect":   trainingEffect,
+	"workout_details":   workoutDetails,
+	"intensity_minutes": intensityMinutes,
+	"heart_rate":        heartRate,
+	"elevation":         elevation,
+	"running_dynamics":  runningDynamics,
+	"power":             power,
+	"swim":              swim,
+}
+
+// RegisterSection adds or replaces a named section that templates can list.
+func RegisterSection(name string, fn Section) {
+	sections[name] = fn
+}
+
+func timing(w io.Writer, in *Input) {
+	fmt.Fprintf(w, "\nTiming\n------\n")
+	fmt.Fprintf(w, "Total Time: %.2f min\n", garminactivity.Duration(in.Session).Minutes())
+	fmt.Fprintf(w, "Distance: %.2f km\n", garminactivity.SessionDistance(in.Session)/1000)
+}
+
+func nutrition(w io.Writer, in *Input) {
+	fmt.Fprintf(w, "\nNutrition & Hydration\n----------------------\n")
+	fmt.Fprintf(w, "Total Calories Burned: %d\n", in.Session.TotalCalories)
+	fmt.Fprintf(w, "Active Calories: %d\n", in.Session.TotalCalories)
+	fmt.Fprintf(w, "Resting Calories: (not in file, estimate from BMR × time)\n")
+	fmt.Fprintf(w, "Calories Consumed: (not in file)\n")
+	fmt.Fprintf(w, "Calories Net: (not in file, UI: Burned - Consumed)\n")
+	fmt.Fprintf(w, "Est. Sweat Loss: (not in file)\n")
+	fmt.Fprintf(w, "Fluid Consumed: (not in file)\n")
+	fmt.Fprintf(w, "Fluid Net: (not in file)\n")
+}
+
+func trainingEffect(w io.Writer, in *Input) {
+	s := in.Session
+	fmt.Fprintf(w, "\nTraining Effect\n---------------\n")
+	fmt.Fprintf(w, "Aerobic: %d\n", s.TotalTrainingEffect)
+	fmt.Fprintf(w, "Anaerobic: %d\n", s.TotalAnaerobicTrainingEffect)
+	fmt.Fprintf(w, "Exercise Load: %d\n", s.TrainingLoadPeak)
+	fmt.Fprintf(w, "Primary Benefit: %s\n", s.SportProfileName)
+}
+
+func workoutDetails(w io.Writer, in *Input) {
+	fmt.Fprintf(w, "\nWorkout Details\n---------------\n")
+	st := garminactivity.StrengthOf(in.Activity)
+	if !st.Present() {
+		fmt.Fprintf(w, "Total Reps: (not in file)\n")
+		fmt.Fprintf(w, "Total Sets: (not in file)\n")
+		fmt.Fprintf(w, "Volume: (not in file)\n")
+		return
+	}
+	fmt.Fprintf(w, "Total Reps: %d\n", st.Reps)
+	fmt.Fprintf(w, "Total Sets: %d\n", st.Sets)
+	fmt.Fprintf(w, "Volume: %s\n", optional(st.VolumeKg, "%.0f kg"))
+}
+
+func intensityMinutes(w io.Writer, in *Input) {
+	fmt.Fprintf(w, "\nIntensity Minutes\n-----------------\n")
+	if im := garminactivity.IntensityMinutesOf(in.Activity.Records, in.MaxHR); im.Method != "" {
+		fmt.Fprintf(w, "Moderate: %.0f min\n", im.Moderate)
+		fmt.Fprintf(w, "Vigorous: %.0f min\n", im.Vigorous)
+		fmt.Fprintf(w, "Total: %.0f min (vigorous counts double; by %s)\n", im.Total(), im.Method)
+	} else {
+		fmt.Fprintf(w, "Moderate: (needs heart rate with -max-hr, or speed records)\n")
+		fmt.Fprintf(w, "Vigorous: (needs heart rate with -max-hr, or speed records)\n")
+		fmt.Fprintf(w, "Total: (needs heart rate with -max-hr, or speed records)\n")
+	}
+}
+
+func heartRate(w io.Writer, in *Input) {
+	fmt.Fprintf(w, "\nHeart Rate\n----------\n")
+	fmt.Fprintf(w, "Avg HR: %s\n", bpm(in.Session.AvgHeartRate))
+	fmt.Fprintf(w, "Max HR: %s\n", bpm(in.Session.MaxHeartRate))
+}
+
+func elevation(w io.Writer, in *Input) {
+	e := garminactivity.ElevationOf(in.Session, in.Activity.Records, in.Elevation)
+	if !e.FromSession && e.Samples == 0 {
+		return
+	}
+	source := fmt.Sprintf("computed from %d altitude records", e.Samples)
+	if e.FromSession {
+		source = "from file"
+	}
+	fmt.Fprintf(w, "\nElevation\n---------\n")
+	fmt.Fprintf(w, "Total Ascent: %.0f m (%s)\n", e.AscentM, source)
+	fmt.Fprintf(w, "Total Descent: %.0f m (%s)\n", e.DescentM, source)
+	if e.Samples > 0 {
+		fmt.Fprintf(w, "Min Elevation: %.0f m\n", e.MinM)
+		fmt.Fprintf(w, "Max Elevation: %.0f m\n", e.MaxM)
+	}
+}
+
+func runningDynamics(w io.Writer, in *Input) {
+	rd := garminactivity.RunningDynamicsOf(in.Session, in.Activity.Records)
+	if !rd.Present() {
+		return
+	}
+	fmt.Fprintf(w, "\nRunning Dynamics\n----------------\n")
+	fmt.Fprintf(w, "Avg Ground Contact Time: %s\n", optional(rd.GroundContactTimeMs, "%.0f ms"))
+	fmt.Fprintf(w, "Avg Vertical Oscillation: %s\n", optional(rd.VerticalOscillationMm/10, "%.1f cm"))
+	fmt.Fprintf(w, "Avg Vertical Ratio: %s\n", optional(rd.VerticalRatioPct, "%.1f %%"))
+	fmt.Fprintf(w, "Avg Stride Length: %s\n", optional(rd.StrideLengthM, "%.2f m"))
+}
+
+func power(w io.Writer, in *Input) {
+	p := garminactivity.PowerOf(in.Session, in.Activity.Records, in.FTP)
+	if !p.Present() {
+		return
+	}
+	fmt.Fprintf(w, "\nPower\n-----\n")
+	fmt.Fprintf(w, "Avg Power: %.0f W\n", p.AvgWatts)
+	fmt.Fprintf(w, "Max Power: %.0f W\n", p.MaxWatts)
+	fmt.Fprintf(w, "Normalized Power: %s\n", optional(p.NormalizedWatts, "%.0f W"))
+	if p.IntensityFactor > 0 {
+		fmt.Fprintf(w, "Intensity Factor: %.2f\n", p.IntensityFactor)
+		fmt.Fprintf(w, "Training Stress Score: %.1f\n", p.TSS)
+	} else {
+		fmt.Fprintf(w, "Intensity Factor: (needs FTP; set -ftp or garmin.ftp_watts)\n")
+	}
+}
+
+func swim(w io.Writer, in *Input) {
+	sw := garminactivity.SwimOf(in.Session, in.Activity.Lengths)
+	if !sw.Present() {
+		return
+	}
+	fmt.Fprintf(w, "\nSwim\n----\n")
+	fmt.Fprintf(w, "Pool Length: %s\n", optional(sw.PoolLengthM, "%.0f m"))
+	fmt.Fprintf(w, "Active Lengths: %d\n", sw.ActiveLengths)
+	fmt.Fprintf(w, "Avg Strokes/Length: %s\n", optional(sw.StrokesPerLength, "%.1f"))
+	fmt.Fprintf(w, "Avg Stroke Rate: %s\n", optional(sw.StrokeRate, "%.0f spm"))
+	fmt.Fprintf(w, "Avg SWOLF: %s\n", optional(sw.SWOLF, "%.0f"))
+}
+
+// bpm formats a heart rate, or describes it as missing when the file has none.
+func bpm(v uint8) string {
+	if v == basetype.Uint8Invalid {
+		return "(not in file)"
+	}
+	return fmt.Sprintf("%d bpm", v)
+}
+
+// optional formats v, or describes it as missing when zero.
+func optional(v float64, format string) string {
+	if v == 0 {
+		return "(not in file)"
+	}
+	return fmt.Sprintf(format, v)
+}
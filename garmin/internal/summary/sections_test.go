@@ -0,0 +1,105 @@
+package summary
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/muktihari/fit/profile/filedef"
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+var start = time.Date(2025, 6, 1, 7, 0, 0, 0, time.UTC)
+
+// heartRateRecords returns 1 Hz records holding bpm over each [from, to] span of seconds.
+func heartRateRecords(bpm uint8, spans ...[2]int) []*mesgdef.Record {
+	var records []*mesgdef.Record
+	for _, span := range spans {
+		for s := span[0]; s <= span[1]; s++ {
+			records = append(records, mesgdef.NewRecord(nil).SetTimestamp(start.Add(time.Duration(s)*time.Second)).SetHeartRate(bpm))
+		}
+	}
+	return records
+}
+
+func TestSections_MissingData(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		session *mesgdef.Session
+		records []*mesgdef.Record
+		maxHR   float64
+		want    []string // lines of the output; none when the section writes nothing
+	}{
+		{
+			name: "heart rate", section: "heart_rate",
+			session: mesgdef.NewSession(nil).SetAvgHeartRate(142).SetMaxHeartRate(171),
+			want:    []string{"Avg HR: 142 bpm", "Max HR: 171 bpm"},
+		},
+		{
+			name: "heart rate missing", section: "heart_rate",
+			want: []string{"Avg HR: (not in file)", "Max HR: (not in file)"},
+		},
+		{
+			name: "intensity minutes without heart rate or speed", section: "intensity_minutes",
+			maxHR: 190,
+			want:  []string{"Total: (needs heart rate with -max-hr, or speed records)"},
+		},
+		{
+			name: "intensity minutes without max heart rate", section: "intensity_minutes",
+			records: heartRateRecords(170, [2]int{0, 60}),
+			want:    []string{"Total: (needs heart rate with -max-hr, or speed records)"},
+		},
+		{
+			name: "intensity minutes by heart rate", section: "intensity_minutes",
+			records: heartRateRecords(170, [2]int{0, 60}),
+			maxHR:   190,
+			want:    []string{"Moderate: 0 min", "Vigorous: 1 min", "Total: 2 min (vigorous counts double; by heart rate)"},
+		},
+		{
+			// the 9-minute pause counts only maxSampleGap: 130 s in all
+			name: "intensity minutes across a pause", section: "intensity_minutes",
+			records: heartRateRecords(170, [2]int{0, 60}, [2]int{600, 660}),
+			maxHR:   190,
+			want:    []string{"Vigorous: 2 min", "Total: 4 min (vigorous counts double; by heart rate)"},
+		},
+		{
+			name: "workout details without sets", section: "workout_details",
+			want: []string{"Total Reps: (not in file)", "Total Sets: (not in file)", "Volume: (not in file)"},
+		},
+		{
+			name: "power without FTP", section: "power",
+			session: mesgdef.NewSession(nil).SetAvgPower(200).SetMaxPower(450),
+			want:    []string{"Avg Power: 200 W", "Normalized Power: (not in file)", "Intensity Factor: (needs FTP; set -ftp or garmin.ftp_watts)"},
+		},
+		{name: "power missing", section: "power"},
+		{name: "elevation missing", section: "elevation"},
+		{name: "running dynamics missing", section: "running_dynamics"},
+		{name: "swim missing", section: "swim"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			session := tc.session
+			if session == nil {
+				session = mesgdef.NewSession(nil)
+			}
+			in := &Input{Activity: &filedef.Activity{Records: tc.records}, Session: session, MaxHR: tc.maxHR}
+			var out strings.Builder
+			sections[tc.section](&out, in)
+			got := out.String()
+			if len(tc.want) == 0 {
+				if got != "" {
+					t.Errorf("%s wrote %q, want nothing", tc.section, got)
+				}
+				return
+			}
+			lines := strings.Split(got, "\n")
+			for _, want := range tc.want {
+				if !slices.Contains(lines, want) {
+					t.Errorf("%s output missing line %q:\n%s", tc.section, want, got)
+				}
+			}
+		})
+	}
+}
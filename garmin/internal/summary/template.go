@@ -0,0 +1,104 @@
+// Package summary renders the Garmin UI-style activity summary through per-sport
+// templates: a swim shows SWOLF and stroke rate, a ride power and TSS, a strength
+// session its sets. Templates are chosen from the FIT sport and sub-sport fields.
+package summary
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	garminactivity "garmin/internal/activity"
+
+	"github.com/muktihari/fit/profile/filedef"
+	"github.com/muktihari/fit/profile/mesgdef"
+)
+
+// Input is what the sections summarize.
+type Input struct {
+	Activity *filedef.Activity
+	// Session is the primary (or combined, or record-derived) session
+	Session   *mesgdef.Session
+	FTP       float64 // watts; <= 0 uses the file's threshold power
+	MaxHR     float64 // for intensity minutes; 0 uses speed-based METs
+	Elevation garminactivity.ElevationOptions
+}
+
+// Template is an ordered list of section names.
+type Template struct {
+	Name     string
+	Sections []string
+}
+
+// Default is used for sports without a template of their own, including running.
+var Default = Template{Name: "default", Sections: []string{
+	"timing", "nutrition", "training_effect", "workout_details", "intensity_minutes",
+	"heart_rate", "elevation", "running_dynamics", "power",
+}}
+
+// templates are keyed by FIT sport ("cycling") or sport and sub-sport
+// ("training/strength_training"), as the typedef String methods name them.
+var templates = map[string]Template{
+	"swimming": {Name: "swimming", Sections: []string{
+		"timing", "swim", "nutrition", "training_effect", "intensity_minutes", "heart_rate",
+	}},
+	"cycling": {Name: "cycling", Sections: []string{
+		"timing", "power", "nutrition", "training_effect", "intensity_minutes", "heart_rate", "elevation",
+	}},
+	"training/strength_training":          strength,
+	"fitness_equipment/strength_training": strength,
+}
+
+var strength = Template{Name: "strength", Sections: []string{
+	"timing", "workout_details", "nutrition", "training_effect", "intensity_minutes", "heart_rate",
+}}
+
+// Register adds or replaces the template of a sport, or of a "sport/sub_sport" pair, so
+// custom sports get their own summary. Every section must be registered.
+func Register(key string, t Template) error {
+	for _, name := range t.Sections {
+		if _, ok := sections[name]; !ok {
+			return fmt.Errorf("template %q: unknown section %q (have %v)", key, name, SectionNames())
+		}
+	}
+	if t.Name == "" {
+		t.Name = key
+	}
+	templates[key] = t
+	return nil
+}
+
+// SectionNames returns the registered section names, sorted.
+func SectionNames() []string {
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// For returns the template for a session: its sport and sub-sport's, else its sport's,
+// else Default.
+func For(s *mesgdef.Session) Template {
+	if s == nil {
+		return Default
+	}
+	sport := s.Sport.String()
+	if t, ok := templates[sport+"/"+s.SubSport.String()]; ok {
+		return t
+	}
+	if t, ok := templates[sport]; ok {
+		return t
+	}
+	return Default
+}
+
+// Render writes the template's sections in order.
+func (t Template) Render(w io.Writer, in *Input) {
+	for _, name := range t.Sections {
+		if fn, ok := sections[name]; ok {
+			fn(w, in)
+		}
+	}
+}
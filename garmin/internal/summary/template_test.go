@@ -0,0 +1,134 @@
+package summary
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/muktihari/fit/profile/mesgdef"
+	"github.com/muktihari/fit/profile/typedef"
+)
+
+func session(sport typedef.Sport, sub typedef.SubSport) *mesgdef.Session {
+	return mesgdef.NewSession(nil).SetSport(sport).SetSubSport(sub)
+}
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		session *mesgdef.Session
+		want    string
+	}{
+		{"no session", nil, "default"},
+		{"running", session(typedef.SportRunning, typedef.SubSportTrail), "default"},
+		{"cycling by sport", session(typedef.SportCycling, typedef.SubSportIndoorCycling), "cycling"},
+		{"swimming by sport", session(typedef.SportSwimming, typedef.SubSportLapSwimming), "swimming"},
+		{"strength by sport and sub-sport", session(typedef.SportTraining, typedef.SubSportStrengthTraining), "strength"},
+		{"strength on equipment", session(typedef.SportFitnessEquipment, typedef.SubSportStrengthTraining), "strength"},
+		{"other training", session(typedef.SportTraining, typedef.SubSportYoga), "default"},
+		{"sport without a template", session(typedef.SportRowing, typedef.SubSportGeneric), "default"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := For(tc.session).Name; got != tc.want {
+				t.Errorf("For = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	t.Cleanup(func() {
+		delete(templates, "rowing")
+		delete(templates, "cycling/indoor_cycling")
+	})
+
+	tests := []struct {
+		name     string
+		key      string
+		template Template
+		wantErr  bool
+		session  *mesgdef.Session
+		want     Template
+	}{
+		{
+			name: "new sport named after its key", key: "rowing",
+			template: Template{Sections: []string{"timing", "heart_rate"}},
+			session:  session(typedef.SportRowing, typedef.SubSportGeneric),
+			want:     Template{Name: "rowing", Sections: []string{"timing", "heart_rate"}},
+		},
+		{
+			name: "sub-sport ahead of its sport", key: "cycling/indoor_cycling",
+			template: Template{Name: "trainer", Sections: []string{"power", "timing"}},
+			session:  session(typedef.SportCycling, typedef.SubSportIndoorCycling),
+			want:     Template{Name: "trainer", Sections: []string{"power", "timing"}},
+		},
+		{
+			name: "unknown section", key: "rowing",
+			template: Template{Sections: []string{"timing", "splits"}},
+			wantErr:  true,
+			// the earlier registration is kept
+			session: session(typedef.SportRowing, typedef.SubSportGeneric),
+			want:    Template{Name: "rowing", Sections: []string{"timing", "heart_rate"}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Register(tc.key, tc.template)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Register error = %v, want error %v", err, tc.wantErr)
+			}
+			got := For(tc.session)
+			if got.Name != tc.want.Name || !slices.Equal(got.Sections, tc.want.Sections) {
+				t.Errorf("For = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	for _, name := range []string{"first", "second"} {
+		RegisterSection(name, func(w io.Writer, in *Input) { fmt.Fprintf(w, "%s\n", name) })
+	}
+	t.Cleanup(func() {
+		delete(sections, "first")
+		delete(sections, "second")
+	})
+
+	tests := []struct {
+		name     string
+		sections []string
+		want     string
+	}{
+		{"in template order", []string{"second", "first"}, "second\nfirst\n"},
+		{"repeated", []string{"first", "first"}, "first\nfirst\n"},
+		{"unknown sections skipped", []string{"first", "missing", "second"}, "first\nsecond\n"},
+		{"empty", nil, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			Template{Sections: tc.sections}.Render(&out, &Input{})
+			if got := out.String(); got != tc.want {
+				t.Errorf("Render = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTemplatesUseRegisteredSections(t *testing.T) {
+	for key, tmpl := range templates {
+		for _, name := range tmpl.Sections {
+			if _, ok := sections[name]; !ok {
+				t.Errorf("template %q lists unknown section %q", key, name)
+			}
+		}
+	}
+	for _, name := range Default.Sections {
+		if _, ok := sections[name]; !ok {
+			t.Errorf("default template lists unknown section %q", name)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package wellness
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/muktihari/fit/profile/basetype"
+	"github.com/muktihari/fit/profile/mesgdef"
+	"github.com/muktihari/fit/profile/typedef"
+)
+
+// nightGap splits sleep and overnight HRV samples into nights: a longer pause starts a new one.
+const nightGap = 2 * time.Hour
+
+// maxSleepSample caps the time credited to one sleep level sample, so a gap in the samples
+// is not counted as sleep.
+const maxSleepSample = 10 * time.Minute
+
+// Day is the wellness summary of one calendar day in the device's time zone. Zero means
+// the value is not in the files.
+type Day struct {
+	Date string `json:"date"` // YYYY-MM-DD
+
+	// Monitoring counters accumulate over the day per activity type; each day takes
+	// their maximum per type, summed over the types
+	Steps           int     `json:"steps"`
+	DistanceM       float64 `json:"distance_m"`
+	Calories        int     `json:"calories"`
+	ModerateMinutes int     `json:"moderate_minutes"`
+	VigorousMinutes int     `json:"vigorous_minutes"`
+	AvgHeartRate    int     `json:"avg_heart_rate"`
+	MinHeartRate    int     `json:"min_heart_rate"`
+
+	Sleep  *Sleep  `json:"sleep,omitempty"`
+	HRV    *HRV    `json:"hrv,omitempty"`
+	Stress *Stress `json:"stress,omitempty"`
+}
+
+// Sleep is the sleep ending on a day, by stage.
+type Sleep struct {
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	DeepMinutes  float64   `json:"deep_minutes"`
+	LightMinutes float64   `json:"light_minutes"`
+	REMMinutes   float64   `json:"rem_minutes"`
+	AwakeMinutes float64   `json:"awake_minutes"`
+}
+
+// AsleepMinutes is the time in deep, light, and REM sleep.
+func (s Sleep) AsleepMinutes() float64 {
+	return s.DeepMinutes + s.LightMinutes + s.REMMinutes
+}
+
+// HRV is the overnight heart rate variability (RMSSD) reported on a day. Without the
+// device's HRV status summary, LastNightMs averages the night's 5-minute values.
+type HRV struct {
+	LastNightMs float64 `json:"last_night_ms"`
+	WeeklyMs    float64 `json:"weekly_ms,omitempty"`
+	Status      string  `json:"status,omitempty"` // balanced, unbalanced, low, or poor
+}
+
+// Stress summarizes a day's stress scores (0-100); unmeasurable samples are skipped.
+type Stress struct {
+	Avg     float64 `json:"avg"`
+	Max     int     `json:"max"`
+	Samples int     `json:"samples"`
+}
+
+// Days summarizes the data per day, in date order.
+func (d *Data) Days() []Day {
+	days := make(map[string]*Day)
+	day := func(t time.Time) *Day {
+		date := t.In(d.Location).Format(time.DateOnly)
+		if days[date] == nil {
+			days[date] = &Day{Date: date}
+		}
+		return days[date]
+	}
+
+	d.addMonitoring(day)
+	d.addSleep(day)
+	d.addHRV(day)
+	d.addStress(day)
+
+	out := make([]Day, 0, len(days))
+	for _, dd := range days {
+		out = append(out, *dd)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Date < out[j].Date })
+	return out
+}
+
+// addMonitoring adds the daily maxima of the accumulated counters and the heart rate range.
+func (d *Data) addMonitoring(day func(time.Time) *Day) {
+	type key struct {
+		day  *Day
+		kind typedef.ActivityType
+	}
+	steps := make(map[key]uint32)
+	distance := make(map[key]float64)
+	calories := make(map[key]uint16)
+	hr := make(map[*Day][]uint8)
+	for _, m := range d.Monitoring {
+		dd := day(m.Timestamp)
+		k := key{dd, m.ActivityType}
+		if name, v := m.GetCycles(); name == "steps" && m.Cycles != basetype.Uint32Invalid {
+			steps[k] = max(steps[k], v.(uint32))
+		}
+		if m.Distance != basetype.Uint32Invalid {
+			distance[k] = math.Max(distance[k], m.DistanceScaled())
+		}
+		if m.Calories != basetype.Uint16Invalid {
+			calories[k] = max(calories[k], m.Calories)
+		}
+		if m.ModerateActivityMinutes != basetype.Uint16Invalid {
+			dd.ModerateMinutes = max(dd.ModerateMinutes, int(m.ModerateActivityMinutes))
+		}
+		if m.VigorousActivityMinutes != basetype.Uint16Invalid {
+			dd.VigorousMinutes = max(dd.VigorousMinutes, int(m.VigorousActivityMinutes))
+		}
+		if m.HeartRate != basetype.Uint8Invalid && m.HeartRate > 0 {
+			hr[dd] = append(hr[dd], m.HeartRate)
+		}
+	}
+	for k, v := range steps {
+		k.day.Steps += int(v)
+	}
+	for k, v := range distance {
+		k.day.DistanceM += v
+	}
+	for k, v := range calories {
+		k.day.Calories += int(v)
+	}
+	for dd, samples := range hr {
+		sum, low := 0, int(samples[0])
+		for _, bpm := range samples {
+			sum += int(bpm)
+			low = min(low, int(bpm))
+		}
+		dd.AvgHeartRate = int(math.Round(float64(sum) / float64(len(samples))))
+		dd.MinHeartRate = low
+	}
+}
+
+// addSleep credits each sleep level sample with the time until the next one and adds each
+// night to the day it ends on.
+func (d *Data) addSleep(day func(time.Time) *Day) {
+	levels := make([]*mesgdef.SleepLevel, 0, len(d.Sleep))
+	for _, l := range d.Sleep {
+		if !l.Timestamp.IsZero() {
+			levels = append(levels, l)
+		}
+	}
+	sortByTime(levels, func(l *mesgdef.SleepLevel) time.Time { return l.Timestamp })
+	times := make([]time.Time, len(levels))
+	for i, l := range levels {
+		times[i] = l.Timestamp
+	}
+
+	for _, run := range nights(times, nightGap) {
+		night := levels[run[0]:run[1]]
+		end := night[len(night)-1].Timestamp
+		dd := day(end)
+		if dd.Sleep == nil {
+			dd.Sleep = &Sleep{Start: night[0].Timestamp}
+		}
+		dd.Sleep.End = end
+		for i, l := range night[:len(night)-1] {
+			minutes := min(night[i+1].Timestamp.Sub(l.Timestamp), maxSleepSample).Minutes()
+			switch l.SleepLevel {
+			case typedef.SleepLevelDeep:
+				dd.Sleep.DeepMinutes += minutes
+			case typedef.SleepLevelLight:
+				dd.Sleep.LightMinutes += minutes
+			case typedef.SleepLevelRem:
+				dd.Sleep.REMMinutes += minutes
+			case typedef.SleepLevelAwake:
+				dd.Sleep.AwakeMinutes += minutes
+			}
+		}
+	}
+}
+
+// addHRV adds the device's HRV status summaries, and for days without one, the average
+// of each night's 5-minute values on the day the night ends.
+func (d *Data) addHRV(day func(time.Time) *Day) {
+	for _, s := range d.HRVStatus {
+		if s.Timestamp.IsZero() || s.LastNightAverage == basetype.Uint16Invalid {
+			continue
+		}
+		h := &HRV{LastNightMs: s.LastNightAverageScaled()}
+		if s.WeeklyAverage != basetype.Uint16Invalid {
+			h.WeeklyMs = s.WeeklyAverageScaled()
+		}
+		if s.Status != typedef.HrvStatusNone && s.Status != typedef.HrvStatusInvalid {
+			h.Status = s.Status.String()
+		}
+		day(s.Timestamp).HRV = h
+	}
+
+	values := make([]*mesgdef.HrvValue, 0, len(d.HRV))
+	for _, v := range d.HRV {
+		if !v.Timestamp.IsZero() && v.Value != basetype.Uint16Invalid {
+			values = append(values, v)
+		}
+	}
+	sortByTime(values, func(v *mesgdef.HrvValue) time.Time { return v.Timestamp })
+	times := make([]time.Time, len(values))
+	for i, v := range values {
+		times[i] = v.Timestamp
+	}
+	for _, run := range nights(times, nightGap) {
+		dd := day(times[run[1]-1])
+		if dd.HRV != nil {
+			continue
+		}
+		var sum float64
+		for _, v := range values[run[0]:run[1]] {
+			sum += v.ValueScaled()
+		}
+		dd.HRV = &HRV{LastNightMs: sum / float64(run[1]-run[0])}
+	}
+}
+
+// addStress adds the average and maximum of each day's measurable stress scores.
+func (d *Data) addStress(day func(time.Time) *Day) {
+	for _, s := range d.Stress {
+		if s.StressLevelTime.IsZero() || s.StressLevelValue < 0 || s.StressLevelValue > 100 {
+			continue
+		}
+		dd := day(s.StressLevelTime)
+		if dd.Stress == nil {
+			dd.Stress = &Stress{}
+		}
+		st := dd.Stress
+		st.Avg = (st.Avg*float64(st.Samples) + float64(s.StressLevelValue)) / float64(st.Samples+1)
+		st.Samples++
+		st.Max = max(st.Max, int(s.StressLevelValue))
+	}
+}
@@ -0,0 +1,287 @@
+package wellness
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/muktihari/fit/profile/mesgdef"
+	"github.com/muktihari/fit/profile/typedef"
+)
+
+// pacific is UTC-7, the device zone of the local-time tests.
+var pacific = time.FixedZone("UTC-7", -7*3600)
+
+// local returns the given time on a June 2025 day in pacific.
+func local(day, hour, minute int) time.Time {
+	return time.Date(2025, 6, day, hour, minute, 0, 0, pacific)
+}
+
+// sample is a sleep level starting at a time.
+type sample struct {
+	at    time.Time
+	level typedef.SleepLevel
+}
+
+// sleep returns a sleep level message per sample.
+func sleep(samples ...sample) []*mesgdef.SleepLevel {
+	out := make([]*mesgdef.SleepLevel, len(samples))
+	for i, s := range samples {
+		out[i] = mesgdef.NewSleepLevel(nil).SetTimestamp(s.at.UTC()).SetSleepLevel(s.level)
+	}
+	return out
+}
+
+func stress(at time.Time, value int16) *mesgdef.StressLevel {
+	return mesgdef.NewStressLevel(nil).SetStressLevelTime(at.UTC()).SetStressLevelValue(value)
+}
+
+func TestDays_Sleep(t *testing.T) {
+	tests := []struct {
+		name  string
+		sleep []*mesgdef.SleepLevel
+		want  map[string]Sleep
+	}{
+		{"no sleep samples", nil, map[string]Sleep{}},
+		{
+			"stages credited until the next sample",
+			sleep(
+				sample{local(1, 23, 0), typedef.SleepLevelLight},
+				sample{local(1, 23, 10), typedef.SleepLevelDeep},
+				sample{local(1, 23, 20), typedef.SleepLevelRem},
+				sample{local(1, 23, 25), typedef.SleepLevelAwake},
+				sample{local(1, 23, 27), typedef.SleepLevelLight},
+			),
+			// the night ends before local midnight, so it is the day's own
+			map[string]Sleep{"2025-06-01": {Start: local(1, 23, 0), End: local(1, 23, 27), LightMinutes: 10, DeepMinutes: 10, REMMinutes: 5, AwakeMinutes: 2}},
+		},
+		{
+			"night across local midnight goes to the day it ends",
+			sleep(
+				sample{local(1, 22, 50), typedef.SleepLevelLight},
+				sample{local(1, 23, 0), typedef.SleepLevelDeep},
+				sample{local(2, 0, 30), typedef.SleepLevelAwake},
+			),
+			// the 90 minutes between the last samples are a gap in the data, credited only
+			// maxSleepSample
+			map[string]Sleep{"2025-06-02": {Start: local(1, 22, 50), End: local(2, 0, 30), LightMinutes: 10, DeepMinutes: 10}},
+		},
+		{
+			"gaps beyond nightGap split nights",
+			sleep(
+				sample{local(1, 1, 0), typedef.SleepLevelLight},
+				sample{local(1, 1, 5), typedef.SleepLevelLight},
+				sample{local(1, 23, 0), typedef.SleepLevelDeep},
+				sample{local(2, 0, 10), typedef.SleepLevelDeep},
+			),
+			map[string]Sleep{
+				"2025-06-01": {Start: local(1, 1, 0), End: local(1, 1, 5), LightMinutes: 5},
+				"2025-06-02": {Start: local(1, 23, 0), End: local(2, 0, 10), DeepMinutes: 10},
+			},
+		},
+		{
+			"samples out of order",
+			sleep(
+				sample{local(2, 0, 10), typedef.SleepLevelRem},
+				sample{local(2, 0, 0), typedef.SleepLevelRem},
+			),
+			map[string]Sleep{"2025-06-02": {Start: local(2, 0, 0), End: local(2, 0, 10), REMMinutes: 10}},
+		},
+		{
+			"samples without a timestamp skipped",
+			append(sleep(sample{local(2, 0, 0), typedef.SleepLevelDeep}, sample{local(2, 0, 5), typedef.SleepLevelDeep}),
+				mesgdef.NewSleepLevel(nil).SetSleepLevel(typedef.SleepLevelAwake)),
+			map[string]Sleep{"2025-06-02": {Start: local(2, 0, 0), End: local(2, 0, 5), DeepMinutes: 5}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			days := (&Data{Sleep: tc.sleep, Location: pacific}).Days()
+			if len(days) != len(tc.want) {
+				t.Fatalf("Days = %+v, want sleep on %d days", days, len(tc.want))
+			}
+			for _, d := range days {
+				want, ok := tc.want[d.Date]
+				if !ok || d.Sleep == nil {
+					t.Errorf("%s: sleep %+v, want %+v", d.Date, d.Sleep, want)
+					continue
+				}
+				got := *d.Sleep
+				if !got.Start.Equal(want.Start) || !got.End.Equal(want.End) {
+					t.Errorf("%s: sleep from %v to %v, want %v to %v", d.Date, got.Start, got.End, want.Start, want.End)
+				}
+				got.Start, got.End, want.Start, want.End = time.Time{}, time.Time{}, time.Time{}, time.Time{}
+				if got != want {
+					t.Errorf("%s: sleep %+v, want %+v", d.Date, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDays_Stress(t *testing.T) {
+	tests := []struct {
+		name   string
+		stress []*mesgdef.StressLevel
+		want   map[string]Stress
+	}{
+		{"no stress samples", nil, map[string]Stress{}},
+		{
+			"average and maximum",
+			[]*mesgdef.StressLevel{stress(local(1, 9, 0), 20), stress(local(1, 9, 3), 45), stress(local(1, 9, 6), 31)},
+			map[string]Stress{"2025-06-01": {Avg: 32, Max: 45, Samples: 3}},
+		},
+		{
+			"unmeasurable samples skipped",
+			// Garmin writes negative scores while moving or off wrist
+			[]*mesgdef.StressLevel{stress(local(1, 9, 0), -1), stress(local(1, 9, 3), 40), stress(local(1, 9, 6), -2), stress(local(1, 9, 9), 101)},
+			map[string]Stress{"2025-06-01": {Avg: 40, Max: 40, Samples: 1}},
+		},
+		{
+			"a day of only unmeasurable samples has no stress",
+			[]*mesgdef.StressLevel{stress(local(1, 9, 0), -1), stress(local(1, 9, 3), -2)},
+			map[string]Stress{},
+		},
+		{
+			"samples without a time skipped",
+			[]*mesgdef.StressLevel{stress(local(1, 9, 0), 25), mesgdef.NewStressLevel(nil).SetStressLevelValue(90)},
+			map[string]Stress{"2025-06-01": {Avg: 25, Max: 25, Samples: 1}},
+		},
+		{
+			"split at local midnight",
+			// 23:57 and 00:00 local are both June 2 in UTC
+			[]*mesgdef.StressLevel{stress(local(1, 23, 57), 10), stress(local(2, 0, 0), 50)},
+			map[string]Stress{
+				"2025-06-01": {Avg: 10, Max: 10, Samples: 1},
+				"2025-06-02": {Avg: 50, Max: 50, Samples: 1},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			days := (&Data{Stress: tc.stress, Location: pacific}).Days()
+			got := map[string]Stress{}
+			for _, d := range days {
+				if d.Stress != nil {
+					got[d.Date] = *d.Stress
+				}
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("stress = %+v, want %+v", got, tc.want)
+			}
+			for date, want := range tc.want {
+				g := got[date]
+				if math.Abs(g.Avg-want.Avg) > 1e-9 || g.Max != want.Max || g.Samples != want.Samples {
+					t.Errorf("%s: stress %+v, want %+v", date, g, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDays_HRV(t *testing.T) {
+	value := func(at time.Time, ms float64) *mesgdef.HrvValue {
+		return mesgdef.NewHrvValue(nil).SetTimestamp(at.UTC()).SetValueScaled(ms)
+	}
+	status := func(at time.Time, ms float64) *mesgdef.HrvStatusSummary {
+		return mesgdef.NewHrvStatusSummary(nil).SetTimestamp(at.UTC()).SetLastNightAverageScaled(ms)
+	}
+
+	tests := []struct {
+		name   string
+		status []*mesgdef.HrvStatusSummary
+		values []*mesgdef.HrvValue
+		want   map[string]HRV
+	}{
+		{"no HRV", nil, nil, map[string]HRV{}},
+		{
+			"status summary",
+			[]*mesgdef.HrvStatusSummary{status(local(2, 7, 0), 48).SetWeeklyAverageScaled(52).SetStatus(typedef.HrvStatusBalanced)},
+			nil,
+			map[string]HRV{"2025-06-02": {LastNightMs: 48, WeeklyMs: 52, Status: "balanced"}},
+		},
+		{
+			"status summary without a status or weekly average",
+			[]*mesgdef.HrvStatusSummary{status(local(2, 7, 0), 48).SetStatus(typedef.HrvStatusNone)},
+			nil,
+			map[string]HRV{"2025-06-02": {LastNightMs: 48}},
+		},
+		{
+			"night's values averaged on the local day the night ends",
+			nil,
+			[]*mesgdef.HrvValue{value(local(1, 23, 30), 40), value(local(1, 23, 35), 50), value(local(2, 0, 20), 60)},
+			map[string]HRV{"2025-06-02": {LastNightMs: 50}},
+		},
+		{
+			"status summary preferred over the values",
+			[]*mesgdef.HrvStatusSummary{status(local(2, 7, 0), 48)},
+			[]*mesgdef.HrvValue{value(local(2, 1, 0), 30), value(local(2, 1, 5), 40)},
+			map[string]HRV{"2025-06-02": {LastNightMs: 48}},
+		},
+		{
+			"values of days without a summary",
+			[]*mesgdef.HrvStatusSummary{status(local(2, 7, 0), 48)},
+			[]*mesgdef.HrvValue{value(local(3, 1, 0), 30), value(local(3, 1, 5), 40)},
+			map[string]HRV{"2025-06-02": {LastNightMs: 48}, "2025-06-03": {LastNightMs: 35}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			days := (&Data{HRVStatus: tc.status, HRV: tc.values, Location: pacific}).Days()
+			got := map[string]HRV{}
+			for _, d := range days {
+				if d.HRV != nil {
+					got[d.Date] = *d.HRV
+				}
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("HRV = %+v, want %+v", got, tc.want)
+			}
+			for date, want := range tc.want {
+				g := got[date]
+				if math.Abs(g.LastNightMs-want.LastNightMs) > 1e-9 || math.Abs(g.WeeklyMs-want.WeeklyMs) > 1e-9 || g.Status != want.Status {
+					t.Errorf("%s: HRV %+v, want %+v", date, g, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDays_LocalDayBoundaries(t *testing.T) {
+	// 05:00 UTC on June 2 is 22:00 on June 1 in UTC-7
+	d := &Data{
+		Monitoring: []*mesgdef.Monitoring{
+			mesgdef.NewMonitoring(nil).SetTimestamp(local(1, 22, 0).UTC()).SetActivityType(typedef.ActivityTypeWalking).SetCycles(8000),
+			mesgdef.NewMonitoring(nil).SetTimestamp(local(2, 0, 30).UTC()).SetActivityType(typedef.ActivityTypeWalking).SetCycles(150),
+		},
+		Stress: []*mesgdef.StressLevel{stress(local(1, 22, 0), 35)},
+	}
+
+	tests := []struct {
+		name string
+		loc  *time.Location
+		want []string // "date steps stressSamples"
+	}{
+		{"device zone", pacific, []string{"2025-06-01 8000 1", "2025-06-02 150 0"}},
+		{"UTC", time.UTC, []string{"2025-06-02 8000 1"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d.Location = tc.loc
+			days := d.Days()
+			if len(days) != len(tc.want) {
+				t.Fatalf("Days = %+v, want %v", days, tc.want)
+			}
+			for i, day := range days {
+				samples := 0
+				if day.Stress != nil {
+					samples = day.Stress.Samples
+				}
+				if got := fmt.Sprintf("%s %d %d", day.Date, day.Steps, samples); got != tc.want[i] {
+					t.Errorf("Days[%d] = %s, want %s", i, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Package wellness reads Garmin monitoring and wellness FIT files — all-day steps and heart
+// rate, sleep stages, HRV, and stress — and summarizes them per day.
+package wellness
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	garminactivity "garmin/internal/activity"
+
+	"github.com/muktihari/fit/profile/basetype"
+	"github.com/muktihari/fit/profile/mesgdef"
+	"github.com/muktihari/fit/profile/typedef"
+	"github.com/muktihari/fit/proto"
+)
+
+// Data holds the wellness messages of one or more FIT files. Garmin writes them across
+// several files a day (monitoring, sleep, HRV status, metrics); any of them may be added,
+// and messages of other kinds are ignored.
+type Data struct {
+	Monitoring []*mesgdef.Monitoring // with Timestamp resolved from compressed timestamps
+	Sleep      []*mesgdef.SleepLevel
+	HRVStatus  []*mesgdef.HrvStatusSummary
+	HRV        []*mesgdef.HrvValue
+	Stress     []*mesgdef.StressLevel
+	// Location is the device's time zone, from the monitoring_info local timestamp, used to
+	// assign samples to days; time.Local until a file carries one
+	Location *time.Location
+}
+
+// New returns empty wellness data.
+func New() *Data {
+	return &Data{Location: time.Local}
+}
+
+// AddFile decodes a FIT file and adds its wellness messages. In tolerant mode a corrupt
+// file contributes the messages before the corruption, which is returned.
+func (d *Data) AddFile(path string, tolerant bool) (*garminactivity.Corruption, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open FIT file: %w", err)
+	}
+	defer f.Close()
+	messages, c, err := garminactivity.DecodeMessages(f, tolerant)
+	if err != nil {
+		return nil, err
+	}
+	d.Add(messages...)
+	return c, nil
+}
+
+// Add adds the wellness messages among messages, which must be in file order: monitoring
+// messages may carry only the low 16 bits of their timestamp, relative to the last full one.
+func (d *Data) Add(messages ...proto.Message) {
+	var last time.Time
+	for i := range messages {
+		mesg := &messages[i]
+		if mesg.Num == typedef.MesgNumMonitoring {
+			m := mesgdef.NewMonitoring(mesg)
+			switch {
+			case !m.Timestamp.IsZero():
+				last = m.Timestamp
+			case m.Timestamp16 != basetype.Uint16Invalid && !last.IsZero():
+				delta := m.Timestamp16 - uint16(last.Sub(fitEpoch)/time.Second)
+				m.Timestamp = last.Add(time.Duration(delta) * time.Second)
+				last = m.Timestamp
+			default:
+				continue
+			}
+			d.Monitoring = append(d.Monitoring, m)
+			continue
+		}
+		if ts := mesg.FieldValueByNum(proto.FieldNumTimestamp); ts.Type() == proto.TypeUint32 {
+			last = garminTime(ts.Uint32())
+		}
+
+		switch mesg.Num {
+		case typedef.MesgNumMonitoringInfo:
+			info := mesgdef.NewMonitoringInfo(mesg)
+			if !info.Timestamp.IsZero() && !info.LocalTimestamp.IsZero() {
+				offset := info.LocalTimestamp.Sub(info.Timestamp)
+				d.Location = time.FixedZone("device", int(offset.Seconds()))
+			}
+		case typedef.MesgNumSleepLevel:
+			d.Sleep = append(d.Sleep, mesgdef.NewSleepLevel(mesg))
+		case typedef.MesgNumHrvStatusSummary:
+			d.HRVStatus = append(d.HRVStatus, mesgdef.NewHrvStatusSummary(mesg))
+		case typedef.MesgNumHrvValue:
+			d.HRV = append(d.HRV, mesgdef.NewHrvValue(mesg))
+		case typedef.MesgNumStressLevel:
+			d.Stress = append(d.Stress, mesgdef.NewStressLevel(mesg))
+		}
+	}
+}
+
+// Empty reports whether no wellness messages were added.
+func (d *Data) Empty() bool {
+	return len(d.Monitoring) == 0 && len(d.Sleep) == 0 && len(d.HRVStatus) == 0 && len(d.HRV) == 0 && len(d.Stress) == 0
+}
+
+// fitEpoch is the FIT timestamp origin, 1989-12-31T00:00:00Z.
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+func garminTime(seconds uint32) time.Time {
+	if seconds == basetype.Uint32Invalid {
+		return time.Time{}
+	}
+	return fitEpoch.Add(time.Duration(seconds) * time.Second)
+}
+
+// nights splits sorted times into runs separated by more than gap, returning the index
+// range [start, end) of each run.
+func nights(times []time.Time, gap time.Duration) [][2]int {
+	if len(times) == 0 {
+		return nil
+	}
+	var runs [][2]int
+	start := 0
+	for i := 1; i <= len(times); i++ {
+		if i == len(times) || times[i].Sub(times[i-1]) > gap {
+			runs = append(runs, [2]int{start, i})
+			start = i
+		}
+	}
+	return runs
+}
+
+// sortByTime sorts items by the time key returns.
+func sortByTime[T any](items []T, key func(T) time.Time) {
+	sort.SliceStable(items, func(i, j int) bool { return key(items[i]).Before(key(items[j])) })
+}
@@ -0,0 +1,236 @@
+package wellness
+
+import (
+	"testing"
+	"time"
+
+	"github.com/muktihari/fit/profile/mesgdef"
+	"github.com/muktihari/fit/profile/typedef"
+	"github.com/muktihari/fit/proto"
+)
+
+// base is a full timestamp whose low 16 bits are 0xFFF0, so compressed timestamps after it
+// wrap around.
+var base = fitEpoch.Add(time.Duration(15258*65536+0xFFF0) * time.Second)
+
+func monitoring() *mesgdef.Monitoring {
+	return mesgdef.NewMonitoring(nil)
+}
+
+func TestAdd_MonitoringTimestamps(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []proto.Message
+		want     []time.Time
+	}{
+		{
+			"full timestamps",
+			[]proto.Message{
+				monitoring().SetTimestamp(base).ToMesg(nil),
+				monitoring().SetTimestamp(base.Add(time.Minute)).ToMesg(nil),
+			},
+			[]time.Time{base, base.Add(time.Minute)},
+		},
+		{
+			"compressed after a full one",
+			[]proto.Message{
+				monitoring().SetTimestamp(base).ToMesg(nil),
+				monitoring().SetTimestamp16(0xFFF0 + 10).ToMesg(nil),
+			},
+			[]time.Time{base, base.Add(10 * time.Second)},
+		},
+		{
+			"compressed wrapping around",
+			[]proto.Message{
+				monitoring().SetTimestamp(base).ToMesg(nil),
+				monitoring().SetTimestamp16(0x0010).ToMesg(nil),
+				monitoring().SetTimestamp16(0x0100).ToMesg(nil),
+			},
+			[]time.Time{base, base.Add(32 * time.Second), base.Add(272 * time.Second)},
+		},
+		{
+			"compressed relative to another message's timestamp",
+			[]proto.Message{
+				mesgdef.NewSleepLevel(nil).SetTimestamp(base).SetSleepLevel(typedef.SleepLevelLight).ToMesg(nil),
+				monitoring().SetTimestamp16(0x002C).ToMesg(nil),
+			},
+			[]time.Time{base.Add(time.Minute)},
+		},
+		{
+			"compressed before any full timestamp dropped",
+			[]proto.Message{
+				monitoring().SetTimestamp16(0x0010).ToMesg(nil),
+				monitoring().SetTimestamp(base).ToMesg(nil),
+			},
+			[]time.Time{base},
+		},
+		{
+			"no timestamp dropped",
+			[]proto.Message{monitoring().SetHeartRate(60).ToMesg(nil)},
+			nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := New()
+			d.Add(tc.messages...)
+			if len(d.Monitoring) != len(tc.want) {
+				t.Fatalf("Add kept %d monitoring messages, want %d", len(d.Monitoring), len(tc.want))
+			}
+			for i, want := range tc.want {
+				if got := d.Monitoring[i].Timestamp; !got.Equal(want) {
+					t.Errorf("Monitoring[%d].Timestamp = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAdd_Location(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []proto.Message
+		offset   int // seconds east of UTC; -1 for time.Local
+	}{
+		{"no monitoring info", []proto.Message{monitoring().SetTimestamp(base).ToMesg(nil)}, -1},
+		{
+			"west of UTC",
+			[]proto.Message{mesgdef.NewMonitoringInfo(nil).SetTimestamp(base).SetLocalTimestamp(base.Add(-7 * time.Hour)).ToMesg(nil)},
+			-7 * 3600,
+		},
+		{
+			"east of UTC",
+			[]proto.Message{mesgdef.NewMonitoringInfo(nil).SetTimestamp(base).SetLocalTimestamp(base.Add(5*time.Hour + 30*time.Minute)).ToMesg(nil)},
+			5*3600 + 1800,
+		},
+		{
+			"without a local timestamp",
+			[]proto.Message{mesgdef.NewMonitoringInfo(nil).SetTimestamp(base).ToMesg(nil)},
+			-1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := New()
+			d.Add(tc.messages...)
+			if tc.offset == -1 {
+				if d.Location != time.Local {
+					t.Errorf("Location = %v, want time.Local", d.Location)
+				}
+				return
+			}
+			if _, got := base.In(d.Location).Zone(); got != tc.offset {
+				t.Errorf("Location offset = %d s, want %d s", got, tc.offset)
+			}
+		})
+	}
+}
+
+func TestAdd_MessageKinds(t *testing.T) {
+	d := New()
+	if !d.Empty() {
+		t.Fatal("New data is not empty")
+	}
+	d.Add(
+		mesgdef.NewRecord(nil).SetTimestamp(base).SetHeartRate(120).ToMesg(nil), // activity messages are ignored
+	)
+	if !d.Empty() {
+		t.Fatal("data holding only an activity record is not empty")
+	}
+	d.Add(
+		mesgdef.NewSleepLevel(nil).SetTimestamp(base).SetSleepLevel(typedef.SleepLevelDeep).ToMesg(nil),
+		mesgdef.NewHrvStatusSummary(nil).SetTimestamp(base).SetLastNightAverageScaled(48).ToMesg(nil),
+		mesgdef.NewHrvValue(nil).SetTimestamp(base).SetValueScaled(51).ToMesg(nil),
+		mesgdef.NewStressLevel(nil).SetStressLevelTime(base).SetStressLevelValue(30).ToMesg(nil),
+	)
+	if d.Empty() {
+		t.Fatal("data with wellness messages is empty")
+	}
+	if len(d.Sleep) != 1 || len(d.HRVStatus) != 1 || len(d.HRV) != 1 || len(d.Stress) != 1 || len(d.Monitoring) != 0 {
+		t.Errorf("Add kept sleep %d, HRV status %d, HRV %d, stress %d, monitoring %d; want one of each but monitoring",
+			len(d.Sleep), len(d.HRVStatus), len(d.HRV), len(d.Stress), len(d.Monitoring))
+	}
+}
+
+func TestDays_Monitoring(t *testing.T) {
+	day1 := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	walk := func(at time.Time, steps uint32) *mesgdef.Monitoring {
+		return monitoring().SetTimestamp(at).SetActivityType(typedef.ActivityTypeWalking).SetCycles(steps)
+	}
+	run := func(at time.Time, steps uint32) *mesgdef.Monitoring {
+		return monitoring().SetTimestamp(at).SetActivityType(typedef.ActivityTypeRunning).SetCycles(steps)
+	}
+	hr := func(at time.Time, bpm uint8) *mesgdef.Monitoring {
+		return monitoring().SetTimestamp(at).SetHeartRate(bpm)
+	}
+
+	tests := []struct {
+		name       string
+		monitoring []*mesgdef.Monitoring
+		want       []Day
+	}{
+		{"no data", nil, []Day{}},
+		{
+			"counters take each type's maximum, summed over types",
+			[]*mesgdef.Monitoring{
+				walk(day1, 1000), walk(day1.Add(time.Hour), 2500), run(day1.Add(2*time.Hour), 3000), walk(day1.Add(3*time.Hour), 2400),
+			},
+			[]Day{{Date: "2025-06-01", Steps: 5500}},
+		},
+		{
+			"cycling strokes are not steps",
+			[]*mesgdef.Monitoring{
+				walk(day1, 800),
+				monitoring().SetTimestamp(day1.Add(time.Hour)).SetActivityType(typedef.ActivityTypeCycling).SetCycles(4000),
+			},
+			[]Day{{Date: "2025-06-01", Steps: 800}},
+		},
+		{
+			"distance and calories",
+			[]*mesgdef.Monitoring{
+				walk(day1, 100).SetDistanceScaled(800).SetCalories(1500),
+				walk(day1.Add(time.Hour), 200).SetDistanceScaled(1200).SetCalories(1700),
+				run(day1.Add(2*time.Hour), 300).SetDistanceScaled(3000).SetCalories(200),
+			},
+			[]Day{{Date: "2025-06-01", Steps: 500, DistanceM: 4200, Calories: 1900}},
+		},
+		{
+			"intensity minutes take the day's maximum",
+			[]*mesgdef.Monitoring{
+				monitoring().SetTimestamp(day1).SetModerateActivityMinutes(10).SetVigorousActivityMinutes(5),
+				monitoring().SetTimestamp(day1.Add(time.Hour)).SetModerateActivityMinutes(25),
+			},
+			[]Day{{Date: "2025-06-01", ModerateMinutes: 25, VigorousMinutes: 5}},
+		},
+		{
+			"heart rate range",
+			[]*mesgdef.Monitoring{hr(day1, 62), hr(day1.Add(time.Minute), 0), hr(day1.Add(2*time.Minute), 75), hr(day1.Add(3*time.Minute), 58)},
+			[]Day{{Date: "2025-06-01", AvgHeartRate: 65, MinHeartRate: 58}},
+		},
+		{
+			"missing heart rate leaves it zero",
+			[]*mesgdef.Monitoring{walk(day1, 400)},
+			[]Day{{Date: "2025-06-01", Steps: 400}},
+		},
+		{
+			"counters restart each day",
+			[]*mesgdef.Monitoring{walk(day1, 9000), walk(day2, 1200), hr(day2, 70)},
+			[]Day{{Date: "2025-06-01", Steps: 9000}, {Date: "2025-06-02", Steps: 1200, AvgHeartRate: 70, MinHeartRate: 70}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &Data{Monitoring: tc.monitoring, Location: time.UTC}
+			got := d.Days()
+			if len(got) != len(tc.want) {
+				t.Fatalf("Days = %+v, want %+v", got, tc.want)
+			}
+			for i, want := range tc.want {
+				if got[i] != want {
+					t.Errorf("Days[%d] = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
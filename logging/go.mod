@@ -0,0 +1,3 @@
+module github.com/JerkyTreats/PHITE/logging
+
+go 1.24.3
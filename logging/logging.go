@@ -0,0 +1,282 @@
+// Package logging is the logger shared by the PHITE tools, so the converter, the risk
+// calculator, and the FIT tools agree on levels, output format, and where logs go.
+//
+// It is a thin layer over log/slog. A Logger is built from Options (level, text or JSON
+// format, an optional size-rotated log file) and tags its records with a component, e.g.
+// component=converter. Messages take key/value pairs; the f-suffixed methods format a
+// printf-style message instead.
+//
+// The PHITE_LOG_LEVEL, PHITE_LOG_FORMAT, and PHITE_LOG_FILE environment variables override
+// the configured values (see FromEnv), which is how `phite -log-level` reaches every tool.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Level names accepted by ParseLevel.
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+	LevelFatal = "fatal"
+	// LevelNone discards all records
+	LevelNone = "none"
+)
+
+// Output formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Environment variables read by FromEnv.
+const (
+	EnvLevel  = "PHITE_LOG_LEVEL"
+	EnvFormat = "PHITE_LOG_FORMAT"
+	EnvFile   = "PHITE_LOG_FILE"
+)
+
+// levelFatal sorts above slog.LevelError; levelNone above every record.
+const (
+	levelFatal = slog.LevelError + 4
+	levelNone  = slog.Level(100)
+)
+
+// ParseLevel parses a level name, case-insensitively. "warning" is accepted for warn.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case LevelDebug:
+		return slog.LevelDebug, nil
+	case LevelInfo, "":
+		return slog.LevelInfo, nil
+	case LevelWarn, "warning":
+		return slog.LevelWarn, nil
+	case LevelError:
+		return slog.LevelError, nil
+	case LevelFatal:
+		return levelFatal, nil
+	case LevelNone:
+		return levelNone, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("invalid log level: %s", s)
+	}
+}
+
+// Options configure a Logger. The zero value logs info and above as text to stderr.
+type Options struct {
+	// Level is a level name (see ParseLevel); empty means info
+	Level string
+	// Format is FormatText or FormatJSON; empty means text
+	Format string
+	// File is a log file path; empty means Output
+	File string
+	// MaxSizeMB rotates File when it would grow past this size; 0 means DefaultMaxSizeMB
+	MaxSizeMB int
+	// MaxBackups is the number of rotated files kept; 0 means DefaultMaxBackups
+	MaxBackups int
+	// Component tags every record, e.g. "converter"
+	Component string
+	// Output is where records go when File is empty; nil means os.Stderr
+	Output io.Writer
+}
+
+// FromEnv returns opts with Level, Format, and File overridden by the PHITE_LOG_*
+// environment variables that are set.
+func FromEnv(opts Options) Options {
+	if v := os.Getenv(EnvLevel); v != "" {
+		opts.Level = v
+	}
+	if v := os.Getenv(EnvFormat); v != "" {
+		opts.Format = v
+	}
+	if v := os.Getenv(EnvFile); v != "" {
+		opts.File = v
+	}
+	return opts
+}
+
+// Logger writes leveled, structured records.
+type Logger struct {
+	slog  *slog.Logger
+	level *slog.LevelVar
+	out   io.Closer
+}
+
+// New builds a Logger from opts.
+func New(opts Options) (*Logger, error) {
+	lvl, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	level := new(slog.LevelVar)
+	level.Set(lvl)
+
+	w := opts.Output
+	if w == nil {
+		w = os.Stderr
+	}
+	var closer io.Closer
+	if opts.File != "" {
+		f, err := OpenRotatingFile(opts.File, opts.MaxSizeMB, opts.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		w, closer = f, f
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level, ReplaceAttr: replaceLevel}
+	var h slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case FormatText, "":
+		h = slog.NewTextHandler(w, handlerOpts)
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, fmt.Errorf("invalid log format: %s", opts.Format)
+	}
+
+	l := slog.New(h)
+	if opts.Component != "" {
+		l = l.With("component", opts.Component)
+	}
+	return &Logger{slog: l, level: level, out: closer}, nil
+}
+
+// replaceLevel names the fatal level, which slog prints as ERROR+4.
+func replaceLevel(_ []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey {
+		if lvl, ok := a.Value.Any().(slog.Level); ok && lvl == levelFatal {
+			a.Value = slog.StringValue("FATAL")
+		}
+	}
+	return a
+}
+
+// SetLevel changes the level of l and every logger derived from it.
+func (l *Logger) SetLevel(level string) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.Set(lvl)
+	return nil
+}
+
+// Enabled reports whether records at level are written.
+func (l *Logger) Enabled(level slog.Level) bool {
+	return l.slog.Enabled(context.Background(), level)
+}
+
+// With returns a logger that adds the key/value pairs to every record.
+func (l *Logger) With(args ...any) *Logger {
+	return &Logger{slog: l.slog.With(args...), level: l.level, out: l.out}
+}
+
+// Component returns a logger tagged with a (sub)component, e.g. "converter.watch".
+func (l *Logger) Component(name string) *Logger {
+	return l.With("component", name)
+}
+
+// Slog returns the underlying slog.Logger.
+func (l *Logger) Slog() *slog.Logger {
+	return l.slog
+}
+
+// Close closes the log file, if any.
+func (l *Logger) Close() error {
+	if l.out == nil {
+		return nil
+	}
+	return l.out.Close()
+}
+
+// Debug, Info, Warn, and Error log msg with key/value pairs.
+func (l *Logger) Debug(msg string, args ...any) { l.log(slog.LevelDebug, msg, args...) }
+func (l *Logger) Info(msg string, args ...any)  { l.log(slog.LevelInfo, msg, args...) }
+func (l *Logger) Warn(msg string, args ...any)  { l.log(slog.LevelWarn, msg, args...) }
+func (l *Logger) Error(msg string, args ...any) { l.log(slog.LevelError, msg, args...) }
+
+// Fatal logs at fatal level and exits with status 1.
+func (l *Logger) Fatal(msg string, args ...any) {
+	l.log(levelFatal, msg, args...)
+	l.Close()
+	os.Exit(1)
+}
+
+// Debugf, Infof, Warnf, and Errorf log a printf-style message.
+func (l *Logger) Debugf(format string, args ...any) { l.logf(slog.LevelDebug, format, args...) }
+func (l *Logger) Infof(format string, args ...any)  { l.logf(slog.LevelInfo, format, args...) }
+func (l *Logger) Warnf(format string, args ...any)  { l.logf(slog.LevelWarn, format, args...) }
+func (l *Logger) Errorf(format string, args ...any) { l.logf(slog.LevelError, format, args...) }
+
+func (l *Logger) log(level slog.Level, msg string, args ...any) {
+	l.slog.Log(context.Background(), level, msg, args...)
+}
+
+func (l *Logger) logf(level slog.Level, format string, args ...any) {
+	if !l.Enabled(level) {
+		return
+	}
+	l.slog.Log(context.Background(), level, fmt.Sprintf(format, args...))
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger *Logger
+)
+
+// Default returns the process-wide logger, built from FromEnv(Options{}) on first use.
+func Default() *Logger {
+	defaultMu.RLock()
+	l := defaultLogger
+	defaultMu.RUnlock()
+	if l != nil {
+		return l
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultLogger == nil {
+		l, err := New(FromEnv(Options{}))
+		if err != nil {
+			l, _ = New(Options{})
+		}
+		defaultLogger = l
+	}
+	return defaultLogger
+}
+
+// SetDefault replaces the process-wide logger.
+func SetDefault(l *Logger) {
+	defaultMu.Lock()
+	defaultLogger = l
+	defaultMu.Unlock()
+}
+
+// Configure builds a logger from opts and makes it the process-wide logger, closing the
+// previous one's log file.
+func Configure(opts Options) (*Logger, error) {
+	l, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+	defaultMu.Lock()
+	prev := defaultLogger
+	defaultLogger = l
+	defaultMu.Unlock()
+	if prev != nil {
+		prev.Close()
+	}
+	return l, nil
+}
@@ -0,0 +1,117 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Options{Level: "WARN", Output: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	l.Errorf("error %d", 42)
+
+	out := buf.String()
+	if strings.Contains(out, "msg=debug") || strings.Contains(out, "msg=info") {
+		t.Errorf("records below warn were written:\n%s", out)
+	}
+	if !strings.Contains(out, "msg=warn") || !strings.Contains(out, `msg="error 42"`) {
+		t.Errorf("missing warn/error records:\n%s", out)
+	}
+
+	if err := l.SetLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	l.Component("child").Debug("now visible")
+	if !strings.Contains(buf.String(), "now visible") {
+		t.Error("SetLevel did not apply to derived loggers")
+	}
+}
+
+func TestNoneSilencesEverything(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Options{Level: LevelNone, Output: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Error("error")
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	if _, err := New(Options{Level: "loud"}); err == nil {
+		t.Error("expected an error for an invalid level")
+	}
+	if _, err := New(Options{Format: "xml"}); err == nil {
+		t.Error("expected an error for an invalid format")
+	}
+}
+
+func TestJSONWithComponent(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Options{Format: FormatJSON, Component: "converter", Output: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("wrote file", "path", "out.json")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("not JSON: %v\n%s", err, buf.String())
+	}
+	if rec["component"] != "converter" || rec["msg"] != "wrote file" || rec["path"] != "out.json" || rec["level"] != "INFO" {
+		t.Errorf("unexpected record: %v", rec)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(EnvLevel, "debug")
+	t.Setenv(EnvFormat, "")
+	opts := FromEnv(Options{Level: "info", Format: FormatJSON})
+	if opts.Level != "debug" || opts.Format != FormatJSON {
+		t.Errorf("FromEnv = %+v", opts)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "phite.log")
+	r, err := OpenRotatingFile(path, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.maxBytes = 10 // rotate every other write
+
+	for _, s := range []string{"aaaaaa", "bbbbbb", "cccccc", "dddddd"} {
+		if _, err := r.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{path: "dddddd", path + ".1": "cccccc", path + ".2": "bbbbbb"}
+	for file, content := range want {
+		got, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Errorf("%s = %q, want %q", file, got, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("kept more than MaxBackups files")
+	}
+}
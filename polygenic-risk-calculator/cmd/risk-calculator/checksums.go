@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/integrity"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/output"
+)
+
+// verifyInputs checks the run's input files against the checksum manifest and returns the
+// provenance record to embed in the output. Verification failures are fatal only with
+// --require-checksums; otherwise they are logged and recorded as unverified.
+func verifyInputs(opts cli.Options) (*output.Provenance, error) {
+	manifest, err := integrity.ParseManifest(opts.ChecksumManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{opts.GenotypeFile}
+	if opts.SNPsFile != "" {
+		files = append(files, opts.SNPsFile)
+	}
+	if gwasDB := config.GetString("gwas_db_path"); gwasDB != "" {
+		files = append(files, gwasDB)
+	}
+
+	checksums, err := integrity.Verify(manifest, files, opts.RequireChecksums)
+	if err != nil {
+		if opts.RequireChecksums {
+			return nil, fmt.Errorf("checksum verification failed: %w", err)
+		}
+		logging.Warn("Checksum verification issues (continuing without --require-checksums): %v", err)
+	}
+	return &output.Provenance{InputFiles: checksums}, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/cohort"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/output"
+	"phite.io/polygenic-risk-calculator/internal/pipeline"
+	"phite.io/polygenic-risk-calculator/internal/reference"
+)
+
+// runCohort handles `risk-calculator cohort`. Returns exit code.
+func runCohort(args []string) int {
+	opts, err := cli.ParseCohortOptions(args)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintCohortHelp()
+		return 1
+	}
+	samples, err := cohort.ReadManifest(opts.Manifest)
+	if err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+	if config.GetString(ancestry.CohortKey) != "" && cohort.HasAncestries(samples) {
+		logging.Error("the manifest's ancestry column needs gnomAD reference populations: unset %s", ancestry.CohortKey)
+		return 1
+	}
+	fallback, fallbackErr := ancestry.NewFromConfig()
+	groups, err := cohort.GroupByAncestry(samples, fallback)
+	if err != nil {
+		logging.Error("%v: %v", err, fallbackErr)
+		return 1
+	}
+
+	if len(config.MissingKeys) > 0 {
+		logging.Error("missing required configuration keys: %v", config.MissingKeys)
+		return 1
+	}
+	if err := localizeConfigInputs(); err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+	rs, err := reference.NewReferenceService(nil, nil, nil)
+	if err != nil {
+		logging.Error("failed to create reference service: %v", err)
+		return 1
+	}
+	if !config.GetBool(reference.SkipTableProbeKey) {
+		if err := rs.ProbeAlleleFrequencyTable(context.Background()); err != nil {
+			logging.Error("startup probe failed: %v", err)
+			return 1
+		}
+	}
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		logging.Error("failed to create %s: %v", opts.OutputDir, err)
+		return 1
+	}
+
+	// The first sample computes the reference stats of every group in one frequency query;
+	// later samples find them cached.
+	if len(groups) > 1 {
+		extra := config.GetStringSlice(pipeline.ExtraAncestriesKey)
+		for _, g := range groups {
+			extra = append(extra, g.Ancestry.Code())
+		}
+		config.Set(pipeline.ExtraAncestriesKey, extra)
+	}
+
+	scored := 0
+	for _, g := range groups {
+		if !g.Ancestry.IsCustomCohort() {
+			config.Set(ancestry.PopulationKey, g.Ancestry.Population())
+			config.Set(ancestry.GenderKey, g.Ancestry.Gender())
+		}
+		logging.Info("Scoring %d samples against %s reference stats", len(g.Samples), g.Ancestry.Code())
+		for _, s := range g.Samples {
+			if err := scoreSample(rs.ForRun(), s, opts); err != nil {
+				logging.Error("sample %s: %v", s.ID, err)
+				continue
+			}
+			scored++
+		}
+	}
+	logging.Info("Cohort: scored %d of %d samples in %d ancestry groups into %s", scored, len(samples), len(groups), opts.OutputDir)
+	if scored < len(samples) {
+		return 1
+	}
+	return 0
+}
+
+// scoreSample scores one sample with rs and writes its results to opts.OutputDir. Samples
+// share the cohort's clients and caches; rs carries this sample's allele and contig tallies.
+func scoreSample(rs *reference.ReferenceService, s cohort.Sample, opts cli.CohortOptions) error {
+	out, err := pipeline.Run(pipeline.PipelineInput{
+		GenotypeFile:   s.GenotypeFile,
+		SNPs:           opts.SNPs,
+		ReferenceTable: opts.ReferenceTable,
+		OutputFormat:   "json",
+	}, rs)
+	if err != nil {
+		return err
+	}
+	return output.Write(outputResult(out, &output.Provenance{}), "json", filepath.Join(opts.OutputDir, s.ID+".json"), nil)
+}
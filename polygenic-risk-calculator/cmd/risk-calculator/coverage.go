@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/contig"
+	"phite.io/polygenic-risk-calculator/internal/genotype"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/panel"
+	"phite.io/polygenic-risk-calculator/internal/reference"
+)
+
+// traitCoverage is one trait's row of the coverage report.
+type traitCoverage struct {
+	Trait string `json:"trait"`
+	Model string `json:"model"`
+	genotype.ModelCoverage
+	PanelVariants int    `json:"panel_variants,omitempty"` // the panel's variants for the trait
+	PanelCovered  int    `json:"panel_covered,omitempty"`  // of those, on the array
+	Supported     bool   `json:"supported"`                // weight coverage reaches --min-coverage
+	Error         string `json:"error,omitempty"`          // why the model could not be loaded
+}
+
+// runCoverage handles `risk-calculator coverage`. Returns exit code.
+func runCoverage(args []string, stdout io.Writer) int {
+	opts, err := cli.ParseCoverageOptions(args)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintCoverageHelp()
+		return 1
+	}
+	if err := localizeConfigInputs(); err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+
+	contigs, err := contig.FromConfig()
+	if err != nil {
+		logging.Error("invalid contig configuration: %v", err)
+		return 1
+	}
+	manifest, err := genotype.ReadManifest(opts.Manifest, contigs)
+	if err != nil {
+		logging.Error("failed to read manifest: %v", err)
+		return 1
+	}
+	logging.Info("Manifest %s lists %d sites", opts.Manifest, manifest.Sites)
+
+	rs, err := reference.NewReferenceService(nil, nil, nil)
+	if err != nil {
+		logging.Error("Failed to create reference service: %v", err)
+		return 1
+	}
+	if opts.Panel != nil {
+		if models := opts.Panel.Models(); len(models) > 0 {
+			merged := maps.Clone(rs.TraitModels())
+			if merged == nil {
+				merged = make(map[string]string, len(models))
+			}
+			maps.Copy(merged, models)
+			rs.SetTraitModels(merged)
+		}
+	}
+
+	report := make([]traitCoverage, 0, len(opts.Traits))
+	supported := true
+	for _, trait := range opts.Traits {
+		row := coverageOf(context.Background(), rs, manifest, opts.Panel, trait)
+		row.Supported = row.Error == "" && row.WeightCovered >= opts.MinCoverage
+		supported = supported && row.Supported
+		report = append(report, row)
+	}
+
+	if opts.Format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			logging.Error("failed to write coverage report: %v", err)
+			return 1
+		}
+	} else {
+		writeCoverageText(stdout, report)
+	}
+	if !supported {
+		return 2
+	}
+	return 0
+}
+
+// coverageOf loads trait's model and counts its variants, and the panel's, on the array.
+func coverageOf(ctx context.Context, rs *reference.ReferenceService, manifest *genotype.Manifest, p *panel.Panel, trait string) traitCoverage {
+	row := traitCoverage{Trait: trait, Model: rs.ModelID(trait)}
+	if p != nil {
+		for _, t := range p.Traits {
+			if t.Trait != trait {
+				continue
+			}
+			row.PanelVariants = len(t.SNPs)
+			for _, id := range t.SNPs {
+				if manifest.Has(id, "", 0) {
+					row.PanelCovered++
+				}
+			}
+		}
+	}
+	m, err := rs.LoadModel(ctx, trait)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.ModelCoverage = manifest.Coverage(m.Variants)
+	return row
+}
+
+func writeCoverageText(w io.Writer, report []traitCoverage) {
+	fmt.Fprintf(w, "%-30s %-20s %9s %9s %7s %s\n", "trait", "model", "variants", "on array", "weight", "status")
+	for _, r := range report {
+		status := "ok"
+		switch {
+		case r.Error != "":
+			status = "ERROR " + r.Error
+		case !r.Supported:
+			status = "LOW"
+		}
+		if r.PanelVariants > 0 {
+			status += fmt.Sprintf(" (panel variants %d/%d)", r.PanelCovered, r.PanelVariants)
+		}
+		fmt.Fprintf(w, "%-30s %-20s %9d %9d %6.1f%% %s\n", r.Trait, r.Model, r.Variants, r.Covered, 100*r.WeightCovered, status)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"io"
+
+	"phite.io/polygenic-risk-calculator/internal/buildinfo"
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/workflow"
+)
+
+// runDescriptor handles `risk-calculator descriptor`. Returns exit code.
+func runDescriptor(args []string, stdout io.Writer) int {
+	opts, err := cli.ParseDescriptorOptions(args)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintDescriptorHelp()
+		return 1
+	}
+
+	task := workflow.Task{
+		Name:      "phite_risk_calculator",
+		Command:   "risk-calculator",
+		Args:      []string{"--machine-readable-io", "--format", "json"},
+		Version:   buildinfo.Current().Version,
+		Container: opts.Container,
+		Inputs:    cli.WorkflowInputs(),
+		Results:   "results.json",
+		Manifest:  "manifest.json",
+	}
+	if err := workflow.Render(stdout, opts.Engine, task); err != nil {
+		logging.Error("failed to write descriptor: %v", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/output"
+)
+
+// runDiff handles `risk-calculator diff`. Returns exit code.
+func runDiff(args []string, stdout io.Writer) int {
+	opts, err := cli.ParseDiffOptions(args)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintDiffHelp()
+		return 1
+	}
+
+	a, err := output.ReadResult(opts.A)
+	if err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+	b, err := output.ReadResult(opts.B)
+	if err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+
+	d := output.Compare(a, b, output.ToleranceFromConfig())
+	if opts.Format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(d); err != nil {
+			logging.Error("failed to write diff report: %v", err)
+			return 1
+		}
+	} else {
+		writeDiffText(stdout, d, opts.A, opts.B)
+	}
+
+	if !d.Equal() {
+		return 2
+	}
+	return 0
+}
+
+func writeDiffText(w io.Writer, d output.Diff, a, b string) {
+	if d.Model != nil {
+		fmt.Fprintf(w, "model  %+v -> %+v\n", d.Model.A, d.Model.B)
+	}
+	for _, t := range d.Traits {
+		switch t.Change {
+		case output.TraitAdded:
+			fmt.Fprintf(w, "+ %s  only in %s\n", t.Trait, b)
+		case output.TraitRemoved:
+			fmt.Fprintf(w, "- %s  only in %s\n", t.Trait, a)
+		default:
+			for _, f := range t.Fields {
+				if f.Delta != 0 {
+					fmt.Fprintf(w, "~ %s  %s %v -> %v (%+g)\n", t.Trait, f.Field, f.A, f.B, f.Delta)
+				} else {
+					fmt.Fprintf(w, "~ %s  %s %v -> %v\n", t.Trait, f.Field, f.A, f.B)
+				}
+			}
+		}
+	}
+	for _, set := range []struct {
+		label string
+		diff  output.SetDiff
+	}{{"missing SNP", d.SNPsMissing}, {"excluded SNP", d.ExcludedSNPs}} {
+		for _, s := range set.diff.Added {
+			fmt.Fprintf(w, "+ %s %s\n", set.label, s)
+		}
+		for _, s := range set.diff.Removed {
+			fmt.Fprintf(w, "- %s %s\n", set.label, s)
+		}
+	}
+	if d.Equal() {
+		fmt.Fprintf(w, "outputs match within tolerance (score %g, percentile %g)\n", d.Tolerance.Score, d.Tolerance.Percentile)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/contig"
+	"phite.io/polygenic-risk-calculator/internal/dosage"
+	"phite.io/polygenic-risk-calculator/internal/genotype"
+	"phite.io/polygenic-risk-calculator/internal/gwas"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/reference"
+)
+
+// runExplainDosage handles `risk-calculator explain-dosage`. Returns exit code.
+func runExplainDosage(args []string, stdout io.Writer) int {
+	opts, err := cli.ParseExplainDosageOptions(args)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintExplainDosageHelp()
+		return 1
+	}
+	if err := localizeConfigInputs(); err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+
+	anc, err := ancestry.NewFromConfig()
+	if err != nil {
+		logging.Error("Failed to initialize ancestry: %v", err)
+		return 1
+	}
+	selector, err := dosage.NewSelectorFromConfig()
+	if err != nil {
+		logging.Error("invalid dosage configuration: %v", err)
+		return 1
+	}
+	dropAmbiguous, err := gwas.DropAmbiguousFromConfig()
+	if err != nil {
+		logging.Error("invalid allele harmonization configuration: %v", err)
+		return 1
+	}
+	contigs, err := contig.FromConfig()
+	if err != nil {
+		logging.Error("invalid contig configuration: %v", err)
+		return 1
+	}
+	calls, err := genotype.ReadCalls(opts.GenotypeFile)
+	if err != nil {
+		logging.Error("failed to read genotype file: %v", err)
+		return 1
+	}
+	gwasService := gwas.NewGWASService()
+	if gwasService == nil {
+		logging.Error("failed to initialize GWAS service")
+		return 1
+	}
+	records, err := gwasService.FetchGWASRecords(context.Background(), opts.SNPs, anc)
+	if err != nil {
+		logging.Error("failed to fetch GWAS records: %v", err)
+		return 1
+	}
+	traitModels, err := reference.TraitModelsFromConfig()
+	if err != nil {
+		logging.Error("invalid trait model map: %v", err)
+		return 1
+	}
+	recordList := gwas.MapToGWASList(records)
+	reference.AssignModels(recordList, traitModels)
+	explanations := gwas.ExplainDosage(gwas.ExplainDosageInput{
+		RSIDs:         opts.SNPs,
+		Calls:         calls,
+		Records:       recordList,
+		Dosage:        selector,
+		Contigs:       contigs,
+		DropAmbiguous: dropAmbiguous,
+	})
+	if opts.Trait != "" {
+		explanations = onlyTrait(explanations, opts.Trait)
+	}
+
+	if opts.Format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(explanations); err != nil {
+			logging.Error("failed to write dosage report: %v", err)
+			return 1
+		}
+		return 0
+	}
+	writeExplanationText(stdout, explanations)
+	return 0
+}
+
+// onlyTrait keeps the rows of trait, and of rsids with no association at all.
+func onlyTrait(explanations []gwas.DosageExplanation, trait string) []gwas.DosageExplanation {
+	kept := explanations[:0]
+	for _, e := range explanations {
+		if strings.EqualFold(e.Trait, trait) || e.Reason == gwas.ReasonNoAssociation {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+func writeExplanationText(w io.Writer, explanations []gwas.DosageExplanation) {
+	fmt.Fprintf(w, "%-12s %-20s %-8s %-6s %-6s %-7s %-18s %s\n", "rsid", "trait", "observed", "effect", "other", "dosage", "reason", "detail")
+	for _, e := range explanations {
+		dosage := "-"
+		if e.Scored {
+			dosage = fmt.Sprintf("%g", e.Dosage)
+		}
+		observed := e.Observed
+		if observed == "" {
+			observed = "-"
+		}
+		fmt.Fprintf(w, "%-12s %-20s %-8s %-6s %-6s %-7s %-18s %s\n", e.RSID, e.Trait, observed, e.EffectAllele, e.OtherAllele, dosage, e.Reason, e.Detail)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/fingerprint"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// runFingerprint handles `risk-calculator fingerprint`. Returns exit code.
+func runFingerprint(args []string, stdout io.Writer) int {
+	opts, err := cli.ParseFingerprintOptions(args)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintFingerprintHelp()
+		return 1
+	}
+
+	panel := fingerprint.PanelFromConfig()
+	fps := make([]fingerprint.Fingerprint, 0, len(opts.Files))
+	for _, file := range opts.Files {
+		fp, err := fingerprint.FromFile(file, panel)
+		if err != nil {
+			logging.Error("%v", err)
+			return 1
+		}
+		if fp.Called() < len(panel)/2 {
+			logging.Warn("Sample %s called only %d/%d panel SNPs; duplicate detection is less reliable", file, fp.Called(), len(panel))
+		}
+		fps = append(fps, fp)
+	}
+
+	matches := fingerprint.FindDuplicates(fps, fingerprint.OptionsFromConfig())
+	if opts.Format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			Samples []fingerprint.Fingerprint `json:"samples"`
+			Matches []fingerprint.Match       `json:"matches"`
+		}{fps, matches}); err != nil {
+			logging.Error("failed to write fingerprint report: %v", err)
+			return 1
+		}
+	} else {
+		for _, m := range matches {
+			fmt.Fprintf(stdout, "MATCH  %s  %s  %d shared SNPs, %.1f%% concordant\n", m.SampleA, m.SampleB, m.Shared, m.Concordance*100)
+		}
+		if len(matches) == 0 {
+			fmt.Fprintf(stdout, "no duplicate samples among %d files\n", len(fps))
+		}
+	}
+
+	if len(matches) > 0 {
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	reference_cache "phite.io/polygenic-risk-calculator/internal/reference/cache"
+	"phite.io/polygenic-risk-calculator/internal/retention"
+)
+
+// runGC handles `risk-calculator gc`. Returns exit code.
+func runGC(args []string, stdout io.Writer) int {
+	opts, err := cli.ParseGCOptions(args)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintGCHelp()
+		return 1
+	}
+
+	policy := retention.PolicyFromConfig()
+	if policy.CacheMaxAge == 0 && policy.ResultsMaxAge == 0 {
+		logging.Warn("No retention configured; set %s or %s", retention.CacheMaxAgeDaysKey, retention.ResultsMaxAgeDaysKey)
+	}
+
+	collector, err := newCollector(policy)
+	if err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+	report, runErr := collector.Run(context.Background())
+	if err := writeGCReport(report, opts.Format, stdout); err != nil {
+		logging.Error("failed to write gc report: %v", err)
+		return 1
+	}
+	if runErr != nil {
+		logging.Error("Retention error: %v", runErr)
+		return 1
+	}
+	return 0
+}
+
+// newCollector returns a collector for policy, with the configured cache when cache
+// entries expire.
+func newCollector(policy retention.Policy) (*retention.Collector, error) {
+	var pruner retention.CachePruner
+	if policy.CacheMaxAge > 0 {
+		cache, err := reference_cache.NewFromConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cache repository: %w", err)
+		}
+		pruner = cache
+	}
+	return retention.NewCollector(policy, pruner), nil
+}
+
+// scheduleRetention collects expired cache entries and results every retention.interval
+// until ctx ends. Nothing is scheduled when no interval or no retention is configured.
+func scheduleRetention(ctx context.Context) error {
+	interval := retention.IntervalFromConfig()
+	if interval == 0 {
+		return nil
+	}
+	policy := retention.PolicyFromConfig()
+	if policy.CacheMaxAge == 0 && policy.ResultsMaxAge == 0 {
+		logging.Warn("%s is set but no retention is configured; set %s or %s", retention.IntervalKey, retention.CacheMaxAgeDaysKey, retention.ResultsMaxAgeDaysKey)
+		return nil
+	}
+	collector, err := newCollector(policy)
+	if err != nil {
+		return err
+	}
+	go retention.Schedule(ctx, collector, interval)
+	logging.Info("Collecting expired cache entries and results every %s", interval)
+	return nil
+}
+
+func writeGCReport(report retention.Report, format string, w io.Writer) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	if !report.CacheCutoff.IsZero() {
+		fmt.Fprintf(w, "cache: entries before %s deleted=%t\n", report.CacheCutoff.Format(time.RFC3339), report.CachePruned)
+	}
+	if !report.ResultsCutoff.IsZero() {
+		fmt.Fprintf(w, "results: %d files before %s removed\n", len(report.ResultsRemoved), report.ResultsCutoff.Format(time.RFC3339))
+		for _, path := range report.ResultsRemoved {
+			fmt.Fprintf(w, "  %s\n", path)
+		}
+	}
+	return nil
+}
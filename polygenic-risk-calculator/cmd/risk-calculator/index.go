@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/db/duckdb"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// indexTimingSamples is the number of distinct values each timed lookup filters on,
+// roughly the size of a typical SNP request.
+const indexTimingSamples = 500
+
+// indexReport is the output of `risk-calculator index`.
+type indexReport struct {
+	Recommendations []duckdb.Recommendation `json:"recommendations"`
+	Applied         bool                    `json:"applied"`
+	Timings         []indexTiming           `json:"timings,omitempty"`
+}
+
+// indexTiming is how long one access took before and after applying the recommendations.
+type indexTiming struct {
+	Access duckdb.Access `json:"access"`
+	Before time.Duration `json:"before_ns"`
+	After  time.Duration `json:"after_ns"`
+}
+
+// runIndex handles `risk-calculator index`. Returns exit code.
+func runIndex(args []string, stdout io.Writer) int {
+	opts, err := cli.ParseIndexOptions(args)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintIndexHelp()
+		return 1
+	}
+
+	var accesses []duckdb.Access
+	if table := config.GetString("gwas_table"); table != "" {
+		accesses = append(accesses, duckdb.Access{Table: table, Column: "rsid", Pattern: duckdb.InListFilter})
+	}
+	if table := config.GetString(config.TableModelTableKey); table != "" {
+		accesses = append(accesses, duckdb.Access{Table: table, Column: "trait", Pattern: duckdb.EqualityFilter})
+	}
+	if len(accesses) == 0 {
+		logging.Error("No tables to advise on: set gwas_table or %s", config.TableModelTableKey)
+		return 1
+	}
+
+	db, err := duckdb.OpenDB(config.GetString("gwas_db_path"))
+	if err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	recs, err := duckdb.Advise(ctx, db, accesses)
+	if err != nil {
+		logging.Error("Index advisor failed: %v", err)
+		return 1
+	}
+	report := indexReport{Recommendations: recs}
+
+	if opts.Apply {
+		timings := make([]indexTiming, len(accesses))
+		for i, a := range accesses {
+			timings[i].Access = a
+			if timings[i].Before, err = duckdb.TimeAccess(ctx, db, a, indexTimingSamples); err != nil {
+				logging.Error("%v", err)
+				return 1
+			}
+		}
+		if err := duckdb.Apply(ctx, db, recs); err != nil {
+			logging.Error("%v", err)
+			return 1
+		}
+		for i, a := range accesses {
+			if timings[i].After, err = duckdb.TimeAccess(ctx, db, a, indexTimingSamples); err != nil {
+				logging.Error("%v", err)
+				return 1
+			}
+		}
+		report.Applied = true
+		report.Timings = timings
+	}
+
+	if err := writeIndexReport(report, opts.Format, stdout); err != nil {
+		logging.Error("failed to write index report: %v", err)
+		return 1
+	}
+	return 0
+}
+
+func writeIndexReport(report indexReport, format string, w io.Writer) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	for _, r := range report.Recommendations {
+		status := "TODO"
+		switch {
+		case r.Satisfied:
+			status = "OK"
+		case r.Statement == "":
+			status = "SKIP"
+		case report.Applied:
+			status = "DONE"
+		}
+		fmt.Fprintf(w, "%-4s  %-5s  %s.%s  %s\n", status, r.Kind, r.Access.Table, r.Access.Column, r.Reason)
+		if r.Statement != "" && !report.Applied {
+			fmt.Fprintf(w, "      %s;\n", r.Statement)
+		}
+	}
+	for _, t := range report.Timings {
+		fmt.Fprintf(w, "timing  %s.%s  %s -> %s\n", t.Access.Table, t.Access.Column, t.Before.Round(time.Microsecond), t.After.Round(time.Microsecond))
+	}
+	return nil
+}
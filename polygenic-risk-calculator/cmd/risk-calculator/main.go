@@ -1,25 +1,103 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"os"
 
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
+	"phite.io/polygenic-risk-calculator/internal/buildinfo"
 	"phite.io/polygenic-risk-calculator/internal/cli"
 	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/contig"
+	"phite.io/polygenic-risk-calculator/internal/db/querylog"
+	"phite.io/polygenic-risk-calculator/internal/haplotype"
+	"phite.io/polygenic-risk-calculator/internal/input"
 	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
 	"phite.io/polygenic-risk-calculator/internal/output"
+	"phite.io/polygenic-risk-calculator/internal/pgx"
 	"phite.io/polygenic-risk-calculator/internal/pipeline"
-	"phite.io/polygenic-risk-calculator/internal/prs"
+	"phite.io/polygenic-risk-calculator/internal/reference"
 )
 
 // RunCLI parses arguments and runs the entrypoint logic. Returns exit code.
+// Results are written to stdout and logs to stderr, so output can be piped.
 func RunCLI(args []string, stdout, stderr io.Writer) int {
+	args, global, err := cli.SplitGlobalFlags(args)
+	logging.SetOutput(stderr)
+	logging.SetQuiet(global.Quiet)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		return 1
+	}
 	logging.Info("PHITE CLI started with args: %v", args)
+	defer input.Cleanup()
+
+	if global.Config != "" {
+		path, err := input.Localize(context.Background(), global.Config)
+		if err != nil {
+			logging.Error("--config: %v", err)
+			return 1
+		}
+		config.SetConfigPath(path)
+		if err := config.Reload(); err != nil {
+			logging.Error("--config: %v", err)
+			return 1
+		}
+	}
+
+	queryLog, err := querylog.Start(global.QueryLog)
+	if err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+	if queryLog != nil {
+		logging.Info("Recording database queries to %s", queryLog.Path())
+		defer queryLog.Close()
+	}
 
 	defer func() {
+		logging.FlushRepeated()
 		logging.Info("PHITE CLI exiting")
 	}()
 
+	if len(args) > 0 {
+		switch args[0] {
+		case "schema":
+			return runSchema(args[1:], stdout)
+		case "gc":
+			return runGC(args[1:], stdout)
+		case "fingerprint":
+			return runFingerprint(args[1:], stdout)
+		case "index":
+			return runIndex(args[1:], stdout)
+		case "diff":
+			return runDiff(args[1:], stdout)
+		case "validate-stats":
+			return runValidateStats(args[1:], stdout)
+		case "explain-dosage":
+			return runExplainDosage(args[1:], stdout)
+		case "version":
+			return runVersion(args[1:], stdout)
+		case "descriptor":
+			return runDescriptor(args[1:], stdout)
+		case "worker":
+			return runWorker(args[1:], global, stderr)
+		case "panels":
+			return runPanels(args[1:], stdout)
+		case "coverage":
+			return runCoverage(args[1:], stdout)
+		case "research-export":
+			return runResearchExport(args[1:], stdout)
+		case "serve":
+			return runServe(args[1:])
+		case "cohort":
+			return runCohort(args[1:])
+		}
+	}
+
 	opts, err := cli.ParseOptions(args)
 	if err != nil {
 		logging.Error("parameter error: %v", err)
@@ -27,68 +105,164 @@ func RunCLI(args []string, stdout, stderr io.Writer) int {
 		return 1
 	}
 
+	outputs, err := score(opts, stdout)
+	if opts.MachineReadableIO {
+		if merr := writeIOManifest(stdout, opts, outputs, err); merr != nil {
+			logging.Error("failed to write I/O manifest: %v", merr)
+			return 1
+		}
+	}
+	if err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+	return 0
+}
+
+// score runs the pipeline on opts and writes the results, returning the files written
+// (none when results go to stdout).
+func score(opts cli.Options, stdout io.Writer) ([]string, error) {
+	// Check for missing required keys early in RunCLI
+	if len(config.MissingKeys) > 0 {
+		return nil, fmt.Errorf("missing required configuration keys: %v", config.MissingKeys)
+	}
+	if err := localizeConfigInputs(); err != nil {
+		return nil, err
+	}
+
+	var runKey string
+	if usesRunKey(opts) {
+		var err error
+		if runKey, err = computeRunKey(opts); err != nil {
+			return nil, fmt.Errorf("failed to compute run key: %w", err)
+		}
+		applyRunKey(&opts, runKey)
+		logging.Info("Run key: %s", runKey)
+	}
+	if opts.SkipIfUnchanged {
+		prev, err := output.ReadPreviousRun(opts.Output, opts.OutputDir)
+		if err != nil {
+			return nil, err
+		}
+		if prev.RunKey == runKey {
+			touchOutputs(prev.Files)
+			logging.Info("Inputs and config unchanged since the previous run; keeping its results")
+			return prev.Files, nil
+		}
+		if prev.RunKey != "" {
+			logging.Info("Inputs or config changed since the previous run (run key %s); rescoring", prev.RunKey)
+		}
+	}
+
 	pipelineInput := pipeline.PipelineInput{
 		GenotypeFile:   opts.GenotypeFile,
 		SNPs:           opts.SNPs,
 		ReferenceTable: opts.ReferenceTable,
+		OutputFormat:   opts.Format,
+		OutputPath:     opts.Output,
 	}
 
-	// Check for missing required keys early in RunCLI
-	if len(config.MissingKeys) > 0 {
-		logging.Error("Missing required configuration keys: %v", config.MissingKeys)
-		return 1
+	var provenance *output.Provenance
+	if opts.ChecksumManifest != "" {
+		var err error
+		provenance, err = verifyInputs(opts)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	outputData, err := pipeline.Run(pipelineInput)
+	rs, err := reference.NewReferenceService(nil, nil, nil)
 	if err != nil {
-		logging.Error("Pipeline error: %v", err)
-		return 1
+		return nil, fmt.Errorf("failed to create reference service: %w", err)
+	}
+
+	// Fail before any bulk query if the allele frequency table does not resolve
+	if !config.GetBool(reference.SkipTableProbeKey) {
+		if err := rs.ProbeAlleleFrequencyTable(context.Background()); err != nil {
+			return nil, fmt.Errorf("startup probe failed: %w", err)
+		}
+	}
+
+	outputData, err := pipeline.Run(pipelineInput, rs)
+	if err != nil {
+		return nil, fmt.Errorf("pipeline error: %w", err)
 	}
 
 	// Output results (formatting)
 	logging.Info("Formatting output: format=%s, outputPath=%s", opts.Format, opts.Output)
-	// For compatibility, output only the first trait's PRS and normalized PRS if present
-	var normPRSVal interface{} = nil
-	var prsResultVal interface{} = nil
-	if len(outputData.NormalizedPRS) > 0 {
-		for _, v := range outputData.NormalizedPRS {
-			normPRSVal = v
-			break
-		}
+	if provenance == nil {
+		provenance = &output.Provenance{}
 	}
-	if len(outputData.PRSResults) > 0 {
-		for _, v := range outputData.PRSResults {
-			prsResultVal = v
-			break
+	provenance.RunKey = runKey
+	result := outputResult(outputData, provenance)
+	var written []string
+	if opts.OutputDir != "" {
+		written, err = output.WriteSplit(result, opts.OutputDir)
+	} else {
+		err = output.Write(result, opts.Format, opts.Output, stdout)
+		if opts.Output != "" {
+			written = []string{opts.Output}
 		}
 	}
-	var normPRS prs.NormalizedPRS
-	var prsResult prs.PRSResult
-	if normPRSVal != nil {
-		normPRS = normPRSVal.(prs.NormalizedPRS)
-	}
-	if prsResultVal != nil {
-		prsResult = prsResultVal.(prs.PRSResult)
-	}
-	err = output.FormatOutput(
-		normPRS,
-		prsResult,
-		outputData.TraitSummaries,
-		outputData.SNPSMissing,
-		opts.Format,
-		opts.Output,
-		stdout,
-	)
 	if err != nil {
-		logging.Error("failed to format output: %v", err)
-		return 1
+		return nil, fmt.Errorf("failed to format output: %w", err)
 	}
 	logging.Info("Output formatting complete")
+	return written, nil
+}
 
-	return 0
+// outputResult assembles the results of a run, recording the model, build, and ancestry
+// the scores were computed with in provenance.
+func outputResult(outputData pipeline.PipelineOutput, provenance *output.Provenance) output.OutputResult {
+	// For compatibility, output only the first trait's PRS and normalized PRS if present
+	normPRS, prsResult := outputData.Primary()
+	var contigReport *contig.Report
+	if len(outputData.Contigs.Dropped) > 0 || len(outputData.Contigs.Remapped) > 0 {
+		contigReport = &outputData.Contigs
+	}
+	var alleles *model.AlleleHarmonization
+	if !outputData.Alleles.Empty() {
+		alleles = &outputData.Alleles
+	}
+	provenance.Model = &outputData.Model
+	build := buildinfo.Current()
+	provenance.Build = &build
+	if anc, err := ancestry.NewFromConfig(); err == nil {
+		provenance.Ancestry = anc.Code()
+	}
+
+	return output.OutputResult{
+		NormalizedPRS:  normPRS,
+		PRSResult:      prsResult,
+		TraitSummaries: outputData.TraitSummaries,
+		TraitGroups:    outputData.TraitGroups,
+		SNPSMissing:    outputData.SNPSMissing,
+		ExcludedSNPs:   outputData.ExcludedSNPs,
+		Alleles:        alleles,
+		Contigs:        contigReport,
+		DerivedMetrics: outputData.DerivedMetrics,
+		Compound:       outputData.Compound,
+		PGx:            outputData.PGx,
+		Provenance:     provenance,
+
+		TraitScores:        outputData.NormalizedPRS,
+		TraitContributions: outputData.PRSResults,
+	}
 }
 
 func main() {
-	logging.Info("PHITE CLI invoked")
 	os.Exit(RunCLI(os.Args[1:], os.Stdout, os.Stderr))
 }
+
+// localizeConfigInputs downloads the remote (s3://, https://) files named by config keys,
+// so the model database, cohort table, and mapping files can live in object storage.
+func localizeConfigInputs() error {
+	return input.LocalizeConfig(context.Background(),
+		"gwas_db_path",
+		reference.CohortPathKey,
+		reference.TraitModelMapKey,
+		contig.AliasFileKey,
+		haplotype.DefinitionsFileKey,
+		pgx.MappingFileKey,
+	)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"phite.io/polygenic-risk-calculator/internal/buildinfo"
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/input"
+	"phite.io/polygenic-risk-calculator/internal/integrity"
+	"phite.io/polygenic-risk-calculator/internal/reference"
+)
+
+// ioManifest is what --machine-readable-io prints on stdout: whether the run succeeded and
+// every file it read and wrote, so a workflow engine can wire the outputs to later steps
+// without parsing logs.
+type ioManifest struct {
+	Status  string   `json:"status"` // "succeeded" or "failed"
+	Error   string   `json:"error,omitempty"`
+	Version string   `json:"version"`
+	Inputs  []ioFile `json:"inputs"`
+	Outputs []ioFile `json:"outputs"`
+}
+
+// ioFile is one input or output file of the run.
+type ioFile struct {
+	Role   string `json:"role"` // the flag or config key naming it, or "results"
+	Path   string `json:"path"` // as given; remote inputs keep their s3:// or https:// location
+	Bytes  int64  `json:"bytes,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// writeIOManifest writes the manifest of a run that wrote outputs and failed with runErr,
+// if not nil.
+func writeIOManifest(w io.Writer, opts cli.Options, outputs []string, runErr error) error {
+	m := ioManifest{Status: "succeeded", Version: buildinfo.Current().Version, Inputs: []ioFile{}, Outputs: []ioFile{}}
+	if runErr != nil {
+		m.Status = "failed"
+		m.Error = runErr.Error()
+	}
+
+	inputs := []struct{ role, path string }{
+		{"genotype-file", opts.GenotypeFile},
+		{"snps-file", opts.SNPsFile},
+		{"gwas-db", config.GetString("gwas_db_path")},
+		{"trait-model-map", opts.TraitModelMap},
+		{"checksum-manifest", opts.ChecksumManifest},
+		{reference.CohortPathKey, config.GetString(reference.CohortPathKey)},
+	}
+	for _, note := range opts.CuratedNotes {
+		inputs = append(inputs, struct{ role, path string }{"curated-notes", note})
+	}
+	for _, in := range inputs {
+		if in.path != "" {
+			m.Inputs = append(m.Inputs, describeFile(in.role, in.path))
+		}
+	}
+	for _, out := range outputs {
+		m.Outputs = append(m.Outputs, describeFile("results", out))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// describeFile records a file's size and sha256 when it is a readable regular file.
+func describeFile(role, path string) ioFile {
+	f := ioFile{Role: role, Path: input.Origin(path)}
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+		f.Bytes = info.Size()
+		if digest, err := integrity.HashFile(path); err == nil {
+			f.SHA256 = digest
+		}
+	}
+	return f
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/panel"
+)
+
+// runPanels handles `risk-calculator panels`. Returns exit code.
+func runPanels(args []string, stdout io.Writer) int {
+	opts, err := cli.ParsePanelsOptions(args)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintPanelsHelp()
+		return 1
+	}
+
+	panels, err := panel.Bundled()
+	if err != nil {
+		logging.Error("failed to load panels: %v", err)
+		return 1
+	}
+	if opts.Format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(panels); err != nil {
+			logging.Error("failed to write panels: %v", err)
+			return 1
+		}
+		return 0
+	}
+	for _, p := range panels {
+		fmt.Fprintf(stdout, "%s: %s (%d variants)\n", p.Name, p.Description, len(p.SNPs))
+		// One line per section, in display order
+		var sections []string
+		traits := make(map[string][]string)
+		for _, t := range p.Traits {
+			if _, ok := traits[t.Section]; !ok {
+				sections = append(sections, t.Section)
+			}
+			traits[t.Section] = append(traits[t.Section], t.Trait)
+		}
+		for _, section := range sections {
+			if section == "" {
+				fmt.Fprintf(stdout, "  %s\n", strings.Join(traits[section], "; "))
+				continue
+			}
+			fmt.Fprintf(stdout, "  %s: %s\n", section, strings.Join(traits[section], "; "))
+		}
+	}
+	return 0
+}
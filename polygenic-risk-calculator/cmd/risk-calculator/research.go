@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/output"
+)
+
+// runResearchExport handles `risk-calculator research-export`. Returns exit code.
+func runResearchExport(args []string, stdout io.Writer) int {
+	opts, err := cli.ParseResearchExportOptions(args)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintResearchExportHelp()
+		return 1
+	}
+	if !output.ResearchEnabled() {
+		logging.Error("%v", output.ErrResearchDisabled)
+		return 1
+	}
+
+	samples := make([]output.ResearchSample, 0, len(opts.Results))
+	for _, path := range opts.Results {
+		result, err := output.ReadResult(path)
+		if err != nil {
+			logging.Error("%v", err)
+			return 1
+		}
+		samples = append(samples, output.ResearchSample{ID: output.SampleID(path), Result: result})
+	}
+	var pheno *output.Phenotypes
+	if opts.Phenotypes != "" {
+		if pheno, err = output.ReadPhenotypes(opts.Phenotypes, opts.IDColumn, opts.Columns); err != nil {
+			logging.Error("%v", err)
+			return 1
+		}
+	}
+
+	w := stdout
+	if opts.Output != "" {
+		f, err := os.Create(opts.Output)
+		if err != nil {
+			logging.Error("failed to create %s: %v", opts.Output, err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	stats, err := output.WriteResearchTable(w, samples, pheno)
+	if err != nil {
+		logging.Error("failed to write research table: %v", err)
+		return 1
+	}
+	logging.Info("Research table: %d rows for %d samples", stats.Rows, stats.Samples)
+	if stats.WithoutPhenotypes > 0 {
+		logging.Warn("%d samples have no phenotype row; their phenotype columns are empty", stats.WithoutPhenotypes)
+	}
+	if stats.UnusedPhenotypes > 0 {
+		logging.Info("%d phenotype rows match no result file and were not exported", stats.UnusedPhenotypes)
+	}
+	return 0
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"phite.io/polygenic-risk-calculator/internal/buildinfo"
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/contig"
+	"phite.io/polygenic-risk-calculator/internal/haplotype"
+	"phite.io/polygenic-risk-calculator/internal/integrity"
+	"phite.io/polygenic-risk-calculator/internal/output"
+	"phite.io/polygenic-risk-calculator/internal/panel"
+	"phite.io/polygenic-risk-calculator/internal/pgx"
+	"phite.io/polygenic-risk-calculator/internal/reference"
+)
+
+// runKeyFileKeys are the config keys naming input files. Their content goes into the run
+// key, so their paths are left out of the config it digests.
+var runKeyFileKeys = []string{
+	"gwas_db_path",
+	reference.CohortPathKey,
+	reference.TraitModelMapKey,
+	contig.AliasFileKey,
+	haplotype.DefinitionsFileKey,
+	pgx.MappingFileKey,
+}
+
+// runKeyIgnoredConfig are config subtrees that do not affect results.
+var runKeyIgnoredConfig = []string{"logging", "input", "db.query_log"}
+
+// computeRunKey digests the inputs and settings of a scoring run: the content of every
+// input file, the effective config and PHITE_* environment, the requested SNPs and format,
+// and the calculator build. Call it after localizeConfigInputs.
+func computeRunKey(opts cli.Options) (string, error) {
+	var files []integrity.RunInput
+	add := func(role, path string) {
+		if path != "" {
+			files = append(files, integrity.RunInput{Role: role, Path: path})
+		}
+	}
+	add("genotype-file", opts.GenotypeFile)
+	add("snps-file", opts.SNPsFile)
+	for _, key := range runKeyFileKeys {
+		add(key, config.GetString(key))
+	}
+	panelFile := panel.IsPath(config.GetString(panel.NameKey))
+	if panelFile {
+		add("panel", config.GetString(panel.NameKey))
+	}
+	for _, note := range opts.CuratedNotes {
+		notes, err := curatedNoteFiles(note)
+		if err != nil {
+			return "", fmt.Errorf("curated-notes: %w", err)
+		}
+		for _, f := range notes {
+			add("curated-notes", f)
+		}
+	}
+
+	settings := struct {
+		Config  map[string]interface{} `json:"config"`
+		Env     map[string]string      `json:"env"`
+		SNPs    []string               `json:"snps,omitempty"`
+		Format  string                 `json:"format"`
+		Version string                 `json:"version"`
+		Commit  string                 `json:"commit"`
+	}{
+		Env:     phiteEnv(),
+		SNPs:    opts.SNPs,
+		Format:  opts.Format,
+		Version: buildinfo.Current().Version,
+		Commit:  buildinfo.Current().Commit,
+	}
+	if err := config.UnmarshalKey("", &settings.Config); err != nil {
+		return "", fmt.Errorf("failed to read config for run key: %w", err)
+	}
+	if panelFile {
+		deleteConfigKey(settings.Config, panel.NameKey)
+	}
+	for _, key := range runKeyFileKeys {
+		deleteConfigKey(settings.Config, key)
+	}
+	for _, key := range runKeyIgnoredConfig {
+		deleteConfigKey(settings.Config, key)
+	}
+	return integrity.RunKey(files, settings)
+}
+
+// curatedNoteFiles returns the JSON files a --curated-notes path names, in sorted order.
+func curatedNoteFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".json") {
+			files = append(files, p)
+		}
+		return err
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// phiteEnv returns the PHITE_* environment variables, which override config, except the
+// logging ones.
+func phiteEnv() map[string]string {
+	env := map[string]string{}
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(k, "PHITE_") && !strings.HasPrefix(k, "PHITE_LOG") {
+			env[k] = v
+		}
+	}
+	return env
+}
+
+// deleteConfigKey removes a dotted key such as "reference.cohort_path" from a config tree.
+func deleteConfigKey(tree map[string]interface{}, key string) {
+	head, rest, nested := strings.Cut(key, ".")
+	if !nested {
+		delete(tree, head)
+		return
+	}
+	if sub, ok := tree[head].(map[string]interface{}); ok {
+		deleteConfigKey(sub, rest)
+	}
+}
+
+// applyRunKey expands {run_key} in the output locations of opts.
+func applyRunKey(opts *cli.Options, runKey string) {
+	opts.Output = output.ExpandRunKey(opts.Output, runKey)
+	opts.OutputDir = output.ExpandRunKey(opts.OutputDir, runKey)
+}
+
+// touchOutputs marks files as just written, so make-style tools consider them newer than
+// the inputs that were checked against them.
+func touchOutputs(files []string) {
+	now := time.Now()
+	for _, f := range files {
+		_ = os.Chtimes(f, now, now)
+	}
+}
+
+// usesRunKey reports whether a run needs its run key.
+func usesRunKey(opts cli.Options) bool {
+	return opts.SkipIfUnchanged ||
+		strings.Contains(opts.Output, output.RunKeyPlaceholder) ||
+		strings.Contains(opts.OutputDir, output.RunKeyPlaceholder)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"io"
+	"slices"
+
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/reference"
+	"phite.io/polygenic-risk-calculator/internal/schema"
+)
+
+// Exit codes for `schema verify`.
+const (
+	schemaExitOK       = 0
+	schemaExitError    = 1
+	schemaExitMismatch = 2
+)
+
+// runSchema handles `risk-calculator schema <subcommand>`. Returns exit code.
+func runSchema(args []string, stdout io.Writer) int {
+	if len(args) == 0 || args[0] != "verify" {
+		cli.PrintSchemaHelp()
+		return schemaExitError
+	}
+
+	opts, err := cli.ParseSchemaOptions(args[1:])
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintSchemaHelp()
+		return schemaExitError
+	}
+
+	rs, err := reference.NewReferenceService(nil, nil, nil)
+	if err != nil {
+		logging.Error("Failed to create reference service: %v", err)
+		return schemaExitError
+	}
+	checks, err := rs.SchemaChecks()
+	if err != nil {
+		logging.Error("Failed to build schema checks: %v", err)
+		return schemaExitError
+	}
+	if len(opts.Only) > 0 {
+		checks = slices.DeleteFunc(checks, func(c schema.Check) bool {
+			return !slices.Contains(opts.Only, c.Name)
+		})
+	}
+
+	report := schema.Verify(context.Background(), checks)
+	if err := schema.Write(report, opts.Format, stdout); err != nil {
+		logging.Error("failed to write schema report: %v", err)
+		return schemaExitError
+	}
+	if !report.OK {
+		return schemaExitMismatch
+	}
+	return schemaExitOK
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/output"
+	"phite.io/polygenic-risk-calculator/internal/pipeline"
+	"phite.io/polygenic-risk-calculator/internal/reference"
+	"phite.io/polygenic-risk-calculator/internal/server"
+)
+
+// shutdownGrace is how long in-flight requests may run once the server is interrupted.
+const shutdownGrace = 30 * time.Second
+
+// runServe handles `risk-calculator serve`. Returns exit code.
+func runServe(args []string) int {
+	opts, err := cli.ParseServeOptions(args)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintServeHelp()
+		return 1
+	}
+	if len(config.MissingKeys) > 0 {
+		logging.Error("missing required configuration keys: %v", config.MissingKeys)
+		return 1
+	}
+	if err := localizeConfigInputs(); err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+
+	rs, err := reference.NewReferenceService(nil, nil, nil)
+	if err != nil {
+		logging.Error("failed to create reference service: %v", err)
+		return 1
+	}
+	if !config.GetBool(reference.SkipTableProbeKey) {
+		if err := rs.ProbeAlleleFrequencyTable(context.Background()); err != nil {
+			logging.Error("startup probe failed: %v", err)
+			return 1
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := scheduleRetention(ctx); err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+
+	srv := server.New(scoreRequest(rs, opts.ReferenceTable), rs.ListModelTraits, opts.Concurrency, opts.Timeout)
+	httpServer := &http.Server{Addr: opts.Addr, Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	errc := make(chan error, 1)
+	go func() { errc <- httpServer.ListenAndServe() }()
+	logging.Info("Serving on %s (concurrency %d, timeout %s)", opts.Addr, opts.Concurrency, opts.Timeout)
+
+	select {
+	case err := <-errc:
+		logging.Error("server stopped: %v", err)
+		return 1
+	case <-ctx.Done():
+	}
+	logging.Info("Shutting down; waiting up to %s for in-flight requests", shutdownGrace)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownGrace)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logging.Error("shutdown: %v", err)
+		return 1
+	}
+	if err := srv.Wait(shutdownCtx); err != nil {
+		logging.Error("shutdown: async jobs still running: %v", err)
+		return 1
+	}
+	logging.Info("Server stopped")
+	return 0
+}
+
+// scoreRequest returns a scorer running the pipeline on each request. Requests share rs's
+// clients and caches, and each gets its own allele and contig tallies from rs.ForRun.
+// Requests read the process configuration, so concurrent runs never swap providers under
+// each other.
+func scoreRequest(rs *reference.ReferenceService, referenceTable string) server.Scorer {
+	return func(ctx context.Context, req server.Request) (output.OutputResult, error) {
+		out, err := pipeline.RunContext(ctx, pipeline.PipelineInput{
+			GenotypeFile:   req.GenotypeFile,
+			SNPs:           req.SNPs,
+			ReferenceTable: referenceTable,
+			OutputFormat:   "json",
+			Progress:       req.Progress,
+		}, rs.ForRun())
+		if err != nil {
+			return output.OutputResult{}, fmt.Errorf("pipeline error: %w", err)
+		}
+		return outputResult(out, &output.Provenance{}), nil
+	}
+}
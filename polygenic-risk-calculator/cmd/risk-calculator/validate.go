@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/reference"
+	reference_stats "phite.io/polygenic-risk-calculator/internal/reference/stats"
+)
+
+// runValidateStats handles `risk-calculator validate-stats`. Returns exit code.
+func runValidateStats(args []string, stdout io.Writer) int {
+	opts, err := cli.ParseValidateStatsOptions(args)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintValidateStatsHelp()
+		return 1
+	}
+
+	anc, err := ancestry.NewFromConfig()
+	if err != nil {
+		logging.Error("Failed to initialize ancestry: %v", err)
+		return 1
+	}
+	rs, err := reference.NewReferenceService(nil, nil, nil)
+	if err != nil {
+		logging.Error("Failed to create reference service: %v", err)
+		return 1
+	}
+	validations, err := rs.ValidateStats(context.Background(), anc, opts.Traits, opts.Checks)
+	if err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+
+	if opts.Format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(validations); err != nil {
+			logging.Error("failed to write validation report: %v", err)
+			return 1
+		}
+	} else {
+		writeValidationText(stdout, validations)
+	}
+
+	for _, v := range validations {
+		if !v.OK {
+			return 2
+		}
+	}
+	return 0
+}
+
+func writeValidationText(w io.Writer, validations []reference_stats.Validation) {
+	for _, v := range validations {
+		fmt.Fprintf(w, "%s %s (%s, %s)\n", v.Trait, v.Ancestry, v.Model, v.Source)
+		for _, c := range v.Checks {
+			status := "ok  "
+			if !c.OK {
+				status = "FAIL"
+			}
+			fmt.Fprintf(w, "  %s %-10s %s\n", status, c.Name, c.Detail)
+		}
+		if len(v.Contributors) > 0 {
+			fmt.Fprintf(w, "  top variance contributors:\n")
+			for _, c := range v.Contributors {
+				fmt.Fprintf(w, "    %-24s %10.4g %5.1f%%\n", c.Variant, c.Variance, 100*c.Share)
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/buildinfo"
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// runVersion handles `risk-calculator version`. Returns exit code.
+func runVersion(args []string, stdout io.Writer) int {
+	opts, err := cli.ParseVersionOptions(args)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintVersionHelp()
+		return 1
+	}
+
+	info := buildinfo.Current()
+	if opts.Format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			logging.Error("failed to write version: %v", err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(stdout, "risk-calculator %s\n", info.Version)
+	fmt.Fprintf(stdout, "commit:     %s\n", orUnknown(info.Commit))
+	fmt.Fprintf(stdout, "build date: %s\n", orUnknown(info.Date))
+	fmt.Fprintf(stdout, "go:         %s\n", info.GoVersion)
+	fmt.Fprintf(stdout, "backends:   %s\n", strings.Join(info.Backends, ", "))
+	return 0
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
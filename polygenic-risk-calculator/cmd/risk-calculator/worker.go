@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"phite.io/polygenic-risk-calculator/internal/cli"
+	"phite.io/polygenic-risk-calculator/internal/input"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/panel"
+	"phite.io/polygenic-risk-calculator/internal/queue"
+)
+
+// job is the body of a job message: the flags of one scoring run.
+type job struct {
+	ID   string   `json:"id,omitempty"` // caller's job ID, echoed in the result; defaults to the message ID
+	Args []string `json:"args"`         // run flags, as on the command line
+}
+
+// jobResult is the body of the message published for each job.
+type jobResult struct {
+	JobID     string          `json:"job_id"`
+	MessageID string          `json:"message_id"`
+	Status    string          `json:"status"` // "succeeded" or "failed"
+	Error     string          `json:"error,omitempty"`
+	Attempt   int             `json:"attempt"`
+	Outputs   []string        `json:"outputs,omitempty"` // files written for --output or --output-dir
+	Results   json.RawMessage `json:"results,omitempty"` // the JSON results when the job wrote no files
+}
+
+// jobRunner scores jobs, each in a child calculator process: run flags are applied to the
+// process-wide config, so jobs sharing a process would see each other's settings.
+type jobRunner struct {
+	exe        string   // the calculator executable
+	global     []string // global flags passed on to every job
+	resultsDir string   // directory job output paths are resolved in; empty forbids output files
+	inputsDir  string   // directory job input paths are resolved in; empty allows only s3:// inputs
+	stderr     io.Writer
+}
+
+// jobFlags are the run flags a job may set, and whether each takes a value. Queue messages
+// are not trusted with the rest, which choose databases, config, and files on the worker's
+// host; the worker's own config and global flags decide those. The files a job reads and
+// writes are confined by jobArgs.
+var jobFlags = map[string]bool{
+	"genotype-file":       true,
+	"vcf-sample":          true,
+	"snps":                true,
+	"snps-file":           true,
+	"panel":               true,
+	"format":              true,
+	"ancestry":            true,
+	"gender":              true,
+	"sort-by":             true,
+	"group-by":            true,
+	"model-version":       true,
+	"output":              true,
+	"output-dir":          true,
+	"pgx":                 false,
+	"suppress-actionable": false,
+}
+
+// jobInputFlags are the job flags naming a file the job reads. --panel names a file only
+// when it is a path rather than a bundled panel.
+var jobInputFlags = map[string]bool{"genotype-file": true, "snps-file": true}
+
+// readsFile reports whether a job flag's value is a file the job reads.
+func readsFile(name, value string) bool {
+	return jobInputFlags[name] || name == "panel" && panel.IsPath(value)
+}
+
+// jobArgs checks a job's args against jobFlags. Input files must be s3:// objects or
+// paths in inputsDir, and --output and --output-dir paths are resolved in resultsDir;
+// neither directory may be escaped. Other URLs are refused, as fetching them would let a
+// message reach services on the worker's network. It returns the args to run the job with
+// and whether the job writes files.
+func jobArgs(args []string, inputsDir, resultsDir string) ([]string, bool, error) {
+	var out []string
+	writesFiles := false
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(args[i], "--"), "=")
+		takesValue, ok := jobFlags[name]
+		switch {
+		case !strings.HasPrefix(args[i], "--"):
+			return nil, false, fmt.Errorf("args must be run flags, not %q", args[i])
+		case !ok:
+			return nil, false, fmt.Errorf("--%s cannot be set by a job", name)
+		case !takesValue:
+			out = append(out, args[i])
+			continue
+		case !hasValue:
+			if i+1 == len(args) {
+				return nil, false, fmt.Errorf("--%s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		if readsFile(name, value) && !strings.HasPrefix(value, "s3://") {
+			switch {
+			case input.IsRemote(value):
+				return nil, false, fmt.Errorf("--%s %q: jobs may only read s3:// objects, not URLs", name, value)
+			case inputsDir == "":
+				return nil, false, fmt.Errorf("--%s must be an s3:// object: the worker has no inputs directory (%s)", name, queue.InputsDirKey)
+			case !filepath.IsLocal(value):
+				return nil, false, fmt.Errorf("--%s %q must be an s3:// object or a relative path inside the inputs directory", name, value)
+			}
+			value = filepath.Join(inputsDir, value)
+		}
+		if name == "output" || name == "output-dir" {
+			if resultsDir == "" {
+				return nil, false, fmt.Errorf("--%s needs a results directory on the worker (%s)", name, queue.ResultsDirKey)
+			}
+			if !filepath.IsLocal(value) {
+				return nil, false, fmt.Errorf("--%s %q must be a relative path inside the results directory", name, value)
+			}
+			value = filepath.Join(resultsDir, value)
+			writesFiles = true
+		}
+		out = append(out, "--"+name, value)
+	}
+	return out, writesFiles, nil
+}
+
+// runWorker handles `risk-calculator worker`. Returns exit code.
+func runWorker(args []string, global cli.GlobalOptions, stderr io.Writer) int {
+	opts, err := cli.ParseWorkerOptions(args)
+	if err != nil {
+		logging.Error("parameter error: %v", err)
+		cli.PrintWorkerHelp()
+		return 1
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		logging.Error("failed to locate the calculator executable: %v", err)
+		return 1
+	}
+	runner := &jobRunner{exe: exe, global: global.Args(), resultsDir: opts.ResultsDir, inputsDir: opts.InputsDir, stderr: stderr}
+
+	// The client outlives the signal context, so jobs running at shutdown can still publish.
+	ps, err := queue.NewPubSub(context.Background(), opts.Subscription, opts.ResultsTopic)
+	if err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := scheduleRetention(ctx); err != nil {
+		logging.Error("%v", err)
+		return 1
+	}
+
+	logging.Info("Worker pulling jobs from %s (concurrency %d), publishing results to %s", opts.Subscription, opts.Concurrency, opts.ResultsTopic)
+	err = queue.Consume(ctx, ps, ps, runner.handle, queue.Options{Concurrency: opts.Concurrency, MaxAttempts: opts.MaxAttempts})
+	if err != nil {
+		logging.Error("worker stopped: %v", err)
+		return 1
+	}
+	logging.Info("Worker stopped")
+	return 0
+}
+
+// handle is the worker's queue.Handler: it scores the job in m and returns its result.
+func (r *jobRunner) handle(ctx context.Context, m queue.Message) ([]byte, error) {
+	res := jobResult{JobID: m.ID, MessageID: m.ID, Status: "succeeded", Attempt: m.Attempt}
+	err := r.run(ctx, m, &res)
+	if err != nil {
+		res.Status = "failed"
+		res.Error = err.Error()
+	}
+	reply, merr := json.Marshal(res)
+	if merr != nil {
+		return nil, errors.Join(err, merr)
+	}
+	return reply, err
+}
+
+// run scores one job, filling in res. Jobs that cannot succeed on a retry fail with a
+// queue.Permanent error.
+func (r *jobRunner) run(ctx context.Context, m queue.Message, res *jobResult) error {
+	var j job
+	if err := json.Unmarshal(m.Data, &j); err != nil {
+		return queue.Permanent(fmt.Errorf("invalid job: %w", err))
+	}
+	if j.ID != "" {
+		res.JobID = j.ID
+	}
+	runArgs, writesFiles, err := jobArgs(j.Args, r.inputsDir, r.resultsDir)
+	if err != nil {
+		return queue.Permanent(fmt.Errorf("invalid job: %w", err))
+	}
+	// With results in files, the child's stdout is its I/O manifest, listing them.
+	args := append(slices.Clone(r.global), runArgs...)
+	if writesFiles {
+		args = append(args, "--machine-readable-io")
+	}
+
+	logging.Info("Scoring job %s (attempt %d)", res.JobID, m.Attempt)
+	var stdout bytes.Buffer
+	errTail := &tailWriter{max: 4096}
+	cmd := exec.CommandContext(ctx, r.exe, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = io.MultiWriter(r.stderr, errTail)
+	runErr := cmd.Run()
+
+	if writesFiles {
+		var manifest ioManifest
+		if err := json.Unmarshal(stdout.Bytes(), &manifest); err == nil {
+			for _, out := range manifest.Outputs {
+				res.Outputs = append(res.Outputs, out.Path)
+			}
+			if manifest.Error != "" {
+				return jobError(manifest.Error)
+			}
+		}
+	} else if runErr == nil {
+		res.Results = json.RawMessage(bytes.TrimSpace(stdout.Bytes()))
+		if !json.Valid(res.Results) {
+			res.Results = nil
+			return queue.Permanent(errors.New("jobs without --output or --output-dir must use --format json"))
+		}
+	}
+	if runErr != nil {
+		if msg := errTail.lastError(); msg != "" {
+			return jobError(msg)
+		}
+		return fmt.Errorf("scoring failed: %w", runErr)
+	}
+	return nil
+}
+
+// jobError turns the error a child run reported into a job error. Parameter errors are
+// permanent; anything else, such as an unreachable backend, may pass on a retry.
+func jobError(msg string) error {
+	err := errors.New(msg)
+	if strings.Contains(msg, "parameter error") {
+		return queue.Permanent(err)
+	}
+	return err
+}
+
+// tailWriter keeps the last max bytes written to it.
+type tailWriter struct {
+	max int
+	buf []byte
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	if len(w.buf) > w.max {
+		w.buf = w.buf[len(w.buf)-w.max:]
+	}
+	return len(p), nil
+}
+
+// lastError returns the message of the last error logged, which for a failed run is the
+// error it exited with. Both the text and JSON log formats are recognized.
+func (w *tailWriter) lastError() string {
+	lines := strings.Split(string(w.buf), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		var rec struct {
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}
+		if json.Unmarshal([]byte(line), &rec) == nil && rec.Level == "ERROR" {
+			return rec.Msg
+		}
+		if !strings.Contains(line, "level=ERROR") {
+			continue
+		}
+		_, msg, ok := strings.Cut(line, " msg=")
+		if !ok {
+			return line
+		}
+		if quoted, err := strconv.QuotedPrefix(msg); err == nil {
+			if s, err := strconv.Unquote(quoted); err == nil {
+				return s
+			}
+		}
+		msg, _, _ = strings.Cut(msg, " ")
+		return msg
+	}
+	return ""
+}
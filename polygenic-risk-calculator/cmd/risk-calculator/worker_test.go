@@ -0,0 +1,69 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobArgs(t *testing.T) {
+	inputs, results := filepath.Join("/srv", "inputs"), filepath.Join("/srv", "results")
+	tests := []struct {
+		name        string
+		args        []string
+		inputsDir   string
+		resultsDir  string
+		want        []string
+		writesFiles bool
+		err         string
+	}{
+		{
+			name: "inputs and format",
+			args: []string{"--genotype-file", "s3://b/g.txt", "--snps=rs1,rs2", "--pgx", "--format", "json"},
+			want: []string{"--genotype-file", "s3://b/g.txt", "--snps", "rs1,rs2", "--pgx", "--format", "json"},
+		},
+		{
+			name:        "output resolved in the results directory",
+			args:        []string{"--genotype-file", "s3://b/g.txt", "--output-dir=sample-17", "--output", "sample-17/all.json"},
+			resultsDir:  results,
+			want:        []string{"--genotype-file", "s3://b/g.txt", "--output-dir", filepath.Join(results, "sample-17"), "--output", filepath.Join(results, "sample-17", "all.json")},
+			writesFiles: true,
+		},
+		{
+			name:      "inputs resolved in the inputs directory",
+			args:      []string{"--genotype-file", "batch-3/g.txt", "--snps-file=snps.txt"},
+			inputsDir: inputs,
+			want:      []string{"--genotype-file", filepath.Join(inputs, "batch-3", "g.txt"), "--snps-file", filepath.Join(inputs, "snps.txt")},
+		},
+		{name: "bundled panel", args: []string{"--panel", "cardiometabolic"}, want: []string{"--panel", "cardiometabolic"}},
+		{name: "panel file", args: []string{"--panel", "panels/lipids.yaml"}, inputsDir: inputs, want: []string{"--panel", filepath.Join(inputs, "panels", "lipids.yaml")}},
+		{name: "panel file outside the inputs directory", args: []string{"--panel", "../../etc/lipids.yaml"}, inputsDir: inputs, err: "relative path inside the inputs directory"},
+		{name: "input without an inputs directory", args: []string{"--genotype-file", "g.txt"}, err: "must be an s3:// object"},
+		{name: "absolute input", args: []string{"--genotype-file", "/etc/shadow"}, inputsDir: inputs, err: "relative path inside the inputs directory"},
+		{name: "input escaping the inputs directory", args: []string{"--snps-file=../results/other.json"}, inputsDir: inputs, err: "relative path inside the inputs directory"},
+		{name: "URL input", args: []string{"--genotype-file", "http://169.254.169.254/latest/meta-data"}, inputsDir: inputs, err: "not URLs"},
+		{name: "output without a results directory", args: []string{"--output", "r.json"}, err: "results directory"},
+		{name: "absolute output", args: []string{"--output", "/etc/cron.d/job"}, resultsDir: results, err: "relative path"},
+		{name: "output escaping the results directory", args: []string{"--output-dir=../../home"}, resultsDir: results, err: "relative path"},
+		{name: "config", args: []string{"--config", "/tmp/evil.json"}, err: "--config cannot be set"},
+		{name: "database", args: []string{"--gwas-db=/data/other.duckdb"}, err: "--gwas-db cannot be set"},
+		{name: "query log", args: []string{"--query-log", "/tmp/q.log"}, err: "--query-log cannot be set"},
+		{name: "command", args: []string{"serve", "--addr", ":80"}, err: `not "serve"`},
+		{name: "short flag", args: []string{"-o", "x"}, err: `not "-o"`},
+		{name: "missing value", args: []string{"--genotype-file"}, err: "needs a value"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, writesFiles, err := jobArgs(tc.args, tc.inputsDir, tc.resultsDir)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.writesFiles, writesFiles)
+		})
+	}
+}
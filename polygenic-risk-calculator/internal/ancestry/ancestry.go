@@ -2,7 +2,9 @@ package ancestry
 
 import (
 	"fmt"
+	"regexp"
 	"slices"
+	"strings"
 
 	"phite.io/polygenic-risk-calculator/internal/utils"
 )
@@ -37,6 +39,48 @@ func New(population, gender string) (*Ancestry, error) {
 	}, nil
 }
 
+// FromCode creates an Ancestry from a combined code as returned by Code, e.g. "EUR" or
+// "EUR_FEMALE".
+func FromCode(code string) (*Ancestry, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	population, gender := code, ""
+	for _, g := range getSupportedGenders() {
+		if p, ok := strings.CutSuffix(code, "_"+g); ok && g != "" {
+			population, gender = p, g
+		}
+	}
+	return New(population, gender)
+}
+
+// CohortPopulation is the population code reported for custom cohorts.
+const CohortPopulation = "COHORT"
+
+var cohortNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// NewCustomCohort creates an Ancestry backed by a user-provided cohort frequency table.
+// Its code ("COHORT_<NAME>") keeps cached reference stats separate from gnomAD populations,
+// and frequencies are read from the single afColumn.
+func NewCustomCohort(name, afColumn string) (*Ancestry, error) {
+	if !cohortNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid cohort name %q: use letters, digits, and underscores", name)
+	}
+	if !cohortNamePattern.MatchString(afColumn) {
+		return nil, fmt.Errorf("invalid cohort allele frequency column %q", afColumn)
+	}
+	upper := strings.ToUpper(name)
+	return &Ancestry{
+		population:  CohortPopulation,
+		code:        fmt.Sprintf("%s_%s", CohortPopulation, upper),
+		description: fmt.Sprintf("Custom cohort %s", name),
+		precedence:  []string{afColumn},
+	}, nil
+}
+
+// IsCustomCohort reports whether the ancestry is backed by a custom cohort table.
+func (a *Ancestry) IsCustomCohort() bool {
+	return a.population == CohortPopulation
+}
+
 // Code returns the combined ancestry code (e.g., "EUR_MALE", "AFR")
 func (a *Ancestry) Code() string {
 	return a.code
@@ -72,12 +116,64 @@ func (a *Ancestry) SelectFrequency(rowData map[string]interface{}) (float64, str
 	return 0, "", fmt.Errorf("no frequency data available for %s", a.code)
 }
 
+// WeightColumn returns the model column holding ancestry-specific effect weights
+// (e.g. "beta_eur"), or "" for custom cohorts.
+func (a *Ancestry) WeightColumn() string {
+	if a == nil || a.IsCustomCohort() {
+		return ""
+	}
+	return "beta_" + strings.ToLower(a.population)
+}
+
+// SelectWeight returns the ancestry-specific weight from row when that column is present
+// and non-null, falling back to the generic column. The second value names the column used.
+// A nil Ancestry always uses the generic column.
+func (a *Ancestry) SelectWeight(row map[string]interface{}, generic string) (float64, string) {
+	if col := a.WeightColumn(); col != "" && row[col] != nil {
+		return utils.ToFloat64(row[col]), col
+	}
+	return utils.ToFloat64(row[generic]), generic
+}
+
+// All returns an Ancestry for every supported population and gender combination, sorted by code.
+func All() []*Ancestry {
+	var all []*Ancestry
+	for _, population := range getSupportedPopulations() {
+		for _, gender := range getSupportedGenders() {
+			if a, err := New(population, gender); err == nil {
+				all = append(all, a)
+			}
+		}
+	}
+	slices.SortFunc(all, func(a, b *Ancestry) int { return strings.Compare(a.code, b.code) })
+	return all
+}
+
 // IsSupported validates if the population and gender combination is supported
 func IsSupported(population, gender string) bool {
 	return slices.Contains(getSupportedPopulations(), population) &&
 		slices.Contains(getSupportedGenders(), gender)
 }
 
+// NormalizePopulation upper-cases a population code and checks that it is supported.
+func NormalizePopulation(population string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(population))
+	if !slices.Contains(getSupportedPopulations(), code) {
+		return "", fmt.Errorf("unsupported population %q: use one of %s", population, strings.Join(getSupportedPopulations(), ", "))
+	}
+	return code, nil
+}
+
+// NormalizeGender upper-cases a gender code and checks that it is supported; "" selects
+// the combined frequencies.
+func NormalizeGender(gender string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(gender))
+	if !slices.Contains(getSupportedGenders(), code) {
+		return "", fmt.Errorf("unsupported gender %q: use MALE or FEMALE, or omit for combined frequencies", gender)
+	}
+	return code, nil
+}
+
 // GetSupportedPopulations returns all supported population codes
 func GetSupportedPopulations() []string {
 	return getSupportedPopulations()
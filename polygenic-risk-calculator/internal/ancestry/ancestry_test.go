@@ -2,6 +2,7 @@ package ancestry
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -230,6 +231,21 @@ func TestIsSupported(t *testing.T) {
 	}
 }
 
+func TestNormalizePopulationAndGender(t *testing.T) {
+	if got, err := NormalizePopulation(" eur "); err != nil || got != "EUR" {
+		t.Errorf("NormalizePopulation(eur) = %q, %v, want EUR", got, err)
+	}
+	if _, err := NormalizePopulation("XYZ"); err == nil || !strings.Contains(err.Error(), "AFR, AMR") {
+		t.Errorf("NormalizePopulation(XYZ) error = %v, want one listing the supported codes", err)
+	}
+	if got, err := NormalizeGender("female"); err != nil || got != "FEMALE" {
+		t.Errorf("NormalizeGender(female) = %q, %v, want FEMALE", got, err)
+	}
+	if _, err := NormalizeGender("other"); err == nil || !strings.Contains(err.Error(), "MALE or FEMALE") {
+		t.Errorf("NormalizeGender(other) error = %v, want one listing the supported codes", err)
+	}
+}
+
 func TestGetSupportedPopulations(t *testing.T) {
 	got := GetSupportedPopulations()
 	expected := []string{"AFR", "AMR", "ASJ", "EAS", "EUR", "FIN", "SAS", "OTH", "AMI"}
@@ -309,3 +325,92 @@ func TestBuildInternalCode(t *testing.T) {
 		})
 	}
 }
+
+func TestNewCustomCohort(t *testing.T) {
+	a, err := NewCustomCohort("island_study", "af")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.Code() != "COHORT_ISLAND_STUDY" || !a.IsCustomCohort() {
+		t.Errorf("unexpected cohort ancestry: code=%s custom=%v", a.Code(), a.IsCustomCohort())
+	}
+	if !reflect.DeepEqual(a.ColumnPrecedence(), []string{"af"}) {
+		t.Errorf("ColumnPrecedence = %v, want [af]", a.ColumnPrecedence())
+	}
+	freq, col, err := a.SelectFrequency(map[string]interface{}{"af": 0.3, "AF_nfe": 0.9})
+	if err != nil || freq != 0.3 || col != "af" {
+		t.Errorf("SelectFrequency = %v, %s, %v; want 0.3 from af", freq, col, err)
+	}
+
+	if _, err := NewCustomCohort("bad name;", "af"); err == nil {
+		t.Error("expected error for invalid cohort name")
+	}
+	if _, err := NewCustomCohort("ok", "af) OR 1=1"); err == nil {
+		t.Error("expected error for invalid frequency column")
+	}
+
+	eur, _ := New("EUR", "")
+	if eur.IsCustomCohort() {
+		t.Error("gnomAD ancestry should not report as custom cohort")
+	}
+}
+
+func TestAll(t *testing.T) {
+	all := All()
+	want := len(GetSupportedPopulations()) * len(GetSupportedGenders())
+	if len(all) != want {
+		t.Fatalf("All() returned %d ancestries, want %d", len(all), want)
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i-1].Code() >= all[i].Code() {
+			t.Errorf("All() not sorted: %s before %s", all[i-1].Code(), all[i].Code())
+		}
+	}
+}
+
+func TestSelectWeight(t *testing.T) {
+	eur, _ := New("EUR", "FEMALE")
+	if got := eur.WeightColumn(); got != "beta_eur" {
+		t.Fatalf("WeightColumn() = %q, want beta_eur", got)
+	}
+
+	row := map[string]interface{}{"beta": 0.1, "beta_eur": 0.4}
+	if w, col := eur.SelectWeight(row, "beta"); w != 0.4 || col != "beta_eur" {
+		t.Errorf("SelectWeight() = %v, %q; want 0.4, beta_eur", w, col)
+	}
+	row["beta_eur"] = nil
+	if w, col := eur.SelectWeight(row, "beta"); w != 0.1 || col != "beta" {
+		t.Errorf("SelectWeight() with null ancestry weight = %v, %q; want 0.1, beta", w, col)
+	}
+
+	var none *Ancestry
+	if w, col := none.SelectWeight(map[string]interface{}{"beta": 0.2, "beta_eur": 0.4}, "beta"); w != 0.2 || col != "beta" {
+		t.Errorf("nil ancestry SelectWeight() = %v, %q; want 0.2, beta", w, col)
+	}
+
+	cohort, _ := NewCustomCohort("island", "af")
+	if got := cohort.WeightColumn(); got != "" {
+		t.Errorf("cohort WeightColumn() = %q, want empty", got)
+	}
+}
+
+func TestFromCode(t *testing.T) {
+	for code, want := range map[string][2]string{
+		"EUR":        {"EUR", ""},
+		"eur_female": {"EUR", "FEMALE"},
+		"AFR_MALE":   {"AFR", "MALE"},
+	} {
+		a, err := FromCode(code)
+		if err != nil {
+			t.Fatalf("FromCode(%q): %v", code, err)
+		}
+		if a.Population() != want[0] || a.Gender() != want[1] {
+			t.Errorf("FromCode(%q) = %s/%s, want %s/%s", code, a.Population(), a.Gender(), want[0], want[1])
+		}
+	}
+	for _, code := range []string{"", "XYZ", "EUR_OTHER", "MALE"} {
+		if _, err := FromCode(code); err == nil {
+			t.Errorf("FromCode(%q) succeeded, want error", code)
+		}
+	}
+}
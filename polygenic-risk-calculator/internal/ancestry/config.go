@@ -2,6 +2,7 @@ package ancestry
 
 import (
 	"fmt"
+	"strings"
 
 	"phite.io/polygenic-risk-calculator/internal/config"
 )
@@ -10,21 +11,40 @@ import (
 const (
 	PopulationKey = "ancestry.population" // Population code (EUR, AFR, EAS, etc.)
 	GenderKey     = "ancestry.gender"     // Gender filter (optional)
+
+	CohortKey         = "ancestry.cohort"           // Custom cohort name; replaces gnomAD populations when set
+	CohortAFColumnKey = "ancestry.cohort_af_column" // Allele frequency column in the cohort table (default "af")
+
+	TraitOverridesKey = "ancestry.trait_overrides" // Map of trait -> ancestry code whose reference stats normalize that trait
 )
 
+// defaultCohortAFColumn is used when CohortAFColumnKey is not configured.
+const defaultCohortAFColumn = "af"
+
 // ancestryInfo holds the mapping information for each ancestry/gender combination
 type ancestryInfo struct {
 	precedence  []string // Column precedence order for frequency selection
 	description string   // Human-readable description
 }
 
-// NewFromConfig creates a new Ancestry object from configuration values
+// NewFromConfig creates a new Ancestry object from configuration values.
+// When a custom cohort is configured it takes precedence over the gnomAD population, which
+// is only required without one. Both are read here rather than at startup, so flags,
+// --config, and per-run providers applied since are honoured.
 func NewFromConfig() (*Ancestry, error) {
+	if cohort := config.GetString(CohortKey); cohort != "" {
+		afColumn := config.GetString(CohortAFColumnKey)
+		if afColumn == "" {
+			afColumn = defaultCohortAFColumn
+		}
+		return NewCustomCohort(cohort, afColumn)
+	}
+
 	population := config.GetString(PopulationKey)
 	gender := config.GetString(GenderKey) // defaults to ""
 
 	if population == "" {
-		return nil, fmt.Errorf("%s is required in configuration", PopulationKey)
+		return nil, fmt.Errorf("%s is required in configuration unless %s is set", PopulationKey, CohortKey)
 	}
 
 	if !IsSupported(population, gender) {
@@ -34,6 +54,25 @@ func NewFromConfig() (*Ancestry, error) {
 	return New(population, gender)
 }
 
+// TraitOverridesFromConfig returns the per-trait reference ancestries, keyed by lower-cased
+// trait name. They let a trait be normalized against the only population its reference
+// stats are validated for, even when the user's ancestry differs.
+func TraitOverridesFromConfig() (map[string]*Ancestry, error) {
+	raw := config.GetStringMapString(TraitOverridesKey)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	overrides := make(map[string]*Ancestry, len(raw))
+	for trait, code := range raw {
+		a, err := FromCode(code)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", TraitOverridesKey, trait, err)
+		}
+		overrides[strings.ToLower(trait)] = a
+	}
+	return overrides, nil
+}
+
 // getBuiltinMappings returns the hardcoded ancestry mappings based on gnomAD v3 schema
 func getBuiltinMappings() map[string]ancestryInfo {
 	return map[string]ancestryInfo{
@@ -75,10 +114,3 @@ func getBuiltinMappings() map[string]ancestryInfo {
 		"FEMALE": {precedence: []string{"AF_female"}, description: "All females (ancestry-combined)"},
 	}
 }
-
-// init registers the required configuration keys
-func init() {
-	// Register configuration keys with the config system
-	config.RegisterRequiredKey(PopulationKey)
-	// Note: ancestry.gender is optional and defaults to empty string if not provided
-}
@@ -2,6 +2,8 @@ package ancestry
 
 import (
 	"testing"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
 )
 
 func TestNewFromConfig_MockTest(t *testing.T) {
@@ -15,6 +17,36 @@ func TestNewFromConfig_MockTest(t *testing.T) {
 	t.Skip("NewFromConfig requires actual config file setup - skipping in unit tests")
 }
 
+func TestNewFromConfig(t *testing.T) {
+	// Population is resolved on use, from whatever provider is installed then
+	restore := config.Use(config.NewValues(map[string]any{}))
+	_, err := NewFromConfig()
+	restore()
+	if err == nil {
+		t.Error("expected an error without a population or cohort")
+	}
+
+	restore = config.Use(config.NewValues(map[string]any{"ancestry": map[string]any{"population": "EAS", "gender": "FEMALE"}}))
+	a, err := NewFromConfig()
+	restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Code() != "EAS_FEMALE" {
+		t.Errorf("code = %s, want EAS_FEMALE", a.Code())
+	}
+
+	restore = config.Use(config.NewValues(map[string]any{"ancestry": map[string]any{"cohort": "biobank"}}))
+	a, err = NewFromConfig()
+	restore()
+	if err != nil {
+		t.Fatalf("a custom cohort needs no population: %v", err)
+	}
+	if !a.IsCustomCohort() {
+		t.Errorf("ancestry = %s, want the custom cohort", a.Code())
+	}
+}
+
 func TestGetBuiltinMappings(t *testing.T) {
 	mappings := getBuiltinMappings()
 
@@ -82,3 +114,24 @@ func isValidColumnName(col string) bool {
 	}
 	return false
 }
+
+func TestTraitOverridesFromConfig(t *testing.T) {
+	config.Set(TraitOverridesKey, map[string]string{"Height": "eur", "ldl": "EAS_FEMALE"})
+	defer config.Set(TraitOverridesKey, map[string]string{})
+
+	overrides, err := TraitOverridesFromConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := overrides["height"].Code(); got != "EUR" {
+		t.Errorf("height override = %s, want EUR", got)
+	}
+	if got := overrides["ldl"].Code(); got != "EAS_FEMALE" {
+		t.Errorf("ldl override = %s, want EAS_FEMALE", got)
+	}
+
+	config.Set(TraitOverridesKey, map[string]string{"height": "MARS"})
+	if _, err := TraitOverridesFromConfig(); err == nil {
+		t.Error("expected an error for an unsupported ancestry code")
+	}
+}
@@ -0,0 +1,101 @@
+// Package buildinfo reports the version, commit, and build date of the running binary and
+// the database backends compiled into it. Release builds set the version fields with
+// -ldflags "-X phite.io/polygenic-risk-calculator/internal/buildinfo.Version=v1.2.3 ...";
+// other builds fall back to the VCS stamp Go embeds.
+package buildinfo
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+)
+
+// Set at build time with -ldflags -X.
+var (
+	Version = "" // release version, e.g. v1.2.3
+	Commit  = "" // git commit hash
+	Date    = "" // build date, RFC 3339
+)
+
+// Response headers Handler sets.
+const (
+	HeaderVersion = "X-Phite-Version"
+	HeaderCommit  = "X-Phite-Commit"
+)
+
+// Info describes the running binary.
+type Info struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit,omitempty"`
+	Date      string   `json:"build_date,omitempty"`
+	GoVersion string   `json:"go_version"`
+	Backends  []string `json:"backends,omitempty"` // repository types compiled in, e.g. duckdb, bq
+}
+
+var (
+	mu       sync.Mutex
+	backends = map[string]bool{}
+)
+
+// RegisterBackend records a repository type compiled into the binary. Backend packages call
+// it from init.
+func RegisterBackend(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	backends[name] = true
+}
+
+// Current returns the build information of the running binary. Fields not set at build
+// time are read from the module and VCS information Go embeds; the version is "dev" when
+// neither is available.
+func Current() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		modified := false
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = s.Value
+				}
+			case "vcs.modified":
+				modified = s.Value == "true"
+			}
+		}
+		if modified && Commit == "" && info.Commit != "" {
+			info.Commit += "-dirty"
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+
+	mu.Lock()
+	for name := range backends {
+		info.Backends = append(info.Backends, name)
+	}
+	mu.Unlock()
+	sort.Strings(info.Backends)
+	return info
+}
+
+// Handler wraps h to set the HeaderVersion and HeaderCommit response headers.
+func Handler(h http.Handler) http.Handler {
+	info := Current()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderVersion, info.Version)
+		if info.Commit != "" {
+			w.Header().Set(HeaderCommit, info.Commit)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,42 @@
+package buildinfo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrent(t *testing.T) {
+	defer func(v, c, d string) { Version, Commit, Date = v, c, d }(Version, Commit, Date)
+	Version, Commit, Date = "v1.2.3", "abc123", "2026-01-02T03:04:05Z"
+	RegisterBackend("duckdb")
+	RegisterBackend("bq")
+	RegisterBackend("duckdb")
+
+	info := Current()
+	assert.Equal(t, "v1.2.3", info.Version)
+	assert.Equal(t, "abc123", info.Commit)
+	assert.Equal(t, "2026-01-02T03:04:05Z", info.Date)
+	assert.NotEmpty(t, info.GoVersion)
+	assert.Equal(t, []string{"bq", "duckdb"}, info.Backends)
+}
+
+func TestCurrent_DefaultsToDev(t *testing.T) {
+	defer func(v string) { Version = v }(Version)
+	Version = ""
+	// Test binaries carry no module version
+	assert.Equal(t, "dev", Current().Version)
+}
+
+func TestHandler(t *testing.T) {
+	defer func(v, c string) { Version, Commit = v, c }(Version, Commit)
+	Version, Commit = "v1.2.3", "abc123"
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "v1.2.3", rec.Header().Get(HeaderVersion))
+	assert.Equal(t, "abc123", rec.Header().Get(HeaderCommit))
+}
@@ -1,25 +1,62 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
 	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/db/dbutil"
+	"phite.io/polygenic-risk-calculator/internal/genotype"
+	"phite.io/polygenic-risk-calculator/internal/input"
+	"phite.io/polygenic-risk-calculator/internal/output"
+	"phite.io/polygenic-risk-calculator/internal/panel"
+	"phite.io/polygenic-risk-calculator/internal/pgx"
+	"phite.io/polygenic-risk-calculator/internal/pipeline"
+	"phite.io/polygenic-risk-calculator/internal/queue"
+	"phite.io/polygenic-risk-calculator/internal/reference"
+	reference_stats "phite.io/polygenic-risk-calculator/internal/reference/stats"
+	"phite.io/polygenic-risk-calculator/internal/retention"
+	"phite.io/polygenic-risk-calculator/internal/server"
 	snpsutil "phite.io/polygenic-risk-calculator/internal/snps"
+	"phite.io/polygenic-risk-calculator/internal/workflow"
 )
 
 type Options struct {
-	GenotypeFile   string
-	SNPs           []string
-	SNPsFile       string
-	GWASDB         string
-	GWASTable      string
-	Output         string
-	Format         string
-	ReferenceTable string
+	GenotypeFile       string
+	VCFSample          string // sample read from a multi-sample VCF genotype file
+	SNPs               []string
+	SNPsFile           string
+	Panel              string // bundled trait panel name, or path of a panel YAML file, supplying the SNPs
+	GWASDB             string
+	GWASTable          string
+	Output             string
+	OutputDir          string // directory for one JSON file per trait plus an index
+	Format             string
+	ReferenceTable     string
+	Ancestry           string   // population code overriding ancestry.population
+	Gender             string   // gender code overriding ancestry.gender
+	SortBy             string   // trait summary order: percentile, abs_z, trait, category, or panel
+	GroupBy            string   // "topic" to group trait summaries by topic
+	ModelVersion       string   // pinned model release label recorded in cache keys and outputs
+	TraitModelMap      string   // TSV of trait, model ID naming each trait's model
+	AllAncestries      bool     // also compute and cache reference stats for every supported ancestry
+	PGx                bool     // report CPIC-style pharmacogene phenotypes
+	CuratedNotes       []string // converter JSON files or directories merged into trait summaries
+	SuppressActionable bool     // withhold traits listed as clinically actionable
+	Concurrency        int      // traits scored at once in Phase 3; 0 keeps pipeline.trait_concurrency
+
+	ChecksumManifest string // sha256sum-format manifest to verify input files against
+	RequireChecksums bool   // refuse to run unless every input file is listed and matches
+
+	MachineReadableIO bool // print an I/O manifest on stdout instead of results, for workflow engines
+	SkipIfUnchanged   bool // exit early when the previous results' run key matches this run's
 }
 
 // ParseOptions parses CLI flags and resolves each parameter from CLI/env/config/default.
@@ -30,26 +67,170 @@ func ParseOptions(args []string) (Options, error) {
 	var snps string
 
 	flags.StringVar(&opts.GenotypeFile, "genotype-file", "", "Path to genotype file (required)")
+	flags.StringVar(&opts.VCFSample, "vcf-sample", "", "Sample to read from a multi-sample VCF genotype file (default: the first)")
 	flags.StringVar(&snps, "snps", "", "Comma-separated list of SNP IDs (required unless --snps-file)")
 	flags.StringVar(&opts.SNPsFile, "snps-file", "", "Path to SNPs file (required unless --snps)")
+	flags.StringVar(&opts.Panel, "panel", "", "Trait panel to score, e.g. cardiometabolic, or the path of a panel YAML file (replaces --snps)")
 	flags.StringVar(&opts.GWASDB, "gwas-db", "", "Path to GWAS DuckDB (required)")
 	flags.StringVar(&opts.GWASTable, "gwas-table", "", "GWAS table name (optional)")
 	flags.StringVar(&opts.Output, "output", "", "Output file path (optional)")
-	flags.StringVar(&opts.Format, "format", "", "Output format (optional)")
+	flags.StringVar(&opts.OutputDir, "output-dir", "", "Directory for one JSON file per trait plus index.json (optional)")
+	flags.StringVar(&opts.Format, "format", "json", "Output format: json, csv, html, or a formatter under output.formatters (default: json)")
 	flags.StringVar(&opts.ReferenceTable, "reference-table", "reference_panel", "Reference stats table name (optional, default: reference_panel)")
+	flags.StringVar(&opts.Ancestry, "ancestry", "", "Population code for reference frequencies, e.g. EUR (optional)")
+	flags.StringVar(&opts.Gender, "gender", "", "Gender for reference frequencies: MALE or FEMALE (optional)")
+	flags.StringVar(&opts.SortBy, "sort-by", "", "Sort trait summaries by percentile, abs_z, trait, category, or panel (optional)")
+	flags.StringVar(&opts.GroupBy, "group-by", "", "Group trait summaries by topic (optional)")
+	flags.StringVar(&opts.ModelVersion, "model-version", "", "Pin the PRS model release label (optional)")
+	flags.StringVar(&opts.TraitModelMap, "trait-model-map", "", "TSV mapping traits to model IDs in the model table (optional)")
+	flags.BoolVar(&opts.AllAncestries, "all-ancestries", false, "Also compute and cache reference stats for every supported ancestry")
+	flags.BoolVar(&opts.PGx, "pgx", false, "Report CPIC-style pharmacogene phenotypes")
+	flags.StringSliceVar(&opts.CuratedNotes, "curated-notes", nil, "Converter JSON files or directories to merge into trait summaries (optional)")
+	flags.BoolVar(&opts.SuppressActionable, "suppress-actionable", false, "Withhold every trait listed as clinically actionable from the outputs")
+	flags.IntVar(&opts.Concurrency, "concurrency", 0, "Traits scored at once (default: pipeline.trait_concurrency, else 1)")
+	flags.StringVar(&opts.ChecksumManifest, "checksum-manifest", "", "sha256 manifest to verify input files against (optional)")
+	flags.BoolVar(&opts.RequireChecksums, "require-checksums", false, "Refuse to run unless every input file passes checksum verification")
+	flags.BoolVar(&opts.MachineReadableIO, "machine-readable-io", false, "Print a JSON manifest of input and output files on stdout; requires --output or --output-dir")
+	flags.BoolVar(&opts.SkipIfUnchanged, "skip-if-unchanged", false, "Keep the previous JSON results when their inputs and config match this run's")
 
 	if err := flags.Parse(args); err != nil {
 		return opts, err
 	}
 
+	// Remote inputs (s3://, https://) are downloaded before anything reads them
+	if err := localize([]remoteFlag{
+		{"--genotype-file", &opts.GenotypeFile},
+		{"--snps-file", &opts.SNPsFile},
+		{"--gwas-db", &opts.GWASDB},
+		{"--trait-model-map", &opts.TraitModelMap},
+		{"--checksum-manifest", &opts.ChecksumManifest},
+	}); err != nil {
+		return opts, err
+	}
+	for i := range opts.CuratedNotes {
+		if err := localize([]remoteFlag{{"--curated-notes", &opts.CuratedNotes[i]}}); err != nil {
+			return opts, err
+		}
+	}
+
+	if err := output.ValidateFormat(opts.Format); err != nil {
+		return opts, fmt.Errorf("--format: %w", err)
+	}
+	if opts.OutputDir != "" {
+		if opts.Output != "" {
+			return opts, errors.New("--output and --output-dir are mutually exclusive")
+		}
+		if opts.Format != "json" {
+			return opts, errors.New("--output-dir writes JSON only")
+		}
+	}
+	if opts.MachineReadableIO && opts.Output == "" && opts.OutputDir == "" {
+		return opts, errors.New("--machine-readable-io needs --output or --output-dir: stdout carries the manifest")
+	}
+	if opts.SkipIfUnchanged && (opts.Format != "json" || opts.Output == "" && opts.OutputDir == "") {
+		return opts, errors.New("--skip-if-unchanged needs JSON results in --output or --output-dir")
+	}
+
 	// GWAS Database with Validation
 	if opts.GWASDB != "" {
 		config.Set("gwas_db_path", opts.GWASDB)
 	}
 	if opts.GWASTable != "" {
+		if err := dbutil.ValidateIdent(opts.GWASTable); err != nil {
+			return opts, fmt.Errorf("--gwas-table: %w", err)
+		}
 		config.Set("gwas_table", opts.GWASTable)
 	}
 
+	if opts.VCFSample != "" {
+		config.Set(genotype.VCFSampleKey, opts.VCFSample)
+	}
+
+	// Ancestry overrides
+	if opts.Ancestry != "" {
+		population, err := ancestry.NormalizePopulation(opts.Ancestry)
+		if err != nil {
+			return opts, fmt.Errorf("--ancestry: %w", err)
+		}
+		opts.Ancestry = population
+		config.Set(ancestry.PopulationKey, population)
+	}
+	if opts.Gender != "" {
+		gender, err := ancestry.NormalizeGender(opts.Gender)
+		if err != nil {
+			return opts, fmt.Errorf("--gender: %w", err)
+		}
+		opts.Gender = gender
+		config.Set(ancestry.GenderKey, gender)
+	}
+
+	// Trait summary ordering
+	if opts.SortBy != "" {
+		if err := output.ValidateSortBy(opts.SortBy); err != nil {
+			return opts, fmt.Errorf("--sort-by: %w", err)
+		}
+		config.Set(output.SortByKey, opts.SortBy)
+	}
+	if opts.GroupBy != "" {
+		if err := output.ValidateGroupBy(opts.GroupBy); err != nil {
+			return opts, fmt.Errorf("--group-by: %w", err)
+		}
+		config.Set(output.GroupByKey, opts.GroupBy)
+	}
+
+	// Model version pinning
+	if opts.ModelVersion != "" {
+		if err := reference.ValidateModelVersion(opts.ModelVersion); err != nil {
+			return opts, fmt.Errorf("--model-version: %w", err)
+		}
+		config.Set(reference.ModelVersionKey, opts.ModelVersion)
+	}
+
+	if opts.TraitModelMap != "" {
+		if _, err := reference.LoadTraitModels(opts.TraitModelMap); err != nil {
+			return opts, fmt.Errorf("--trait-model-map: %w", err)
+		}
+		config.Set(reference.TraitModelMapKey, opts.TraitModelMap)
+	}
+
+	if opts.AllAncestries {
+		config.Set(pipeline.AllAncestriesKey, true)
+	}
+	if opts.PGx {
+		config.Set(pgx.EnabledKey, true)
+	}
+	if len(opts.CuratedNotes) > 0 {
+		config.Set(output.CuratedNotesKey, opts.CuratedNotes)
+	}
+	if opts.SuppressActionable {
+		config.Set(output.SuppressActionableKey, true)
+	}
+	if opts.Concurrency < 0 {
+		return opts, errors.New("--concurrency must be positive")
+	}
+	if opts.Concurrency > 0 {
+		config.Set(pipeline.TraitConcurrencyKey, opts.Concurrency)
+	}
+
+	// A panel supplies the SNPs, and the pipeline keeps only its traits
+	if opts.Panel == "" && snps == "" && opts.SNPsFile == "" {
+		opts.Panel = config.GetString(panel.NameKey)
+	}
+	if opts.Panel != "" {
+		if snps != "" || opts.SNPsFile != "" {
+			return opts, errors.New("--panel supplies the SNPs: drop --snps and --snps-file")
+		}
+		if err := localize([]remoteFlag{{"--panel", &opts.Panel}}); err != nil {
+			return opts, err
+		}
+		p, err := panel.Get(opts.Panel)
+		if err != nil {
+			return opts, fmt.Errorf("--panel: %w", err)
+		}
+		config.Set(panel.NameKey, opts.Panel)
+		opts.SNPs = p.SNPs
+	}
+
 	// Canonical SNP resolution (enforce mutual exclusion, requiredness, and validation)
 	if opts.SNPsFile != "" {
 		config.Set("snps_file", opts.SNPsFile)
@@ -64,7 +245,7 @@ func ParseOptions(args []string) (Options, error) {
 	if err != nil {
 		switch err {
 		case snpsutil.ErrNoSNPsProvided:
-			return opts, errors.New("either --snps or --snps-file is required")
+			return opts, errors.New("one of --snps, --snps-file, or --panel is required")
 		default:
 			return opts, err
 		}
@@ -82,6 +263,9 @@ func ParseOptions(args []string) (Options, error) {
 	if opts.GWASDB == "" && config.GetString("gwas_db_path") == "" {
 		errMsgs = append(errMsgs, "--gwas-db is required")
 	}
+	if opts.RequireChecksums && opts.ChecksumManifest == "" {
+		errMsgs = append(errMsgs, "--require-checksums needs --checksum-manifest")
+	}
 	if len(errMsgs) > 0 {
 		return opts, errors.New(strings.Join(errMsgs, "; "))
 	}
@@ -89,17 +273,1048 @@ func ParseOptions(args []string) (Options, error) {
 	return opts, nil
 }
 
+// remoteFlag is a path flag that may name an s3:// or https:// location.
+type remoteFlag struct {
+	name string
+	path *string
+}
+
+// localize replaces each remote flag value with the local path of its download.
+func localize(flags []remoteFlag) error {
+	for _, f := range flags {
+		local, err := input.Localize(context.Background(), *f.path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+		*f.path = local
+	}
+	return nil
+}
+
+// GlobalOptions holds the flags every command accepts.
+type GlobalOptions struct {
+	Quiet    bool   // --quiet (or -q): suppress logs below error level
+	QueryLog string // --query-log PATH: record every database query to PATH (a file, or a directory for one file per run)
+	Config   string // --config PATH: config file to read instead of ~/.phite/config.json; may be an s3:// or https:// location
+}
+
+// SplitGlobalFlags removes the flags every command accepts from args and returns the rest
+// with the global options. Arguments after "--" are kept as is.
+func SplitGlobalFlags(args []string) (rest []string, global GlobalOptions, err error) {
+	rest = make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return append(rest, args[i:]...), global, nil
+		case arg == "--quiet" || arg == "-q":
+			global.Quiet = true
+		case arg == "--query-log":
+			if i+1 >= len(args) {
+				return nil, global, fmt.Errorf("--query-log requires a path")
+			}
+			i++
+			global.QueryLog = args[i]
+		case strings.HasPrefix(arg, "--query-log="):
+			global.QueryLog = strings.TrimPrefix(arg, "--query-log=")
+		case arg == "--config":
+			if i+1 >= len(args) {
+				return nil, global, fmt.Errorf("--config requires a path")
+			}
+			i++
+			global.Config = args[i]
+		case strings.HasPrefix(arg, "--config="):
+			global.Config = strings.TrimPrefix(arg, "--config=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest, global, nil
+}
+
+// Args returns the global options as flags, for passing on to a child process.
+func (g GlobalOptions) Args() []string {
+	var args []string
+	if g.Quiet {
+		args = append(args, "--quiet")
+	}
+	if g.QueryLog != "" {
+		args = append(args, "--query-log", g.QueryLog)
+	}
+	if g.Config != "" {
+		args = append(args, "--config", g.Config)
+	}
+	return args
+}
+
 // PrintHelp prints the usage/help text for the CLI.
 func PrintHelp() {
-	fmt.Fprintf(os.Stderr, `Usage: risk-calculator [OPTIONS]\n
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator [OPTIONS]
+       risk-calculator schema verify [--format text|json] [--only gnomad,model,cache]
+       risk-calculator gc [--cache-days N] [--results-days M] [--results-dir DIR]
+       risk-calculator fingerprint [--format text|json] FILE FILE...
+       risk-calculator index [--apply] [--format text|json]
+       risk-calculator diff [--tolerance X] [--format text|json] RUN1.json RUN2.json
+       risk-calculator validate-stats --traits T1,T2 [--cohort N] [--format text|json]
+       risk-calculator explain-dosage --genotype-file FILE --snps rs1,rs2 [--trait T] [--format text|json]
+       risk-calculator version [--format text|json]
+       risk-calculator descriptor --engine wdl|nextflow|cwl [--container IMAGE]
+       risk-calculator worker [--subscription SUB] [--results-topic TOPIC] [--concurrency N] [--max-attempts N]
+       risk-calculator panels [--format text|json]
+       risk-calculator coverage --manifest FILE (--panel NAME | --traits T1,T2) [--format text|json]
+       risk-calculator research-export --opt-in [--phenotypes FILE --columns C1,C2] [--output FILE] RUN.json...
+       risk-calculator serve [--addr ADDR] [--concurrency N] [--timeout D] [--gwas-db FILE]
+       risk-calculator cohort --manifest FILE --output-dir DIR (--snps rs1,rs2 | --snps-file FILE | --panel NAME)\n
 Options:
-  --genotype-file   Path to genotype file (required)
+  --genotype-file   Path to genotype file: AncestryDNA, 23andMe, or VCF, optionally gzip/bgzip compressed (required)
+  --vcf-sample      Sample to read from a multi-sample VCF (default: genotype.vcf_sample, else the first)
   --snps            Comma-separated list of SNP IDs (required unless --snps-file)
   --snps-file       Path to SNPs file (required unless --snps)
+  --panel           Trait panel to score instead of --snps: a name listed by "risk-calculator panels",
+                    or the path of a panel YAML file (default: panel.name)
   --gwas-db         Path to GWAS DuckDB (required)
   --gwas-table      GWAS table name (optional)
   --output          Output file path (optional)
-  --format          Output format (optional)
+  --output-dir      Directory for one JSON file per trait plus index.json (optional)
+  --format          Output format: json, csv, html, or a formatter under output.formatters (default: json)
   --reference-db    Path to reference stats DB (optional)
+  --ancestry        Population code for reference frequencies: AFR, AMR, ASJ, EAS, EUR, FIN, SAS, OTH, AMI (optional)
+  --gender          Gender for reference frequencies: MALE or FEMALE (optional)
+  --sort-by         Sort trait summaries: percentile, abs_z, trait, category, or panel (optional)
+  --group-by        Group trait summaries by topic (optional)
+  --model-version   Pin the PRS model release; cached stats and outputs record it (optional)
+  --trait-model-map  TSV mapping traits to model IDs in the model table (optional)
+  --all-ancestries  Also compute and cache reference stats for every supported ancestry
+  --pgx             Report CPIC-style pharmacogene phenotypes (optional)
+  --curated-notes   Converter JSON files or directories to merge into trait summaries (optional)
+  --suppress-actionable  Withhold every trait listed as clinically actionable from the outputs
+  --concurrency     Traits scored at once in Phase 3 (default: pipeline.trait_concurrency, else 1)
+  --checksum-manifest  sha256 manifest to verify input files against (optional)
+  --require-checksums  Refuse to run unless every input file passes verification
+  --machine-readable-io  Print a JSON manifest of input and output files on stdout (needs --output or --output-dir)
+  --skip-if-unchanged  Keep the previous JSON results when their inputs and config match this run's
+
+--output and --output-dir may contain {run_key}, replaced by a digest of the inputs and config.
+  --quiet, -q       Suppress logs below error level (any command)
+  --query-log       Record every database query, with DuckDB plans, to a JSON-lines file or directory (any command)
+  --config          Config file to read instead of ~/.phite/config.json (any command)
+
+File arguments, --config, and path config keys accept s3://bucket/key and https:// locations.
+
+Results go to stdout (or --output); logs go to stderr.
+`)
+}
+
+// SchemaOptions holds the flags for `risk-calculator schema verify`.
+type SchemaOptions struct {
+	Format string   // text (default) or json
+	Only   []string // check names to run; empty runs all
+}
+
+// ParseSchemaOptions parses the flags that follow `schema verify`.
+func ParseSchemaOptions(args []string) (SchemaOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator schema verify", pflag.ContinueOnError)
+
+	var opts SchemaOptions
+	var only string
+	flags.StringVar(&opts.Format, "format", "text", "Report format: text or json")
+	flags.StringVar(&only, "only", "", "Comma-separated checks to run: gnomad, model, cache (default: all)")
+
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return opts, fmt.Errorf("unsupported --format %q: use text or json", opts.Format)
+	}
+	if only != "" {
+		for _, name := range strings.Split(only, ",") {
+			name = strings.TrimSpace(name)
+			if name != "gnomad" && name != "model" && name != "cache" {
+				return opts, fmt.Errorf("unknown schema check %q: use gnomad, model, or cache", name)
+			}
+			opts.Only = append(opts.Only, name)
+		}
+	}
+	return opts, nil
+}
+
+// PrintSchemaHelp prints the usage/help text for the schema subcommand.
+func PrintSchemaHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator schema verify [OPTIONS]
+
+Verifies that the allele frequency, model, and cache tables exist and have the expected columns.
+Exit codes: 0 all tables valid, 1 usage or setup error, 2 schema mismatch.
+
+Options:
+  --format   Report format: text or json (default: text)
+  --only     Comma-separated checks to run: gnomad, model, cache (default: all)
+`)
+}
+
+// GCOptions holds the flags for `risk-calculator gc`.
+type GCOptions struct {
+	Format string // text (default) or json
+}
+
+// ParseGCOptions parses the flags that follow `gc`. Retention flags override their config keys.
+func ParseGCOptions(args []string) (GCOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator gc", pflag.ContinueOnError)
+
+	var opts GCOptions
+	var cacheDays, resultsDays int
+	var resultsDir string
+	flags.StringVar(&opts.Format, "format", "text", "Report format: text or json")
+	flags.IntVar(&cacheDays, "cache-days", 0, "Delete cache entries older than N days (overrides retention.cache_max_age_days)")
+	flags.IntVar(&resultsDays, "results-days", 0, "Purge stored results older than M days (overrides retention.results_max_age_days)")
+	flags.StringVar(&resultsDir, "results-dir", "", "Directory holding stored results (overrides retention.results_dir)")
+
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return opts, fmt.Errorf("unsupported --format %q: use text or json", opts.Format)
+	}
+	if cacheDays < 0 || resultsDays < 0 {
+		return opts, errors.New("--cache-days and --results-days must not be negative")
+	}
+
+	if flags.Changed("cache-days") {
+		config.Set(retention.CacheMaxAgeDaysKey, cacheDays)
+	}
+	if flags.Changed("results-days") {
+		config.Set(retention.ResultsMaxAgeDaysKey, resultsDays)
+	}
+	if resultsDir != "" {
+		config.Set(retention.ResultsDirKey, resultsDir)
+	}
+	return opts, nil
+}
+
+// PrintGCHelp prints the usage/help text for the gc subcommand.
+func PrintGCHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator gc [OPTIONS]
+
+Applies the retention policy: deletes expired reference stats cache entries and stored results.
+
+Options:
+  --cache-days     Delete cache entries older than N days (default: retention.cache_max_age_days)
+  --results-days   Purge stored results older than M days (default: retention.results_max_age_days)
+  --results-dir    Directory holding stored results (default: retention.results_dir)
+  --format         Report format: text or json (default: text)
+`)
+}
+
+// FingerprintOptions holds the flags for `risk-calculator fingerprint`.
+type FingerprintOptions struct {
+	Format string   // text (default) or json
+	Files  []string // genotype files to compare
+}
+
+// ParseFingerprintOptions parses the flags and genotype file arguments that follow `fingerprint`.
+func ParseFingerprintOptions(args []string) (FingerprintOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator fingerprint", pflag.ContinueOnError)
+
+	var opts FingerprintOptions
+	flags.StringVar(&opts.Format, "format", "text", "Report format: text or json")
+
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return opts, fmt.Errorf("unsupported --format %q: use text or json", opts.Format)
+	}
+	opts.Files = flags.Args()
+	if len(opts.Files) < 2 {
+		return opts, errors.New("at least two genotype files are required")
+	}
+	return opts, nil
+}
+
+// PrintFingerprintHelp prints the usage/help text for the fingerprint subcommand.
+func PrintFingerprintHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator fingerprint [OPTIONS] FILE FILE...
+
+Fingerprints each genotype file on a panel of common variants and flags likely duplicate
+or swapped samples. Exit codes: 0 no matches, 1 usage or read error, 2 matches found.
+
+Options:
+  --format   Report format: text or json (default: text)
+`)
+}
+
+// IndexOptions holds the flags for `risk-calculator index`.
+type IndexOptions struct {
+	Format string // text (default) or json
+	Apply  bool   // execute the recommended statements instead of only listing them
+}
+
+// ParseIndexOptions parses the flags that follow `index`. --gwas-db and --gwas-table override their config keys.
+func ParseIndexOptions(args []string) (IndexOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator index", pflag.ContinueOnError)
+
+	var opts IndexOptions
+	var gwasDB, gwasTable string
+	flags.StringVar(&opts.Format, "format", "text", "Report format: text or json")
+	flags.BoolVar(&opts.Apply, "apply", false, "Create the recommended indexes and sort the tables")
+	flags.StringVar(&gwasDB, "gwas-db", "", "Path to GWAS DuckDB (overrides gwas_db_path)")
+	flags.StringVar(&gwasTable, "gwas-table", "", "GWAS table name (overrides gwas_table)")
+
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return opts, fmt.Errorf("unsupported --format %q: use text or json", opts.Format)
+	}
+	if gwasDB != "" {
+		config.Set("gwas_db_path", gwasDB)
+	}
+	if gwasTable != "" {
+		config.Set("gwas_table", gwasTable)
+	}
+	if config.GetString("gwas_db_path") == "" {
+		return opts, errors.New("--gwas-db or config key 'gwas_db_path' is required")
+	}
+	return opts, nil
+}
+
+// PrintIndexHelp prints the usage/help text for the index subcommand.
+func PrintIndexHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator index [OPTIONS]
+
+Recommends indexes and table ordering for the lookups the pipeline runs against the GWAS
+DuckDB: rsid IN lists on gwas_table and trait filters on tables.model_table. With --apply,
+tables are sorted and indexed, and each lookup is timed before and after.
+Sorting rewrites a table, dropping its constraints; back up the database first.
+
+Options:
+  --apply        Create the recommended indexes and sort the tables
+  --gwas-db      Path to GWAS DuckDB (default: gwas_db_path)
+  --gwas-table   GWAS table name (default: gwas_table)
+  --format       Report format: text or json (default: text)
+`)
+}
+
+// DiffOptions holds the flags for `risk-calculator diff`.
+type DiffOptions struct {
+	Format string // text (default) or json
+	A, B   string // JSON output files to compare
+}
+
+// ParseDiffOptions parses the flags and output file arguments that follow `diff`.
+// Tolerance flags override their config keys.
+func ParseDiffOptions(args []string) (DiffOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator diff", pflag.ContinueOnError)
+
+	var opts DiffOptions
+	var scoreTol, percentileTol float64
+	flags.StringVar(&opts.Format, "format", "text", "Report format: text or json")
+	flags.Float64Var(&scoreTol, "tolerance", 0, "Largest z-score or contribution change treated as equal")
+	flags.Float64Var(&percentileTol, "percentile-tolerance", 0, "Largest percentile change treated as equal")
+
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return opts, fmt.Errorf("unsupported --format %q: use text or json", opts.Format)
+	}
+	if scoreTol < 0 || percentileTol < 0 {
+		return opts, errors.New("tolerances must not be negative")
+	}
+	if scoreTol > 0 {
+		config.Set(output.DiffScoreToleranceKey, scoreTol)
+	}
+	if percentileTol > 0 {
+		config.Set(output.DiffPercentileToleranceKey, percentileTol)
+	}
+	if flags.NArg() != 2 {
+		return opts, errors.New("exactly two output files are required")
+	}
+	opts.A, opts.B = flags.Arg(0), flags.Arg(1)
+	return opts, nil
+}
+
+// PrintDiffHelp prints the usage/help text for the diff subcommand.
+func PrintDiffHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator diff [OPTIONS] RUN1.json RUN2.json
+
+Compares two JSON pipeline outputs: trait scores and statuses, missing SNPs, and excluded-SNP
+warnings. Numeric changes within tolerance are ignored.
+Exit codes: 0 equal within tolerance, 1 usage or read error, 2 differences found.
+
+Options:
+  --tolerance              Largest z-score or contribution change treated as equal
+                           (default: output.diff_score_tolerance, else 1e-9)
+  --percentile-tolerance   Largest percentile change treated as equal
+                           (default: output.diff_percentile_tolerance, else 1e-9)
+  --format                 Report format: text or json (default: text)
+`)
+}
+
+// ValidateStatsOptions holds the flags for `risk-calculator validate-stats`.
+type ValidateStatsOptions struct {
+	Format string   // text (default) or json
+	Traits []string // traits whose reference stats are checked
+	Checks reference_stats.ValidationOptions
+}
+
+// ParseValidateStatsOptions parses the flags that follow `validate-stats`. --ancestry and
+// --gender override their config keys as for a run.
+func ParseValidateStatsOptions(args []string) (ValidateStatsOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator validate-stats", pflag.ContinueOnError)
+
+	opts := ValidateStatsOptions{Checks: reference_stats.DefaultValidationOptions()}
+	var population, gender string
+	flags.StringVar(&opts.Format, "format", "text", "Report format: text or json")
+	flags.StringSliceVar(&opts.Traits, "traits", nil, "Comma-separated traits to validate (required)")
+	flags.StringVar(&population, "ancestry", "", "Population code (overrides ancestry.population)")
+	flags.StringVar(&gender, "gender", "", "MALE or FEMALE (overrides ancestry.gender)")
+	flags.IntVar(&opts.Checks.Cohort, "cohort", opts.Checks.Cohort, "Simulated individuals; 0 skips the simulation")
+	flags.Int64Var(&opts.Checks.Seed, "seed", opts.Checks.Seed, "Seed of the simulated cohort")
+	flags.Float64Var(&opts.Checks.MinCoverage, "min-coverage", opts.Checks.MinCoverage, "Smallest fraction of model variants with a frequency")
+
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return opts, fmt.Errorf("unsupported --format %q: use text or json", opts.Format)
+	}
+	if len(opts.Traits) == 0 {
+		return opts, errors.New("--traits is required")
+	}
+	if opts.Checks.Cohort < 0 {
+		return opts, errors.New("--cohort must not be negative")
+	}
+	if opts.Checks.MinCoverage < 0 || opts.Checks.MinCoverage > 1 {
+		return opts, errors.New("--min-coverage must be between 0 and 1")
+	}
+	if population != "" {
+		code, err := ancestry.NormalizePopulation(population)
+		if err != nil {
+			return opts, fmt.Errorf("--ancestry: %w", err)
+		}
+		config.Set(ancestry.PopulationKey, code)
+	}
+	if gender != "" {
+		code, err := ancestry.NormalizeGender(gender)
+		if err != nil {
+			return opts, fmt.Errorf("--gender: %w", err)
+		}
+		config.Set(ancestry.GenderKey, code)
+	}
+	return opts, nil
+}
+
+// PrintValidateStatsHelp prints the usage/help text for the validate-stats subcommand.
+func PrintValidateStatsHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator validate-stats --traits T1,T2 [OPTIONS]
+
+Computes each trait's reference stats from its model and allele frequencies and checks them,
+and any cached stats, for sanity: std positive and mean within [min, max]; mean and variance
+matching the frequencies; model variant frequency coverage; monotonic quantiles; and agreement
+with a simulated Hardy-Weinberg cohort.
+Exit codes: 0 all checks pass, 1 usage or query error, 2 checks failed.
+
+Options:
+  --traits         Comma-separated traits to validate (required)
+  --ancestry       Population code (default: ancestry.population)
+  --gender         MALE or FEMALE (default: ancestry.gender)
+  --cohort         Simulated individuals; 0 skips the simulation (default: 2000)
+  --seed           Seed of the simulated cohort (default: 1)
+  --min-coverage   Smallest fraction of model variants with a frequency (default: 0.8)
+  --format         Report format: text or json (default: text)
+`)
+}
+
+// ExplainDosageOptions holds the flags for `risk-calculator explain-dosage`.
+type ExplainDosageOptions struct {
+	Format       string   // text (default) or json
+	GenotypeFile string   // genotype file whose calls are explained
+	SNPs         []string // variants to explain
+	Trait        string   // only explain associations of this trait; empty explains all
+}
+
+// ParseExplainDosageOptions parses the flags that follow `explain-dosage`. --gwas-db and
+// --gwas-table override their config keys as for a run.
+func ParseExplainDosageOptions(args []string) (ExplainDosageOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator explain-dosage", pflag.ContinueOnError)
+
+	var opts ExplainDosageOptions
+	var snps, snpsFile, gwasDB, gwasTable string
+	flags.StringVar(&opts.Format, "format", "text", "Report format: text or json")
+	flags.StringVar(&opts.GenotypeFile, "genotype-file", "", "Path to genotype file (required)")
+	flags.StringVar(&snps, "snps", "", "Comma-separated list of SNP IDs (required unless --snps-file)")
+	flags.StringVar(&snpsFile, "snps-file", "", "Path to SNPs file (required unless --snps)")
+	flags.StringVar(&opts.Trait, "trait", "", "Only explain this trait's associations (optional)")
+	flags.StringVar(&gwasDB, "gwas-db", "", "Path to GWAS DuckDB (overrides gwas_db_path)")
+	flags.StringVar(&gwasTable, "gwas-table", "", "GWAS table name (overrides gwas_table)")
+
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return opts, fmt.Errorf("unsupported --format %q: use text or json", opts.Format)
+	}
+	if opts.GenotypeFile == "" {
+		return opts, errors.New("--genotype-file is required")
+	}
+	if err := localize([]remoteFlag{
+		{"--genotype-file", &opts.GenotypeFile},
+		{"--snps-file", &snpsFile},
+		{"--gwas-db", &gwasDB},
+	}); err != nil {
+		return opts, err
+	}
+	if snps != "" && snpsFile != "" {
+		return opts, errors.New("--snps and --snps-file are mutually exclusive")
+	}
+	var list []string
+	if snps != "" {
+		list = strings.Split(snps, ",")
+	}
+	resolved, err := snpsutil.ResolveSNPs(list, snpsFile)
+	if err != nil {
+		if err == snpsutil.ErrNoSNPsProvided {
+			return opts, errors.New("either --snps or --snps-file is required")
+		}
+		return opts, err
+	}
+	opts.SNPs = resolved
+	if gwasDB != "" {
+		config.Set("gwas_db_path", gwasDB)
+	}
+	if gwasTable != "" {
+		if err := dbutil.ValidateIdent(gwasTable); err != nil {
+			return opts, fmt.Errorf("--gwas-table: %w", err)
+		}
+		config.Set("gwas_table", gwasTable)
+	}
+	if config.GetString("gwas_db_path") == "" {
+		return opts, errors.New("--gwas-db or config key 'gwas_db_path' is required")
+	}
+	return opts, nil
+}
+
+// PrintExplainDosageHelp prints the usage/help text for the explain-dosage subcommand.
+func PrintExplainDosageHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator explain-dosage --genotype-file FILE --snps rs1,rs2 [OPTIONS]
+
+Prints, for each variant and each of its GWAS associations, the call read from the genotype
+file, the effect and other alleles, and the dosage scoring assigns with the reason: homozygous
+effect, heterozygous, homozygous other (dosage 0), or why the variant is not scored (not
+genotyped, hemizygous single-allele call, no call, or an allele validation failure).
+The configured dosage strategies and contig policy apply as in a run.
+
+Options:
+  --genotype-file  Path to genotype file (required)
+  --snps           Comma-separated list of SNP IDs (required unless --snps-file)
+  --snps-file      Path to SNPs file (required unless --snps)
+  --trait          Only explain this trait's associations (optional)
+  --gwas-db        Path to GWAS DuckDB (default: gwas_db_path)
+  --gwas-table     GWAS table name (default: gwas_table)
+  --format         Report format: text or json (default: text)
+`)
+}
+
+// VersionOptions holds the flags for `risk-calculator version`.
+type VersionOptions struct {
+	Format string // text (default) or json
+}
+
+// ParseVersionOptions parses the flags that follow `version`.
+func ParseVersionOptions(args []string) (VersionOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator version", pflag.ContinueOnError)
+
+	var opts VersionOptions
+	flags.StringVar(&opts.Format, "format", "text", "Output format: text or json")
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return opts, fmt.Errorf("unsupported --format %q: use text or json", opts.Format)
+	}
+	return opts, nil
+}
+
+// PrintVersionHelp prints the usage/help text for the version subcommand.
+func PrintVersionHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator version [--format text|json]
+
+Prints the version, git commit, and build date embedded at build time, the Go version, and
+the database backends compiled in. Every result's provenance.build records the same fields.
+
+Options:
+  --format   Output format: text or json (default: text)
+`)
+}
+
+// PanelsOptions holds the flags for `risk-calculator panels`.
+type PanelsOptions struct {
+	Format string // text (default) or json
+}
+
+// ParsePanelsOptions parses the flags that follow `panels`.
+func ParsePanelsOptions(args []string) (PanelsOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator panels", pflag.ContinueOnError)
+
+	var opts PanelsOptions
+	flags.StringVar(&opts.Format, "format", "text", "Output format: text or json")
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return opts, fmt.Errorf("unsupported --format %q: use text or json", opts.Format)
+	}
+	return opts, nil
+}
+
+// PrintPanelsHelp prints the usage/help text for the panels subcommand.
+func PrintPanelsHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator panels [--format text|json]
+
+Lists the bundled trait panels with their traits, by report section, and variant counts.
+Score one with --panel NAME.
+
+Options:
+  --format   Output format: text or json (default: text)
+`)
+}
+
+// CoverageOptions holds the flags for `risk-calculator coverage`.
+type CoverageOptions struct {
+	Format      string       // text (default) or json
+	Manifest    string       // array site list the models are checked against
+	Traits      []string     // traits whose models are checked
+	Panel       *panel.Panel // panel whose traits are checked, instead of Traits
+	MinCoverage float64      // smallest share of a model's weight on the array for the trait to be supported
+}
+
+// ParseCoverageOptions parses the flags that follow `coverage`. --gwas-db and
+// --trait-model-map override their config keys as for a run; a panel's models override the
+// trait model map.
+func ParseCoverageOptions(args []string) (CoverageOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator coverage", pflag.ContinueOnError)
+
+	opts := CoverageOptions{}
+	var panelName, gwasDB, traitModelMap string
+	flags.StringVar(&opts.Format, "format", "text", "Report format: text or json")
+	flags.StringVar(&opts.Manifest, "manifest", "", "Array site list, or a raw data file from the array (required)")
+	flags.StringVar(&panelName, "panel", "", "Trait panel whose traits are checked")
+	flags.StringSliceVar(&opts.Traits, "traits", nil, "Comma-separated traits to check (instead of --panel)")
+	flags.Float64Var(&opts.MinCoverage, "min-coverage", 0.8, "Smallest share of a model's weight on the array")
+	flags.StringVar(&gwasDB, "gwas-db", "", "Path to GWAS DuckDB holding the model table (overrides gwas_db_path)")
+	flags.StringVar(&traitModelMap, "trait-model-map", "", "TSV mapping traits to model IDs (overrides reference.trait_model_map)")
+
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return opts, fmt.Errorf("unsupported --format %q: use text or json", opts.Format)
+	}
+	if opts.Manifest == "" {
+		return opts, errors.New("--manifest is required")
+	}
+	if (panelName == "") == (len(opts.Traits) == 0) {
+		return opts, errors.New("one of --panel or --traits is required")
+	}
+	if opts.MinCoverage < 0 || opts.MinCoverage > 1 {
+		return opts, errors.New("--min-coverage must be between 0 and 1")
+	}
+	if err := localize([]remoteFlag{
+		{"--manifest", &opts.Manifest},
+		{"--panel", &panelName},
+		{"--gwas-db", &gwasDB},
+		{"--trait-model-map", &traitModelMap},
+	}); err != nil {
+		return opts, err
+	}
+	if panelName != "" {
+		p, err := panel.Get(panelName)
+		if err != nil {
+			return opts, fmt.Errorf("--panel: %w", err)
+		}
+		opts.Panel = p
+		opts.Traits = p.Names()
+	}
+	if gwasDB != "" {
+		config.Set("gwas_db_path", gwasDB)
+	}
+	if traitModelMap != "" {
+		if _, err := reference.LoadTraitModels(traitModelMap); err != nil {
+			return opts, fmt.Errorf("--trait-model-map: %w", err)
+		}
+		config.Set(reference.TraitModelMapKey, traitModelMap)
+	}
+	return opts, nil
+}
+
+// PrintCoverageHelp prints the usage/help text for the coverage subcommand.
+func PrintCoverageHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator coverage --manifest FILE (--panel NAME | --traits T1,T2) [OPTIONS]
+
+Reports, before any sample is scored, how much of each trait's PRS model a genotyping array
+assays: the model variants on the array, matched by rsid or position, and their share of the
+model's total absolute weight. With --panel, the panel's own variants are counted too.
+The manifest is a site list (a VCF, or one rsid or chr:pos per line, optionally followed by
+chromosome and position columns); a 23andMe or AncestryDNA raw data file from the same
+array serves as one.
+Exit codes: 0 every trait supported, 1 usage or query error, 2 some trait below --min-coverage.
+
+Options:
+  --manifest          Array site list, or a raw data file from the array (required)
+  --panel             Trait panel whose traits are checked: a name listed by "risk-calculator
+                      panels", or the path of a panel YAML file
+  --traits            Comma-separated traits to check (instead of --panel)
+  --min-coverage      Smallest share of a model's weight on the array (default: 0.8)
+  --gwas-db           Path to GWAS DuckDB holding the model table (default: gwas_db_path)
+  --trait-model-map   TSV mapping traits to model IDs (default: reference.trait_model_map)
+  --format            Report format: text or json (default: text)
+`)
+}
+
+// WorkflowInputs returns the run flags exposed as inputs of workflow task descriptors, in
+// the order they are passed. --format and --output are fixed by the descriptor.
+func WorkflowInputs() []workflow.Input {
+	return []workflow.Input{
+		{Flag: "genotype-file", Kind: workflow.File, Required: true, Doc: "Genotype file"},
+		{Flag: "vcf-sample", Kind: workflow.String, Doc: "Sample to read from a multi-sample VCF"},
+		{Flag: "snps", Kind: workflow.String, Doc: "Comma-separated SNP IDs (or snps_file)"},
+		{Flag: "snps-file", Kind: workflow.File, Doc: "SNPs file (or snps)"},
+		{Flag: "panel", Kind: workflow.String, Doc: "Trait panel name (or snps)"},
+		{Flag: "gwas-db", Kind: workflow.File, Doc: "GWAS DuckDB (required unless gwas_db_path is configured)"},
+		{Flag: "gwas-table", Kind: workflow.String, Doc: "GWAS table name"},
+		{Flag: "config", Kind: workflow.File, Doc: "Config file replacing ~/.phite/config.json"},
+		{Flag: "ancestry", Kind: workflow.String, Doc: "Population code for reference frequencies"},
+		{Flag: "gender", Kind: workflow.String, Doc: "MALE or FEMALE"},
+		{Flag: "model-version", Kind: workflow.String, Doc: "Pinned PRS model release"},
+		{Flag: "trait-model-map", Kind: workflow.File, Doc: "TSV mapping traits to model IDs"},
+		{Flag: "sort-by", Kind: workflow.String, Doc: "percentile, abs_z, trait, category, or panel"},
+		{Flag: "group-by", Kind: workflow.String, Doc: "topic to group trait summaries"},
+		{Flag: "curated-notes", Kind: workflow.File, Doc: "Converter JSON notes to merge into trait summaries"},
+		{Flag: "checksum-manifest", Kind: workflow.File, Doc: "sha256 manifest to verify inputs against"},
+		{Flag: "all-ancestries", Kind: workflow.Bool, Doc: "Also compute reference stats for every ancestry"},
+		{Flag: "pgx", Kind: workflow.Bool, Doc: "Report pharmacogene phenotypes"},
+		{Flag: "suppress-actionable", Kind: workflow.Bool, Doc: "Withhold clinically actionable traits"},
+		{Flag: "require-checksums", Kind: workflow.Bool, Doc: "Fail unless every input passes verification"},
+		{Flag: "skip-if-unchanged", Kind: workflow.Bool, Doc: "Keep results.json when inputs and config are unchanged"},
+	}
+}
+
+// DescriptorOptions holds the flags for `risk-calculator descriptor`.
+type DescriptorOptions struct {
+	Engine    string // wdl, nextflow, or cwl
+	Container string // optional container image the task runs in
+}
+
+// ParseDescriptorOptions parses the flags that follow `descriptor`.
+func ParseDescriptorOptions(args []string) (DescriptorOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator descriptor", pflag.ContinueOnError)
+
+	var opts DescriptorOptions
+	flags.StringVar(&opts.Engine, "engine", "", "Workflow engine: "+strings.Join(workflow.Engines(), ", ")+" (required)")
+	flags.StringVar(&opts.Container, "container", "", "Container image the task runs in (optional)")
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Engine == "" {
+		return opts, fmt.Errorf("--engine is required: use %s", strings.Join(workflow.Engines(), ", "))
+	}
+	opts.Engine = strings.ToLower(opts.Engine)
+	if !slices.Contains(workflow.Engines(), opts.Engine) {
+		return opts, fmt.Errorf("unsupported --engine %q: use %s", opts.Engine, strings.Join(workflow.Engines(), ", "))
+	}
+	return opts, nil
+}
+
+// PrintDescriptorHelp prints the usage/help text for the descriptor subcommand.
+func PrintDescriptorHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator descriptor --engine wdl|nextflow|cwl [--container IMAGE]
+
+Prints a task descriptor that runs the calculator as a workflow step with
+--machine-readable-io. The task exports results.json and manifest.json (the I/O manifest).
+
+Options:
+  --engine      Workflow engine: wdl, nextflow, or cwl (required)
+  --container   Container image the task runs in (optional)
+`)
+}
+
+// WorkerOptions holds the flags for `risk-calculator worker`.
+type WorkerOptions struct {
+	Subscription string // Pub/Sub subscription jobs are pulled from
+	ResultsTopic string // Pub/Sub topic results are published to
+	Concurrency  int    // jobs scored at once
+	MaxAttempts  int    // deliveries of a failing job before its error is published
+	ResultsDir   string // directory job output paths are resolved in; empty forbids job output files
+	InputsDir    string // directory job input paths are resolved in; empty limits jobs to s3:// inputs
+}
+
+// ParseWorkerOptions parses the flags that follow `worker`. Unset flags fall back to the
+// worker.* config keys.
+func ParseWorkerOptions(args []string) (WorkerOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator worker", pflag.ContinueOnError)
+
+	var opts WorkerOptions
+	flags.StringVar(&opts.Subscription, "subscription", config.GetString(queue.SubscriptionKey), "Pub/Sub subscription to pull jobs from, as projects/P/subscriptions/S")
+	flags.StringVar(&opts.ResultsTopic, "results-topic", config.GetString(queue.ResultsTopicKey), "Pub/Sub topic to publish results to, as projects/P/topics/T")
+	flags.IntVar(&opts.Concurrency, "concurrency", config.GetInt(queue.ConcurrencyKey), "Jobs scored at once")
+	flags.IntVar(&opts.MaxAttempts, "max-attempts", config.GetInt(queue.MaxAttemptsKey), "Deliveries of a failing job before its error is published")
+	flags.StringVar(&opts.ResultsDir, "results-dir", config.GetString(queue.ResultsDirKey), "Directory job --output and --output-dir paths are resolved in")
+	flags.StringVar(&opts.InputsDir, "inputs-dir", config.GetString(queue.InputsDirKey), "Directory job --genotype-file and --snps-file paths are resolved in")
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Subscription == "" || opts.ResultsTopic == "" {
+		return opts, errors.New("--subscription and --results-topic are required")
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	return opts, nil
+}
+
+// PrintWorkerHelp prints the usage/help text for the worker subcommand.
+func PrintWorkerHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator worker [--subscription SUB] [--results-topic TOPIC] [--concurrency N] [--max-attempts N] [--results-dir DIR] [--inputs-dir DIR]
+
+Scores jobs pulled from a Pub/Sub subscription until interrupted, publishing each job's
+results or error to a topic. A job is a JSON message {"id": "...", "args": [run flags]};
+each runs in its own calculator process. Jobs may only set input, selection, and output
+flags. Their input files are s3:// objects or paths relative to --inputs-dir, and their
+output paths are relative to --results-dir.
+
+Options:
+  --subscription    Subscription to pull jobs from, as projects/P/subscriptions/S (default worker.subscription)
+  --results-topic   Topic to publish results to, as projects/P/topics/T (default worker.results_topic)
+  --concurrency     Jobs scored at once (default worker.concurrency, or 1)
+  --max-attempts    Deliveries of a failing job before its error is published (default worker.max_attempts, or 3)
+  --results-dir     Directory job output paths are resolved in (default worker.results_dir; unset, jobs cannot write files)
+  --inputs-dir      Directory job input paths are resolved in (default worker.inputs_dir; unset, jobs may only read s3:// objects)
+`)
+}
+
+// ResearchExportOptions holds the flags for `risk-calculator research-export`.
+type ResearchExportOptions struct {
+	Results    []string // JSON result files, one per sample
+	Phenotypes string   // optional phenotype CSV
+	IDColumn   string   // phenotype column holding the sample ID
+	Columns    []string // phenotype columns exported
+	Output     string   // table path; empty writes to stdout
+}
+
+// ParseResearchExportOptions parses the flags and result file arguments that follow
+// `research-export`. --opt-in is required, and phenotype columns are only exported when
+// listed by --columns.
+func ParseResearchExportOptions(args []string) (ResearchExportOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator research-export", pflag.ContinueOnError)
+
+	var opts ResearchExportOptions
+	var optIn bool
+	flags.BoolVar(&optIn, "opt-in", false, "Confirm the export is for research use the samples consented to (required)")
+	flags.StringVar(&opts.Phenotypes, "phenotypes", "", "Phenotype CSV to join by sample ID")
+	flags.StringVar(&opts.IDColumn, "id-column", "sample_id", "Phenotype column holding the sample ID")
+	flags.StringSliceVar(&opts.Columns, "columns", nil, "Comma-separated phenotype columns to export")
+	flags.StringVar(&opts.Output, "output", "", "Table path (default: stdout)")
+
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if !optIn {
+		return opts, errors.New("--opt-in is required: research exports link scores with phenotypes")
+	}
+	if (opts.Phenotypes == "") != (len(opts.Columns) == 0) {
+		return opts, errors.New("--phenotypes and --columns must be given together")
+	}
+	if flags.NArg() == 0 {
+		return opts, errors.New("at least one JSON result file is required")
+	}
+	opts.Results = flags.Args()
+	return opts, nil
+}
+
+// PrintResearchExportHelp prints the usage/help text for the research-export subcommand.
+func PrintResearchExportHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator research-export --opt-in [OPTIONS] RUN.json...
+
+Joins the JSON results of several samples, and optionally their phenotypes, into one CSV table
+with a row per sample and trait, for downstream association analysis. Each result file's name,
+without its extension, is its sample ID. Only phenotype columns listed by --columns are
+exported. Requires research.enabled in the configuration as well as --opt-in.
+
+Options:
+  --opt-in       Confirm the export is for research use the samples consented to (required)
+  --phenotypes   Phenotype CSV to join by sample ID
+  --id-column    Phenotype column holding the sample ID (default: sample_id)
+  --columns      Comma-separated phenotype columns to export (required with --phenotypes)
+  --output       Table path (default: stdout)
+`)
+}
+
+// ServeOptions holds the flags for `risk-calculator serve`.
+type ServeOptions struct {
+	Addr           string        // address to listen on
+	Concurrency    int           // requests scored at once
+	Timeout        time.Duration // longest a scoring request may take; zero for none
+	ReferenceTable string        // reference stats table name
+}
+
+// ParseServeOptions parses the flags that follow `serve`. Unset flags fall back to the
+// server.* config keys.
+func ParseServeOptions(args []string) (ServeOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator serve", pflag.ContinueOnError)
+
+	timeout, err := server.TimeoutFromConfig()
+	if err != nil {
+		return ServeOptions{}, err
+	}
+	var opts ServeOptions
+	var gwasDB string
+	flags.StringVar(&opts.Addr, "addr", config.GetString(server.AddrKey), "Address to listen on")
+	flags.IntVar(&opts.Concurrency, "concurrency", server.ConcurrencyFromConfig(), "Requests scored at once")
+	flags.DurationVar(&opts.Timeout, "timeout", timeout, "Longest a scoring request may take (0 for none)")
+	flags.StringVar(&opts.ReferenceTable, "reference-table", "reference_panel", "Reference stats table name")
+	flags.StringVar(&gwasDB, "gwas-db", "", "Path to GWAS DuckDB")
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if flags.NArg() > 0 {
+		return opts, fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+	if gwasDB != "" {
+		config.Set("gwas_db_path", gwasDB)
+	}
+	if config.GetString("gwas_db_path") == "" {
+		return opts, errors.New("--gwas-db is required")
+	}
+	if opts.Addr == "" {
+		opts.Addr = server.DefaultAddr
+	}
+	if opts.Concurrency <= 0 {
+		return opts, errors.New("--concurrency must be positive")
+	}
+	if opts.Timeout < 0 {
+		return opts, errors.New("--timeout must not be negative")
+	}
+	return opts, nil
+}
+
+// PrintServeHelp prints the usage/help text for the serve subcommand.
+func PrintServeHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator serve [OPTIONS]
+
+Serves the calculator over HTTP until interrupted:
+
+  POST /v1/prs     multipart form with a "genotype" file and either "snps" (comma-separated
+                   IDs) or a "snps_file" upload; returns the JSON results
+  GET  /v1/traits  traits of the model table, with their model and variant count
+  GET  /healthz    liveness
+
+Every request is scored with the server's configuration; only the genotype and SNPs vary.
+
+Options:
+  --addr              Address to listen on (default server.addr, or :8080)
+  --concurrency       Requests scored at once; the rest wait (default server.concurrency, or 2)
+  --timeout           Longest a scoring request may take, waiting included (default server.timeout, or 10m)
+  --reference-table   Reference stats table name (default: reference_panel)
+  --gwas-db           Path to GWAS DuckDB (required unless gwas_db_path is configured)
+`)
+}
+
+// CohortOptions holds the flags for `risk-calculator cohort`.
+type CohortOptions struct {
+	Manifest       string   // CSV of sample_id, genotype_file, and optional ancestry
+	OutputDir      string   // directory receiving one JSON result file per sample
+	SNPs           []string // SNPs scored for every sample
+	ReferenceTable string   // reference stats table name
+}
+
+// ParseCohortOptions parses the flags that follow `cohort`. --snps, --snps-file, --panel,
+// and --gwas-db resolve as for a single run.
+func ParseCohortOptions(args []string) (CohortOptions, error) {
+	flags := pflag.NewFlagSet("risk-calculator cohort", pflag.ContinueOnError)
+
+	var opts CohortOptions
+	var snps, snpsFile, panelName, gwasDB string
+	flags.StringVar(&opts.Manifest, "manifest", "", "Cohort manifest CSV: sample_id, genotype_file, and optional ancestry (required)")
+	flags.StringVar(&opts.OutputDir, "output-dir", "", "Directory for one JSON result file per sample (required)")
+	flags.StringVar(&snps, "snps", "", "Comma-separated list of SNP IDs")
+	flags.StringVar(&snpsFile, "snps-file", "", "Path to SNPs file")
+	flags.StringVar(&panelName, "panel", "", "Trait panel to score instead of --snps")
+	flags.StringVar(&gwasDB, "gwas-db", "", "Path to GWAS DuckDB")
+	flags.StringVar(&opts.ReferenceTable, "reference-table", "reference_panel", "Reference stats table name")
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if flags.NArg() > 0 {
+		return opts, fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+	if opts.Manifest == "" || opts.OutputDir == "" {
+		return opts, errors.New("--manifest and --output-dir are required")
+	}
+	if err := localize([]remoteFlag{
+		{"--manifest", &opts.Manifest},
+		{"--snps-file", &snpsFile},
+		{"--panel", &panelName},
+		{"--gwas-db", &gwasDB},
+	}); err != nil {
+		return opts, err
+	}
+	if gwasDB != "" {
+		config.Set("gwas_db_path", gwasDB)
+	}
+	if config.GetString("gwas_db_path") == "" {
+		return opts, errors.New("--gwas-db is required")
+	}
+
+	if panelName == "" && snps == "" && snpsFile == "" {
+		panelName = config.GetString(panel.NameKey)
+	}
+	var direct []string
+	if panelName != "" {
+		if snps != "" || snpsFile != "" {
+			return opts, errors.New("--panel supplies the SNPs: drop --snps and --snps-file")
+		}
+		p, err := panel.Get(panelName)
+		if err != nil {
+			return opts, fmt.Errorf("--panel: %w", err)
+		}
+		config.Set(panel.NameKey, panelName)
+		direct = p.SNPs
+	} else if snps != "" {
+		direct = strings.Split(snps, ",")
+	}
+	resolved, err := snpsutil.ResolveSNPs(direct, snpsFile)
+	if errors.Is(err, snpsutil.ErrNoSNPsProvided) {
+		return opts, errors.New("one of --snps, --snps-file, or --panel is required")
+	}
+	if err != nil {
+		return opts, err
+	}
+	opts.SNPs = resolved
+	return opts, nil
+}
+
+// PrintCohortHelp prints the usage/help text for the cohort subcommand.
+func PrintCohortHelp() {
+	fmt.Fprintf(os.Stderr, `Usage: risk-calculator cohort --manifest FILE --output-dir DIR (--snps rs1,rs2 | --snps-file FILE | --panel NAME) [OPTIONS]
+
+Scores every sample of a cohort manifest, writing DIR/<sample_id>.json for each. The manifest
+is a CSV with sample_id and genotype_file columns; relative genotype paths are resolved
+against its directory. An optional ancestry column (e.g. AFR, EUR_FEMALE) normalizes each
+sample against its own reference stats; samples without one use ancestry.population.
+Samples are scored by ancestry group, and the reference stats of every group are computed
+in one shared frequency query.
+Exit codes: 0 every sample scored, 1 usage error or some sample failed.
+
+Options:
+  --manifest          Cohort manifest CSV (required)
+  --output-dir        Directory for one JSON result file per sample (required)
+  --snps              Comma-separated list of SNP IDs
+  --snps-file         Path to SNPs file
+  --panel             Trait panel to score instead of --snps
+  --gwas-db           Path to GWAS DuckDB (required unless gwas_db_path is configured)
+  --reference-table   Reference stats table name (default: reference_panel)
 `)
 }
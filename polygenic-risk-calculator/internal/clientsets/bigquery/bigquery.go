@@ -16,15 +16,6 @@ import (
 
 // Client encapsulates config, connection, and query logic for reference stats in BigQuery.
 
-func init() {
-	// BigQuery client uses infrastructure constants for shared GCP resources
-	config.RegisterRequiredKey(config.GCPDataProjectKey)        // Project where the data resides
-	config.RegisterRequiredKey(config.GCPBillingProjectKey)     // Project used for API calls and billing
-	config.RegisterRequiredKey(config.BigQueryGnomadDatasetKey) // Dataset reference
-	// Note: Table references are now handled by infrastructure constants (config.Table*)
-	// bq_credentials remains optional (no constant needed)
-}
-
 type BQClient struct {
 	ProjectID string
 	Dataset   string
@@ -41,7 +32,9 @@ func NewClient(ctx context.Context) (*BQClient, error) {
 	dataset := config.GetString(config.BigQueryGnomadDatasetKey)
 	table := config.GetString(config.TableAlleleFreqTableKey) // Default to allele freq table
 	creds := config.GetString("bq_credentials")               // Still using string key as this is optional
-	// Validation for project, dataset, and table is now handled by infrastructure constants
+	if err := config.CheckKeys(config.GCPDataProjectKey, config.BigQueryGnomadDatasetKey, config.GCPBillingProjectKey); err != nil {
+		return nil, err
+	}
 
 	var client *bigquery.Client
 	var err error
@@ -0,0 +1,144 @@
+// Package cohort reads the sample manifest of a cohort run and groups its samples by
+// reference ancestry, so each group's reference stats are computed once for all its samples.
+package cohort
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
+)
+
+// Manifest columns. AncestryColumn is optional.
+const (
+	IDColumn       = "sample_id"
+	GenotypeColumn = "genotype_file"
+	AncestryColumn = "ancestry"
+)
+
+// Sample is one row of a cohort manifest.
+type Sample struct {
+	ID           string
+	GenotypeFile string
+	Ancestry     *ancestry.Ancestry // nil when the row names none; the configured ancestry applies
+}
+
+// ReadManifest reads a cohort manifest: a CSV with a header row and sample_id and
+// genotype_file columns, plus an optional ancestry column holding codes such as EUR or
+// EUR_FEMALE. Relative genotype paths are resolved against the manifest's directory.
+// Sample IDs name the result files, so they must be unique and free of path separators.
+func ReadManifest(path string) ([]Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest header of %s: %w", path, err)
+	}
+	index := make(map[string]int, len(header))
+	for i, col := range header {
+		index[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	for _, col := range []string{IDColumn, GenotypeColumn} {
+		if _, ok := index[col]; !ok {
+			return nil, fmt.Errorf("manifest %s has no %q column", path, col)
+		}
+	}
+	field := func(record []string, col string) string {
+		if i, ok := index[col]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	dir := filepath.Dir(path)
+	seen := make(map[string]bool)
+	var samples []Sample
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest %s: %w", path, err)
+		}
+		s := Sample{ID: field(record, IDColumn), GenotypeFile: field(record, GenotypeColumn)}
+		switch {
+		case s.ID == "" || s.GenotypeFile == "":
+			return nil, fmt.Errorf("manifest %s line %d: %s and %s are required", path, line, IDColumn, GenotypeColumn)
+		case s.ID == "." || s.ID == ".." || strings.ContainsAny(s.ID, `/\`):
+			return nil, fmt.Errorf("manifest %s line %d: sample ID %q cannot name a result file", path, line, s.ID)
+		case seen[s.ID]:
+			return nil, fmt.Errorf("manifest %s line %d: duplicate sample %q", path, line, s.ID)
+		}
+		seen[s.ID] = true
+		if !filepath.IsAbs(s.GenotypeFile) {
+			s.GenotypeFile = filepath.Join(dir, s.GenotypeFile)
+		}
+		if code := field(record, AncestryColumn); code != "" {
+			if s.Ancestry, err = ancestry.FromCode(code); err != nil {
+				return nil, fmt.Errorf("manifest %s line %d: %w", path, line, err)
+			}
+		}
+		samples = append(samples, s)
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("manifest %s lists no samples", path)
+	}
+	return samples, nil
+}
+
+// Group is the samples normalized against one reference ancestry.
+type Group struct {
+	Ancestry *ancestry.Ancestry
+	Samples  []Sample
+}
+
+// GroupByAncestry groups samples by ancestry, in code order, keeping manifest order within
+// a group. Samples without an ancestry join fallback's group; fallback may be nil only when
+// every sample names its ancestry.
+func GroupByAncestry(samples []Sample, fallback *ancestry.Ancestry) ([]Group, error) {
+	byCode := make(map[string]*Group)
+	for _, s := range samples {
+		a := s.Ancestry
+		if a == nil {
+			if fallback == nil {
+				return nil, fmt.Errorf("sample %q has no ancestry and none is configured", s.ID)
+			}
+			a = fallback
+		}
+		g, ok := byCode[a.Code()]
+		if !ok {
+			g = &Group{Ancestry: a}
+			byCode[a.Code()] = g
+		}
+		g.Samples = append(g.Samples, s)
+	}
+	groups := make([]Group, 0, len(byCode))
+	for _, g := range byCode {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Ancestry.Code() < groups[j].Ancestry.Code() })
+	return groups, nil
+}
+
+// HasAncestries reports whether any sample names its own ancestry.
+func HasAncestries(samples []Sample) bool {
+	for _, s := range samples {
+		if s.Ancestry != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package cohort
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
+)
+
+func writeManifest(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "manifest.csv")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestReadManifestAndGroup(t *testing.T) {
+	path := writeManifest(t, "sample_id,genotype_file,ancestry\ns1,s1.txt,afr\ns2,/data/s2.vcf.gz,\ns3,s3.txt,EUR_FEMALE\ns4,s4.txt,AFR\n")
+	samples, err := ReadManifest(path)
+	require.NoError(t, err)
+	require.Len(t, samples, 4)
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "s1.txt"), samples[0].GenotypeFile)
+	assert.Equal(t, "/data/s2.vcf.gz", samples[1].GenotypeFile)
+	assert.Equal(t, "AFR", samples[0].Ancestry.Code())
+	assert.Nil(t, samples[1].Ancestry)
+	assert.True(t, HasAncestries(samples))
+
+	_, err = GroupByAncestry(samples, nil)
+	assert.ErrorContains(t, err, `sample "s2" has no ancestry`)
+
+	eur, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+	groups, err := GroupByAncestry(samples, eur)
+	require.NoError(t, err)
+	var got [][]string
+	for _, g := range groups {
+		ids := []string{g.Ancestry.Code()}
+		for _, s := range g.Samples {
+			ids = append(ids, s.ID)
+		}
+		got = append(got, ids)
+	}
+	assert.Equal(t, [][]string{{"AFR", "s1", "s4"}, {"EUR", "s2"}, {"EUR_FEMALE", "s3"}}, got)
+}
+
+func TestReadManifest_Errors(t *testing.T) {
+	cases := map[string]struct{ content, want string }{
+		"missing column": {"sample_id,ancestry\ns1,EUR\n", `no "genotype_file" column`},
+		"duplicate":      {"sample_id,genotype_file\ns1,a.txt\ns1,b.txt\n", `duplicate sample "s1"`},
+		"path in id":     {"sample_id,genotype_file\n../s1,a.txt\n", "cannot name a result file"},
+		"bad ancestry":   {"sample_id,genotype_file,ancestry\ns1,a.txt,MARS\n", "line 2"},
+		"empty":          {"sample_id,genotype_file\n", "lists no samples"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadManifest(writeManifest(t, tc.content))
+			assert.ErrorContains(t, err, tc.want)
+		})
+	}
+
+	samples, err := ReadManifest(writeManifest(t, "sample_id,genotype_file\ns1,a.txt\n"))
+	require.NoError(t, err)
+	assert.False(t, HasAncestries(samples))
+}
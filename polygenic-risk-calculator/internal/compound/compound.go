@@ -0,0 +1,281 @@
+// Package compound derives well-known compound genotypes — APOE ε alleles and CYP2C19,
+// CYP2C9, and SLCO1B1 star alleles — from their constituent SNPs and reports them as categorical results alongside
+// the quantitative scores.
+//
+// Each allele of a gene is defined by the base it carries at every marker SNP (forward
+// strand, GRCh38). A diplotype is a pair of alleles whose bases together explain the calls
+// at all markers; phased calls additionally fix which bases share a chromosome copy.
+package compound
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// Domain-specific configuration keys for compound genotypes
+const (
+	GenesKey = "compound.genes" // Genes whose compound genotypes are reported, e.g. ["APOE", "CYP2C19"]
+)
+
+// Result statuses for genes without a single diplotype.
+const (
+	StatusIncomplete = "incomplete" // a marker SNP has no valid call
+	StatusAmbiguous  = "ambiguous"  // several diplotypes explain unphased calls equally well
+	StatusNoMatch    = "no_match"   // no pair of known alleles explains the calls
+)
+
+// Allele is a named allele of a gene and its base at each marker, in marker order.
+// Diplotypes name their alleles in the gene's allele order.
+type Allele struct {
+	Name  string
+	Bases string
+	Rare  bool // only chosen when no diplotype of common alleles fits
+}
+
+// Gene defines the marker SNPs and known alleles of a gene.
+type Gene struct {
+	Name      string
+	Markers   []string // rsids
+	Alleles   []Allele
+	Phenotype func(a, b Allele) string // optional interpretation of a diplotype
+}
+
+// Result is the compound genotype reported for one gene.
+type Result struct {
+	Gene       string            `json:"gene"`
+	Diplotype  string            `json:"diplotype,omitempty"`  // e.g. "ε3/ε4" or "*1/*17"
+	Phenotype  string            `json:"phenotype,omitempty"`  // e.g. "rapid metabolizer"
+	Status     string            `json:"status,omitempty"`     // set when no single diplotype was determined
+	Candidates []string          `json:"candidates,omitempty"` // diplotypes consistent with ambiguous calls
+	Genotypes  map[string]string `json:"genotypes"`            // marker rsid -> call, "C|T" when phased
+}
+
+// APOE defines the ε alleles by rs429358 and rs7412.
+var APOE = Gene{
+	Name:    "APOE",
+	Markers: []string{"rs429358", "rs7412"},
+	Alleles: []Allele{
+		{Name: "ε1", Bases: "CT", Rare: true},
+		{Name: "ε2", Bases: "TT"},
+		{Name: "ε3", Bases: "TC"},
+		{Name: "ε4", Bases: "CC"},
+	},
+	Phenotype: func(a, b Allele) string {
+		switch strings.Count(a.Name+b.Name, "ε4") {
+		case 0:
+			return "ε4 non-carrier"
+		case 1:
+			return "ε4 heterozygote"
+		default:
+			return "ε4 homozygote"
+		}
+	},
+}
+
+// CYP2C19 defines the *2, *3, and *17 star alleles by rs4244285, rs4986893, and rs12248560.
+// *1 is the reference allele.
+var CYP2C19 = Gene{
+	Name:    "CYP2C19",
+	Markers: []string{"rs4244285", "rs4986893", "rs12248560"},
+	Alleles: []Allele{
+		{Name: "*1", Bases: "GGC"},
+		{Name: "*2", Bases: "AGC"},
+		{Name: "*3", Bases: "GAC"},
+		{Name: "*17", Bases: "GGT"},
+	},
+	Phenotype: func(a, b Allele) string {
+		noFunction, increased := 0, 0
+		for _, allele := range []Allele{a, b} {
+			switch allele.Name {
+			case "*2", "*3":
+				noFunction++
+			case "*17":
+				increased++
+			}
+		}
+		switch {
+		case noFunction == 2:
+			return "poor metabolizer"
+		case noFunction == 1:
+			return "intermediate metabolizer"
+		case increased == 2:
+			return "ultrarapid metabolizer"
+		case increased == 1:
+			return "rapid metabolizer"
+		default:
+			return "normal metabolizer"
+		}
+	},
+}
+
+// CYP2C9 defines the *2 and *3 star alleles by rs1799853 and rs1057910.
+var CYP2C9 = Gene{
+	Name:    "CYP2C9",
+	Markers: []string{"rs1799853", "rs1057910"},
+	Alleles: []Allele{
+		{Name: "*1", Bases: "CA"},
+		{Name: "*2", Bases: "TA"},
+		{Name: "*3", Bases: "CC"},
+	},
+}
+
+// SLCO1B1 defines the reduced-function *5 allele by rs4149056 (c.521T>C).
+var SLCO1B1 = Gene{
+	Name:    "SLCO1B1",
+	Markers: []string{"rs4149056"},
+	Alleles: []Allele{
+		{Name: "*1", Bases: "T"},
+		{Name: "*5", Bases: "C"},
+	},
+}
+
+// genes lists the supported genes by upper-cased name.
+var genes = map[string]Gene{"APOE": APOE, "CYP2C19": CYP2C19, "CYP2C9": CYP2C9, "SLCO1B1": SLCO1B1}
+
+// Lookup returns the supported gene with the given name, ignoring case.
+func Lookup(name string) (Gene, bool) {
+	g, ok := genes[strings.ToUpper(strings.TrimSpace(name))]
+	return g, ok
+}
+
+// FromConfig returns the configured genes, in configuration order.
+func FromConfig() ([]Gene, error) {
+	var selected []Gene
+	for _, name := range config.GetStringSlice(GenesKey) {
+		gene, ok := Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("%s: unsupported gene %q (supported: APOE, CYP2C19, CYP2C9, SLCO1B1)", GenesKey, name)
+		}
+		selected = append(selected, gene)
+	}
+	return selected, nil
+}
+
+// RSIDs returns the distinct marker SNPs of genes, sorted.
+func RSIDs(genes []Gene) []string {
+	seen := make(map[string]bool)
+	var rsids []string
+	for _, g := range genes {
+		for _, rsid := range g.Markers {
+			if !seen[rsid] {
+				seen[rsid] = true
+				rsids = append(rsids, rsid)
+			}
+		}
+	}
+	sort.Strings(rsids)
+	return rsids
+}
+
+// Report determines the compound genotype of each gene from the validated calls.
+func Report(genes []Gene, validated []model.ValidatedSNP) []Result {
+	if len(genes) == 0 {
+		return nil
+	}
+	calls := make(map[string]model.ValidatedSNP, len(validated))
+	for _, v := range validated {
+		calls[v.RSID] = v
+	}
+	results := make([]Result, len(genes))
+	for i, g := range genes {
+		results[i] = g.call(calls)
+	}
+	return results
+}
+
+func (g Gene) call(calls map[string]model.ValidatedSNP) Result {
+	r := Result{Gene: g.Name, Genotypes: make(map[string]string, len(g.Markers))}
+	for _, rsid := range g.Markers {
+		c, ok := calls[rsid]
+		switch {
+		case !ok:
+			r.Status = StatusIncomplete
+		case c.Phased:
+			r.Genotypes[rsid] = c.Genotype[:1] + "|" + c.Genotype[1:]
+		default:
+			r.Genotypes[rsid] = c.Genotype
+		}
+	}
+	if r.Status != "" {
+		return r
+	}
+
+	// Collect the allele pairs that explain the calls, preferring common alleles
+	var common, rare [][2]Allele
+	for i, a := range g.Alleles {
+		for _, b := range g.Alleles[i:] {
+			if !g.explains(a, b, calls) {
+				continue
+			}
+			if a.Rare || b.Rare {
+				rare = append(rare, [2]Allele{a, b})
+			} else {
+				common = append(common, [2]Allele{a, b})
+			}
+		}
+	}
+	candidates := common
+	if len(candidates) == 0 {
+		candidates = rare
+	}
+
+	switch len(candidates) {
+	case 0:
+		r.Status = StatusNoMatch
+	case 1:
+		a, b := candidates[0][0], candidates[0][1]
+		r.Diplotype = a.Name + "/" + b.Name
+		if g.Phenotype != nil {
+			r.Phenotype = g.Phenotype(a, b)
+		}
+	default:
+		r.Status = StatusAmbiguous
+		for _, c := range candidates {
+			r.Candidates = append(r.Candidates, c[0].Name+"/"+c[1].Name)
+		}
+	}
+	return r
+}
+
+// explains reports whether alleles a and b carry exactly the called bases at every marker.
+// When every heterozygous marker is phased, a and b must also agree with the phase.
+func (g Gene) explains(a, b Allele, calls map[string]model.ValidatedSNP) bool {
+	unordered := func() bool {
+		for i, rsid := range g.Markers {
+			geno := calls[rsid].Genotype
+			pair := string([]byte{a.Bases[i], b.Bases[i]})
+			if pair != geno && pair != string([]byte{geno[1], geno[0]}) {
+				return false
+			}
+		}
+		return true
+	}
+	if !unordered() {
+		return false
+	}
+
+	phased := true
+	for _, rsid := range g.Markers {
+		c := calls[rsid]
+		if c.Genotype[0] != c.Genotype[1] && !c.Phased {
+			phased = false
+		}
+	}
+	if !phased {
+		return true
+	}
+	ordered := func(first, second Allele) bool {
+		for i, rsid := range g.Markers {
+			geno := calls[rsid].Genotype
+			if first.Bases[i] != geno[0] || second.Bases[i] != geno[1] {
+				return false
+			}
+		}
+		return true
+	}
+	return ordered(a, b) || ordered(b, a)
+}
@@ -0,0 +1,92 @@
+package compound
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+func calls(pairs ...string) []model.ValidatedSNP {
+	var out []model.ValidatedSNP
+	for i := 0; i < len(pairs); i += 2 {
+		geno, phased := pairs[i+1], false
+		if len(geno) == 3 {
+			geno, phased = geno[:1]+geno[2:], true
+		}
+		out = append(out, model.ValidatedSNP{RSID: pairs[i], Genotype: geno, Phased: phased})
+	}
+	return out
+}
+
+func TestReport_APOE(t *testing.T) {
+	tests := []struct {
+		rs429358, rs7412 string
+		diplotype        string
+		phenotype        string
+		status           string
+	}{
+		{"TT", "CC", "ε3/ε3", "ε4 non-carrier", ""},
+		{"CT", "CC", "ε3/ε4", "ε4 heterozygote", ""},
+		{"CC", "CC", "ε4/ε4", "ε4 homozygote", ""},
+		{"TT", "CT", "ε2/ε3", "ε4 non-carrier", ""},
+		{"CT", "CT", "ε2/ε4", "ε4 heterozygote", ""},  // ε1/ε3 is rare
+		{"C|T", "T|C", "ε1/ε3", "ε4 non-carrier", ""}, // phase rules out ε2/ε4
+	}
+	for _, tt := range tests {
+		t.Run(tt.rs429358+"_"+tt.rs7412, func(t *testing.T) {
+			r := Report([]Gene{APOE}, calls("rs429358", tt.rs429358, "rs7412", tt.rs7412))
+			require.Len(t, r, 1)
+			assert.Equal(t, tt.status, r[0].Status)
+			assert.Equal(t, tt.diplotype, r[0].Diplotype)
+			assert.Equal(t, tt.phenotype, r[0].Phenotype)
+		})
+	}
+
+	r := Report([]Gene{APOE}, calls("rs429358", "CT"))
+	assert.Equal(t, StatusIncomplete, r[0].Status)
+	assert.Empty(t, r[0].Diplotype)
+	assert.Equal(t, map[string]string{"rs429358": "CT"}, r[0].Genotypes)
+}
+
+func TestReport_CYP2C19(t *testing.T) {
+	tests := []struct {
+		rs4244285, rs4986893, rs12248560 string
+		diplotype, phenotype             string
+		status                           string
+	}{
+		{"GG", "GG", "CC", "*1/*1", "normal metabolizer", ""},
+		{"AG", "GG", "CC", "*1/*2", "intermediate metabolizer", ""},
+		{"AA", "GG", "CC", "*2/*2", "poor metabolizer", ""},
+		{"GA", "GA", "CC", "*2/*3", "poor metabolizer", ""},
+		{"GG", "GG", "CT", "*1/*17", "rapid metabolizer", ""},
+		{"GG", "GG", "TT", "*17/*17", "ultrarapid metabolizer", ""},
+		{"GA", "GG", "CT", "*2/*17", "intermediate metabolizer", ""},
+		{"AA", "GG", "TT", "", "", StatusNoMatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.diplotype, func(t *testing.T) {
+			r := Report([]Gene{CYP2C19}, calls("rs4244285", tt.rs4244285, "rs4986893", tt.rs4986893, "rs12248560", tt.rs12248560))
+			assert.Equal(t, tt.status, r[0].Status)
+			assert.Equal(t, tt.diplotype, r[0].Diplotype)
+			assert.Equal(t, tt.phenotype, r[0].Phenotype)
+		})
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	config.Set(GenesKey, []string{"apoe", "CYP2C19"})
+	defer config.Set(GenesKey, []string{})
+
+	genes, err := FromConfig()
+	require.NoError(t, err)
+	require.Len(t, genes, 2)
+	assert.Equal(t, "APOE", genes[0].Name)
+	assert.Equal(t, []string{"rs12248560", "rs4244285", "rs429358", "rs4986893", "rs7412"}, RSIDs(genes))
+
+	config.Set(GenesKey, []string{"BRCA1"})
+	_, err = FromConfig()
+	assert.Error(t, err)
+}
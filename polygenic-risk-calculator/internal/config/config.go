@@ -1,18 +1,38 @@
-// Package config provides centralized, extensible configuration loading for PHITE using spf13/viper.
-// All config access must go through this package.
+// Package config provides centralized, extensible configuration loading for PHITE on top
+// of the shared github.com/JerkyTreats/PHITE/config framework. All config access must go
+// through this package.
+//
+// Keys are read from ~/.phite/config.json, the active profile, and PHITE_<KEY> environment
+// variables (e.g. PHITE_GCP_BILLING_PROJECT for gcp.billing_project, PHITE_GWAS_DB_PATH for
+// gwas_db_path, which is also read from the legacy GWAS_DB_PATH); Set overrides all of them
+// for the process, which is how command-line flags are applied.
+//
+// Embedders that want none of that process-wide file and environment state can supply
+// their own Provider, such as a Values set in code, to pipeline.Run and
+// reference.NewReferenceService.
 package config
 
 import (
-	"os" // Added for ToUpper
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
 	"sync"
 
-	"github.com/spf13/viper"
+	phiteconfig "github.com/JerkyTreats/PHITE/config"
 )
 
 // Shared infrastructure configuration keys - used across multiple domains
 const (
 	// Logging (core infrastructure)
-	LogLevelKey = "logging.level"
+	LogLevelKey      = "logging.level"
+	LogFormatKey     = "logging.format"      // text or json
+	LogFileKey       = "logging.file"        // log to this file instead of stderr
+	LogMaxSizeMBKey  = "logging.max_size_mb" // rotate the log file past this size
+	LogMaxBackupsKey = "logging.max_backups" // rotated log files to keep
+
+	LogRepeatLimitKey          = "logging.repeat_limit"           // occurrences of a repeated message logged before the rest are counted; 0 logs all
+	LogRepeatSummarySecondsKey = "logging.repeat_summary_seconds" // interval between summaries of suppressed repeats
 
 	// GCP Project Infrastructure - addresses duplication across domains
 	GCPDataProjectKey    = "gcp.data_project"    // Where data lives (e.g., bigquery-public-data)
@@ -27,16 +47,126 @@ const (
 	TableCacheTableKey      = "tables.cache_table"       // Reference stats cache table
 	TableModelTableKey      = "tables.model_table"       // PRS model table
 	TableAlleleFreqTableKey = "tables.allele_freq_table" // Allele frequency table
+	TableFreqCacheTableKey  = "tables.freq_cache_table"  // Allele frequency lookup cache table (optional)
 )
 
 // NOTE: Domain-specific configuration constants are defined in their respective packages:
-// - ancestry.PopulationKey, ancestry.GenderKey -> internal/ancestry/config.go
+// - ancestry.PopulationKey, ancestry.GenderKey, ancestry.CohortKey, ancestry.CohortAFColumnKey -> internal/ancestry/config.go
+// - fingerprint.PanelKey, fingerprint.MinConcordanceKey, fingerprint.MinSharedKey -> internal/fingerprint/fingerprint.go
 // - invariance.EnableValidationKey, invariance.StrictModeKey -> internal/invariance/invariance.go
 // - cache.BatchSizeKey -> internal/reference/cache/cache.go
+// - cache.BackendKey, cache.PathKey -> internal/reference/cache/local.go
+// - dosage.StrategyKey, dosage.TraitStrategyKey -> internal/dosage/dosage.go
+// - output.SortByKey, output.GroupByKey, output.TaxonomyKey, output.TraitTopicsKey -> internal/output/ordering.go
+// - pipeline.MinSNPCoverageKey -> internal/pipeline/coverage.go
+// - pipeline.TraitConcurrencyKey -> internal/pipeline/concurrency.go
+// - prs.ScoreScalesKey -> internal/prs/scaling.go
+// - reference.CohortPathKey -> internal/reference/service.go
+// - reference.TraitTimeoutKey, reference.TraitMaxVariantsKey -> internal/reference/budget.go
+// - reference.SkipTableProbeKey -> internal/reference/probe.go
+// - retention.CacheMaxAgeDaysKey, retention.ResultsMaxAgeDaysKey, retention.ResultsDirKey, retention.IntervalKey -> internal/retention/retention.go
 // This maintains domain ownership while eliminating infrastructure duplication.
 
+// Provider supplies configuration values. *phiteconfig.Config, which layers the config
+// file, profile, and environment, is the default; Values holds values set in code.
+type Provider interface {
+	String(key string) string
+	Int(key string) int
+	Float64(key string) float64
+	Bool(key string) bool
+	StringSlice(key string) []string
+	StringMap(key string) map[string]string
+	IsSet(key string) bool
+	Unmarshal(key string, out any) error
+	Set(key string, value any)
+}
+
+// lease is one provider installed by Use, with the goroutines holding it. A provider used
+// by a goroutine that alone holds the current one is nested on top of it.
+type lease struct {
+	provider Provider
+	holders  map[uint64]int // goroutine ID -> Use calls not yet restored
+	parent   *lease         // lease restored when this one is released
+}
+
 var (
-	config            *viper.Viper
+	installed    *lease // top of the stack of leases; nil means the default config
+	providerMu   sync.RWMutex
+	providerFree = sync.NewCond(&providerMu) // signalled when a lease is released
+)
+
+// Use makes p the source of every config read in the process until the returned restore
+// function is called. Concurrent runs may share a provider. A goroutine that is the only
+// holder of the current provider may nest a different one, e.g. a run creating a
+// reference service with its own provider, and gets the outer one back on restore; other
+// runs using a different provider wait until every run holding the current one has
+// restored it. A nil p is a no-op.
+func Use(p Provider) (restore func()) {
+	if p == nil {
+		return func() {}
+	}
+	g := goroutineID()
+	providerMu.Lock()
+	for installed != nil && installed.provider != p && !installed.heldOnlyBy(g) {
+		providerFree.Wait()
+	}
+	if installed == nil || installed.provider != p {
+		installed = &lease{provider: p, holders: map[uint64]int{}, parent: installed}
+	}
+	l := installed
+	l.holders[g]++
+	providerMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			providerMu.Lock()
+			defer providerMu.Unlock()
+			if l.holders[g]--; l.holders[g] == 0 {
+				delete(l.holders, g)
+			}
+			// Leases restored out of order are popped once those above them are released
+			for installed != nil && len(installed.holders) == 0 {
+				installed = installed.parent
+			}
+			providerFree.Broadcast()
+		})
+	}
+}
+
+// heldOnlyBy reports whether goroutine g is the only holder of l.
+func (l *lease) heldOnlyBy(g uint64) bool {
+	return len(l.holders) == 1 && l.holders[g] > 0
+}
+
+// goroutineID returns the ID of the calling goroutine, parsed from its stack header
+// ("goroutine 42 [running]:"). Go exposes no goroutine identity; Use only needs it to tell
+// a nested call from a concurrent one.
+func goroutineID() uint64 {
+	var buf [64]byte
+	header := buf[:runtime.Stack(buf[:], false)]
+	header = bytes.TrimPrefix(header, []byte("goroutine "))
+	id, _ := strconv.ParseUint(string(header[:bytes.IndexByte(header, ' ')]), 10, 64)
+	return id
+}
+
+// current returns the installed provider, or the default config; nil if neither is loaded.
+func current() Provider {
+	providerMu.RLock()
+	l := installed
+	providerMu.RUnlock()
+	if l != nil {
+		return l.provider
+	}
+	_ = initConfig()
+	if config == nil {
+		return nil
+	}
+	return config
+}
+
+var (
+	config            *phiteconfig.Config
 	configOnce        sync.Once
 	configPath        string
 	requiredKeys      []string
@@ -61,33 +191,26 @@ func SetConfigPath(path string) {
 	configPath = path
 }
 
-// loadConfig initializes viper and loads config from file and env.
-func loadConfig() (*viper.Viper, error) {
-	v := viper.New()
-	v.SetConfigType("json")
-	v.SetConfigName("config")
-	v.AddConfigPath(os.ExpandEnv("$HOME/.phite"))
-	if configPath != "" {
-		v.SetConfigFile(configPath)
-	}
-	v.AutomaticEnv()
-	v.SetDefault(LogLevelKey, "INFO")
-	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			// File not found: return viper instance with defaults
-			return v, nil
-		}
-		// For parse errors or other errors, log and return viper with defaults
-		return v, nil
+// loadConfig loads config from file, profile, and env. Top-level keys are still read from
+// their unprefixed variables (GWAS_DB_PATH), as before the PHITE_ prefix.
+func loadConfig() (*phiteconfig.Config, error) {
+	opts := phiteconfig.Options{Path: configPath, LegacyEnv: true}
+	c, err := phiteconfig.Load(opts)
+	if err != nil {
+		// For parse errors or other errors, fall back to defaults
+		c = phiteconfig.New(opts)
 	}
-	return v, nil
+	c.SetDefault(LogLevelKey, "INFO")
+	c.SetDefault(LogRepeatLimitKey, 5)
+	c.SetDefault(LogRepeatSummarySecondsKey, 30)
+	return c, nil
 }
 
 // initConfig ensures config is loaded once.
 func initConfig() error {
 	var err error
 	configOnce.Do(func() {
-		var c *viper.Viper
+		var c *phiteconfig.Config
 		c, err = loadConfig()
 		if err == nil {
 			config = c
@@ -108,41 +231,74 @@ func Reload() error {
 	return nil
 }
 
+// Shared returns the underlying shared config, loading it if needed.
+func Shared() *phiteconfig.Config {
+	_ = initConfig()
+	return config
+}
+
 // GetString returns a string config value.
 func GetString(key string) string {
-	_ = initConfig()
-	if config == nil {
+	p := current()
+	if p == nil {
 		// Return reasonable default for string
 		return ""
 	}
-	return config.GetString(key)
+	return p.String(key)
 }
 
 // GetInt returns an int config value.
 func GetInt(key string) int {
-	_ = initConfig()
-	if config == nil {
+	p := current()
+	if p == nil {
 		return 0
 	}
-	return config.GetInt(key)
+	return p.Int(key)
+}
+
+// GetFloat64 returns a float64 config value.
+func GetFloat64(key string) float64 {
+	p := current()
+	if p == nil {
+		return 0
+	}
+	return p.Float64(key)
 }
 
 // GetBool returns a bool config value.
 func GetBool(key string) bool {
-	_ = initConfig()
-	if config == nil {
+	p := current()
+	if p == nil {
 		return false
 	}
-	return config.GetBool(key)
+	return p.Bool(key)
 }
 
 // GetStringMapString returns a map[string]string config value.
 func GetStringMapString(key string) map[string]string {
-	_ = initConfig()
-	if config == nil {
+	p := current()
+	if p == nil {
 		return make(map[string]string) // Return empty map if config not loaded
 	}
-	return config.GetStringMapString(key)
+	return p.StringMap(key)
+}
+
+// GetStringSlice returns a []string config value.
+func GetStringSlice(key string) []string {
+	p := current()
+	if p == nil {
+		return nil
+	}
+	return p.StringSlice(key)
+}
+
+// UnmarshalKey decodes a nested config value (e.g. a map of structs) into out.
+func UnmarshalKey(key string, out interface{}) error {
+	p := current()
+	if p == nil {
+		return nil
+	}
+	return p.Unmarshal(key, out)
 }
 
 // RegisterRequiredKey adds a key to the list of required configuration items.
@@ -157,25 +313,43 @@ func RegisterRequiredKey(key string) {
 		}
 	}
 	requiredKeys = append(requiredKeys, key)
+	if config := Shared(); config != nil {
+		config.Require(key)
+	}
 	// Check if the key is present in the config
 	if !HasKey(key) {
 		MissingKeys = append(MissingKeys, key)
 	}
 }
 
+// CheckKeys returns an error naming those of keys that are not set. It checks keys whose
+// requirement depends on other configuration, such as the selected backend, when the
+// component needing them is created rather than at init.
+func CheckKeys(keys ...string) error {
+	var missing []string
+	for _, key := range keys {
+		if !HasKey(key) {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required configuration keys: %v", missing)
+	}
+	return nil
+}
+
 // HasKey returns true if the config has the key.
 func HasKey(key string) bool {
-	_ = initConfig()
-	if config == nil {
+	p := current()
+	if p == nil {
 		return false
 	}
-	return config.IsSet(key)
+	return p.IsSet(key)
 }
 
 // Set sets a configuration value for testing purposes only.
 func Set(key string, value interface{}) {
-	_ = initConfig()
-	if config != nil {
-		config.Set(key, value)
+	if p := current(); p != nil {
+		p.Set(key, value)
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -51,6 +52,13 @@ func TestRequiredKeys(t *testing.T) {
 	assert.False(t, HasKey("test_key")) // HasKey should return false since the key isn't in the config
 }
 
+func TestCheckKeys(t *testing.T) {
+	defer Use(NewValues(map[string]any{"gcp.billing_project": "billing"}))()
+	assert.NoError(t, CheckKeys("gcp.billing_project"))
+	err := CheckKeys("gcp.billing_project", "gcp.data_project", "bigquery.gnomad_dataset")
+	assert.EqualError(t, err, "missing required configuration keys: [gcp.data_project bigquery.gnomad_dataset]")
+}
+
 func TestReload(t *testing.T) {
 	// Reset config before test
 	ResetForTest()
@@ -110,3 +118,127 @@ func TestConfigNotFound(t *testing.T) {
 	assert.False(t, GetBool("nonexistent"))
 	assert.Empty(t, GetStringMapString("nonexistent"))
 }
+
+func TestValues(t *testing.T) {
+	v := NewValues(map[string]any{
+		"ancestry.population": "EUR",
+		"Reference":           map[string]any{"Model": "v1", "trait_max_variants": 500},
+		"consent.excluded":    "a, b",
+		"output.derived_metrics": map[string]any{
+			"combined": "ldl_z + hdl_z",
+		},
+		"output.actionable": map[string]any{
+			"ldl": map[string]any{"min_percentile": 95, "note": "lipid panel"},
+		},
+	})
+
+	assert.Equal(t, "EUR", v.String("ancestry.population"))
+	assert.Equal(t, "v1", v.String("reference.model"))
+	assert.Equal(t, 500, v.Int("REFERENCE.trait_max_variants"))
+	assert.Equal(t, []string{"a", "b"}, v.StringSlice("consent.excluded"))
+	assert.Equal(t, map[string]string{"combined": "ldl_z + hdl_z"}, v.StringMap("output.derived_metrics"))
+	assert.True(t, v.IsSet("reference"))
+	assert.False(t, v.IsSet("reference.missing"))
+	assert.False(t, v.Bool("reference.missing"))
+
+	var rules map[string]struct {
+		MinPercentile float64 `json:"min_percentile"`
+		Note          string  `json:"note"`
+	}
+	assert.NoError(t, v.Unmarshal("output.actionable", &rules))
+	assert.Equal(t, 95.0, rules["ldl"].MinPercentile)
+	assert.Equal(t, "lipid panel", rules["ldl"].Note)
+
+	v.Set("reference.model", "v2")
+	assert.Equal(t, "v2", v.String("reference.model"))
+}
+
+func TestUse(t *testing.T) {
+	ResetForTest()
+	SetConfigPath("/nonexistent/path/config.json")
+	t.Setenv("PHITE_ANCESTRY_POPULATION", "AFR")
+	assert.Equal(t, "AFR", GetString("ancestry.population"))
+
+	// The installed provider replaces the file and environment for every read and write
+	restore := Use(NewValues(map[string]any{"ancestry.population": "EUR"}))
+	assert.Equal(t, "EUR", GetString("ancestry.population"))
+	assert.Equal(t, "", GetString(LogLevelKey))
+	Set("reference.model", "v1")
+	assert.True(t, HasKey("reference.model"))
+
+	restore()
+	assert.Equal(t, "AFR", GetString("ancestry.population"))
+	assert.False(t, HasKey("reference.model"))
+}
+
+func TestUse_Concurrent(t *testing.T) {
+	ResetForTest()
+	a := NewValues(map[string]any{"ancestry.population": "EUR"})
+	b := NewValues(map[string]any{"ancestry.population": "AFR"})
+
+	// Runs sharing a provider overlap, and restoring twice is harmless
+	restoreA1 := Use(a)
+	restoreA2 := Use(a)
+	restoreA1()
+	restoreA1()
+	assert.Equal(t, "EUR", GetString("ancestry.population"))
+
+	// A run with another provider waits for the last run holding a
+	installed := make(chan string)
+	go func() {
+		restore := Use(b)
+		defer restore()
+		installed <- GetString("ancestry.population")
+	}()
+	select {
+	case <-installed:
+		t.Fatal("a different provider was installed while a was in use")
+	case <-time.After(20 * time.Millisecond):
+	}
+	restoreA2()
+	assert.Equal(t, "AFR", <-installed)
+}
+
+func TestUse_Nested(t *testing.T) {
+	ResetForTest()
+	SetConfigPath("/nonexistent/path/config.json")
+	a := NewValues(map[string]any{"ancestry.population": "EUR"})
+	b := NewValues(map[string]any{"ancestry.population": "AFR"})
+
+	// A run holding a alone may nest b, and gets a back when b is restored
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		restoreA := Use(a)
+		restoreB := Use(b)
+		assert.Equal(t, "AFR", GetString("ancestry.population"))
+		restoreB()
+		assert.Equal(t, "EUR", GetString("ancestry.population"))
+		restoreA()
+		assert.Equal(t, "", GetString("ancestry.population"))
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("nested Use with a second provider deadlocked")
+	}
+
+	// While another run shares a, nesting b waits for it rather than switching its config
+	restoreOther := Use(a)
+	nested := make(chan string)
+	go func() {
+		restoreA := Use(a)
+		defer restoreA()
+		restoreB := Use(b)
+		defer restoreB()
+		nested <- GetString("ancestry.population")
+	}()
+	select {
+	case <-nested:
+		t.Fatal("b was installed while another run held a")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.Equal(t, "EUR", GetString("ancestry.population"))
+	restoreOther()
+	assert.Equal(t, "AFR", <-nested)
+}
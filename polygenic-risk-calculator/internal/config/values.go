@@ -0,0 +1,207 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Values is a Provider holding configuration set in code. It reads no config file, profile,
+// or environment variable, so a library user controls every value:
+//
+//	cfg := config.NewValues(map[string]any{
+//		"ancestry.population": "EUR",
+//		"reference": map[string]any{"model": "v1"},
+//	})
+//
+// Keys are case-insensitive; dotted keys and nested maps are interchangeable.
+type Values struct {
+	mu sync.RWMutex
+	m  map[string]any
+}
+
+var _ Provider = (*Values)(nil)
+
+// NewValues returns a provider holding values.
+func NewValues(values map[string]any) *Values {
+	v := &Values{m: make(map[string]any)}
+	for key, value := range values {
+		v.Set(key, value)
+	}
+	return v
+}
+
+// Set sets key. A nested map value sets each of its keys below key.
+func (v *Values) Set(key string, value any) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.put(key, value)
+}
+
+func (v *Values) put(key string, value any) {
+	if nested, ok := value.(map[string]any); ok {
+		for k, sub := range nested {
+			v.put(key+"."+k, sub)
+		}
+		return
+	}
+	path := strings.Split(strings.ToLower(key), ".")
+	m := v.m
+	for _, k := range path[:len(path)-1] {
+		sub, ok := m[k].(map[string]any)
+		if !ok {
+			sub = make(map[string]any)
+			m[k] = sub
+		}
+		m = sub
+	}
+	m[path[len(path)-1]] = value
+}
+
+// get returns the value at key; a map is returned for a key with values below it.
+func (v *Values) get(key string) any {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.lookup(key)
+}
+
+// lookup is get for callers holding the lock.
+func (v *Values) lookup(key string) any {
+	var cur any = v.m
+	if key == "" {
+		return cur
+	}
+	for _, k := range strings.Split(strings.ToLower(key), ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[k]
+	}
+	return cur
+}
+
+// IsSet reports whether key has a value.
+func (v *Values) IsSet(key string) bool {
+	return v.get(key) != nil
+}
+
+// String returns key as a string, or "".
+func (v *Values) String(key string) string {
+	switch val := v.get(key).(type) {
+	case nil, map[string]any:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// Int returns key as an int, or 0 if it is unset or not a number.
+func (v *Values) Int(key string) int {
+	return int(v.Float64(key))
+}
+
+// Float64 returns key as a float64, or 0 if it is unset or not a number.
+func (v *Values) Float64(key string) float64 {
+	switch val := v.get(key).(type) {
+	case float64:
+		return val
+	case float32:
+		return float64(val)
+	case int:
+		return float64(val)
+	case int64:
+		return float64(val)
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		return f
+	case bool:
+		if val {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Bool returns key as a bool: true, or a string strconv.ParseBool accepts as true.
+func (v *Values) Bool(key string) bool {
+	switch val := v.get(key).(type) {
+	case bool:
+		return val
+	case string:
+		b, _ := strconv.ParseBool(strings.TrimSpace(val))
+		return b
+	case int:
+		return val != 0
+	case float64:
+		return val != 0
+	}
+	return false
+}
+
+// StringSlice returns key as a list of strings; a string value is split on commas.
+func (v *Values) StringSlice(key string) []string {
+	switch val := v.get(key).(type) {
+	case []string:
+		return val
+	case []any:
+		out := make([]string, 0, len(val))
+		for _, e := range val {
+			out = append(out, fmt.Sprint(e))
+		}
+		return out
+	case string:
+		if strings.TrimSpace(val) == "" {
+			return nil
+		}
+		parts := strings.Split(val, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		return parts
+	}
+	return nil
+}
+
+// StringMap returns the values below key as strings; it is never nil.
+func (v *Values) StringMap(key string) map[string]string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	out := map[string]string{}
+	switch val := v.lookup(key).(type) {
+	case map[string]any:
+		for k, e := range val {
+			out[k] = fmt.Sprint(e)
+		}
+	case map[string]string:
+		for k, e := range val {
+			out[strings.ToLower(k)] = e
+		}
+	}
+	return out
+}
+
+// Unmarshal decodes the value at key ("" for every value) into out, as JSON.
+func (v *Values) Unmarshal(key string, out any) error {
+	v.mu.RLock()
+	val := v.lookup(key)
+	if val == nil {
+		v.mu.RUnlock()
+		return nil
+	}
+	data, err := json.Marshal(val)
+	v.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode config %q: %w", key, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode config %q: %w", key, err)
+	}
+	return nil
+}
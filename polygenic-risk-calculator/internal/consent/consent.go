@@ -0,0 +1,109 @@
+// Package consent applies the user's opt-outs: traits in excluded taxonomy categories, or
+// excluded by name, are dropped in Phase 1 as soon as they are discovered, so they are
+// never scored, cached, or reported. Genes filed under an excluded category are likewise
+// left out of compound genotype and pharmacogenomic reports.
+package consent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JerkyTreats/PHITE/taxonomy"
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+// Domain-specific configuration keys for user consent
+const (
+	ExcludedCategoriesKey = "consent.excluded_categories" // Taxonomy categories, "Topic" or "Topic/Group", whose traits are never computed
+	ExcludedTraitsKey     = "consent.excluded_traits"     // Individual traits that are never computed
+)
+
+// Policy is the set of opted-out traits and categories. A nil Policy excludes nothing.
+type Policy struct {
+	categories []taxonomy.Category
+	traits     map[string]bool // lower-cased trait names
+	tax        *taxonomy.Taxonomy
+}
+
+// FromConfig reads the opt-outs, resolving categories against tax. It returns nil when
+// nothing is excluded. An unknown category is an error rather than a silent no-op, since
+// a misspelled opt-out would otherwise compute exactly what the user declined.
+func FromConfig(tax *taxonomy.Taxonomy) (*Policy, error) {
+	p := &Policy{traits: make(map[string]bool), tax: tax}
+	for _, raw := range config.GetStringSlice(ExcludedCategoriesKey) {
+		c, err := resolve(tax, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ExcludedCategoriesKey, err)
+		}
+		p.categories = append(p.categories, c)
+	}
+	for _, trait := range config.GetStringSlice(ExcludedTraitsKey) {
+		if trait = strings.TrimSpace(trait); trait != "" {
+			p.traits[strings.ToLower(trait)] = true
+		}
+	}
+	if len(p.categories) == 0 && len(p.traits) == 0 {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// resolve parses "Topic" or "Topic/Group" into a category known to tax, matching names
+// regardless of case and spacing.
+func resolve(tax *taxonomy.Taxonomy, raw string) (taxonomy.Category, error) {
+	topic, group, _ := strings.Cut(raw, "/")
+	known, ok := tax.SuggestTopic(topic)
+	if !ok || !sameName(known, topic) {
+		if ok {
+			return taxonomy.Category{}, fmt.Errorf("unknown topic %q (did you mean %q?)", topic, known)
+		}
+		return taxonomy.Category{}, fmt.Errorf("unknown topic %q: set output.taxonomy to a taxonomy that defines it", topic)
+	}
+	c := taxonomy.Category{Topic: known}
+	if strings.TrimSpace(group) == "" {
+		return c, nil
+	}
+	knownGroup, ok := tax.SuggestGroup(known, group)
+	if !ok || !sameName(knownGroup, group) {
+		if ok {
+			return taxonomy.Category{}, fmt.Errorf("unknown group %q in topic %s (did you mean %q?)", group, known, knownGroup)
+		}
+		return taxonomy.Category{}, fmt.Errorf("unknown group %q in topic %s", group, known)
+	}
+	c.Group = knownGroup
+	return c, nil
+}
+
+func sameName(a, b string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " "))
+}
+
+// Excludes reports whether trait was opted out, by name or by its taxonomy category.
+func (p *Policy) Excludes(trait string) bool {
+	if p == nil {
+		return false
+	}
+	if p.traits[strings.ToLower(trait)] {
+		return true
+	}
+	c, ok := p.tax.Trait(trait)
+	return ok && p.excludesCategory(c)
+}
+
+// ExcludesGene reports whether gene is filed under an opted-out category.
+func (p *Policy) ExcludesGene(gene string) bool {
+	if p == nil {
+		return false
+	}
+	c, ok := p.tax.Gene(gene)
+	return ok && p.excludesCategory(c)
+}
+
+func (p *Policy) excludesCategory(c taxonomy.Category) bool {
+	for _, excluded := range p.categories {
+		if excluded.Topic == c.Topic && (excluded.Group == "" || excluded.Group == c.Group) {
+			return true
+		}
+	}
+	return false
+}
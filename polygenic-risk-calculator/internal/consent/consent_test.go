@@ -0,0 +1,65 @@
+package consent
+
+import (
+	"testing"
+
+	"github.com/JerkyTreats/PHITE/taxonomy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+func testTaxonomy() *taxonomy.Taxonomy {
+	tax := taxonomy.New()
+	tax.AddTrait("alzheimers", taxonomy.Category{Topic: "Neurological", Group: "Neurodegenerative"})
+	tax.AddTrait("migraine", taxonomy.Category{Topic: "Neurological", Group: "Headache"})
+	tax.AddTrait("ldl", taxonomy.Category{Topic: "Cardiovascular", Group: "Lipids"})
+	tax.AddGene("APOE", taxonomy.Category{Topic: "Neurological", Group: "Neurodegenerative"})
+	tax.AddGene("CYP2C19", taxonomy.Category{Topic: "Pharmacogenomics"})
+	return tax
+}
+
+func TestFromConfig(t *testing.T) {
+	defer func() {
+		config.Set(ExcludedCategoriesKey, []string{})
+		config.Set(ExcludedTraitsKey, []string{})
+	}()
+
+	p, err := FromConfig(testTaxonomy())
+	require.NoError(t, err)
+	assert.Nil(t, p, "nothing is excluded by default")
+	assert.False(t, p.Excludes("alzheimers"))
+
+	config.Set(ExcludedCategoriesKey, []string{"neurological / neurodegenerative"})
+	config.Set(ExcludedTraitsKey, []string{"LDL"})
+	p, err = FromConfig(testTaxonomy())
+	require.NoError(t, err)
+	assert.True(t, p.Excludes("Alzheimers"))
+	assert.False(t, p.Excludes("migraine"), "other groups of the topic are kept")
+	assert.True(t, p.Excludes("ldl"), "excluded by name")
+	assert.False(t, p.Excludes("height"), "uncategorized traits are kept")
+	assert.True(t, p.ExcludesGene("apoe"))
+	assert.False(t, p.ExcludesGene("CYP2C19"))
+
+	config.Set(ExcludedCategoriesKey, []string{"Neurological"})
+	config.Set(ExcludedTraitsKey, []string{})
+	p, err = FromConfig(testTaxonomy())
+	require.NoError(t, err)
+	assert.True(t, p.Excludes("migraine"), "a topic excludes all of its groups")
+}
+
+func TestFromConfig_UnknownCategory(t *testing.T) {
+	defer config.Set(ExcludedCategoriesKey, []string{})
+
+	config.Set(ExcludedCategoriesKey, []string{"Neurologcal"})
+	_, err := FromConfig(testTaxonomy())
+	assert.ErrorContains(t, err, `did you mean "Neurological"`)
+
+	config.Set(ExcludedCategoriesKey, []string{"Neurological/Sleep"})
+	_, err = FromConfig(testTaxonomy())
+	assert.ErrorContains(t, err, "unknown group")
+
+	config.Set(ExcludedCategoriesKey, []string{"Neurological"})
+	_, err = FromConfig(nil)
+	assert.Error(t, err, "categories cannot be resolved without a taxonomy")
+}
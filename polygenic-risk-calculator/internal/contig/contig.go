@@ -0,0 +1,300 @@
+// Package contig normalizes chromosome names so genotype, model, and allele frequency data
+// written with different conventions (1, chr1, NC_000001.11) match, and handles variants on
+// hg38 ALT, patch, and unplaced contigs, which have no position on the primary assembly.
+package contig
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+// Domain-specific configuration keys for contig handling
+const (
+	AltPolicyKey       = "contig.alt_policy"       // What to do with ALT/patch contig variants: drop (default) or remap
+	AliasFileKey       = "contig.alias_file"       // TSV of extra aliases: name, canonical chromosome, optional position offset
+	FrequencyNamingKey = "contig.frequency_naming" // Chromosome naming of the allele frequency table: ucsc, ensembl, or refseq
+)
+
+// ALT contig policies.
+const (
+	// PolicyDrop drops every variant on an ALT, patch, or unplaced contig.
+	PolicyDrop = "drop"
+	// PolicyRemap moves variants on contigs listed in the alias file onto the primary
+	// chromosome, shifting positions by the listed offset, and drops the rest.
+	PolicyRemap = "remap"
+)
+
+// Chromosome naming conventions.
+const (
+	NamingUCSC    = "ucsc"    // chr1, chrX, chrM
+	NamingEnsembl = "ensembl" // 1, X, MT
+	NamingRefSeq  = "refseq"  // NC_000001.11
+)
+
+// Sources reported in a Report.
+const (
+	SourceGenotype  = "genotype"
+	SourceModel     = "model"
+	SourceFrequency = "frequency"
+)
+
+// ErrAltContig is returned for a variant dropped because it lies on an ALT or patch contig.
+var ErrAltContig = errors.New("variant on ALT or patch contig")
+
+// primary lists the GRCh38 primary assembly chromosomes with their RefSeq accessions.
+// Canonical names use the Ensembl convention.
+var primary = []struct{ name, refseq string }{
+	{"1", "NC_000001.11"}, {"2", "NC_000002.12"}, {"3", "NC_000003.12"}, {"4", "NC_000004.12"},
+	{"5", "NC_000005.10"}, {"6", "NC_000006.12"}, {"7", "NC_000007.14"}, {"8", "NC_000008.11"},
+	{"9", "NC_000009.12"}, {"10", "NC_000010.11"}, {"11", "NC_000011.10"}, {"12", "NC_000012.12"},
+	{"13", "NC_000013.11"}, {"14", "NC_000014.9"}, {"15", "NC_000015.10"}, {"16", "NC_000016.10"},
+	{"17", "NC_000017.11"}, {"18", "NC_000018.10"}, {"19", "NC_000019.10"}, {"20", "NC_000020.11"},
+	{"21", "NC_000021.9"}, {"22", "NC_000022.11"}, {"X", "NC_000023.11"}, {"Y", "NC_000024.10"},
+	{"MT", "NC_012920.1"},
+}
+
+// altPattern matches UCSC and GenBank names of ALT, fix/novel patch, random, and unplaced contigs.
+var altPattern = regexp.MustCompile(`(?i)(^(chr)?un_|_(alt|fix|novel|random)$|^(gl|ki|kn|kq|kv|kz|jh|ml|mu)\d+(\.\d+|v\d+)$)`)
+
+// IsAlt reports whether name is an ALT, patch, random, or unplaced contig.
+func IsAlt(name string) bool {
+	return altPattern.MatchString(name)
+}
+
+type alias struct {
+	canonical string
+	offset    int64
+}
+
+// Report counts variants dropped or remapped by contig handling, per source.
+type Report struct {
+	Dropped  map[string]int `json:"dropped,omitempty"`
+	Remapped map[string]int `json:"remapped,omitempty"`
+}
+
+// Normalizer resolves chromosome names to canonical names and applies the ALT policy.
+// A nil Normalizer passes names through unchanged. It is safe for concurrent use.
+type Normalizer struct {
+	aliases map[string]alias // lower-cased name -> canonical chromosome
+	policy  string
+	naming  string
+
+	mu     sync.Mutex
+	report Report
+}
+
+// New creates a Normalizer with the built-in aliases for every primary chromosome.
+func New(policy, naming string) (*Normalizer, error) {
+	switch policy {
+	case "":
+		policy = PolicyDrop
+	case PolicyDrop, PolicyRemap:
+	default:
+		return nil, fmt.Errorf("invalid %s %q: must be %q or %q", AltPolicyKey, policy, PolicyDrop, PolicyRemap)
+	}
+	switch naming {
+	case "", NamingUCSC, NamingEnsembl, NamingRefSeq:
+	default:
+		return nil, fmt.Errorf("invalid %s %q: must be %q, %q, or %q", FrequencyNamingKey, naming, NamingUCSC, NamingEnsembl, NamingRefSeq)
+	}
+
+	n := &Normalizer{aliases: make(map[string]alias), policy: policy, naming: naming}
+	for _, c := range primary {
+		for _, name := range []string{c.name, "chr" + c.name, c.refseq} {
+			n.aliases[strings.ToLower(name)] = alias{canonical: c.name}
+		}
+	}
+	n.aliases["chrm"] = alias{canonical: "MT"}
+	n.aliases["m"] = alias{canonical: "MT"}
+	return n, nil
+}
+
+// FromConfig creates a Normalizer from configuration, loading the alias file when set.
+func FromConfig() (*Normalizer, error) {
+	n, err := New(config.GetString(AltPolicyKey), config.GetString(FrequencyNamingKey))
+	if err != nil {
+		return nil, err
+	}
+	if path := config.GetString(AliasFileKey); path != "" {
+		if err := n.LoadAliases(path); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// LoadAliases adds aliases from a tab-separated file of name, canonical chromosome, and an
+// optional position offset. Entries naming ALT contigs are only applied under PolicyRemap.
+// Blank lines and lines starting with # are ignored.
+func (n *Normalizer) LoadAliases(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open contig alias file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		cols := strings.Split(text, "\t")
+		if len(cols) < 2 {
+			return fmt.Errorf("%s:%d: expected name and canonical chromosome", path, line)
+		}
+		target, ok := n.aliases[strings.ToLower(strings.TrimSpace(cols[1]))]
+		if !ok {
+			return fmt.Errorf("%s:%d: %q is not a primary chromosome", path, line, cols[1])
+		}
+		a := alias{canonical: target.canonical}
+		if len(cols) > 2 && strings.TrimSpace(cols[2]) != "" {
+			a.offset, err = strconv.ParseInt(strings.TrimSpace(cols[2]), 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s:%d: invalid offset %q", path, line, cols[2])
+			}
+		}
+		n.aliases[strings.ToLower(strings.TrimSpace(cols[0]))] = a
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read contig alias file: %w", err)
+	}
+	return nil
+}
+
+// Resolve returns the canonical chromosome and position for a variant read from source.
+// It returns false when the variant lies on an ALT contig the policy drops. Unknown
+// primary-looking names are returned unchanged.
+func (n *Normalizer) Resolve(source, chrom string, pos int64) (string, int64, bool) {
+	if n == nil {
+		return chrom, pos, true
+	}
+	a, known := n.aliases[strings.ToLower(chrom)]
+	alt := IsAlt(chrom)
+	switch {
+	case alt && known && n.policy == PolicyRemap:
+		n.count(&n.report.Remapped, source)
+		return a.canonical, pos + a.offset, true
+	case alt:
+		n.count(&n.report.Dropped, source)
+		return "", 0, false
+	case known:
+		return a.canonical, pos + a.offset, true
+	default:
+		return chrom, pos, true
+	}
+}
+
+// Canonical returns the canonical name of chrom, or chrom itself when it has no alias.
+func (n *Normalizer) Canonical(chrom string) string {
+	if n == nil {
+		return chrom
+	}
+	if a, ok := n.aliases[strings.ToLower(chrom)]; ok {
+		return a.canonical
+	}
+	return chrom
+}
+
+// FrequencyName returns the name the allele frequency table uses for chrom. Without a
+// configured naming, chrom is returned as given.
+func (n *Normalizer) FrequencyName(chrom string) string {
+	if n == nil || n.naming == "" {
+		return chrom
+	}
+	canonical := n.Canonical(chrom)
+	for _, c := range primary {
+		if c.name != canonical {
+			continue
+		}
+		switch n.naming {
+		case NamingUCSC:
+			if canonical == "MT" {
+				return "chrM"
+			}
+			return "chr" + canonical
+		case NamingRefSeq:
+			return c.refseq
+		default:
+			return canonical
+		}
+	}
+	return chrom
+}
+
+func (n *Normalizer) count(m *map[string]int, source string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if *m == nil {
+		*m = make(map[string]int)
+	}
+	(*m)[source]++
+}
+
+// Fork returns a Normalizer with n's aliases, policy, and naming but no counts, so a run
+// sharing n's configuration gets a report of its own. A nil Normalizer forks to nil.
+func (n *Normalizer) Fork() *Normalizer {
+	if n == nil {
+		return nil
+	}
+	return &Normalizer{aliases: n.aliases, policy: n.policy, naming: n.naming}
+}
+
+// Report returns a copy of the counts accumulated so far.
+func (n *Normalizer) Report() Report {
+	if n == nil {
+		return Report{}
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return Report{Dropped: copyCounts(n.report.Dropped), Remapped: copyCounts(n.report.Remapped)}
+}
+
+func copyCounts(m map[string]int) map[string]int {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]int, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// String summarizes the report for logging, e.g. "dropped model=3; remapped genotype=1".
+func (r Report) String() string {
+	var parts []string
+	for _, section := range []struct {
+		label  string
+		counts map[string]int
+	}{{"dropped", r.Dropped}, {"remapped", r.Remapped}} {
+		if len(section.counts) == 0 {
+			continue
+		}
+		sources := make([]string, 0, len(section.counts))
+		for s := range section.counts {
+			sources = append(sources, s)
+		}
+		sort.Strings(sources)
+		items := make([]string, len(sources))
+		for i, s := range sources {
+			items[i] = fmt.Sprintf("%s=%d", s, section.counts[s])
+		}
+		parts = append(parts, section.label+" "+strings.Join(items, " "))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,107 @@
+package contig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve_Aliases(t *testing.T) {
+	n, err := New("", "")
+	require.NoError(t, err)
+
+	for _, name := range []string{"1", "chr1", "CHR1", "NC_000001.11"} {
+		chrom, pos, ok := n.Resolve(SourceModel, name, 100)
+		assert.True(t, ok, name)
+		assert.Equal(t, "1", chrom, name)
+		assert.Equal(t, int64(100), pos)
+	}
+	assert.Equal(t, "MT", n.Canonical("chrM"))
+	assert.Equal(t, "scaffold_7", n.Canonical("scaffold_7"))
+}
+
+func TestResolve_DropsAltContigs(t *testing.T) {
+	n, err := New(PolicyDrop, "")
+	require.NoError(t, err)
+
+	for _, name := range []string{"chr1_KI270762v1_alt", "chr17_KI270909v1_alt", "chrUn_KI270302v1", "chr1_KN196472v1_fix", "GL000220.1"} {
+		assert.True(t, IsAlt(name), name)
+		_, _, ok := n.Resolve(SourceGenotype, name, 5)
+		assert.False(t, ok, name)
+	}
+	assert.Equal(t, map[string]int{SourceGenotype: 5}, n.Report().Dropped)
+	assert.Equal(t, "dropped genotype=5", n.Report().String())
+}
+
+func TestResolve_RemapsListedAltContigs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aliases.tsv")
+	require.NoError(t, os.WriteFile(path, []byte("# name\tcanonical\toffset\nchr1_KI270762v1_alt\tchr1\t2448810\n"), 0644))
+
+	n, err := New(PolicyRemap, "")
+	require.NoError(t, err)
+	require.NoError(t, n.LoadAliases(path))
+
+	chrom, pos, ok := n.Resolve(SourceModel, "chr1_KI270762v1_alt", 10)
+	assert.True(t, ok)
+	assert.Equal(t, "1", chrom)
+	assert.Equal(t, int64(2448820), pos)
+
+	_, _, ok = n.Resolve(SourceModel, "chr2_KI270769v1_alt", 10)
+	assert.False(t, ok, "unlisted ALT contigs are still dropped")
+
+	report := n.Report()
+	assert.Equal(t, map[string]int{SourceModel: 1}, report.Remapped)
+	assert.Equal(t, map[string]int{SourceModel: 1}, report.Dropped)
+}
+
+func TestFork(t *testing.T) {
+	n, err := New(PolicyDrop, NamingUCSC)
+	require.NoError(t, err)
+	n.Resolve(SourceModel, "chr1_KI270762v1_alt", 1)
+
+	fork := n.Fork()
+	assert.Equal(t, "chr2", fork.FrequencyName("2"), "the fork keeps the naming")
+	assert.Empty(t, fork.Report().Dropped)
+	fork.Resolve(SourceGenotype, "chrUn_KI270302v1", 1)
+	assert.Equal(t, map[string]int{SourceGenotype: 1}, fork.Report().Dropped)
+	assert.Equal(t, map[string]int{SourceModel: 1}, n.Report().Dropped, "the original's counts are its own")
+
+	var nilNormalizer *Normalizer
+	assert.Nil(t, nilNormalizer.Fork())
+}
+
+func TestLoadAliases_RejectsUnknownTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aliases.tsv")
+	require.NoError(t, os.WriteFile(path, []byte("chr1_KI270762v1_alt\tchr99\n"), 0644))
+	n, err := New(PolicyRemap, "")
+	require.NoError(t, err)
+	assert.Error(t, n.LoadAliases(path))
+}
+
+func TestFrequencyName(t *testing.T) {
+	for naming, want := range map[string]string{"": "chr1", NamingUCSC: "chr1", NamingEnsembl: "1", NamingRefSeq: "NC_000001.11"} {
+		n, err := New("", naming)
+		require.NoError(t, err)
+		assert.Equal(t, want, n.FrequencyName("chr1"), naming)
+	}
+	n, err := New("", NamingUCSC)
+	require.NoError(t, err)
+	assert.Equal(t, "chrM", n.FrequencyName("MT"))
+
+	_, err = New("", "hg")
+	assert.Error(t, err)
+	_, err = New("liftover", "")
+	assert.Error(t, err)
+}
+
+func TestNilNormalizerPassesThrough(t *testing.T) {
+	var n *Normalizer
+	chrom, pos, ok := n.Resolve(SourceModel, "chr1_KI270762v1_alt", 10)
+	assert.True(t, ok)
+	assert.Equal(t, "chr1_KI270762v1_alt", chrom)
+	assert.Equal(t, int64(10), pos)
+	assert.Equal(t, "none", n.Report().String())
+}
@@ -3,12 +3,16 @@ package bq
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"cloud.google.com/go/bigquery"
 	"google.golang.org/api/iterator"
 	bigqueryclient "phite.io/polygenic-risk-calculator/internal/clientsets/bigquery"
 	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/db/dbutil"
 	dbinterface "phite.io/polygenic-risk-calculator/internal/db/interface"
+	"phite.io/polygenic-risk-calculator/internal/db/querylog"
+	"phite.io/polygenic-risk-calculator/internal/events"
 	"phite.io/polygenic-risk-calculator/internal/logging"
 )
 
@@ -64,8 +68,23 @@ func NewRepository(projectID, datasetID, billingProject string) (dbinterface.Rep
 	}, nil
 }
 
-// Query executes a SQL query and returns the results as a slice of maps
+// Dialect reports that queries are BigQuery SQL.
+func (r *Repository) Dialect() dbutil.Dialect {
+	return dbutil.BigQuery
+}
+
+// Query executes a SQL query and returns the results as a slice of maps. Each query is
+// published as a QueryExecuted event on the context's event bus.
 func (r *Repository) Query(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	start := time.Now()
+	results, err := r.query(ctx, query, args...)
+	d := time.Since(start)
+	events.QueryDone(ctx, "bigquery", query, len(results), d, err)
+	querylog.Done("bigquery", query, args, len(results), d, err, "")
+	return results, err
+}
+
+func (r *Repository) query(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
 	logging.Debug("Executing BigQuery query with %d args: %s", len(args), query)
 
 	q := r.bqclient.Client.Query(query)
@@ -98,7 +117,6 @@ func (r *Repository) Query(ctx context.Context, query string, args ...interface{
 		results = append(results, convertedRow)
 	}
 
-	logging.Debug("Successfully executed query and scanned %d rows for query: %s", len(results), query)
 	return results, nil
 }
 
@@ -0,0 +1,125 @@
+// Package dbutil builds SQL for the repositories so table and column names are quoted
+// and values are always bound as parameters instead of being concatenated into queries.
+//
+// Identifiers are written bare when they are plain names (letters, digits, underscore) and
+// quoted for the dialect otherwise, so existing queries read the same while names such as
+// bigquery-public-data.gnomad.genomes are still safe. Long IN lists are split into chunks
+// (see Chunks) to stay under the engines' parameter limits.
+package dbutil
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Dialect is the SQL flavour of a repository.
+type Dialect int
+
+const (
+	// DuckDB quotes identifiers with double quotes.
+	DuckDB Dialect = iota
+	// BigQuery quotes identifiers with backticks.
+	BigQuery
+)
+
+// String returns the dialect name.
+func (d Dialect) String() string {
+	if d == BigQuery {
+		return "bigquery"
+	}
+	return "duckdb"
+}
+
+// Dialecter is implemented by repositories that know their SQL dialect.
+type Dialecter interface {
+	Dialect() Dialect
+}
+
+// DialectOf returns repo's dialect, or DuckDB if it does not say.
+func DialectOf(repo interface{}) Dialect {
+	if d, ok := repo.(Dialecter); ok {
+		return d.Dialect()
+	}
+	return DuckDB
+}
+
+var bareIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// QuoteIdent quotes a possibly qualified identifier (dataset.table) part by part. Plain
+// names are left bare; anything else is wrapped in the dialect's quotes with embedded
+// quote characters escaped, so the result is always a single identifier.
+func (d Dialect) QuoteIdent(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = d.quotePart(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// QuoteParts joins the parts of a qualified name, quoting every part even when it is a
+// plain name, as BigQuery project.dataset.table references usually are written.
+func (d Dialect) QuoteParts(parts ...string) string {
+	out := make([]string, len(parts))
+	for i, part := range parts {
+		out[i] = d.quote(part)
+	}
+	return strings.Join(out, ".")
+}
+
+// QuoteIdents quotes each name with QuoteIdent.
+func (d Dialect) QuoteIdents(names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = d.QuoteIdent(name)
+	}
+	return out
+}
+
+func (d Dialect) quotePart(part string) string {
+	if bareIdent.MatchString(part) {
+		return part
+	}
+	return d.quote(part)
+}
+
+func (d Dialect) quote(part string) string {
+	if d == BigQuery {
+		return "`" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(part) + "`"
+	}
+	return `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+}
+
+// QuoteString quotes s as a string literal, for the few statements (DDL such as
+// read_csv_auto paths) where DuckDB cannot bind parameters.
+func QuoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Placeholders returns n comma-separated "?" placeholders.
+func Placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// DefaultChunkSize is the number of values bound per IN list. BigQuery allows 10,000 query
+// parameters and DuckDB plans long IN lists slowly, so lists are split well below that.
+const DefaultChunkSize = 1000
+
+// Chunks splits values into consecutive slices of at most size elements (DefaultChunkSize
+// if size <= 0). The chunks share values' backing array.
+func Chunks[T any](values []T, size int) [][]T {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	var chunks [][]T
+	for len(values) > size {
+		chunks = append(chunks, values[:size:size])
+		values = values[size:]
+	}
+	if len(values) > 0 {
+		chunks = append(chunks, values)
+	}
+	return chunks
+}
@@ -0,0 +1,164 @@
+package dbutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteIdent(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		name    string
+		want    string
+	}{
+		{DuckDB, "reference_panel", "reference_panel"},
+		{DuckDB, "main.reference_panel", "main.reference_panel"},
+		{DuckDB, "my table", `"my table"`},
+		{DuckDB, `x"; DROP TABLE t; --`, `"x""; DROP TABLE t; --"`},
+		{BigQuery, "bigquery-public-data.gnomad.genomes", "`bigquery-public-data`.gnomad.genomes"},
+		{BigQuery, "a`b", "`a\\`b`"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.dialect.QuoteIdent(tt.name), "%s %q", tt.dialect, tt.name)
+	}
+	assert.Equal(t, "`proj`.`ds`.`tbl`", BigQuery.QuoteParts("proj", "ds", "tbl"))
+}
+
+func TestQuoteString(t *testing.T) {
+	assert.Equal(t, `'it''s.csv'`, QuoteString("it's.csv"))
+}
+
+func TestSelectBuild(t *testing.T) {
+	query, args, err := DuckDB.Select("rsid", "beta").
+		ColumnAs("other", "other_allele").
+		From("gwas").
+		WhereEq("trait", "t2d").
+		WhereIn("rsid", []interface{}{"rs1", "rs2"}).
+		OrderBy("rsid").
+		Page(2, 50).
+		Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT rsid, beta, other AS other_allele FROM gwas WHERE trait = ? AND rsid IN (?, ?) ORDER BY rsid LIMIT 50 OFFSET 100", query)
+	assert.Equal(t, []interface{}{"t2d", "rs1", "rs2"}, args)
+
+	query, args, err = BigQuery.Select().From("af").WhereAnyOf("chrom = ? AND pos = ?", [][]interface{}{{"1", 10}, {"2", 20}}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM af WHERE ((chrom = ? AND pos = ?) OR (chrom = ? AND pos = ?))", query)
+	assert.Equal(t, []interface{}{"1", 10, "2", 20}, args)
+
+	query, _, err = DuckDB.Select().From("t").WhereIn("rsid", nil).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM t WHERE 1 = 0", query)
+
+	_, _, err = DuckDB.Select("a").Build()
+	assert.Error(t, err, "select without a table")
+}
+
+func TestSelectBuild_RejectsInvalidIdents(t *testing.T) {
+	malicious := []string{
+		"models; DROP TABLE cache",
+		"models --",
+		`x"; DROP TABLE t; --`,
+		"a`b",
+		"t /* c */",
+		"my table",
+		"",
+	}
+	for _, name := range malicious {
+		_, _, err := DuckDB.Select().From(name).Build()
+		assert.ErrorIs(t, err, ErrInvalidIdentifier, "table %q", name)
+
+		_, _, err = BigQuery.Select(name).From("t").Build()
+		assert.ErrorIs(t, err, ErrInvalidIdentifier, "column %q", name)
+
+		_, _, err = DuckDB.Select().From("t").WhereEq(name, 1).Build()
+		assert.ErrorIs(t, err, ErrInvalidIdentifier, "where column %q", name)
+
+		_, _, err = DuckDB.Select().From("t").OrderBy(name).Build()
+		assert.ErrorIs(t, err, ErrInvalidIdentifier, "order column %q", name)
+	}
+}
+
+func TestValidateIdent(t *testing.T) {
+	for _, name := range []string{"reference_panel", "main.reference_panel", "bigquery-public-data.gnomad.genomes", "AF_nfe", "_tmp1"} {
+		assert.NoError(t, ValidateIdent(name), name)
+	}
+	for _, name := range []string{"", "a.b.c.d", "1abc", "a..b", ".a", "a b", "a;b", "a'b", `a"b`, "a`b", "a\nb", strings.Repeat("x", MaxIdentPartLength+1)} {
+		assert.ErrorIs(t, ValidateIdent(name), ErrInvalidIdentifier, name)
+	}
+
+	assert.NoError(t, ValidateConfigIdent("tables.model_table", ""))
+	err := ValidateConfigIdent("tables.model_table", "models; DROP TABLE cache")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tables.model_table")
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+}
+
+func TestInsert(t *testing.T) {
+	query, err := DuckDB.Insert("cache", []string{"mean_value", "trait"})
+	require.NoError(t, err)
+	assert.Equal(t, `INSERT INTO cache (mean_value, trait) VALUES (?, ?)`, query)
+
+	_, err = DuckDB.Insert("cache", []string{"mean value", "trait"})
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+	_, err = DuckDB.Insert("cache; DROP TABLE x", []string{"trait"})
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+}
+
+func TestChunks(t *testing.T) {
+	values := []int{1, 2, 3, 4, 5}
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, Chunks(values, 2))
+	assert.Equal(t, [][]int{{1, 2, 3, 4, 5}}, Chunks(values, 0))
+	assert.Empty(t, Chunks([]int{}, 2))
+
+	// Appending to a chunk must not overwrite the next one
+	chunks := Chunks(values, 2)
+	_ = append(chunks[0], 99)
+	assert.Equal(t, 3, chunks[1][0])
+}
+
+type pagedRepo struct {
+	rows    int
+	queries []string
+}
+
+func (r *pagedRepo) Query(_ context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	r.queries = append(r.queries, query)
+	var limit, offset int
+	fmt.Sscanf(query[len("SELECT * FROM t ORDER BY id LIMIT "):], "%d OFFSET %d", &limit, &offset)
+	var out []map[string]interface{}
+	for i := offset; i < r.rows && i < offset+limit; i++ {
+		out = append(out, map[string]interface{}{"id": i})
+	}
+	return out, nil
+}
+
+func TestPaginate(t *testing.T) {
+	repo := &pagedRepo{rows: 5}
+	var seen []interface{}
+	err := Paginate(context.Background(), repo, DuckDB.Select().From("t").OrderBy("id"), 2, func(rows []map[string]interface{}) error {
+		for _, row := range rows {
+			seen = append(seen, row["id"])
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{0, 1, 2, 3, 4}, seen)
+	assert.Len(t, repo.queries, 3)
+
+	assert.Error(t, Paginate(context.Background(), repo, DuckDB.Select().From("t"), 0, nil))
+}
+
+type dialectRepo struct{}
+
+func (dialectRepo) Dialect() Dialect { return BigQuery }
+
+func TestDialectOf(t *testing.T) {
+	assert.Equal(t, BigQuery, DialectOf(dialectRepo{}))
+	assert.Equal(t, DuckDB, DialectOf(struct{}{}))
+}
@@ -0,0 +1,67 @@
+package dbutil
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidIdentifier is wrapped by the errors ValidateIdent returns.
+var ErrInvalidIdentifier = errors.New("invalid SQL identifier")
+
+// MaxIdentParts and MaxIdentPartLength bound a qualified identifier: at most
+// project.dataset.table, each part at most 128 characters.
+const (
+	MaxIdentParts      = 3
+	MaxIdentPartLength = 128
+)
+
+// identPart allows letters, digits, underscores, and the hyphens of GCP project IDs.
+var identPart = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// ValidateIdent checks that name is a table or column name that can only ever be read as
+// an identifier: one to three dot-separated parts of letters, digits, underscores, and
+// hyphens, each starting with a letter or underscore. Quotes, whitespace, semicolons,
+// comments, and everything else are rejected, so a config value such as
+// "models; DROP TABLE cache" fails here instead of reaching a query.
+func ValidateIdent(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidIdentifier)
+	}
+	parts := strings.Split(name, ".")
+	if len(parts) > MaxIdentParts {
+		return fmt.Errorf("%w %q: more than %d dot-separated parts", ErrInvalidIdentifier, name, MaxIdentParts)
+	}
+	for _, part := range parts {
+		if len(part) > MaxIdentPartLength {
+			return fmt.Errorf("%w %q: part longer than %d characters", ErrInvalidIdentifier, name, MaxIdentPartLength)
+		}
+		if !identPart.MatchString(part) {
+			return fmt.Errorf("%w %q: use letters, digits, underscores, and hyphens", ErrInvalidIdentifier, name)
+		}
+	}
+	return nil
+}
+
+// ValidateIdents checks each name with ValidateIdent.
+func ValidateIdents(names ...string) error {
+	for _, name := range names {
+		if err := ValidateIdent(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateConfigIdent checks the identifier configured under key, naming the key in the
+// error. An empty value is allowed; callers that require it check that separately.
+func ValidateConfigIdent(key, value string) error {
+	if value == "" {
+		return nil
+	}
+	if err := ValidateIdent(value); err != nil {
+		return fmt.Errorf("config %s: %w", key, err)
+	}
+	return nil
+}
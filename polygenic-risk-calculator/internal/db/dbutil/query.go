@@ -0,0 +1,205 @@
+package dbutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SelectBuilder builds a SELECT statement. Table and column names are validated with
+// ValidateIdent and quoted; values are only ever added as bound arguments. Conditions passed to Where are raw SQL and must be
+// constants written in code, never built from input.
+type SelectBuilder struct {
+	dialect Dialect
+	columns []string
+	table   string
+	where   []string
+	args    []interface{}
+	orderBy []string
+	limit   int
+	offset  int
+	err     error
+}
+
+// Select starts a query for columns; no columns selects *.
+func (d Dialect) Select(columns ...string) *SelectBuilder {
+	b := &SelectBuilder{dialect: d}
+	for _, c := range columns {
+		b.Column(c)
+	}
+	return b
+}
+
+// ident validates and quotes name, recording the first invalid name for Build.
+func (b *SelectBuilder) ident(name string) string {
+	if err := ValidateIdent(name); err != nil && b.err == nil {
+		b.err = err
+	}
+	return b.dialect.QuoteIdent(name)
+}
+
+// Column adds a column to the select list.
+func (b *SelectBuilder) Column(name string) *SelectBuilder {
+	b.columns = append(b.columns, b.ident(name))
+	return b
+}
+
+// ColumnAs adds a column selected under alias.
+func (b *SelectBuilder) ColumnAs(name, alias string) *SelectBuilder {
+	b.columns = append(b.columns, b.ident(name)+" AS "+b.ident(alias))
+	return b
+}
+
+// From sets the table.
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.table = b.ident(table)
+	return b
+}
+
+// FromQuoted sets a table reference that is already quoted, e.g. by QuoteParts.
+func (b *SelectBuilder) FromQuoted(table string) *SelectBuilder {
+	b.table = table
+	return b
+}
+
+// Where adds a raw condition with "?" placeholders for args. Conditions are ANDed.
+func (b *SelectBuilder) Where(cond string, args ...interface{}) *SelectBuilder {
+	b.where = append(b.where, cond)
+	b.args = append(b.args, args...)
+	return b
+}
+
+// WhereEq adds "column = ?".
+func (b *SelectBuilder) WhereEq(column string, value interface{}) *SelectBuilder {
+	return b.Where(b.ident(column)+" = ?", value)
+}
+
+// WhereIn adds "column IN (?, ...)". An empty list matches no rows.
+func (b *SelectBuilder) WhereIn(column string, values []interface{}) *SelectBuilder {
+	if len(values) == 0 {
+		return b.Where("1 = 0")
+	}
+	return b.Where(b.ident(column)+" IN ("+Placeholders(len(values))+")", values...)
+}
+
+// WhereAnyOf adds "(clause) OR (clause) ...", binding one set of args per clause; the
+// clause is raw SQL, e.g. "chrom = ? AND pos = ?". An empty set matches no rows.
+func (b *SelectBuilder) WhereAnyOf(clause string, argSets [][]interface{}) *SelectBuilder {
+	if len(argSets) == 0 {
+		return b.Where("1 = 0")
+	}
+	groups := make([]string, len(argSets))
+	var args []interface{}
+	for i, set := range argSets {
+		groups[i] = "(" + clause + ")"
+		args = append(args, set...)
+	}
+	cond := strings.Join(groups, " OR ")
+	if len(b.where) > 0 || len(groups) > 1 {
+		cond = "(" + cond + ")"
+	}
+	return b.Where(cond, args...)
+}
+
+// OrderBy adds ORDER BY columns.
+func (b *SelectBuilder) OrderBy(columns ...string) *SelectBuilder {
+	for _, c := range columns {
+		b.orderBy = append(b.orderBy, b.ident(c))
+	}
+	return b
+}
+
+// Limit sets LIMIT; 0 means none.
+func (b *SelectBuilder) Limit(n int) *SelectBuilder {
+	b.limit = n
+	return b
+}
+
+// Offset sets OFFSET.
+func (b *SelectBuilder) Offset(n int) *SelectBuilder {
+	b.offset = n
+	return b
+}
+
+// Page sets LIMIT and OFFSET for the zero-based page of size rows.
+func (b *SelectBuilder) Page(page, size int) *SelectBuilder {
+	return b.Limit(size).Offset(page * size)
+}
+
+// Build returns the statement and its arguments, or an error if any table or column name
+// is invalid.
+func (b *SelectBuilder) Build() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	if b.table == "" {
+		return "", nil, fmt.Errorf("select has no table")
+	}
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	if len(b.columns) == 0 {
+		sb.WriteString("*")
+	} else {
+		sb.WriteString(strings.Join(b.columns, ", "))
+	}
+	sb.WriteString(" FROM ")
+	sb.WriteString(b.table)
+	if len(b.where) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(b.where, " AND "))
+	}
+	if len(b.orderBy) > 0 {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(strings.Join(b.orderBy, ", "))
+	}
+	if b.limit > 0 {
+		fmt.Fprintf(&sb, " LIMIT %d", b.limit)
+	}
+	if b.offset > 0 {
+		fmt.Fprintf(&sb, " OFFSET %d", b.offset)
+	}
+	return sb.String(), append([]interface{}(nil), b.args...), nil
+}
+
+// Querier runs a query; dbinterface.Repository satisfies it.
+type Querier interface {
+	Query(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error)
+}
+
+// Paginate runs b one page of size rows at a time, calling fn with each non-empty page
+// until a short page is returned. b should have an ORDER BY so pages are stable.
+func Paginate(ctx context.Context, q Querier, b *SelectBuilder, size int, fn func(rows []map[string]interface{}) error) error {
+	if size <= 0 {
+		return fmt.Errorf("page size must be positive, got %d", size)
+	}
+	for page := 0; ; page++ {
+		query, args, err := b.Page(page, size).Build()
+		if err != nil {
+			return err
+		}
+		rows, err := q.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query page %d: %w", page, err)
+		}
+		if len(rows) > 0 {
+			if err := fn(rows); err != nil {
+				return err
+			}
+		}
+		if len(rows) < size {
+			return nil
+		}
+	}
+}
+
+// Insert builds "INSERT INTO table (columns) VALUES (?, ...)" for one row.
+func (d Dialect) Insert(table string, columns []string) (string, error) {
+	if err := ValidateIdent(table); err != nil {
+		return "", err
+	}
+	if err := ValidateIdents(columns...); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		d.QuoteIdent(table), strings.Join(d.QuoteIdents(columns), ", "), Placeholders(len(columns))), nil
+}
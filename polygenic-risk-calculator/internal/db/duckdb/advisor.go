@@ -0,0 +1,221 @@
+package duckdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"phite.io/polygenic-risk-calculator/internal/db/dbutil"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// AccessPattern describes how the pipeline filters a table.
+type AccessPattern string
+
+const (
+	// EqualityFilter is "WHERE column = ?", as LoadModel filters the model table by trait.
+	EqualityFilter AccessPattern = "equality"
+	// InListFilter is "WHERE column IN (?, ...)", as Phase 1 fetches GWAS rows by rsid.
+	InListFilter AccessPattern = "in_list"
+)
+
+// Access is one filtered lookup the pipeline runs against a DuckDB table.
+type Access struct {
+	Table   string        `json:"table"`
+	Column  string        `json:"column"`
+	Pattern AccessPattern `json:"pattern"`
+}
+
+// Recommendation kinds.
+const (
+	RecommendIndex = "index" // create an ART index on the column
+	RecommendSort  = "sort"  // rewrite the table ordered by the column so zone maps skip row groups
+)
+
+// Recommendation is one change the advisor suggests for an Access.
+type Recommendation struct {
+	Access    Access `json:"access"`
+	Kind      string `json:"kind"`
+	Statement string `json:"statement,omitempty"`
+	Satisfied bool   `json:"satisfied"` // already in place; nothing to do
+	Reason    string `json:"reason"`
+}
+
+// Advise inspects each accessed table and recommends an index for every filtered column,
+// plus sorting the table by equality-filtered columns. Tables that are views are reported
+// with no statement, since only their base tables can be indexed.
+func Advise(ctx context.Context, db *sql.DB, accesses []Access) ([]Recommendation, error) {
+	var recs []Recommendation
+	for _, a := range accesses {
+		if err := dbutil.ValidateIdents(a.Table, a.Column); err != nil {
+			return nil, err
+		}
+		kind, err := relationKind(ctx, db, a.Table)
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case "":
+			return nil, fmt.Errorf("table %q does not exist", a.Table)
+		case "view":
+			recs = append(recs, Recommendation{Access: a, Kind: RecommendIndex,
+				Reason: "is a view; index the filtered column of its base table instead"})
+			continue
+		}
+
+		indexed, err := hasIndexOn(ctx, db, a.Table, a.Column)
+		if err != nil {
+			return nil, err
+		}
+		idx := Recommendation{Access: a, Kind: RecommendIndex, Satisfied: indexed}
+		if indexed {
+			idx.Reason = "an index on the column already exists"
+		} else {
+			idx.Statement = fmt.Sprintf("CREATE INDEX %s ON %s (%s)",
+				dbutil.DuckDB.QuoteIdent(indexName(a.Table, a.Column)), dbutil.DuckDB.QuoteIdent(a.Table), dbutil.DuckDB.QuoteIdent(a.Column))
+			idx.Reason = fmt.Sprintf("%s lookups on %s scan the whole table without an index", a.Pattern, a.Column)
+		}
+
+		if a.Pattern == EqualityFilter {
+			sorted, err := isSortedBy(ctx, db, a.Table, a.Column)
+			if err != nil {
+				return nil, err
+			}
+			sort := Recommendation{Access: a, Kind: RecommendSort, Satisfied: sorted}
+			if sorted {
+				sort.Reason = "rows are already ordered by the column"
+			} else {
+				table := dbutil.DuckDB.QuoteIdent(a.Table)
+				sort.Statement = fmt.Sprintf("CREATE OR REPLACE TABLE %s AS SELECT * FROM %s ORDER BY %s",
+					table, table, dbutil.DuckDB.QuoteIdent(a.Column))
+				sort.Reason = fmt.Sprintf("clustering rows by %s lets each lookup read only the row groups holding that value", a.Column)
+			}
+			// Sorting rewrites the table and drops its indexes, so it must run first.
+			recs = append(recs, sort)
+		}
+		recs = append(recs, idx)
+	}
+	return recs, nil
+}
+
+// Apply executes the statements of unsatisfied recommendations in order.
+func Apply(ctx context.Context, db *sql.DB, recs []Recommendation) error {
+	for _, r := range recs {
+		if r.Satisfied || r.Statement == "" {
+			continue
+		}
+		logging.Info("Applying %s recommendation: %s", r.Kind, r.Statement)
+		if _, err := db.ExecContext(ctx, r.Statement); err != nil {
+			return fmt.Errorf("failed to apply %s on %s.%s: %w", r.Kind, r.Access.Table, r.Access.Column, err)
+		}
+	}
+	return nil
+}
+
+// TimeAccess runs a representative lookup for a, filtering on sample values taken from the
+// table itself, and returns how long it took. It is used to measure the effect of Apply.
+func TimeAccess(ctx context.Context, db *sql.DB, a Access, samples int) (time.Duration, error) {
+	if err := dbutil.ValidateIdents(a.Table, a.Column); err != nil {
+		return 0, err
+	}
+	if samples <= 0 {
+		samples = 1
+	}
+	table, column := dbutil.DuckDB.QuoteIdent(a.Table), dbutil.DuckDB.QuoteIdent(a.Column)
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT DISTINCT %s FROM %s USING SAMPLE %d ROWS", column, table, samples))
+	if err != nil {
+		return 0, fmt.Errorf("failed to sample %s.%s: %w", a.Table, a.Column, err)
+	}
+	var values []interface{}
+	for rows.Next() {
+		var v interface{}
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		values = append(values, v)
+	}
+	rows.Close()
+	if len(values) == 0 {
+		return 0, nil
+	}
+	if a.Pattern == EqualityFilter {
+		values = values[:1]
+	}
+
+	query := fmt.Sprintf("SELECT count(*) FROM %s WHERE %s IN (%s)", table, column, dbutil.Placeholders(len(values)))
+	start := time.Now()
+	var n int64
+	if err := db.QueryRowContext(ctx, query, values...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to time lookup on %s.%s: %w", a.Table, a.Column, err)
+	}
+	return time.Since(start), nil
+}
+
+// relationKind returns "table", "view", or "" when name does not exist.
+func relationKind(ctx context.Context, db *sql.DB, name string) (string, error) {
+	schema, table := splitName(name)
+	var kind string
+	err := db.QueryRowContext(ctx, `
+		SELECT 'table' FROM duckdb_tables() WHERE schema_name = ? AND table_name = ?
+		UNION ALL
+		SELECT 'view' FROM duckdb_views() WHERE schema_name = ? AND view_name = ?
+		LIMIT 1`, schema, table, schema, table).Scan(&kind)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect %s: %w", name, err)
+	}
+	return kind, nil
+}
+
+// hasIndexOn reports whether an index on table has column as its leading expression.
+func hasIndexOn(ctx context.Context, db *sql.DB, name, column string) (bool, error) {
+	schema, table := splitName(name)
+	rows, err := db.QueryContext(ctx,
+		"SELECT expressions FROM duckdb_indexes() WHERE schema_name = ? AND table_name = ?", schema, table)
+	if err != nil {
+		return false, fmt.Errorf("failed to list indexes on %s: %w", name, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var expressions string
+		if err := rows.Scan(&expressions); err != nil {
+			return false, err
+		}
+		// expressions is rendered as a list literal such as [rsid] or ['"rsid"'].
+		first := strings.Split(strings.Trim(expressions, "[]"), ",")[0]
+		if strings.EqualFold(strings.Trim(first, `'" `), column) {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// isSortedBy reports whether the table's rows are stored in ascending column order.
+func isSortedBy(ctx context.Context, db *sql.DB, name, column string) (bool, error) {
+	col := dbutil.DuckDB.QuoteIdent(column)
+	query := fmt.Sprintf(
+		"SELECT count(*) FROM (SELECT %s AS v, lag(%s) OVER (ORDER BY rowid) AS prev FROM %s) WHERE prev > v",
+		col, col, dbutil.DuckDB.QuoteIdent(name))
+	var outOfOrder int64
+	if err := db.QueryRowContext(ctx, query).Scan(&outOfOrder); err != nil {
+		return false, fmt.Errorf("failed to check ordering of %s by %s: %w", name, column, err)
+	}
+	return outOfOrder == 0, nil
+}
+
+// splitName splits schema.table, defaulting the schema to main.
+func splitName(name string) (schema, table string) {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "main", name
+}
+
+func indexName(table, column string) string {
+	return "idx_" + strings.ReplaceAll(table, ".", "_") + "_" + column
+}
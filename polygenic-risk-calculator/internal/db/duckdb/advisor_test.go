@@ -0,0 +1,66 @@
+package duckdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/db/dbutil"
+)
+
+func TestAdviseAndApply(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenDB(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.ExecContext(ctx, `
+		CREATE TABLE models AS SELECT 'rs' || i AS rsid, CASE WHEN i % 2 = 0 THEN 'Height' ELSE 'BMI' END AS trait, i * 0.01 AS beta
+		FROM range(100) t(i);
+		CREATE TABLE gwas AS SELECT * FROM models;
+		CREATE VIEW models_view AS SELECT * FROM models;`)
+	require.NoError(t, err)
+
+	accesses := []Access{
+		{Table: "models", Column: "trait", Pattern: EqualityFilter},
+		{Table: "gwas", Column: "rsid", Pattern: InListFilter},
+	}
+	recs, err := Advise(ctx, db, accesses)
+	require.NoError(t, err)
+	require.Len(t, recs, 3)
+	assert.Equal(t, RecommendSort, recs[0].Kind, "sorting runs before indexing")
+	assert.Equal(t, `CREATE OR REPLACE TABLE models AS SELECT * FROM models ORDER BY trait`, recs[0].Statement)
+	assert.Equal(t, `CREATE INDEX idx_models_trait ON models (trait)`, recs[1].Statement)
+	assert.Equal(t, `CREATE INDEX idx_gwas_rsid ON gwas (rsid)`, recs[2].Statement)
+	for _, r := range recs {
+		assert.False(t, r.Satisfied)
+	}
+
+	before, err := TimeAccess(ctx, db, accesses[1], 10)
+	require.NoError(t, err)
+	assert.Positive(t, before)
+
+	require.NoError(t, Apply(ctx, db, recs))
+
+	recs, err = Advise(ctx, db, accesses)
+	require.NoError(t, err)
+	for _, r := range recs {
+		assert.True(t, r.Satisfied, "%s on %s.%s", r.Kind, r.Access.Table, r.Access.Column)
+		assert.Empty(t, r.Statement)
+	}
+	var n int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT count(*) FROM models").Scan(&n))
+	assert.Equal(t, 100, n, "sorting keeps every row")
+
+	recs, err = Advise(ctx, db, []Access{{Table: "models_view", Column: "trait", Pattern: EqualityFilter}})
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Empty(t, recs[0].Statement)
+	assert.Contains(t, recs[0].Reason, "view")
+
+	_, err = Advise(ctx, db, []Access{{Table: "missing", Column: "trait", Pattern: EqualityFilter}})
+	assert.ErrorContains(t, err, "does not exist")
+	_, err = Advise(ctx, db, []Access{{Table: "models; DROP TABLE gwas", Column: "trait", Pattern: EqualityFilter}})
+	assert.ErrorIs(t, err, dbutil.ErrInvalidIdentifier)
+}
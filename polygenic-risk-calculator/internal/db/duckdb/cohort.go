@@ -0,0 +1,58 @@
+package duckdb
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/db/dbutil"
+	dbinterface "phite.io/polygenic-risk-calculator/internal/db/interface"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// CohortRequiredColumns are the columns a custom cohort frequency table must provide,
+// in addition to its allele frequency column.
+var CohortRequiredColumns = []string{"chrom", "pos", "ref", "alt"}
+
+// OpenCohort opens a user-provided cohort allele frequency table as a repository.
+// DuckDB files are opened directly and must contain table. CSV/TSV files are loaded
+// into an in-memory DuckDB and exposed as a view named table, so callers query both
+// sources the same way.
+func OpenCohort(ctx context.Context, path, table string) (dbinterface.Repository, error) {
+	if path == "" {
+		return nil, fmt.Errorf("cohort path is required")
+	}
+	if table == "" {
+		return nil, fmt.Errorf("cohort table name is required")
+	}
+	if err := dbutil.ValidateIdent(table); err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".duckdb", ".db":
+		db, err := OpenDB(path)
+		if err != nil {
+			return nil, err
+		}
+		return NewRepository(db), nil
+	case ".csv", ".tsv", ".txt":
+		db, err := OpenDB(":memory:")
+		if err != nil {
+			return nil, err
+		}
+		// DuckDB cannot bind parameters in DDL, so the path is quoted as a string literal.
+		// chrom is read as text so "1" and "X" compare the same way as in gnomAD tables.
+		stmt := fmt.Sprintf("CREATE VIEW %s AS SELECT * FROM read_csv_auto(%s, header = true, types = {'chrom': 'VARCHAR'})",
+			dbutil.DuckDB.QuoteIdent(table), dbutil.QuoteString(path))
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to load cohort file %s: %w", path, err)
+		}
+		logging.Info("Loaded cohort allele frequencies from %s as view %s", path, table)
+		return NewRepository(db), nil
+	default:
+		return nil, fmt.Errorf("unsupported cohort file type %q: expected .csv, .tsv, or .duckdb", filepath.Ext(path))
+	}
+}
@@ -0,0 +1,36 @@
+package duckdb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenCohort_CSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cohort.csv")
+	require.NoError(t, os.WriteFile(path, []byte("chrom,pos,ref,alt,af\n1,100,A,G,0.25\n2,200,C,T,0.5\n"), 0644))
+
+	repo, err := OpenCohort(context.Background(), path, "cohort_af")
+	require.NoError(t, err)
+	require.NoError(t, repo.ValidateTable(context.Background(), "cohort_af", append(CohortRequiredColumns, "af")))
+
+	rows, err := repo.Query(context.Background(), "SELECT chrom, pos, ref, alt, af FROM cohort_af WHERE (chrom = ? AND pos = ?)", "1", 100)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.InDelta(t, 0.25, rows[0]["af"], 1e-9)
+}
+
+func TestOpenCohort_Errors(t *testing.T) {
+	_, err := OpenCohort(context.Background(), "", "cohort_af")
+	assert.Error(t, err)
+
+	_, err = OpenCohort(context.Background(), "freqs.parquet", "cohort_af")
+	assert.ErrorContains(t, err, "unsupported cohort file type")
+
+	_, err = OpenCohort(context.Background(), filepath.Join(t.TempDir(), "missing.csv"), "cohort_af")
+	assert.ErrorContains(t, err, "failed to load cohort file")
+}
@@ -5,8 +5,12 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
+	"phite.io/polygenic-risk-calculator/internal/db/dbutil"
 	dbinterface "phite.io/polygenic-risk-calculator/internal/db/interface"
+	"phite.io/polygenic-risk-calculator/internal/db/querylog"
+	"phite.io/polygenic-risk-calculator/internal/events"
 	"phite.io/polygenic-risk-calculator/internal/logging"
 )
 
@@ -20,8 +24,49 @@ func NewRepository(db *sql.DB) dbinterface.Repository {
 	return &Repository{db: db}
 }
 
-// Query executes a SQL query and returns the results as a slice of maps
+// Dialect reports that queries are DuckDB SQL.
+func (r *Repository) Dialect() dbutil.Dialect {
+	return dbutil.DuckDB
+}
+
+// Query executes a SQL query and returns the results as a slice of maps. Each query is
+// published as a QueryExecuted event on the context's event bus.
 func (r *Repository) Query(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	start := time.Now()
+	results, err := r.query(ctx, query, args...)
+	d := time.Since(start)
+	events.QueryDone(ctx, "duckdb", query, len(results), d, err)
+	if querylog.Enabled() {
+		querylog.Done("duckdb", query, args, len(results), d, err, r.explain(ctx, query, args...))
+	}
+	return results, err
+}
+
+// explain returns the physical plan of a SELECT query for the query log, or "" for other
+// statements or when EXPLAIN fails.
+func (r *Repository) explain(ctx context.Context, query string, args ...interface{}) string {
+	if !querylog.Explainable(query) {
+		return ""
+	}
+	rows, err := r.db.QueryContext(ctx, "EXPLAIN "+query, args...)
+	if err != nil {
+		logging.Debug("EXPLAIN failed: %v", err)
+		return ""
+	}
+	defer rows.Close()
+	var plan strings.Builder
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			logging.Debug("EXPLAIN failed: %v", err)
+			return ""
+		}
+		plan.WriteString(value)
+	}
+	return plan.String()
+}
+
+func (r *Repository) query(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
 	logging.Debug("Executing DuckDB query with %d args: %s", len(args), query)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
@@ -82,7 +127,6 @@ func (r *Repository) Query(ctx context.Context, query string, args ...interface{
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	logging.Debug("Successfully executed query and scanned %d rows for query: %s", len(results), query)
 	return results, nil
 }
 
@@ -100,19 +144,11 @@ func (r *Repository) Insert(ctx context.Context, table string, rows []map[string
 		columns = append(columns, col)
 	}
 
-	// Build the INSERT statement
-	placeholders := make([]string, len(columns))
-	for i := range columns {
-		placeholders[i] = "?"
+	query, err := dbutil.DuckDB.Insert(table, columns)
+	if err != nil {
+		return err
 	}
 
-	query := fmt.Sprintf(
-		"INSERT INTO %s (%s) VALUES (%s)",
-		table,
-		strings.Join(columns, ", "),
-		strings.Join(placeholders, ", "),
-	)
-
 	// Prepare the statement
 	stmt, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
@@ -145,8 +181,12 @@ func (r *Repository) TestConnection(ctx context.Context, table string) error {
 func (r *Repository) ValidateTable(ctx context.Context, table string, requiredColumns []string) error {
 	logging.Info("Validating table %q for required columns", table)
 
+	if err := dbutil.ValidateIdent(table); err != nil {
+		return err
+	}
+
 	// Check if table exists
-	query := fmt.Sprintf("SELECT * FROM %s LIMIT 0", table)
+	query := fmt.Sprintf("SELECT * FROM %s LIMIT 0", dbutil.DuckDB.QuoteIdent(table))
 	if _, err := r.db.QueryContext(ctx, query); err != nil {
 		return fmt.Errorf("table %q does not exist", table)
 	}
@@ -158,7 +198,7 @@ func (r *Repository) ValidateTable(ctx context.Context, table string, requiredCo
 	}
 
 	// Get table columns
-	rows, err := r.db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", dbutil.QuoteString(table)))
 	if err != nil {
 		return fmt.Errorf("failed to get table info: %w", err)
 	}
@@ -2,11 +2,16 @@ package duckdb
 
 import (
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	dbinterface "phite.io/polygenic-risk-calculator/internal/db/interface"
+	"phite.io/polygenic-risk-calculator/internal/db/querylog"
 )
 
 // testRecord represents a test table record
@@ -139,3 +144,29 @@ func TestRepository_ValidateTable(t *testing.T) {
 	err = repo.ValidateTable(context.Background(), "nonexistent", nil)
 	require.Error(t, err)
 }
+
+func TestRepository_Query_RecordsQueryLogWithPlan(t *testing.T) {
+	repo := setupTestDB(t)
+	path := filepath.Join(t.TempDir(), "queries.jsonl")
+	l, err := querylog.Start(path)
+	require.NoError(t, err)
+
+	_, err = repo.Query(context.Background(), "INSERT INTO test_records VALUES (1, 'a', 1.0), (2, 'b', 2.0)")
+	require.NoError(t, err)
+	_, err = repo.Query(context.Background(), "SELECT * FROM test_records WHERE id > ?", 1)
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	var insert, sel querylog.Entry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &insert))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &sel))
+	assert.Empty(t, insert.Explain)
+	assert.Equal(t, "duckdb", sel.Backend)
+	assert.Equal(t, []interface{}{float64(1)}, sel.Args)
+	assert.Equal(t, 1, sel.Rows)
+	assert.Contains(t, sel.Explain, "test_records")
+}
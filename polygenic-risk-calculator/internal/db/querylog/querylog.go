@@ -0,0 +1,140 @@
+// Package querylog records every database query of a run — statement, parameters,
+// execution time, row count, and for DuckDB the EXPLAIN plan — to a JSON-lines file, for
+// query tuning and support diagnostics. It is off unless a log is opened with Start.
+package querylog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+// Domain-specific configuration keys for the query log
+const (
+	PathKey = "db.query_log" // File, or existing directory for one file per run, receiving every query; overridden by --query-log
+)
+
+// Entry is one line of the query log.
+type Entry struct {
+	Time       time.Time     `json:"time"`
+	Backend    string        `json:"backend"` // "bigquery" or "duckdb"
+	Query      string        `json:"query"`
+	Args       []interface{} `json:"args,omitempty"`
+	DurationMS float64       `json:"duration_ms"`
+	Rows       int           `json:"rows"`
+	Error      string        `json:"error,omitempty"`
+	Explain    string        `json:"explain,omitempty"` // DuckDB physical plan of the statement
+}
+
+// Log appends entries to a file. It is safe for concurrent use.
+type Log struct {
+	mu   sync.Mutex
+	f    *os.File
+	enc  *json.Encoder
+	path string
+}
+
+var (
+	mu      sync.RWMutex
+	current *Log
+)
+
+// Start opens the query log at path and makes it the log queries are recorded to. When path
+// is an existing directory, a file named after the start time is created in it, so each run
+// gets its own log. An empty path falls back to PathKey; when both are empty, Start does
+// nothing and returns a nil Log. Close the returned log when the run ends.
+func Start(path string) (*Log, error) {
+	if path == "" {
+		path = config.GetString(PathKey)
+	}
+	if path == "" {
+		return nil, nil
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "queries-"+time.Now().UTC().Format("20060102T150405.000Z")+".jsonl")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open query log: %w", err)
+	}
+	l := &Log{f: f, enc: json.NewEncoder(f), path: path}
+	mu.Lock()
+	current = l
+	mu.Unlock()
+	return l, nil
+}
+
+// Path returns the file the log writes to.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Close stops recording to l and closes its file.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	mu.Lock()
+	if current == l {
+		current = nil
+	}
+	mu.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+// Enabled reports whether a query log is open, so backends can skip extra work such as
+// fetching a plan when it is not.
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current != nil
+}
+
+// Record appends a query to the open log; without one it does nothing. Write errors are
+// ignored: a diagnostic log must not fail the query it describes.
+func Record(e Entry) {
+	mu.RLock()
+	l := current
+	mu.RUnlock()
+	if l == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_ = l.enc.Encode(e)
+}
+
+// Done records a query that finished after running for d.
+func Done(backend, query string, args []interface{}, rows int, d time.Duration, err error, explain string) {
+	e := Entry{
+		Time:       time.Now().Add(-d).UTC(),
+		Backend:    backend,
+		Query:      strings.TrimSpace(query),
+		Args:       args,
+		DurationMS: float64(d.Microseconds()) / 1000,
+		Rows:       rows,
+		Explain:    explain,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	Record(e)
+}
+
+// Explainable reports whether query is a statement EXPLAIN accepts and that is worth a plan:
+// a SELECT or WITH query. DDL, inserts, and PRAGMAs are logged without one.
+func Explainable(query string) bool {
+	q := strings.ToUpper(strings.TrimSpace(query))
+	return strings.HasPrefix(q, "SELECT") || strings.HasPrefix(q, "WITH")
+}
@@ -0,0 +1,80 @@
+package querylog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readEntries(t *testing.T, path string) []Entry {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var entries []Entry
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var e Entry
+		require.NoError(t, json.Unmarshal(s.Bytes(), &e))
+		entries = append(entries, e)
+	}
+	require.NoError(t, s.Err())
+	return entries
+}
+
+func TestStart_RecordsQueriesUntilClosed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.jsonl")
+	assert.False(t, Enabled())
+	Done("duckdb", "SELECT 1", nil, 1, time.Millisecond, nil, "") // dropped: no log open
+
+	l, err := Start(path)
+	require.NoError(t, err)
+	assert.True(t, Enabled())
+	Done("duckdb", " SELECT * FROM t WHERE id = ? ", []interface{}{int64(7)}, 3, 1500*time.Microsecond, nil, "SEQ_SCAN")
+	Done("bigquery", "SELECT x", nil, 0, time.Second, errors.New("boom"), "")
+	require.NoError(t, l.Close())
+	assert.False(t, Enabled())
+	Done("duckdb", "SELECT 2", nil, 1, time.Millisecond, nil, "") // dropped: closed
+
+	entries := readEntries(t, path)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "duckdb", entries[0].Backend)
+	assert.Equal(t, "SELECT * FROM t WHERE id = ?", entries[0].Query)
+	assert.Equal(t, []interface{}{float64(7)}, entries[0].Args)
+	assert.Equal(t, 1.5, entries[0].DurationMS)
+	assert.Equal(t, 3, entries[0].Rows)
+	assert.Equal(t, "SEQ_SCAN", entries[0].Explain)
+	assert.Equal(t, "boom", entries[1].Error)
+	assert.Equal(t, 1000.0, entries[1].DurationMS)
+}
+
+func TestStart_DirectoryGetsPerRunFile(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Start(dir)
+	require.NoError(t, err)
+	defer l.Close()
+	assert.Equal(t, dir, filepath.Dir(l.Path()))
+	assert.Regexp(t, `^queries-\d{8}T\d{6}\.\d{3}Z\.jsonl$`, filepath.Base(l.Path()))
+}
+
+func TestStart_EmptyPathIsDisabled(t *testing.T) {
+	l, err := Start("")
+	require.NoError(t, err)
+	assert.Nil(t, l)
+	assert.False(t, Enabled())
+	assert.NoError(t, l.Close())
+}
+
+func TestExplainable(t *testing.T) {
+	assert.True(t, Explainable("  select 1"))
+	assert.True(t, Explainable("WITH a AS (SELECT 1) SELECT * FROM a"))
+	assert.False(t, Explainable("CREATE TABLE t (id INTEGER)"))
+	assert.False(t, Explainable("INSERT INTO t VALUES (1)"))
+}
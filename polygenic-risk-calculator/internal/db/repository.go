@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"phite.io/polygenic-risk-calculator/internal/buildinfo"
 	"phite.io/polygenic-risk-calculator/internal/config"
 	"phite.io/polygenic-risk-calculator/internal/db/bq"
 	"phite.io/polygenic-risk-calculator/internal/db/duckdb"
@@ -11,12 +12,11 @@ import (
 )
 
 func init() {
-	// Register required infrastructure configuration keys for repository creation
-	config.RegisterRequiredKey(config.GCPDataProjectKey)        // For BigQuery data project
-	config.RegisterRequiredKey(config.GCPBillingProjectKey)     // For BigQuery billing project
-	config.RegisterRequiredKey(config.BigQueryGnomadDatasetKey) // For BigQuery dataset fallback
-
-	// Note: DuckDB fallback path would be domain-specific if implemented
+	// BigQuery keys are only needed by the repositories that query BigQuery, so their
+	// callers check them when creating one rather than registering them here.
+	for name := range constructors {
+		buildinfo.RegisterBackend(name)
+	}
 }
 
 // RepositoryConstructor is a function type for creating new repository instances
@@ -25,6 +25,7 @@ type RepositoryConstructor func(ctx context.Context, params map[string]string) (
 var constructors = map[string]RepositoryConstructor{
 	"duckdb": newDuckDBRepository,
 	"bq":     newBQRepository,
+	"cohort": newCohortRepository,
 }
 
 // GetRepository creates a repository instance of the specified type with optional parameters
@@ -67,6 +68,16 @@ func newDuckDBRepository(ctx context.Context, params map[string]string) (dbinter
 	return duckdb.NewRepository(db), nil
 }
 
+// newCohortRepository creates a repository over a user-provided cohort allele frequency
+// table (CSV/TSV or DuckDB) exposed under the given table name.
+func newCohortRepository(ctx context.Context, params map[string]string) (dbinterface.Repository, error) {
+	path := params["path"]
+	if path == "" {
+		return nil, fmt.Errorf("path parameter is required for cohort repository")
+	}
+	return duckdb.OpenCohort(ctx, path, params["table"])
+}
+
 // newBQRepository creates a new BigQuery repository instance
 func newBQRepository(ctx context.Context, params map[string]string) (dbinterface.Repository, error) {
 	// Use params if provided, otherwise fall back to infrastructure config
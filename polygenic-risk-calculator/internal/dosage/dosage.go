@@ -0,0 +1,172 @@
+// Package dosage provides pluggable strategies for coding a genotype call into the
+// dosage value used by PRS scoring (additive, probabilistic, dominant, recessive).
+package dosage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// Domain-specific configuration keys for dosage coding
+const (
+	StrategyKey      = "dosage.strategy"         // Default strategy name (additive if unset)
+	TraitStrategyKey = "dosage.trait_strategies" // Per-model overrides: model ID (the trait unless the trait model map names another) -> strategy name
+)
+
+// Call is a single genotype observation for one variant.
+type Call struct {
+	Genotype     string    // two-base hard call, e.g. "AG"
+	EffectAllele string    // allele carrying the effect weight
+	Probs        []float64 // optional P(0), P(1), P(2) copies of the effect allele
+}
+
+// EffectAlleleCount returns the number of effect-allele copies in the hard call.
+// Missing or malformed genotypes yield 0.
+func (c Call) EffectAlleleCount() int {
+	if len(c.Genotype) != 2 || c.EffectAllele == "" {
+		return 0
+	}
+	count := 0
+	for i := 0; i < 2; i++ {
+		if string(c.Genotype[i]) == c.EffectAllele {
+			count++
+		}
+	}
+	return count
+}
+
+// Strategy codes a genotype call into a scoring dosage.
+type Strategy interface {
+	Name() string
+	Dosage(call Call) float64
+	// Levels returns the dosage of hard calls with 0, 1, and 2 effect-allele copies, from
+	// which the reference distribution of the score is derived.
+	Levels() [3]float64
+}
+
+// Additive counts effect-allele copies (0, 1, 2). This is the standard hard-call coding.
+type Additive struct{}
+
+func (Additive) Name() string { return "additive" }
+
+func (Additive) Dosage(call Call) float64 { return float64(call.EffectAlleleCount()) }
+
+func (Additive) Levels() [3]float64 { return [3]float64{0, 1, 2} }
+
+// Probabilistic uses the expected dosage P(1) + 2*P(2) when genotype probabilities are
+// available, and falls back to the additive hard call otherwise.
+type Probabilistic struct{}
+
+func (Probabilistic) Name() string { return "probabilistic" }
+
+func (Probabilistic) Dosage(call Call) float64 {
+	if len(call.Probs) == 3 {
+		return call.Probs[1] + 2*call.Probs[2]
+	}
+	return Additive{}.Dosage(call)
+}
+
+// Levels are additive: the expected dosage of a reference population is its expected
+// effect-allele count.
+func (Probabilistic) Levels() [3]float64 { return Additive{}.Levels() }
+
+// Dominant codes carriers of at least one effect allele as 1.
+type Dominant struct{}
+
+func (Dominant) Name() string { return "dominant" }
+
+func (Dominant) Dosage(call Call) float64 {
+	if call.EffectAlleleCount() >= 1 {
+		return 1
+	}
+	return 0
+}
+
+func (Dominant) Levels() [3]float64 { return [3]float64{0, 1, 1} }
+
+// Recessive codes only homozygous effect-allele carriers as 1.
+type Recessive struct{}
+
+func (Recessive) Name() string { return "recessive" }
+
+func (Recessive) Dosage(call Call) float64 {
+	if call.EffectAlleleCount() == 2 {
+		return 1
+	}
+	return 0
+}
+
+func (Recessive) Levels() [3]float64 { return [3]float64{0, 0, 1} }
+
+var strategies = map[string]Strategy{
+	"additive":      Additive{},
+	"hard_call":     Additive{},
+	"probabilistic": Probabilistic{},
+	"dominant":      Dominant{},
+	"recessive":     Recessive{},
+}
+
+// Get returns the strategy registered under name (case-insensitive).
+func Get(name string) (Strategy, error) {
+	s, ok := strategies[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("unknown dosage strategy %q (supported: %s)", name, strings.Join(Names(), ", "))
+	}
+	return s, nil
+}
+
+// Names returns the registered strategy names in sorted order.
+func Names() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Selector picks the dosage strategy for a given model.
+type Selector struct {
+	Default  Strategy
+	PerModel map[string]Strategy // lower-cased model ID -> strategy
+}
+
+// NewSelectorFromConfig builds a Selector from the dosage configuration keys.
+func NewSelectorFromConfig() (*Selector, error) {
+	sel := &Selector{Default: Additive{}, PerModel: make(map[string]Strategy)}
+	if name := config.GetString(StrategyKey); name != "" {
+		s, err := Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", StrategyKey, err)
+		}
+		sel.Default = s
+	}
+	for modelID, name := range config.GetStringMapString(TraitStrategyKey) {
+		s, err := Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", TraitStrategyKey, modelID, err)
+		}
+		sel.PerModel[strings.ToLower(modelID)] = s
+	}
+	logging.Debug("Dosage strategy: default=%s, %d per-model overrides", sel.Default.Name(), len(sel.PerModel))
+	return sel, nil
+}
+
+// For returns the strategy for the given model ID. A nil Selector yields Additive.
+func (s *Selector) For(modelID string) Strategy {
+	if s == nil {
+		return Additive{}
+	}
+	// PerModel is keyed by lower-cased model ID, so lookups are case-insensitive.
+	if st, ok := s.PerModel[strings.ToLower(modelID)]; ok {
+		return st
+	}
+	if s.Default != nil {
+		return s.Default
+	}
+	return Additive{}
+}
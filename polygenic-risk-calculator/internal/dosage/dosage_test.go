@@ -0,0 +1,176 @@
+package dosage
+
+import (
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+func TestStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy Strategy
+		call     Call
+		want     float64
+	}{
+		{"additive hom", Additive{}, Call{Genotype: "GG", EffectAllele: "G"}, 2},
+		{"additive het", Additive{}, Call{Genotype: "AG", EffectAllele: "G"}, 1},
+		{"additive none", Additive{}, Call{Genotype: "AA", EffectAllele: "G"}, 0},
+		{"dominant het", Dominant{}, Call{Genotype: "AG", EffectAllele: "G"}, 1},
+		{"dominant hom", Dominant{}, Call{Genotype: "GG", EffectAllele: "G"}, 1},
+		{"dominant none", Dominant{}, Call{Genotype: "AA", EffectAllele: "G"}, 0},
+		{"recessive het", Recessive{}, Call{Genotype: "AG", EffectAllele: "G"}, 0},
+		{"recessive hom", Recessive{}, Call{Genotype: "GG", EffectAllele: "G"}, 1},
+		{"probabilistic with probs", Probabilistic{}, Call{Genotype: "AG", EffectAllele: "G", Probs: []float64{0.1, 0.6, 0.3}}, 1.2},
+		{"probabilistic falls back to hard call", Probabilistic{}, Call{Genotype: "GG", EffectAllele: "G"}, 2},
+		{"malformed genotype", Additive{}, Call{Genotype: "G", EffectAllele: "G"}, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.InDelta(t, tc.want, tc.strategy.Dosage(tc.call), 1e-9)
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	s, err := Get("Dominant")
+	require.NoError(t, err)
+	assert.Equal(t, "dominant", s.Name())
+
+	s, err = Get("hard_call")
+	require.NoError(t, err)
+	assert.Equal(t, "additive", s.Name())
+
+	_, err = Get("multiplicative")
+	assert.ErrorContains(t, err, "unknown dosage strategy")
+}
+
+func TestSelector_For(t *testing.T) {
+	var nilSel *Selector
+	assert.Equal(t, "additive", nilSel.For("height").Name())
+
+	sel := &Selector{Default: Additive{}, PerModel: map[string]Strategy{"celiac": Recessive{}}}
+	assert.Equal(t, "recessive", sel.For("Celiac").Name())
+	assert.Equal(t, "additive", sel.For("height").Name())
+}
+
+func TestNewSelectorFromConfig(t *testing.T) {
+	config.Set(StrategyKey, "probabilistic")
+	config.Set(TraitStrategyKey, map[string]string{"celiac": "recessive"})
+	defer config.Set(StrategyKey, "")
+	defer config.Set(TraitStrategyKey, map[string]string{})
+
+	sel, err := NewSelectorFromConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "probabilistic", sel.For("height").Name())
+	assert.Equal(t, "recessive", sel.For("celiac").Name())
+
+	config.Set(StrategyKey, "bogus")
+	_, err = NewSelectorFromConfig()
+	assert.ErrorContains(t, err, StrategyKey)
+}
+
+// Property tests. Calls are generated over the four bases plus the no-call and indel codes
+// found in consumer genotype files, so malformed calls are exercised alongside valid ones.
+
+var callAlphabet = []byte("ACGT-0ID")
+
+// quickCall builds a call from generated bytes.
+func quickCall(g0, g1, effect uint8) Call {
+	n := uint8(len(callAlphabet))
+	return Call{
+		Genotype:     string([]byte{callAlphabet[g0%n], callAlphabet[g1%n]}),
+		EffectAllele: string(callAlphabet[effect%n]),
+	}
+}
+
+var complements = map[byte]byte{'A': 'T', 'T': 'A', 'C': 'G', 'G': 'C'}
+
+// flip relabels every base by its strand complement; other codes are kept.
+func flip(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if comp, ok := complements[c]; ok {
+			b[i] = comp
+		}
+	}
+	return string(b)
+}
+
+func TestProperty_DosageRange(t *testing.T) {
+	prop := func(g0, g1, effect uint8) bool {
+		call := quickCall(g0, g1, effect)
+		n := call.EffectAlleleCount()
+		add, dom, rec := Additive{}.Dosage(call), Dominant{}.Dosage(call), Recessive{}.Dosage(call)
+		return n >= 0 && n <= 2 &&
+			add == float64(n) &&
+			(dom == 0 || dom == 1) && (rec == 0 || rec == 1) &&
+			rec <= dom && dom <= add
+	}
+	require.NoError(t, quick.Check(prop, nil))
+}
+
+func TestProperty_LevelsMatchHardCalls(t *testing.T) {
+	prop := func(g0, g1, effect uint8) bool {
+		call := quickCall(g0, g1, effect)
+		for _, s := range strategies {
+			if s.Levels()[call.EffectAlleleCount()] != s.Dosage(call) {
+				return false
+			}
+		}
+		return true
+	}
+	require.NoError(t, quick.Check(prop, nil))
+}
+
+func TestProperty_GenotypeOrderInvariance(t *testing.T) {
+	prop := func(g0, g1, effect uint8) bool {
+		call := quickCall(g0, g1, effect)
+		swapped := Call{Genotype: string([]byte{call.Genotype[1], call.Genotype[0]}), EffectAllele: call.EffectAllele}
+		return call.EffectAlleleCount() == swapped.EffectAlleleCount()
+	}
+	require.NoError(t, quick.Check(prop, nil))
+}
+
+func TestProperty_StrandFlip(t *testing.T) {
+	prop := func(g0, g1, effect uint8) bool {
+		call := quickCall(g0, g1, effect)
+		flipped := Call{Genotype: flip(call.Genotype), EffectAllele: flip(call.EffectAllele)}
+		// flip is an involution, and relabeling call and effect allele together keeps the dosage
+		return flip(flip(call.Genotype)) == call.Genotype &&
+			flipped.EffectAlleleCount() == call.EffectAlleleCount()
+	}
+	require.NoError(t, quick.Check(prop, nil))
+}
+
+func TestProperty_EffectAlleleSwap(t *testing.T) {
+	// At a biallelic site, the copies of either allele add up to two
+	prop := func(a, b, g0, g1 uint8) bool {
+		bases := "ACGT"
+		alleles := [2]byte{bases[a%4], bases[b%4]}
+		if alleles[0] == alleles[1] {
+			return true
+		}
+		genotype := string([]byte{alleles[g0%2], alleles[g1%2]})
+		effect := Call{Genotype: genotype, EffectAllele: string(alleles[0])}
+		other := Call{Genotype: genotype, EffectAllele: string(alleles[1])}
+		return effect.EffectAlleleCount()+other.EffectAlleleCount() == 2
+	}
+	require.NoError(t, quick.Check(prop, nil))
+}
+
+func TestProperty_ProbabilisticDosageRange(t *testing.T) {
+	prop := func(p0, p1, p2 uint16) bool {
+		total := float64(p0) + float64(p1) + float64(p2)
+		if total == 0 {
+			return true
+		}
+		probs := []float64{float64(p0) / total, float64(p1) / total, float64(p2) / total}
+		d := Probabilistic{}.Dosage(Call{Genotype: "AG", EffectAllele: "G", Probs: probs})
+		return d >= 0 && d <= 2+1e-12
+	}
+	require.NoError(t, quick.Check(prop, nil))
+}
@@ -0,0 +1,216 @@
+// Package events is an in-process event bus for pipeline extensions. The pipeline and
+// the database layer publish events — a trait scored, a reference stats cache miss, a
+// query executed, a run completed — and subsystems such as progress reporting, logging,
+// metrics, audit trails, or webhooks subscribe to the ones they need, without the
+// publishers knowing about them.
+//
+// The bus for a run travels in its context (WithBus), so code deep in the call stack can
+// publish with Publish(ctx, ...) without threading the bus through every signature.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/progress"
+)
+
+// Type identifies an event.
+type Type string
+
+// Event types.
+const (
+	PhaseStarted   Type = "phase_started"   // Phase, PhaseName
+	PhaseCompleted Type = "phase_completed" // Phase, PhaseName
+	TraitScored    Type = "trait_scored"    // Trait, Completed, Total; also published for skipped traits
+	CacheMiss      Type = "cache_miss"      // Trait, Ancestry, Model
+	ModelLoading   Type = "model_loading"   // Trait, Completed model rows; published per page of a paged model load
+	QueryExecuted  Type = "query_executed"  // Backend, Query, Rows, Duration; Error on failure
+	RunCompleted   Type = "run_completed"
+	RunFailed      Type = "run_failed" // Error
+)
+
+// Event is one occurrence published on the bus. Fields beyond Type and Time are set as
+// listed for each type.
+type Event struct {
+	Type      Type          `json:"type"`
+	Time      time.Time     `json:"time"`
+	Phase     int           `json:"phase,omitempty"`
+	PhaseName string        `json:"phase_name,omitempty"`
+	Trait     string        `json:"trait,omitempty"`
+	Ancestry  string        `json:"ancestry,omitempty"`
+	Model     string        `json:"model,omitempty"`
+	Completed int           `json:"completed,omitempty"`
+	Total     int           `json:"total,omitempty"`
+	Backend   string        `json:"backend,omitempty"` // "bigquery" or "duckdb"
+	Query     string        `json:"query,omitempty"`
+	Rows      int           `json:"rows,omitempty"`
+	Duration  time.Duration `json:"duration,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Handler receives events. Handlers run synchronously on the publishing goroutine, in
+// subscription order, so they should return quickly and hand slow work (e.g. HTTP calls)
+// to their own goroutine.
+type Handler func(Event)
+
+type subscription struct {
+	id      int
+	types   map[Type]bool // nil receives every type
+	handler Handler
+}
+
+// Bus delivers published events to its subscribers. It is safe for concurrent use; a nil
+// Bus logs events and delivers them nowhere else.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   []subscription
+	nextID int
+}
+
+// New returns a bus with the debug log subscribed, so every event is logged once whether
+// or not other subsystems listen.
+func New() *Bus {
+	b := &Bus{}
+	b.Subscribe(Log)
+	return b
+}
+
+// Subscribe registers h for the given event types, or for every type when none are
+// given, and returns a function that removes the subscription.
+func (b *Bus) Subscribe(h Handler, types ...Type) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := subscription{id: b.nextID, handler: h}
+	b.nextID++
+	if len(types) > 0 {
+		s.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+	b.subs = append(b.subs, s)
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, sub := range b.subs {
+			if sub.id == s.id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish stamps ev with the current time, if unset, and delivers it to the matching
+// subscribers. A panicking handler is logged and does not stop delivery or the run.
+func (b *Bus) Publish(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	if b == nil {
+		Log(ev)
+		return
+	}
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+	for _, s := range subs {
+		if s.types == nil || s.types[ev.Type] {
+			deliver(s.handler, ev)
+		}
+	}
+}
+
+func deliver(h Handler, ev Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Error("event handler for %s panicked: %v", ev.Type, r)
+		}
+	}()
+	h(ev)
+}
+
+type contextKey struct{}
+
+// WithBus returns a context carrying b.
+func WithBus(ctx context.Context, b *Bus) context.Context {
+	return context.WithValue(ctx, contextKey{}, b)
+}
+
+// FromContext returns the bus carried by ctx, or nil.
+func FromContext(ctx context.Context) *Bus {
+	if ctx == nil {
+		return nil
+	}
+	b, _ := ctx.Value(contextKey{}).(*Bus)
+	return b
+}
+
+// Publish publishes ev on the bus carried by ctx; without one, ev is only logged.
+func Publish(ctx context.Context, ev Event) {
+	FromContext(ctx).Publish(ev)
+}
+
+// QueryDone publishes a QueryExecuted event for a database query on the bus carried by ctx.
+func QueryDone(ctx context.Context, backend, query string, rows int, d time.Duration, err error) {
+	ev := Event{Type: QueryExecuted, Backend: backend, Query: query, Rows: rows, Duration: d}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	Publish(ctx, ev)
+}
+
+// Log writes an event to the debug log.
+func Log(ev Event) {
+	switch ev.Type {
+	case QueryExecuted:
+		if ev.Error != "" {
+			logging.Debug("%s query failed after %s: %s: %s", ev.Backend, ev.Duration, ev.Error, ev.Query)
+		} else {
+			logging.Debug("%s query returned %d rows in %s: %s", ev.Backend, ev.Rows, ev.Duration, ev.Query)
+		}
+	case CacheMiss:
+		logging.Debug("Reference stats cache miss: %s|%s|%s", ev.Ancestry, ev.Trait, ev.Model)
+	case TraitScored:
+		logging.Debug("Trait %s handled (%d/%d)", ev.Trait, ev.Completed, ev.Total)
+	case ModelLoading:
+		logging.Debug("Trait %s: %d model rows loaded", ev.Trait, ev.Completed)
+	default:
+		logging.Debug("Pipeline event %s %s", ev.Type, ev.PhaseName)
+	}
+}
+
+// Progress returns a handler that forwards phase, trait, and run events to a progress
+// reporter, for streaming to clients.
+func Progress(r progress.Reporter) Handler {
+	return func(ev Event) {
+		var t progress.EventType
+		switch ev.Type {
+		case PhaseStarted:
+			t = progress.PhaseStarted
+		case PhaseCompleted:
+			t = progress.PhaseCompleted
+		case TraitScored:
+			t = progress.TraitCompleted
+		case RunCompleted:
+			t = progress.RunCompleted
+		case RunFailed:
+			t = progress.RunFailed
+		default:
+			return
+		}
+		progress.Emit(r, progress.Event{
+			Type:      t,
+			Phase:     ev.Phase,
+			PhaseName: ev.PhaseName,
+			Trait:     ev.Trait,
+			Completed: ev.Completed,
+			Total:     ev.Total,
+			Error:     ev.Error,
+			Time:      ev.Time,
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/progress"
+)
+
+func TestBus_SubscribeAndPublish(t *testing.T) {
+	b := New()
+	var all, misses []Type
+	unsubscribe := b.Subscribe(func(ev Event) { all = append(all, ev.Type) })
+	b.Subscribe(func(ev Event) { misses = append(misses, ev.Type) }, CacheMiss)
+
+	b.Publish(Event{Type: CacheMiss, Trait: "height"})
+	b.Publish(Event{Type: TraitScored, Trait: "height"})
+	unsubscribe()
+	b.Publish(Event{Type: CacheMiss, Trait: "bmi"})
+
+	assert.Equal(t, []Type{CacheMiss, TraitScored}, all)
+	assert.Equal(t, []Type{CacheMiss, CacheMiss}, misses)
+}
+
+func TestBus_HandlerPanicDoesNotStopDelivery(t *testing.T) {
+	b := New()
+	delivered := false
+	b.Subscribe(func(Event) { panic("boom") })
+	b.Subscribe(func(Event) { delivered = true })
+
+	assert.NotPanics(t, func() { b.Publish(Event{Type: RunCompleted}) })
+	assert.True(t, delivered)
+}
+
+func TestPublish_Context(t *testing.T) {
+	var got Event
+	b := New()
+	b.Subscribe(func(ev Event) { got = ev }, QueryExecuted)
+	ctx := WithBus(context.Background(), b)
+
+	QueryDone(ctx, "duckdb", "SELECT 1", 1, time.Millisecond, errors.New("timeout"))
+	assert.Equal(t, "duckdb", got.Backend)
+	assert.Equal(t, 1, got.Rows)
+	assert.Equal(t, "timeout", got.Error)
+	assert.False(t, got.Time.IsZero())
+
+	// Without a bus the event is only logged
+	assert.Nil(t, FromContext(context.Background()))
+	assert.NotPanics(t, func() { Publish(context.Background(), Event{Type: CacheMiss}) })
+}
+
+func TestProgress(t *testing.T) {
+	var reported []progress.Event
+	b := New()
+	b.Subscribe(Progress(progress.ReporterFunc(func(ev progress.Event) { reported = append(reported, ev) })))
+
+	b.Publish(Event{Type: PhaseStarted, Phase: 1, PhaseName: "requirements_analysis"})
+	b.Publish(Event{Type: CacheMiss, Trait: "height"})
+	b.Publish(Event{Type: TraitScored, Trait: "height", Completed: 1, Total: 1})
+	b.Publish(Event{Type: RunFailed, Error: "boom"})
+
+	require.Len(t, reported, 3, "cache misses are not progress")
+	assert.Equal(t, progress.PhaseStarted, reported[0].Type)
+	assert.Equal(t, progress.TraitCompleted, reported[1].Type)
+	assert.Equal(t, 1, reported[1].Total)
+	assert.Equal(t, progress.RunFailed, reported[2].Type)
+	assert.Equal(t, "boom", reported[2].Error)
+}
@@ -0,0 +1,379 @@
+// Package expr parses and evaluates small arithmetic expressions over named variables,
+// such as "0.5*height_z + 0.5*bmi_z", for user-defined derived metrics.
+//
+// Supported syntax: decimal numbers, identifiers ([A-Za-z_][A-Za-z0-9_]*), the binary
+// operators + - * / ^ (^ is right-associative and binds tightest), unary minus,
+// parentheses, and the functions abs, sqrt, exp, log, min, and max.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrUnknownVariable is wrapped by Eval when a variable has no value.
+var ErrUnknownVariable = errors.New("unknown variable")
+
+// Expr is a parsed expression. It is immutable and safe for concurrent use.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse compiles src into an Expr.
+func Parse(src string) (*Expr, error) {
+	p := &parser{src: src}
+	p.next()
+	root, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %q", p.tok.text)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the source the expression was parsed from.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Vars returns the distinct variables the expression references, sorted.
+func (e *Expr) Vars() []string {
+	seen := make(map[string]struct{})
+	e.root.vars(seen)
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Eval evaluates the expression with the given variable values. A result that is NaN or
+// infinite, e.g. from division by zero, is an error.
+func (e *Expr) Eval(vars map[string]float64) (float64, error) {
+	v, err := e.root.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("%s evaluates to %v", e.src, v)
+	}
+	return v, nil
+}
+
+type node interface {
+	eval(vars map[string]float64) (float64, error)
+	vars(seen map[string]struct{})
+}
+
+type number float64
+
+func (n number) eval(map[string]float64) (float64, error) { return float64(n), nil }
+func (n number) vars(map[string]struct{})                 {}
+
+type variable string
+
+func (v variable) eval(vars map[string]float64) (float64, error) {
+	x, ok := vars[string(v)]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownVariable, string(v))
+	}
+	return x, nil
+}
+func (v variable) vars(seen map[string]struct{}) { seen[string(v)] = struct{}{} }
+
+type unary struct{ operand node }
+
+func (u unary) eval(vars map[string]float64) (float64, error) {
+	x, err := u.operand.eval(vars)
+	return -x, err
+}
+func (u unary) vars(seen map[string]struct{}) { u.operand.vars(seen) }
+
+type binary struct {
+	op          byte
+	left, right node
+}
+
+func (b binary) eval(vars map[string]float64) (float64, error) {
+	x, err := b.left.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	y, err := b.right.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	switch b.op {
+	case '+':
+		return x + y, nil
+	case '-':
+		return x - y, nil
+	case '*':
+		return x * y, nil
+	case '/':
+		return x / y, nil
+	default: // '^'
+		return math.Pow(x, y), nil
+	}
+}
+func (b binary) vars(seen map[string]struct{}) { b.left.vars(seen); b.right.vars(seen) }
+
+type call struct {
+	fn   string
+	args []node
+}
+
+// functions maps each supported function to its arity (0 means one or more).
+var functions = map[string]int{"abs": 1, "sqrt": 1, "exp": 1, "log": 1, "min": 0, "max": 0}
+
+func (c call) eval(vars map[string]float64) (float64, error) {
+	xs := make([]float64, len(c.args))
+	for i, a := range c.args {
+		x, err := a.eval(vars)
+		if err != nil {
+			return 0, err
+		}
+		xs[i] = x
+	}
+	switch c.fn {
+	case "abs":
+		return math.Abs(xs[0]), nil
+	case "sqrt":
+		return math.Sqrt(xs[0]), nil
+	case "exp":
+		return math.Exp(xs[0]), nil
+	case "log":
+		return math.Log(xs[0]), nil
+	case "min":
+		m := xs[0]
+		for _, x := range xs[1:] {
+			m = math.Min(m, x)
+		}
+		return m, nil
+	default: // "max"
+		m := xs[0]
+		for _, x := range xs[1:] {
+			m = math.Max(m, x)
+		}
+		return m, nil
+	}
+}
+func (c call) vars(seen map[string]struct{}) {
+	for _, a := range c.args {
+		a.vars(seen)
+	}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("expression %q at offset %d: %s", p.src, p.tok.pos, fmt.Sprintf(format, args...))
+}
+
+// next advances to the following token. Invalid characters become single-character
+// operator tokens, which the grammar then rejects.
+func (p *parser) next() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: tokEOF, pos: start}
+		return
+	}
+	c := p.src[p.pos]
+	switch {
+	case c >= '0' && c <= '9' || c == '.':
+		for p.pos < len(p.src) && (isDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		// Exponent, e.g. 1e-3
+		if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+			end := p.pos + 1
+			if end < len(p.src) && (p.src[end] == '+' || p.src[end] == '-') {
+				end++
+			}
+			if end < len(p.src) && isDigit(p.src[end]) {
+				for end < len(p.src) && isDigit(p.src[end]) {
+					end++
+				}
+				p.pos = end
+			}
+		}
+		p.tok = token{kind: tokNumber, text: p.src[start:p.pos], pos: start}
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isDigit(p.src[p.pos]) || unicode.IsLetter(rune(p.src[p.pos]))) {
+			p.pos++
+		}
+		p.tok = token{kind: tokIdent, text: p.src[start:p.pos], pos: start}
+	default:
+		p.pos++
+		p.tok = token{kind: tokOp, text: string(c), pos: start}
+	}
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func (p *parser) isOp(ops string) bool {
+	return p.tok.kind == tokOp && strings.Contains(ops, p.tok.text)
+}
+
+// parseSum := product (('+' | '-') product)*
+func (p *parser) parseSum() (node, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+-") {
+		op := p.tok.text[0]
+		p.next()
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseProduct := unary (('*' | '/') unary)*
+func (p *parser) parseProduct() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*/") {
+		op := p.tok.text[0]
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseUnary := '-' unary | power
+func (p *parser) parseUnary() (node, error) {
+	if p.isOp("-") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unary{operand: operand}, nil
+	}
+	return p.parsePower()
+}
+
+// parsePower := primary ('^' unary)?
+func (p *parser) parsePower() (node, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOp("^") {
+		p.next()
+		exponent, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return binary{op: '^', left: base, right: exponent}, nil
+	}
+	return base, nil
+}
+
+// parsePrimary := number | ident | ident '(' args ')' | '(' sum ')'
+func (p *parser) parsePrimary() (node, error) {
+	switch p.tok.kind {
+	case tokNumber:
+		v, err := strconv.ParseFloat(p.tok.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", p.tok.text)
+		}
+		p.next()
+		return number(v), nil
+	case tokIdent:
+		name := p.tok.text
+		p.next()
+		if !p.isOp("(") {
+			return variable(name), nil
+		}
+		return p.parseCall(name)
+	case tokOp:
+		if p.isOp("(") {
+			p.next()
+			inner, err := p.parseSum()
+			if err != nil {
+				return nil, err
+			}
+			if !p.isOp(")") {
+				return nil, p.errorf("expected )")
+			}
+			p.next()
+			return inner, nil
+		}
+		return nil, p.errorf("unexpected %q", p.tok.text)
+	default:
+		return nil, p.errorf("unexpected end of expression")
+	}
+}
+
+func (p *parser) parseCall(name string) (node, error) {
+	arity, ok := functions[name]
+	if !ok {
+		return nil, p.errorf("unknown function %s", name)
+	}
+	p.next() // (
+	var args []node
+	for !p.isOp(")") {
+		if len(args) > 0 {
+			if !p.isOp(",") {
+				return nil, p.errorf("expected , or )")
+			}
+			p.next()
+		}
+		arg, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next() // )
+	if arity > 0 && len(args) != arity {
+		return nil, p.errorf("%s takes %d argument(s), got %d", name, arity, len(args))
+	}
+	if len(args) == 0 {
+		return nil, p.errorf("%s needs at least one argument", name)
+	}
+	return call{fn: name, args: args}, nil
+}
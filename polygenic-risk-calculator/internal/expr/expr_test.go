@@ -0,0 +1,55 @@
+package expr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEval(t *testing.T) {
+	vars := map[string]float64{"height_z": 1.0, "bmi_z": -0.5, "ldl_percentile": 90}
+	cases := map[string]float64{
+		"0.5*height_z + 0.5*bmi_z": 0.25,
+		"1 + 2 * 3":                7,
+		"(1 + 2) * 3":              9,
+		"-2^2":                     -4,
+		"2^3^2":                    512,
+		"10 / 4 - 1":               1.5,
+		"max(height_z, bmi_z, 0)":  1,
+		"min(height_z, bmi_z)":     -0.5,
+		"abs(bmi_z) + sqrt(4)":     2.5,
+		"ldl_percentile / 100":     0.9,
+		"1e-1 * 10":                1,
+		"--1":                      1,
+	}
+	for src, want := range cases {
+		e, err := Parse(src)
+		require.NoError(t, err, src)
+		got, err := e.Eval(vars)
+		require.NoError(t, err, src)
+		assert.InDelta(t, want, got, 1e-12, src)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, src := range []string{"", "1 +", "(1", "1)", "foo(1)", "abs(1, 2)", "max()", "1 $ 2", "height_z bmi_z"} {
+		_, err := Parse(src)
+		assert.Error(t, err, src)
+	}
+}
+
+func TestEval_Errors(t *testing.T) {
+	e, err := Parse("height_z + weight_z")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"height_z", "weight_z"}, e.Vars())
+
+	_, err = e.Eval(map[string]float64{"height_z": 1})
+	assert.True(t, errors.Is(err, ErrUnknownVariable))
+
+	e, err = Parse("1 / x")
+	require.NoError(t, err)
+	_, err = e.Eval(map[string]float64{"x": 0})
+	assert.Error(t, err, "division by zero is not a value")
+}
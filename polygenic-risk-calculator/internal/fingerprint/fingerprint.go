@@ -0,0 +1,171 @@
+// Package fingerprint identifies genotype samples by their calls at a panel of common
+// variants, so duplicate or swapped samples can be flagged before results are emitted.
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/genotype"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// Domain-specific configuration keys for sample fingerprinting
+const (
+	PanelKey          = "fingerprint.panel"           // rsIDs to fingerprint on (default: DefaultPanel)
+	MinConcordanceKey = "fingerprint.min_concordance" // concordance at or above which two samples match (default 0.95)
+	MinSharedKey      = "fingerprint.min_shared_snps" // panel SNPs both samples must call before comparing (default 20)
+)
+
+const (
+	defaultMinConcordance = 0.95
+	defaultMinShared      = 20
+)
+
+// DefaultPanel is a set of common, widely genotyped variants with high minor allele
+// frequency across populations, so unrelated samples rarely agree at all of them.
+var DefaultPanel = []string{
+	"rs4680", "rs1801133", "rs429358", "rs1800497", "rs53576", "rs1815739",
+	"rs4988235", "rs12913832", "rs762551", "rs1042522", "rs1799971", "rs6265",
+	"rs9939609", "rs7903146", "rs1333049", "rs10757274", "rs16891982", "rs1229984",
+	"rs17822931", "rs713598", "rs1726866", "rs10246939", "rs1800795", "rs1801282",
+	"rs1042713", "rs1799945", "rs1800562", "rs2282679", "rs4343", "rs1799983",
+	"rs2070744", "rs1800629",
+}
+
+// Fingerprint is a sample's genotype calls at the panel variants.
+type Fingerprint struct {
+	Sample    string            `json:"sample"`
+	Hash      string            `json:"hash"`
+	Genotypes map[string]string `json:"-"` // rsid -> genotype with alleles sorted, e.g. "AG"
+}
+
+// Called returns the number of panel variants with a valid call.
+func (f Fingerprint) Called() int {
+	return len(f.Genotypes)
+}
+
+// New builds a fingerprint from rsid -> genotype calls. Allele order is normalized so
+// "GA" and "AG" fingerprint the same.
+func New(sample string, calls map[string]string) Fingerprint {
+	genotypes := make(map[string]string, len(calls))
+	for rsid, geno := range calls {
+		genotypes[rsid] = normalize(geno)
+	}
+
+	rsids := make([]string, 0, len(genotypes))
+	for rsid := range genotypes {
+		rsids = append(rsids, rsid)
+	}
+	sort.Strings(rsids)
+
+	h := sha256.New()
+	for _, rsid := range rsids {
+		fmt.Fprintf(h, "%s:%s;", rsid, genotypes[rsid])
+	}
+	return Fingerprint{Sample: sample, Hash: hex.EncodeToString(h.Sum(nil)), Genotypes: genotypes}
+}
+
+func normalize(geno string) string {
+	b := []byte(strings.ToUpper(geno))
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	return string(b)
+}
+
+// FromFile fingerprints a genotype file (AncestryDNA or 23andMe) on the given panel.
+func FromFile(path string, panel []string) (Fingerprint, error) {
+	parsed, err := genotype.ParseGenotypeData(genotype.ParseGenotypeDataInput{
+		GenotypeFilePath: path,
+		RequestedRSIDs:   panel,
+	})
+	if err != nil {
+		return Fingerprint{}, fmt.Errorf("failed to fingerprint %s: %w", path, err)
+	}
+	calls := make(map[string]string, len(parsed.UserGenotypes))
+	for _, g := range parsed.UserGenotypes {
+		calls[g.RSID] = g.Genotype
+	}
+	return New(path, calls), nil
+}
+
+// Match reports two samples that look like the same individual.
+type Match struct {
+	SampleA     string  `json:"sample_a"`
+	SampleB     string  `json:"sample_b"`
+	Shared      int     `json:"shared_snps"`
+	Concordance float64 `json:"concordance"`
+	Identical   bool    `json:"identical"` // every shared call agrees and both samples called the same SNPs
+}
+
+// Compare returns the number of panel variants called in both samples and the fraction
+// of those with identical genotypes.
+func Compare(a, b Fingerprint) (shared int, concordance float64) {
+	agree := 0
+	for rsid, ga := range a.Genotypes {
+		if gb, ok := b.Genotypes[rsid]; ok {
+			shared++
+			if ga == gb {
+				agree++
+			}
+		}
+	}
+	if shared == 0 {
+		return 0, 0
+	}
+	return shared, float64(agree) / float64(shared)
+}
+
+// Options controls when two fingerprints are considered a match.
+type Options struct {
+	MinConcordance float64
+	MinShared      int
+}
+
+// OptionsFromConfig reads match thresholds from configuration, applying defaults.
+func OptionsFromConfig() Options {
+	opts := Options{MinConcordance: defaultMinConcordance, MinShared: defaultMinShared}
+	if config.HasKey(MinConcordanceKey) {
+		opts.MinConcordance = config.GetFloat64(MinConcordanceKey)
+	}
+	if config.HasKey(MinSharedKey) {
+		opts.MinShared = config.GetInt(MinSharedKey)
+	}
+	return opts
+}
+
+// PanelFromConfig returns the configured panel, or DefaultPanel.
+func PanelFromConfig() []string {
+	if panel := config.GetStringSlice(PanelKey); len(panel) > 0 {
+		return panel
+	}
+	return DefaultPanel
+}
+
+// FindDuplicates compares every pair of fingerprints and returns those that match:
+// likely duplicate samples, or samples swapped between input files.
+func FindDuplicates(fps []Fingerprint, opts Options) []Match {
+	var matches []Match
+	for i := 0; i < len(fps); i++ {
+		for j := i + 1; j < len(fps); j++ {
+			shared, concordance := Compare(fps[i], fps[j])
+			if shared < opts.MinShared || concordance < opts.MinConcordance {
+				continue
+			}
+			m := Match{
+				SampleA:     fps[i].Sample,
+				SampleB:     fps[j].Sample,
+				Shared:      shared,
+				Concordance: concordance,
+				Identical:   fps[i].Hash == fps[j].Hash,
+			}
+			logging.Warn("Samples %s and %s match at %d/%d panel SNPs (%.1f%%): likely duplicate or swapped",
+				m.SampleA, m.SampleB, int(concordance*float64(shared)+0.5), shared, concordance*100)
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,68 @@
+package fingerprint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+func writeGenotypeFile(t *testing.T, name string, calls map[string]string) string {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("# 23andMe test file\nrsid\tchromosome\tposition\tgenotype\n")
+	for rsid, geno := range calls {
+		fmt.Fprintf(&b, "%s\t1\t100\t%s\n", rsid, geno)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(b.String()), 0644))
+	return path
+}
+
+func TestNew_NormalizesAlleleOrder(t *testing.T) {
+	a := New("a", map[string]string{"rs1": "GA", "rs2": "CC"})
+	b := New("b", map[string]string{"rs2": "CC", "rs1": "AG"})
+	assert.Equal(t, a.Hash, b.Hash)
+
+	c := New("c", map[string]string{"rs1": "GG", "rs2": "CC"})
+	assert.NotEqual(t, a.Hash, c.Hash)
+}
+
+func TestFindDuplicates(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	panel := []string{"rs1", "rs2", "rs3", "rs4"}
+
+	sample := map[string]string{"rs1": "AG", "rs2": "CC", "rs3": "TT", "rs4": "AA"}
+	other := map[string]string{"rs1": "GG", "rs2": "CT", "rs3": "TT", "rs4": "AG"}
+	// Re-genotyped duplicate with one discordant call and one no-call
+	near := map[string]string{"rs1": "GA", "rs2": "CC", "rs3": "CT"}
+
+	var fps []Fingerprint
+	for name, calls := range map[string]map[string]string{"a.txt": sample, "b.txt": other, "c.txt": sample, "d.txt": near} {
+		fp, err := FromFile(writeGenotypeFile(t, name, calls), panel)
+		require.NoError(t, err)
+		fp.Sample = name
+		fps = append(fps, fp)
+	}
+
+	matches := FindDuplicates(fps, Options{MinConcordance: 0.95, MinShared: 3})
+	require.Len(t, matches, 1)
+	pair := []string{matches[0].SampleA, matches[0].SampleB}
+	assert.ElementsMatch(t, []string{"a.txt", "c.txt"}, pair)
+	assert.True(t, matches[0].Identical)
+	assert.Equal(t, 4, matches[0].Shared)
+
+	matches = FindDuplicates(fps, Options{MinConcordance: 0.6, MinShared: 3})
+	assert.Len(t, matches, 3, "the near-duplicate matches both copies at a looser threshold")
+}
+
+func TestCompare_NoOverlap(t *testing.T) {
+	shared, concordance := Compare(New("a", map[string]string{"rs1": "AA"}), New("b", map[string]string{"rs2": "AA"}))
+	assert.Zero(t, shared)
+	assert.Zero(t, concordance)
+}
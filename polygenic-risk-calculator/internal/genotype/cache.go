@@ -0,0 +1,108 @@
+package genotype
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/integrity"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// Domain-specific configuration keys for the parsed genotype cache
+const (
+	CacheDirKey = "genotype.cache_dir" // Keep every parsed call of each genotype file here, keyed by the file's sha256; empty disables
+)
+
+// cacheFormat versions the cache files; bump it when Call or the parsing rules change.
+const cacheFormat = "v3"
+
+// ParserFromConfig returns a CachedParser when CacheDirKey is set, or FileParser.
+func ParserFromConfig() GenotypeParser {
+	if dir := config.GetString(CacheDirKey); dir != "" {
+		return CachedParser{Dir: dir}
+	}
+	return FileParser
+}
+
+// CachedParser parses genotype files like FileParser, but keeps every call of each file it
+// reads in Dir, keyed by the sha256 of the file's contents. Scoring a new trait set against
+// the same file then reads the cached calls instead of parsing it again; an edited file has
+// a new hash, so stale calls are never used.
+type CachedParser struct {
+	Dir string
+}
+
+// ParseGenotypeData implements GenotypeParser. A cache that cannot be read or written is
+// logged and the file is parsed directly.
+func (p CachedParser) ParseGenotypeData(input ParseGenotypeDataInput) (ParseGenotypeDataOutput, error) {
+	hash, err := integrity.HashFile(input.GenotypeFilePath)
+	if err != nil {
+		logging.Error("failed to open genotype file: %s, err: %v", input.GenotypeFilePath, err)
+		return ParseGenotypeDataOutput{}, err
+	}
+	name := cacheFormat + "-" + hash
+	if sample := config.GetString(VCFSampleKey); sample != "" {
+		// Another sample of the same VCF has other calls
+		sum := sha256.Sum256([]byte(sample))
+		name += "-" + hex.EncodeToString(sum[:8])
+	}
+	path := filepath.Join(p.Dir, name+".gob")
+
+	calls, err := readCallCache(path)
+	if err == nil {
+		logging.Info("Using cached genotype calls for %s (%d calls)", input.GenotypeFilePath, len(calls))
+		return selectCalls(calls, input), nil
+	}
+	if !os.IsNotExist(err) {
+		logging.Warn("Ignoring unreadable genotype cache %s: %v", path, err)
+	}
+
+	logging.Info("Opening genotype file: %s", input.GenotypeFilePath)
+	calls, err = ReadCalls(input.GenotypeFilePath)
+	if err != nil {
+		return ParseGenotypeDataOutput{}, err
+	}
+	if err := writeCallCache(p.Dir, path, calls); err != nil {
+		logging.Warn("Failed to cache genotype calls for %s: %v", input.GenotypeFilePath, err)
+	}
+	return selectCalls(calls, input), nil
+}
+
+func readCallCache(path string) (map[string]Call, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var calls map[string]Call
+	if err := gob.NewDecoder(f).Decode(&calls); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	return calls, nil
+}
+
+// writeCallCache writes calls through a temporary file so concurrent readers never see a
+// partial cache.
+func writeCallCache(dir, path string, calls map[string]Call) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "genotype-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := gob.NewEncoder(tmp).Encode(calls); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
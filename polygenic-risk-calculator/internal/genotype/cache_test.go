@@ -0,0 +1,63 @@
+package genotype_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/genotype"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+func TestCachedParser(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	data, err := os.ReadFile(filepath.Join("testdata", "23andme_valid.txt"))
+	require.NoError(t, err)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "genome.txt")
+	require.NoError(t, os.WriteFile(file, data, 0o600))
+	cacheDir := filepath.Join(dir, "cache")
+	cached := genotype.CachedParser{Dir: cacheDir}
+	cacheFiles := func() []string {
+		m, _ := filepath.Glob(filepath.Join(cacheDir, "*.gob"))
+		return m
+	}
+
+	input := genotype.ParseGenotypeDataInput{
+		GenotypeFilePath: file,
+		RequestedRSIDs:   []string{"rs2001", "rs2002", "rs9999"},
+		GWASData:         map[string]model.GWASSNPRecord{"rs2001": {RSID: "rs2001"}},
+	}
+	want, err := genotype.ParseGenotypeData(input)
+	require.NoError(t, err)
+	got, err := cached.ParseGenotypeData(input)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	require.Len(t, cacheFiles(), 1)
+
+	// A different trait set is answered from the cached calls
+	input.RequestedRSIDs = []string{"rs2002"}
+	want, err = genotype.ParseGenotypeData(input)
+	require.NoError(t, err)
+	got, err = cached.ParseGenotypeData(input)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Len(t, cacheFiles(), 1)
+
+	// Editing the file changes its hash, so the stale calls are not reused
+	require.NoError(t, os.WriteFile(file, []byte("rsid\tchromosome\tposition\tgenotype\nrs2002\t1\t100\tGG\n"), 0o600))
+	got, err = cached.ParseGenotypeData(input)
+	require.NoError(t, err)
+	require.Len(t, got.ValidatedSNPs, 1)
+	assert.Equal(t, "GG", got.ValidatedSNPs[0].Genotype)
+	assert.Len(t, cacheFiles(), 2)
+}
+
+func TestCachedParser_MissingFile(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	_, err := genotype.CachedParser{Dir: t.TempDir()}.ParseGenotypeData(genotype.ParseGenotypeDataInput{GenotypeFilePath: "nonexistent.txt"})
+	assert.Error(t, err)
+}
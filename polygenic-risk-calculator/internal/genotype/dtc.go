@@ -0,0 +1,162 @@
+package genotype
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// NoCall is the genotype of a call the array could not make: "--" in 23andMe exports and
+// alleles "0" "0" in AncestryDNA exports.
+const NoCall = "--"
+
+// Consumer (direct-to-consumer) raw data formats.
+const (
+	format23andMe  = "23andMe"
+	formatAncestry = "AncestryDNA"
+)
+
+// ancestryChroms maps the numeric chromosome codes of AncestryDNA exports to names: 25 is
+// the pseudoautosomal region, reported on X coordinates.
+var ancestryChroms = map[string]string{"23": "X", "24": "Y", "25": "X", "26": "MT"}
+
+// buildPattern finds the reference build in export header comments, e.g. "build 37" or
+// "GRCh37".
+var buildPattern = regexp.MustCompile(`(?i)(?:build|GRCh)\s*(\d+)`)
+
+// dtcStats counts the calls of a consumer export that cannot be scored.
+type dtcStats struct {
+	noCalls int
+	indels  int
+}
+
+// scanDTC calls fn for each call in a 23andMe (v3 to v5) or AncestryDNA raw data export.
+// The format is detected from the header comments or column header row, and failing that
+// from the shape of the first data line, since 23andMe writes its column header as a
+// comment. No-calls are reported with the genotype NoCall, and indels in the exports' I/D
+// notation as e.g. "DI"; validation rejects both.
+func scanDTC(br *bufio.Reader, name string, fn func(Call)) error {
+	scanner := bufio.NewScanner(br)
+	format, build := "", ""
+	var stats dtcStats
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "#") {
+			if format == "" {
+				format = commentFormat(line)
+			}
+			if m := buildPattern.FindStringSubmatch(line); m != nil && build == "" {
+				build = m[1]
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		if strings.EqualFold(cols[0], "rsid") {
+			// Column header row
+			switch {
+			case len(cols) >= 5 && cols[3] == "allele1":
+				format = formatAncestry
+			case len(cols) >= 4 && cols[3] == "genotype":
+				format = format23andMe
+			default:
+				logging.Error("unknown genotype file format in file: %s", name)
+				return errors.New("unknown file format")
+			}
+			continue
+		}
+		if format == "" {
+			// No header: tell the formats apart by their column counts
+			switch {
+			case len(cols) == 5:
+				format = formatAncestry
+			case len(cols) == 4:
+				format = format23andMe
+			default:
+				logging.Error("unknown genotype file format in file: %s", name)
+				return errors.New("unknown file format")
+			}
+		}
+		if c, ok := dtcCall(format, cols); ok {
+			stats.count(c.Genotype)
+			fn(c)
+		} // else: skip malformed lines
+	}
+	if err := scanner.Err(); err != nil {
+		logging.Error("failed to read genotype file: %s, err: %v", name, err)
+		return fmt.Errorf("failed to read genotype file: %w", err)
+	}
+
+	if format != "" {
+		if build != "" {
+			logging.Info("Detected genotype file format: %s (build %s)", format, build)
+		} else {
+			logging.Info("Detected genotype file format: %s", format)
+		}
+	}
+	if stats.noCalls > 0 || stats.indels > 0 {
+		logging.Info("Genotype file has %d no-calls and %d indel calls (I/D notation), which are not scored", stats.noCalls, stats.indels)
+	}
+	return nil
+}
+
+// commentFormat returns the export format a header comment names, or "".
+func commentFormat(line string) string {
+	lower := strings.ToLower(line)
+	switch {
+	case strings.Contains(lower, "ancestrydna"):
+		return formatAncestry
+	case strings.Contains(lower, "23andme"):
+		return format23andMe
+	case strings.Contains(lower, "allele1"):
+		return formatAncestry
+	case strings.Contains(lower, "rsid") && strings.Contains(lower, "genotype"):
+		return format23andMe
+	}
+	return ""
+}
+
+// dtcCall decodes a data line of a consumer export.
+func dtcCall(format string, cols []string) (Call, bool) {
+	var c Call
+	switch {
+	case format == formatAncestry && len(cols) >= 5:
+		// rsid, chrom, pos, allele1, allele2
+		c = Call{RSID: cols[0], Chrom: cols[1], Genotype: cols[3] + cols[4]}
+		if name, ok := ancestryChroms[c.Chrom]; ok {
+			c.Chrom = name
+		}
+		if c.Genotype == "00" {
+			c.Genotype = NoCall
+		}
+	case format == format23andMe && len(cols) >= 4:
+		// rsid, chrom, pos, genotype
+		c = Call{RSID: cols[0], Chrom: cols[1], Genotype: cols[3]}
+		// A phased call is written with a separator, e.g. "C|T"
+		if len(c.Genotype) == 3 && c.Genotype[1] == '|' {
+			c.Genotype = c.Genotype[:1] + c.Genotype[2:]
+			c.Phased = true
+		}
+	default:
+		return Call{}, false
+	}
+	c.Pos, _ = strconv.ParseInt(cols[2], 10, 64)
+	return c, true
+}
+
+// count records a call that cannot be scored.
+func (s *dtcStats) count(genotype string) {
+	switch {
+	case genotype == NoCall:
+		s.noCalls++
+	case genotype != "" && strings.Trim(genotype, "DI") == "":
+		s.indels++
+	}
+}
@@ -0,0 +1,52 @@
+package genotype_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/genotype"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+func TestParseGenotypeData_23andMeExport(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	// The column header is a comment, as 23andMe writes it
+	out := parseFixture(t, filepath.Join("testdata", "23andme_v5.txt"),
+		"rs548049170", "i713426", "rs3131972", "rs12124819", "rs11240777", "rs4477212", "1:565508")
+
+	assert.Equal(t, []model.ValidatedSNP{
+		{RSID: "rs548049170", Genotype: "TT"},
+		{RSID: "i713426", Genotype: "AG"},
+		{RSID: "1:565508", Genotype: "AA"},
+	}, out.ValidatedSNPs)
+	// A no-call, two indels, and a haploid X call
+	assert.Equal(t, []string{"rs3131972", "rs12124819", "rs11240777", "rs4477212"}, out.SNPsMissing)
+
+	calls, err := genotype.ReadCalls(filepath.Join("testdata", "23andme_v5.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, genotype.NoCall, calls["rs3131972"].Genotype)
+	assert.Equal(t, "DI", calls["rs12124819"].Genotype)
+}
+
+func TestParseGenotypeData_AncestryDNAExport(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	// CRLF line endings and numeric sex and mitochondrial chromosome codes
+	out := parseFixture(t, filepath.Join("testdata", "ancestry_v2.txt"),
+		"rs3131972", "rs12562034", "rs4040617", "X:2710146", "MT:16519")
+
+	assert.Equal(t, []model.ValidatedSNP{
+		{RSID: "rs3131972", Genotype: "AG"},
+		{RSID: "X:2710146", Genotype: "CC"},
+		{RSID: "MT:16519", Genotype: "TT"},
+	}, out.ValidatedSNPs)
+	assert.Equal(t, []string{"rs12562034", "rs4040617"}, out.SNPsMissing)
+
+	calls, err := genotype.ReadCalls(filepath.Join("testdata", "ancestry_v2.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, genotype.NoCall, calls["rs12562034"].Genotype)
+	assert.Equal(t, "DI", calls["rs4040617"].Genotype)
+	assert.Equal(t, "X", calls["rs5939319"].Chrom)
+}
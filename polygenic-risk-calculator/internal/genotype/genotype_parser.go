@@ -1,11 +1,10 @@
 package genotype
 
 import (
-	"bufio"
-	"errors"
+	"io"
 	"os"
-	"strings"
 
+	"phite.io/polygenic-risk-calculator/internal/contig"
 	"phite.io/polygenic-risk-calculator/internal/logging"
 
 	"phite.io/polygenic-risk-calculator/internal/model"
@@ -16,6 +15,7 @@ type ParseGenotypeDataInput struct {
 	GenotypeFilePath string
 	RequestedRSIDs   []string
 	GWASData         map[string]model.GWASSNPRecord // rsid -> GWASSNPRecord
+	Contigs          *contig.Normalizer             // optional; drops calls on ALT contigs the policy does not remap
 }
 
 // ParseGenotypeDataOutput holds the results of parsing and validation.
@@ -25,8 +25,29 @@ type ParseGenotypeDataOutput struct {
 	SNPsMissing   []string // rsids not found in user data or GWAS, or non-GACT
 }
 
+// GenotypeParser parses a user genotype file. FileParser is the default implementation;
+// tests can substitute their own calls without writing files.
+type GenotypeParser interface {
+	ParseGenotypeData(input ParseGenotypeDataInput) (ParseGenotypeDataOutput, error)
+}
+
+// ParserFunc adapts a function to the GenotypeParser interface.
+type ParserFunc func(ParseGenotypeDataInput) (ParseGenotypeDataOutput, error)
+
+// ParseGenotypeData calls f(input).
+func (f ParserFunc) ParseGenotypeData(input ParseGenotypeDataInput) (ParseGenotypeDataOutput, error) {
+	return f(input)
+}
+
+// FileParser reads genotype files from disk with ParseGenotypeData.
+var FileParser GenotypeParser = ParserFunc(ParseGenotypeData)
+
 // ParseGenotypeData parses a user genotype file, validates SNPs, and reports missing ones.
-// It autodetects the file format (AncestryDNA or 23andMe).
+// It autodetects the file format (AncestryDNA, 23andMe, or VCF), reading gzip and bgzip
+// compressed files as well. Consumer exports are read as downloaded; see scanDTC. In
+// 23andMe-style files, a genotype written with a '|' separator (e.g. "C|T") is read as a
+// phased call. Requested SNPs written as "chr:pos" or
+// "chr:pos:ref:alt" match calls at that position.
 //
 // Inputs:
 //   - input: ParseGenotypeDataInput struct containing file path, requested SNPs, and GWAS data.
@@ -41,9 +62,6 @@ func ParseGenotypeData(input ParseGenotypeDataInput) (ParseGenotypeDataOutput, e
 		requested[rsid] = struct{}{}
 	}
 
-	output := ParseGenotypeDataOutput{}
-	userGenos := make(map[string]string)
-
 	logging.Info("Opening genotype file: %s", input.GenotypeFilePath)
 	f, err := os.Open(input.GenotypeFilePath)
 	if err != nil {
@@ -52,62 +70,117 @@ func ParseGenotypeData(input ParseGenotypeDataInput) (ParseGenotypeDataOutput, e
 	}
 	defer f.Close()
 
-	scanner := bufio.NewScanner(f)
-	format := ""
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
-			continue
+	loci := make(map[string]struct{})
+	for _, id := range input.RequestedRSIDs {
+		if l, ok := parseLocus(id); ok {
+			loci[l.key(input.Contigs)] = struct{}{}
 		}
-		cols := strings.Split(line, "\t")
-		if format == "" {
-			if len(cols) >= 5 && cols[0] == "rsid" && cols[3] == "allele1" {
-				format = "ancestry"
-				logging.Info("Detected genotype file format: AncestryDNA")
-				continue
-			} else if len(cols) >= 4 && cols[0] == "rsid" && cols[3] == "genotype" {
-				format = "23andme"
-				logging.Info("Detected genotype file format: 23andMe")
-				continue
-			} else {
-				logging.Error("unknown genotype file format in file: %s", input.GenotypeFilePath)
-				return ParseGenotypeDataOutput{}, errors.New("unknown file format")
-			}
+	}
+
+	// Keep only the requested calls, so a whole-genome file is never held in memory
+	calls := make(map[string]Call)
+	err = scanCalls(f, input.GenotypeFilePath, func(c Call) {
+		_, ok := requested[c.RSID]
+		if !ok && len(loci) > 0 {
+			_, ok = loci[locus{c.Chrom, c.Pos}.key(input.Contigs)]
 		}
-		if format == "ancestry" && len(cols) >= 5 {
-			// rsid, chrom, pos, allele1, allele2
-			rsid := cols[0]
-			if _, ok := requested[rsid]; ok {
-				geno := cols[3] + cols[4]
-				userGenos[rsid] = geno
-			}
-		} else if format == "23andme" && len(cols) >= 4 {
-			// rsid, chrom, pos, genotype
-			rsid := cols[0]
-			if _, ok := requested[rsid]; ok {
-				geno := cols[3]
-				userGenos[rsid] = geno
-			}
-		} // else: skip malformed lines
+		if ok && onUsableContig(input.Contigs, c) {
+			calls[c.RSID] = c
+		}
+	})
+	if err != nil {
+		return ParseGenotypeDataOutput{}, err
 	}
+	return selectCalls(calls, input), nil
+}
+
+// Call is one genotype call read from a genotype file.
+type Call struct {
+	RSID     string
+	Chrom    string
+	Pos      int64
+	Genotype string // two alleles, separator removed
+	Phased   bool   // written with a '|' separator
+
+	// Probs are the probabilities of 0, 1, and 2 copies of Alleles[1], read from the GP or DS
+	// field of a biallelic VCF record; nil for hard calls only.
+	Probs   []float64
+	Alleles [2]string // REF and ALT of the record Probs were read from
+}
 
-	for rsid := range requested {
-		geno, found := userGenos[rsid]
-		if found && isValidGenotype(geno) {
-			output.UserGenotypes = append(output.UserGenotypes, model.UserGenotype{RSID: rsid, Genotype: geno})
+// ReadCalls reads every call of a genotype file, keyed by rsid (or chr:pos for VCF records
+// without one), without validating them.
+// Diagnostics use it to see calls ParseGenotypeData would report missing.
+func ReadCalls(path string) (map[string]Call, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	calls := make(map[string]Call)
+	if err := scanCalls(f, path, func(c Call) { calls[c.RSID] = c }); err != nil {
+		return nil, err
+	}
+	return calls, nil
+}
+
+// scanCalls autodetects the format of a genotype file and calls fn for each call in it;
+// name labels log messages. Malformed lines are skipped.
+func scanCalls(r io.Reader, name string, fn func(Call)) error {
+	br, err := decompress(r)
+	if err != nil {
+		logging.Error("failed to read genotype file: %s, err: %v", name, err)
+		return err
+	}
+	if isVCF(br) {
+		return scanVCF(br, name, fn)
+	}
+	return scanDTC(br, name, fn)
+}
+
+// selectCalls validates the requested SNPs among calls (keyed by rsid) and reports the
+// missing ones.
+func selectCalls(calls map[string]Call, input ParseGenotypeDataInput) ParseGenotypeDataOutput {
+	output := ParseGenotypeDataOutput{}
+	seen := make(map[string]struct{}, len(input.RequestedRSIDs))
+	var byLocus map[string]Call // built on the first SNP requested by locus
+
+	// Report in request order so repeated runs produce identical output
+	for _, rsid := range input.RequestedRSIDs {
+		if _, dup := seen[rsid]; dup {
+			continue
+		}
+		seen[rsid] = struct{}{}
+		c, found := calls[rsid]
+		if l, ok := parseLocus(rsid); !found && ok {
+			if byLocus == nil {
+				byLocus = make(map[string]Call, len(calls))
+				for _, c := range calls {
+					byLocus[locus{c.Chrom, c.Pos}.key(input.Contigs)] = c
+				}
+			}
+			c, found = byLocus[l.key(input.Contigs)]
+		}
+		if found && isValidGenotype(c.Genotype) && onUsableContig(input.Contigs, c) {
+			output.UserGenotypes = append(output.UserGenotypes, model.UserGenotype{RSID: rsid, Genotype: c.Genotype})
 			foundInGWAS := false
 			if _, ok := input.GWASData[rsid]; ok {
 				foundInGWAS = true
 			}
-			output.ValidatedSNPs = append(output.ValidatedSNPs, model.ValidatedSNP{RSID: rsid, Genotype: geno, FoundInGWAS: foundInGWAS})
+			output.ValidatedSNPs = append(output.ValidatedSNPs, model.ValidatedSNP{RSID: rsid, Genotype: c.Genotype, FoundInGWAS: foundInGWAS, Phased: c.Phased, Probs: c.Probs, ProbsAlleles: c.Alleles})
 		} else {
-			// logging.Info("requested SNP %s not found or invalid in genotype file", rsid)
 			output.SNPsMissing = append(output.SNPsMissing, rsid)
 		}
 	}
 
 	logging.Info("Validated %d SNPs, %d missing", len(output.ValidatedSNPs), len(output.SNPsMissing))
-	return output, nil
+	return output
+}
+
+// onUsableContig reports whether a call's chromosome survives the contig policy.
+func onUsableContig(contigs *contig.Normalizer, c Call) bool {
+	_, _, ok := contigs.Resolve(contig.SourceGenotype, c.Chrom, c.Pos)
+	return ok
 }
 
 func isValidGenotype(geno string) bool {
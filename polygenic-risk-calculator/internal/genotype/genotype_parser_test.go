@@ -1,12 +1,15 @@
 package genotype_test
 
 import (
+	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
 	"sort"
+	"strings"
 	"testing"
 
+	"phite.io/polygenic-risk-calculator/internal/contig"
 	"phite.io/polygenic-risk-calculator/internal/genotype"
 	"phite.io/polygenic-risk-calculator/internal/model"
 	"phite.io/polygenic-risk-calculator/internal/logging"
@@ -153,3 +156,128 @@ func TestParseGenotypeData(t *testing.T) {
 		})
 	}
 }
+
+func TestParseGenotypeData_DropsAltContigCalls(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	path := filepath.Join(t.TempDir(), "genotype.txt")
+	content := "rsid\tchromosome\tposition\tgenotype\n" +
+		"rs1\tchr1\t100\tAG\n" +
+		"rs2\tchr1_KI270762v1_alt\t200\tCT\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	contigs, err := contig.New(contig.PolicyDrop, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := genotype.ParseGenotypeData(genotype.ParseGenotypeDataInput{
+		GenotypeFilePath: path,
+		RequestedRSIDs:   []string{"rs1", "rs2"},
+		Contigs:          contigs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.ValidatedSNPs) != 1 || out.ValidatedSNPs[0].RSID != "rs1" {
+		t.Errorf("expected only rs1 to validate, got %+v", out.ValidatedSNPs)
+	}
+	if !reflect.DeepEqual(out.SNPsMissing, []string{"rs2"}) {
+		t.Errorf("expected rs2 missing, got %v", out.SNPsMissing)
+	}
+	if got := contigs.Report().Dropped[contig.SourceGenotype]; got != 1 {
+		t.Errorf("expected 1 dropped genotype call, got %d", got)
+	}
+}
+
+func TestParseGenotypeData_PhasedCalls(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	path := filepath.Join(t.TempDir(), "genotype.txt")
+	content := "rsid\tchromosome\tposition\tgenotype\n" +
+		"rs1\t19\t100\tC|T\n" +
+		"rs2\t19\t200\tCT\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := genotype.ParseGenotypeData(genotype.ParseGenotypeDataInput{
+		GenotypeFilePath: path,
+		RequestedRSIDs:   []string{"rs1", "rs2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := make(map[string]model.ValidatedSNP)
+	for _, v := range out.ValidatedSNPs {
+		calls[v.RSID] = v
+	}
+	if got := calls["rs1"]; got.Genotype != "CT" || !got.Phased {
+		t.Errorf("rs1: expected phased CT, got %+v", got)
+	}
+	if got := calls["rs2"]; got.Genotype != "CT" || got.Phased {
+		t.Errorf("rs2: expected unphased CT, got %+v", got)
+	}
+}
+
+func TestParseGenotypeData_OverlongLine(t *testing.T) {
+	// A line past the scanner's buffer must fail the parse, not silently end it early
+	path := filepath.Join(t.TempDir(), "genotype.txt")
+	data := "rsid\tchromosome\tposition\tgenotype\nrs1\t1\t100\t" + strings.Repeat("A", 70000) + "\nrs2\t1\t200\tAG\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := genotype.ParseGenotypeData(genotype.ParseGenotypeDataInput{
+		GenotypeFilePath: path,
+		RequestedRSIDs:   []string{"rs2"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a line longer than the scanner buffer")
+	}
+}
+
+// FuzzParseGenotypeData checks that malformed genotype files are rejected with an error
+// rather than a panic, and that every requested SNP is reported exactly once, either
+// validated as a two-allele call or missing.
+func FuzzParseGenotypeData(f *testing.F) {
+	seeds, err := filepath.Glob(filepath.Join("testdata", "*.txt"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	seeds = append(seeds, filepath.Join("testdata", "sample.vcf"))
+	for _, seed := range seeds {
+		data, err := os.ReadFile(seed)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte("rsid\tchromosome\tposition\tgenotype\nrs1\tchrUn_xyz\tnotanumber\tC|T\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		path := filepath.Join(t.TempDir(), "genotype.txt")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		contigs, err := contig.New(contig.PolicyDrop, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		requested := []string{"rs1", "rs2", "rs3", "rs4", "rsid"}
+		out, err := genotype.ParseGenotypeData(genotype.ParseGenotypeDataInput{
+			GenotypeFilePath: path,
+			RequestedRSIDs:   requested,
+			Contigs:          contigs,
+		})
+		if err != nil {
+			return
+		}
+		if len(out.ValidatedSNPs)+len(out.SNPsMissing) != len(requested) {
+			t.Fatalf("%d validated + %d missing, want %d requested", len(out.ValidatedSNPs), len(out.SNPsMissing), len(requested))
+		}
+		for _, snp := range out.ValidatedSNPs {
+			if len(snp.Genotype) != 2 {
+				t.Fatalf("validated %s with genotype %q", snp.RSID, snp.Genotype)
+			}
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package genotype
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/contig"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// rsidPattern matches dbSNP rsids.
+var rsidPattern = regexp.MustCompile(`^rs\d+$`)
+
+// Manifest is the set of sites a genotyping array assays, so model coverage can be
+// estimated before any sample is genotyped. Sites are matched by rsid or by position.
+type Manifest struct {
+	Sites   int // sites read
+	rsids   map[string]struct{}
+	loci    map[string]struct{}
+	contigs *contig.Normalizer
+}
+
+// ReadManifest reads an array site list: a VCF, where the CHROM, POS, and ID columns name
+// each site; or a text file with one site per line, given as an rsid or chr:pos locus,
+// optionally followed by chromosome and position columns, separated by tabs, commas, or
+// spaces. Consumer raw data exports read this way too, so any 23andMe or AncestryDNA file
+// from the same chip serves as its manifest. Comment lines and a header row are skipped.
+// contigs canonicalizes chromosome names; nil uses the built-in aliases.
+func ReadManifest(path string, contigs *contig.Normalizer) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	br, err := decompress(f)
+	if err != nil {
+		return nil, err
+	}
+	vcf := isVCF(br)
+
+	m := &Manifest{rsids: make(map[string]struct{}), loci: make(map[string]struct{}), contigs: contigs}
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxVCFLine)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		cols := strings.FieldsFunc(line, func(r rune) bool { return r == '\t' || r == ',' || r == ' ' })
+		var id, chrom, pos string
+		switch {
+		case vcf && len(cols) >= 3:
+			chrom, pos, id = cols[0], cols[1], cols[2]
+		case len(cols) >= 3:
+			id, chrom, pos = cols[0], cols[1], cols[2]
+		default:
+			id = cols[0]
+		}
+		if p, err := strconv.ParseInt(pos, 10, 64); err == nil && p > 0 {
+			m.loci[locus{chrom, p}.key(contigs)] = struct{}{}
+		} else if l, ok := parseLocus(id); ok {
+			m.loci[l.key(contigs)] = struct{}{}
+		} else if !rsidPattern.MatchString(strings.Split(id, ";")[0]) {
+			continue // header row or an unplaced probe
+		}
+		for _, rsid := range strings.Split(id, ";") {
+			if rsidPattern.MatchString(rsid) {
+				m.rsids[rsid] = struct{}{}
+			}
+		}
+		m.Sites++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", path, err)
+	}
+	if m.Sites == 0 {
+		return nil, fmt.Errorf("manifest %s lists no sites", path)
+	}
+	return m, nil
+}
+
+// Has reports whether the array assays a variant, by its rsid or, when chrom and pos are
+// set, its position. id may itself be a chr:pos or chr:pos:ref:alt locus.
+func (m *Manifest) Has(id, chrom string, pos int64) bool {
+	if _, ok := m.rsids[id]; ok {
+		return true
+	}
+	if l, ok := parseLocus(id); ok && chrom == "" {
+		chrom, pos = l.chrom, l.pos
+	}
+	if chrom == "" || pos <= 0 {
+		return false
+	}
+	_, ok := m.loci[locus{chrom, pos}.key(m.contigs)]
+	return ok
+}
+
+// ModelCoverage is the share of a model an array assays.
+type ModelCoverage struct {
+	Variants      int     `json:"variants"`       // model variants
+	Covered       int     `json:"covered"`        // variants on the array
+	WeightCovered float64 `json:"weight_covered"` // share of the model's total absolute weight on the array
+}
+
+// Coverage returns the share of variants, and of their absolute weight, the array assays.
+func (m *Manifest) Coverage(variants []model.Variant) ModelCoverage {
+	c := ModelCoverage{Variants: len(variants)}
+	var total, covered float64
+	for _, v := range variants {
+		w := math.Abs(v.EffectWeight)
+		total += w
+		id := v.ID
+		if v.RSID != nil && *v.RSID != "" {
+			id = *v.RSID
+		}
+		if m.Has(id, v.Chromosome, v.Position) || (id != v.ID && m.Has(v.ID, "", 0)) {
+			c.Covered++
+			covered += w
+		}
+	}
+	if total > 0 {
+		c.WeightCovered = covered / total
+	}
+	return c
+}
@@ -0,0 +1,54 @@
+package genotype_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/genotype"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+func TestReadManifest(t *testing.T) {
+	// A raw data export serves as its array's manifest; no-calls are still assayed sites
+	m, err := genotype.ReadManifest(filepath.Join("testdata", "23andme_v5.txt"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 8, m.Sites)
+	assert.True(t, m.Has("rs3131972", "", 0))
+	assert.True(t, m.Has("chr1:726912", "", 0), "internal IDs match by position")
+	assert.True(t, m.Has("rs999", "chr1", 69869))
+	assert.False(t, m.Has("rs999", "", 0))
+
+	m, err = genotype.ReadManifest(filepath.Join("testdata", "sample.vcf"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, m.Sites)
+	assert.True(t, m.Has("rs11", "", 0))
+	assert.True(t, m.Has("1:800000:C:T", "", 0))
+
+	path := filepath.Join(t.TempDir(), "sites.txt")
+	require.NoError(t, os.WriteFile(path, []byte("Name\nrs1\n2:200\nrs3\n"), 0o644))
+	m, err = genotype.ReadManifest(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, m.Sites)
+
+	require.NoError(t, os.WriteFile(path, []byte("# nothing\n"), 0o644))
+	_, err = genotype.ReadManifest(path, nil)
+	assert.ErrorContains(t, err, "lists no sites")
+}
+
+func TestManifest_Coverage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sites.txt")
+	require.NoError(t, os.WriteFile(path, []byte("rs1\n2:200\n"), 0o644))
+	m, err := genotype.ReadManifest(path, nil)
+	require.NoError(t, err)
+
+	rs1, rs3 := "rs1", "rs3"
+	c := m.Coverage([]model.Variant{
+		{ID: "1:100:A:G", RSID: &rs1, Chromosome: "1", Position: 100, EffectWeight: 0.5},
+		{ID: "2:200:C:T", Chromosome: "2", Position: 200, EffectWeight: -0.25},
+		{ID: "3:300:G:A", RSID: &rs3, Chromosome: "3", Position: 300, EffectWeight: 0.25},
+	})
+	assert.Equal(t, genotype.ModelCoverage{Variants: 3, Covered: 2, WeightCovered: 0.75}, c)
+}
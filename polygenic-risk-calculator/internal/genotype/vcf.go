@@ -0,0 +1,241 @@
+package genotype
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/contig"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// Domain-specific configuration keys for VCF input
+const (
+	VCFSampleKey = "genotype.vcf_sample" // Sample column read from multi-sample VCFs; empty reads the first sample
+)
+
+// maxVCFLine bounds a VCF record, which in a multi-sample file holds a column per sample.
+const maxVCFLine = 64 << 20
+
+// decompress returns r, transparently decompressed when it is gzip or bgzip data. bgzip
+// files are concatenated gzip members, which gzip.Reader reads as one stream.
+func decompress(r io.Reader) (*bufio.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(2)
+	if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return br, nil
+	}
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compressed genotype file: %w", err)
+	}
+	return bufio.NewReader(gz), nil
+}
+
+// isVCF reports whether br starts with a VCF header.
+func isVCF(br *bufio.Reader) bool {
+	head, _ := br.Peek(len("##fileformat=VCF"))
+	return bytes.HasPrefix(head, []byte("##fileformat=VCF")) || bytes.HasPrefix(head, []byte("#CHROM"))
+}
+
+// scanVCF calls fn for each genotype call of one sample in a VCF: the sample named by
+// VCFSampleKey, or the first. A record whose ID column holds rsids is reported once per
+// rsid; a record without one is reported under its chr:pos locus. Calls are decoded from
+// the GT field into REF/ALT bases; missing calls are skipped, and indel or haploid calls
+// are reported as they are, which validation rejects.
+func scanVCF(br *bufio.Reader, name string, fn func(Call)) error {
+	logging.Info("Detected genotype file format: VCF")
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxVCFLine)
+	sample := -1
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "##") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		if sample < 0 {
+			if !strings.HasPrefix(line, "#CHROM") {
+				logging.Error("VCF without a #CHROM header line: %s", name)
+				return errors.New("invalid VCF: missing #CHROM header")
+			}
+			var err error
+			if sample, err = vcfSampleColumn(cols); err != nil {
+				logging.Error("%v in VCF: %s", err, name)
+				return err
+			}
+			continue
+		}
+		// CHROM, POS, ID, REF, ALT, QUAL, FILTER, INFO, FORMAT, samples...
+		if len(cols) <= sample {
+			continue // skip malformed records
+		}
+		pos, err := strconv.ParseInt(cols[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		gt, phased, ok := vcfGenotype(cols[8], cols[sample], cols[3], cols[4])
+		if !ok {
+			continue
+		}
+		c := Call{Chrom: cols[0], Pos: pos, Genotype: gt, Phased: phased}
+		if probs := vcfProbs(cols[8], cols[sample], cols[4]); probs != nil {
+			c.Probs, c.Alleles = probs, [2]string{cols[3], cols[4]}
+		}
+		reported := false
+		for _, id := range strings.Split(cols[2], ";") {
+			if strings.HasPrefix(id, "rs") {
+				c.RSID = id
+				fn(c)
+				reported = true
+			}
+		}
+		if !reported {
+			c.RSID = fmt.Sprintf("%s:%d", c.Chrom, c.Pos)
+			fn(c)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		logging.Error("failed to read genotype file: %s, err: %v", name, err)
+		return fmt.Errorf("failed to read genotype file: %w", err)
+	}
+	if sample < 0 {
+		logging.Error("VCF without a #CHROM header line: %s", name)
+		return errors.New("invalid VCF: missing #CHROM header")
+	}
+	return nil
+}
+
+// vcfSampleColumn returns the column of the sample to read from the #CHROM header.
+func vcfSampleColumn(header []string) (int, error) {
+	if len(header) < 10 {
+		return 0, errors.New("invalid VCF: no sample columns")
+	}
+	want := config.GetString(VCFSampleKey)
+	if want == "" {
+		if len(header) > 10 {
+			logging.Warn("VCF has %d samples; reading the first, %s (set %s to choose another)", len(header)-9, header[9], VCFSampleKey)
+		}
+		return 9, nil
+	}
+	for i := 9; i < len(header); i++ {
+		if header[i] == want {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("sample %q not found", want)
+}
+
+// vcfGenotype decodes the GT subfield of a sample column into bases, e.g. "0|1" with REF A
+// and ALT G into "AG", phased. It reports false for a missing or undecodable call.
+func vcfGenotype(format, sampleCol, ref, alt string) (string, bool, bool) {
+	gtIndex := -1
+	for i, key := range strings.Split(format, ":") {
+		if key == "GT" {
+			gtIndex = i
+			break
+		}
+	}
+	fields := strings.Split(sampleCol, ":")
+	if gtIndex < 0 || gtIndex >= len(fields) {
+		return "", false, false
+	}
+	gt := fields[gtIndex]
+	phased := strings.Contains(gt, "|")
+	alleles := append([]string{ref}, strings.Split(alt, ",")...)
+	var b strings.Builder
+	for _, idx := range strings.FieldsFunc(gt, func(r rune) bool { return r == '/' || r == '|' }) {
+		i, err := strconv.Atoi(idx)
+		if err != nil || i < 0 || i >= len(alleles) {
+			return "", false, false // "." or an index past ALT
+		}
+		b.WriteString(alleles[i])
+	}
+	if b.Len() == 0 {
+		return "", false, false
+	}
+	return b.String(), phased, true
+}
+
+// vcfProbs reads the probabilities of 0, 1, and 2 ALT copies from the GP subfield of a
+// sample column, or failing that from its DS (expected ALT dosage) subfield. A dosage
+// alone is spread over the two genotypes either side of it, which keeps its expectation.
+// Multi-allelic records, and missing or out-of-range values, yield nil.
+func vcfProbs(format, sampleCol, alt string) []float64 {
+	if strings.Contains(alt, ",") {
+		return nil
+	}
+	fields := strings.Split(sampleCol, ":")
+	values := make(map[string]string, len(fields))
+	for i, key := range strings.Split(format, ":") {
+		if i < len(fields) {
+			values[key] = fields[i]
+		}
+	}
+	if gp := strings.Split(values["GP"], ","); len(gp) == 3 {
+		probs := make([]float64, 3)
+		var total float64
+		for i, v := range gp {
+			p, err := strconv.ParseFloat(v, 64)
+			if err != nil || !(p >= 0 && p <= 1) {
+				return nil
+			}
+			probs[i] = p
+			total += p
+		}
+		if total <= 0 {
+			return nil
+		}
+		for i := range probs {
+			probs[i] /= total // GP is written rounded, so it rarely sums to exactly 1
+		}
+		return probs
+	}
+	ds, err := strconv.ParseFloat(values["DS"], 64)
+	if err != nil || !(ds >= 0 && ds <= 2) {
+		return nil
+	}
+	if ds <= 1 {
+		return []float64{1 - ds, ds, 0}
+	}
+	return []float64{0, 2 - ds, ds - 1}
+}
+
+// builtinContigs canonicalizes chromosome names for locus matching when the caller gives no
+// normalizer.
+var builtinContigs, _ = contig.New("", "")
+
+// locus is a variant position, written "chr:pos" with an optional ":ref:alt" suffix, e.g.
+// "chr1:752721" or "1:752721:A:G". Requested SNPs given as loci match calls by position, so
+// variants without an rsid in the genotype file can still be scored.
+type locus struct {
+	chrom string
+	pos   int64
+}
+
+// parseLocus parses a "chr:pos" or "chr:pos:ref:alt" variant ID.
+func parseLocus(id string) (locus, bool) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 2 && len(parts) != 4 {
+		return locus{}, false
+	}
+	pos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || pos <= 0 || parts[0] == "" {
+		return locus{}, false
+	}
+	return locus{parts[0], pos}, true
+}
+
+// key returns the locus with its chromosome canonicalized, so "chr1:5" and "1:5" match.
+func (l locus) key(contigs *contig.Normalizer) string {
+	if contigs == nil {
+		contigs = builtinContigs
+	}
+	return fmt.Sprintf("%s:%d", contigs.Canonical(l.chrom), l.pos)
+}
@@ -0,0 +1,112 @@
+package genotype_test
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/genotype"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// parseFixture parses the genotype file at path for the requested IDs.
+func parseFixture(t *testing.T, path string, requested ...string) genotype.ParseGenotypeDataOutput {
+	t.Helper()
+	out, err := genotype.ParseGenotypeData(genotype.ParseGenotypeDataInput{
+		GenotypeFilePath: path,
+		RequestedRSIDs:   requested,
+	})
+	require.NoError(t, err)
+	return out
+}
+
+func TestParseGenotypeData_VCF(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	out := parseFixture(t, filepath.Join("testdata", "sample.vcf"),
+		"rs3131972", "1:800000", "rs11", "rs12", "rs13", "chr2:2000:A:G", "rs404")
+
+	assert.Equal(t, []model.ValidatedSNP{
+		{RSID: "rs3131972", Genotype: "AG"},
+		{RSID: "1:800000", Genotype: "CT", Phased: true},
+		{RSID: "rs11", Genotype: "AT"},
+	}, out.ValidatedSNPs)
+	// rs12 is a missing call and rs13 an indel
+	assert.Equal(t, []string{"rs12", "rs13", "chr2:2000:A:G", "rs404"}, out.SNPsMissing)
+}
+
+func TestParseGenotypeData_VCFSample(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	old := config.GetString(genotype.VCFSampleKey)
+	defer config.Set(genotype.VCFSampleKey, old)
+	path := filepath.Join("testdata", "sample.vcf")
+
+	config.Set(genotype.VCFSampleKey, "S2")
+	out := parseFixture(t, path, "rs3131972", "rs12")
+	assert.Equal(t, []model.ValidatedSNP{
+		{RSID: "rs3131972", Genotype: "GG"},
+		{RSID: "rs12", Genotype: "AG"},
+	}, out.ValidatedSNPs)
+
+	config.Set(genotype.VCFSampleKey, "S3")
+	_, err := genotype.ParseGenotypeData(genotype.ParseGenotypeDataInput{GenotypeFilePath: path, RequestedRSIDs: []string{"rs12"}})
+	assert.ErrorContains(t, err, `sample "S3" not found`)
+}
+
+func TestParseGenotypeData_CompressedVCF(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	data, err := os.ReadFile(filepath.Join("testdata", "sample.vcf"))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "sample.vcf.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	// Two members, as bgzip writes them
+	for _, part := range [][]byte{data[:len(data)/2], data[len(data)/2:]} {
+		zw := gzip.NewWriter(f)
+		_, err = zw.Write(part)
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+	}
+	require.NoError(t, f.Close())
+
+	out := parseFixture(t, path, "rs3131972", "rs11")
+	assert.Equal(t, []model.ValidatedSNP{
+		{RSID: "rs3131972", Genotype: "AG"},
+		{RSID: "rs11", Genotype: "AT"},
+	}, out.ValidatedSNPs)
+}
+
+func TestParseGenotypeData_LocusMatchesTextFormats(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	out := parseFixture(t, filepath.Join("testdata", "23andme_valid.txt"), "chr1:2000", "rs2001")
+	assert.Equal(t, []model.ValidatedSNP{
+		{RSID: "chr1:2000", Genotype: "CC"},
+		{RSID: "rs2001", Genotype: "AG"},
+	}, out.ValidatedSNPs)
+}
+
+func TestParseGenotypeData_VCFProbabilities(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	path := filepath.Join(t.TempDir(), "imputed.vcf")
+	require.NoError(t, os.WriteFile(path, []byte("##fileformat=VCFv4.2\n"+
+		"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\tFORMAT\tS1\n"+
+		"1\t100\trs1\tA\tG\t.\tPASS\t.\tGT:DS:GP\t0/1:0.9:0.2,0.7,0.1\n"+
+		"1\t200\trs2\tC\tT\t.\tPASS\t.\tGT:DS\t1/1:1.6\n"+
+		"1\t300\trs3\tG\tA,C\t.\tPASS\t.\tGT:GP\t0/1:0.1,0.8,0.1,0,0,0\n"+
+		"1\t400\trs4\tT\tC\t.\tPASS\t.\tGT:DS\t0/0:.\n"), 0o644))
+
+	out := parseFixture(t, path, "rs1", "rs2", "rs3", "rs4")
+	require.Len(t, out.ValidatedSNPs, 4)
+	rs1, rs2 := out.ValidatedSNPs[0], out.ValidatedSNPs[1]
+	assert.InDeltaSlice(t, []float64{0.2, 0.7, 0.1}, rs1.Probs, 1e-12, "GP is preferred to DS")
+	assert.Equal(t, [2]string{"A", "G"}, rs1.ProbsAlleles)
+	assert.InDeltaSlice(t, []float64{0.1, 0.7, 0.2}, rs1.EffectProbs("A"), 1e-12, "REF copies are counted in reverse")
+	assert.Nil(t, rs1.EffectProbs("C"))
+	assert.InDeltaSlice(t, []float64{0, 0.4, 0.6}, rs2.Probs, 1e-12, "a dosage keeps its expectation")
+	assert.Nil(t, out.ValidatedSNPs[2].Probs, "multi-allelic records have no biallelic probabilities")
+	assert.Nil(t, out.ValidatedSNPs[3].Probs, "missing dosage")
+}
@@ -0,0 +1,47 @@
+package gwas
+
+import "phite.io/polygenic-risk-calculator/internal/model"
+
+// Reason codes for variants excluded during effect-allele validation.
+const (
+	ReasonNoCall              = "no_call"               // genotype is missing, ambiguous, or not two bases
+	ReasonMissingEffectAllele = "missing_effect_allele" // GWAS record has no effect allele
+	ReasonUnsupportedAllele   = "unsupported_allele"    // effect or other allele is not a single A/C/G/T base
+	ReasonAlleleMismatch      = "allele_mismatch"       // genotype carries an allele that is neither the effect nor the other allele
+)
+
+// validateAlleles checks that a genotype is consistent with a GWAS record's effect/other
+// alleles. It returns an empty string when the variant can be scored, or a reason code.
+// When the record has no other allele, only the genotype and effect allele are checked.
+func validateAlleles(genotype string, assoc model.GWASSNPRecord) string {
+	if len(genotype) != 2 || !isBase(genotype[0]) || !isBase(genotype[1]) {
+		return ReasonNoCall
+	}
+	if assoc.RiskAllele == "" {
+		return ReasonMissingEffectAllele
+	}
+	if len(assoc.RiskAllele) != 1 || !isBase(assoc.RiskAllele[0]) {
+		return ReasonUnsupportedAllele
+	}
+	if assoc.OtherAllele == "" {
+		return ""
+	}
+	if len(assoc.OtherAllele) != 1 || !isBase(assoc.OtherAllele[0]) {
+		return ReasonUnsupportedAllele
+	}
+	for i := 0; i < 2; i++ {
+		a := genotype[i]
+		if a != assoc.RiskAllele[0] && a != assoc.OtherAllele[0] {
+			return ReasonAlleleMismatch
+		}
+	}
+	return ""
+}
+
+func isBase(b byte) bool {
+	switch b {
+	case 'A', 'C', 'G', 'T':
+		return true
+	}
+	return false
+}
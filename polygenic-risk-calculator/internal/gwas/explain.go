@@ -0,0 +1,147 @@
+package gwas
+
+import (
+	"fmt"
+
+	"phite.io/polygenic-risk-calculator/internal/contig"
+	"phite.io/polygenic-risk-calculator/internal/dosage"
+	"phite.io/polygenic-risk-calculator/internal/genotype"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// Reason codes of a dosage explanation, in addition to the allele validation reasons.
+const (
+	ReasonHomozygousEffect = "homozygous_effect" // two effect alleles
+	ReasonHeterozygous     = "heterozygous"      // one effect allele
+	ReasonHomozygousOther  = "homozygous_other"  // no effect allele: scored with dosage 0
+	ReasonHemizygous       = "hemizygous"        // single-allele call, e.g. chrX in males; not scored
+	ReasonNotGenotyped     = "not_genotyped"     // rsid absent from the genotype file, or on a dropped contig
+	ReasonNoAssociation    = "no_association"    // no GWAS record for the rsid
+)
+
+// DosageExplanation records how the dosage of one variant for one trait was assigned.
+type DosageExplanation struct {
+	RSID         string  `json:"rsid"`
+	Trait        string  `json:"trait,omitempty"`
+	Observed     string  `json:"observed"` // call as read from the genotype file, separator removed
+	Phased       bool    `json:"phased,omitempty"`
+	EffectAllele string  `json:"effect_allele,omitempty"`
+	OtherAllele  string  `json:"other_allele,omitempty"`
+	Scored       bool    `json:"scored"`
+	Count        int     `json:"effect_allele_count"` // copies of the effect allele in the call
+	Strategy     string  `json:"strategy,omitempty"`
+	Dosage       float64 `json:"dosage"` // dosage scored; 0 when not scored
+	Reason       string  `json:"reason"`
+	Detail       string  `json:"detail"`
+}
+
+// ExplainDosageInput holds the inputs of ExplainDosage.
+type ExplainDosageInput struct {
+	RSIDs   []string                 // variants to explain, in report order
+	Calls   map[string]genotype.Call // every call of the genotype file, keyed by rsid
+	Records []model.GWASSNPRecord    // associations; an rsid may have one per trait
+	Dosage  *dosage.Selector         // optional; nil uses additive coding for every model
+	Contigs *contig.Normalizer       // optional; calls on contigs it drops count as not genotyped
+
+	DropAmbiguous bool // exclude palindromic (A/T, C/G) variants, as FetchAndAnnotateGWAS does
+}
+
+// ExplainDosage reports, for each rsid and each of its associations, the call observed, the
+// effect allele, and the dosage the scoring path assigns with the reason, following the same
+// rules as ParseGenotypeData and FetchAndAnnotateGWAS.
+func ExplainDosage(input ExplainDosageInput) []DosageExplanation {
+	byRSID := make(map[string][]model.GWASSNPRecord)
+	for _, r := range input.Records {
+		byRSID[r.RSID] = append(byRSID[r.RSID], r)
+	}
+
+	var out []DosageExplanation
+	seen := make(map[string]bool, len(input.RSIDs))
+	for _, rsid := range input.RSIDs {
+		if seen[rsid] {
+			continue
+		}
+		seen[rsid] = true
+		call, genotyped := input.Calls[rsid]
+		if genotyped {
+			_, _, genotyped = input.Contigs.Resolve(contig.SourceGenotype, call.Chrom, call.Pos)
+		}
+		records := byRSID[rsid]
+		if len(records) == 0 {
+			e := DosageExplanation{RSID: rsid, Observed: call.Genotype, Reason: ReasonNoAssociation, Detail: "no GWAS association; not scored for any trait"}
+			out = append(out, e)
+			continue
+		}
+		for _, r := range records {
+			out = append(out, explainOne(rsid, call, genotyped, r, input.Dosage, input.DropAmbiguous))
+		}
+	}
+	return out
+}
+
+func explainOne(rsid string, call genotype.Call, genotyped bool, r model.GWASSNPRecord, sel *dosage.Selector, dropAmbiguous bool) DosageExplanation {
+	e := DosageExplanation{
+		RSID:         rsid,
+		Trait:        r.Trait,
+		EffectAllele: r.RiskAllele,
+		OtherAllele:  r.OtherAllele,
+	}
+	if !genotyped {
+		e.Reason = ReasonNotGenotyped
+		e.Detail = "not in the genotype file (or on a contig the contig policy drops); reported missing"
+		return e
+	}
+	e.Observed, e.Phased = call.Genotype, call.Phased
+	if len(call.Genotype) == 1 && isBase(call.Genotype[0]) {
+		e.Reason = ReasonHemizygous
+		e.Detail = fmt.Sprintf("single allele %s on chr%s (hemizygous, e.g. X or Y in males); the parser requires two alleles, so the variant is reported missing", call.Genotype, call.Chrom)
+		return e
+	}
+	if len(call.Genotype) != 2 || !isBase(call.Genotype[0]) || !isBase(call.Genotype[1]) {
+		e.Reason = ReasonNoCall
+		e.Detail = fmt.Sprintf("call %q is not two A/C/G/T bases; reported missing", call.Genotype)
+		return e
+	}
+	scored, strand := harmonizeStrand(call.Genotype, r)
+	if strand == strandAmbiguous && dropAmbiguous {
+		e.Reason = ReasonAmbiguousStrand
+		e.Detail = fmt.Sprintf("excluded: effect allele %s and other allele %s are complementary, so the strand of %s cannot be told", r.RiskAllele, r.OtherAllele, call.Genotype)
+		return e
+	}
+	if reason := validateAlleles(scored, r); reason != "" {
+		e.Reason = reason
+		e.Detail = fmt.Sprintf("excluded: genotype %s, effect allele %q, other allele %q", call.Genotype, r.RiskAllele, r.OtherAllele)
+		return e
+	}
+
+	validated := model.ValidatedSNP{Probs: call.Probs, ProbsAlleles: call.Alleles}
+	c := dosage.Call{Genotype: scored, EffectAllele: r.RiskAllele, Probs: effectProbs(validated, r.RiskAllele, strand)}
+	strategy := sel.For(r.ModelID())
+	e.Scored = true
+	e.Count = c.EffectAlleleCount()
+	e.Strategy = strategy.Name()
+	e.Dosage = strategy.Dosage(c)
+	switch e.Count {
+	case 2:
+		e.Reason = ReasonHomozygousEffect
+	case 1:
+		e.Reason = ReasonHeterozygous
+	default:
+		e.Reason = ReasonHomozygousOther
+	}
+	e.Detail = fmt.Sprintf("%s carries %d cop%s of effect allele %s; %s dosage %g", scored, e.Count, plural(e.Count, "y", "ies"), r.RiskAllele, e.Strategy, e.Dosage)
+	if strand == strandFlipped {
+		e.Detail = fmt.Sprintf("%s is on the opposite strand to the model, read as %s; ", call.Genotype, scored) + e.Detail
+	}
+	if e.Dosage == 0 {
+		e.Detail += ", so it adds nothing to the score but counts toward coverage"
+	}
+	return e
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
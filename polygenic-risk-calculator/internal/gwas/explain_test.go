@@ -0,0 +1,87 @@
+package gwas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/dosage"
+	"phite.io/polygenic-risk-calculator/internal/genotype"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+func TestExplainDosage(t *testing.T) {
+	calls := map[string]genotype.Call{
+		"rs1": {RSID: "rs1", Chrom: "1", Pos: 10, Genotype: "GG"},
+		"rs2": {RSID: "rs2", Chrom: "1", Pos: 20, Genotype: "AG"},
+		"rs3": {RSID: "rs3", Chrom: "1", Pos: 30, Genotype: "AA"},
+		"rs4": {RSID: "rs4", Chrom: "X", Pos: 40, Genotype: "T"},
+		"rs5": {RSID: "rs5", Chrom: "1", Pos: 50, Genotype: "--"},
+		"rs6": {RSID: "rs6", Chrom: "1", Pos: 60, Genotype: "CG"},
+		"rs8": {RSID: "rs8", Chrom: "1", Pos: 80, Genotype: "AG"},
+	}
+	records := []model.GWASSNPRecord{
+		{RSID: "rs1", Trait: "height", RiskAllele: "G", OtherAllele: "A"},
+		{RSID: "rs2", Trait: "height", RiskAllele: "G", OtherAllele: "A"},
+		{RSID: "rs3", Trait: "height", RiskAllele: "G", OtherAllele: "A"},
+		{RSID: "rs4", Trait: "height", RiskAllele: "T"},
+		{RSID: "rs5", Trait: "height", RiskAllele: "G"},
+		{RSID: "rs6", Trait: "height", RiskAllele: "G", OtherAllele: "A"},
+		{RSID: "rs7", Trait: "height", RiskAllele: "G"},
+		{RSID: "rs2", Trait: "bmi", RiskAllele: "A", OtherAllele: "G"},
+	}
+	got := ExplainDosage(ExplainDosageInput{
+		RSIDs:   []string{"rs1", "rs2", "rs3", "rs4", "rs5", "rs6", "rs7", "rs8", "rs1"},
+		Calls:   calls,
+		Records: records,
+		Dosage:  &dosage.Selector{Default: dosage.Additive{}, PerModel: map[string]dosage.Strategy{"bmi": dosage.Recessive{}}},
+	})
+
+	type row struct {
+		rsid, trait, reason string
+		scored              bool
+		dosage              float64
+	}
+	var rows []row
+	for _, e := range got {
+		rows = append(rows, row{e.RSID, e.Trait, e.Reason, e.Scored, e.Dosage})
+	}
+	assert.Equal(t, []row{
+		{"rs1", "height", ReasonHomozygousEffect, true, 2},
+		{"rs2", "height", ReasonHeterozygous, true, 1},
+		{"rs2", "bmi", ReasonHeterozygous, true, 0}, // recessive coding of one copy
+		{"rs3", "height", ReasonHomozygousOther, true, 0},
+		{"rs4", "height", ReasonHemizygous, false, 0},
+		{"rs5", "height", ReasonNoCall, false, 0},
+		{"rs6", "height", ReasonAlleleMismatch, false, 0},
+		{"rs7", "height", ReasonNotGenotyped, false, 0},
+		{"rs8", "", ReasonNoAssociation, false, 0},
+	}, rows)
+
+	require.Len(t, got, 9)
+	assert.Equal(t, "recessive", got[2].Strategy)
+	assert.Equal(t, 1, got[2].Count)
+	assert.Contains(t, got[3].Detail, "adds nothing to the score")
+}
+
+// The explanation of every scored variant agrees with the annotation the scoring path makes.
+func TestExplainDosage_MatchesAnnotation(t *testing.T) {
+	records := []model.GWASSNPRecord{
+		{RSID: "rs1", Trait: "height", RiskAllele: "G", OtherAllele: "A", Beta: 0.1},
+		{RSID: "rs2", Trait: "height", RiskAllele: "C", Beta: -0.2},
+	}
+	calls := map[string]genotype.Call{
+		"rs1": {RSID: "rs1", Chrom: "1", Pos: 1, Genotype: "AG"},
+		"rs2": {RSID: "rs2", Chrom: "1", Pos: 2, Genotype: "CC"},
+	}
+	annotated := FetchAndAnnotateGWAS(GWASDataFetcherInput{
+		ValidatedSNPs:     []model.ValidatedSNP{{RSID: "rs1", Genotype: "AG"}, {RSID: "rs2", Genotype: "CC"}},
+		AssociationsClean: records,
+	}).AnnotatedSNPs
+	explained := ExplainDosage(ExplainDosageInput{RSIDs: []string{"rs1", "rs2"}, Calls: calls, Records: records})
+	require.Len(t, explained, len(annotated))
+	for i, a := range annotated {
+		assert.True(t, explained[i].Scored)
+		assert.Equal(t, a.ScoringDosage(), explained[i].Dosage, a.RSID)
+	}
+}
@@ -1,6 +1,11 @@
 package gwas
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/dosage"
 	"phite.io/polygenic-risk-calculator/internal/logging"
 	"phite.io/polygenic-risk-calculator/internal/model"
 )
@@ -8,31 +13,72 @@ import (
 type GWASDataFetcherInput struct {
 	ValidatedSNPs     []model.ValidatedSNP
 	AssociationsClean []model.GWASSNPRecord
+	Dosage            *dosage.Selector // optional; nil uses additive coding for every model
+	DropAmbiguous     bool             // exclude palindromic (A/T, C/G) variants rather than score them as reported
 }
 
 type GWASDataFetcherOutput struct {
 	AnnotatedSNPs []model.AnnotatedSNP
 	GWASRecords   []model.GWASSNPRecord
+	ExcludedSNPs  []model.ExcludedSNP // variants whose alleles are incompatible with the GWAS record
+	Harmonization model.AlleleHarmonization
 }
 
 // FetchAndAnnotateGWAS fetches GWAS associations for validated SNPs and annotates them with risk allele, effect size, and computed dosage.
+// Calls reported on the opposite strand to the record's effect/other alleles are reverse-complemented first; variants
+// whose genotype alleles are still inconsistent with them are excluded with a reason code.
 func FetchAndAnnotateGWAS(input GWASDataFetcherInput) GWASDataFetcherOutput {
 	logging.Info("Starting GWAS annotation for %d SNPs", len(input.ValidatedSNPs))
 
 	var result GWASDataFetcherOutput
+	excluded := make(map[string]map[string]int) // trait -> reason -> variants excluded
 	for _, snp := range input.ValidatedSNPs {
 		found := false
 		for _, assoc := range input.AssociationsClean {
 			if assoc.RSID == snp.RSID {
 				found = true
-				dosage := computeDosage(snp.Genotype, assoc.RiskAllele)
+				genotype, strand := harmonizeStrand(snp.Genotype, assoc)
+				reason := validateAlleles(genotype, assoc)
+				switch {
+				case strand == strandFlipped:
+					result.Harmonization.Flipped++
+				case strand == strandAmbiguous:
+					result.Harmonization.Ambiguous++
+					if input.DropAmbiguous {
+						reason = ReasonAmbiguousStrand
+					}
+				}
+				if reason == ReasonAlleleMismatch || reason == ReasonAmbiguousStrand {
+					result.Harmonization.Dropped++
+				}
+				if reason != "" {
+					logging.DebugRepeated("Excluding SNP %s for trait %q: %s (genotype %s, effect allele %q, other allele %q)",
+						snp.RSID, assoc.Trait, reason, snp.Genotype, assoc.RiskAllele, assoc.OtherAllele)
+					if excluded[assoc.Trait] == nil {
+						excluded[assoc.Trait] = make(map[string]int)
+					}
+					excluded[assoc.Trait][reason]++
+					result.ExcludedSNPs = append(result.ExcludedSNPs, model.ExcludedSNP{
+						RSID:     snp.RSID,
+						Trait:    assoc.Trait,
+						Genotype: snp.Genotype,
+						Reason:   reason,
+					})
+					continue
+				}
+				call := dosage.Call{Genotype: genotype, EffectAllele: assoc.RiskAllele, Probs: effectProbs(snp, assoc.RiskAllele, strand)}
 				annotated := model.AnnotatedSNP{
-					RSID:       snp.RSID,
-					Genotype:   snp.Genotype,
-					RiskAllele: assoc.RiskAllele,
-					Beta:       assoc.Beta,
-					Dosage:     dosage,
-					Trait:      assoc.Trait,
+					RSID:         snp.RSID,
+					Genotype:     genotype,
+					RiskAllele:   assoc.RiskAllele,
+					Beta:         assoc.Beta,
+					Dosage:       call.EffectAlleleCount(),
+					Trait:        assoc.Trait,
+					WeightSource: assoc.WeightSource,
+				}
+				if strategy := input.Dosage.For(assoc.ModelID()); strategy.Name() != (dosage.Additive{}).Name() {
+					coded := strategy.Dosage(call)
+					annotated.CodedDosage = &coded
 				}
 				result.AnnotatedSNPs = append(result.AnnotatedSNPs, annotated)
 				result.GWASRecords = append(result.GWASRecords, assoc)
@@ -42,23 +88,40 @@ func FetchAndAnnotateGWAS(input GWASDataFetcherInput) GWASDataFetcherOutput {
 			// logging.Info("No GWAS association found for SNP: %s", snp.RSID)
 		}
 	}
-	logging.Info("GWAS annotation complete: %d SNPs annotated", len(result.AnnotatedSNPs))
+	logExclusions(excluded)
+	logging.Info("GWAS annotation complete: %d SNPs annotated, %d excluded", len(result.AnnotatedSNPs), len(result.ExcludedSNPs))
+	if h := result.Harmonization; !h.Empty() {
+		logging.Info("Allele harmonization: %d calls strand-flipped, %d palindromic, %d dropped", h.Flipped, h.Ambiguous, h.Dropped)
+	}
 	return result
 }
 
-// computeDosage calculates the count of the risk allele in the genotype string. Ambiguous/missing genotypes yield 0.
-func computeDosage(genotype string, riskAllele string) int {
-	if len(genotype) != 2 {
-		return 0
-	}
-	if riskAllele == "" || genotype == "NN" || genotype == "--" {
-		return 0
+// logExclusions logs one warning per trait with the number of variants excluded for each
+// reason, in place of a warning per variant.
+func logExclusions(excluded map[string]map[string]int) {
+	traits := make([]string, 0, len(excluded))
+	for trait := range excluded {
+		traits = append(traits, trait)
 	}
-	count := 0
-	for i := 0; i < 2; i++ {
-		if string(genotype[i]) == riskAllele {
-			count++
+	sort.Strings(traits)
+	for _, trait := range traits {
+		reasons := make([]string, 0, len(excluded[trait]))
+		total := 0
+		for reason, n := range excluded[trait] {
+			reasons = append(reasons, fmt.Sprintf("%d %s", n, reason))
+			total += n
 		}
+		sort.Strings(reasons)
+		logging.Warn("Excluded %d SNPs for trait %q: %s", total, trait, strings.Join(reasons, ", "))
+	}
+}
+
+// effectProbs returns the call's genotype probabilities as copies of the effect allele. The
+// probabilities are counted in the call's alleles, so on a strand-flipped call the effect
+// allele is looked up by its complement.
+func effectProbs(snp model.ValidatedSNP, effect string, strand int) []float64 {
+	if strand == strandFlipped && len(effect) == 1 {
+		effect = string(model.Complement(effect[0]))
 	}
-	return count
+	return snp.EffectProbs(effect)
 }
@@ -1,10 +1,16 @@
 package gwas
 
 import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
 	"testing"
 	"phite.io/polygenic-risk-calculator/internal/model"
 	"reflect"
 
+	shared "github.com/JerkyTreats/PHITE/logging"
+	"phite.io/polygenic-risk-calculator/internal/dosage"
 	"phite.io/polygenic-risk-calculator/internal/logging"
 )
 
@@ -73,7 +79,161 @@ func TestFetchAndAnnotateGWAS_AmbiguousGenotype(t *testing.T) {
 		},
 	}
 	out := FetchAndAnnotateGWAS(input)
-	if len(out.AnnotatedSNPs) != 1 || out.AnnotatedSNPs[0].Dosage != 0 {
-		t.Errorf("Ambiguous genotype should yield dosage 0. Got %+v", out.AnnotatedSNPs)
+	if len(out.AnnotatedSNPs) != 0 {
+		t.Errorf("Ambiguous genotype should not be scored. Got %+v", out.AnnotatedSNPs)
+	}
+	if len(out.ExcludedSNPs) != 1 || out.ExcludedSNPs[0].Reason != ReasonNoCall {
+		t.Errorf("Ambiguous genotype should be excluded as %s. Got %+v", ReasonNoCall, out.ExcludedSNPs)
+	}
+}
+
+func TestFetchAndAnnotateGWAS_AlleleValidation(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	tests := []struct {
+		name     string
+		genotype string
+		record   model.GWASSNPRecord
+		reason   string
+	}{
+		{"consistent alleles", "AG", model.GWASSNPRecord{RiskAllele: "G", OtherAllele: "A"}, ""},
+		{"homozygous other allele", "AA", model.GWASSNPRecord{RiskAllele: "G", OtherAllele: "A"}, ""},
+		{"no other allele known", "CC", model.GWASSNPRecord{RiskAllele: "G"}, ""},
+		{"allele mismatch", "CG", model.GWASSNPRecord{RiskAllele: "G", OtherAllele: "A"}, ReasonAlleleMismatch},
+		{"missing effect allele", "AG", model.GWASSNPRecord{}, ReasonMissingEffectAllele},
+		{"indel effect allele", "AG", model.GWASSNPRecord{RiskAllele: "AT"}, ReasonUnsupportedAllele},
+		{"no call", "--", model.GWASSNPRecord{RiskAllele: "G"}, ReasonNoCall},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.record.RSID = "rs1"
+			tc.record.Trait = "height"
+			out := FetchAndAnnotateGWAS(GWASDataFetcherInput{
+				ValidatedSNPs:     []model.ValidatedSNP{{RSID: "rs1", Genotype: tc.genotype, FoundInGWAS: true}},
+				AssociationsClean: []model.GWASSNPRecord{tc.record},
+			})
+			if tc.reason == "" {
+				if len(out.AnnotatedSNPs) != 1 || len(out.ExcludedSNPs) != 0 {
+					t.Errorf("expected SNP to be scored, got annotated=%+v excluded=%+v", out.AnnotatedSNPs, out.ExcludedSNPs)
+				}
+				return
+			}
+			if len(out.AnnotatedSNPs) != 0 {
+				t.Errorf("expected SNP to be excluded, got %+v", out.AnnotatedSNPs)
+			}
+			want := []model.ExcludedSNP{{RSID: "rs1", Trait: "height", Genotype: tc.genotype, Reason: tc.reason}}
+			if !reflect.DeepEqual(out.ExcludedSNPs, want) {
+				t.Errorf("excluded = %+v, want %+v", out.ExcludedSNPs, want)
+			}
+		})
+	}
+}
+
+func TestFetchAndAnnotateGWAS_LogsExclusionsPerTrait(t *testing.T) {
+	t.Setenv(shared.EnvLevel, "info")
+	var buf bytes.Buffer
+	logging.SetOutput(&buf)
+	t.Cleanup(func() { logging.SetOutput(nil) })
+
+	var snps []model.ValidatedSNP
+	var records []model.GWASSNPRecord
+	// Two palindromic A/T records, and two calls matching neither strand of an A/G record
+	for i, c := range []struct{ genotype, other string }{{"AT", "T"}, {"AA", "T"}, {"AC", "G"}, {"GT", "G"}} {
+		rsid := fmt.Sprintf("rs%d", i)
+		snps = append(snps, model.ValidatedSNP{RSID: rsid, Genotype: c.genotype, FoundInGWAS: true})
+		records = append(records, model.GWASSNPRecord{RSID: rsid, Trait: "height", RiskAllele: "A", OtherAllele: c.other})
+	}
+	out := FetchAndAnnotateGWAS(GWASDataFetcherInput{ValidatedSNPs: snps, AssociationsClean: records, DropAmbiguous: true})
+
+	if len(out.ExcludedSNPs) != 4 {
+		t.Fatalf("expected 4 excluded SNPs, got %+v", out.ExcludedSNPs)
+	}
+	log := buf.String()
+	if strings.Contains(log, "Excluding SNP") {
+		t.Errorf("expected no per-variant warnings at info level, got:\n%s", log)
+	}
+	if n := strings.Count(log, "Excluded "); n != 1 {
+		t.Fatalf("expected one summary for the trait, got %d:\n%s", n, log)
+	}
+	if !strings.Contains(log, `Excluded 4 SNPs for trait \"height\": 2 allele_mismatch, 2 ambiguous_strand`) {
+		t.Errorf("unexpected summary:\n%s", log)
+	}
+}
+
+func TestFetchAndAnnotateGWAS_DosageStrategy(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	input := GWASDataFetcherInput{
+		ValidatedSNPs: []model.ValidatedSNP{
+			{RSID: "rs6", Genotype: "AG", FoundInGWAS: true},
+		},
+		AssociationsClean: []model.GWASSNPRecord{
+			{RSID: "rs6", RiskAllele: "G", Beta: 0.4, Trait: "celiac"},
+			{RSID: "rs6", RiskAllele: "G", Beta: 0.1, Trait: "height"},
+		},
+		Dosage: &dosage.Selector{Default: dosage.Additive{}, PerModel: map[string]dosage.Strategy{"celiac": dosage.Recessive{}}},
+	}
+	out := FetchAndAnnotateGWAS(input)
+	if len(out.AnnotatedSNPs) != 2 {
+		t.Fatalf("expected two annotations, got %+v", out.AnnotatedSNPs)
+	}
+	for _, snp := range out.AnnotatedSNPs {
+		if snp.Dosage != 1 {
+			t.Errorf("%s: risk allele count should stay 1, got %d", snp.Trait, snp.Dosage)
+		}
+		switch snp.Trait {
+		case "celiac":
+			if snp.CodedDosage == nil || snp.ScoringDosage() != 0 {
+				t.Errorf("recessive coding of a heterozygote should score 0, got %+v", snp)
+			}
+		case "height":
+			if snp.CodedDosage != nil || snp.ScoringDosage() != 1 {
+				t.Errorf("additive coding should score the allele count, got %+v", snp)
+			}
+		}
+	}
+}
+
+func TestFetchAndAnnotateGWAS_ProbabilisticDosage(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	probs := []float64{0.1, 0.3, 0.6} // P(0), P(1), P(2) copies of ALT G
+	input := GWASDataFetcherInput{
+		ValidatedSNPs: []model.ValidatedSNP{
+			{RSID: "rs7", Genotype: "GG", Probs: probs, ProbsAlleles: [2]string{"A", "G"}},
+			{RSID: "rs8", Genotype: "GG", Probs: probs, ProbsAlleles: [2]string{"A", "G"}},
+			{RSID: "rs9", Genotype: "GG", Probs: probs, ProbsAlleles: [2]string{"A", "G"}},
+		},
+		AssociationsClean: []model.GWASSNPRecord{
+			{RSID: "rs7", RiskAllele: "G", OtherAllele: "A", Beta: 1, Trait: "height"},
+			{RSID: "rs8", RiskAllele: "A", OtherAllele: "G", Beta: 1, Trait: "height"},
+			{RSID: "rs9", RiskAllele: "C", OtherAllele: "T", Beta: 1, Trait: "height"}, // opposite strand
+		},
+		Dosage: &dosage.Selector{Default: dosage.Probabilistic{}},
+	}
+	out := FetchAndAnnotateGWAS(input)
+	want := map[string]float64{"rs7": 1.5, "rs8": 0.5, "rs9": 1.5}
+	if len(out.AnnotatedSNPs) != len(want) {
+		t.Fatalf("expected %d annotations, got %+v", len(want), out.AnnotatedSNPs)
+	}
+	for _, snp := range out.AnnotatedSNPs {
+		if got := snp.ScoringDosage(); math.Abs(got-want[snp.RSID]) > 1e-12 {
+			t.Errorf("%s: expected dosage %g, got %g", snp.RSID, want[snp.RSID], got)
+		}
+	}
+}
+
+func TestFetchAndAnnotateGWAS_DosageStrategyByModel(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	input := GWASDataFetcherInput{
+		ValidatedSNPs: []model.ValidatedSNP{
+			{RSID: "rs6", Genotype: "AG", FoundInGWAS: true},
+		},
+		AssociationsClean: []model.GWASSNPRecord{
+			{RSID: "rs6", RiskAllele: "G", Beta: 0.4, Trait: "height", Model: "PGS000297"},
+		},
+		// The strategy is configured for the model, not the trait it scores
+		Dosage: &dosage.Selector{Default: dosage.Additive{}, PerModel: map[string]dosage.Strategy{"pgs000297": dosage.Dominant{}, "height": dosage.Recessive{}}},
+	}
+	out := FetchAndAnnotateGWAS(input)
+	if len(out.AnnotatedSNPs) != 1 || out.AnnotatedSNPs[0].CodedDosage == nil || out.AnnotatedSNPs[0].ScoringDosage() != 1 {
+		t.Fatalf("expected dominant coding of the model to score a heterozygote 1, got %+v", out.AnnotatedSNPs)
 	}
 }
@@ -4,15 +4,28 @@ import (
 	"context"
 	"fmt"
 	"strconv"
-	"strings"
 
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
 	"phite.io/polygenic-risk-calculator/internal/config"
 	"phite.io/polygenic-risk-calculator/internal/db"
+	"phite.io/polygenic-risk-calculator/internal/db/dbutil"
 	dbinterface "phite.io/polygenic-risk-calculator/internal/db/interface"
 	"phite.io/polygenic-risk-calculator/internal/logging"
 	"phite.io/polygenic-risk-calculator/internal/model"
 )
 
+// OtherAlleleColumnKey optionally names the GWAS table column holding the non-effect allele.
+// When set, records carry an OtherAllele so genotype alleles can be validated against it.
+const OtherAlleleColumnKey = "gwas_other_allele_column"
+
+// GWASFetcher loads the GWAS associations of a set of rsids. GWASService is the default
+// implementation; tests and alternative association sources can substitute their own.
+type GWASFetcher interface {
+	FetchGWASRecords(ctx context.Context, rsids []string, anc ...*ancestry.Ancestry) (map[string]model.GWASSNPRecord, error)
+}
+
+var _ GWASFetcher = (*GWASService)(nil)
+
 type GWASService struct {
 	repo dbinterface.Repository
 }
@@ -29,32 +42,62 @@ func NewGWASService() *GWASService {
 }
 
 // FetchGWASRecordsWithTable loads GWAS SNP records for the given rsids from the specified table using the repository abstraction.
-func (s *GWASService) FetchGWASRecordsWithTable(ctx context.Context, table string, rsids []string) (map[string]model.GWASSNPRecord, error) {
+// When an ancestry is given and the table has its weight column (e.g. beta_eur), that weight is used in place of beta
+// wherever it is non-null; each record's WeightSource names the column used.
+func (s *GWASService) FetchGWASRecordsWithTable(ctx context.Context, table string, rsids []string, anc ...*ancestry.Ancestry) (map[string]model.GWASSNPRecord, error) {
 	if len(rsids) == 0 {
 		return map[string]model.GWASSNPRecord{}, nil
 	}
-	placeholders := make([]string, len(rsids))
-	args := make([]interface{}, len(rsids))
-	for i, rsid := range rsids {
-		placeholders[i] = "?"
-		args[i] = rsid
+	dialect := dbutil.DialectOf(s.repo)
+	columns := []string{"rsid", "risk_allele", "beta", "trait"}
+	otherCol := config.GetString(OtherAlleleColumnKey)
+	var weightAncestry *ancestry.Ancestry
+	if len(anc) > 0 {
+		if col := anc[0].WeightColumn(); col != "" {
+			if err := s.repo.ValidateTable(ctx, table, []string{col}); err == nil {
+				weightAncestry = anc[0]
+			} else {
+				logging.Debug("GWAS table %s has no %s column; using generic beta", table, col)
+			}
+		}
 	}
-	query := "SELECT rsid, risk_allele, beta, trait FROM " + table + " WHERE rsid IN (" + strings.Join(placeholders, ",") + ")"
 	logging.Info("Executing GWAS query for %d SNPs", len(rsids))
 
-	results, err := s.repo.Query(ctx, query, args...)
-	if err != nil {
-		logging.Error("GWAS query failed: %v", err)
-		return nil, err
+	var results []map[string]interface{}
+	for _, chunk := range dbutil.Chunks(rsids, dbutil.DefaultChunkSize) {
+		args := make([]interface{}, len(chunk))
+		for i, rsid := range chunk {
+			args[i] = rsid
+		}
+		b := dialect.Select(columns...)
+		if otherCol != "" {
+			b.ColumnAs(otherCol, "other_allele")
+		}
+		if weightAncestry != nil {
+			b.Column(weightAncestry.WeightColumn())
+		}
+		query, args, err := b.From(table).WhereIn("rsid", args).Build()
+		if err != nil {
+			return nil, err
+		}
+		rows, err := s.repo.Query(ctx, query, args...)
+		if err != nil {
+			logging.Error("GWAS query failed: %v", err)
+			return nil, err
+		}
+		results = append(results, rows...)
 	}
 
 	recordMap := make(map[string]model.GWASSNPRecord, len(results))
 	for _, row := range results {
+		beta, weightSource := weightAncestry.SelectWeight(row, "beta")
 		rec := model.GWASSNPRecord{
-			RSID:       toString(row["rsid"]),
-			RiskAllele: toString(row["risk_allele"]),
-			Beta:       toFloat64(row["beta"]),
-			Trait:      toString(row["trait"]),
+			RSID:         toString(row["rsid"]),
+			RiskAllele:   toString(row["risk_allele"]),
+			OtherAllele:  toString(row["other_allele"]),
+			Beta:         beta,
+			Trait:        toString(row["trait"]),
+			WeightSource: weightSource,
 		}
 		recordMap[rec.RSID] = rec
 	}
@@ -63,12 +106,12 @@ func (s *GWASService) FetchGWASRecordsWithTable(ctx context.Context, table strin
 }
 
 // FetchGWASRecords loads GWAS SNP records for the given rsids from the configured table using the repository abstraction.
-func (s *GWASService) FetchGWASRecords(ctx context.Context, rsids []string) (map[string]model.GWASSNPRecord, error) {
+func (s *GWASService) FetchGWASRecords(ctx context.Context, rsids []string, anc ...*ancestry.Ancestry) (map[string]model.GWASSNPRecord, error) {
 	table := config.GetString("gwas_table")
 	if table == "" {
 		return nil, fmt.Errorf("gwas_table is not set")
 	}
-	return s.FetchGWASRecordsWithTable(ctx, table, rsids)
+	return s.FetchGWASRecordsWithTable(ctx, table, rsids, anc...)
 }
 
 // Helper functions for type conversion
@@ -3,10 +3,13 @@ package gwas_test
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
 	"phite.io/polygenic-risk-calculator/internal/config"
 	"phite.io/polygenic-risk-calculator/internal/db"
+	"phite.io/polygenic-risk-calculator/internal/db/duckdb"
 	"phite.io/polygenic-risk-calculator/internal/gwas"
 	"phite.io/polygenic-risk-calculator/internal/logging"
 )
@@ -156,3 +159,46 @@ func setEnv(key, val string) func() {
 		}
 	}
 }
+
+func TestFetchGWASRecords_AncestrySpecificWeights(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+
+	path := filepath.Join(t.TempDir(), "gwas.duckdb")
+	sqlDB, err := duckdb.OpenDB(path)
+	if err != nil {
+		t.Fatalf("failed to create test db: %v", err)
+	}
+	_, err = sqlDB.Exec(`CREATE TABLE weighted (rsid VARCHAR, risk_allele VARCHAR, beta DOUBLE, beta_eur DOUBLE, trait VARCHAR);
+		INSERT INTO weighted VALUES ('rs1', 'A', 0.1, 0.3, 'Height'), ('rs2', 'G', 0.2, NULL, 'Height')`)
+	sqlDB.Close()
+	if err != nil {
+		t.Fatalf("failed to seed test db: %v", err)
+	}
+
+	config.Set("gwas_db_path", path)
+	defer setupGWASTestConfig(t)
+	service := gwas.NewGWASService()
+	ctx := context.Background()
+
+	eur, _ := ancestry.New("EUR", "")
+	records, err := service.FetchGWASRecordsWithTable(ctx, "weighted", []string{"rs1", "rs2"}, eur)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := records["rs1"]; got.Beta != 0.3 || got.WeightSource != "beta_eur" {
+		t.Errorf("rs1: expected beta_eur weight 0.3, got %v from %q", got.Beta, got.WeightSource)
+	}
+	if got := records["rs2"]; got.Beta != 0.2 || got.WeightSource != "beta" {
+		t.Errorf("rs2: expected fallback beta 0.2, got %v from %q", got.Beta, got.WeightSource)
+	}
+
+	// No beta_afr column: every record falls back to the generic weight
+	afr, _ := ancestry.New("AFR", "")
+	records, err = service.FetchGWASRecordsWithTable(ctx, "weighted", []string{"rs1"}, afr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := records["rs1"]; got.Beta != 0.1 || got.WeightSource != "beta" {
+		t.Errorf("rs1: expected generic weight 0.1, got %v from %q", got.Beta, got.WeightSource)
+	}
+}
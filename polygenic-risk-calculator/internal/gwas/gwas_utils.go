@@ -1,8 +1,12 @@
 package gwas
 
-import "phite.io/polygenic-risk-calculator/internal/model"
+import (
+	"sort"
 
-// MapToGWASList converts a map of GWASSNPRecord to a slice for annotation.
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// MapToGWASList converts a map of GWASSNPRecord to a slice for annotation, sorted by rsid.
 func MapToGWASList(m map[string]model.GWASSNPRecord) []model.GWASSNPRecord {
 	if m == nil {
 		return nil
@@ -11,5 +15,6 @@ func MapToGWASList(m map[string]model.GWASSNPRecord) []model.GWASSNPRecord {
 	for _, rec := range m {
 		records = append(records, rec)
 	}
+	sort.Slice(records, func(i, j int) bool { return records[i].RSID < records[j].RSID })
 	return records
 }
@@ -0,0 +1,70 @@
+package gwas
+
+import (
+	"fmt"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// Domain-specific configuration keys for allele harmonization
+const (
+	AmbiguousStrandKey = "gwas.ambiguous_strand" // Palindromic (A/T, C/G) variants: "keep" (default) scores them as reported; "drop" excludes them
+)
+
+// Ambiguous strand policies.
+const (
+	AmbiguousKeep = "keep"
+	AmbiguousDrop = "drop"
+)
+
+// ReasonAmbiguousStrand is the reason code of palindromic variants excluded under the drop policy.
+const ReasonAmbiguousStrand = "ambiguous_strand"
+
+// DropAmbiguousFromConfig reports whether AmbiguousStrandKey drops palindromic variants.
+func DropAmbiguousFromConfig() (bool, error) {
+	switch policy := config.GetString(AmbiguousStrandKey); policy {
+	case "", AmbiguousKeep:
+		return false, nil
+	case AmbiguousDrop:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%s: unknown policy %q: use %s or %s", AmbiguousStrandKey, policy, AmbiguousKeep, AmbiguousDrop)
+	}
+}
+
+// Strand outcomes of harmonizeStrand.
+const (
+	strandMatched   = iota // alleles already on the record's strand, or not checkable
+	strandFlipped          // alleles on the opposite strand; the call was reverse-complemented
+	strandAmbiguous        // palindromic record; the call is kept as reported
+)
+
+// harmonizeStrand orients a genotype call to the strand of a GWAS record's effect and
+// other alleles. A call whose alleles are not the record's, but whose complements are, was
+// reported on the opposite strand and is returned complemented. When the record's alleles
+// are complements of each other (A/T or C/G) the strand cannot be told from the alleles, so
+// the call is returned unchanged as ambiguous. Calls and records validateAlleles rejects are
+// returned unchanged, for it to report.
+func harmonizeStrand(genotype string, assoc model.GWASSNPRecord) (string, int) {
+	if validateAlleles(genotype, assoc) == ReasonNoCall || len(assoc.RiskAllele) != 1 || len(assoc.OtherAllele) != 1 {
+		return genotype, strandMatched
+	}
+	effect, other := assoc.RiskAllele[0], assoc.OtherAllele[0]
+	if !isBase(effect) || !isBase(other) {
+		return genotype, strandMatched
+	}
+	if model.Complement(effect) == other {
+		return genotype, strandAmbiguous
+	}
+	fits := func(a, b byte) bool {
+		return (a == effect || a == other) && (b == effect || b == other)
+	}
+	if fits(genotype[0], genotype[1]) {
+		return genotype, strandMatched
+	}
+	if fits(model.Complement(genotype[0]), model.Complement(genotype[1])) {
+		return string([]byte{model.Complement(genotype[0]), model.Complement(genotype[1])}), strandFlipped
+	}
+	return genotype, strandMatched
+}
@@ -0,0 +1,63 @@
+package gwas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+func TestFetchAndAnnotateGWAS_StrandHarmonization(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	snps := []model.ValidatedSNP{
+		{RSID: "rs1", Genotype: "CT"}, // G/A record on the opposite strand: read as GA
+		{RSID: "rs2", Genotype: "TT"}, // read as AA
+		{RSID: "rs3", Genotype: "AT"}, // palindromic A/T record
+		{RSID: "rs4", Genotype: "CA"}, // neither strand
+		{RSID: "rs5", Genotype: "GG"},
+	}
+	records := []model.GWASSNPRecord{
+		{RSID: "rs1", Trait: "height", RiskAllele: "G", OtherAllele: "A"},
+		{RSID: "rs2", Trait: "height", RiskAllele: "G", OtherAllele: "A"},
+		{RSID: "rs3", Trait: "height", RiskAllele: "T", OtherAllele: "A"},
+		{RSID: "rs4", Trait: "height", RiskAllele: "G", OtherAllele: "A"},
+		{RSID: "rs5", Trait: "height", RiskAllele: "G", OtherAllele: "A"},
+	}
+
+	out := FetchAndAnnotateGWAS(GWASDataFetcherInput{ValidatedSNPs: snps, AssociationsClean: records})
+	dosages := make(map[string]int)
+	genotypes := make(map[string]string)
+	for _, a := range out.AnnotatedSNPs {
+		dosages[a.RSID] = a.Dosage
+		genotypes[a.RSID] = a.Genotype
+	}
+	assert.Equal(t, map[string]int{"rs1": 1, "rs2": 0, "rs3": 1, "rs5": 2}, dosages)
+	assert.Equal(t, "GA", genotypes["rs1"])
+	assert.Equal(t, "AA", genotypes["rs2"])
+	require.Len(t, out.ExcludedSNPs, 1)
+	assert.Equal(t, model.ExcludedSNP{RSID: "rs4", Trait: "height", Genotype: "CA", Reason: ReasonAlleleMismatch}, out.ExcludedSNPs[0])
+	assert.Equal(t, model.AlleleHarmonization{Flipped: 2, Ambiguous: 1, Dropped: 1}, out.Harmonization)
+
+	out = FetchAndAnnotateGWAS(GWASDataFetcherInput{ValidatedSNPs: snps, AssociationsClean: records, DropAmbiguous: true})
+	require.Len(t, out.ExcludedSNPs, 2)
+	assert.Equal(t, model.ExcludedSNP{RSID: "rs3", Trait: "height", Genotype: "AT", Reason: ReasonAmbiguousStrand}, out.ExcludedSNPs[0])
+	assert.Equal(t, model.AlleleHarmonization{Flipped: 2, Ambiguous: 1, Dropped: 2}, out.Harmonization)
+}
+
+func TestDropAmbiguousFromConfig(t *testing.T) {
+	old := config.GetString(AmbiguousStrandKey)
+	defer config.Set(AmbiguousStrandKey, old)
+
+	for policy, want := range map[string]bool{"": false, AmbiguousKeep: false, AmbiguousDrop: true} {
+		config.Set(AmbiguousStrandKey, policy)
+		got, err := DropAmbiguousFromConfig()
+		require.NoError(t, err)
+		assert.Equal(t, want, got, policy)
+	}
+	config.Set(AmbiguousStrandKey, "flip")
+	_, err := DropAmbiguousFromConfig()
+	assert.Error(t, err)
+}
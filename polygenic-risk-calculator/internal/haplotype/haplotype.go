@@ -0,0 +1,299 @@
+// Package haplotype scores model terms whose weights apply to haplotypes — combinations of
+// alleles at several SNPs on the same chromosome copy, such as the APOE ε alleles — rather
+// than to single SNPs.
+//
+// Haplotypes are defined in a tab-separated file with the columns trait, haplotype name,
+// weight, reference frequency (optional), and the defining alleles as comma-separated
+// rsid:allele pairs:
+//
+//	alzheimers	APOE_e4	1.12	0.14	rs429358:C,rs7412:C
+//
+// A haplotype's dosage is the number of chromosome copies carrying every defining allele.
+// It is exact when the calls are phased, or when at most one defining SNP is heterozygous;
+// otherwise the phase is ambiguous and the haplotype is excluded from scoring. Phased calls
+// (written "C|T" in the genotype file) are assumed to share one phase set: the first allele
+// of every phased call lies on the same chromosome copy.
+package haplotype
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// Domain-specific configuration keys for haplotype models
+const (
+	DefinitionsFileKey = "haplotype.definitions_file" // TSV of haplotype definitions scored alongside SNP models
+)
+
+// Reason codes for haplotypes excluded from scoring.
+const (
+	ReasonAmbiguousPhase = "ambiguous_phase"       // two or more defining SNPs are heterozygous and not all are phased
+	ReasonMissingSNP     = "missing_haplotype_snp" // a defining SNP has no valid call
+)
+
+// WeightSource is reported as the weight source of haplotype terms.
+const WeightSource = "haplotype"
+
+// Allele is one defining allele of a haplotype.
+type Allele struct {
+	RSID   string
+	Allele string
+}
+
+// Definition is a haplotype and the weight it contributes per copy to a trait's score.
+type Definition struct {
+	Trait     string
+	Name      string
+	Weight    float64
+	Frequency float64 // haplotype frequency in the reference population; 0 when unknown
+	Alleles   []Allele
+}
+
+// Set is a collection of haplotype definitions. A nil Set defines no haplotypes.
+type Set struct {
+	defs []Definition
+}
+
+// FromConfig loads the configured definitions file, returning nil when none is configured.
+func FromConfig() (*Set, error) {
+	path := config.GetString(DefinitionsFileKey)
+	if path == "" {
+		return nil, nil
+	}
+	return Load(path)
+}
+
+// Load reads haplotype definitions from a file.
+func Load(path string) (*Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open haplotype definitions: %w", err)
+	}
+	defer f.Close()
+	return Parse(f, path)
+}
+
+// Parse reads haplotype definitions; name labels errors. Blank lines, lines starting with #,
+// and a header row starting with "trait" are ignored.
+func Parse(r io.Reader, name string) (*Set, error) {
+	s := &Set{}
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(strings.ToLower(text), "trait\t") {
+			continue
+		}
+		cols := strings.Split(text, "\t")
+		if len(cols) != 5 {
+			return nil, fmt.Errorf("%s:%d: expected 5 tab-separated columns, got %d", name, line, len(cols))
+		}
+		def := Definition{Trait: strings.TrimSpace(cols[0]), Name: strings.TrimSpace(cols[1])}
+		if def.Trait == "" || def.Name == "" {
+			return nil, fmt.Errorf("%s:%d: trait and haplotype name are required", name, line)
+		}
+		key := def.Trait + "\x00" + def.Name
+		if seen[key] {
+			return nil, fmt.Errorf("%s:%d: duplicate haplotype %s for trait %s", name, line, def.Name, def.Trait)
+		}
+		seen[key] = true
+		weight, err := strconv.ParseFloat(strings.TrimSpace(cols[2]), 64)
+		if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return nil, fmt.Errorf("%s:%d: invalid weight %q", name, line, cols[2])
+		}
+		def.Weight = weight
+		if f := strings.TrimSpace(cols[3]); f != "" {
+			def.Frequency, err = strconv.ParseFloat(f, 64)
+			if err != nil || def.Frequency < 0 || def.Frequency > 1 {
+				return nil, fmt.Errorf("%s:%d: invalid frequency %q: must be between 0 and 1", name, line, cols[3])
+			}
+		}
+		for _, pair := range strings.Split(cols[4], ",") {
+			rsid, allele, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			allele = strings.ToUpper(allele)
+			if !ok || rsid == "" || len(allele) != 1 || !strings.Contains("ACGT", allele) {
+				return nil, fmt.Errorf("%s:%d: invalid allele %q: expected rsid:A|C|G|T", name, line, pair)
+			}
+			def.Alleles = append(def.Alleles, Allele{RSID: rsid, Allele: allele})
+		}
+		s.defs = append(s.defs, def)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read haplotype definitions: %w", err)
+	}
+	return s, nil
+}
+
+// Definitions returns the definitions in file order.
+func (s *Set) Definitions() []Definition {
+	if s == nil {
+		return nil
+	}
+	return s.defs
+}
+
+// RSIDs returns the distinct SNPs the haplotypes are defined over, sorted.
+func (s *Set) RSIDs() []string {
+	seen := make(map[string]bool)
+	var rsids []string
+	for _, def := range s.Definitions() {
+		for _, a := range def.Alleles {
+			if !seen[a.RSID] {
+				seen[a.RSID] = true
+				rsids = append(rsids, a.RSID)
+			}
+		}
+	}
+	sort.Strings(rsids)
+	return rsids
+}
+
+// Without returns the definitions whose traits are not excluded.
+func (s *Set) Without(excluded func(trait string) bool) *Set {
+	if s == nil {
+		return nil
+	}
+	kept := &Set{}
+	for _, def := range s.defs {
+		if !excluded(def.Trait) {
+			kept.defs = append(kept.defs, def)
+		}
+	}
+	return kept
+}
+
+// CountByTrait returns the number of haplotypes defined per trait.
+func (s *Set) CountByTrait() map[string]int {
+	counts := make(map[string]int)
+	for _, def := range s.Definitions() {
+		counts[def.Trait]++
+	}
+	return counts
+}
+
+// Dosage returns the number of chromosome copies carrying every defining allele of def, or
+// a reason code when it cannot be determined from calls (rsid -> validated call).
+func Dosage(def Definition, calls map[string]model.ValidatedSNP) (int, string) {
+	var hets []int
+	phased := true
+	for i, a := range def.Alleles {
+		call, ok := calls[a.RSID]
+		if !ok || len(call.Genotype) != 2 {
+			return 0, ReasonMissingSNP
+		}
+		switch strings.Count(call.Genotype, a.Allele) {
+		case 0:
+			return 0, "" // no copy can carry the haplotype
+		case 1:
+			hets = append(hets, i)
+			phased = phased && call.Phased
+		}
+	}
+	switch {
+	case len(hets) == 0:
+		return 2, ""
+	case phased:
+		// Count the copies whose allele matches at every heterozygous site
+		dosage := 0
+		for copy := 0; copy < 2; copy++ {
+			carries := true
+			for _, i := range hets {
+				a := def.Alleles[i]
+				carries = carries && calls[a.RSID].Genotype[copy] == a.Allele[0]
+			}
+			if carries {
+				dosage++
+			}
+		}
+		return dosage, ""
+	case len(hets) == 1:
+		return 1, ""
+	default:
+		return 0, ReasonAmbiguousPhase
+	}
+}
+
+// Annotate scores every haplotype against the validated calls, returning one annotated term
+// per haplotype whose dosage is known and an exclusion for each of the rest.
+func (s *Set) Annotate(validated []model.ValidatedSNP) ([]model.AnnotatedSNP, []model.ExcludedSNP) {
+	if len(s.Definitions()) == 0 {
+		return nil, nil
+	}
+	calls := make(map[string]model.ValidatedSNP, len(validated))
+	for _, v := range validated {
+		calls[v.RSID] = v
+	}
+
+	var annotated []model.AnnotatedSNP
+	var excluded []model.ExcludedSNP
+	for _, def := range s.defs {
+		genotype := haplotypeGenotype(def, calls)
+		dosage, reason := Dosage(def, calls)
+		if reason != "" {
+			logging.Warn("Excluding haplotype %s for trait %q: %s (%s)", def.Name, def.Trait, reason, genotype)
+			excluded = append(excluded, model.ExcludedSNP{RSID: def.Name, Trait: def.Trait, Genotype: genotype, Reason: reason})
+			continue
+		}
+		annotated = append(annotated, model.AnnotatedSNP{
+			RSID:         def.Name,
+			Genotype:     genotype,
+			RiskAllele:   def.Name,
+			Beta:         def.Weight,
+			Dosage:       dosage,
+			Trait:        def.Trait,
+			WeightSource: WeightSource,
+		})
+	}
+	logging.Info("Haplotype scoring: %d haplotypes scored, %d excluded", len(annotated), len(excluded))
+	return annotated, excluded
+}
+
+// haplotypeGenotype renders the calls at a haplotype's SNPs, e.g. "rs429358:C|T,rs7412:CC".
+func haplotypeGenotype(def Definition, calls map[string]model.ValidatedSNP) string {
+	parts := make([]string, len(def.Alleles))
+	for i, a := range def.Alleles {
+		call, ok := calls[a.RSID]
+		switch {
+		case !ok:
+			parts[i] = a.RSID + ":--"
+		case call.Phased && len(call.Genotype) == 2:
+			parts[i] = a.RSID + ":" + call.Genotype[:1] + "|" + call.Genotype[1:]
+		default:
+			parts[i] = a.RSID + ":" + call.Genotype
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// AdjustStats adds the haplotype terms of trait with a known reference frequency to SNP-only
+// reference stats, treating each haplotype as a biallelic locus in Hardy-Weinberg equilibrium.
+// The SNP-only quantiles no longer describe the adjusted score, so they are dropped and the
+// percentile falls back to the normal approximation.
+func (s *Set) AdjustStats(trait string, stats model.ReferenceStats) model.ReferenceStats {
+	variance := stats.Std * stats.Std
+	for _, def := range s.Definitions() {
+		if def.Trait != trait || def.Frequency == 0 {
+			continue
+		}
+		p := def.Frequency
+		stats.Mean += 2 * p * def.Weight
+		variance += 2 * p * (1 - p) * def.Weight * def.Weight
+		stats.Min += math.Min(0, 2*def.Weight)
+		stats.Max += math.Max(0, 2*def.Weight)
+		stats.Quantiles = nil
+	}
+	stats.Std = math.Sqrt(variance)
+	return stats
+}
@@ -0,0 +1,112 @@
+package haplotype
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+const apoe = "trait\thaplotype\tweight\tfrequency\talleles\n" +
+	"# APOE epsilon alleles\n" +
+	"alzheimers\tAPOE_e4\t1.2\t0.14\trs429358:C,rs7412:C\n" +
+	"alzheimers\tAPOE_e2\t-0.5\t\trs429358:T,rs7412:T\n"
+
+func TestParse(t *testing.T) {
+	set, err := Parse(strings.NewReader(apoe), "apoe.tsv")
+	require.NoError(t, err)
+	require.Len(t, set.Definitions(), 2)
+	e4 := set.Definitions()[0]
+	assert.Equal(t, "APOE_e4", e4.Name)
+	assert.Equal(t, 1.2, e4.Weight)
+	assert.Equal(t, 0.14, e4.Frequency)
+	assert.Equal(t, []Allele{{"rs429358", "C"}, {"rs7412", "C"}}, e4.Alleles)
+	assert.Equal(t, []string{"rs429358", "rs7412"}, set.RSIDs())
+	assert.Equal(t, map[string]int{"alzheimers": 2}, set.CountByTrait())
+
+	for _, bad := range []string{
+		"alzheimers\tAPOE_e4\t1.2\t0.14\n",
+		"alzheimers\tAPOE_e4\tx\t0.14\trs429358:C\n",
+		"alzheimers\tAPOE_e4\t1.2\t2\trs429358:C\n",
+		"alzheimers\tAPOE_e4\t1.2\t\trs429358\n",
+		"alzheimers\tAPOE_e4\t1.2\t\trs429358:N\n",
+		"alzheimers\tAPOE_e4\t1.2\t\trs1:C\nalzheimers\tAPOE_e4\t1\t\trs2:C\n",
+	} {
+		_, err := Parse(strings.NewReader(bad), "bad.tsv")
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestDosage(t *testing.T) {
+	e4 := Definition{Name: "APOE_e4", Alleles: []Allele{{"rs429358", "C"}, {"rs7412", "C"}}}
+	call := func(g string, phased bool) model.ValidatedSNP { return model.ValidatedSNP{Genotype: g, Phased: phased} }
+
+	tests := []struct {
+		name       string
+		rs429358   model.ValidatedSNP
+		rs7412     model.ValidatedSNP
+		wantDosage int
+		wantReason string
+	}{
+		{"e4/e4", call("CC", false), call("CC", false), 2, ""},
+		{"e3/e4 unphased, one het site", call("CT", false), call("CC", false), 1, ""},
+		{"e3/e3", call("TT", false), call("CC", false), 0, ""},
+		{"e2/e4 unphased is ambiguous", call("CT", false), call("CT", false), 0, ReasonAmbiguousPhase},
+		{"e2/e4 phased", call("CT", true), call("CT", true), 1, ""},
+		{"e1/e3 phased", call("CT", true), call("TC", true), 0, ""},
+		{"one phased het is still ambiguous", call("CT", true), call("CT", false), 0, ReasonAmbiguousPhase},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dosage, reason := Dosage(e4, map[string]model.ValidatedSNP{"rs429358": tt.rs429358, "rs7412": tt.rs7412})
+			assert.Equal(t, tt.wantReason, reason)
+			assert.Equal(t, tt.wantDosage, dosage)
+		})
+	}
+
+	_, reason := Dosage(e4, map[string]model.ValidatedSNP{"rs429358": call("CC", false)})
+	assert.Equal(t, ReasonMissingSNP, reason)
+}
+
+func TestAnnotate(t *testing.T) {
+	set, err := Parse(strings.NewReader(apoe), "apoe.tsv")
+	require.NoError(t, err)
+
+	annotated, excluded := set.Annotate([]model.ValidatedSNP{
+		{RSID: "rs429358", Genotype: "CT", Phased: true},
+		{RSID: "rs7412", Genotype: "CT", Phased: true},
+	})
+	require.Len(t, annotated, 2)
+	assert.Empty(t, excluded)
+	assert.Equal(t, model.AnnotatedSNP{
+		RSID: "APOE_e4", Genotype: "rs429358:C|T,rs7412:C|T", RiskAllele: "APOE_e4",
+		Beta: 1.2, Dosage: 1, Trait: "alzheimers", WeightSource: WeightSource,
+	}, annotated[0])
+	assert.Equal(t, 1, annotated[1].Dosage, "the second copy carries e2")
+
+	annotated, excluded = set.Annotate([]model.ValidatedSNP{{RSID: "rs429358", Genotype: "CT"}, {RSID: "rs7412", Genotype: "CT"}})
+	assert.Empty(t, annotated)
+	require.Len(t, excluded, 2)
+	assert.Equal(t, ReasonAmbiguousPhase, excluded[0].Reason)
+
+	var none *Set
+	annotated, excluded = none.Annotate(nil)
+	assert.Nil(t, annotated)
+	assert.Nil(t, excluded)
+}
+
+func TestAdjustStats(t *testing.T) {
+	set, err := Parse(strings.NewReader(apoe), "apoe.tsv")
+	require.NoError(t, err)
+
+	base := model.ReferenceStats{Mean: 1, Std: 0.5, Min: -1, Max: 3}
+	got := set.AdjustStats("alzheimers", base)
+	assert.InDelta(t, 1+2*0.14*1.2, got.Mean, 1e-12)
+	assert.InDelta(t, math.Sqrt(0.25+2*0.14*0.86*1.44), got.Std, 1e-12)
+	assert.InDelta(t, -1, got.Min, 1e-12)
+	assert.InDelta(t, 3+2.4, got.Max, 1e-12)
+	assert.Equal(t, base, set.AdjustStats("height", base), "other traits are unchanged")
+}
@@ -0,0 +1,275 @@
+// Package input resolves input locations to local files. Besides local paths, genotype
+// files, model databases, mapping tables, and config files can be given as https:// (or
+// http://) URLs and s3://bucket/key objects; they are downloaded before anything reads
+// them, so the rest of the calculator only ever sees local paths.
+//
+// Downloads go to a per-process temporary directory removed by Cleanup, or, when
+// input.cache_dir is set, to that directory, where they are kept and revalidated with the
+// server's ETag or Last-Modified on later runs.
+package input
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// Domain-specific configuration keys for remote inputs
+const (
+	CacheDirKey   = "input.cache_dir"   // Keep downloaded inputs here across runs, revalidated on use; empty downloads to a temporary directory per run
+	S3EndpointKey = "input.s3_endpoint" // S3-compatible endpoint (e.g. http://localhost:9000), addressed path-style; empty uses AWS
+	S3RegionKey   = "input.s3_region"   // Region of s3:// inputs; defaults to AWS_REGION, AWS_DEFAULT_REGION, then us-east-1
+)
+
+// HTTPClient is the client downloads use.
+var HTTPClient = http.DefaultClient
+
+var (
+	tempMu  sync.Mutex
+	tempDir string
+	origins = map[string]string{} // local copy -> remote location
+)
+
+// IsRemote reports whether p is an s3://, https://, or http:// location.
+func IsRemote(p string) bool {
+	return strings.HasPrefix(p, "s3://") || strings.HasPrefix(p, "https://") || strings.HasPrefix(p, "http://")
+}
+
+// Localize returns a local path holding the content of p: p itself when it is local,
+// otherwise the downloaded copy. The copy keeps the base name's extension, so readers that
+// pick a format by extension still work.
+func Localize(ctx context.Context, p string) (string, error) {
+	if !IsRemote(p) {
+		return p, nil
+	}
+	req, err := newRequest(ctx, p)
+	if err != nil {
+		return "", err
+	}
+	dir, persistent, err := downloadDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(p))
+	local := filepath.Join(dir, hex.EncodeToString(sum[:16])+path.Ext(req.URL.Path))
+	if err := download(req, p, local, persistent); err != nil {
+		return "", err
+	}
+	tempMu.Lock()
+	origins[local] = p
+	tempMu.Unlock()
+	return local, nil
+}
+
+// Origin returns the remote location a path returned by Localize was downloaded from, or
+// the path itself when it is local.
+func Origin(local string) string {
+	tempMu.Lock()
+	defer tempMu.Unlock()
+	if p, ok := origins[local]; ok {
+		return p
+	}
+	return local
+}
+
+// LocalizeConfig replaces each config key holding a remote location with the local path
+// of its download. Keys that are unset or local are left as they are.
+func LocalizeConfig(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		p := config.GetString(key)
+		if !IsRemote(p) {
+			continue
+		}
+		local, err := Localize(ctx, p)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		config.Set(key, local)
+	}
+	return nil
+}
+
+// Cleanup removes the temporary downloads of this process. Cached downloads are kept.
+func Cleanup() {
+	tempMu.Lock()
+	defer tempMu.Unlock()
+	if tempDir != "" {
+		os.RemoveAll(tempDir)
+		tempDir = ""
+	}
+}
+
+// downloadDir returns the directory downloads go to and whether it persists across runs.
+func downloadDir() (string, bool, error) {
+	if dir := config.GetString(CacheDirKey); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return "", false, fmt.Errorf("failed to create input cache directory: %w", err)
+		}
+		return dir, true, nil
+	}
+	tempMu.Lock()
+	defer tempMu.Unlock()
+	if tempDir == "" {
+		dir, err := os.MkdirTemp("", "phite-inputs-")
+		if err != nil {
+			return "", false, fmt.Errorf("failed to create download directory: %w", err)
+		}
+		tempDir = dir
+	}
+	return tempDir, false, nil
+}
+
+// validators are the response headers a cached download is revalidated with.
+type validators struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// download fetches req into local. With persistent set, an existing copy is revalidated
+// and kept when unchanged, or when the server cannot be reached.
+func download(req *http.Request, p, local string, persistent bool) error {
+	metaPath := local + ".meta"
+	var cached *validators
+	if persistent {
+		if _, err := os.Stat(local); err == nil {
+			if data, err := os.ReadFile(metaPath); err == nil {
+				var v validators
+				if json.Unmarshal(data, &v) == nil && v.URL == p {
+					cached = &v
+				}
+			}
+		}
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		if cached != nil {
+			logging.Warn("Could not revalidate cached input %s, using cached copy: %v", p, err)
+			return nil
+		}
+		return fmt.Errorf("failed to download %s: %w", p, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		logging.Info("Using cached input %s", p)
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to download %s: %s: %s", p, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(local), ".download-*")
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", p, err)
+	}
+	n, err := io.Copy(tmp, resp.Body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), local)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to download %s: %w", p, err)
+	}
+	logging.Info("Downloaded %s (%d bytes)", p, n)
+
+	if persistent {
+		v := validators{URL: p, ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+		data, _ := json.Marshal(v)
+		if err := os.WriteFile(metaPath, data, 0o600); err != nil {
+			logging.Warn("Failed to record cache validators for %s: %v", p, err)
+		}
+	}
+	return nil
+}
+
+// newRequest builds the GET request of a remote location, signing s3:// requests when AWS
+// credentials are set in the environment.
+func newRequest(ctx context.Context, p string) (*http.Request, error) {
+	if !strings.HasPrefix(p, "s3://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, p, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input URL %q: %w", p, err)
+		}
+		return req, nil
+	}
+
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(p, "s3://"), "/")
+	if bucket == "" || !ok || key == "" {
+		return nil, fmt.Errorf("invalid S3 location %q: expected s3://bucket/key", p)
+	}
+	region := s3Region()
+	var target string
+	if endpoint := strings.TrimSuffix(config.GetString(S3EndpointKey), "/"); endpoint != "" {
+		target = endpoint + "/" + bucket + "/" + s3Escape(key)
+	} else {
+		target = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, s3Escape(key))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 location %q: %w", p, err)
+	}
+	if creds, ok := credentialsFromEnv(); ok {
+		signV4(req, creds, region, now())
+	} else {
+		logging.Debug("No AWS credentials set; requesting %s unsigned", p)
+	}
+	return req, nil
+}
+
+func s3Region() string {
+	for _, r := range []string{config.GetString(S3RegionKey), os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")} {
+		if r != "" {
+			return r
+		}
+	}
+	return "us-east-1"
+}
+
+// s3Escape percent-encodes an object key as SigV4 canonical URIs require: every byte
+// but unreserved characters and "/".
+func s3Escape(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// escapedPath returns the request path as sent, for signing.
+func escapedPath(u *url.URL) string {
+	if p := u.EscapedPath(); p != "" {
+		return p
+	}
+	return "/"
+}
@@ -0,0 +1,118 @@
+// Package integrity verifies input files against a sha256 checksum manifest.
+package integrity
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// FileChecksum records an input file's sha256 and whether it matched the manifest.
+type FileChecksum struct {
+	Path     string `json:"path"`
+	SHA256   string `json:"sha256"`
+	Verified bool   `json:"verified"` // true only when the manifest lists the file and the hash matches
+}
+
+// Manifest maps absolute file paths to expected sha256 hex digests.
+type Manifest map[string]string
+
+// ParseManifest reads a manifest in sha256sum format ("<hex>  <path>" per line, optional
+// "*" binary marker). Relative paths are resolved against the manifest's directory.
+func ParseManifest(path string) (Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checksum manifest: %w", err)
+	}
+	defer f.Close()
+
+	base, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve checksum manifest directory: %w", err)
+	}
+	manifest := make(Manifest)
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("checksum manifest line %d: expected \"<sha256>  <path>\"", lineNum)
+		}
+		digest := strings.ToLower(fields[0])
+		if b, err := hex.DecodeString(digest); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("checksum manifest line %d: invalid sha256 %q", lineNum, fields[0])
+		}
+		file := strings.TrimPrefix(fields[1], "*")
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(base, file)
+		}
+		manifest[filepath.Clean(file)] = digest
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checksum manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// HashFile returns the sha256 hex digest of a file.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify hashes each file and checks it against the manifest (which may be nil).
+// Checksums are returned for every file that could be read, alongside an error for each
+// mismatch. With require set, a file the manifest does not list is also an error;
+// otherwise it is recorded unverified.
+func Verify(manifest Manifest, files []string, require bool) ([]FileChecksum, error) {
+	var checksums []FileChecksum
+	var errs []error
+	for _, file := range files {
+		digest, err := HashFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to hash: %w", file, err))
+			continue
+		}
+		checksum := FileChecksum{Path: file, SHA256: digest}
+
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			abs = file
+		}
+		expected, listed := manifest[filepath.Clean(abs)]
+		switch {
+		case listed && expected == digest:
+			checksum.Verified = true
+		case listed:
+			errs = append(errs, fmt.Errorf("%s: sha256 mismatch (manifest %s, actual %s)", file, expected, digest))
+		case require:
+			errs = append(errs, fmt.Errorf("%s: not listed in checksum manifest", file))
+		case manifest != nil:
+			logging.Warn("Input file %s is not listed in the checksum manifest; recording unverified hash", file)
+		}
+		checksums = append(checksums, checksum)
+	}
+	return checksums, errors.Join(errs...)
+}
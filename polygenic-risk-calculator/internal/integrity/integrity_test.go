@@ -0,0 +1,68 @@
+package integrity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+func sha(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestVerify(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	dir := t.TempDir()
+	genotype := filepath.Join(dir, "genotype.txt")
+	snps := filepath.Join(dir, "snps.txt")
+	extra := filepath.Join(dir, "extra.txt")
+	require.NoError(t, os.WriteFile(genotype, []byte("rsid\tchromosome\tposition\tgenotype\n"), 0644))
+	require.NoError(t, os.WriteFile(snps, []byte("rs1\n"), 0644))
+	require.NoError(t, os.WriteFile(extra, []byte("x"), 0644))
+
+	manifestPath := filepath.Join(dir, "SHA256SUMS")
+	manifestBody := fmt.Sprintf("# inputs\n%s  genotype.txt\n%s *%s\n",
+		sha("rsid\tchromosome\tposition\tgenotype\n"), sha("rs1\n"), snps)
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifestBody), 0644))
+
+	manifest, err := ParseManifest(manifestPath)
+	require.NoError(t, err)
+	assert.Len(t, manifest, 2)
+
+	checksums, err := Verify(manifest, []string{genotype, snps}, true)
+	require.NoError(t, err)
+	require.Len(t, checksums, 2)
+	assert.True(t, checksums[0].Verified)
+	assert.Equal(t, sha("rs1\n"), checksums[1].SHA256)
+
+	// Unlisted files fail only when checksums are required
+	_, err = Verify(manifest, []string{genotype, extra}, true)
+	assert.ErrorContains(t, err, "not listed")
+	checksums, err = Verify(manifest, []string{genotype, extra}, false)
+	assert.NoError(t, err)
+	assert.False(t, checksums[1].Verified)
+
+	// A modified file never verifies
+	require.NoError(t, os.WriteFile(snps, []byte("rs2\n"), 0644))
+	checksums, err = Verify(manifest, []string{snps}, false)
+	assert.ErrorContains(t, err, "sha256 mismatch")
+	assert.False(t, checksums[0].Verified)
+}
+
+func TestParseManifest_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "SHA256SUMS")
+	require.NoError(t, os.WriteFile(path, []byte("deadbeef  genotype.txt\n"), 0644))
+	_, err := ParseManifest(path)
+	assert.ErrorContains(t, err, "invalid sha256")
+
+	_, err = ParseManifest(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,38 @@
+package integrity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// RunInput is one input file of a run, named by the flag or config key it came from.
+type RunInput struct {
+	Role string
+	Path string
+}
+
+// RunKey digests everything that determines a run's results: the content (not the path) of
+// each input file under its role, and settings, encoded as JSON with map keys sorted. Two
+// runs with equal keys produce the same results, so a build system can skip the second.
+func RunKey(files []RunInput, settings any) (string, error) {
+	sorted := append([]RunInput(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Role < sorted[j].Role })
+
+	h := sha256.New()
+	for _, f := range sorted {
+		digest, err := HashFile(f.Path)
+		if err != nil {
+			return "", fmt.Errorf("%s: failed to hash %s: %w", f.Role, f.Path, err)
+		}
+		fmt.Fprintf(h, "file %s %s\n", f.Role, digest)
+	}
+	b, err := json.Marshal(settings)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode run settings: %w", err)
+	}
+	fmt.Fprintf(h, "settings %s\n", b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,39 @@
+package integrity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunKey(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "copy-of-a.txt")
+	require.NoError(t, os.WriteFile(a, []byte("rs1\tAA\n"), 0o644))
+	require.NoError(t, os.WriteFile(b, []byte("rs1\tAA\n"), 0o644))
+	settings := map[string]any{"ancestry": "EUR", "snps": []string{"rs1"}}
+
+	key, err := RunKey([]RunInput{{"genotype-file", a}}, settings)
+	require.NoError(t, err)
+	assert.Len(t, key, 64)
+
+	same, err := RunKey([]RunInput{{"genotype-file", b}}, map[string]any{"snps": []string{"rs1"}, "ancestry": "EUR"})
+	require.NoError(t, err)
+	assert.Equal(t, key, same, "the key depends on content and settings, not paths or map order")
+
+	otherRole, _ := RunKey([]RunInput{{"snps-file", a}}, settings)
+	assert.NotEqual(t, key, otherRole)
+	otherSettings, _ := RunKey([]RunInput{{"genotype-file", a}}, map[string]any{"ancestry": "AFR", "snps": []string{"rs1"}})
+	assert.NotEqual(t, key, otherSettings)
+
+	require.NoError(t, os.WriteFile(b, []byte("rs1\tAG\n"), 0o644))
+	changed, _ := RunKey([]RunInput{{"genotype-file", b}}, settings)
+	assert.NotEqual(t, key, changed)
+
+	_, err = RunKey([]RunInput{{"genotype-file", filepath.Join(dir, "missing.txt")}}, settings)
+	assert.ErrorContains(t, err, "genotype-file")
+}
@@ -113,6 +113,19 @@ func AssertValidDosage(dosage int, context string) error {
 	return nil
 }
 
+// AssertValidCodedDosage ensures a strategy-coded dosage is finite and within [0,2]
+func AssertValidCodedDosage(dosage float64, context string) error {
+	if math.IsNaN(dosage) || dosage < 0 || dosage > 2 {
+		return &InvariantViolationError{
+			Type:    "dosage",
+			Message: "coded dosage must be in range [0,2] for diploid genotypes",
+			Context: context,
+			Value:   dosage,
+		}
+	}
+	return nil
+}
+
 // AssertValidBetaCoefficient ensures a beta coefficient is reasonable
 func AssertValidBetaCoefficient(beta float64, context string) error {
 	if math.IsNaN(beta) || math.IsInf(beta, 0) {
@@ -0,0 +1,90 @@
+// Package limits enforces configurable caps on input sizes so oversized genotype files,
+// SNP lists, models, or trait sets fail fast with a clear error instead of exhausting a
+// shared server.
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+// Domain-specific configuration keys for input limits. A value of 0 disables the limit.
+const (
+	MaxGenotypeFileMBKey = "limits.max_genotype_file_mb" // Largest genotype file accepted, in MiB
+	MaxSNPsKey           = "limits.max_snps"             // Most SNPs requested in one run
+	MaxModelVariantsKey  = "limits.max_model_variants"   // Most variants any single PRS model may contain
+	MaxTraitsKey         = "limits.max_traits"           // Most traits scored in one run
+)
+
+// ErrLimitExceeded is wrapped by every error a Limits check returns.
+var ErrLimitExceeded = errors.New("input limit exceeded")
+
+// Limits holds the configured caps. Zero fields are not enforced.
+type Limits struct {
+	MaxGenotypeFileBytes int64
+	MaxSNPs              int
+	MaxModelVariants     int
+	MaxTraits            int
+}
+
+// FromConfig reads the limits from configuration. Negative values are treated as 0.
+func FromConfig() Limits {
+	return Limits{
+		MaxGenotypeFileBytes: int64(positive(config.GetInt(MaxGenotypeFileMBKey))) << 20,
+		MaxSNPs:              positive(config.GetInt(MaxSNPsKey)),
+		MaxModelVariants:     positive(config.GetInt(MaxModelVariantsKey)),
+		MaxTraits:            positive(config.GetInt(MaxTraitsKey)),
+	}
+}
+
+func positive(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// CheckGenotypeFile rejects a genotype file larger than MaxGenotypeFileBytes. It only stats
+// the file, so it can run before any parsing.
+func (l Limits) CheckGenotypeFile(path string) error {
+	if l.MaxGenotypeFileBytes == 0 {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat genotype file: %w", err)
+	}
+	if info.Size() > l.MaxGenotypeFileBytes {
+		return fmt.Errorf("%w: genotype file %s is %d bytes, limit is %d (%s)",
+			ErrLimitExceeded, path, info.Size(), l.MaxGenotypeFileBytes, MaxGenotypeFileMBKey)
+	}
+	return nil
+}
+
+// CheckSNPs rejects a run requesting more than MaxSNPs SNPs.
+func (l Limits) CheckSNPs(n int) error {
+	if l.MaxSNPs > 0 && n > l.MaxSNPs {
+		return fmt.Errorf("%w: %d SNPs requested, limit is %d (%s)", ErrLimitExceeded, n, l.MaxSNPs, MaxSNPsKey)
+	}
+	return nil
+}
+
+// CheckModelVariants rejects a trait's model with more than MaxModelVariants variants.
+func (l Limits) CheckModelVariants(trait string, n int) error {
+	if l.MaxModelVariants > 0 && n > l.MaxModelVariants {
+		return fmt.Errorf("%w: model for trait %s has %d variants, limit is %d (%s)",
+			ErrLimitExceeded, trait, n, l.MaxModelVariants, MaxModelVariantsKey)
+	}
+	return nil
+}
+
+// CheckTraits rejects a run that would score more than MaxTraits traits.
+func (l Limits) CheckTraits(n int) error {
+	if l.MaxTraits > 0 && n > l.MaxTraits {
+		return fmt.Errorf("%w: %d traits matched, limit is %d (%s)", ErrLimitExceeded, n, l.MaxTraits, MaxTraitsKey)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package limits
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+func TestFromConfig(t *testing.T) {
+	config.Set(MaxGenotypeFileMBKey, 2)
+	config.Set(MaxSNPsKey, 100)
+	config.Set(MaxModelVariantsKey, -5)
+	defer config.Set(MaxGenotypeFileMBKey, 0)
+	defer config.Set(MaxSNPsKey, 0)
+	defer config.Set(MaxModelVariantsKey, 0)
+
+	l := FromConfig()
+	assert.Equal(t, int64(2<<20), l.MaxGenotypeFileBytes)
+	assert.Equal(t, 100, l.MaxSNPs)
+	assert.Zero(t, l.MaxModelVariants, "negative values disable the limit")
+	assert.Zero(t, l.MaxTraits)
+}
+
+func TestChecks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "genotype.txt")
+	assert.NoError(t, os.WriteFile(path, make([]byte, 64), 0644))
+
+	var none Limits
+	assert.NoError(t, none.CheckGenotypeFile(path))
+	assert.NoError(t, none.CheckSNPs(1e6))
+	assert.NoError(t, none.CheckModelVariants("Height", 1e6))
+	assert.NoError(t, none.CheckTraits(1e6))
+
+	l := Limits{MaxGenotypeFileBytes: 32, MaxSNPs: 10, MaxModelVariants: 10, MaxTraits: 2}
+	err := l.CheckGenotypeFile(path)
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	assert.ErrorContains(t, err, MaxGenotypeFileMBKey)
+	assert.ErrorIs(t, l.CheckSNPs(11), ErrLimitExceeded)
+	assert.NoError(t, l.CheckSNPs(10))
+	err = l.CheckModelVariants("Height", 11)
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	assert.ErrorContains(t, err, "Height")
+	assert.ErrorIs(t, l.CheckTraits(3), ErrLimitExceeded)
+	assert.NoError(t, l.CheckTraits(2))
+
+	assert.NoError(t, Limits{MaxGenotypeFileBytes: 64}.CheckGenotypeFile(path))
+	err = l.CheckGenotypeFile(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrLimitExceeded)
+}
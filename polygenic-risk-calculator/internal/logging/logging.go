@@ -1,56 +1,80 @@
-// Package logging provides centralized logging for PHITE using uber-go/zap.
-// All code must use this package for logging.
+// Package logging provides centralized logging for PHITE on top of the shared
+// github.com/JerkyTreats/PHITE/logging package. All code must use this package for logging.
+//
+// Records are tagged component=risk-calculator. The level, format, and log file come from
+// the logging.* config keys, overridden by the PHITE_LOG_* environment variables.
+// Records go to stderr (or the log file), never stdout, so command results can be piped.
 package logging
 
 import (
-	"fmt"
+	"io"
+	"log/slog"
 	"os"
-	"strings"
 	"sync"
 
-	"go.uber.org/zap"
+	shared "github.com/JerkyTreats/PHITE/logging"
 	"phite.io/polygenic-risk-calculator/internal/config"
 )
 
+// Component tags the calculator's records.
+const Component = "risk-calculator"
+
 var (
-	logger     *zap.SugaredLogger
+	logger     *shared.Logger
 	loggerOnce sync.Once
+
+	output io.Writer // nil means os.Stderr
+	quiet  bool
 )
 
-// getZapLevel maps config log_level string to zapcore.Level.
-// Supports 'NONE' to silence all logs (for testing).
-func getZapLevel() zap.AtomicLevel {
-	levelStr := strings.ToUpper(config.GetString("logging.level"))
-	switch levelStr {
-	case "DEBUG":
-		return zap.NewAtomicLevelAt(zap.DebugLevel)
-	case "ERROR":
-		return zap.NewAtomicLevelAt(zap.ErrorLevel)
-	case "WARN":
-		return zap.NewAtomicLevelAt(zap.WarnLevel)
-	case "NONE":
-		// Use zapcore.FatalLevel+1 to silence all logs
-		return zap.NewAtomicLevelAt(100) // higher than FatalLevel
-	case "INFO":
-		fallthrough
-	default:
-		return zap.NewAtomicLevelAt(zap.InfoLevel)
+// options reads the logging options from config and the environment.
+// The level 'NONE' silences all logs (for testing).
+// In quiet mode levels below ERROR are raised to ERROR.
+func options() shared.Options {
+	opts := shared.FromEnv(shared.Options{
+		Level:      config.GetString(config.LogLevelKey),
+		Format:     config.GetString(config.LogFormatKey),
+		File:       config.GetString(config.LogFileKey),
+		MaxSizeMB:  config.GetInt(config.LogMaxSizeMBKey),
+		MaxBackups: config.GetInt(config.LogMaxBackupsKey),
+		Component:  Component,
+		Output:     output,
+	})
+	if quiet {
+		if lvl, err := shared.ParseLevel(opts.Level); err == nil && lvl < slog.LevelError {
+			opts.Level = shared.LevelError
+		}
 	}
+	return opts
 }
 
-// initLogger initializes the zap logger singleton.
+// initLogger initializes the logger singleton. Invalid options fall back to info-level
+// text on stderr, with a warning.
 func initLogger() {
 	loggerOnce.Do(func() {
-		cfg := zap.NewDevelopmentConfig()
-		cfg.Level = getZapLevel()
-		l, err := cfg.Build(zap.AddCaller(), zap.AddCallerSkip(1))
+		l, err := shared.New(options())
 		if err != nil {
-			panic(fmt.Sprintf("failed to build logger: %v", err))
+			l, _ = shared.New(shared.Options{Component: Component})
+			l.Warn("invalid logging config, using defaults", "error", err)
 		}
-		logger = l.Sugar()
+		logger = l
 	})
 }
 
+// SetOutput directs records to w instead of stderr when no log file is configured.
+// It takes effect from the next record.
+func SetOutput(w io.Writer) {
+	resetLogger()
+	output = w
+}
+
+// SetQuiet suppresses records below error level, as the CLI's --quiet flag does.
+// It takes effect from the next record.
+func SetQuiet(q bool) {
+	resetLogger()
+	quiet = q
+}
+
 // Info logs an info-level message.
 func Info(format string, args ...interface{}) {
 	initLogger()
@@ -75,18 +99,30 @@ func Warn(format string, args ...interface{}) {
 	logger.Warnf(format, args...)
 }
 
-// Sync flushes any buffered log entries.
+// Logger returns the underlying shared logger, for structured key/value logging.
+func Logger() *shared.Logger {
+	initLogger()
+	return logger
+}
+
+// Sync summarizes suppressed repeated messages and closes the log file, if any. Records
+// are written unbuffered.
 func Sync() error {
+	FlushRepeated()
 	if logger != nil {
-		return logger.Sync()
+		return logger.Close()
 	}
 	return nil
 }
 
 // For testing: resetLogger resets the logger singleton.
 func resetLogger() {
+	if logger != nil {
+		logger.Close()
+	}
 	logger = nil
 	loggerOnce = sync.Once{}
+	resetRepeats()
 }
 
 // SetSilentLoggingForTest sets log level to NONE for the duration of the test.
@@ -94,6 +130,5 @@ func resetLogger() {
 func SetSilentLoggingForTest() {
 	resetLogger()
 	config.SetConfigPath("") // clear any test config
-	os.Setenv("PHITE_LOG_LEVEL", "NONE")
-	// If config package supports env override, this will work; otherwise, set config value directly if needed.
+	os.Setenv(shared.EnvLevel, "NONE")
 }
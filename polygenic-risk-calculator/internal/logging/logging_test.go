@@ -1,9 +1,13 @@
 package logging
 
 import (
+	"bytes"
 	"os"
+	"strings"
 	"testing"
 	"phite.io/polygenic-risk-calculator/internal/config"
+
+	shared "github.com/JerkyTreats/PHITE/logging"
 )
 
 func TestLogging_RespectsLogLevelFromConfig(t *testing.T) {
@@ -48,3 +52,28 @@ func TestLogging_NoneLevelSuppressesAllLogs(t *testing.T) {
 }
 
 
+
+func TestLogging_SetOutputAndQuiet(t *testing.T) {
+	config.ResetForTest()
+	t.Setenv(shared.EnvLevel, "info")
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	t.Cleanup(func() { SetOutput(nil); SetQuiet(false) })
+
+	Info("info goes to the writer")
+	if !strings.Contains(buf.String(), "info goes to the writer") {
+		t.Fatalf("expected info record in output, got %q", buf.String())
+	}
+
+	buf.Reset()
+	SetQuiet(true)
+	Info("info is suppressed")
+	Warn("warn is suppressed")
+	Error("error still appears")
+	if strings.Contains(buf.String(), "suppressed") {
+		t.Errorf("quiet mode logged below error level: %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), "error still appears") {
+		t.Errorf("quiet mode dropped an error: %q", buf.String())
+	}
+}
@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+// repeat tracks one repeated message, keyed by its format string.
+type repeat struct {
+	level      slog.Level
+	logged     int       // occurrences logged in full
+	suppressed int       // occurrences counted since the last summary
+	total      int       // occurrences counted since the first suppression
+	since      time.Time // when the current summary period started
+	last       string    // the most recent suppressed occurrence, formatted
+}
+
+var (
+	repeatsMu sync.Mutex
+	repeats   map[string]*repeat
+)
+
+// WarnRepeated logs a warning that can repeat once per variant, e.g. "Skipping variant %q".
+// Messages sharing a format string are collapsed: the first logging.repeat_limit are logged
+// in full, and later ones are counted and logged as a summary with the count at most every
+// logging.repeat_summary_seconds, and by FlushRepeated.
+func WarnRepeated(format string, args ...interface{}) {
+	logRepeated(slog.LevelWarn, format, args...)
+}
+
+// DebugRepeated is WarnRepeated at debug level.
+func DebugRepeated(format string, args ...interface{}) {
+	logRepeated(slog.LevelDebug, format, args...)
+}
+
+func logRepeated(level slog.Level, format string, args ...interface{}) {
+	initLogger()
+	if !logger.Enabled(level) {
+		return
+	}
+	limit := config.GetInt(config.LogRepeatLimitKey)
+	if limit <= 0 {
+		logAt(level, format, args...)
+		return
+	}
+
+	repeatsMu.Lock()
+	if repeats == nil {
+		repeats = make(map[string]*repeat)
+	}
+	r, ok := repeats[format]
+	if !ok {
+		r = &repeat{level: level}
+		repeats[format] = r
+	}
+	if r.logged < limit {
+		r.logged++
+		repeatsMu.Unlock()
+		logAt(level, format, args...)
+		return
+	}
+	now := time.Now()
+	if r.suppressed == 0 {
+		r.since = now
+	}
+	r.suppressed++
+	r.total++
+	r.last = fmt.Sprintf(format, args...)
+	var summary string
+	if interval := time.Duration(config.GetInt(config.LogRepeatSummarySecondsKey)) * time.Second; now.Sub(r.since) >= interval {
+		summary = r.summary(now)
+	}
+	repeatsMu.Unlock()
+	if summary != "" {
+		logAt(level, "%s", summary)
+	}
+}
+
+// summary describes the suppressed occurrences and starts a new period. Callers hold
+// repeatsMu.
+func (r *repeat) summary(now time.Time) string {
+	s := fmt.Sprintf("%d similar messages suppressed in the last %s (%d in total); latest: %s",
+		r.suppressed, now.Sub(r.since).Round(time.Second), r.total, r.last)
+	r.suppressed = 0
+	return s
+}
+
+// FlushRepeated logs a summary of every repeated message with occurrences suppressed since
+// its last summary. Call it when a run ends so no count is lost.
+func FlushRepeated() {
+	repeatsMu.Lock()
+	now := time.Now()
+	var pending []*repeat
+	for _, r := range repeats {
+		if r.suppressed > 0 {
+			pending = append(pending, r)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].since.Before(pending[j].since) })
+	type line struct {
+		level slog.Level
+		msg   string
+	}
+	lines := make([]line, len(pending))
+	for i, r := range pending {
+		lines[i] = line{r.level, r.summary(now)}
+	}
+	repeatsMu.Unlock()
+	for _, l := range lines {
+		logAt(l.level, "%s", l.msg)
+	}
+}
+
+// resetRepeats forgets every repeated message, for a fresh logger.
+func resetRepeats() {
+	repeatsMu.Lock()
+	repeats = nil
+	repeatsMu.Unlock()
+}
+
+func logAt(level slog.Level, format string, args ...interface{}) {
+	initLogger()
+	switch level {
+	case slog.LevelDebug:
+		logger.Debugf(format, args...)
+	case slog.LevelWarn:
+		logger.Warnf(format, args...)
+	default:
+		logger.Infof(format, args...)
+	}
+}
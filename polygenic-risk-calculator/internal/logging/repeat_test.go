@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	shared "github.com/JerkyTreats/PHITE/logging"
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+func TestWarnRepeated_CollapsesAfterLimit(t *testing.T) {
+	config.ResetForTest()
+	t.Setenv(shared.EnvLevel, "info")
+	config.Set(config.LogRepeatLimitKey, 2)
+	config.Set(config.LogRepeatSummarySecondsKey, 3600)
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	t.Cleanup(func() { SetOutput(nil); config.ResetForTest() })
+
+	for i := 0; i < 1000; i++ {
+		WarnRepeated("Skipping variant rs%d", i)
+	}
+	Warn("unrelated warning")
+	WarnRepeated("cannot build filter for %s", "rs1")
+
+	out := buf.String()
+	if n := strings.Count(out, "Skipping variant"); n != 2 {
+		t.Fatalf("expected 2 repeated warnings before the limit, got %d:\n%s", n, out)
+	}
+	if !strings.Contains(out, "unrelated warning") || !strings.Contains(out, "cannot build filter for rs1") {
+		t.Fatalf("other messages were suppressed:\n%s", out)
+	}
+
+	buf.Reset()
+	FlushRepeated()
+	out = buf.String()
+	if !strings.Contains(out, "998 similar messages suppressed") || !strings.Contains(out, "latest: Skipping variant rs999") {
+		t.Fatalf("expected a summary of 998 suppressed messages, got:\n%s", out)
+	}
+	if strings.Contains(out, "cannot build filter") {
+		t.Errorf("summarized a message that was never suppressed:\n%s", out)
+	}
+
+	buf.Reset()
+	FlushRepeated()
+	if buf.Len() != 0 {
+		t.Errorf("second flush repeated the summary: %q", buf.String())
+	}
+}
+
+func TestWarnRepeated_PeriodicSummary(t *testing.T) {
+	config.ResetForTest()
+	t.Setenv(shared.EnvLevel, "info")
+	config.Set(config.LogRepeatLimitKey, 1)
+	config.Set(config.LogRepeatSummarySecondsKey, 0)
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	t.Cleanup(func() { SetOutput(nil); config.ResetForTest() })
+
+	WarnRepeated("missing frequency for %s", "rs1")
+	WarnRepeated("missing frequency for %s", "rs2")
+	WarnRepeated("missing frequency for %s", "rs3")
+
+	out := buf.String()
+	if n := strings.Count(out, "1 similar messages suppressed"); n != 2 {
+		t.Fatalf("expected a summary per suppressed message with a zero interval, got %d:\n%s", n, out)
+	}
+	if !strings.Contains(out, "(2 in total); latest: missing frequency for rs3") {
+		t.Errorf("expected running total in summary:\n%s", out)
+	}
+}
+
+func TestWarnRepeated_ZeroLimitLogsEverything(t *testing.T) {
+	config.ResetForTest()
+	t.Setenv(shared.EnvLevel, "info")
+	config.Set(config.LogRepeatLimitKey, 0)
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	t.Cleanup(func() { SetOutput(nil); config.ResetForTest() })
+
+	for i := 0; i < 20; i++ {
+		WarnRepeated("Skipping variant rs%d", i)
+	}
+	if n := strings.Count(buf.String(), "Skipping variant"); n != 20 {
+		t.Errorf("expected every message with repeat_limit 0, got %d", n)
+	}
+}
@@ -3,31 +3,125 @@ package model
 
 import (
 	"fmt"
+	"math"
 )
 
 // GWASSNPRecord represents a single SNP record from GWAS summary statistics.
 type GWASSNPRecord struct {
-	RSID       string
-	RiskAllele string
-	Beta       float64
-	Trait      string // optional
+	RSID         string
+	RiskAllele   string
+	OtherAllele  string // optional; enables allele consistency checks when set
+	Beta         float64
+	Trait        string // optional
+	Model        string // model the record's weight belongs to; empty when the trait is its own model
+	WeightSource string // column Beta was read from, e.g. "beta_eur" or "beta"
+}
+
+// ModelID returns the model the record belongs to: Model, or the trait when it is unset.
+func (r GWASSNPRecord) ModelID() string {
+	if r.Model != "" {
+		return r.Model
+	}
+	return r.Trait
+}
+
+// ExcludedSNP records a variant dropped from scoring and the reason code explaining why.
+type ExcludedSNP struct {
+	RSID     string `json:"rsid"`
+	Trait    string `json:"trait,omitempty"`
+	Genotype string `json:"genotype"`
+	Reason   string `json:"reason"`
+}
+
+// AlleleHarmonization counts the variants whose alleles were reconciled with a model's
+// effect and other alleles before scoring.
+type AlleleHarmonization struct {
+	Flipped   int `json:"flipped"`   // alleles reported on the opposite strand, reverse-complemented
+	Swapped   int `json:"swapped"`   // effect allele is the reference allele, so its frequency is 1 - the alt frequency
+	Ambiguous int `json:"ambiguous"` // palindromic (A/T or C/G) variants, whose strand cannot be told from the alleles
+	Dropped   int `json:"dropped"`   // alleles matching neither strand, or ambiguous ones dropped by policy
+}
+
+// Add adds o's counts to h.
+func (h *AlleleHarmonization) Add(o AlleleHarmonization) {
+	h.Flipped += o.Flipped
+	h.Swapped += o.Swapped
+	h.Ambiguous += o.Ambiguous
+	h.Dropped += o.Dropped
+}
+
+// Empty reports whether no variant was counted.
+func (h AlleleHarmonization) Empty() bool {
+	return h == AlleleHarmonization{}
+}
+
+// Complement returns the base paired with b on the opposite strand, or b itself when it is
+// not one of A, C, G, and T.
+func Complement(b byte) byte {
+	switch b {
+	case 'A':
+		return 'T'
+	case 'T':
+		return 'A'
+	case 'C':
+		return 'G'
+	case 'G':
+		return 'C'
+	}
+	return b
+}
+
+// ModelVersion identifies the PRS model data a run was scored against, so results and
+// cached reference stats from different model releases are never compared unknowingly.
+type ModelVersion struct {
+	Version  string `json:"version,omitempty"`  // pinned release label, e.g. from --model-version
+	Checksum string `json:"checksum,omitempty"` // sha256 of the model database file
 }
 
 // ValidatedSNP represents a user SNP that has been validated against GWAS data.
 type ValidatedSNP struct {
-	RSID        string
-	Genotype    string
-	FoundInGWAS bool
+	RSID         string
+	Genotype     string
+	FoundInGWAS  bool
+	Probs        []float64 // optional genotype probabilities P(0), P(1), P(2) copies of ProbsAlleles[1]
+	ProbsAlleles [2]string // the other and counted alleles of Probs, e.g. a VCF record's REF and ALT
+	Phased       bool      // Genotype[0] and Genotype[1] lie on known, distinct chromosome copies
+}
+
+// EffectProbs returns Probs as probabilities of 0, 1, and 2 copies of effect, which must be
+// one of ProbsAlleles; nil when it is neither or there are no probabilities.
+func (s ValidatedSNP) EffectProbs(effect string) []float64 {
+	if len(s.Probs) != 3 {
+		return nil
+	}
+	switch effect {
+	case s.ProbsAlleles[1]:
+		return s.Probs
+	case s.ProbsAlleles[0]:
+		return []float64{s.Probs[2], s.Probs[1], s.Probs[0]}
+	}
+	return nil
 }
 
 // AnnotatedSNP represents a user SNP annotated with GWAS and PRS calculation data.
 type AnnotatedSNP struct {
-	RSID       string
-	Genotype   string
-	RiskAllele string
-	Beta       float64
-	Dosage     int
-	Trait      string // optional
+	RSID         string
+	Genotype     string
+	RiskAllele   string
+	Beta         float64
+	Dosage       int      // count of risk-allele copies in the hard call
+	CodedDosage  *float64 // optional; set when a non-additive dosage strategy applies
+	Trait        string   // optional
+	WeightSource string   // column Beta was read from, e.g. "beta_eur" or "beta"
+}
+
+// ScoringDosage returns the dosage used for PRS scoring: the coded dosage when a
+// dosage strategy set one, otherwise the additive risk-allele count.
+func (s AnnotatedSNP) ScoringDosage() float64 {
+	if s.CodedDosage != nil {
+		return *s.CodedDosage
+	}
+	return float64(s.Dosage)
 }
 
 // ReferenceStats holds population-level statistics for PRS normalization.
@@ -39,6 +133,67 @@ type ReferenceStats struct {
 	Ancestry string
 	Trait    string
 	Model    string
+
+	Contributors []VarianceContribution // largest variance contributions, when computed rather than read from the cache
+
+	// Quantiles are the 1st to 99th percentiles of the reference score distribution, in
+	// order; empty when only the moments are known, e.g. for entries cached before they were
+	// stored.
+	Quantiles []float64
+}
+
+// QuantileCount is the number of reference quantiles, the 1st to 99th percentiles.
+const QuantileCount = 99
+
+// QuantilePercentile returns the percentile of score in the reference distribution,
+// interpolated between the quantiles. A score equal to several quantiles, as happens when
+// few variants make the distribution discrete, lies at the middle of them. Beyond the
+// 1st and 99th percentiles the normal tail is scaled to meet them. False when the stats
+// have no quantiles.
+func (s ReferenceStats) QuantilePercentile(score float64) (float64, bool) {
+	q := s.Quantiles
+	if len(q) != QuantileCount {
+		return 0, false
+	}
+	n := len(q)
+	below, atOrBelow := 0, 0 // quantiles strictly below score, and at or below it
+	for _, v := range q {
+		if v < score {
+			below++
+		}
+		if v <= score {
+			atOrBelow++
+		}
+	}
+	normCdf := func(x float64) float64 {
+		if s.Std <= 0 {
+			return 0.5
+		}
+		return 0.5 * (1 + math.Erf((x-s.Mean)/s.Std/math.Sqrt2))
+	}
+	switch {
+	case atOrBelow > below: // score equals quantiles below+1 .. atOrBelow
+		return float64(below+1+atOrBelow) / 2, true
+	case below == 0:
+		if c := normCdf(q[0]); c > 0 {
+			return normCdf(score) / c, true
+		}
+		return 0, true
+	case below == n:
+		if c := 1 - normCdf(q[n-1]); c > 0 {
+			return 100 - (1-normCdf(score))/c, true
+		}
+		return 100, true
+	}
+	lo, hi := q[below-1], q[below]
+	return float64(below) + (score-lo)/(hi-lo), true
+}
+
+// VarianceContribution is one variant's term of a PRS's population variance.
+type VarianceContribution struct {
+	Variant  string  `json:"variant"`
+	Variance float64 `json:"variance"` // 2p(1-p)β²
+	Share    float64 `json:"share"`    // of the population variance
 }
 
 // UserGenotype represents a single SNP in the user's genotype file.
@@ -66,6 +221,7 @@ type Variant struct {
 	ORCIUpper    *float64 // Optional: Upper bound of OR's confidence interval
 	VariantID    *string  // Optional: Variant ID (e.g., chr:pos:ref:alt)
 	RSID         *string  // Optional: rsID
+	WeightSource string   // Column EffectWeight was read from, e.g. "beta_eur" or "beta"
 }
 
 // PRSModel represents a complete PRS model with its variants.
@@ -0,0 +1,118 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// Domain-specific configuration keys for clinically actionable findings
+const (
+	ActionableKey         = "output.actionable"          // Map of trait -> thresholds above which a result is flagged as actionable
+	SuppressActionableKey = "output.suppress_actionable" // Withhold every trait listed as actionable from the outputs
+)
+
+// ActionableRule is the threshold above which a trait's result is clinically actionable,
+// e.g. "output": {"actionable": {"ldl": {"min_percentile": 95, "note": "consider lipid panel"}}}.
+// A result is flagged when it reaches either threshold that is set.
+type ActionableRule struct {
+	MinPercentile *float64 `json:"min_percentile" yaml:"min_percentile"`
+	MinZ          *float64 `json:"min_z" yaml:"min_z"`
+	Note          string   `json:"note" yaml:"note"` // shown with the flag
+}
+
+// Validate checks that the rule sets a usable threshold.
+func (r ActionableRule) Validate() error {
+	if r.MinPercentile == nil && r.MinZ == nil {
+		return fmt.Errorf("min_percentile or min_z is required")
+	}
+	if p := r.MinPercentile; p != nil && (math.IsNaN(*p) || *p < 0 || *p > 100) {
+		return fmt.Errorf("min_percentile must be between 0 and 100, got %v", *p)
+	}
+	if z := r.MinZ; z != nil && (math.IsNaN(*z) || math.IsInf(*z, 0)) {
+		return fmt.Errorf("min_z must be finite, got %v", *z)
+	}
+	return nil
+}
+
+// Exceeded reports whether a scored summary reaches the rule's thresholds.
+func (r ActionableRule) Exceeded(ts TraitSummary) bool {
+	if ts.Status != "" {
+		return false
+	}
+	return (r.MinPercentile != nil && ts.Percentile >= *r.MinPercentile) ||
+		(r.MinZ != nil && ts.ZScore >= *r.MinZ)
+}
+
+// Actionability flags clinically actionable findings, or withholds them when the user has
+// not consented to receive them. The zero value flags nothing.
+type Actionability struct {
+	Rules    map[string]ActionableRule // lower-cased trait -> rule
+	Suppress bool
+}
+
+// ActionabilityFromConfig reads the actionable traits and the suppression setting.
+func ActionabilityFromConfig() (Actionability, error) {
+	raw := make(map[string]ActionableRule)
+	if err := config.UnmarshalKey(ActionableKey, &raw); err != nil {
+		return Actionability{}, fmt.Errorf("%s: %w", ActionableKey, err)
+	}
+	a := Actionability{Rules: make(map[string]ActionableRule, len(raw)), Suppress: config.GetBool(SuppressActionableKey)}
+	for trait, rule := range raw {
+		if err := rule.Validate(); err != nil {
+			return Actionability{}, fmt.Errorf("%s.%s: %w", ActionableKey, trait, err)
+		}
+		a.Rules[strings.ToLower(trait)] = rule
+	}
+	return a, nil
+}
+
+// Withheld reports whether trait is left out of the outputs. With suppression on, every
+// trait listed as actionable is withheld whatever its result, so that a missing trait
+// does not itself reveal a finding.
+func (a Actionability) Withheld(trait string) bool {
+	if !a.Suppress {
+		return false
+	}
+	_, ok := a.Rules[strings.ToLower(trait)]
+	return ok
+}
+
+// Flag marks the summaries whose results reach their trait's thresholds and returns the
+// number flagged.
+func (a Actionability) Flag(summaries []TraitSummary) int {
+	flagged := 0
+	for i := range summaries {
+		rule, ok := a.Rules[strings.ToLower(summaries[i].Trait)]
+		if ok && rule.Exceeded(summaries[i]) {
+			summaries[i].Actionable = true
+			summaries[i].ActionableNote = rule.Note
+			flagged++
+		}
+	}
+	return flagged
+}
+
+// Withhold removes the withheld traits from summaries and from excluded-SNP warnings,
+// which name their trait.
+func (a Actionability) Withhold(summaries []TraitSummary, excluded []model.ExcludedSNP) ([]TraitSummary, []model.ExcludedSNP) {
+	if !a.Suppress || len(a.Rules) == 0 {
+		return summaries, excluded
+	}
+	keptSummaries := summaries[:0:0]
+	for _, ts := range summaries {
+		if !a.Withheld(ts.Trait) {
+			keptSummaries = append(keptSummaries, ts)
+		}
+	}
+	var keptExcluded []model.ExcludedSNP
+	for _, e := range excluded {
+		if !a.Withheld(e.Trait) {
+			keptExcluded = append(keptExcluded, e)
+		}
+	}
+	return keptSummaries, keptExcluded
+}
@@ -0,0 +1,74 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+func TestActionabilityFromConfig(t *testing.T) {
+	config.Set(ActionableKey, map[string]interface{}{
+		"LDL": map[string]interface{}{"min_percentile": 95, "note": "consider a lipid panel"},
+		"t2d": map[string]interface{}{"min_z": 2},
+	})
+	defer config.Set(ActionableKey, nil)
+
+	a, err := ActionabilityFromConfig()
+	require.NoError(t, err)
+	require.Len(t, a.Rules, 2)
+	assert.Equal(t, 95.0, *a.Rules["ldl"].MinPercentile)
+	assert.Equal(t, "consider a lipid panel", a.Rules["ldl"].Note)
+	assert.Nil(t, a.Rules["t2d"].MinPercentile)
+	assert.False(t, a.Suppress)
+
+	config.Set(ActionableKey, map[string]interface{}{"ldl": map[string]interface{}{"note": "no threshold"}})
+	_, err = ActionabilityFromConfig()
+	assert.ErrorContains(t, err, "ldl: min_percentile or min_z is required")
+
+	config.Set(ActionableKey, map[string]interface{}{"ldl": map[string]interface{}{"min_percentile": 150}})
+	_, err = ActionabilityFromConfig()
+	assert.ErrorContains(t, err, "min_percentile must be between 0 and 100")
+}
+
+func TestActionability_Flag(t *testing.T) {
+	p95, z2 := 95.0, 2.0
+	a := Actionability{Rules: map[string]ActionableRule{
+		"ldl": {MinPercentile: &p95, Note: "consider a lipid panel"},
+		"t2d": {MinZ: &z2},
+	}}
+	summaries := []TraitSummary{
+		{Trait: "LDL", Percentile: 97},
+		{Trait: "T2D", Percentile: 96, ZScore: 1.8},
+		{Trait: "BMI", Percentile: 99},
+		{Trait: "ldl", Status: StatusInsufficientCoverage},
+	}
+
+	assert.Equal(t, 1, a.Flag(summaries))
+	assert.True(t, summaries[0].Actionable)
+	assert.Equal(t, "consider a lipid panel", summaries[0].ActionableNote)
+	assert.False(t, summaries[1].Actionable, "only the configured threshold applies")
+	assert.False(t, summaries[2].Actionable, "unlisted traits are never flagged")
+	assert.False(t, summaries[3].Actionable, "unscored traits are never flagged")
+}
+
+func TestActionability_Withhold(t *testing.T) {
+	p95 := 95.0
+	a := Actionability{Rules: map[string]ActionableRule{"ldl": {MinPercentile: &p95}}}
+	summaries := []TraitSummary{{Trait: "LDL", Percentile: 10}, {Trait: "BMI"}}
+	excluded := []model.ExcludedSNP{{RSID: "rs1", Trait: "LDL"}, {RSID: "rs2", Trait: "BMI"}}
+
+	kept, keptExcluded := a.Withhold(summaries, excluded)
+	assert.Len(t, kept, 2, "nothing is withheld without suppression")
+	assert.Len(t, keptExcluded, 2)
+
+	a.Suppress = true
+	kept, keptExcluded = a.Withhold(summaries, excluded)
+	require.Len(t, kept, 1, "listed traits are withheld whether or not they reach the threshold")
+	assert.Equal(t, "BMI", kept[0].Trait)
+	require.Len(t, keptExcluded, 1)
+	assert.Equal(t, "rs2", keptExcluded[0].RSID)
+	assert.Len(t, summaries, 2, "the input is not modified")
+}
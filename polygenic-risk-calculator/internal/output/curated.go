@@ -0,0 +1,229 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// Domain-specific configuration keys for curated SNP notes
+const (
+	CuratedNotesKey = "output.curated_notes" // Converter JSON files or directories whose SNP notes are merged into trait summaries
+)
+
+// CuratedSNP is a user-curated SNP note produced by the converter.
+type CuratedSNP struct {
+	RSID     string `json:"rsid"`
+	Gene     string `json:"gene,omitempty"`
+	Allele   string `json:"allele,omitempty"`
+	Genotype string `json:"genotype,omitempty"` // the subject's genotype as recorded by the converter
+	Match    string `json:"match,omitempty"`    // None, Partial, or Full
+	Notes    string `json:"notes,omitempty"`
+	Topic    string `json:"topic,omitempty"`
+	Group    string `json:"group,omitempty"`
+}
+
+// CuratedNotes indexes curated SNP notes for merging into trait summaries. A nil
+// CuratedNotes holds no notes.
+type CuratedNotes struct {
+	snps    []CuratedSNP
+	byRSID  map[string][]int
+	byGroup map[string][]int // lower-cased "topic|group" -> indexes
+}
+
+// converterSNP and converterFile mirror the converter's JSON output in its group, topic,
+// and gene grouping modes.
+type converterSNP struct {
+	Gene    string `json:"Gene"`
+	RSID    string `json:"RSID"`
+	Allele  string `json:"Allele"`
+	Notes   string `json:"Notes"`
+	Subject struct {
+		Genotype string `json:"Genotype"`
+		Match    string `json:"Match"`
+	} `json:"Subject"`
+}
+
+type converterFile struct {
+	Grouping *struct {
+		Topic string         `json:"Topic"`
+		Name  string         `json:"Name"`
+		SNP   []converterSNP `json:"SNP"`
+	} `json:"Grouping"`
+	Topic     string                    `json:"Topic"`
+	Groupings map[string][]converterSNP `json:"Groupings"`
+	Gene      string                    `json:"Gene"`
+	SNP       []converterSNP            `json:"SNP"`
+}
+
+// CuratedNotesFromConfig loads the configured converter outputs, returning nil when none
+// are configured.
+func CuratedNotesFromConfig() (*CuratedNotes, error) {
+	paths := config.GetStringSlice(CuratedNotesKey)
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	notes, err := LoadCuratedNotes(paths...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", CuratedNotesKey, err)
+	}
+	return notes, nil
+}
+
+// LoadCuratedNotes reads converter JSON outputs. Each path is a file or a directory searched
+// recursively for .json files; files in a directory that are not converter outputs, such as
+// the converter's reports, are skipped.
+func LoadCuratedNotes(paths ...string) (*CuratedNotes, error) {
+	c := &CuratedNotes{byRSID: make(map[string][]int), byGroup: make(map[string][]int)}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read curated notes: %w", err)
+		}
+		if !info.IsDir() {
+			ok, err := c.addFile(path)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, fmt.Errorf("%s is not a converter output", path)
+			}
+			continue
+		}
+		var files []string
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".json") {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read curated notes: %w", err)
+		}
+		sort.Strings(files)
+		for _, f := range files {
+			ok, err := c.addFile(f)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				logging.Debug("Skipping %s: not a converter output", f)
+			}
+		}
+	}
+	logging.Info("Loaded %d curated SNP notes", len(c.snps))
+	return c, nil
+}
+
+// addFile adds the notes of one converter output, reporting false when the file is JSON
+// of another shape.
+func (c *CuratedNotes) addFile(path string) (bool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read curated notes: %w", err)
+	}
+	var f converterFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return false, fmt.Errorf("failed to parse curated notes %s: %w", path, err)
+	}
+	switch {
+	case f.Grouping != nil:
+		c.add(f.Grouping.Topic, f.Grouping.Name, f.Grouping.SNP)
+	case f.Groupings != nil:
+		groups := make([]string, 0, len(f.Groupings))
+		for group := range f.Groupings {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+		for _, group := range groups {
+			c.add(f.Topic, group, f.Groupings[group])
+		}
+	case f.Gene != "":
+		// Gene-mode outputs do not record the group of each SNP; they match by rsid only
+		c.add("", "", f.SNP)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+func (c *CuratedNotes) add(topic, group string, snps []converterSNP) {
+	for _, s := range snps {
+		if s.RSID == "" {
+			continue
+		}
+		i := len(c.snps)
+		c.snps = append(c.snps, CuratedSNP{
+			RSID:     s.RSID,
+			Gene:     s.Gene,
+			Allele:   s.Allele,
+			Genotype: s.Subject.Genotype,
+			Match:    s.Subject.Match,
+			Notes:    s.Notes,
+			Topic:    topic,
+			Group:    group,
+		})
+		c.byRSID[s.RSID] = append(c.byRSID[s.RSID], i)
+		if topic != "" && group != "" {
+			key := groupKey(topic, group)
+			c.byGroup[key] = append(c.byGroup[key], i)
+		}
+	}
+}
+
+// Attach adds to each summary the notes on SNPs scored for its trait (traitRSIDs: trait ->
+// rsid set) and the notes filed under its taxonomy topic and group. Summaries must already
+// carry their topic and group (see Arrangement.Categorize).
+func (c *CuratedNotes) Attach(summaries []TraitSummary, traitRSIDs map[string]map[string]bool) {
+	if c == nil || len(c.snps) == 0 {
+		return
+	}
+	matched := 0
+	for i := range summaries {
+		ts := &summaries[i]
+		seen := make(map[int]bool)
+		var idx []int
+		add := func(is []int) {
+			for _, n := range is {
+				if !seen[n] {
+					seen[n] = true
+					idx = append(idx, n)
+				}
+			}
+		}
+		rsids := make([]string, 0, len(traitRSIDs[ts.Trait]))
+		for rsid := range traitRSIDs[ts.Trait] {
+			rsids = append(rsids, rsid)
+		}
+		sort.Strings(rsids)
+		for _, rsid := range rsids {
+			add(c.byRSID[rsid])
+		}
+		if ts.Topic != "" && ts.Group != "" {
+			add(c.byGroup[groupKey(ts.Topic, ts.Group)])
+		}
+		sort.Ints(idx)
+		ts.CuratedNotes = nil
+		for _, n := range idx {
+			ts.CuratedNotes = append(ts.CuratedNotes, c.snps[n])
+		}
+		if len(idx) > 0 {
+			matched++
+		}
+	}
+	logging.Info("Attached curated SNP notes to %d of %d traits", matched, len(summaries))
+}
+
+func groupKey(topic, group string) string {
+	return strings.ToLower(strings.TrimSpace(topic)) + "|" + strings.ToLower(strings.TrimSpace(group))
+}
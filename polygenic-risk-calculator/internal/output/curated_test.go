@@ -0,0 +1,74 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestLoadCuratedNotes_Attach(t *testing.T) {
+	dir := t.TempDir()
+	// group mode
+	writeFile(t, filepath.Join(dir, "metabolic", "weight.json"), `{"Grouping": {"Topic": "Metabolic", "Name": "Weight", "SNP": [
+		{"Gene": "FTO", "RSID": "rs9939609", "Allele": "A", "Notes": "appetite", "Subject": {"Genotype": "AT", "Match": "Partial"}}]}}`)
+	// topic mode
+	writeFile(t, filepath.Join(dir, "cardiovascular.json"), `{"Topic": "Cardiovascular", "Groupings": {"Lipids": [
+		{"Gene": "APOB", "RSID": "rs693", "Allele": "A", "Notes": "LDL", "Subject": {"Genotype": "AA", "Match": "Full"}}]}}`)
+	// gene mode
+	writeFile(t, filepath.Join(dir, "genes", "MC4R.json"), `{"Gene": "MC4R", "Topics": ["Metabolic"], "Groups": ["Weight"], "SNP": [
+		{"Gene": "MC4R", "RSID": "rs17782313", "Allele": "C", "Notes": "satiety", "Subject": {"Genotype": "CC", "Match": "Full"}}]}`)
+	// converter report, skipped
+	writeFile(t, filepath.Join(dir, "genotype_report.json"), `{"Filled": 3, "Overridden": [], "Missing": []}`)
+
+	notes, err := LoadCuratedNotes(dir)
+	require.NoError(t, err)
+
+	summaries := []TraitSummary{
+		{Trait: "BMI", Topic: "metabolic", Group: "weight"},
+		{Trait: "LDL"},
+		{Trait: "height"},
+	}
+	notes.Attach(summaries, map[string]map[string]bool{
+		"BMI": {"rs17782313": true},
+		"LDL": {"rs693": true},
+	})
+
+	require.Len(t, summaries[0].CuratedNotes, 2)
+	// Notes keep the order of the files they were loaded from
+	assert.Equal(t, "rs17782313", summaries[0].CuratedNotes[0].RSID, "matched by rsid")
+	assert.Equal(t, "rs9939609", summaries[0].CuratedNotes[1].RSID, "matched by topic and group")
+	assert.Equal(t, "Weight", summaries[0].CuratedNotes[1].Group)
+	assert.Equal(t, "Partial", summaries[0].CuratedNotes[1].Match)
+	require.Len(t, summaries[1].CuratedNotes, 1)
+	assert.Equal(t, "LDL", summaries[1].CuratedNotes[0].Notes)
+	assert.Equal(t, "Lipids", summaries[1].CuratedNotes[0].Group)
+	assert.Empty(t, summaries[2].CuratedNotes)
+}
+
+func TestLoadCuratedNotes_Errors(t *testing.T) {
+	dir := t.TempDir()
+	report := filepath.Join(dir, "conflicts_report.json")
+	writeFile(t, report, `{"Duplicates": 0, "Conflicts": []}`)
+	_, err := LoadCuratedNotes(report)
+	assert.Error(t, err, "a file given explicitly must be a converter output")
+
+	broken := filepath.Join(dir, "broken.json")
+	writeFile(t, broken, `{"Grouping":`)
+	_, err = LoadCuratedNotes(dir)
+	assert.Error(t, err)
+
+	_, err = LoadCuratedNotes(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+
+	var nilNotes *CuratedNotes
+	nilNotes.Attach([]TraitSummary{{Trait: "BMI"}}, nil)
+}
@@ -0,0 +1,95 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/expr"
+	"phite.io/polygenic-risk-calculator/internal/prs"
+)
+
+// Domain-specific configuration keys for derived metrics
+const (
+	DerivedMetricsKey = "output.derived_metrics" // Map of metric name -> expression over per-trait results
+)
+
+// DerivedMetric is a user-defined value computed from the normalized trait results.
+// Value is nil when the expression could not be evaluated, e.g. because a trait it
+// references was not scored; Error then says why.
+type DerivedMetric struct {
+	Name       string   `json:"name"`
+	Expression string   `json:"expression"`
+	Value      *float64 `json:"value,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// MetricDefinition is a named, parsed derived metric expression.
+type MetricDefinition struct {
+	Name string
+	Expr *expr.Expr
+}
+
+// DerivedMetricsFromConfig parses the configured derived metrics, sorted by name, so that
+// invalid expressions are reported before any scoring work.
+func DerivedMetricsFromConfig() ([]MetricDefinition, error) {
+	raw := config.GetStringMapString(DerivedMetricsKey)
+	defs := make([]MetricDefinition, 0, len(raw))
+	for name, src := range raw {
+		e, err := expr.Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", DerivedMetricsKey, name, err)
+		}
+		defs = append(defs, MetricDefinition{Name: name, Expr: e})
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs, nil
+}
+
+// MetricVariables exposes each trait's results to derived metric expressions as
+// <trait>_z, <trait>_percentile, <trait>_raw, and, when the model defines a scale,
+// <trait>_scaled. Trait names are lower-cased with every character other than a
+// letter or digit replaced by '_', so "Height (cm)" becomes height__cm_.
+func MetricVariables(normalized map[string]prs.NormalizedPRS) map[string]float64 {
+	vars := make(map[string]float64, 4*len(normalized))
+	for trait, n := range normalized {
+		id := metricIdent(trait)
+		vars[id+"_z"] = n.ZScore
+		vars[id+"_percentile"] = n.Percentile
+		vars[id+"_raw"] = n.RawScore
+		if n.Scaled != nil {
+			vars[id+"_scaled"] = n.Scaled.Value
+		}
+	}
+	return vars
+}
+
+func metricIdent(trait string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(trait))
+}
+
+// EvaluateDerivedMetrics evaluates each definition against the normalized trait results.
+// A metric that fails to evaluate is reported with its error rather than failing the run.
+func EvaluateDerivedMetrics(defs []MetricDefinition, normalized map[string]prs.NormalizedPRS) []DerivedMetric {
+	if len(defs) == 0 {
+		return nil
+	}
+	vars := MetricVariables(normalized)
+	metrics := make([]DerivedMetric, len(defs))
+	for i, def := range defs {
+		metrics[i] = DerivedMetric{Name: def.Name, Expression: def.Expr.String()}
+		v, err := def.Expr.Eval(vars)
+		if err != nil {
+			metrics[i].Error = err.Error()
+			continue
+		}
+		metrics[i].Value = &v
+	}
+	return metrics
+}
@@ -0,0 +1,63 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/prs"
+)
+
+func TestDerivedMetricsFromConfig(t *testing.T) {
+	config.ResetForTest()
+	t.Cleanup(config.ResetForTest)
+
+	config.Set(DerivedMetricsKey, map[string]string{
+		"composite": "0.5*height_z + 0.5*bmi_z",
+		"anthro":    "max(height_percentile, bmi_percentile)",
+	})
+	defs, err := DerivedMetricsFromConfig()
+	require.NoError(t, err)
+	require.Len(t, defs, 2)
+	assert.Equal(t, "anthro", defs[0].Name)
+	assert.Equal(t, "composite", defs[1].Name)
+
+	config.Set(DerivedMetricsKey, map[string]string{"broken": "height_z +"})
+	_, err = DerivedMetricsFromConfig()
+	assert.ErrorContains(t, err, "output.derived_metrics.broken")
+}
+
+func TestEvaluateDerivedMetrics(t *testing.T) {
+	config.ResetForTest()
+	t.Cleanup(config.ResetForTest)
+
+	config.Set(DerivedMetricsKey, map[string]string{
+		"composite": "0.5*height_z + 0.5*bmi_z",
+		"bp":        "systolic_bp_scaled - 120",
+		"missing":   "ldl_z * 2",
+	})
+	defs, err := DerivedMetricsFromConfig()
+	require.NoError(t, err)
+
+	metrics := EvaluateDerivedMetrics(defs, map[string]prs.NormalizedPRS{
+		"Height":      {ZScore: 1.0},
+		"BMI":         {ZScore: -0.5},
+		"Systolic BP": {ZScore: 0.2, Scaled: &prs.ScaledScore{Value: 128, Unit: "mmHg"}},
+	})
+	require.Len(t, metrics, 3)
+
+	assert.Equal(t, "bp", metrics[0].Name)
+	require.NotNil(t, metrics[0].Value)
+	assert.InDelta(t, 8.0, *metrics[0].Value, 1e-12)
+
+	assert.Equal(t, "composite", metrics[1].Name)
+	require.NotNil(t, metrics[1].Value)
+	assert.InDelta(t, 0.25, *metrics[1].Value, 1e-12)
+
+	assert.Equal(t, "missing", metrics[2].Name)
+	assert.Nil(t, metrics[2].Value)
+	assert.Contains(t, metrics[2].Error, "ldl_z")
+
+	assert.Nil(t, EvaluateDerivedMetrics(nil, nil))
+}
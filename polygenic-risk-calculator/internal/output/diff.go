@@ -0,0 +1,222 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// Domain-specific configuration keys for comparing pipeline outputs
+const (
+	DiffScoreToleranceKey      = "output.diff_score_tolerance"      // Largest z-score or contribution change reported as equal
+	DiffPercentileToleranceKey = "output.diff_percentile_tolerance" // Largest percentile change reported as equal
+)
+
+// DefaultDiffTolerance absorbs floating-point noise when no tolerance is configured.
+const DefaultDiffTolerance = 1e-9
+
+// Tolerance bounds the numeric changes Compare ignores.
+type Tolerance struct {
+	Score      float64 `json:"score"`      // z-score, effect-weighted contribution, and coverage
+	Percentile float64 `json:"percentile"` // percentile points
+}
+
+// ToleranceFromConfig reads the diff tolerances, defaulting unset values to DefaultDiffTolerance.
+func ToleranceFromConfig() Tolerance {
+	tol := Tolerance{Score: DefaultDiffTolerance, Percentile: DefaultDiffTolerance}
+	if v := config.GetFloat64(DiffScoreToleranceKey); v > 0 {
+		tol.Score = v
+	}
+	if v := config.GetFloat64(DiffPercentileToleranceKey); v > 0 {
+		tol.Percentile = v
+	}
+	return tol
+}
+
+// Trait change kinds.
+const (
+	TraitAdded   = "added"   // only in the second output
+	TraitRemoved = "removed" // only in the first output
+	TraitChanged = "changed" // in both, with fields beyond tolerance
+)
+
+// FieldDiff is one trait summary field whose value differs between two outputs.
+type FieldDiff struct {
+	Field string      `json:"field"`
+	A     interface{} `json:"a"`
+	B     interface{} `json:"b"`
+	Delta float64     `json:"delta,omitempty"` // B - A for numeric fields
+}
+
+// TraitDiff describes how one trait differs between two outputs.
+type TraitDiff struct {
+	Trait  string      `json:"trait"`
+	Change string      `json:"change"`
+	Fields []FieldDiff `json:"fields,omitempty"`
+}
+
+// SetDiff lists the members found in only one of two outputs.
+type SetDiff struct {
+	Added   []string `json:"added,omitempty"`   // only in the second output
+	Removed []string `json:"removed,omitempty"` // only in the first output
+}
+
+// Empty reports whether both sides hold the same members.
+func (d SetDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// Diff is a structured comparison of two pipeline outputs.
+type Diff struct {
+	Tolerance    Tolerance   `json:"tolerance"`
+	Traits       []TraitDiff `json:"traits,omitempty"`
+	SNPsMissing  SetDiff     `json:"snps_missing"`
+	ExcludedSNPs SetDiff     `json:"excluded_snps"` // rsid/trait/reason warnings
+	Model        *FieldDiff  `json:"model,omitempty"`
+}
+
+// Equal reports whether the outputs match within tolerance. A model version change alone
+// does not make outputs unequal; it is reported to explain the other differences.
+func (d Diff) Equal() bool {
+	return len(d.Traits) == 0 && d.SNPsMissing.Empty() && d.ExcludedSNPs.Empty()
+}
+
+// ReadResult loads an output file written in JSON format.
+func ReadResult(path string) (OutputResult, error) {
+	var result OutputResult
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return result, fmt.Errorf("failed to read output file: %w", err)
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return result, fmt.Errorf("failed to parse output file %s (only JSON outputs can be compared): %w", path, err)
+	}
+	return result, nil
+}
+
+// Compare reports the differences from a to b in trait summaries, missing SNPs, and
+// excluded-SNP warnings. Traits are matched by name and reported in name order.
+func Compare(a, b OutputResult, tol Tolerance) Diff {
+	d := Diff{Tolerance: tol}
+
+	as, bs := summariesByTrait(a.TraitSummaries), summariesByTrait(b.TraitSummaries)
+	for _, trait := range unionKeys(as, bs) {
+		sa, inA := as[trait]
+		sb, inB := bs[trait]
+		switch {
+		case !inA:
+			d.Traits = append(d.Traits, TraitDiff{Trait: trait, Change: TraitAdded})
+		case !inB:
+			d.Traits = append(d.Traits, TraitDiff{Trait: trait, Change: TraitRemoved})
+		default:
+			if fields := compareSummaries(sa, sb, tol); len(fields) > 0 {
+				d.Traits = append(d.Traits, TraitDiff{Trait: trait, Change: TraitChanged, Fields: fields})
+			}
+		}
+	}
+
+	d.SNPsMissing = diffSets(a.SNPSMissing, b.SNPSMissing)
+	d.ExcludedSNPs = diffSets(excludedKeys(a.ExcludedSNPs), excludedKeys(b.ExcludedSNPs))
+
+	va, vb := modelVersionOf(a), modelVersionOf(b)
+	if va != vb {
+		d.Model = &FieldDiff{Field: "model", A: va, B: vb}
+	}
+	return d
+}
+
+func compareSummaries(a, b TraitSummary, tol Tolerance) []FieldDiff {
+	var fields []FieldDiff
+	number := func(name string, x, y, limit float64) {
+		if math.Abs(y-x) > limit {
+			fields = append(fields, FieldDiff{Field: name, A: x, B: y, Delta: y - x})
+		}
+	}
+	text := func(name string, x, y interface{}) {
+		if x != y {
+			fields = append(fields, FieldDiff{Field: name, A: x, B: y})
+		}
+	}
+	text("status", a.Status, b.Status)
+	text("risk_level", a.RiskLevel, b.RiskLevel)
+	number("z_score", a.ZScore, b.ZScore, tol.Score)
+	number("percentile", a.Percentile, b.Percentile, tol.Percentile)
+	number("effect_weighted_contribution", a.EffectWeightedContribution, b.EffectWeightedContribution, tol.Score)
+	number("coverage", a.Coverage, b.Coverage, tol.Score)
+	text("num_risk_alleles", a.NumRiskAlleles, b.NumRiskAlleles)
+	text("snps_present", a.SNPsPresent, b.SNPsPresent)
+	text("snps_expected", a.SNPsExpected, b.SNPsExpected)
+	text("reference_ancestry", a.ReferenceAncestry, b.ReferenceAncestry)
+	text("actionable", a.Actionable, b.Actionable)
+	return fields
+}
+
+// summariesByTrait indexes summaries by trait, keeping the first summary of each trait.
+func summariesByTrait(summaries []TraitSummary) map[string]TraitSummary {
+	m := make(map[string]TraitSummary, len(summaries))
+	for _, s := range summaries {
+		if _, ok := m[s.Trait]; !ok {
+			m[s.Trait] = s
+		}
+	}
+	return m
+}
+
+func unionKeys(a, b map[string]TraitSummary) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func diffSets(a, b []string) SetDiff {
+	inA := make(map[string]bool, len(a))
+	for _, s := range a {
+		inA[s] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, s := range b {
+		inB[s] = true
+	}
+	var d SetDiff
+	for s := range inB {
+		if !inA[s] {
+			d.Added = append(d.Added, s)
+		}
+	}
+	for s := range inA {
+		if !inB[s] {
+			d.Removed = append(d.Removed, s)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	return d
+}
+
+func excludedKeys(excluded []model.ExcludedSNP) []string {
+	keys := make([]string, len(excluded))
+	for i, e := range excluded {
+		keys[i] = fmt.Sprintf("%s/%s/%s", e.RSID, e.Trait, e.Reason)
+	}
+	return keys
+}
+
+func modelVersionOf(r OutputResult) model.ModelVersion {
+	if r.Provenance == nil || r.Provenance.Model == nil {
+		return model.ModelVersion{}
+	}
+	return *r.Provenance.Model
+}
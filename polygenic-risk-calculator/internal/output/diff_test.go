@@ -0,0 +1,78 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+func TestCompare(t *testing.T) {
+	a := OutputResult{
+		TraitSummaries: []TraitSummary{
+			{Trait: "Height", RiskLevel: "average", ZScore: 0.50, Percentile: 69.1},
+			{Trait: "LDL", RiskLevel: "high", ZScore: 2.0, Percentile: 97.7},
+		},
+		SNPSMissing:  []string{"rs1", "rs2"},
+		ExcludedSNPs: []model.ExcludedSNP{{RSID: "rs9", Trait: "LDL", Reason: "allele_mismatch"}},
+	}
+	b := OutputResult{
+		TraitSummaries: []TraitSummary{
+			{Trait: "Height", RiskLevel: "average", ZScore: 0.5000001, Percentile: 69.1},
+			{Trait: "BMI", RiskLevel: "low", ZScore: -1.2, Percentile: 11.5},
+		},
+		SNPSMissing: []string{"rs2", "rs3"},
+		Provenance:  &Provenance{Model: &model.ModelVersion{Version: "v2"}},
+	}
+
+	d := Compare(a, b, Tolerance{Score: 1e-3, Percentile: 0.1})
+	assert.False(t, d.Equal())
+	assert.Equal(t, []TraitDiff{
+		{Trait: "BMI", Change: TraitAdded},
+		{Trait: "LDL", Change: TraitRemoved},
+	}, d.Traits, "Height differs only within tolerance")
+	assert.Equal(t, SetDiff{Added: []string{"rs3"}, Removed: []string{"rs1"}}, d.SNPsMissing)
+	assert.Equal(t, SetDiff{Removed: []string{"rs9/LDL/allele_mismatch"}}, d.ExcludedSNPs)
+	require.NotNil(t, d.Model)
+
+	d = Compare(a, b, Tolerance{Score: 1e-9, Percentile: 0.1})
+	require.NotEmpty(t, d.Traits)
+	var height *TraitDiff
+	for i := range d.Traits {
+		if d.Traits[i].Trait == "Height" {
+			height = &d.Traits[i]
+		}
+	}
+	require.NotNil(t, height)
+	assert.Equal(t, TraitChanged, height.Change)
+	assert.Equal(t, "z_score", height.Fields[0].Field)
+	assert.InDelta(t, 1e-7, height.Fields[0].Delta, 1e-12)
+}
+
+func TestCompare_IdenticalOutputs(t *testing.T) {
+	r := OutputResult{
+		TraitSummaries: []TraitSummary{{Trait: "Height", Status: StatusInsufficientCoverage}},
+		SNPSMissing:    []string{"rs1"},
+	}
+	assert.True(t, Compare(r, r, Tolerance{}).Equal())
+}
+
+func TestReadResult_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	want := OutputResult{TraitSummaries: []TraitSummary{{Trait: "Height", ZScore: 1.5}}, SNPSMissing: []string{"rs1"}}
+	var buf bytes.Buffer
+	require.NoError(t, Write(want, "json", "", &buf))
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+
+	got, err := ReadResult(path)
+	require.NoError(t, err)
+	assert.True(t, Compare(want, got, Tolerance{}).Equal())
+
+	require.NoError(t, os.WriteFile(path, []byte("raw_score,z_score\n"), 0644))
+	_, err = ReadResult(path)
+	assert.Error(t, err)
+}
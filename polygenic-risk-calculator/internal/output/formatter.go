@@ -0,0 +1,140 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// Domain-specific configuration keys for external output formatters
+const (
+	FormattersKey = "output.formatters" // Map of format name -> external formatter run for --format <name>
+)
+
+// defaultFormatterTimeout bounds a formatter that sets no timeout.
+const defaultFormatterTimeout = time.Minute
+
+// maxFormatterStderr is how much of a failing formatter's stderr is quoted in the error.
+const maxFormatterStderr = 2048
+
+// ExternalFormatter is an executable that renders results in a custom format, e.g.
+// "output": {"formatters": {"pdf": {"command": ["/opt/reports/render-pdf", "--letter"]}}}.
+// It receives the JSON results document on stdin and writes the formatted bytes to stdout;
+// a non-zero exit fails the run. The command is run directly, not through a shell.
+type ExternalFormatter struct {
+	Name           string   `json:"-"`
+	Command        []string `json:"command"`         // executable and arguments
+	TimeoutSeconds int      `json:"timeout_seconds"` // 0 means one minute
+}
+
+// Validate checks that the formatter names an executable.
+func (f ExternalFormatter) Validate() error {
+	if len(f.Command) == 0 || strings.TrimSpace(f.Command[0]) == "" {
+		return errors.New("command is required")
+	}
+	if f.TimeoutSeconds < 0 {
+		return fmt.Errorf("timeout_seconds must not be negative, got %d", f.TimeoutSeconds)
+	}
+	return nil
+}
+
+// builtinFormats are the formats Write renders itself.
+var builtinFormats = []string{"json", "csv", "html"}
+
+func builtinFormat(format string) bool {
+	return slices.Contains(builtinFormats, format)
+}
+
+// FormattersFromConfig reads the external formatters, keyed by lower-cased name. The
+// built-in json, csv, and html formats cannot be replaced.
+func FormattersFromConfig() (map[string]ExternalFormatter, error) {
+	raw := make(map[string]ExternalFormatter)
+	if err := config.UnmarshalKey(FormattersKey, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", FormattersKey, err)
+	}
+	formatters := make(map[string]ExternalFormatter, len(raw))
+	for name, f := range raw {
+		name = strings.ToLower(name)
+		if builtinFormat(name) {
+			return nil, fmt.Errorf("%s.%s: the built-in %s format cannot be replaced", FormattersKey, name, name)
+		}
+		if err := f.Validate(); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", FormattersKey, name, err)
+		}
+		f.Name = name
+		formatters[name] = f
+	}
+	return formatters, nil
+}
+
+// ValidateFormat checks that format is built in or a configured external formatter.
+func ValidateFormat(format string) error {
+	if builtinFormat(format) {
+		return nil
+	}
+	formatters, err := FormattersFromConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := formatters[strings.ToLower(format)]; ok {
+		return nil
+	}
+	names := slices.Clone(builtinFormats)
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names[len(builtinFormats):])
+	return fmt.Errorf("unsupported format %q: use %s", format, strings.Join(names, ", "))
+}
+
+// Format runs the formatter on output and writes what it prints to w.
+func (f ExternalFormatter) Format(ctx context.Context, output OutputResult, w io.Writer) error {
+	doc, err := json.Marshal(output)
+	if err != nil {
+		return fmt.Errorf("failed to encode results for formatter %s: %w", f.Name, err)
+	}
+	timeout := defaultFormatterTimeout
+	if f.TimeoutSeconds > 0 {
+		timeout = time.Duration(f.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, f.Command[0], f.Command[1:]...)
+	cmd.Env = append(os.Environ(), "PHITE_FORMAT="+f.Name)
+	cmd.Stdin = bytes.NewReader(doc)
+	cmd.Stdout = w
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	logging.Info("Running external formatter %s: %s", f.Name, strings.Join(f.Command, " "))
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("formatter %s timed out after %s", f.Name, timeout)
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > maxFormatterStderr {
+			msg = "..." + msg[len(msg)-maxFormatterStderr:]
+		}
+		if msg != "" {
+			return fmt.Errorf("formatter %s failed: %w: %s", f.Name, err, msg)
+		}
+		return fmt.Errorf("formatter %s failed: %w", f.Name, err)
+	}
+	if stderr.Len() > 0 {
+		logging.Debug("Formatter %s stderr: %s", f.Name, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
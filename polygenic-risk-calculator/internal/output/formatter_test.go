@@ -0,0 +1,72 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+func TestFormattersFromConfig(t *testing.T) {
+	config.Set(FormattersKey, map[string]interface{}{
+		"PDF": map[string]interface{}{"command": []interface{}{"render-pdf", "--letter"}, "timeout_seconds": 30},
+	})
+	defer config.Set(FormattersKey, nil)
+
+	formatters, err := FormattersFromConfig()
+	require.NoError(t, err)
+	require.Contains(t, formatters, "pdf")
+	assert.Equal(t, []string{"render-pdf", "--letter"}, formatters["pdf"].Command)
+	assert.Equal(t, 30, formatters["pdf"].TimeoutSeconds)
+	assert.NoError(t, ValidateFormat("pdf"))
+	assert.NoError(t, ValidateFormat("csv"))
+	assert.ErrorContains(t, ValidateFormat("docx"), "use json, csv, html, pdf")
+
+	config.Set(FormattersKey, map[string]interface{}{"json": map[string]interface{}{"command": []interface{}{"jq"}}})
+	_, err = FormattersFromConfig()
+	assert.ErrorContains(t, err, "cannot be replaced")
+
+	config.Set(FormattersKey, map[string]interface{}{"pdf": map[string]interface{}{}})
+	_, err = FormattersFromConfig()
+	assert.ErrorContains(t, err, "pdf: command is required")
+}
+
+func TestWrite_ExternalFormatter(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	config.Set(FormattersKey, map[string]interface{}{
+		"echo": map[string]interface{}{"command": []interface{}{"sh", "-c", `cat; printf '\n%s\n' "$PHITE_FORMAT"`}},
+		"fail": map[string]interface{}{"command": []interface{}{"sh", "-c", "echo bad template >&2; exit 3"}},
+	})
+	defer config.Set(FormattersKey, nil)
+
+	result := OutputResult{TraitSummaries: []TraitSummary{{Trait: "height", RiskLevel: "moderate"}}}
+	var out bytes.Buffer
+	require.NoError(t, Write(result, "echo", "", &out))
+	doc, name, _ := strings.Cut(out.String(), "\n")
+	assert.Equal(t, "echo\n", name)
+	var got OutputResult
+	require.NoError(t, json.Unmarshal([]byte(doc), &got), "formatter receives the JSON results document")
+	assert.Equal(t, "height", got.TraitSummaries[0].Trait)
+	assert.Empty(t, got.TraitSummaries[0].PlotSVG)
+
+	// With plots on, formatters such as HTML reports receive each trait's plot to embed
+	config.Set(PlotsKey, true)
+	defer config.Set(PlotsKey, false)
+	out.Reset()
+	require.NoError(t, Write(result, "echo", "", &out))
+	doc, _, _ = strings.Cut(out.String(), "\n")
+	require.NoError(t, json.Unmarshal([]byte(doc), &got))
+	assert.True(t, strings.HasPrefix(got.TraitSummaries[0].PlotSVG, "<svg"))
+	assert.Empty(t, result.TraitSummaries[0].PlotSVG, "the caller's summaries are left as they were")
+
+	err := Write(result, "fail", "", &out)
+	assert.ErrorContains(t, err, "formatter fail failed")
+	assert.ErrorContains(t, err, "bad template")
+
+	assert.ErrorContains(t, Write(result, "docx", "", &out), "unsupported format")
+}
@@ -0,0 +1,78 @@
+package output
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/plot"
+	"phite.io/polygenic-risk-calculator/internal/prs"
+)
+
+//go:embed html_report.tmpl
+var htmlReportTemplate string
+
+// reportTemplate renders the html format: one self-contained page, with its styles,
+// script, and plots inline, so it opens offline and can be shared as a single file.
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"pct":      func(v float64) string { return fmt.Sprintf("%.1f", v) },
+	"num":      func(v float64) string { return fmt.Sprintf("%.4g", v) },
+	"coverage": func(v float64) string { return fmt.Sprintf("%.0f%%", v*100) },
+}).Parse(htmlReportTemplate))
+
+// htmlReport is the data of reportTemplate.
+type htmlReport struct {
+	OutputResult
+	Traits []htmlTrait
+}
+
+// htmlTrait is one trait's section of the report.
+type htmlTrait struct {
+	TraitSummary
+	Anchor        string
+	Score         *prs.NormalizedPRS
+	Plot          template.HTML // SVG rendered by the plot package
+	Contributions []prs.SNPContribution
+}
+
+// writeHTML renders output as a self-contained HTML report: each trait's normalized PRS
+// and its placement on the reference distribution, the SNPs contributing to its score,
+// and warnings for missing and excluded SNPs. Distribution plots are always drawn; the
+// output.plots key only governs the other formats.
+func writeHTML(output OutputResult, w io.Writer) error {
+	report := htmlReport{OutputResult: output}
+	used := make(map[string]bool)
+	for _, s := range output.TraitSummaries {
+		t := htmlTrait{TraitSummary: s, Anchor: strings.TrimSuffix(TraitFileName(s.Trait, used), ".json")}
+		if score, ok := output.TraitScores[s.Trait]; ok {
+			t.Score = &score
+		}
+		if s.Status == "" {
+			population := s.ReferenceAncestry
+			if population == "" && output.Provenance != nil {
+				population = output.Provenance.Ancestry
+			}
+			svg := plot.Distribution{Trait: s.Trait, Population: population, ZScore: s.ZScore, Percentile: s.Percentile}.SVG()
+			t.Plot = template.HTML(svg)
+		}
+		t.Contributions = sortedContributions(output.TraitContributions[s.Trait].Details)
+		report.Traits = append(report.Traits, t)
+	}
+	if err := reportTemplate.Execute(w, report); err != nil {
+		return fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return nil
+}
+
+// sortedContributions returns the SNP contributions by decreasing absolute contribution.
+func sortedContributions(details []prs.SNPContribution) []prs.SNPContribution {
+	out := append([]prs.SNPContribution(nil), details...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return math.Abs(out[i].Contribution) > math.Abs(out[j].Contribution)
+	})
+	return out
+}
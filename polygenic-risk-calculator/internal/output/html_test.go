@@ -0,0 +1,51 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
+	"phite.io/polygenic-risk-calculator/internal/prs"
+)
+
+func TestWrite_HTML(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	out := OutputResult{
+		TraitSummaries: []TraitSummary{
+			{Trait: "LDL Cholesterol", RiskLevel: "high", Percentile: 91.2, ZScore: 1.35, SNPsPresent: 2, SNPsExpected: 3, Coverage: 2.0 / 3},
+			{Trait: "Height", RiskLevel: "unknown", Status: StatusInsufficientCoverage, SNPsPresent: 1, SNPsExpected: 10, MinCoverage: 0.5},
+		},
+		SNPSMissing:  []string{"rs404"},
+		ExcludedSNPs: []model.ExcludedSNP{{RSID: "rs9", Trait: "LDL Cholesterol", Genotype: "GT", Reason: "allele_mismatch"}},
+		Provenance:   &Provenance{Ancestry: "EUR", RunKey: "abc123"},
+		TraitScores: map[string]prs.NormalizedPRS{
+			"LDL Cholesterol": {RawScore: 0.42, ZScore: 1.35, Percentile: 91.2},
+		},
+		TraitContributions: map[string]prs.PRSResult{
+			"LDL Cholesterol": {Details: []prs.SNPContribution{
+				{Rsid: "rs1", Dosage: 1, Beta: 0.02, Contribution: 0.02},
+				{Rsid: "rs2", Dosage: 2, Beta: -0.2, Contribution: -0.4},
+			}},
+		},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, Write(out, "html", "", &buf))
+	html := buf.String()
+
+	assert.Contains(t, html, "<style>")
+	assert.Contains(t, html, `<section class="trait" id="ldl-cholesterol"`)
+	assert.Contains(t, html, `href="#height"`)
+	assert.Contains(t, html, "<svg")
+	assert.Equal(t, 1, strings.Count(html, "<svg"), "only scored traits are plotted")
+	assert.Contains(t, html, "Not scored: insufficient_coverage (1 of 10 model variants genotyped, 50% required)")
+	assert.Contains(t, html, "1 requested SNPs were not found")
+	assert.Contains(t, html, "rs404")
+	assert.Contains(t, html, "<td>rs9</td><td>LDL Cholesterol</td><td>GT</td><td>allele_mismatch</td>")
+	assert.Contains(t, html, "abc123")
+	assert.Contains(t, html, "0.42")
+	assert.Less(t, strings.Index(html, "<td>rs2</td>"), strings.Index(html, "<td>rs1</td>"), "larger contributions come first")
+}
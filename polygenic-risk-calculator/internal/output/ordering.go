@@ -0,0 +1,234 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/JerkyTreats/PHITE/taxonomy"
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+// Domain-specific configuration keys for trait summary ordering
+const (
+	SortByKey      = "output.sort_by"      // percentile, abs_z, trait (default), or category
+	GroupByKey     = "output.group_by"     // "topic" to group summaries by topic; empty leaves them flat
+	TaxonomyKey    = "output.taxonomy"     // path to the shared Topic/Group taxonomy TSV
+	TraitTopicsKey = "output.trait_topics" // trait -> topic overrides applied on top of the taxonomy
+)
+
+// Sort keys for trait summaries.
+const (
+	SortByPercentile = "percentile" // highest percentile first
+	SortByAbsZ       = "abs_z"      // largest |z-score| first
+	SortByTrait      = "trait"      // trait name, A-Z
+	SortByCategory   = "category"   // topic (uncategorized last), then trait name
+	SortByPanel      = "panel"      // the trait panel's display order, other traits after by name
+)
+
+// GroupByTopic groups trait summaries under their topic.
+const GroupByTopic = "topic"
+
+// UncategorizedTopic is the topic of traits with no taxonomy entry.
+const UncategorizedTopic = "Uncategorized"
+
+// TraitGroup is a set of trait summaries sharing a topic.
+type TraitGroup struct {
+	Topic  string         `json:"topic"`
+	Traits []TraitSummary `json:"traits"`
+}
+
+// Arrangement controls how trait summaries are ordered and grouped in reports.
+type Arrangement struct {
+	SortBy   string
+	GroupBy  string
+	Taxonomy *taxonomy.Taxonomy // files traits under a topic and group; may be nil
+	Order    []string           // trait display order for SortByPanel
+}
+
+// ArrangementFromConfig reads the sort key, grouping, and taxonomy from configuration.
+func ArrangementFromConfig() (Arrangement, error) {
+	a := Arrangement{
+		SortBy:   strings.ToLower(config.GetString(SortByKey)),
+		GroupBy:  strings.ToLower(config.GetString(GroupByKey)),
+		Taxonomy: taxonomy.New(),
+	}
+	if a.SortBy == "" {
+		a.SortBy = SortByTrait
+	}
+	if err := ValidateSortBy(a.SortBy); err != nil {
+		return a, fmt.Errorf("%s: %w", SortByKey, err)
+	}
+	if err := ValidateGroupBy(a.GroupBy); err != nil {
+		return a, fmt.Errorf("%s: %w", GroupByKey, err)
+	}
+	if path := config.GetString(TaxonomyKey); path != "" {
+		tax, err := taxonomy.Load(path)
+		if err != nil {
+			return a, fmt.Errorf("%s: %w", TaxonomyKey, err)
+		}
+		a.Taxonomy = tax
+	}
+	for trait, topic := range config.GetStringMapString(TraitTopicsKey) {
+		a.Taxonomy.AddTrait(trait, taxonomy.Category{Topic: topic})
+	}
+	return a, nil
+}
+
+// WithLayout lays summaries out as a trait panel does: in its display order, grouped under
+// its sections, which take the place of the traits' taxonomy topics. Sort and grouping keys
+// set in configuration are kept.
+func (a Arrangement) WithLayout(order []string, sections map[string]string) Arrangement {
+	a.Order = order
+	if config.GetString(SortByKey) == "" {
+		a.SortBy = SortByPanel
+	}
+	if len(sections) == 0 {
+		return a
+	}
+	if config.GetString(GroupByKey) == "" {
+		a.GroupBy = GroupByTopic
+	}
+	if a.Taxonomy == nil {
+		a.Taxonomy = taxonomy.New()
+	}
+	for trait, section := range sections {
+		a.Taxonomy.AddTrait(trait, taxonomy.Category{Topic: section})
+	}
+	return a
+}
+
+// ValidateSortBy checks that key is a supported sort key.
+func ValidateSortBy(key string) error {
+	switch key {
+	case SortByPercentile, SortByAbsZ, SortByTrait, SortByCategory, SortByPanel:
+		return nil
+	}
+	return fmt.Errorf("unknown sort key %q: use %s, %s, %s, %s, or %s", key, SortByPercentile, SortByAbsZ, SortByTrait, SortByCategory, SortByPanel)
+}
+
+// ValidateGroupBy checks that key is empty or a supported grouping.
+func ValidateGroupBy(key string) error {
+	if key == "" || key == GroupByTopic {
+		return nil
+	}
+	return fmt.Errorf("unknown grouping %q: use %s or leave empty", key, GroupByTopic)
+}
+
+// Categorize annotates each summary with its topic and group from the taxonomy.
+func (a Arrangement) Categorize(summaries []TraitSummary) {
+	for i := range summaries {
+		if c, ok := a.Taxonomy.Trait(summaries[i].Trait); ok {
+			summaries[i].Topic, summaries[i].Group = c.Topic, c.Group
+		}
+	}
+}
+
+// Arrange annotates each summary with its topic and group, sorts the summaries in place, and, when
+// grouping by topic, returns them grouped in topic order, or in order of first appearance
+// for SortByPanel. Traits that were not scored sort after scored traits for the percentile
+// and abs_z keys.
+func (a Arrangement) Arrange(summaries []TraitSummary) []TraitGroup {
+	a.Categorize(summaries)
+	if a.SortBy == SortByPanel {
+		sortByOrder(summaries, a.Order)
+	} else {
+		SortTraitSummaries(summaries, a.SortBy)
+	}
+	if a.GroupBy != GroupByTopic {
+		return nil
+	}
+	if a.SortBy == SortByPanel {
+		return groupTraitSummaries(summaries)
+	}
+	return GroupTraitSummaries(summaries)
+}
+
+// sortByOrder sorts summaries in place into the order of the traits listed in order;
+// unlisted traits follow by name.
+func sortByOrder(summaries []TraitSummary, order []string) {
+	rank := make(map[string]int, len(order))
+	for i, trait := range order {
+		rank[strings.ToLower(trait)] = i
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		ri, iok := rank[strings.ToLower(summaries[i].Trait)]
+		rj, jok := rank[strings.ToLower(summaries[j].Trait)]
+		if iok != jok {
+			return iok
+		}
+		if iok && ri != rj {
+			return ri < rj
+		}
+		return summaries[i].Trait < summaries[j].Trait
+	})
+}
+
+// SortTraitSummaries sorts summaries in place by key. Ties, and unknown keys, fall back to
+// trait name so output is deterministic.
+func SortTraitSummaries(summaries []TraitSummary, key string) {
+	sort.SliceStable(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		switch key {
+		case SortByPercentile, SortByAbsZ:
+			if scoredA, scoredB := a.Status == "", b.Status == ""; scoredA != scoredB {
+				return scoredA
+			}
+			va, vb := a.Percentile, b.Percentile
+			if key == SortByAbsZ {
+				va, vb = math.Abs(a.ZScore), math.Abs(b.ZScore)
+			}
+			if va != vb {
+				return va > vb
+			}
+		case SortByCategory:
+			if ta, tb := topicOf(a), topicOf(b); ta != tb {
+				return topicLess(ta, tb)
+			}
+		}
+		return a.Trait < b.Trait
+	})
+}
+
+// GroupTraitSummaries groups summaries by topic. Groups are ordered by topic name, with
+// uncategorized traits last; each group keeps the summaries' existing order.
+func GroupTraitSummaries(summaries []TraitSummary) []TraitGroup {
+	groups := groupTraitSummaries(summaries)
+	sort.SliceStable(groups, func(i, j int) bool {
+		return topicLess(groups[i].Topic, groups[j].Topic)
+	})
+	return groups
+}
+
+// groupTraitSummaries groups summaries by topic, in order of each topic's first summary.
+func groupTraitSummaries(summaries []TraitSummary) []TraitGroup {
+	index := make(map[string]int)
+	var groups []TraitGroup
+	for _, ts := range summaries {
+		topic := topicOf(ts)
+		i, ok := index[topic]
+		if !ok {
+			i = len(groups)
+			index[topic] = i
+			groups = append(groups, TraitGroup{Topic: topic})
+		}
+		groups[i].Traits = append(groups[i].Traits, ts)
+	}
+	return groups
+}
+
+// topicLess orders topics by name, with UncategorizedTopic last.
+func topicLess(a, b string) bool {
+	if (a == UncategorizedTopic) != (b == UncategorizedTopic) {
+		return b == UncategorizedTopic
+	}
+	return a < b
+}
+
+func topicOf(ts TraitSummary) string {
+	if ts.Topic == "" {
+		return UncategorizedTopic
+	}
+	return ts.Topic
+}
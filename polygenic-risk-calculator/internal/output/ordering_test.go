@@ -0,0 +1,146 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JerkyTreats/PHITE/taxonomy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+func traitNames(summaries []TraitSummary) []string {
+	names := make([]string, len(summaries))
+	for i, ts := range summaries {
+		names[i] = ts.Trait
+	}
+	return names
+}
+
+func orderingFixture() []TraitSummary {
+	return []TraitSummary{
+		{Trait: "ldl", Percentile: 40, ZScore: -2.1, Topic: "Cardiovascular"},
+		{Trait: "bmi", Percentile: 90, ZScore: 1.3, Topic: "Metabolic"},
+		{Trait: "height", Status: StatusInsufficientCoverage},
+		{Trait: "t2d", Percentile: 70, ZScore: 0.5, Topic: "Metabolic"},
+	}
+}
+
+func TestSortTraitSummaries(t *testing.T) {
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{SortByPercentile, []string{"bmi", "t2d", "ldl", "height"}},
+		{SortByAbsZ, []string{"ldl", "bmi", "t2d", "height"}},
+		{SortByTrait, []string{"bmi", "height", "ldl", "t2d"}},
+		{SortByCategory, []string{"ldl", "bmi", "t2d", "height"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			summaries := orderingFixture()
+			SortTraitSummaries(summaries, tt.key)
+			assert.Equal(t, tt.want, traitNames(summaries))
+		})
+	}
+}
+
+func TestArrangement_Arrange(t *testing.T) {
+	summaries := []TraitSummary{
+		{Trait: "T2D", Percentile: 70},
+		{Trait: "height", Percentile: 55},
+		{Trait: "BMI", Percentile: 90},
+		{Trait: "LDL", Percentile: 40},
+	}
+	tax := taxonomy.New()
+	tax.AddTrait("bmi", taxonomy.Category{Topic: "Metabolic", Group: "Weight"})
+	tax.AddTrait("t2d", taxonomy.Category{Topic: "Metabolic", Group: "Diabetes Risk"})
+	tax.AddTrait("ldl", taxonomy.Category{Topic: "Cardiovascular"})
+	a := Arrangement{SortBy: SortByPercentile, GroupBy: GroupByTopic, Taxonomy: tax}
+
+	groups := a.Arrange(summaries)
+	assert.Equal(t, []string{"BMI", "T2D", "height", "LDL"}, traitNames(summaries))
+	require.Len(t, groups, 3)
+	assert.Equal(t, "Cardiovascular", groups[0].Topic)
+	assert.Equal(t, "Metabolic", groups[1].Topic)
+	assert.Equal(t, []string{"BMI", "T2D"}, traitNames(groups[1].Traits))
+	assert.Equal(t, "Diabetes Risk", groups[1].Traits[1].Group)
+	assert.Equal(t, UncategorizedTopic, groups[2].Topic)
+	assert.Equal(t, "", groups[2].Traits[0].Topic)
+
+	a.GroupBy = ""
+	assert.Nil(t, a.Arrange(summaries))
+}
+
+func TestArrangementFromConfig(t *testing.T) {
+	defer func() {
+		config.Set(SortByKey, "")
+		config.Set(GroupByKey, "")
+		config.Set(TaxonomyKey, "")
+		config.Set(TraitTopicsKey, nil)
+	}()
+
+	a, err := ArrangementFromConfig()
+	require.NoError(t, err)
+	assert.Equal(t, SortByTrait, a.SortBy)
+	assert.Equal(t, "", a.GroupBy)
+
+	config.Set(SortByKey, "ABS_Z")
+	config.Set(GroupByKey, "topic")
+	path := filepath.Join(t.TempDir(), "taxonomy.tsv")
+	require.NoError(t, os.WriteFile(path, []byte("Topic\tGroup\tTraits\nMetabolic\tDiabetes Risk\tt2d,bmi\n"), 0o644))
+	config.Set(TaxonomyKey, path)
+	config.Set(TraitTopicsKey, map[string]interface{}{"BMI": "Anthropometric"})
+	a, err = ArrangementFromConfig()
+	require.NoError(t, err)
+	assert.Equal(t, SortByAbsZ, a.SortBy)
+	assert.Equal(t, GroupByTopic, a.GroupBy)
+	c, _ := a.Taxonomy.Trait("T2D")
+	assert.Equal(t, taxonomy.Category{Topic: "Metabolic", Group: "Diabetes Risk"}, c)
+	c, _ = a.Taxonomy.Trait("bmi")
+	assert.Equal(t, taxonomy.Category{Topic: "Anthropometric"}, c)
+
+	config.Set(TaxonomyKey, filepath.Join(t.TempDir(), "missing.tsv"))
+	_, err = ArrangementFromConfig()
+	assert.ErrorContains(t, err, TaxonomyKey)
+	config.Set(TaxonomyKey, "")
+
+	config.Set(SortByKey, "risk")
+	_, err = ArrangementFromConfig()
+	assert.ErrorContains(t, err, "unknown sort key")
+
+	config.Set(SortByKey, "")
+	config.Set(GroupByKey, "gene")
+	_, err = ArrangementFromConfig()
+	assert.ErrorContains(t, err, "unknown grouping")
+}
+
+func TestArrangement_WithLayout(t *testing.T) {
+	summaries := []TraitSummary{
+		{Trait: "height", Percentile: 55},
+		{Trait: "T2D", Percentile: 70},
+		{Trait: "LDL", Percentile: 40},
+		{Trait: "BMI", Percentile: 90},
+	}
+	base := Arrangement{SortBy: SortByTrait, Taxonomy: taxonomy.New()}
+	a := base.WithLayout([]string{"ldl", "bmi", "t2d"}, map[string]string{"ldl": "Heart", "bmi": "Weight", "t2d": "Heart"})
+	assert.Equal(t, SortByPanel, a.SortBy)
+	assert.Equal(t, GroupByTopic, a.GroupBy)
+
+	groups := a.Arrange(summaries)
+	assert.Equal(t, []string{"LDL", "BMI", "T2D", "height"}, traitNames(summaries))
+	require.Len(t, groups, 3)
+	assert.Equal(t, "Heart", groups[0].Topic)
+	assert.Equal(t, []string{"LDL", "T2D"}, traitNames(groups[0].Traits))
+	assert.Equal(t, "Weight", groups[1].Topic)
+	assert.Equal(t, UncategorizedTopic, groups[2].Topic)
+
+	// Keys set in configuration win over the panel's layout
+	config.Set(SortByKey, "percentile")
+	defer config.Set(SortByKey, "")
+	a = base.WithLayout([]string{"ldl"}, nil)
+	assert.Equal(t, SortByTrait, a.SortBy)
+	assert.Equal(t, "", a.GroupBy)
+}
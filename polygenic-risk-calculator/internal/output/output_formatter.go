@@ -1,48 +1,113 @@
 package output
 
 import (
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"phite.io/polygenic-risk-calculator/internal/logging"
 	"io"
 	"os"
+	"strings"
 
+	"phite.io/polygenic-risk-calculator/internal/buildinfo"
+	"phite.io/polygenic-risk-calculator/internal/compound"
+	"phite.io/polygenic-risk-calculator/internal/contig"
+	"phite.io/polygenic-risk-calculator/internal/integrity"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
 	"phite.io/polygenic-risk-calculator/internal/prs"
 )
 
 // TraitSummary represents a summary for a trait (from data_model.md)
 type TraitSummary struct {
-	Trait                      string  `json:"trait"`
-	NumRiskAlleles             int     `json:"num_risk_alleles"`
-	EffectWeightedContribution float64 `json:"effect_weighted_contribution"`
-	RiskLevel                  string  `json:"risk_level"`
+	Trait                      string         `json:"trait"`
+	NumRiskAlleles             int            `json:"num_risk_alleles"`
+	EffectWeightedContribution float64        `json:"effect_weighted_contribution"`
+	RiskLevel                  string         `json:"risk_level"`
+	Status                     string         `json:"status,omitempty"`             // set when the trait was not scored, e.g. "insufficient_coverage"
+	SNPsPresent                int            `json:"snps_present"`                 // model variants found in the genotype
+	SNPsExpected               int            `json:"snps_expected"`                // model variants requested for the trait
+	SNPsExcluded               int            `json:"snps_excluded"`                // genotyped variants excluded for alleles incompatible with the model, e.g. ambiguous strand
+	Coverage                   float64        `json:"coverage"`                     // SNPsPresent / SNPsExpected
+	MinCoverage                float64        `json:"min_coverage"`                 // coverage threshold in effect
+	WeightSources              map[string]int `json:"weight_sources,omitempty"`     // weight column -> number of SNPs scored with it
+	Percentile                 float64        `json:"percentile,omitempty"`         // normalized PRS percentile of the trait
+	ZScore                     float64        `json:"z_score,omitempty"`            // normalized PRS z-score of the trait
+	Topic                      string         `json:"topic,omitempty"`              // taxonomy topic, when configured
+	Group                      string         `json:"group,omitempty"`              // taxonomy group within the topic
+	ReferenceAncestry          string         `json:"reference_ancestry,omitempty"` // reference population used in place of the user's ancestry
+	AncestryCaveat             bool           `json:"ancestry_caveat,omitempty"`    // true when normalized against a population other than the user's
+	CuratedNotes               []CuratedSNP   `json:"curated_notes,omitempty"`      // converter SNP notes on the trait's SNPs or taxonomy group
+	Actionable                 bool           `json:"actionable,omitempty"`         // result reaches the trait's clinically actionable threshold
+	ActionableNote             string         `json:"actionable_note,omitempty"`    // configured guidance shown with the flag
+
+	VarianceContributors []model.VarianceContribution `json:"variance_contributors,omitempty"` // variants contributing most to the reference variance, when the stats were computed this run
+	PlotSVG              string                       `json:"plot_svg,omitempty"`              // reference distribution plot, given to external formatters when output.plots is set
 }
 
+// StatusInsufficientCoverage marks a trait skipped because too few model variants were genotyped.
+const StatusInsufficientCoverage = "insufficient_coverage"
+
 // OutputResult represents the output structure for PRS results, summaries, and missing SNPs.
 type OutputResult struct {
-	NormalizedPRS  prs.NormalizedPRS `json:"normalized_prs"`
-	PRSResult      prs.PRSResult     `json:"prs_result"`
-	TraitSummaries []TraitSummary    `json:"trait_summaries"`
-	SNPSMissing    []string          `json:"snps_missing"`
+	NormalizedPRS  prs.NormalizedPRS          `json:"normalized_prs"`
+	PRSResult      prs.PRSResult              `json:"prs_result"`
+	TraitSummaries []TraitSummary             `json:"trait_summaries"`
+	TraitGroups    []TraitGroup               `json:"trait_groups,omitempty"` // summaries grouped by topic, when requested
+	SNPSMissing    []string                   `json:"snps_missing"`
+	ExcludedSNPs   []model.ExcludedSNP        `json:"excluded_snps,omitempty"`
+	Alleles        *model.AlleleHarmonization `json:"allele_harmonization,omitempty"` // variants strand-flipped, swapped, or dropped to match the models' alleles
+	Contigs        *contig.Report             `json:"contigs,omitempty"`              // variants dropped or remapped for lying on ALT contigs
+	DerivedMetrics []DerivedMetric            `json:"derived_metrics,omitempty"`
+	Compound       []compound.Result          `json:"compound_genotypes,omitempty"` // categorical genotypes such as APOE diplotypes
+	PGx            []compound.Result          `json:"pgx,omitempty"`                // pharmacogene diplotypes with CPIC-style phenotypes
+	Provenance     *Provenance                `json:"provenance,omitempty"`
+
+	// Per-trait scores and SNP contributions, shown by the HTML report; the JSON keeps its
+	// single-score fields
+	TraitScores        map[string]prs.NormalizedPRS `json:"-"`
+	TraitContributions map[string]prs.PRSResult     `json:"-"`
+}
+
+// Provenance records the inputs a result was computed from.
+type Provenance struct {
+	InputFiles []integrity.FileChecksum `json:"input_files,omitempty"`
+	Model      *model.ModelVersion      `json:"model,omitempty"`
+	Build      *buildinfo.Info          `json:"build,omitempty"`    // binary that computed the result
+	RunKey     string                   `json:"run_key,omitempty"`  // digest of the run's inputs and settings; see integrity.RunKey
+	Ancestry   string                   `json:"ancestry,omitempty"` // reference population the run's scores are normalized against
 }
 
-// FormatOutput serializes results as JSON or CSV and writes to file or stdout.
+// FormatOutput serializes results as JSON, CSV, or HTML and writes to file or stdout.
 // If outFile is empty, writes to out (or stdout if out is nil).
 func FormatOutput(norm prs.NormalizedPRS, prs prs.PRSResult, summaries []TraitSummary, snpsMissing []string, format, outFile string, out io.Writer) error {
-	logging.Info("Formatting output: format=%s, outFile=%s", format, outFile)
-	if format != "json" && format != "csv" {
-		logging.Error("unsupported output format: %s", format)
-		return errors.New("unsupported format: must be 'json' or 'csv'")
-	}
-
-	output := OutputResult{
+	return Write(OutputResult{
 		NormalizedPRS:  norm,
 		PRSResult:      prs,
 		TraitSummaries: summaries,
 		SNPSMissing:    snpsMissing,
+	}, format, outFile, out)
+}
+
+// Write serializes a complete OutputResult as JSON, CSV, or an HTML report and writes to
+// file or stdout. If outFile is empty, writes to out (or stdout if out is nil).
+func Write(output OutputResult, format, outFile string, out io.Writer) error {
+	logging.Info("Formatting output: format=%s, outFile=%s", format, outFile)
+	var external *ExternalFormatter
+	if !builtinFormat(format) {
+		formatters, err := FormattersFromConfig()
+		if err != nil {
+			return err
+		}
+		f, ok := formatters[strings.ToLower(format)]
+		if !ok {
+			logging.Error("unsupported output format: %s", format)
+			return errors.New("unsupported format: must be 'json', 'csv', 'html', or a name under " + FormattersKey)
+		}
+		external = &f
 	}
+	norm, prs, summaries, snpsMissing := output.NormalizedPRS, output.PRSResult, output.TraitSummaries, output.SNPSMissing
 
 	var w io.Writer
 	if outFile != "" {
@@ -60,6 +125,16 @@ func FormatOutput(norm prs.NormalizedPRS, prs prs.PRSResult, summaries []TraitSu
 		w = os.Stdout
 	}
 
+	if external != nil {
+		output.TraitSummaries = withPlots(output.TraitSummaries, output.Provenance)
+		return external.Format(context.Background(), output, w)
+	}
+
+	if format == "html" {
+		logging.Info("Rendering output as an HTML report")
+		return writeHTML(output, w)
+	}
+
 	if format == "json" {
 		logging.Info("Encoding output as JSON")
 		e := json.NewEncoder(w)
@@ -76,12 +151,18 @@ func FormatOutput(norm prs.NormalizedPRS, prs prs.PRSResult, summaries []TraitSu
 	csvw := csv.NewWriter(w)
 	defer csvw.Flush()
 	// Write prs.NormalizedPRS
-	csvw.Write([]string{"raw_score", "z_score", "percentile"})
-	csvw.Write([]string{
+	header := []string{"raw_score", "z_score", "percentile"}
+	row := []string{
 		fmt.Sprintf("%v", norm.RawScore),
 		fmt.Sprintf("%v", norm.ZScore),
 		fmt.Sprintf("%v", norm.Percentile),
-	})
+	}
+	if norm.Scaled != nil {
+		header = append(header, "scaled_score", "scaled_unit")
+		row = append(row, fmt.Sprintf("%v", norm.Scaled.Value), norm.Scaled.Unit)
+	}
+	csvw.Write(header)
+	csvw.Write(row)
 	// Write prs.PRSResult
 	csvw.Write([]string{"prs_score"})
 	csvw.Write([]string{fmt.Sprintf("%v", prs.PRSScore)})
@@ -93,6 +174,14 @@ func FormatOutput(norm prs.NormalizedPRS, prs prs.PRSResult, summaries []TraitSu
 		}
 		csvw.Write([]string{"trait_summaries", string(b)})
 	}
+	// Write TraitGroups as JSON
+	if output.TraitGroups != nil {
+		b, err := json.Marshal(output.TraitGroups)
+		if err != nil {
+			logging.Error("failed to marshal trait groups as JSON: %v", err)
+		}
+		csvw.Write([]string{"trait_groups", string(b)})
+	}
 	// Write SNPSMissing as JSON
 	if snpsMissing != nil {
 		b, err := json.Marshal(snpsMissing)
@@ -101,5 +190,61 @@ func FormatOutput(norm prs.NormalizedPRS, prs prs.PRSResult, summaries []TraitSu
 		}
 		csvw.Write([]string{"snps_missing", string(b)})
 	}
+	// Write ExcludedSNPs as JSON
+	if output.ExcludedSNPs != nil {
+		b, err := json.Marshal(output.ExcludedSNPs)
+		if err != nil {
+			logging.Error("failed to marshal excluded_snps as JSON: %v", err)
+		}
+		csvw.Write([]string{"excluded_snps", string(b)})
+	}
+	// Write Alleles as JSON
+	if output.Alleles != nil {
+		b, err := json.Marshal(output.Alleles)
+		if err != nil {
+			logging.Error("failed to marshal allele_harmonization as JSON: %v", err)
+		}
+		csvw.Write([]string{"allele_harmonization", string(b)})
+	}
+	// Write Contigs as JSON
+	if output.Contigs != nil {
+		b, err := json.Marshal(output.Contigs)
+		if err != nil {
+			logging.Error("failed to marshal contigs as JSON: %v", err)
+		}
+		csvw.Write([]string{"contigs", string(b)})
+	}
+	// Write DerivedMetrics as JSON
+	if output.DerivedMetrics != nil {
+		b, err := json.Marshal(output.DerivedMetrics)
+		if err != nil {
+			logging.Error("failed to marshal derived_metrics as JSON: %v", err)
+		}
+		csvw.Write([]string{"derived_metrics", string(b)})
+	}
+	// Write Compound as JSON
+	if output.Compound != nil {
+		b, err := json.Marshal(output.Compound)
+		if err != nil {
+			logging.Error("failed to marshal compound_genotypes as JSON: %v", err)
+		}
+		csvw.Write([]string{"compound_genotypes", string(b)})
+	}
+	// Write PGx as JSON
+	if output.PGx != nil {
+		b, err := json.Marshal(output.PGx)
+		if err != nil {
+			logging.Error("failed to marshal pgx as JSON: %v", err)
+		}
+		csvw.Write([]string{"pgx", string(b)})
+	}
+	// Write Provenance as JSON
+	if output.Provenance != nil {
+		b, err := json.Marshal(output.Provenance)
+		if err != nil {
+			logging.Error("failed to marshal provenance as JSON: %v", err)
+		}
+		csvw.Write([]string{"provenance", string(b)})
+	}
 	return nil
 }
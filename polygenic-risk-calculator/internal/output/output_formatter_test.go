@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"phite.io/polygenic-risk-calculator/internal/model"
 	"phite.io/polygenic-risk-calculator/internal/prs"
 	"phite.io/polygenic-risk-calculator/internal/logging"
 )
@@ -71,3 +72,50 @@ func TestOutputFormatter_HandlesEmptyInputs(t *testing.T) {
 		t.Errorf("unexpected error for empty input: %v", err)
 	}
 }
+
+func TestOutputFormatter_Write_IncludesExcludedSNPs(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	result := OutputResult{
+		TraitSummaries: []TraitSummary{{Trait: "height", RiskLevel: "low"}},
+		ExcludedSNPs:   []model.ExcludedSNP{{RSID: "rs9", Trait: "height", Genotype: "CT", Reason: "allele_mismatch"}},
+	}
+	for _, format := range []string{"json", "csv"} {
+		var out strings.Builder
+		if err := Write(result, format, "", &out); err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		if !strings.Contains(out.String(), "excluded_snps") || !strings.Contains(out.String(), "allele_mismatch") {
+			t.Errorf("%s output missing excluded SNPs: %v", format, out.String())
+		}
+	}
+}
+
+func TestOutputFormatter_CSV_ScaledScore(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	norm := prs.NormalizedPRS{RawScore: 2, ZScore: 1.5, Percentile: 95.0, Scaled: &prs.ScaledScore{Value: 125, Unit: "mmHg"}}
+	var out strings.Builder
+	err := Write(OutputResult{NormalizedPRS: norm}, "csv", "", &out)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "raw_score,z_score,percentile,scaled_score,scaled_unit\n2,1.5,95,125,mmHg\n") {
+		t.Errorf("CSV output missing scaled score: %v", out.String())
+	}
+}
+
+func TestOutputFormatter_JSON_ZeroCoverage(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	result := OutputResult{
+		TraitSummaries: []TraitSummary{{Trait: "height", RiskLevel: "unknown", Status: StatusInsufficientCoverage, SNPsExpected: 12, MinCoverage: 0.8}},
+	}
+	var out strings.Builder
+	if err := Write(result, "json", "", &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A trait with none of its variants genotyped still reports the counts, not their absence
+	for _, field := range []string{`"snps_present": 0`, `"snps_expected": 12`, `"coverage": 0`, `"min_coverage": 0.8`} {
+		if !strings.Contains(out.String(), field) {
+			t.Errorf("JSON output missing %s: %v", field, out.String())
+		}
+	}
+}
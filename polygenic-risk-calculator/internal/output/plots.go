@@ -0,0 +1,39 @@
+package output
+
+import (
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/plot"
+)
+
+// Domain-specific configuration keys for distribution plots
+const (
+	PlotsKey = "output.plots" // Render each scored trait's reference distribution, with the user's score marked, as SVG
+)
+
+// plotFor returns the SVG distribution plot of a scored trait summary, or nil when plots
+// are off or the trait was not scored. The population is the summary's own reference
+// ancestry when it has one, else the run's.
+func plotFor(s TraitSummary, provenance *Provenance) []byte {
+	if !config.GetBool(PlotsKey) || s.Status != "" {
+		return nil
+	}
+	population := s.ReferenceAncestry
+	if population == "" && provenance != nil {
+		population = provenance.Ancestry
+	}
+	return plot.Distribution{Trait: s.Trait, Population: population, ZScore: s.ZScore, Percentile: s.Percentile}.SVG()
+}
+
+// withPlots returns a copy of summaries carrying their plots inline, for external
+// formatters such as HTML reports to embed.
+func withPlots(summaries []TraitSummary, provenance *Provenance) []TraitSummary {
+	if !config.GetBool(PlotsKey) {
+		return summaries
+	}
+	plotted := make([]TraitSummary, len(summaries))
+	for i, s := range summaries {
+		s.PlotSVG = string(plotFor(s, provenance))
+		plotted[i] = s
+	}
+	return plotted
+}
@@ -0,0 +1,82 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RunKeyPlaceholder in an --output or --output-dir path is replaced by the run key's first
+// RunKeyPrefixLength hex digits, naming results by content, e.g. "results/{run_key}.json".
+const RunKeyPlaceholder = "{run_key}"
+
+// RunKeyPrefixLength is how much of the run key names content-addressed outputs.
+const RunKeyPrefixLength = 16
+
+// ExpandRunKey replaces RunKeyPlaceholder in path with the run key's prefix.
+func ExpandRunKey(path, runKey string) string {
+	return strings.ReplaceAll(path, RunKeyPlaceholder, runKey[:min(RunKeyPrefixLength, len(runKey))])
+}
+
+// PreviousRun is what an earlier run left at an output location.
+type PreviousRun struct {
+	RunKey string   // run key recorded in its provenance; empty when none was recorded
+	Files  []string // files it wrote
+}
+
+// ReadPreviousRun reads the JSON results at outFile, or the split results in dir (see
+// WriteSplit), returning a zero PreviousRun when there are none. A results set missing any
+// of its files is treated as absent.
+func ReadPreviousRun(outFile, dir string) (PreviousRun, error) {
+	if dir != "" {
+		indexPath := filepath.Join(dir, IndexFile)
+		var index Index
+		if found, err := readJSON(indexPath, &index); !found || err != nil {
+			return PreviousRun{}, err
+		}
+		prev := PreviousRun{Files: make([]string, 0, len(index.Traits)+1)}
+		for _, t := range index.Traits {
+			path := filepath.Join(dir, t.File)
+			if _, err := os.Stat(path); err != nil {
+				return PreviousRun{}, nil
+			}
+			prev.Files = append(prev.Files, path)
+		}
+		prev.Files = append(prev.Files, indexPath)
+		if index.Provenance != nil {
+			prev.RunKey = index.Provenance.RunKey
+		}
+		return prev, nil
+	}
+
+	var doc struct {
+		Provenance *Provenance `json:"provenance"`
+	}
+	if found, err := readJSON(outFile, &doc); !found || err != nil {
+		return PreviousRun{}, err
+	}
+	prev := PreviousRun{Files: []string{outFile}}
+	if doc.Provenance != nil {
+		prev.RunKey = doc.Provenance.RunKey
+	}
+	return prev, nil
+}
+
+// readJSON decodes the file at path into v, reporting whether it exists. A file that is not
+// valid JSON, e.g. one a crashed run left half-written, counts as absent.
+func readJSON(path string, v any) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read previous results: %w", err)
+	}
+	if json.Unmarshal(data, v) != nil {
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,58 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+func TestExpandRunKey(t *testing.T) {
+	key := "0123456789abcdef0123456789abcdef"
+	assert.Equal(t, "results/0123456789abcdef.json", ExpandRunKey("results/{run_key}.json", key))
+	assert.Equal(t, "results.json", ExpandRunKey("results.json", key))
+}
+
+func TestReadPreviousRun(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	dir := t.TempDir()
+	out := OutputResult{
+		TraitSummaries: []TraitSummary{{Trait: "Height", RiskLevel: "moderate"}},
+		Provenance:     &Provenance{RunKey: "abc"},
+	}
+
+	t.Run("results file", func(t *testing.T) {
+		path := filepath.Join(dir, "results.json")
+		prev, err := ReadPreviousRun(path, "")
+		require.NoError(t, err)
+		assert.Empty(t, prev.RunKey)
+
+		require.NoError(t, Write(out, "json", path, &bytes.Buffer{}))
+		prev, err = ReadPreviousRun(path, "")
+		require.NoError(t, err)
+		assert.Equal(t, PreviousRun{RunKey: "abc", Files: []string{path}}, prev)
+
+		require.NoError(t, os.WriteFile(path, []byte(`{"provenance":`), 0o644))
+		prev, err = ReadPreviousRun(path, "")
+		require.NoError(t, err)
+		assert.Empty(t, prev.RunKey, "a truncated file is not a previous run")
+	})
+
+	t.Run("split results", func(t *testing.T) {
+		split := filepath.Join(dir, "split")
+		written, err := WriteSplit(out, split)
+		require.NoError(t, err)
+		prev, err := ReadPreviousRun("", split)
+		require.NoError(t, err)
+		assert.Equal(t, PreviousRun{RunKey: "abc", Files: written}, prev)
+
+		require.NoError(t, os.Remove(written[0]))
+		prev, err = ReadPreviousRun("", split)
+		require.NoError(t, err)
+		assert.Empty(t, prev.RunKey, "a set missing a trait file is not a previous run")
+	})
+}
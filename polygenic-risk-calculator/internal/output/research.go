@@ -0,0 +1,194 @@
+package output
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+// Domain-specific configuration keys for research exports
+const (
+	ResearchEnabledKey = "research.enabled" // Allows research-export to link scores with phenotypes (default false)
+)
+
+// ErrResearchDisabled is returned when a research export is requested without
+// ResearchEnabledKey set.
+var ErrResearchDisabled = fmt.Errorf("research exports are disabled: set %s to true", ResearchEnabledKey)
+
+// ResearchEnabled reports whether ResearchEnabledKey allows research exports.
+func ResearchEnabled() bool {
+	return config.GetBool(ResearchEnabledKey)
+}
+
+// researchColumns are the score columns of a research table, before the phenotype columns.
+var researchColumns = []string{
+	"sample_id", "trait", "status", "z_score", "percentile", "risk_level",
+	"effect_weighted_contribution", "snps_present", "snps_expected", "coverage", "reference_ancestry", "model_version",
+}
+
+// ResearchSample is one sample's results, named by its sample ID.
+type ResearchSample struct {
+	ID     string
+	Result OutputResult
+}
+
+// SampleID returns the sample ID of a result file: its file name without the extension.
+func SampleID(path string) string {
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// Phenotypes are user-supplied phenotype values by sample ID.
+type Phenotypes struct {
+	Columns []string                     // exported columns, in the order requested
+	Values  map[string]map[string]string // sample ID -> column -> value
+}
+
+// ReadPhenotypes reads a phenotype CSV with a header row, keeping idColumn and the listed
+// columns only. Every listed column must be present, and sample IDs must be unique.
+func ReadPhenotypes(path, idColumn string, columns []string) (*Phenotypes, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read phenotype header of %s: %w", path, err)
+	}
+	index := make(map[string]int, len(header))
+	for i, col := range header {
+		index[strings.TrimSpace(col)] = i
+	}
+	idCol, ok := index[idColumn]
+	if !ok {
+		return nil, fmt.Errorf("phenotype file %s has no %q column", path, idColumn)
+	}
+	for _, col := range columns {
+		if _, ok := index[col]; !ok {
+			return nil, fmt.Errorf("phenotype file %s has no %q column", path, col)
+		}
+		if col == idColumn || slices.Contains(researchColumns, col) {
+			return nil, fmt.Errorf("phenotype column %q clashes with a research table column", col)
+		}
+	}
+
+	p := &Phenotypes{Columns: columns, Values: make(map[string]map[string]string)}
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read phenotype file %s: %w", path, err)
+		}
+		if idCol >= len(record) || strings.TrimSpace(record[idCol]) == "" {
+			return nil, fmt.Errorf("phenotype file %s line %d: missing %s", path, line, idColumn)
+		}
+		id := strings.TrimSpace(record[idCol])
+		if _, dup := p.Values[id]; dup {
+			return nil, fmt.Errorf("phenotype file %s line %d: duplicate sample %q", path, line, id)
+		}
+		values := make(map[string]string, len(columns))
+		for _, col := range columns {
+			if i := index[col]; i < len(record) {
+				values[col] = strings.TrimSpace(record[i])
+			}
+		}
+		p.Values[id] = values
+	}
+	return p, nil
+}
+
+// ResearchExport counts how samples were matched with phenotypes.
+type ResearchExport struct {
+	Rows              int // sample x trait rows written
+	Samples           int
+	WithoutPhenotypes int // samples with no phenotype row; their phenotype cells are empty
+	UnusedPhenotypes  int // phenotype rows of no exported sample
+}
+
+// WriteResearchTable writes one CSV row per sample and trait: the trait's score columns
+// followed by the sample's phenotype columns, for downstream association analysis.
+// Traits a result did not score are written with their status and empty scores. pheno may
+// be nil to export scores only. Sample IDs must be unique.
+func WriteResearchTable(w io.Writer, samples []ResearchSample, pheno *Phenotypes) (ResearchExport, error) {
+	var stats ResearchExport
+	header := append([]string(nil), researchColumns...)
+	if pheno != nil {
+		header = append(header, pheno.Columns...)
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return stats, err
+	}
+
+	seen := make(map[string]bool, len(samples))
+	for _, s := range samples {
+		if seen[s.ID] {
+			return stats, fmt.Errorf("duplicate sample %q", s.ID)
+		}
+		seen[s.ID] = true
+		stats.Samples++
+
+		var values map[string]string
+		if pheno != nil {
+			var ok bool
+			if values, ok = pheno.Values[s.ID]; !ok {
+				stats.WithoutPhenotypes++
+			}
+		}
+		ancestry, version := "", ""
+		if prov := s.Result.Provenance; prov != nil {
+			ancestry = prov.Ancestry
+			if prov.Model != nil {
+				version = prov.Model.Version
+			}
+		}
+		for _, t := range s.Result.TraitSummaries {
+			var z, percentile, risk, contribution string
+			if t.Status == "" {
+				z, percentile, risk, contribution = formatFloat(t.ZScore), formatFloat(t.Percentile), t.RiskLevel, formatFloat(t.EffectWeightedContribution)
+			}
+			traitAncestry := ancestry
+			if t.ReferenceAncestry != "" {
+				traitAncestry = t.ReferenceAncestry
+			}
+			row := []string{s.ID, t.Trait, t.Status, z, percentile, risk, contribution,
+				strconv.Itoa(t.SNPsPresent), strconv.Itoa(t.SNPsExpected), formatFloat(t.Coverage), traitAncestry, version}
+			if pheno != nil {
+				for _, col := range pheno.Columns {
+					row = append(row, values[col])
+				}
+			}
+			if err := cw.Write(row); err != nil {
+				return stats, err
+			}
+			stats.Rows++
+		}
+	}
+	if pheno != nil {
+		for id := range pheno.Values {
+			if !seen[id] {
+				stats.UnusedPhenotypes++
+			}
+		}
+	}
+	cw.Flush()
+	return stats, cw.Error()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
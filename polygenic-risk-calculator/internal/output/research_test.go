@@ -0,0 +1,54 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+func TestWriteResearchTable(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "phenotypes.csv")
+	require.NoError(t, os.WriteFile(path, []byte("sample_id,age,ldl_measured,name\ns1,54,3.9,Alice\ns3,61,,Carol\n"), 0644))
+
+	pheno, err := ReadPhenotypes(path, "sample_id", []string{"age", "ldl_measured"})
+	require.NoError(t, err)
+
+	samples := []ResearchSample{
+		{ID: "s1", Result: OutputResult{
+			TraitSummaries: []TraitSummary{
+				{Trait: "LDL", RiskLevel: "high", ZScore: 2, Percentile: 97.7, EffectWeightedContribution: 0.4, SNPsPresent: 9, SNPsExpected: 10, Coverage: 0.9},
+				{Trait: "BMI", Status: StatusInsufficientCoverage, SNPsPresent: 1, SNPsExpected: 10, Coverage: 0.1},
+			},
+			Provenance: &Provenance{Ancestry: "EUR", Model: &model.ModelVersion{Version: "v2"}},
+		}},
+		{ID: "s2", Result: OutputResult{
+			TraitSummaries: []TraitSummary{{Trait: "LDL", RiskLevel: "low", ZScore: -1, Percentile: 15.9, ReferenceAncestry: "AFR"}},
+		}},
+	}
+	var buf bytes.Buffer
+	stats, err := WriteResearchTable(&buf, samples, pheno)
+	require.NoError(t, err)
+	assert.Equal(t, ResearchExport{Rows: 3, Samples: 2, WithoutPhenotypes: 1, UnusedPhenotypes: 1}, stats)
+	assert.Equal(t, "sample_id,trait,status,z_score,percentile,risk_level,effect_weighted_contribution,snps_present,snps_expected,coverage,reference_ancestry,model_version,age,ldl_measured\n"+
+		"s1,LDL,,2,97.7,high,0.4,9,10,0.9,EUR,v2,54,3.9\n"+
+		"s1,BMI,insufficient_coverage,,,,,1,10,0.1,EUR,v2,54,3.9\n"+
+		"s2,LDL,,-1,15.9,low,0,0,0,0,AFR,,,\n", buf.String())
+
+	_, err = WriteResearchTable(&buf, append(samples, samples[0]), nil)
+	assert.ErrorContains(t, err, "duplicate sample")
+
+	_, err = ReadPhenotypes(path, "sample_id", []string{"height"})
+	assert.ErrorContains(t, err, `no "height" column`)
+	_, err = ReadPhenotypes(path, "sample_id", []string{"sample_id"})
+	assert.ErrorContains(t, err, "clashes")
+}
+
+func TestSampleID(t *testing.T) {
+	assert.Equal(t, "NA12878", SampleID("results/NA12878.json"))
+}
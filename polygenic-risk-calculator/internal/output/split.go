@@ -0,0 +1,143 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"phite.io/polygenic-risk-calculator/internal/compound"
+	"phite.io/polygenic-risk-calculator/internal/contig"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// IndexFile is the name of the index WriteSplit writes next to the per-trait files.
+const IndexFile = "index.json"
+
+// TraitDocument is the content of one per-trait file: the trait's summary and the run's
+// provenance.
+type TraitDocument struct {
+	TraitSummary
+	Plot       string      `json:"plot,omitempty"` // distribution plot file, relative to the document
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// IndexEntry lists one per-trait file with the fields a frontend needs to render a list.
+type IndexEntry struct {
+	Trait      string  `json:"trait"`
+	File       string  `json:"file"` // relative to the index
+	Topic      string  `json:"topic,omitempty"`
+	RiskLevel  string  `json:"risk_level"`
+	Status     string  `json:"status,omitempty"`
+	Percentile float64 `json:"percentile,omitempty"`
+	ZScore     float64 `json:"z_score,omitempty"`
+	Actionable bool    `json:"actionable,omitempty"`
+	Plot       string  `json:"plot,omitempty"` // distribution plot file, relative to the index
+}
+
+// Index is the content of IndexFile: every trait file, and the run-level sections of the
+// results document.
+type Index struct {
+	Traits         []IndexEntry        `json:"traits"`
+	SNPSMissing    []string            `json:"snps_missing"`
+	ExcludedSNPs   []model.ExcludedSNP `json:"excluded_snps,omitempty"`
+	Contigs        *contig.Report      `json:"contigs,omitempty"`
+	DerivedMetrics []DerivedMetric     `json:"derived_metrics,omitempty"`
+	Compound       []compound.Result   `json:"compound_genotypes,omitempty"`
+	PGx            []compound.Result   `json:"pgx,omitempty"`
+	Provenance     *Provenance         `json:"provenance,omitempty"`
+}
+
+// WriteSplit writes output to dir as one JSON file per trait summary, named by the slugged
+// trait, plus IndexFile, and returns the paths written. With output.plots set, each scored
+// trait's distribution plot is written next to its file as SVG. The index is written last, so its
+// presence marks a complete set.
+func WriteSplit(output OutputResult, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	index := Index{
+		Traits:         make([]IndexEntry, 0, len(output.TraitSummaries)),
+		SNPSMissing:    output.SNPSMissing,
+		ExcludedSNPs:   output.ExcludedSNPs,
+		Contigs:        output.Contigs,
+		DerivedMetrics: output.DerivedMetrics,
+		Compound:       output.Compound,
+		PGx:            output.PGx,
+		Provenance:     output.Provenance,
+	}
+	used := make(map[string]bool)
+	written := make([]string, 0, len(output.TraitSummaries)+1)
+	for _, s := range output.TraitSummaries {
+		name := TraitFileName(s.Trait, used)
+		var plotName string
+		if svg := plotFor(s, output.Provenance); svg != nil {
+			plotName = strings.TrimSuffix(name, ".json") + ".svg"
+			plotPath := filepath.Join(dir, plotName)
+			if err := os.WriteFile(plotPath, svg, 0o644); err != nil {
+				return nil, fmt.Errorf("failed to write plot: %w", err)
+			}
+			written = append(written, plotPath)
+		}
+		path := filepath.Join(dir, name)
+		if err := writeJSONFile(path, TraitDocument{TraitSummary: s, Plot: plotName, Provenance: output.Provenance}); err != nil {
+			return nil, err
+		}
+		written = append(written, path)
+		index.Traits = append(index.Traits, IndexEntry{
+			Trait:      s.Trait,
+			File:       name,
+			Topic:      s.Topic,
+			RiskLevel:  s.RiskLevel,
+			Status:     s.Status,
+			Percentile: s.Percentile,
+			ZScore:     s.ZScore,
+			Actionable: s.Actionable,
+			Plot:       plotName,
+		})
+	}
+	indexPath := filepath.Join(dir, IndexFile)
+	if err := writeJSONFile(indexPath, index); err != nil {
+		return nil, err
+	}
+	logging.Info("Wrote %d trait files and %s to %s", len(index.Traits), IndexFile, dir)
+	return append(written, indexPath), nil
+}
+
+// TraitFileName returns the file name of trait's document: the trait lower-cased with runs
+// of other characters than letters and digits joined by "-", e.g. "EFO_0004339" ->
+// "efo-0004339.json". used holds the names handed out so far; a repeated slug gets a
+// numeric suffix.
+func TraitFileName(trait string, used map[string]bool) string {
+	words := strings.FieldsFunc(strings.ToLower(trait), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	slug := strings.Join(words, "-")
+	if slug == "" || slug == strings.TrimSuffix(IndexFile, ".json") {
+		slug = "trait-" + slug
+	}
+	slug = strings.TrimSuffix(slug, "-")
+	name := slug
+	for n := 2; used[name]; n++ {
+		name = fmt.Sprintf("%s-%d", slug, n)
+	}
+	used[name] = true
+	return name + ".json"
+}
+
+func writeJSONFile(path string, v any) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	e := json.NewEncoder(f)
+	e.SetIndent("", "  ")
+	if err := e.Encode(v); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to encode %s: %w", filepath.Base(path), err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,99 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+func TestTraitFileName(t *testing.T) {
+	used := map[string]bool{}
+	assert.Equal(t, "efo-0004339.json", TraitFileName("EFO_0004339", used))
+	assert.Equal(t, "type-2-diabetes.json", TraitFileName("Type 2 Diabetes", used))
+	assert.Equal(t, "type-2-diabetes-2.json", TraitFileName("type-2 diabetes", used))
+	assert.Equal(t, "trait-index.json", TraitFileName("Index", used))
+	assert.Equal(t, "trait.json", TraitFileName("??", used))
+}
+
+func TestWriteSplit(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	dir := filepath.Join(t.TempDir(), "results")
+	out := OutputResult{
+		TraitSummaries: []TraitSummary{
+			{Trait: "Height", RiskLevel: "moderate", Percentile: 62, ZScore: 0.3},
+			{Trait: "LDL Cholesterol", RiskLevel: "unknown", Status: StatusInsufficientCoverage},
+		},
+		SNPSMissing: []string{"rs1"},
+		Provenance:  &Provenance{},
+	}
+	written, err := WriteSplit(out, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "height.json"), filepath.Join(dir, "ldl-cholesterol.json"), filepath.Join(dir, IndexFile),
+	}, written)
+
+	var index Index
+	b, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &index))
+	require.Len(t, index.Traits, 2)
+	assert.Equal(t, "height.json", index.Traits[0].File)
+	assert.Equal(t, "ldl-cholesterol.json", index.Traits[1].File)
+	assert.Equal(t, StatusInsufficientCoverage, index.Traits[1].Status)
+	assert.Equal(t, []string{"rs1"}, index.SNPSMissing)
+
+	var doc TraitDocument
+	b, err = os.ReadFile(filepath.Join(dir, "height.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &doc))
+	assert.Equal(t, "Height", doc.Trait)
+	assert.Equal(t, 62.0, doc.Percentile)
+	assert.NotNil(t, doc.Provenance)
+}
+
+func TestWriteSplit_Plots(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	old := config.GetBool(PlotsKey)
+	defer config.Set(PlotsKey, old)
+	config.Set(PlotsKey, true)
+
+	dir := t.TempDir()
+	out := OutputResult{
+		TraitSummaries: []TraitSummary{
+			{Trait: "Height", RiskLevel: "moderate", Percentile: 62, ZScore: 0.3},
+			{Trait: "LDL", RiskLevel: "unknown", Status: StatusInsufficientCoverage},
+			{Trait: "BMI", RiskLevel: "low", Percentile: 10, ZScore: -1.28, ReferenceAncestry: "AFR"},
+		},
+		Provenance: &Provenance{Ancestry: "EUR"},
+	}
+	written, err := WriteSplit(out, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "height.svg"), filepath.Join(dir, "height.json"), filepath.Join(dir, "ldl.json"),
+		filepath.Join(dir, "bmi.svg"), filepath.Join(dir, "bmi.json"), filepath.Join(dir, IndexFile),
+	}, written)
+
+	svg, err := os.ReadFile(filepath.Join(dir, "bmi.svg"))
+	require.NoError(t, err)
+	assert.Contains(t, string(svg), "BMI (AFR reference)")
+
+	var index Index
+	b, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &index))
+	assert.Equal(t, "height.svg", index.Traits[0].Plot)
+	assert.Empty(t, index.Traits[1].Plot, "unscored traits have no plot")
+
+	var doc TraitDocument
+	b, err = os.ReadFile(filepath.Join(dir, "height.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &doc))
+	assert.Equal(t, "height.svg", doc.Plot)
+	assert.Empty(t, doc.PlotSVG, "split output links plots rather than inlining them")
+}
@@ -22,11 +22,17 @@ func GenerateTraitSummaries(snps []model.AnnotatedSNP, norm prs.NormalizedPRS) [
 		}
 		ts, ok := traitMap[trait]
 		if !ok {
-			ts = &TraitSummary{Trait: trait}
+			ts = &TraitSummary{Trait: trait, Percentile: norm.Percentile, ZScore: norm.ZScore}
 			traitMap[trait] = ts
 		}
 		ts.NumRiskAlleles += snp.Dosage
-		ts.EffectWeightedContribution += float64(snp.Dosage) * snp.Beta
+		ts.EffectWeightedContribution += snp.ScoringDosage() * snp.Beta
+		if snp.WeightSource != "" {
+			if ts.WeightSources == nil {
+				ts.WeightSources = make(map[string]int)
+			}
+			ts.WeightSources[snp.WeightSource]++
+		}
 	}
 	// Assign risk level based on normalized PRS percentile
 	riskLevel := "moderate"
@@ -41,6 +47,7 @@ func GenerateTraitSummaries(snps []model.AnnotatedSNP, norm prs.NormalizedPRS) [
 		ts.RiskLevel = riskLevel
 		summaries = append(summaries, *ts)
 	}
+	SortTraitSummaries(summaries, SortByTrait)
 	logging.Info("Generated %d trait summaries", len(summaries))
 	return summaries
 }
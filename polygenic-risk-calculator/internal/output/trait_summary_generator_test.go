@@ -1,6 +1,7 @@
 package output
 
 import (
+	"reflect"
 	"testing"
 	"phite.io/polygenic-risk-calculator/internal/model"
 	"phite.io/polygenic-risk-calculator/internal/prs"
@@ -22,7 +23,7 @@ func TestGenerateTraitSummaries(t *testing.T) {
 				{RSID: "rs2", Dosage: 1, Beta: 0.2, Trait: "BMI"},
 			},
 			norm: prs.NormalizedPRS{RawScore: 0.4, ZScore: 2.0, Percentile: 95.0},
-			want: []TraitSummary{{Trait: "BMI", NumRiskAlleles: 3, EffectWeightedContribution: 0.4, RiskLevel: "high"}},
+			want: []TraitSummary{{Trait: "BMI", NumRiskAlleles: 3, EffectWeightedContribution: 0.4, RiskLevel: "high", Percentile: 95.0, ZScore: 2.0}},
 		},
 		{
 			name: "multiple traits, moderate and low",
@@ -31,7 +32,7 @@ func TestGenerateTraitSummaries(t *testing.T) {
 				{RSID: "rs2", Dosage: 2, Beta: 0.2, Trait: "Height"},
 			},
 			norm: prs.NormalizedPRS{RawScore: 0.5, ZScore: 0.0, Percentile: 50.0},
-			want: []TraitSummary{{Trait: "BMI", NumRiskAlleles: 1, EffectWeightedContribution: 0.1, RiskLevel: "moderate"}, {Trait: "Height", NumRiskAlleles: 2, EffectWeightedContribution: 0.4, RiskLevel: "moderate"}},
+			want: []TraitSummary{{Trait: "BMI", NumRiskAlleles: 1, EffectWeightedContribution: 0.1, RiskLevel: "moderate", Percentile: 50.0}, {Trait: "Height", NumRiskAlleles: 2, EffectWeightedContribution: 0.4, RiskLevel: "moderate", Percentile: 50.0}},
 		},
 		{
 			name: "missing trait info",
@@ -39,7 +40,17 @@ func TestGenerateTraitSummaries(t *testing.T) {
 				{RSID: "rs1", Dosage: 1, Beta: 0.1, Trait: ""},
 			},
 			norm: prs.NormalizedPRS{RawScore: 0.1, ZScore: -1.0, Percentile: 10.0},
-			want: []TraitSummary{{Trait: "unknown", NumRiskAlleles: 1, EffectWeightedContribution: 0.1, RiskLevel: "low"}},
+			want: []TraitSummary{{Trait: "unknown", NumRiskAlleles: 1, EffectWeightedContribution: 0.1, RiskLevel: "low", Percentile: 10.0, ZScore: -1.0}},
+		},
+		{
+			name: "weight provenance",
+			annotated: []model.AnnotatedSNP{
+				{RSID: "rs1", Dosage: 1, Beta: 0.3, Trait: "BMI", WeightSource: "beta_eur"},
+				{RSID: "rs2", Dosage: 1, Beta: 0.1, Trait: "BMI", WeightSource: "beta"},
+				{RSID: "rs3", Dosage: 0, Beta: 0.2, Trait: "BMI", WeightSource: "beta_eur"},
+			},
+			norm: prs.NormalizedPRS{RawScore: 0.4, ZScore: 0.0, Percentile: 50.0},
+			want: []TraitSummary{{Trait: "BMI", NumRiskAlleles: 2, EffectWeightedContribution: 0.4, RiskLevel: "moderate", Percentile: 50.0, WeightSources: map[string]int{"beta_eur": 2, "beta": 1}}},
 		},
 		{
 			name:      "empty input",
@@ -57,7 +68,7 @@ func TestGenerateTraitSummaries(t *testing.T) {
 				return
 			}
 			for i := range got {
-				if got[i] != tt.want[i] {
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
 					t.Errorf("got %+v, want %+v", got[i], tt.want[i])
 				}
 			}
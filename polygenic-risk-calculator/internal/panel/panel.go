@@ -0,0 +1,257 @@
+// Package panel provides curated trait panels, such as "cardiometabolic", so a run can
+// score a meaningful set of traits without the user assembling SNP and trait lists. A panel
+// lists traits, named as in the GWAS table (GWAS Catalog mapped trait labels), with the
+// variants that score them; a run with a panel requests those variants and keeps only the
+// panel's traits.
+//
+// Panels are YAML files. Traits are listed in display order, each optionally filed under a
+// report section, scored by a named model in the model table, and flagged as actionable
+// above thresholds:
+//
+//	name: cardiometabolic
+//	description: Blood sugar, blood lipids, blood pressure, body weight, and heart disease
+//	traits:
+//	  - trait: coronary artery disease
+//	    section: Heart
+//	    model: PGS000018
+//	    snps: [rs1333049, rs4977574]
+//	    thresholds: {min_percentile: 95, note: discuss with your doctor}
+package panel
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/output"
+)
+
+// Domain-specific configuration keys for trait panels
+const (
+	NameKey = "panel.name" // Bundled panel name, or path of a panel YAML file, whose traits a run scores; overridden by --panel
+)
+
+//go:embed panels/*.yaml
+var bundled embed.FS
+
+// Panel is a named set of traits and the variants that score them.
+type Panel struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description"`
+	Traits      []Trait  `json:"traits" yaml:"traits"` // in display order
+	SNPs        []string `json:"snps" yaml:"-"`        // of every trait, in file order, without duplicates
+
+	index map[string]int // lower-cased trait name -> index in Traits
+}
+
+// Trait is a panel entry.
+type Trait struct {
+	Trait      string                 `json:"trait" yaml:"trait"`
+	Section    string                 `json:"section,omitempty" yaml:"section"`       // report section the trait is shown under
+	Model      string                 `json:"model,omitempty" yaml:"model"`           // model ID in the model table; defaults to the trait
+	SNPs       []string               `json:"snps" yaml:"snps"`                       // variants requested for the trait
+	Thresholds *output.ActionableRule `json:"thresholds,omitempty" yaml:"thresholds"` // flags the result as clinically actionable
+}
+
+// IsPath reports whether a panel name is the path of a panel file rather than the name of
+// a bundled panel: it contains a path separator or has a YAML extension.
+func IsPath(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return strings.ContainsRune(name, os.PathSeparator) || ext == ".yaml" || ext == ".yml"
+}
+
+// Names returns the names of the bundled panels in sorted order.
+func Names() []string {
+	entries, _ := bundled.ReadDir("panels")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Bundled returns the bundled panels in name order.
+func Bundled() ([]*Panel, error) {
+	var panels []*Panel
+	for _, name := range Names() {
+		p, err := Get(name)
+		if err != nil {
+			return nil, err
+		}
+		panels = append(panels, p)
+	}
+	return panels, nil
+}
+
+// Get returns the bundled panel called name, or reads a panel file when name is a path.
+func Get(name string) (*Panel, error) {
+	if IsPath(name) {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open panel: %w", err)
+		}
+		defer f.Close()
+		base := filepath.Base(name)
+		return Parse(f, strings.TrimSuffix(base, filepath.Ext(base)))
+	}
+	f, err := bundled.Open("panels/" + strings.ToLower(name) + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("unknown panel %q: use %s, or the path of a panel YAML file", name, strings.Join(Names(), ", "))
+	}
+	defer f.Close()
+	return Parse(f, strings.ToLower(name))
+}
+
+// FromConfig returns the panel named by NameKey, or nil when none is configured.
+func FromConfig() (*Panel, error) {
+	name := config.GetString(NameKey)
+	if name == "" {
+		return nil, nil
+	}
+	return Get(name)
+}
+
+// Parse reads and checks a panel definition. name labels errors and is the panel's name
+// unless the file sets one. Unknown fields are rejected so that misspelled settings are not
+// silently ignored.
+func Parse(r io.Reader, name string) (*Panel, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read panel %s: %w", name, err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var p Panel
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("panel %s: %w", name, err)
+	}
+	if p.Name == "" {
+		p.Name = name
+	}
+
+	p.index = make(map[string]int, len(p.Traits))
+	seen := make(map[string]bool)
+	for i, t := range p.Traits {
+		t.Trait = strings.TrimSpace(t.Trait)
+		if t.Trait == "" {
+			return nil, fmt.Errorf("panel %s: trait %d has no name", p.Name, i+1)
+		}
+		key := strings.ToLower(t.Trait)
+		if _, dup := p.index[key]; dup {
+			return nil, fmt.Errorf("panel %s: duplicate trait %s", p.Name, t.Trait)
+		}
+		if len(t.SNPs) == 0 {
+			return nil, fmt.Errorf("panel %s: trait %s lists no variants", p.Name, t.Trait)
+		}
+		if strings.ContainsAny(t.Model, "|@") {
+			return nil, fmt.Errorf("panel %s: trait %s: model ID %q must not contain '|' or '@'", p.Name, t.Trait, t.Model)
+		}
+		if t.Thresholds != nil {
+			if err := t.Thresholds.Validate(); err != nil {
+				return nil, fmt.Errorf("panel %s: trait %s: thresholds: %w", p.Name, t.Trait, err)
+			}
+		}
+		for _, rsid := range t.SNPs {
+			if !seen[rsid] {
+				seen[rsid] = true
+				p.SNPs = append(p.SNPs, rsid)
+			}
+		}
+		p.Traits[i] = t
+		p.index[key] = i
+	}
+	if len(p.Traits) == 0 {
+		return nil, fmt.Errorf("panel %s lists no traits", p.Name)
+	}
+	return &p, nil
+}
+
+// HasTrait reports whether trait is in the panel. Matching is case-insensitive.
+func (p *Panel) HasTrait(trait string) bool {
+	_, ok := p.index[strings.ToLower(trait)]
+	return ok
+}
+
+// Names returns the panel's trait names in display order.
+func (p *Panel) Names() []string {
+	names := make([]string, len(p.Traits))
+	for i, t := range p.Traits {
+		names[i] = t.Trait
+	}
+	return names
+}
+
+// Sections returns the section of each trait filed under one, keyed by trait name.
+func (p *Panel) Sections() map[string]string {
+	sections := make(map[string]string)
+	for _, t := range p.Traits {
+		if t.Section != "" {
+			sections[t.Trait] = t.Section
+		}
+	}
+	return sections
+}
+
+// Models returns the model ID of each trait that names one, keyed by lower-cased trait as
+// in a trait model map.
+func (p *Panel) Models() map[string]string {
+	models := make(map[string]string)
+	for _, t := range p.Traits {
+		if t.Model != "" {
+			models[strings.ToLower(t.Trait)] = t.Model
+		}
+	}
+	return models
+}
+
+// Thresholds returns the actionable thresholds of each trait that sets them, keyed by
+// lower-cased trait as in output.Actionability.
+func (p *Panel) Thresholds() map[string]output.ActionableRule {
+	rules := make(map[string]output.ActionableRule)
+	for _, t := range p.Traits {
+		if t.Thresholds != nil {
+			rules[strings.ToLower(t.Trait)] = *t.Thresholds
+		}
+	}
+	return rules
+}
+
+// Validate checks the panel against the data a run scores it with: found reports whether
+// the GWAS data (or model table) has associations for a trait, and hasModel, when not nil,
+// whether the model table holds a model ID. Every model the panel names must exist. Traits
+// without associations are returned for the caller to report; the panel is invalid only
+// when none of its traits has any.
+func (p *Panel) Validate(found func(trait string) bool, hasModel func(id string) (bool, error)) (missing []string, err error) {
+	if hasModel != nil {
+		for _, t := range p.Traits {
+			if t.Model == "" {
+				continue
+			}
+			ok, err := hasModel(t.Model)
+			if err != nil {
+				return nil, fmt.Errorf("panel %s: failed to look up model %s: %w", p.Name, t.Model, err)
+			}
+			if !ok {
+				return nil, fmt.Errorf("panel %s: trait %s: model %s is not in the model table", p.Name, t.Trait, t.Model)
+			}
+		}
+	}
+	for _, t := range p.Traits {
+		if !found(t.Trait) {
+			missing = append(missing, t.Trait)
+		}
+	}
+	if len(missing) == len(p.Traits) {
+		return missing, fmt.Errorf("panel %s: none of its traits has associations for its variants", p.Name)
+	}
+	return missing, nil
+}
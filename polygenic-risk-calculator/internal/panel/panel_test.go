@@ -0,0 +1,108 @@
+package panel
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundled(t *testing.T) {
+	assert.Equal(t, []string{"cardiometabolic", "fitness", "nutrition"}, Names())
+
+	panels, err := Bundled()
+	require.NoError(t, err)
+	for i, p := range panels {
+		assert.Equal(t, Names()[i], p.Name)
+		assert.NotEmpty(t, p.Description, p.Name)
+		for _, tr := range p.Traits {
+			assert.NotEmpty(t, tr.Section, "%s: %s", p.Name, tr.Trait)
+		}
+		for _, rsid := range p.SNPs {
+			assert.True(t, strings.HasPrefix(rsid, "rs"), "%s: %s", p.Name, rsid)
+		}
+	}
+
+	p, err := Get("Cardiometabolic")
+	require.NoError(t, err)
+	assert.Equal(t, "cardiometabolic", p.Name)
+	assert.True(t, p.HasTrait("Type 2 Diabetes Mellitus"))
+	assert.False(t, p.HasTrait("vitamin D measurement"))
+
+	_, err = Get("sleep")
+	assert.ErrorContains(t, err, `unknown panel "sleep": use cardiometabolic, fitness, nutrition`)
+}
+
+const custom = `description: My traits
+traits:
+  - trait: height
+    section: Body
+    snps: [rs1, rs2]
+  - trait: LDL
+    section: Heart
+    model: PGS000001
+    snps: [rs2, rs3]
+    thresholds: {min_percentile: 95, note: check lipids}
+`
+
+func TestGet_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mine.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(custom), 0o644))
+	assert.True(t, IsPath(path))
+	assert.True(t, IsPath("mine.yml"))
+	assert.False(t, IsPath("fitness"))
+
+	p, err := Get(path)
+	require.NoError(t, err)
+	assert.Equal(t, "mine", p.Name)
+	assert.Equal(t, "My traits", p.Description)
+	assert.Equal(t, []string{"height", "LDL"}, p.Names())
+	assert.Equal(t, []string{"rs1", "rs2", "rs3"}, p.SNPs)
+	assert.Equal(t, map[string]string{"height": "Body", "LDL": "Heart"}, p.Sections())
+	assert.Equal(t, map[string]string{"ldl": "PGS000001"}, p.Models())
+	rule := p.Thresholds()["ldl"]
+	require.NotNil(t, rule.MinPercentile)
+	assert.Equal(t, 95.0, *rule.MinPercentile)
+	assert.Equal(t, "check lipids", rule.Note)
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name, yaml, want string
+	}{
+		{"unknown field", "traits:\n  - trait: height\n    snp: [rs1]\n", "field snp not found"},
+		{"no variants", "traits:\n  - trait: height\n", "trait height lists no variants"},
+		{"duplicate", "traits:\n  - {trait: height, snps: [rs1]}\n  - {trait: Height, snps: [rs2]}\n", "duplicate trait Height"},
+		{"bad model", "traits:\n  - {trait: height, model: a@b, snps: [rs1]}\n", `model ID "a@b"`},
+		{"bad thresholds", "traits:\n  - {trait: height, snps: [rs1], thresholds: {note: x}}\n", "min_percentile or min_z is required"},
+		{"empty", "description: nothing\n", "panel bad lists no traits"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.yaml), "bad")
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestPanel_Validate(t *testing.T) {
+	p, err := Parse(strings.NewReader(custom), "mine")
+	require.NoError(t, err)
+	found := func(trait string) bool { return trait == "LDL" }
+	models := map[string]bool{"PGS000001": true}
+	hasModel := func(id string) (bool, error) { return models[id], nil }
+
+	missing, err := p.Validate(found, hasModel)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"height"}, missing)
+
+	delete(models, "PGS000001")
+	_, err = p.Validate(found, hasModel)
+	assert.ErrorContains(t, err, "model PGS000001 is not in the model table")
+
+	_, err = p.Validate(func(string) bool { return false }, nil)
+	assert.ErrorContains(t, err, "none of its traits has associations")
+}
@@ -0,0 +1,178 @@
+// Package pgx annotates pharmacogenes with CPIC-style phenotypes, such as "poor
+// metabolizer", for a report section separate from the polygenic scores.
+//
+// Diplotypes are called by the compound package; a mapping table of gene, diplotype, and
+// phenotype then assigns the phenotype. A CPIC-derived table is bundled; a file with the
+// same tab-separated columns can replace it:
+//
+//	CYP2C19	*1/*17	rapid metabolizer
+package pgx
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/compound"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// Domain-specific configuration keys for pharmacogenomic annotation
+const (
+	EnabledKey     = "pgx.enabled"      // Report CPIC-style phenotypes for the pharmacogenes in the mapping table
+	MappingFileKey = "pgx.mapping_file" // TSV of gene, diplotype, phenotype replacing the bundled table
+)
+
+// StatusUnmapped marks a called diplotype that has no phenotype in the mapping table.
+const StatusUnmapped = "unmapped"
+
+//go:embed cpic.tsv
+var bundled string
+
+// Table maps the diplotypes of pharmacogenes to phenotypes. A nil Table annotates nothing.
+type Table struct {
+	genes      []compound.Gene   // in table order
+	phenotypes map[string]string // gene + "|" + diplotypeKey -> phenotype
+}
+
+// FromConfig returns the mapping table when annotation is enabled, or nil otherwise.
+func FromConfig() (*Table, error) {
+	if !config.GetBool(EnabledKey) {
+		return nil, nil
+	}
+	if path := config.GetString(MappingFileKey); path != "" {
+		return Load(path)
+	}
+	return Bundled()
+}
+
+// Bundled returns the CPIC-derived table shipped with the calculator.
+func Bundled() (*Table, error) {
+	return Parse(strings.NewReader(bundled), "bundled CPIC table")
+}
+
+// Load reads a mapping table from a file.
+func Load(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PGx mapping table: %w", err)
+	}
+	defer f.Close()
+	return Parse(f, path)
+}
+
+// Parse reads a mapping table; name labels errors. Blank lines, lines starting with #, and a
+// header row starting with "gene" are ignored. Every gene must be supported by the compound
+// package and every diplotype must name two of its alleles.
+func Parse(r io.Reader, name string) (*Table, error) {
+	t := &Table{phenotypes: make(map[string]string)}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(strings.ToLower(text), "gene\t") {
+			continue
+		}
+		cols := strings.Split(text, "\t")
+		if len(cols) != 3 {
+			return nil, fmt.Errorf("%s:%d: expected 3 tab-separated columns, got %d", name, line, len(cols))
+		}
+		gene, ok := compound.Lookup(cols[0])
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: unsupported gene %q", name, line, cols[0])
+		}
+		a, b, ok := strings.Cut(strings.TrimSpace(cols[1]), "/")
+		a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+		if !ok || !hasAllele(gene, a) || !hasAllele(gene, b) {
+			return nil, fmt.Errorf("%s:%d: invalid %s diplotype %q", name, line, gene.Name, cols[1])
+		}
+		phenotype := strings.TrimSpace(cols[2])
+		if phenotype == "" {
+			return nil, fmt.Errorf("%s:%d: phenotype is required", name, line)
+		}
+		key := gene.Name + "|" + diplotypeKey(a, b)
+		if _, dup := t.phenotypes[key]; dup {
+			return nil, fmt.Errorf("%s:%d: duplicate %s diplotype %s", name, line, gene.Name, cols[1])
+		}
+		if !t.hasGene(gene.Name) {
+			t.genes = append(t.genes, gene)
+		}
+		t.phenotypes[key] = phenotype
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read PGx mapping table: %w", err)
+	}
+	return t, nil
+}
+
+// Genes returns the genes the table maps, in table order.
+func (t *Table) Genes() []compound.Gene {
+	if t == nil {
+		return nil
+	}
+	return t.genes
+}
+
+// Without returns the table without the excluded genes.
+func (t *Table) Without(excluded func(gene string) bool) *Table {
+	if t == nil {
+		return nil
+	}
+	kept := &Table{phenotypes: t.phenotypes}
+	for _, g := range t.genes {
+		if !excluded(g.Name) {
+			kept.genes = append(kept.genes, g)
+		}
+	}
+	return kept
+}
+
+// Annotate calls each gene's diplotype from the validated calls and assigns its phenotype
+// from the table. Genes without a single diplotype keep the compound status.
+func (t *Table) Annotate(validated []model.ValidatedSNP) []compound.Result {
+	results := compound.Report(t.Genes(), validated)
+	for i, r := range results {
+		if r.Diplotype == "" {
+			continue
+		}
+		a, b, _ := strings.Cut(r.Diplotype, "/")
+		if phenotype, ok := t.phenotypes[r.Gene+"|"+diplotypeKey(a, b)]; ok {
+			results[i].Phenotype = phenotype
+		} else {
+			results[i].Phenotype = ""
+			results[i].Status = StatusUnmapped
+		}
+	}
+	return results
+}
+
+func (t *Table) hasGene(name string) bool {
+	for _, g := range t.genes {
+		if g.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAllele(g compound.Gene, name string) bool {
+	for _, a := range g.Alleles {
+		if a.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// diplotypeKey orders a diplotype's allele names so that "*2/*1" and "*1/*2" match.
+func diplotypeKey(a, b string) string {
+	names := []string{a, b}
+	sort.Strings(names)
+	return names[0] + "/" + names[1]
+}
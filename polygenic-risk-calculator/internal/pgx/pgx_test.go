@@ -0,0 +1,98 @@
+package pgx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/compound"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+func TestBundled(t *testing.T) {
+	table, err := Bundled()
+	require.NoError(t, err)
+
+	var names []string
+	for _, g := range table.Genes() {
+		names = append(names, g.Name)
+	}
+	assert.Equal(t, []string{"CYP2C19", "CYP2C9", "SLCO1B1"}, names)
+}
+
+func TestAnnotate(t *testing.T) {
+	table, err := Bundled()
+	require.NoError(t, err)
+
+	results := table.Annotate([]model.ValidatedSNP{
+		// CYP2C19 *2/*17
+		{RSID: "rs4244285", Genotype: "GA"},
+		{RSID: "rs4986893", Genotype: "GG"},
+		{RSID: "rs12248560", Genotype: "CT"},
+		// CYP2C9 *2/*3
+		{RSID: "rs1799853", Genotype: "CT"},
+		{RSID: "rs1057910", Genotype: "AC"},
+		// SLCO1B1 missing
+	})
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "*2/*17", results[0].Diplotype)
+	assert.Equal(t, "intermediate metabolizer", results[0].Phenotype)
+	assert.Equal(t, "*2/*3", results[1].Diplotype)
+	assert.Equal(t, "poor metabolizer", results[1].Phenotype)
+	assert.Equal(t, "SLCO1B1", results[2].Gene)
+	assert.Equal(t, compound.StatusIncomplete, results[2].Status)
+	assert.Empty(t, results[2].Phenotype)
+}
+
+func TestAnnotate_Unmapped(t *testing.T) {
+	table, err := Parse(strings.NewReader("CYP2C19\t*1/*1\tnormal metabolizer\n"), "test")
+	require.NoError(t, err)
+
+	results := table.Annotate([]model.ValidatedSNP{
+		{RSID: "rs4244285", Genotype: "AA"},
+		{RSID: "rs4986893", Genotype: "GG"},
+		{RSID: "rs12248560", Genotype: "CC"},
+	})
+	require.Len(t, results, 1)
+	assert.Equal(t, "*2/*2", results[0].Diplotype)
+	assert.Equal(t, StatusUnmapped, results[0].Status)
+	assert.Empty(t, results[0].Phenotype, "the compound phenotype is not reported for unmapped diplotypes")
+}
+
+func TestParse(t *testing.T) {
+	table, err := Parse(strings.NewReader("gene\tdiplotype\tphenotype\nCYP2C9\t*2/*1\tintermediate metabolizer\n"), "test")
+	require.NoError(t, err)
+	results := table.Annotate([]model.ValidatedSNP{
+		{RSID: "rs1799853", Genotype: "TC"},
+		{RSID: "rs1057910", Genotype: "AA"},
+	})
+	require.Len(t, results, 1)
+	assert.Equal(t, "intermediate metabolizer", results[0].Phenotype, "diplotypes match regardless of allele order")
+
+	for _, bad := range []string{
+		"BRCA1\t*1/*1\tnormal",
+		"CYP2C9\t*1/*9\tnormal",
+		"CYP2C9\t*1\tnormal",
+		"CYP2C9\t*1/*1\t",
+		"CYP2C9\t*1/*1\tnormal\nCYP2C9\t*1/*1\tnormal",
+	} {
+		_, err := Parse(strings.NewReader(bad), "test")
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	table, err := FromConfig()
+	require.NoError(t, err)
+	assert.Nil(t, table)
+	assert.Nil(t, table.Annotate(nil))
+
+	config.Set(EnabledKey, true)
+	defer config.Set(EnabledKey, false)
+	table, err = FromConfig()
+	require.NoError(t, err)
+	assert.Len(t, table.Genes(), 3)
+}
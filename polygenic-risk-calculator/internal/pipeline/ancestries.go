@@ -0,0 +1,162 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/reference"
+	reference_cache "phite.io/polygenic-risk-calculator/internal/reference/cache"
+	reference_stats "phite.io/polygenic-risk-calculator/internal/reference/stats"
+)
+
+// Domain-specific configuration keys for the pipeline
+const (
+	AllAncestriesKey   = "pipeline.all_ancestries"   // Also compute and cache reference stats for every supported ancestry
+	ExtraAncestriesKey = "pipeline.extra_ancestries" // Ancestry codes (e.g. ["AFR", "EUR_FEMALE"]) whose reference stats are also computed and cached
+)
+
+// extraAncestries returns the ancestries whose reference stats are computed and cached
+// alongside the user's: every supported one under AllAncestriesKey, else those listed by
+// ExtraAncestriesKey. Custom cohorts have a single frequency column, so neither applies.
+func extraAncestries(user *ancestry.Ancestry) ([]*ancestry.Ancestry, error) {
+	codes := config.GetStringSlice(ExtraAncestriesKey)
+	all := config.GetBool(AllAncestriesKey)
+	if !all && len(codes) == 0 {
+		return nil, nil
+	}
+	if user.IsCustomCohort() {
+		logging.Warn("Ignoring %s and %s: custom cohort %s has no other ancestries' frequencies", AllAncestriesKey, ExtraAncestriesKey, user.Code())
+		return nil, nil
+	}
+	if all {
+		return ancestry.All(), nil
+	}
+	seen := make(map[string]bool, len(codes))
+	var extra []*ancestry.Ancestry
+	for _, code := range codes {
+		a, err := ancestry.FromCode(code)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ExtraAncestriesKey, err)
+		}
+		if !seen[a.Code()] {
+			seen[a.Code()] = true
+			extra = append(extra, a)
+		}
+	}
+	sort.Slice(extra, func(i, j int) bool { return extra[i].Code() < extra[j].Code() })
+	return extra, nil
+}
+
+// extraAncestryCacheKeys returns the cache requests for trait under each of extra other than
+// the ancestry it is normalized against, which is already requested.
+func extraAncestryCacheKeys(trait, modelID string, ref *ancestry.Ancestry, extra []*ancestry.Ancestry) []reference_cache.StatsRequest {
+	var keys []reference_cache.StatsRequest
+	for _, a := range extra {
+		if a.Code() == ref.Code() {
+			continue
+		}
+		keys = append(keys, reference_cache.StatsRequest{Ancestry: a.Code(), Trait: trait, ModelID: modelID})
+	}
+	return keys
+}
+
+// extraAncestryStats computes the reference stats missing from cached for every trait under
+// the extra ancestries and each trait's reference ancestry, sharing one frequency query
+// across ancestries. When streaming, traits are computed one at a time.
+func extraAncestryStats(ctx context.Context, requirements *PipelineRequirements, cached map[string]*reference_stats.ReferenceStats, refService *reference.ReferenceService) (map[string]*reference_stats.ReferenceStats, []error) {
+	all := requirements.withExtraAncestries()
+	missingTraits := make(map[string]struct{})
+	missingAncestries := make(map[string]*ancestry.Ancestry)
+	for trait := range requirements.TraitSet {
+		for _, a := range all {
+			key := fmt.Sprintf("%s|%s|%s", a.Code(), trait, requirements.modelID(trait))
+			if _, found := cached[key]; !found {
+				missingTraits[trait] = struct{}{}
+				missingAncestries[a.Code()] = a
+			}
+		}
+	}
+	if len(missingTraits) == 0 {
+		return map[string]*reference_stats.ReferenceStats{}, nil
+	}
+
+	traits := make([]string, 0, len(missingTraits))
+	for trait := range missingTraits {
+		traits = append(traits, trait)
+	}
+	sort.Strings(traits)
+	ancestries := make([]*ancestry.Ancestry, 0, len(missingAncestries))
+	for _, a := range all {
+		if _, ok := missingAncestries[a.Code()]; ok {
+			ancestries = append(ancestries, a)
+		}
+	}
+	logging.Info("Computing reference stats for %d traits across %d ancestries", len(traits), len(ancestries))
+
+	if !requirements.Streaming {
+		return refService.GetReferenceStatsForAncestries(ctx, traits, ancestries)
+	}
+	stats := make(map[string]*reference_stats.ReferenceStats)
+	var errs []error
+	for _, trait := range traits {
+		got, traitErrs := refService.GetReferenceStatsForAncestries(ctx, []string{trait}, ancestries)
+		for key, s := range got {
+			stats[key] = s
+		}
+		errs = append(errs, traitErrs...)
+		if ctx.Err() != nil {
+			return stats, append(errs, ctx.Err())
+		}
+		runtime.GC()
+	}
+	return stats, errs
+}
+
+// withExtraAncestries returns the extra ancestries plus the trait reference ancestries not
+// among them, sorted by code.
+func (r *PipelineRequirements) withExtraAncestries() []*ancestry.Ancestry {
+	byCode := make(map[string]*ancestry.Ancestry, len(r.ExtraAncestries)+1)
+	for _, a := range r.ExtraAncestries {
+		byCode[a.Code()] = a
+	}
+	for trait := range r.TraitSet {
+		a := r.referenceAncestry(trait)
+		byCode[a.Code()] = a
+	}
+	all := make([]*ancestry.Ancestry, 0, len(byCode))
+	for _, a := range byCode {
+		all = append(all, a)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Code() < all[j].Code() })
+	return all
+}
+
+// otherAncestryEntries returns cache entries for the computed stats of ancestries other than
+// each trait's reference ancestry, so later runs for those ancestries are cache hits.
+func otherAncestryEntries(requirements *PipelineRequirements, computed map[string]*reference_stats.ReferenceStats, cached map[string]*reference_stats.ReferenceStats) []reference_cache.CacheEntry {
+	var entries []reference_cache.CacheEntry
+	for key, stats := range computed {
+		if _, found := cached[key]; found {
+			continue
+		}
+		if _, ok := requirements.TraitSet[stats.Trait]; !ok || stats.Ancestry == requirements.referenceAncestry(stats.Trait).Code() {
+			continue
+		}
+		entries = append(entries, reference_cache.CacheEntry{
+			Request: reference_cache.StatsRequest{Ancestry: stats.Ancestry, Trait: stats.Trait, ModelID: stats.Model},
+			Stats:   stats,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Request.Trait != entries[j].Request.Trait {
+			return entries[i].Request.Trait < entries[j].Request.Trait
+		}
+		return entries[i].Request.Ancestry < entries[j].Request.Ancestry
+	})
+	return entries
+}
@@ -0,0 +1,45 @@
+package pipeline
+
+import (
+	"sync"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+// Domain-specific configuration keys for Phase 3 parallelism
+const (
+	TraitConcurrencyKey = "pipeline.trait_concurrency" // Traits scored at once in Phase 3 (default 1); results are aggregated in trait order either way
+)
+
+// traitConcurrency returns how many traits Phase 3 scores at once, never more than traits.
+func traitConcurrency(traits int) int {
+	return max(min(config.GetInt(TraitConcurrencyKey), traits), 1)
+}
+
+// forEachIndex calls fn(i) for every i in [0, n) on up to workers goroutines and returns
+// once all calls have. Callers write to index i of presized slices, so aggregating the
+// slices afterwards is deterministic whatever order the calls ran in.
+func forEachIndex(n, workers int, fn func(i int)) {
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
+	"phite.io/polygenic-risk-calculator/internal/output"
+)
+
+// Domain-specific configuration keys for the pipeline
+const (
+	MinSNPCoverageKey = "pipeline.min_snp_coverage" // Minimum fraction (0-1) of a trait's model variants that must be genotyped
+)
+
+// minSNPCoverage returns the configured coverage threshold, or 0 when disabled or invalid.
+func minSNPCoverage() float64 {
+	threshold := config.GetFloat64(MinSNPCoverageKey)
+	if threshold < 0 || threshold > 1 {
+		logging.Warn("Ignoring %s=%v: must be between 0 and 1", MinSNPCoverageKey, threshold)
+		return 0
+	}
+	return threshold
+}
+
+// countExpectedSNPs returns the number of distinct variants per trait among the GWAS records.
+// The records only hold requested variants, so this is the fallback for traits whose model
+// variant count is unknown; see traitRecords.countModelVariants.
+func countExpectedSNPs(records map[string]model.GWASSNPRecord) map[string]int {
+	seen := make(map[string]map[string]struct{})
+	for _, r := range records {
+		if r.Trait == "" {
+			continue
+		}
+		if seen[r.Trait] == nil {
+			seen[r.Trait] = make(map[string]struct{})
+		}
+		seen[r.Trait][r.RSID] = struct{}{}
+	}
+	counts := make(map[string]int, len(seen))
+	for trait, rsids := range seen {
+		counts[trait] = len(rsids)
+	}
+	return counts
+}
+
+// countExcludedSNPs returns the number of variants excluded per trait.
+func countExcludedSNPs(excluded []model.ExcludedSNP) map[string]int {
+	counts := make(map[string]int)
+	for _, e := range excluded {
+		counts[e.Trait]++
+	}
+	return counts
+}
+
+// traitCoverage describes how many of a trait's model variants were found in the genotype.
+type traitCoverage struct {
+	Present   int
+	Expected  int
+	Excluded  int // genotyped variants excluded for alleles incompatible with the model
+	Threshold float64
+}
+
+// Fraction returns Present/Expected, or 1 when the expected count is unknown.
+func (c traitCoverage) Fraction() float64 {
+	if c.Expected <= 0 {
+		return 1
+	}
+	return float64(c.Present) / float64(c.Expected)
+}
+
+// Sufficient reports whether coverage meets the threshold.
+func (c traitCoverage) Sufficient() bool {
+	return c.Threshold <= 0 || c.Fraction() >= c.Threshold
+}
+
+// annotate copies the coverage counts onto a trait summary.
+func (c traitCoverage) annotate(ts *output.TraitSummary) {
+	ts.SNPsExcluded = c.Excluded
+	if c.Expected <= 0 {
+		return
+	}
+	ts.SNPsPresent = c.Present
+	ts.SNPsExpected = c.Expected
+	ts.Coverage = c.Fraction()
+	ts.MinCoverage = c.Threshold
+}
@@ -0,0 +1,157 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	gwas "phite.io/polygenic-risk-calculator/internal/gwas"
+	"phite.io/polygenic-risk-calculator/internal/model"
+	reference "phite.io/polygenic-risk-calculator/internal/reference"
+)
+
+// Domain-specific configuration keys for trait discovery
+const (
+	TraitSourceKey = "pipeline.trait_source" // Where Phase 1 enumerates traits: "gwas" (default) or "model"
+)
+
+// Trait sources.
+const (
+	// TraitSourceGWAS scores the traits of GWAS-annotated SNPs in gwas_table.
+	TraitSourceGWAS = "gwas"
+	// TraitSourceModel scores every PRS model in tables.model_table sharing a variant with
+	// the genotype, as in PGS Catalog workflows where the models define the traits.
+	TraitSourceModel = "model"
+)
+
+// traitSource returns the configured trait source, defaulting to TraitSourceGWAS.
+func traitSource() (string, error) {
+	switch source := config.GetString(TraitSourceKey); source {
+	case "", TraitSourceGWAS:
+		return TraitSourceGWAS, nil
+	case TraitSourceModel:
+		return TraitSourceModel, nil
+	default:
+		return "", fmt.Errorf("invalid %s %q: must be %q or %q", TraitSourceKey, source, TraitSourceGWAS, TraitSourceModel)
+	}
+}
+
+// traitRecords are the per-variant associations Phase 1 annotates the genotype with.
+type traitRecords struct {
+	byRSID   map[string]model.GWASSNPRecord // one record per rsid, for genotype validation
+	all      []model.GWASSNPRecord          // every association, for annotation
+	expected map[string]int                 // trait -> distinct model variants, for coverage
+	// fromModels is set when expected holds the models' variant counts; GWAS records only
+	// count the requested variants until countModelVariants replaces them.
+	fromModels bool
+}
+
+// fetchTraitRecords loads associations for snps from the configured trait source. GWAS
+// associations come from fetcher, or from the configured GWAS database when it is nil.
+func fetchTraitRecords(ctx context.Context, snps []string, anc *ancestry.Ancestry, rs *reference.ReferenceService, fetcher gwas.GWASFetcher) (traitRecords, error) {
+	source, err := traitSource()
+	if err != nil {
+		return traitRecords{}, err
+	}
+
+	if source == TraitSourceModel {
+		if rs == nil {
+			return traitRecords{}, errors.New("model trait discovery requires a reference service")
+		}
+		found, err := rs.DiscoverModelTraits(ctx, snps, anc)
+		if err != nil {
+			return traitRecords{}, fmt.Errorf("failed to discover traits from model table: %w", err)
+		}
+		byRSID := make(map[string]model.GWASSNPRecord, len(found.Records))
+		for _, r := range found.Records {
+			if _, ok := byRSID[r.RSID]; !ok {
+				byRSID[r.RSID] = r
+			}
+		}
+		return traitRecords{byRSID: byRSID, all: found.Records, expected: found.VariantCounts, fromModels: true}, nil
+	}
+
+	if fetcher == nil {
+		gwasService := gwas.NewGWASService()
+		if gwasService == nil {
+			return traitRecords{}, errors.New("failed to initialize GWAS service")
+		}
+		fetcher = gwasService
+	}
+	records, err := fetcher.FetchGWASRecords(ctx, snps, anc)
+	if err != nil {
+		return traitRecords{}, fmt.Errorf("failed to fetch GWAS records: %w", err)
+	}
+	return traitRecords{byRSID: records, all: gwas.MapToGWASList(records), expected: countExpectedSNPs(records)}, nil
+}
+
+// hasTrait reports whether r holds associations for trait, matched case-insensitively.
+func (r traitRecords) hasTrait(trait string) bool {
+	for t := range r.expected {
+		if strings.EqualFold(t, trait) {
+			return true
+		}
+	}
+	return false
+}
+
+// without drops the associations of excluded traits. Variants left with no association
+// are returned so that they are not requested from the genotype either.
+func (r traitRecords) without(excluded func(trait string) bool) (traitRecords, map[string]bool) {
+	kept := traitRecords{byRSID: make(map[string]model.GWASSNPRecord), expected: make(map[string]int), fromModels: r.fromModels}
+	dropped := make(map[string]bool)
+	for _, rec := range r.all {
+		if excluded(rec.Trait) {
+			dropped[rec.RSID] = true
+			continue
+		}
+		kept.all = append(kept.all, rec)
+		if _, ok := kept.byRSID[rec.RSID]; !ok {
+			kept.byRSID[rec.RSID] = rec
+		}
+	}
+	for rsid := range dropped {
+		if _, ok := kept.byRSID[rsid]; ok {
+			delete(dropped, rsid)
+		}
+	}
+	for trait, n := range r.expected {
+		if !excluded(trait) {
+			kept.expected[trait] = n
+		}
+	}
+	return kept, dropped
+}
+
+// countModelVariants measures coverage of GWAS-sourced traits against their full models:
+// each trait's expected count becomes the variant count of the model it is scored with
+// (see reference.AssignModels). Traits without a model in the model table keep the count
+// of their GWAS associations.
+func (r *traitRecords) countModelVariants(ctx context.Context, rs *reference.ReferenceService) error {
+	if r.fromModels || len(r.expected) == 0 {
+		return nil
+	}
+	modelOf := make(map[string]string, len(r.expected))
+	for _, rec := range r.all {
+		if rec.Trait != "" {
+			modelOf[rec.Trait] = rec.ModelID()
+		}
+	}
+	ids := slices.Sorted(maps.Values(modelOf))
+	counts, err := rs.CountModelVariants(ctx, slices.Compact(ids))
+	if err != nil {
+		return err
+	}
+	for trait, id := range modelOf {
+		if n, ok := counts[id]; ok {
+			r.expected[trait] = n
+		}
+	}
+	r.fromModels = true
+	return nil
+}
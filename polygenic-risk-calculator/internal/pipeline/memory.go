@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"runtime"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// Domain-specific configuration keys for pipeline memory accounting
+const (
+	SoftMemoryLimitMBKey = "pipeline.soft_memory_limit_mb" // Heap size (MiB) above which Phases 2-3 switch to streaming; 0 disables
+)
+
+// MemorySnapshot records Go heap usage at a point in the pipeline.
+type MemorySnapshot struct {
+	Phase     string `json:"phase"`
+	HeapAlloc uint64 `json:"heap_alloc_bytes"` // bytes of live and not-yet-collected heap objects
+	HeapInuse uint64 `json:"heap_inuse_bytes"` // bytes in in-use heap spans
+	Sys       uint64 `json:"sys_bytes"`        // total bytes obtained from the OS
+	NumGC     uint32 `json:"num_gc"`
+}
+
+// takeMemorySnapshot reads runtime.MemStats and logs the result under phase.
+func takeMemorySnapshot(phase string) MemorySnapshot {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	snap := MemorySnapshot{
+		Phase:     phase,
+		HeapAlloc: ms.HeapAlloc,
+		HeapInuse: ms.HeapInuse,
+		Sys:       ms.Sys,
+		NumGC:     ms.NumGC,
+	}
+	logging.Info("Memory after %s: heap %.1f MiB (in use %.1f MiB), sys %.1f MiB, %d GCs",
+		phase, mib(snap.HeapAlloc), mib(snap.HeapInuse), mib(snap.Sys), snap.NumGC)
+	return snap
+}
+
+// softMemoryLimit returns the configured soft limit in bytes, or 0 when disabled.
+func softMemoryLimit() uint64 {
+	n := config.GetInt(SoftMemoryLimitMBKey)
+	if n <= 0 {
+		return 0
+	}
+	return uint64(n) << 20
+}
+
+// exceeds reports whether the snapshot's heap is above limit. A zero limit is never exceeded.
+func (s MemorySnapshot) exceeds(limit uint64) bool {
+	return limit > 0 && s.HeapAlloc > limit
+}
+
+func mib(b uint64) float64 {
+	return float64(b) / (1 << 20)
+}
@@ -4,14 +4,29 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
 
-	"github.com/spf13/viper"
 	"phite.io/polygenic-risk-calculator/internal/ancestry"
+	"phite.io/polygenic-risk-calculator/internal/compound"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/consent"
+	"phite.io/polygenic-risk-calculator/internal/contig"
+	"phite.io/polygenic-risk-calculator/internal/dosage"
+	"phite.io/polygenic-risk-calculator/internal/events"
 	"phite.io/polygenic-risk-calculator/internal/genotype"
 	gwas "phite.io/polygenic-risk-calculator/internal/gwas"
+	"phite.io/polygenic-risk-calculator/internal/haplotype"
+	"phite.io/polygenic-risk-calculator/internal/limits"
 	"phite.io/polygenic-risk-calculator/internal/logging"
 	"phite.io/polygenic-risk-calculator/internal/model"
 	"phite.io/polygenic-risk-calculator/internal/output"
+	"phite.io/polygenic-risk-calculator/internal/panel"
+	"phite.io/polygenic-risk-calculator/internal/pgx"
+	"phite.io/polygenic-risk-calculator/internal/progress"
 	"phite.io/polygenic-risk-calculator/internal/prs"
 	reference "phite.io/polygenic-risk-calculator/internal/reference"
 	reference_cache "phite.io/polygenic-risk-calculator/internal/reference/cache"
@@ -25,24 +40,146 @@ type PipelineInput struct {
 	ReferenceTable string // reference stats table name (default: reference_panel)
 	OutputFormat   string
 	OutputPath     string
-	Config         *viper.Viper // Add config parameter
+	Config         config.Provider         // optional; read instead of the process configuration, e.g. a config.Values
+	Progress       progress.Reporter       // optional; receives phase and per-trait progress events
+	Events         *events.Bus             // optional; extensions subscribe here to pipeline events
+	GWAS           gwas.GWASFetcher        // optional; defaults to the DuckDB-backed gwas.GWASService
+	Genotypes      genotype.GenotypeParser // optional; defaults to genotype.ParserFromConfig
 }
 
 // PipelineOutput defines the results of the pipeline execution.
 type PipelineOutput struct {
 	TraitSummaries []output.TraitSummary
+	TraitGroups    []output.TraitGroup          // summaries grouped by topic, when output.group_by is set
 	NormalizedPRS  map[string]prs.NormalizedPRS // per trait
 	PRSResults     map[string]prs.PRSResult     // per trait
 	SNPSMissing    []string
+	ExcludedSNPs   []model.ExcludedSNP       // variants dropped by effect-allele validation
+	Alleles        model.AlleleHarmonization // genotype calls and reference frequencies reconciled with the models' alleles
+	Memory         []MemorySnapshot          // heap usage after each phase
+	Streaming      bool                      // true if the soft memory limit switched the run to streaming
+	Model          model.ModelVersion        // model release and checksum the run was scored against
+	Contigs        contig.Report             // variants dropped or remapped for lying on ALT contigs
+	DerivedMetrics []output.DerivedMetric    // user-defined expressions over the normalized results
+	Compound       []compound.Result         // categorical genotypes such as APOE and CYP2C19 diplotypes
+	PGx            []compound.Result         // pharmacogene diplotypes with CPIC-style phenotypes
 	Errors         []error
 }
 
+// Primary returns the results of the first normalized trait in name order, which the
+// single-score fields of the JSON and CSV outputs report.
+func (o PipelineOutput) Primary() (prs.NormalizedPRS, prs.PRSResult) {
+	traits := make(map[string]struct{}, len(o.NormalizedPRS))
+	for trait := range o.NormalizedPRS {
+		traits[trait] = struct{}{}
+	}
+	if len(traits) == 0 {
+		for trait := range o.PRSResults {
+			traits[trait] = struct{}{}
+		}
+	}
+	if len(traits) == 0 {
+		return prs.NormalizedPRS{}, prs.PRSResult{}
+	}
+	first := sortedTraits(traits)[0]
+	return o.NormalizedPRS[first], o.PRSResults[first]
+}
+
 // PipelineRequirements holds all data requirements identified during analysis phase
 type PipelineRequirements struct {
-	TraitSet      map[string]struct{}
-	CacheKeys     []reference_cache.StatsRequest
-	StatsRequests []reference.ReferenceStatsRequest
-	AncestryObj   *ancestry.Ancestry
+	TraitSet        map[string]struct{}
+	ExpectedSNPs    map[string]int // trait -> distinct model variants requested; used for coverage filtering
+	ExcludedSNPs    map[string]int // trait -> variants excluded by effect-allele validation
+	CacheKeys       []reference_cache.StatsRequest
+	StatsRequests   []reference.ReferenceStatsRequest
+	AncestryObj     *ancestry.Ancestry
+	TraitAncestry   map[string]*ancestry.Ancestry // trait -> reference ancestry overriding AncestryObj
+	ScoreScales     map[string]prs.ScoreScale     // lower-cased model ID -> transform to published units
+	Arrangement     output.Arrangement            // how trait summaries are sorted and grouped
+	Streaming       bool                          // compute and process traits one at a time to bound memory
+	ExtraAncestries []*ancestry.Ancestry          // also compute and cache stats for these ancestries
+	ModelVersion    string                        // pinned model release; part of every reference stats cache key
+	TraitModels     map[string]string             // lower-cased trait -> model ID from the trait model map; unmapped traits are their own model
+	Dosage          *dosage.Selector              // dosage coding of each model; coded models have their own reference stats
+	Derived         []output.MetricDefinition     // derived metrics evaluated after Phase 3
+	Haplotypes      *haplotype.Set                // haplotype terms scored alongside each trait's SNPs
+	CompoundGenes   []compound.Gene               // genes whose compound genotypes are reported
+	PGx             *pgx.Table                    // pharmacogene phenotype mapping; nil when disabled
+	CuratedNotes    *output.CuratedNotes          // converter SNP notes merged into trait summaries
+	Actionability   output.Actionability          // clinically actionable thresholds and consent-based suppression
+}
+
+// withoutSNPs returns snps without the dropped ones.
+func withoutSNPs(snps []string, dropped map[string]bool) []string {
+	if len(dropped) == 0 {
+		return snps
+	}
+	kept := make([]string, 0, len(snps))
+	for _, rsid := range snps {
+		if !dropped[rsid] {
+			kept = append(kept, rsid)
+		}
+	}
+	return kept
+}
+
+// withExtraSNPs adds the SNPs haplotypes and compound genes are defined over to the
+// requested SNPs.
+func withExtraSNPs(snps []string, haplotypes *haplotype.Set, genes []compound.Gene) []string {
+	extra := append(haplotypes.RSIDs(), compound.RSIDs(genes)...)
+	if len(extra) == 0 {
+		return snps
+	}
+	seen := make(map[string]bool, len(snps))
+	for _, rsid := range snps {
+		seen[rsid] = true
+	}
+	requested := append([]string(nil), snps...)
+	for _, rsid := range extra {
+		if !seen[rsid] {
+			seen[rsid] = true
+			requested = append(requested, rsid)
+		}
+	}
+	return requested
+}
+
+// traitRSIDs returns the set of SNPs annotated for each trait.
+func traitRSIDs(snps []model.AnnotatedSNP) map[string]map[string]bool {
+	byTrait := make(map[string]map[string]bool)
+	for _, snp := range snps {
+		if byTrait[snp.Trait] == nil {
+			byTrait[snp.Trait] = make(map[string]bool)
+		}
+		byTrait[snp.Trait][snp.RSID] = true
+	}
+	return byTrait
+}
+
+// sortedTraits returns the traits of set in name order, for iteration that does not vary
+// from run to run.
+func sortedTraits(set map[string]struct{}) []string {
+	traits := make([]string, 0, len(set))
+	for trait := range set {
+		traits = append(traits, trait)
+	}
+	sort.Strings(traits)
+	return traits
+}
+
+// referenceAncestry returns the ancestry whose reference stats normalize trait.
+func (r *PipelineRequirements) referenceAncestry(trait string) *ancestry.Ancestry {
+	if a, ok := r.TraitAncestry[trait]; ok {
+		return a
+	}
+	return r.AncestryObj
+}
+
+// modelID returns the identifier trait's reference stats are cached under: its mapped
+// model under the pinned model version and the model's dosage coding.
+func (r *PipelineRequirements) modelID(trait string) string {
+	modelName := reference.ModelFor(r.TraitModels, trait)
+	return reference.CodedModelID(reference.ModelID(modelName, r.ModelVersion), r.Dosage.For(modelName))
 }
 
 // BulkDataContext holds all data retrieved in bulk operations
@@ -52,6 +189,7 @@ type BulkDataContext struct {
 	ComputedStats     map[string]*reference_stats.ReferenceStats // computed for cache misses
 	PRSModels         map[string]*model.PRSModel                 // trait -> model
 	TraitSNPs         map[string][]model.AnnotatedSNP            // trait -> SNPs
+	AncestryEntries   []reference_cache.CacheEntry               // stats computed for ancestries other than each trait's reference
 	Errors            []error
 }
 
@@ -77,15 +215,75 @@ type BulkOperationContext struct {
 // Phase 2: Bulk Data Retrieval - Execute minimal BigQuery operations
 // Phase 3: In-Memory Processing - Process all traits using cached data
 // Phase 4: Bulk Storage - Store all results in single operation
+//
+// Events are published on input.Events (a new bus when unset). If input.Progress is set,
+// phase transitions and per-trait completion are forwarded to it. Configuration is read
+// from input.Config, else from the reference service's provider, else from the process
+// configuration.
 func Run(input PipelineInput, refService ...*reference.ReferenceService) (PipelineOutput, error) {
+	return RunContext(context.Background(), input, refService...)
+}
+
+// RunContext is Run with a context: cancelling ctx, or reaching its deadline, stops the
+// run's queries and fails the run.
+func RunContext(ctx context.Context, input PipelineInput, refService ...*reference.ReferenceService) (PipelineOutput, error) {
+	cfg := input.Config
+	if cfg == nil && len(refService) > 0 && refService[0] != nil {
+		cfg = refService[0].Config()
+	}
+	if cfg != nil {
+		defer config.Use(cfg)()
+	}
+
+	bus := input.Events
+	if bus == nil {
+		bus = events.New()
+	}
+	if input.Progress != nil {
+		defer bus.Subscribe(events.Progress(input.Progress))()
+	}
+	out, err := run(events.WithBus(ctx, bus), input, refService...)
+	if err != nil {
+		bus.Publish(events.Event{Type: events.RunFailed, Error: err.Error()})
+		return out, err
+	}
+	bus.Publish(events.Event{Type: events.RunCompleted})
+	return out, nil
+}
+
+// Pipeline phase names reported in progress events.
+const (
+	phaseRequirements = "requirements_analysis"
+	phaseRetrieval    = "bulk_data_retrieval"
+	phaseProcessing   = "in_memory_processing"
+	phaseStorage      = "bulk_storage"
+)
+
+func run(ctx context.Context, input PipelineInput, refService ...*reference.ReferenceService) (PipelineOutput, error) {
 	logging.Info("Starting pipeline: %+v", input)
 
-	ctx := context.Background()
 	if input.GenotypeFile == "" || input.ReferenceTable == "" || len(input.SNPs) == 0 {
 		logging.Error("Missing required pipeline input: %+v", input)
 		return PipelineOutput{}, errors.New("missing required input")
 	}
 
+	// Reject oversized inputs before any database or BigQuery work
+	inputLimits := limits.FromConfig()
+	if err := inputLimits.CheckSNPs(len(input.SNPs)); err != nil {
+		return PipelineOutput{}, err
+	}
+	if err := inputLimits.CheckGenotypeFile(input.GenotypeFile); err != nil {
+		return PipelineOutput{}, err
+	}
+
+	bus := events.FromContext(ctx)
+	phaseStarted := func(phase int, name string) {
+		bus.Publish(events.Event{Type: events.PhaseStarted, Phase: phase, PhaseName: name})
+	}
+	phaseCompleted := func(phase int, name string) {
+		bus.Publish(events.Event{Type: events.PhaseCompleted, Phase: phase, PhaseName: name})
+	}
+
 	// Use provided reference service or create default
 	var rs *reference.ReferenceService
 	var err error
@@ -100,16 +298,33 @@ func Run(input PipelineInput, refService ...*reference.ReferenceService) (Pipeli
 
 	// ==================== PHASE 1: REQUIREMENTS ANALYSIS ====================
 	logging.Info("Phase 1: Analyzing all pipeline requirements...")
-	requirements, genoOut, annotated, err := analyzeAllRequirements(ctx, input)
+	phaseStarted(1, phaseRequirements)
+	requirements, genoOut, annotated, err := analyzeAllRequirements(ctx, input, rs)
 	if err != nil {
 		logging.Error("Phase 1 failed - Requirements analysis error: %v", err)
 		return PipelineOutput{}, fmt.Errorf("requirements analysis failed: %w", err)
 	}
+	if err := inputLimits.CheckTraits(len(requirements.TraitSet)); err != nil {
+		logging.Error("Phase 1 failed - %v", err)
+		return PipelineOutput{}, err
+	}
 	logging.Info("Phase 1 complete: %d traits, %d cache requests, %d stats requests",
 		len(requirements.TraitSet), len(requirements.CacheKeys), len(requirements.StatsRequests))
+	if requirements.ModelVersion != "" {
+		logging.Info("Reference stats keyed by pinned model version %s", requirements.ModelVersion)
+	}
+	memLimit := softMemoryLimit()
+	memory := []MemorySnapshot{takeMemorySnapshot(phaseRequirements)}
+	if memory[0].exceeds(memLimit) {
+		logging.Warn("Heap %.1f MiB exceeds soft limit %.1f MiB; computing reference stats one trait at a time",
+			mib(memory[0].HeapAlloc), mib(memLimit))
+		requirements.Streaming = true
+	}
+	phaseCompleted(1, phaseRequirements)
 
 	// ==================== PHASE 2: BULK DATA RETRIEVAL ====================
 	logging.Info("Phase 2: Executing bulk data retrieval operations...")
+	phaseStarted(2, phaseRetrieval)
 	bulkData, err := retrieveAllDataBulk(ctx, requirements, &annotated, rs)
 	if err != nil {
 		logging.Error("Phase 2 failed - Bulk data retrieval error: %v", err)
@@ -117,16 +332,24 @@ func Run(input PipelineInput, refService ...*reference.ReferenceService) (Pipeli
 	}
 	logging.Info("Phase 2 complete: Retrieved data for %d traits with %d cache hits, %d computed stats",
 		len(requirements.TraitSet), len(bulkData.CachedStats), len(bulkData.ComputedStats))
+	memory = append(memory, takeMemorySnapshot(phaseRetrieval))
+	if !requirements.Streaming && memory[1].exceeds(memLimit) {
+		logging.Warn("Heap %.1f MiB exceeds soft limit %.1f MiB after bulk retrieval; releasing trait data as it is processed",
+			mib(memory[1].HeapAlloc), mib(memLimit))
+		requirements.Streaming = true
+	}
 	if len(bulkData.Errors) > 0 {
 		logging.Warn("Phase 2 encountered %d errors.", len(bulkData.Errors))
 		for _, e := range bulkData.Errors {
 			logging.Error("- %v", e)
 		}
 	}
+	phaseCompleted(2, phaseRetrieval)
 
 	// ==================== PHASE 3: IN-MEMORY PROCESSING ====================
 	logging.Info("Phase 3: Processing all traits in-memory...")
-	results, err := processAllTraitsInMemory(requirements, bulkData)
+	phaseStarted(3, phaseProcessing)
+	results, err := processAllTraitsInMemory(requirements, bulkData, bus)
 	if err != nil {
 		logging.Error("Phase 3 failed - In-memory processing error: %v", err)
 		return PipelineOutput{}, fmt.Errorf("in-memory processing failed: %w", err)
@@ -136,71 +359,286 @@ func Run(input PipelineInput, refService ...*reference.ReferenceService) (Pipeli
 	if len(results.Errors) > 0 {
 		logging.Warn("Phase 3 finished with %d total errors.", len(results.Errors))
 	}
+	if requirements.Actionability.Suppress {
+		results.TraitSummaries, annotated.ExcludedSNPs = requirements.Actionability.Withhold(results.TraitSummaries, annotated.ExcludedSNPs)
+		for trait := range results.NormalizedPRS {
+			if requirements.Actionability.Withheld(trait) {
+				delete(results.NormalizedPRS, trait)
+				delete(results.PRSResults, trait)
+			}
+		}
+	}
+	if n := requirements.Actionability.Flag(results.TraitSummaries); n > 0 {
+		logging.Warn("%d trait(s) reached a clinically actionable threshold", n)
+	}
+	if requirements.CuratedNotes != nil {
+		requirements.Arrangement.Categorize(results.TraitSummaries)
+		requirements.CuratedNotes.Attach(results.TraitSummaries, traitRSIDs(annotated.AnnotatedSNPs))
+	}
+	traitGroups := requirements.Arrangement.Arrange(results.TraitSummaries)
+	derived := output.EvaluateDerivedMetrics(requirements.Derived, results.NormalizedPRS)
+	for _, m := range derived {
+		if m.Error != "" {
+			logging.Warn("Derived metric %s not computed: %s", m.Name, m.Error)
+		}
+	}
+	compoundResults := compound.Report(requirements.CompoundGenes, genoOut.ValidatedSNPs)
+	for _, r := range compoundResults {
+		if r.Status != "" {
+			logging.Warn("Compound genotype %s not determined: %s", r.Gene, r.Status)
+		}
+	}
+	pgxResults := requirements.PGx.Annotate(genoOut.ValidatedSNPs)
+	for _, r := range pgxResults {
+		if r.Status != "" {
+			logging.Warn("PGx phenotype for %s not determined: %s", r.Gene, r.Status)
+		}
+	}
+	memory = append(memory, takeMemorySnapshot(phaseProcessing))
+	phaseCompleted(3, phaseProcessing)
 
 	// ==================== PHASE 4: BULK STORAGE ====================
 	if len(results.Errors) >= 10 {
 		logging.Error("Error cap reached. Aborting before Phase 4 (Bulk Storage).")
 	} else {
 		logging.Info("Phase 4: Executing bulk storage operations...")
+		phaseStarted(4, phaseStorage)
 		err = storeBulkResults(ctx, results, rs)
 		if err != nil {
 			logging.Error("Phase 4 failed - Bulk storage error: %v", err)
 			return PipelineOutput{}, fmt.Errorf("bulk storage failed: %w", err)
 		}
 		logging.Info("Phase 4 complete: Stored %d cache entries", len(results.CacheEntries))
+		phaseCompleted(4, phaseStorage)
 	}
 
+	contigReport := rs.Contigs().Report()
+	logging.Info("ALT contig variants: %s", contigReport)
+	alleles := annotated.Harmonization
+	alleles.Add(rs.AlleleHarmonization())
+
 	logging.Info("Optimized pipeline completed successfully. Total traits processed: %d", len(requirements.TraitSet))
 
 	return PipelineOutput{
 		TraitSummaries: results.TraitSummaries,
+		TraitGroups:    traitGroups,
 		NormalizedPRS:  results.NormalizedPRS,
 		PRSResults:     results.PRSResults,
 		SNPSMissing:    genoOut.SNPsMissing,
+		ExcludedSNPs:   annotated.ExcludedSNPs,
+		Alleles:        alleles,
+		Memory:         memory,
+		Streaming:      requirements.Streaming,
+		Model:          rs.ModelVersion(),
+		Contigs:        contigReport,
+		DerivedMetrics: derived,
+		Compound:       compoundResults,
+		PGx:            pgxResults,
 		Errors:         results.Errors,
 	}, nil
 }
 
-// analyzeAllRequirements performs comprehensive analysis of all pipeline data requirements
-func analyzeAllRequirements(ctx context.Context, input PipelineInput) (*PipelineRequirements, genotype.ParseGenotypeDataOutput, gwas.GWASDataFetcherOutput, error) {
+// analyzeAllRequirements performs comprehensive analysis of all pipeline data requirements.
+// The reference service is only needed when traits are discovered from the model table.
+func analyzeAllRequirements(ctx context.Context, input PipelineInput, refService ...*reference.ReferenceService) (*PipelineRequirements, genotype.ParseGenotypeDataOutput, gwas.GWASDataFetcherOutput, error) {
 	// Initialize ancestry from configuration
 	ancestryObj, err := ancestry.NewFromConfig()
 	if err != nil {
 		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("failed to initialize ancestry: %w", err)
 	}
 
-	// Fetch GWAS data
-	gwasService := gwas.NewGWASService()
-	if gwasService == nil {
-		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, errors.New("failed to initialize GWAS service")
+	// Fetch trait associations from GWAS annotations or PRS models
+	var rs *reference.ReferenceService
+	var modelVersion string
+	var contigs *contig.Normalizer
+	var traitModels map[string]string
+	if len(refService) > 0 && refService[0] != nil {
+		rs = refService[0]
+		modelVersion = rs.PinnedModelVersion()
+		contigs = rs.Contigs()
+		traitModels = rs.TraitModels()
+	} else if traitModels, err = reference.TraitModelsFromConfig(); err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid trait model map: %w", err)
+	}
+	arrangement, err := output.ArrangementFromConfig()
+	if err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid trait summary ordering: %w", err)
+	}
+
+	// Resolve the user's opt-outs against the taxonomy the outputs are filed under
+	optOuts, err := consent.FromConfig(arrangement.Taxonomy)
+	if err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid consent configuration: %w", err)
+	}
+
+	traitPanel, err := panel.FromConfig()
+	if err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid trait panel: %w", err)
+	}
+
+	records, err := fetchTraitRecords(ctx, input.SNPs, ancestryObj, rs, input.GWAS)
+	if err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, err
+	}
+	requestedSNPs := input.SNPs
+	if traitPanel != nil {
+		var hasModel func(id string) (bool, error)
+		if rs != nil {
+			hasModel = func(id string) (bool, error) { return rs.HasModel(ctx, id) }
+		}
+		missing, err := traitPanel.Validate(records.hasTrait, hasModel)
+		if err != nil {
+			return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid trait panel: %w", err)
+		}
+		if len(missing) > 0 {
+			logging.Warn("Panel %s: no associations for %s; skipping them", traitPanel.Name, strings.Join(missing, ", "))
+		}
+
+		// Score only the panel's traits, even where its variants associate with others
+		var dropped map[string]bool
+		records, dropped = records.without(func(trait string) bool { return !traitPanel.HasTrait(trait) })
+		requestedSNPs = withoutSNPs(requestedSNPs, dropped)
+		logging.Info("Panel %s: scoring %d of its %d traits", traitPanel.Name, len(traitPanel.Traits)-len(missing), len(traitPanel.Traits))
+
+		if models := traitPanel.Models(); len(models) > 0 {
+			merged := maps.Clone(traitModels)
+			if merged == nil {
+				merged = make(map[string]string, len(models))
+			}
+			maps.Copy(merged, models)
+			traitModels = merged
+			if rs != nil {
+				rs.SetTraitModels(traitModels)
+			}
+		}
+		arrangement = arrangement.WithLayout(traitPanel.Names(), traitPanel.Sections())
+	}
+	if optOuts != nil {
+		// Drop opted-out traits before anything is parsed, scored, or cached
+		var dropped map[string]bool
+		records, dropped = records.without(optOuts.Excludes)
+		requestedSNPs = withoutSNPs(requestedSNPs, dropped)
+		logging.Info("Consent: excluded opted-out traits; %d SNPs no longer requested", len(dropped))
+	}
+	gwasMap := records.byRSID
+	// Dosage strategies are configured per model, so each record carries its trait's model
+	reference.AssignModels(records.all, traitModels)
+	if rs != nil {
+		if err := records.countModelVariants(ctx, rs); err != nil {
+			logging.Warn("SNP coverage is measured against GWAS associations only: %v", err)
+		}
+	}
+
+	haplotypes, err := haplotype.FromConfig()
+	if err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid haplotype definitions: %w", err)
 	}
 
-	gwasRecords, err := gwasService.FetchGWASRecords(ctx, input.SNPs)
+	compoundGenes, err := compound.FromConfig()
 	if err != nil {
-		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("failed to fetch GWAS records: %w", err)
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid compound genotype configuration: %w", err)
 	}
 
-	gwasMap := make(map[string]model.GWASSNPRecord)
-	for _, record := range gwasRecords {
-		gwasMap[record.RSID] = record
+	pgxTable, err := pgx.FromConfig()
+	if err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid PGx mapping table: %w", err)
 	}
 
-	// Parse genotype data
-	genoOut, err := genotype.ParseGenotypeData(genotype.ParseGenotypeDataInput{
+	if optOuts != nil {
+		haplotypes = haplotypes.Without(optOuts.Excludes)
+		pgxTable = pgxTable.Without(optOuts.ExcludesGene)
+		kept := compoundGenes[:0:0]
+		for _, g := range compoundGenes {
+			if !optOuts.ExcludesGene(g.Name) {
+				kept = append(kept, g)
+			}
+		}
+		compoundGenes = kept
+	}
+
+	// Parse genotype data, including the SNPs haplotypes, compound genotypes, and
+	// pharmacogenes are defined over
+	parser := input.Genotypes
+	if parser == nil {
+		parser = genotype.ParserFromConfig()
+	}
+	genoOut, err := parser.ParseGenotypeData(genotype.ParseGenotypeDataInput{
 		GenotypeFilePath: input.GenotypeFile,
-		RequestedRSIDs:   input.SNPs,
+		RequestedRSIDs:   withExtraSNPs(requestedSNPs, haplotypes, append(compoundGenes, pgxTable.Genes()...)),
 		GWASData:         gwasMap,
+		Contigs:          contigs,
 	})
 	if err != nil {
 		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("failed to parse genotype data: %w", err)
 	}
 
+	// Resolve dosage coding strategies (per model, from config)
+	dosageSelector, err := dosage.NewSelectorFromConfig()
+	if err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid dosage configuration: %w", err)
+	}
+
+	scoreScales, err := prs.ScoreScalesFromConfig()
+	if err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid score scaling configuration: %w", err)
+	}
+
+	derived, err := output.DerivedMetricsFromConfig()
+	if err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid derived metric: %w", err)
+	}
+
+	curatedNotes, err := output.CuratedNotesFromConfig()
+	if err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid curated notes: %w", err)
+	}
+
+	actionability, err := output.ActionabilityFromConfig()
+	if err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid actionable thresholds: %w", err)
+	}
+	if traitPanel != nil {
+		// Thresholds in output.actionable take precedence over the panel's
+		for trait, rule := range traitPanel.Thresholds() {
+			if _, ok := actionability.Rules[trait]; !ok {
+				actionability.Rules[trait] = rule
+			}
+		}
+	}
+
+	ancestryOverrides, err := ancestry.TraitOverridesFromConfig()
+	if err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid ancestry override: %w", err)
+	}
+
+	dropAmbiguous, err := gwas.DropAmbiguousFromConfig()
+	if err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid allele harmonization configuration: %w", err)
+	}
+
 	// Annotate GWAS data
 	annotated := gwas.FetchAndAnnotateGWAS(gwas.GWASDataFetcherInput{
 		ValidatedSNPs:     genoOut.ValidatedSNPs,
-		AssociationsClean: gwas.MapToGWASList(gwasMap),
+		AssociationsClean: records.all,
+		Dosage:            dosageSelector,
+		DropAmbiguous:     dropAmbiguous,
 	})
 
+	// Score haplotype terms from the validated calls
+	hapSNPs, hapExcluded := haplotypes.Annotate(genoOut.ValidatedSNPs)
+	annotated.AnnotatedSNPs = append(annotated.AnnotatedSNPs, hapSNPs...)
+	annotated.ExcludedSNPs = append(annotated.ExcludedSNPs, hapExcluded...)
+	expected := records.expected
+	if counts := haplotypes.CountByTrait(); len(counts) > 0 {
+		expected = make(map[string]int, len(records.expected)+len(counts))
+		for trait, n := range records.expected {
+			expected[trait] = n
+		}
+		for trait, n := range counts {
+			expected[trait] += n
+		}
+	}
+
 	// Identify all traits
 	traitSet := make(map[string]struct{})
 	for _, snp := range annotated.AnnotatedSNPs {
@@ -209,20 +647,52 @@ func analyzeAllRequirements(ctx context.Context, input PipelineInput) (*Pipeline
 		}
 	}
 
-	// Build cache requests for all traits
-	cacheKeys := make([]reference_cache.StatsRequest, 0, len(traitSet))
+	// Resolve per-trait reference ancestry overrides that differ from the user's ancestry
+	traitAncestry := make(map[string]*ancestry.Ancestry)
 	for trait := range traitSet {
-		cacheKeys = append(cacheKeys, reference_cache.StatsRequest{
-			Ancestry: ancestryObj.Code(),
-			Trait:    trait,
-			ModelID:  trait,
-		})
+		if a, ok := ancestryOverrides[strings.ToLower(trait)]; ok && a.Code() != ancestryObj.Code() {
+			logging.Warn("Normalizing trait %s against %s reference stats instead of %s", trait, a.Code(), ancestryObj.Code())
+			traitAncestry[trait] = a
+		}
+	}
+
+	extra, err := extraAncestries(ancestryObj)
+	if err != nil {
+		return nil, genotype.ParseGenotypeDataOutput{}, gwas.GWASDataFetcherOutput{}, fmt.Errorf("invalid ancestry configuration: %w", err)
 	}
 
 	requirements := &PipelineRequirements{
-		TraitSet:    traitSet,
-		CacheKeys:   cacheKeys,
-		AncestryObj: ancestryObj,
+		TraitSet:        traitSet,
+		ExpectedSNPs:    expected,
+		ExcludedSNPs:    countExcludedSNPs(annotated.ExcludedSNPs),
+		AncestryObj:     ancestryObj,
+		TraitAncestry:   traitAncestry,
+		ScoreScales:     scoreScales,
+		Arrangement:     arrangement,
+		ModelVersion:    modelVersion,
+		TraitModels:     traitModels,
+		Dosage:          dosageSelector,
+		Derived:         derived,
+		ExtraAncestries: extra,
+		Haplotypes:      haplotypes,
+		CompoundGenes:   compoundGenes,
+		PGx:             pgxTable,
+		CuratedNotes:    curatedNotes,
+		Actionability:   actionability,
+	}
+
+	// Build cache requests for all traits, keyed by each trait's model, the pinned version,
+	// and the model's dosage coding
+	requirements.CacheKeys = make([]reference_cache.StatsRequest, 0, len(traitSet))
+	for _, trait := range sortedTraits(traitSet) {
+		refAncestry := requirements.referenceAncestry(trait)
+		modelID := requirements.modelID(trait)
+		requirements.CacheKeys = append(requirements.CacheKeys, reference_cache.StatsRequest{
+			Ancestry: refAncestry.Code(),
+			Trait:    trait,
+			ModelID:  modelID,
+		})
+		requirements.CacheKeys = append(requirements.CacheKeys, extraAncestryCacheKeys(trait, modelID, refAncestry, extra)...)
 	}
 
 	return requirements, genoOut, annotated, nil
@@ -239,34 +709,52 @@ func retrieveAllDataBulk(ctx context.Context, requirements *PipelineRequirements
 
 	// Identify cache misses and prepare for bulk stats computation
 	cacheMisses := make([]string, 0)
-	ancestryCode := requirements.AncestryObj.Code()
 
-	for trait := range requirements.TraitSet {
-		key := fmt.Sprintf("%s|%s|%s", ancestryCode, trait, trait)
+	for _, trait := range sortedTraits(requirements.TraitSet) {
+		key := fmt.Sprintf("%s|%s|%s", requirements.referenceAncestry(trait).Code(), trait, requirements.modelID(trait))
 		if _, found := cacheResults[key]; !found {
 			cacheMisses = append(cacheMisses, trait)
+			events.Publish(ctx, events.Event{
+				Type:     events.CacheMiss,
+				Trait:    trait,
+				Ancestry: requirements.referenceAncestry(trait).Code(),
+				Model:    requirements.modelID(trait),
+			})
 		}
 	}
 
 	computedStats := make(map[string]*reference_stats.ReferenceStats)
+	var ancestryEntries []reference_cache.CacheEntry
 	var allErrors []error
 
-	if len(cacheMisses) > 0 {
+	if len(cacheMisses) > 0 || len(requirements.ExtraAncestries) > 0 {
 		// BULK OPERATION 3: Single bulk reference stats computation for all cache misses
 		logging.Info("Computing reference stats for %d cache misses", len(cacheMisses))
 
 		statsRequests := make([]reference.ReferenceStatsRequest, 0, len(cacheMisses))
 		for _, trait := range cacheMisses {
 			statsRequests = append(statsRequests, reference.ReferenceStatsRequest{
-				Ancestry: requirements.AncestryObj,
+				Ancestry: requirements.referenceAncestry(trait),
 				Trait:    trait,
 			})
 		}
 
-		// This returns
-		bulkStats, errs := refService.GetReferenceStatsBatch(ctx, statsRequests)
+		var bulkStats map[string]*reference_stats.ReferenceStats
+		var errs []error
+		if len(requirements.ExtraAncestries) > 0 {
+			bulkStats, errs = extraAncestryStats(ctx, requirements, cacheResults, refService)
+		} else if requirements.Streaming {
+			bulkStats, errs = streamReferenceStats(ctx, statsRequests, refService)
+		} else {
+			bulkStats, errs = batchReferenceStats(ctx, statsRequests, refService)
+		}
 		if len(errs) > 0 {
 			logging.Warn("Encountered %d errors during bulk reference stats computation", len(errs))
+			for _, e := range errs {
+				if errors.Is(e, limits.ErrLimitExceeded) {
+					return nil, e
+				}
+			}
 			allErrors = append(allErrors, errs...)
 			if len(allErrors) >= 10 {
 				return nil, fmt.Errorf("error cap of 10 reached, aborting pipeline")
@@ -275,13 +763,17 @@ func retrieveAllDataBulk(ctx context.Context, requirements *PipelineRequirements
 
 		// Process bulk stats results
 		for _, trait := range cacheMisses {
-			key := fmt.Sprintf("%s|%s|%s", ancestryCode, trait, trait)
+			key := fmt.Sprintf("%s|%s|%s", requirements.referenceAncestry(trait).Code(), trait, requirements.modelID(trait))
 			if stats, found := bulkStats[key]; found {
 				computedStats[trait] = stats
 			} else {
 				logging.Warn("No reference stats computed for trait %s, likely due to a processing error.", trait)
 			}
 		}
+		if len(requirements.ExtraAncestries) > 0 {
+			ancestryEntries = otherAncestryEntries(requirements, bulkStats, cacheResults)
+			logging.Info("Computed reference stats for %d other trait/ancestry pairs", len(ancestryEntries))
+		}
 	}
 
 	// Organize trait SNPs for processing
@@ -299,95 +791,127 @@ func retrieveAllDataBulk(ctx context.Context, requirements *PipelineRequirements
 	}
 
 	return &BulkDataContext{
-		CachedStats:   cacheResults,
-		ComputedStats: computedStats,
-		TraitSNPs:     traitSNPs,
-		Errors:        allErrors,
+		CachedStats:     cacheResults,
+		ComputedStats:   computedStats,
+		TraitSNPs:       traitSNPs,
+		AncestryEntries: ancestryEntries,
+		Errors:          allErrors,
 	}, nil
 }
 
-// processAllTraitsInMemory processes all traits using pre-loaded bulk data
-func processAllTraitsInMemory(requirements *PipelineRequirements, bulkData *BulkDataContext) (*ProcessingResults, error) {
-	normPRSs := make(map[string]prs.NormalizedPRS)
-	prsResults := make(map[string]prs.PRSResult)
-	summaries := make([]output.TraitSummary, 0, len(requirements.TraitSet))
-	cacheEntries := make([]reference_cache.CacheEntry, 0)
-	pipelineErrors := make([]error, 0)
+// batchReferenceStats computes reference stats in one batch per reference ancestry, since a
+// batch shares a single allele frequency lookup. Without per-trait overrides that is one batch.
+func batchReferenceStats(ctx context.Context, requests []reference.ReferenceStatsRequest, refService *reference.ReferenceService) (map[string]*reference_stats.ReferenceStats, []error) {
+	var codes []string
+	byAncestry := make(map[string][]reference.ReferenceStatsRequest)
+	for _, req := range requests {
+		code := req.Ancestry.Code()
+		if _, ok := byAncestry[code]; !ok {
+			codes = append(codes, code)
+		}
+		byAncestry[code] = append(byAncestry[code], req)
+	}
+	if len(codes) == 1 {
+		return refService.GetReferenceStatsBatch(ctx, requests)
+	}
 
-	ancestryCode := requirements.AncestryObj.Code()
+	stats := make(map[string]*reference_stats.ReferenceStats, len(requests))
+	var errs []error
+	for _, code := range codes {
+		got, batchErrs := refService.GetReferenceStatsBatch(ctx, byAncestry[code])
+		for key, s := range got {
+			stats[key] = s
+		}
+		errs = append(errs, batchErrs...)
+	}
+	return stats, errs
+}
 
-	// Process each trait using pre-loaded data
-	for trait := range requirements.TraitSet {
-		logging.Info("Processing trait: %s", trait)
+// streamReferenceStats computes reference stats one trait at a time, so only one model and its
+// allele frequencies are held at once. It trades the single bulk query for one query per trait.
+// Every trait is attempted, and the errors of all of them returned, as in batch mode.
+func streamReferenceStats(ctx context.Context, requests []reference.ReferenceStatsRequest, refService *reference.ReferenceService) (map[string]*reference_stats.ReferenceStats, []error) {
+	stats := make(map[string]*reference_stats.ReferenceStats, len(requests))
+	var errs []error
+	for _, req := range requests {
+		got, reqErrs := refService.GetReferenceStatsBatch(ctx, []reference.ReferenceStatsRequest{req})
+		for key, s := range got {
+			stats[key] = s
+		}
+		errs = append(errs, reqErrs...)
+		if ctx.Err() != nil {
+			return stats, append(errs, ctx.Err())
+		}
+		runtime.GC()
+	}
+	return stats, errs
+}
+
+// processAllTraitsInMemory processes all traits using pre-loaded bulk data, scoring up to
+// pipeline.trait_concurrency traits at once. Results are aggregated in trait order so that
+// summaries, cache entries, and errors come out the same on every run.
+// A TraitScored event is published on the optional bus after each trait is handled.
+// When requirements.Streaming is set, each trait's SNPs are released once it is processed.
+func processAllTraitsInMemory(requirements *PipelineRequirements, bulkData *BulkDataContext, bus ...*events.Bus) (*ProcessingResults, error) {
+	var b *events.Bus
+	if len(bus) > 0 {
+		b = bus[0]
+	}
+	traits := sortedTraits(requirements.TraitSet)
+	total := len(traits)
+	workers := traitConcurrency(total)
+	if workers > 1 {
+		logging.Info("Scoring %d traits with %d workers", total, workers)
+	}
+	threshold := minSNPCoverage()
 
+	// Streaming deletes from TraitSNPs as traits finish, and progress events must count
+	// up, so both are serialized
+	var mu sync.Mutex
+	completed := 0
+	outcomes := make([]traitOutcome, total)
+	forEachIndex(total, workers, func(i int) {
+		trait := traits[i]
+		mu.Lock()
 		traitSNPs := bulkData.TraitSNPs[trait]
-		if len(traitSNPs) == 0 {
-			logging.Warn("No SNPs found for trait %s, skipping", trait)
-			continue
-		}
+		mu.Unlock()
 
-		// Calculate PRS using pre-loaded data
-		prsResult, err := prs.CalculatePRS(traitSNPs)
-		if err != nil {
-			err = fmt.Errorf("failed to calculate PRS for trait %s: %w", trait, err)
-			pipelineErrors = append(pipelineErrors, err)
-			logging.Error(err.Error())
-			continue
-		}
-		prsResults[trait] = prsResult
-
-		// Get reference stats (from cache or computed)
-		var refStats *reference_stats.ReferenceStats
-		key := fmt.Sprintf("%s|%s|%s", ancestryCode, trait, trait)
-
-		if cachedStats, found := bulkData.CachedStats[key]; found {
-			refStats = cachedStats
-		} else if computedStats, found := bulkData.ComputedStats[trait]; found {
-			refStats = computedStats
-
-			// Prepare cache entry for bulk storage
-			cacheEntries = append(cacheEntries, reference_cache.CacheEntry{
-				Request: reference_cache.StatsRequest{
-					Ancestry: ancestryCode,
-					Trait:    trait,
-					ModelID:  trait,
-				},
-				Stats: refStats,
-			})
-		} else {
-			logging.Warn("No reference stats available for trait %s, skipping processing. Error likely occurred in Phase 2.", trait)
-			continue
-		}
-
-		// Normalize PRS using pre-loaded reference stats
-		if refStats != nil {
-			modelRef := model.ReferenceStats{
-				Mean:     refStats.Mean,
-				Std:      refStats.Std,
-				Min:      refStats.Min,
-				Max:      refStats.Max,
-				Ancestry: refStats.Ancestry,
-				Trait:    refStats.Trait,
-				Model:    refStats.Model,
-			}
+		outcomes[i] = processTrait(requirements, bulkData, trait, traitSNPs, threshold)
 
-			norm, err := prs.NormalizePRS(prsResult, modelRef)
-			if err != nil {
-				err = fmt.Errorf("failed to normalize PRS for trait %s: %w", trait, err)
-				pipelineErrors = append(pipelineErrors, err)
-				logging.Error(err.Error())
-				continue
-			}
-			normPRSs[trait] = norm
+		mu.Lock()
+		defer mu.Unlock()
+		if requirements.Streaming {
+			delete(bulkData.TraitSNPs, trait)
 		}
+		completed++
+		b.Publish(events.Event{Type: events.TraitScored, Trait: trait, Completed: completed, Total: total})
+	})
 
-		// Generate trait summary only if normalization was successful
-		if norm, ok := normPRSs[trait]; ok {
-			ts := output.GenerateTraitSummaries(traitSNPs, norm)
-			summaries = append(summaries, ts...)
+	normPRSs := make(map[string]prs.NormalizedPRS)
+	prsResults := make(map[string]prs.PRSResult)
+	summaries := make([]output.TraitSummary, 0, total)
+	cacheEntries := make([]reference_cache.CacheEntry, 0)
+	pipelineErrors := make([]error, 0)
+	for i, o := range outcomes {
+		trait := traits[i]
+		if o.prs != nil {
+			prsResults[trait] = *o.prs
+		}
+		if o.norm != nil {
+			normPRSs[trait] = *o.norm
+		}
+		summaries = append(summaries, o.summaries...)
+		if o.cacheEntry != nil {
+			cacheEntries = append(cacheEntries, *o.cacheEntry)
+		}
+		if o.err != nil {
+			pipelineErrors = append(pipelineErrors, o.err)
 		}
 	}
 
+	// Stats computed for other ancestries are stored with the run's own
+	cacheEntries = append(cacheEntries, bulkData.AncestryEntries...)
+
 	allErrors := append(bulkData.Errors, pipelineErrors...)
 
 	return &ProcessingResults{
@@ -399,6 +923,119 @@ func processAllTraitsInMemory(requirements *PipelineRequirements, bulkData *Bulk
 	}, nil
 }
 
+// traitOutcome is what scoring one trait in Phase 3 contributes to the results. Nil and
+// empty fields contribute nothing.
+type traitOutcome struct {
+	prs        *prs.PRSResult
+	norm       *prs.NormalizedPRS
+	summaries  []output.TraitSummary
+	cacheEntry *reference_cache.CacheEntry
+	err        error
+}
+
+// processTrait scores one trait against its pre-loaded reference stats. It only reads
+// requirements and bulkData, so traits can be processed concurrently.
+func processTrait(requirements *PipelineRequirements, bulkData *BulkDataContext, trait string, traitSNPs []model.AnnotatedSNP, threshold float64) traitOutcome {
+	var o traitOutcome
+	logging.Info("Processing trait: %s", trait)
+
+	if len(traitSNPs) == 0 {
+		logging.Warn("No SNPs found for trait %s, skipping", trait)
+		return o
+	}
+
+	present := make(map[string]struct{}, len(traitSNPs))
+	for _, snp := range traitSNPs {
+		present[snp.RSID] = struct{}{}
+	}
+	coverage := traitCoverage{Present: len(present), Expected: requirements.ExpectedSNPs[trait], Excluded: requirements.ExcludedSNPs[trait], Threshold: threshold}
+	if !coverage.Sufficient() {
+		logging.Warn("Insufficient SNP coverage for trait %s: %d/%d variants (%.1f%%) below threshold %.1f%%, skipping",
+			trait, coverage.Present, coverage.Expected, coverage.Fraction()*100, threshold*100)
+		ts := output.TraitSummary{Trait: trait, Status: output.StatusInsufficientCoverage}
+		coverage.annotate(&ts)
+		o.summaries = []output.TraitSummary{ts}
+		return o
+	}
+
+	// Calculate PRS using pre-loaded data
+	prsResult, err := prs.CalculatePRS(traitSNPs)
+	if err != nil {
+		o.err = fmt.Errorf("failed to calculate PRS for trait %s: %w", trait, err)
+		logging.Error("%v", o.err)
+		return o
+	}
+	o.prs = &prsResult
+
+	// Get reference stats (from cache or computed)
+	var refStats *reference_stats.ReferenceStats
+	refAncestry := requirements.referenceAncestry(trait)
+	ancestryCode := refAncestry.Code()
+	key := fmt.Sprintf("%s|%s|%s", ancestryCode, trait, requirements.modelID(trait))
+
+	if cachedStats, found := bulkData.CachedStats[key]; found {
+		refStats = cachedStats
+	} else if computedStats, found := bulkData.ComputedStats[trait]; found {
+		refStats = computedStats
+
+		// Prepare cache entry for bulk storage
+		o.cacheEntry = &reference_cache.CacheEntry{
+			Request: reference_cache.StatsRequest{
+				Ancestry: ancestryCode,
+				Trait:    trait,
+				ModelID:  requirements.modelID(trait),
+			},
+			Stats: refStats,
+		}
+	} else {
+		logging.Warn("No reference stats available for trait %s, skipping processing. Error likely occurred in Phase 2.", trait)
+		return o
+	}
+	if refStats == nil {
+		return o
+	}
+
+	// Normalize PRS using pre-loaded reference stats
+	modelRef := model.ReferenceStats{
+		Mean:     refStats.Mean,
+		Std:      refStats.Std,
+		Min:      refStats.Min,
+		Max:      refStats.Max,
+		Ancestry: refStats.Ancestry,
+		Trait:    refStats.Trait,
+		Model:    refStats.Model,
+
+		Quantiles: refStats.Quantiles,
+	}
+	modelRef = requirements.Haplotypes.AdjustStats(trait, modelRef)
+
+	norm, err := prs.NormalizePRS(prsResult, modelRef)
+	if err != nil {
+		o.err = fmt.Errorf("failed to normalize PRS for trait %s: %w", trait, err)
+		logging.Error("%v", o.err)
+		return o
+	}
+	if scale, ok := requirements.ScoreScales[strings.ToLower(reference.ModelFor(requirements.TraitModels, trait))]; ok {
+		scaled := scale.Apply(norm.RawScore)
+		norm.Scaled = &scaled
+	}
+	o.norm = &norm
+
+	// Generate trait summary only if normalization was successful
+	ts := output.GenerateTraitSummaries(traitSNPs, norm)
+	_, overridden := requirements.TraitAncestry[trait]
+	for i := range ts {
+		coverage.annotate(&ts[i])
+		ts[i].VarianceContributors = refStats.Contributors
+		if overridden {
+			ts[i].ReferenceAncestry = ancestryCode
+			ts[i].AncestryCaveat = true
+		}
+	}
+	o.summaries = ts
+	return o
+}
+
 // storeBulkResults executes all storage operations in bulk
 func storeBulkResults(ctx context.Context, results *ProcessingResults, refService *reference.ReferenceService) error {
 	if len(results.CacheEntries) == 0 {
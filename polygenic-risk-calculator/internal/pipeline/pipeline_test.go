@@ -3,6 +3,8 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -13,9 +15,14 @@ import (
 	"phite.io/polygenic-risk-calculator/internal/db"
 	dbinterface "phite.io/polygenic-risk-calculator/internal/db/interface"
 	"phite.io/polygenic-risk-calculator/internal/db/testutils"
+	"phite.io/polygenic-risk-calculator/internal/events"
+	"phite.io/polygenic-risk-calculator/internal/genotype"
 	"phite.io/polygenic-risk-calculator/internal/gwas"
+	"phite.io/polygenic-risk-calculator/internal/limits"
 	"phite.io/polygenic-risk-calculator/internal/model"
 	"phite.io/polygenic-risk-calculator/internal/output"
+	"phite.io/polygenic-risk-calculator/internal/progress"
+	"phite.io/polygenic-risk-calculator/internal/prs"
 	"phite.io/polygenic-risk-calculator/internal/reference"
 	reference_cache "phite.io/polygenic-risk-calculator/internal/reference/cache"
 	reference_stats "phite.io/polygenic-risk-calculator/internal/reference/stats"
@@ -245,6 +252,30 @@ func TestRun_ErrorOnMissingRepository(t *testing.T) {
 	}
 }
 
+func TestRun_ErrorOnInputLimits(t *testing.T) {
+	setupTestConfig(t)
+	input := PipelineInput{
+		GenotypeFile:   "testdata/genotype_single_trait.txt",
+		SNPs:           []string{"rs1", "rs2"},
+		ReferenceTable: "reference_stats",
+		OutputFormat:   "json",
+	}
+
+	config.Set(limits.MaxSNPsKey, 1)
+	_, err := Run(input)
+	config.Set(limits.MaxSNPsKey, 0)
+	assert.ErrorIs(t, err, limits.ErrLimitExceeded)
+	assert.ErrorContains(t, err, limits.MaxSNPsKey)
+
+	config.Set(limits.MaxGenotypeFileMBKey, 1)
+	defer config.Set(limits.MaxGenotypeFileMBKey, 0)
+	input.GenotypeFile = filepath.Join(t.TempDir(), "large.txt")
+	require.NoError(t, os.WriteFile(input.GenotypeFile, make([]byte, 1<<20+1), 0644))
+	_, err = Run(input)
+	assert.ErrorIs(t, err, limits.ErrLimitExceeded)
+	assert.ErrorContains(t, err, limits.MaxGenotypeFileMBKey)
+}
+
 func TestRun_ErrorOnInvalidGenotypeFile(t *testing.T) {
 	setupTestConfig(t)
 
@@ -990,6 +1021,165 @@ func TestAnalyzeAllRequirements_DuplicateTraits(t *testing.T) {
 	assert.Equal(t, len(requirements.TraitSet), len(requirements.CacheKeys))
 }
 
+type fakeGWASFetcher map[string]model.GWASSNPRecord
+
+func (f fakeGWASFetcher) FetchGWASRecords(ctx context.Context, rsids []string, anc ...*ancestry.Ancestry) (map[string]model.GWASSNPRecord, error) {
+	records := make(map[string]model.GWASSNPRecord)
+	for _, rsid := range rsids {
+		if rec, ok := f[rsid]; ok {
+			records[rsid] = rec
+		}
+	}
+	return records, nil
+}
+
+func TestAnalyzeAllRequirements_InjectedSources(t *testing.T) {
+	setupTestConfig(t)
+	// Neither the GWAS database nor the genotype file is read
+	config.Set("gwas_db_path", "/invalid/path/that/does/not/exist.duckdb")
+	defer config.Set("gwas_db_path", "testdata/gwas.duckdb")
+
+	var parsed genotype.ParseGenotypeDataInput
+	input := PipelineInput{
+		GenotypeFile:   "no-such-file.txt",
+		SNPs:           []string{"rs1", "rs2"},
+		ReferenceTable: "reference_stats",
+		GWAS: fakeGWASFetcher{
+			"rs1": {RSID: "rs1", RiskAllele: "A", Beta: 0.2, Trait: "height"},
+			"rs2": {RSID: "rs2", RiskAllele: "T", Beta: -0.1, Trait: "height"},
+		},
+		Genotypes: genotype.ParserFunc(func(in genotype.ParseGenotypeDataInput) (genotype.ParseGenotypeDataOutput, error) {
+			parsed = in
+			return genotype.ParseGenotypeDataOutput{
+				ValidatedSNPs: []model.ValidatedSNP{{RSID: "rs1", Genotype: "AG", FoundInGWAS: true}},
+				SNPsMissing:   []string{"rs2"},
+			}, nil
+		}),
+	}
+
+	requirements, genoOut, annotated, err := analyzeAllRequirements(context.Background(), input)
+	require.NoError(t, err)
+
+	assert.Equal(t, "no-such-file.txt", parsed.GenotypeFilePath)
+	assert.Len(t, parsed.GWASData, 2)
+	assert.Equal(t, []string{"rs2"}, genoOut.SNPsMissing)
+	assert.Contains(t, requirements.TraitSet, "height")
+	assert.Equal(t, 2, requirements.ExpectedSNPs["height"])
+	require.Len(t, annotated.AnnotatedSNPs, 1)
+	assert.Equal(t, 1, annotated.AnnotatedSNPs[0].Dosage)
+}
+
+func TestAnalyzeAllRequirements_TraitModelMap(t *testing.T) {
+	setupTestConfig(t)
+	path := filepath.Join(t.TempDir(), "trait_models.tsv")
+	require.NoError(t, os.WriteFile(path, []byte("height\tPGS000297\n"), 0o644))
+	config.Set(reference.TraitModelMapKey, path)
+	defer config.Set(reference.TraitModelMapKey, "")
+
+	input := PipelineInput{
+		GenotypeFile:   "no-such-file.txt",
+		SNPs:           []string{"rs1", "rs2"},
+		ReferenceTable: "reference_stats",
+		GWAS: fakeGWASFetcher{
+			"rs1": {RSID: "rs1", RiskAllele: "A", Beta: 0.2, Trait: "height"},
+			"rs2": {RSID: "rs2", RiskAllele: "T", Beta: -0.1, Trait: "bmi"},
+		},
+		Genotypes: genotype.ParserFunc(func(in genotype.ParseGenotypeDataInput) (genotype.ParseGenotypeDataOutput, error) {
+			return genotype.ParseGenotypeDataOutput{ValidatedSNPs: []model.ValidatedSNP{
+				{RSID: "rs1", Genotype: "AG", FoundInGWAS: true},
+				{RSID: "rs2", Genotype: "TT", FoundInGWAS: true},
+			}}, nil
+		}),
+	}
+
+	requirements, _, _, err := analyzeAllRequirements(context.Background(), input)
+	require.NoError(t, err)
+	assert.Equal(t, "PGS000297", requirements.TraitModels["height"])
+	models := map[string]string{}
+	for _, k := range requirements.CacheKeys {
+		models[k.Trait] = k.ModelID
+	}
+	assert.Equal(t, map[string]string{"height": "PGS000297", "bmi": "bmi"}, models)
+}
+
+func TestPipeline_DeterministicOutput(t *testing.T) {
+	setupTestConfig(t)
+
+	traits := []string{"height", "bmi", "ldl", "hdl", "t2d", "cad"}
+	alleles := []string{"A", "C", "G", "T"}
+	records := make(fakeGWASFetcher)
+	var snps []string
+	genotypes := "rsid\tchrom\tpos\tgenotype\n"
+	for i := 0; i < 24; i++ {
+		rsid := fmt.Sprintf("rs%d", i+1)
+		snps = append(snps, rsid)
+		records[rsid] = model.GWASSNPRecord{RSID: rsid, RiskAllele: alleles[i%4], Beta: float64(i%5)*0.1 + 1e-9*float64(i), Trait: traits[i%len(traits)]}
+		genotypes += fmt.Sprintf("%s\t1\t%d\t%s%s\n", rsid, 1000*(i+1), alleles[i%4], alleles[(i+1)%4])
+	}
+	snps = append(snps, "rs_missing1", "rs_missing2")
+	genotypeFile := filepath.Join(t.TempDir(), "genotype.txt")
+	require.NoError(t, os.WriteFile(genotypeFile, []byte(genotypes), 0o644))
+
+	render := func(format string) string {
+		requirements, genoOut, annotated, err := analyzeAllRequirements(context.Background(), PipelineInput{
+			GenotypeFile:   genotypeFile,
+			SNPs:           snps,
+			ReferenceTable: "reference_stats",
+			GWAS:           records,
+		})
+		require.NoError(t, err)
+
+		bulkData := &BulkDataContext{
+			CachedStats:   make(map[string]*reference_stats.ReferenceStats),
+			ComputedStats: make(map[string]*reference_stats.ReferenceStats),
+			TraitSNPs:     make(map[string][]model.AnnotatedSNP),
+		}
+		for _, snp := range annotated.AnnotatedSNPs {
+			bulkData.TraitSNPs[snp.Trait] = append(bulkData.TraitSNPs[snp.Trait], snp)
+		}
+		for trait := range requirements.TraitSet {
+			// Computed rather than cached stats, so every trait also yields a cache entry
+			bulkData.ComputedStats[trait] = &reference_stats.ReferenceStats{Mean: 0.1, Std: 0.5, Ancestry: requirements.AncestryObj.Code(), Trait: trait}
+		}
+		results, err := processAllTraitsInMemory(requirements, bulkData)
+		require.NoError(t, err)
+		require.Len(t, results.TraitSummaries, len(traits))
+
+		out := PipelineOutput{
+			TraitSummaries: results.TraitSummaries,
+			NormalizedPRS:  results.NormalizedPRS,
+			PRSResults:     results.PRSResults,
+			SNPSMissing:    genoOut.SNPsMissing,
+			ExcludedSNPs:   annotated.ExcludedSNPs,
+		}
+		out.TraitGroups = requirements.Arrangement.Arrange(out.TraitSummaries)
+		norm, prsResult := out.Primary()
+		var buf strings.Builder
+		require.NoError(t, output.Write(output.OutputResult{
+			NormalizedPRS:  norm,
+			PRSResult:      prsResult,
+			TraitSummaries: out.TraitSummaries,
+			TraitGroups:    out.TraitGroups,
+			SNPSMissing:    out.SNPSMissing,
+			ExcludedSNPs:   out.ExcludedSNPs,
+		}, format, "", &buf))
+
+		// Cache entries are stored in trait order
+		var stored []string
+		for _, e := range results.CacheEntries {
+			stored = append(stored, e.Request.Trait)
+		}
+		return buf.String() + strings.Join(stored, ",")
+	}
+
+	for _, format := range []string{"json", "csv"} {
+		first := render(format)
+		for i := 0; i < 10; i++ {
+			require.Equal(t, first, render(format), "%s output differs between identical runs", format)
+		}
+	}
+}
+
 // ==================== PHASE 2: BULK DATA RETRIEVAL TESTS ====================
 
 func TestRetrieveAllDataBulk_FullCacheHit(t *testing.T) {
@@ -1927,3 +2117,448 @@ func TestProcessAllTraitsInMemory_SkippedTraitsWithNoSNPs(t *testing.T) {
 	// Should have trait summaries for processed traits
 	assert.GreaterOrEqual(t, len(results.TraitSummaries), 2)
 }
+
+func TestProcessAllTraitsInMemory_ReportsTraitProgress(t *testing.T) {
+	ancestryObj, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+
+	requirements := &PipelineRequirements{
+		TraitSet: map[string]struct{}{
+			"height": {},
+			"empty":  {},
+		},
+		AncestryObj: ancestryObj,
+	}
+
+	bulkData := &BulkDataContext{
+		CachedStats: map[string]*reference_stats.ReferenceStats{
+			fmt.Sprintf("%s|height|height", ancestryObj.Code()): {Mean: 1.0, Std: 1.0, Min: -3.0, Max: 3.0},
+		},
+		ComputedStats: make(map[string]*reference_stats.ReferenceStats),
+		TraitSNPs: map[string][]model.AnnotatedSNP{
+			"height": {
+				{RSID: "rs1", Trait: "height", Beta: 0.5, RiskAllele: "A", Genotype: "AG", Dosage: 1},
+			},
+			"empty": {},
+		},
+	}
+
+	var reported []progress.Event
+	reporter := progress.ReporterFunc(func(ev progress.Event) { reported = append(reported, ev) })
+	bus := events.New()
+	bus.Subscribe(events.Progress(reporter))
+
+	_, err = processAllTraitsInMemory(requirements, bulkData, bus)
+	require.NoError(t, err)
+
+	// Skipped traits still advance the count so consumers can reach 100%.
+	require.Len(t, reported, 2)
+	traits := map[string]bool{}
+	for i, ev := range reported {
+		assert.Equal(t, progress.TraitCompleted, ev.Type)
+		assert.Equal(t, i+1, ev.Completed)
+		assert.Equal(t, 2, ev.Total)
+		traits[ev.Trait] = true
+	}
+	assert.True(t, traits["height"])
+	assert.True(t, traits["empty"])
+}
+
+func TestProcessAllTraitsInMemory_ConcurrentMatchesSerial(t *testing.T) {
+	ancestryObj, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+
+	newInputs := func() (*PipelineRequirements, *BulkDataContext) {
+		requirements := &PipelineRequirements{TraitSet: map[string]struct{}{}, AncestryObj: ancestryObj, Streaming: true}
+		bulkData := &BulkDataContext{
+			CachedStats:   make(map[string]*reference_stats.ReferenceStats),
+			ComputedStats: make(map[string]*reference_stats.ReferenceStats),
+			TraitSNPs:     make(map[string][]model.AnnotatedSNP),
+		}
+		for i := 0; i < 40; i++ {
+			trait := fmt.Sprintf("trait%02d", i)
+			requirements.TraitSet[trait] = struct{}{}
+			bulkData.TraitSNPs[trait] = []model.AnnotatedSNP{
+				{RSID: fmt.Sprintf("rs%d", i), Trait: trait, Beta: 0.1 * float64(i+1), RiskAllele: "A", Genotype: "AG", Dosage: 1},
+			}
+			stats := &reference_stats.ReferenceStats{Mean: 0.1, Std: 1.0, Min: -3.0, Max: 3.0}
+			if i%2 == 0 {
+				bulkData.CachedStats[fmt.Sprintf("%s|%s|%s", ancestryObj.Code(), trait, trait)] = stats
+			} else if i%5 != 0 {
+				bulkData.ComputedStats[trait] = stats
+			}
+		}
+		return requirements, bulkData
+	}
+
+	config.Set(TraitConcurrencyKey, 1)
+	serial, err := processAllTraitsInMemory(newInputs())
+	require.NoError(t, err)
+
+	config.Set(TraitConcurrencyKey, 8)
+	defer config.Set(TraitConcurrencyKey, 0)
+	requirements, bulkData := newInputs()
+	var completed []int
+	bus := events.New()
+	bus.Subscribe(func(ev events.Event) { completed = append(completed, ev.Completed) }, events.TraitScored)
+	parallel, err := processAllTraitsInMemory(requirements, bulkData, bus)
+	require.NoError(t, err)
+
+	assert.Equal(t, serial, parallel)
+	assert.Len(t, parallel.PRSResults, 40)
+	assert.Len(t, parallel.NormalizedPRS, 36, "traits without reference stats are not normalized")
+	assert.Empty(t, bulkData.TraitSNPs, "streaming releases every trait's SNPs")
+	require.Len(t, completed, 40)
+	for i, n := range completed {
+		assert.Equal(t, i+1, n)
+	}
+}
+
+func TestRun_ReportsFailureToProgress(t *testing.T) {
+	var events []progress.Event
+	reporter := progress.ReporterFunc(func(ev progress.Event) { events = append(events, ev) })
+
+	_, err := Run(PipelineInput{Progress: reporter})
+	require.Error(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, progress.RunFailed, events[0].Type)
+	assert.Contains(t, events[0].Error, "missing required input")
+}
+
+func TestProcessAllTraitsInMemory_InsufficientCoverage(t *testing.T) {
+	config.Set(MinSNPCoverageKey, 0.8)
+	defer config.Set(MinSNPCoverageKey, 0.0)
+
+	ancestryObj, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+
+	requirements := &PipelineRequirements{
+		TraitSet:     map[string]struct{}{"height": {}, "bmi": {}},
+		ExpectedSNPs: map[string]int{"height": 2, "bmi": 4},
+		ExcludedSNPs: map[string]int{"bmi": 2},
+		AncestryObj:  ancestryObj,
+	}
+	stats := &reference_stats.ReferenceStats{Mean: 0.5, Std: 1.0, Min: -3.0, Max: 3.0}
+	bulkData := &BulkDataContext{
+		CachedStats: map[string]*reference_stats.ReferenceStats{
+			fmt.Sprintf("%s|height|height", ancestryObj.Code()): stats,
+			fmt.Sprintf("%s|bmi|bmi", ancestryObj.Code()):       stats,
+		},
+		ComputedStats: make(map[string]*reference_stats.ReferenceStats),
+		TraitSNPs: map[string][]model.AnnotatedSNP{
+			"height": {
+				{RSID: "rs1", Trait: "height", Beta: 0.5, RiskAllele: "A", Genotype: "AG", Dosage: 1},
+				{RSID: "rs2", Trait: "height", Beta: 0.3, RiskAllele: "T", Genotype: "TT", Dosage: 2},
+			},
+			"bmi": {
+				{RSID: "rs3", Trait: "bmi", Beta: 0.2, RiskAllele: "G", Genotype: "AG", Dosage: 1},
+			},
+		},
+	}
+
+	results, err := processAllTraitsInMemory(requirements, bulkData)
+	require.NoError(t, err)
+
+	assert.Contains(t, results.NormalizedPRS, "height")
+	assert.NotContains(t, results.NormalizedPRS, "bmi")
+	assert.NotContains(t, results.PRSResults, "bmi")
+
+	byTrait := map[string]output.TraitSummary{}
+	for _, ts := range results.TraitSummaries {
+		byTrait[ts.Trait] = ts
+	}
+	require.Contains(t, byTrait, "bmi")
+	assert.Equal(t, output.StatusInsufficientCoverage, byTrait["bmi"].Status)
+	assert.Equal(t, 1, byTrait["bmi"].SNPsPresent)
+	assert.Equal(t, 4, byTrait["bmi"].SNPsExpected)
+	assert.InDelta(t, 0.25, byTrait["bmi"].Coverage, 1e-9)
+	assert.Equal(t, 0.8, byTrait["bmi"].MinCoverage)
+	assert.Equal(t, 2, byTrait["bmi"].SNPsExcluded)
+
+	require.Contains(t, byTrait, "height")
+	assert.Empty(t, byTrait["height"].Status)
+	assert.Equal(t, 2, byTrait["height"].SNPsPresent)
+	assert.InDelta(t, 1.0, byTrait["height"].Coverage, 1e-9)
+	assert.Zero(t, byTrait["height"].SNPsExcluded)
+}
+
+func TestCountExpectedSNPs(t *testing.T) {
+	counts := countExpectedSNPs(map[string]model.GWASSNPRecord{
+		"rs1": {RSID: "rs1", Trait: "height"},
+		"rs2": {RSID: "rs2", Trait: "height"},
+		"rs3": {RSID: "rs3", Trait: "bmi"},
+		"rs4": {RSID: "rs4"},
+	})
+	assert.Equal(t, map[string]int{"height": 2, "bmi": 1}, counts)
+}
+
+func TestCountExcludedSNPs(t *testing.T) {
+	counts := countExcludedSNPs([]model.ExcludedSNP{
+		{RSID: "rs1", Trait: "height", Reason: gwas.ReasonAmbiguousStrand},
+		{RSID: "rs2", Trait: "height", Reason: gwas.ReasonAlleleMismatch},
+		{RSID: "rs3", Trait: "bmi", Reason: gwas.ReasonAmbiguousStrand},
+	})
+	assert.Equal(t, map[string]int{"height": 2, "bmi": 1}, counts)
+}
+
+func TestProcessAllTraitsInMemory_AppliesScoreScale(t *testing.T) {
+	ancestryObj, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+
+	requirements := &PipelineRequirements{
+		TraitSet:    map[string]struct{}{"Systolic_BP": {}},
+		AncestryObj: ancestryObj,
+		ScoreScales: map[string]prs.ScoreScale{"systolic_bp": {Offset: 120, Scale: 4, Unit: "mmHg"}},
+	}
+	bulkData := &BulkDataContext{
+		CachedStats: map[string]*reference_stats.ReferenceStats{
+			fmt.Sprintf("%s|Systolic_BP|Systolic_BP", ancestryObj.Code()): {Mean: 0.0, Std: 1.0, Min: -3.0, Max: 3.0},
+		},
+		ComputedStats: make(map[string]*reference_stats.ReferenceStats),
+		TraitSNPs: map[string][]model.AnnotatedSNP{
+			"Systolic_BP": {{RSID: "rs1", Trait: "Systolic_BP", Beta: 0.5, RiskAllele: "A", Genotype: "AA", Dosage: 2}},
+		},
+	}
+
+	results, err := processAllTraitsInMemory(requirements, bulkData)
+	require.NoError(t, err)
+	norm := results.NormalizedPRS["Systolic_BP"]
+	require.NotNil(t, norm.Scaled)
+	assert.InDelta(t, 124.0, norm.Scaled.Value, 1e-9)
+	assert.Equal(t, "mmHg", norm.Scaled.Unit)
+	assert.InDelta(t, 1.0, norm.ZScore, 1e-9, "z-score is unaffected by scaling")
+}
+
+func TestStreamReferenceStats_AttemptsEveryTrait(t *testing.T) {
+	setupTestConfig(t)
+	refService, _, _, _ := setupMockPipeline(t)
+
+	// None of the traits has a model, so every one fails
+	ancestryObj, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+	var requests []reference.ReferenceStatsRequest
+	for i := 0; i < 15; i++ {
+		requests = append(requests, reference.ReferenceStatsRequest{Ancestry: ancestryObj, Trait: fmt.Sprintf("missing%02d", i)})
+	}
+
+	stats, errs := streamReferenceStats(context.Background(), requests, refService)
+	assert.Empty(t, stats)
+	require.Len(t, errs, 15, "failures do not stop the remaining traits")
+	assert.ErrorContains(t, errs[14], "missing14")
+}
+
+func TestProcessAllTraitsInMemory_StreamingReleasesTraitSNPs(t *testing.T) {
+	ancestryObj, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+
+	requirements := &PipelineRequirements{
+		TraitSet:    map[string]struct{}{"height": {}, "bmi": {}},
+		AncestryObj: ancestryObj,
+		Streaming:   true,
+	}
+	stats := &reference_stats.ReferenceStats{Mean: 0.0, Std: 1.0, Min: -3.0, Max: 3.0}
+	bulkData := &BulkDataContext{
+		CachedStats: map[string]*reference_stats.ReferenceStats{
+			fmt.Sprintf("%s|height|height", ancestryObj.Code()): stats,
+			fmt.Sprintf("%s|bmi|bmi", ancestryObj.Code()):       stats,
+		},
+		ComputedStats: make(map[string]*reference_stats.ReferenceStats),
+		TraitSNPs: map[string][]model.AnnotatedSNP{
+			"height": {{RSID: "rs1", Trait: "height", Beta: 0.5, RiskAllele: "A", Genotype: "AA", Dosage: 2}},
+			"bmi":    {{RSID: "rs2", Trait: "bmi", Beta: 0.2, RiskAllele: "C", Genotype: "CT", Dosage: 1}},
+		},
+	}
+
+	results, err := processAllTraitsInMemory(requirements, bulkData)
+	require.NoError(t, err)
+	assert.Len(t, results.NormalizedPRS, 2, "streaming produces the same results")
+	assert.Empty(t, bulkData.TraitSNPs, "each trait's SNPs are released once processed")
+}
+
+func TestProcessAllTraitsInMemory_AncestryOverride(t *testing.T) {
+	userAncestry, err := ancestry.New("AFR", "")
+	require.NoError(t, err)
+	eur, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+
+	requirements := &PipelineRequirements{
+		TraitSet:      map[string]struct{}{"height": {}, "bmi": {}},
+		AncestryObj:   userAncestry,
+		TraitAncestry: map[string]*ancestry.Ancestry{"height": eur},
+	}
+	bulkData := &BulkDataContext{
+		CachedStats: map[string]*reference_stats.ReferenceStats{
+			"EUR|height|height": {Mean: 0.0, Std: 1.0, Min: -3.0, Max: 3.0},
+			"AFR|bmi|bmi":       {Mean: 0.0, Std: 1.0, Min: -3.0, Max: 3.0},
+		},
+		ComputedStats: make(map[string]*reference_stats.ReferenceStats),
+		TraitSNPs: map[string][]model.AnnotatedSNP{
+			"height": {{RSID: "rs1", Trait: "height", Beta: 0.5, RiskAllele: "A", Genotype: "AA", Dosage: 2}},
+			"bmi":    {{RSID: "rs2", Trait: "bmi", Beta: 0.2, RiskAllele: "C", Genotype: "CT", Dosage: 1}},
+		},
+	}
+
+	results, err := processAllTraitsInMemory(requirements, bulkData)
+	require.NoError(t, err)
+	require.Len(t, results.NormalizedPRS, 2, "each trait finds stats under its own reference ancestry")
+	for _, ts := range results.TraitSummaries {
+		if ts.Trait == "height" {
+			assert.Equal(t, "EUR", ts.ReferenceAncestry)
+			assert.True(t, ts.AncestryCaveat)
+		} else {
+			assert.Empty(t, ts.ReferenceAncestry)
+			assert.False(t, ts.AncestryCaveat)
+		}
+	}
+}
+
+func TestMemorySnapshot_SoftLimit(t *testing.T) {
+	config.Set(SoftMemoryLimitMBKey, 0)
+	assert.Zero(t, softMemoryLimit())
+	config.Set(SoftMemoryLimitMBKey, 512)
+	defer config.Set(SoftMemoryLimitMBKey, 0)
+	assert.Equal(t, uint64(512<<20), softMemoryLimit())
+
+	snap := takeMemorySnapshot("test")
+	assert.Equal(t, "test", snap.Phase)
+	assert.NotZero(t, snap.HeapAlloc)
+	assert.False(t, snap.exceeds(0), "a zero limit is disabled")
+	assert.True(t, snap.exceeds(1))
+	assert.False(t, snap.exceeds(snap.HeapAlloc))
+}
+
+func TestTraitSource(t *testing.T) {
+	prev := config.GetString(TraitSourceKey)
+	defer config.Set(TraitSourceKey, prev)
+
+	config.Set(TraitSourceKey, "")
+	source, err := traitSource()
+	require.NoError(t, err)
+	assert.Equal(t, TraitSourceGWAS, source)
+
+	config.Set(TraitSourceKey, TraitSourceModel)
+	source, err = traitSource()
+	require.NoError(t, err)
+	assert.Equal(t, TraitSourceModel, source)
+
+	config.Set(TraitSourceKey, "annotations")
+	_, err = traitSource()
+	assert.Error(t, err)
+
+	// Model discovery needs the model table.
+	config.Set(TraitSourceKey, TraitSourceModel)
+	_, err = fetchTraitRecords(context.Background(), []string{"rs1"}, nil, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestTraitRecords_CountModelVariants(t *testing.T) {
+	setupTestConfig(t)
+	modelMock := testutils.NewMockRepository()
+	modelMock.QueryFunc = func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+		assert.Contains(t, query, "GROUP BY")
+		assert.ElementsMatch(t, []interface{}{"Height", "PGS000001", "Unmodelled"}, args)
+		return []map[string]interface{}{
+			{"trait": "Height", "variant_count": int64(120)},
+			{"trait": "PGS000001", "variant_count": int64(50)},
+		}, nil
+	}
+	cache, err := reference_cache.NewRepositoryCache(testutils.NewMockRepository())
+	require.NoError(t, err)
+	rs, err := reference.NewReferenceService(testutils.NewMockRepository(), modelMock, cache)
+	require.NoError(t, err)
+
+	gwasRecords := map[string]model.GWASSNPRecord{
+		"rs1": {RSID: "rs1", Trait: "Height"},
+		"rs2": {RSID: "rs2", Trait: "Height"},
+		"rs3": {RSID: "rs3", Trait: "LDL"},
+		"rs4": {RSID: "rs4", Trait: "Unmodelled"},
+	}
+	records := traitRecords{byRSID: gwasRecords, all: gwas.MapToGWASList(gwasRecords), expected: countExpectedSNPs(gwasRecords)}
+	reference.AssignModels(records.all, map[string]string{"ldl": "PGS000001"})
+
+	// Coverage is measured against each trait's model, not the requested variants
+	require.NoError(t, records.countModelVariants(context.Background(), rs))
+	assert.Equal(t, map[string]int{"Height": 120, "LDL": 50, "Unmodelled": 1}, records.expected)
+}
+
+func TestAllAncestries_CacheKeysAndEntries(t *testing.T) {
+	eur, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+
+	keys := extraAncestryCacheKeys("height", "height", eur, ancestry.All())
+	assert.Len(t, keys, len(ancestry.All())-1, "every ancestry but the reference one")
+	for _, k := range keys {
+		assert.NotEqual(t, "EUR", k.Ancestry)
+		assert.Equal(t, "height", k.ModelID)
+	}
+
+	requirements := &PipelineRequirements{
+		TraitSet:    map[string]struct{}{"height": {}},
+		AncestryObj: eur,
+	}
+	stats := func(anc string) *reference_stats.ReferenceStats {
+		return &reference_stats.ReferenceStats{Mean: 0, Std: 1, Ancestry: anc, Trait: "height", Model: "height"}
+	}
+	computed := map[string]*reference_stats.ReferenceStats{
+		"EUR|height|height": stats("EUR"),
+		"AFR|height|height": stats("AFR"),
+		"EAS|height|height": stats("EAS"),
+	}
+	cached := map[string]*reference_stats.ReferenceStats{"EAS|height|height": stats("EAS")}
+
+	entries := otherAncestryEntries(requirements, computed, cached)
+	require.Len(t, entries, 1, "the reference ancestry is stored by Phase 3 and cached pairs are skipped")
+	assert.Equal(t, "AFR", entries[0].Request.Ancestry)
+	assert.Equal(t, "height", entries[0].Request.Trait)
+}
+
+func TestExtraAncestries(t *testing.T) {
+	oldAll, oldExtra := config.GetBool(AllAncestriesKey), config.GetStringSlice(ExtraAncestriesKey)
+	defer config.Set(AllAncestriesKey, oldAll)
+	defer config.Set(ExtraAncestriesKey, oldExtra)
+	eur, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+
+	config.Set(AllAncestriesKey, false)
+	config.Set(ExtraAncestriesKey, []string{"eur_female", "AFR", "afr"})
+	extra, err := extraAncestries(eur)
+	require.NoError(t, err)
+	require.Len(t, extra, 2)
+	assert.Equal(t, "AFR", extra[0].Code())
+	assert.Equal(t, "EUR_FEMALE", extra[1].Code())
+
+	requirements := &PipelineRequirements{TraitSet: map[string]struct{}{"height": {}}, AncestryObj: eur, ExtraAncestries: extra}
+	var codes []string
+	for _, a := range requirements.withExtraAncestries() {
+		codes = append(codes, a.Code())
+	}
+	assert.Equal(t, []string{"AFR", "EUR", "EUR_FEMALE"}, codes, "the reference ancestry is computed with the extras")
+
+	cohort, err := ancestry.NewCustomCohort("island", "af")
+	require.NoError(t, err)
+	extra, err = extraAncestries(cohort)
+	require.NoError(t, err)
+	assert.Empty(t, extra)
+
+	config.Set(ExtraAncestriesKey, []string{"MARS"})
+	_, err = extraAncestries(eur)
+	assert.ErrorContains(t, err, ExtraAncestriesKey)
+}
+
+func TestTraitRecords_Without(t *testing.T) {
+	records := traitRecords{
+		all: []model.GWASSNPRecord{
+			{RSID: "rs429358", Trait: "alzheimers"},
+			{RSID: "rs7412", Trait: "alzheimers"},
+			{RSID: "rs7412", Trait: "ldl"},
+			{RSID: "rs693", Trait: "ldl"},
+		},
+		expected: map[string]int{"alzheimers": 2, "ldl": 2},
+	}
+
+	kept, dropped := records.without(func(trait string) bool { return trait == "alzheimers" })
+	assert.Len(t, kept.all, 2)
+	assert.Equal(t, "ldl", kept.byRSID["rs7412"].Trait)
+	assert.Equal(t, map[string]int{"ldl": 2}, kept.expected)
+	assert.Equal(t, map[string]bool{"rs429358": true}, dropped, "rs7412 is still needed for ldl")
+}
@@ -0,0 +1,128 @@
+// Package plot renders result charts as self-contained SVG, so reports can show them
+// without a plotting library or network access.
+package plot
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+)
+
+// Chart geometry, in SVG user units.
+const (
+	width     = 480.0
+	height    = 240.0
+	left      = 24.0 // plot area margins
+	right     = 24.0
+	top       = 40.0
+	bottom    = 44.0
+	zRange    = 4.0  // the x axis spans -zRange to +zRange standard deviations
+	curveStep = 0.05 // z step between curve points
+)
+
+// Distribution is a trait's reference score distribution with the user's score marked.
+// Reference stats are normal approximations, so the curve is drawn in standard deviations
+// from the reference mean and the score by its z-score.
+type Distribution struct {
+	Trait      string
+	Population string  // reference population code, e.g. "EUR"; empty omits it from the title
+	ZScore     float64 // user's score
+	Percentile float64 // user's percentile, 0-100
+}
+
+// SVG renders the distribution: the standard normal curve, the share of the population
+// scoring below the user shaded, and a marker at the user's score labeled with the
+// percentile. Scores beyond the axis are marked at its end.
+func (d Distribution) SVG() []byte {
+	var b bytes.Buffer
+	plotW, plotH := width-left-right, height-top-bottom
+	baseline := top + plotH
+	peak := normalPDF(0)
+	x := func(z float64) float64 { return left + (z+zRange)/(2*zRange)*plotW }
+	y := func(z float64) float64 { return baseline - normalPDF(z)/peak*plotH }
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %g %g" width="%g" height="%g" font-family="sans-serif" font-size="12">`+"\n", width, height, width, height)
+	title := d.Trait
+	if d.Population != "" {
+		title += " (" + d.Population + " reference)"
+	}
+	fmt.Fprintf(&b, "<title>%s</title>\n", escape(title))
+	fmt.Fprintf(&b, `<text x="%g" y="20" text-anchor="middle" font-size="14">%s</text>`+"\n", width/2, escape(title))
+
+	// Population scoring below the user
+	z := math.Max(-zRange, math.Min(zRange, d.ZScore))
+	fmt.Fprintf(&b, `<path d="M%.1f %.1f`, x(-zRange), baseline)
+	for t := -zRange; t < z; t += curveStep {
+		fmt.Fprintf(&b, " L%.1f %.1f", x(t), y(t))
+	}
+	fmt.Fprintf(&b, ` L%.1f %.1f L%.1f %.1f Z" fill="#c6dbef"/>`+"\n", x(z), y(z), x(z), baseline)
+
+	// Curve
+	fmt.Fprintf(&b, `<path d="M%.1f %.1f`, x(-zRange), y(-zRange))
+	for i := 1; i <= int(2*zRange/curveStep); i++ {
+		t := -zRange + float64(i)*curveStep
+		fmt.Fprintf(&b, " L%.1f %.1f", x(t), y(t))
+	}
+	fmt.Fprintf(&b, `" fill="none" stroke="#2171b5" stroke-width="2"/>`+"\n")
+
+	// Axis, ticked at whole standard deviations
+	fmt.Fprintf(&b, `<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="#444"/>`+"\n", left, baseline, width-right, baseline)
+	for t := -3; t <= 3; t++ {
+		label := fmt.Sprintf("%+dσ", t)
+		if t == 0 {
+			label = "mean"
+		}
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%g" x2="%.1f" y2="%g" stroke="#444"/>`, x(float64(t)), baseline, x(float64(t)), baseline+4)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%g" text-anchor="middle" fill="#444">%s</text>`+"\n", x(float64(t)), baseline+18, label)
+	}
+
+	// User's score
+	label := fmt.Sprintf("You: %s percentile", ordinal(d.Percentile))
+	if z != d.ZScore {
+		label += " (off chart)"
+	}
+	anchor := "middle"
+	switch {
+	case x(z) < left+plotW/4:
+		anchor = "start"
+	case x(z) > left+3*plotW/4:
+		anchor = "end"
+	}
+	fmt.Fprintf(&b, `<line x1="%.1f" y1="%g" x2="%.1f" y2="%g" stroke="#cb181d" stroke-width="2"/>`+"\n", x(z), top-6, x(z), baseline)
+	fmt.Fprintf(&b, `<text x="%.1f" y="%g" text-anchor="%s" fill="#cb181d">%s</text>`+"\n", x(z), top-10, anchor, escape(label))
+	fmt.Fprintf(&b, `<text x="%g" y="%g" text-anchor="middle" fill="#444" font-size="11">standard deviations from the reference mean</text>`+"\n", width/2, height-6)
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+// normalPDF is the standard normal density.
+func normalPDF(z float64) float64 {
+	return math.Exp(-z*z/2) / math.Sqrt(2*math.Pi)
+}
+
+// ordinal writes a percentile as a whole ordinal, e.g. "87th", keeping it within 1st to
+// 99th so a rounded extreme never reads as "0th" or "100th".
+func ordinal(percentile float64) string {
+	n := int(math.Round(percentile))
+	n = max(1, min(99, n))
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// escape escapes text for SVG character data.
+func escape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package plot
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wellFormed decodes svg to the end, failing on malformed XML.
+func wellFormed(t *testing.T, svg []byte) {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(svg))
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestDistribution_SVG(t *testing.T) {
+	svg := Distribution{Trait: "LDL <direct>", Population: "EUR", ZScore: 1.13, Percentile: 87.1}.SVG()
+	wellFormed(t, svg)
+	assert.Contains(t, string(svg), "<title>LDL &lt;direct&gt; (EUR reference)</title>")
+	assert.Contains(t, string(svg), "You: 87th percentile")
+	assert.NotContains(t, string(svg), "off chart")
+
+	svg = Distribution{Trait: "height", ZScore: -5.2, Percentile: 0.00001}.SVG()
+	wellFormed(t, svg)
+	assert.Contains(t, string(svg), "<title>height</title>")
+	assert.Contains(t, string(svg), "You: 1st percentile (off chart)")
+}
+
+func TestOrdinal(t *testing.T) {
+	for percentile, want := range map[float64]string{1: "1st", 2.2: "2nd", 23: "23rd", 11: "11th", 12.4: "12th", 13: "13th", 50: "50th", 99.7: "99th"} {
+		assert.Equal(t, want, ordinal(percentile), "%g", percentile)
+	}
+}
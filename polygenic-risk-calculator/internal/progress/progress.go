@@ -0,0 +1,193 @@
+// Package progress provides pipeline progress events and a per-job broker that
+// fans them out to live subscribers (e.g., Server-Sent Events streams).
+package progress
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType identifies the kind of progress event.
+type EventType string
+
+const (
+	PhaseStarted   EventType = "phase_started"
+	PhaseCompleted EventType = "phase_completed"
+	TraitCompleted EventType = "trait_completed"
+	RunCompleted   EventType = "run_completed"
+	RunFailed      EventType = "run_failed"
+)
+
+// Event is a single progress update emitted by the pipeline.
+type Event struct {
+	Type      EventType `json:"type"`
+	JobID     string    `json:"job_id,omitempty"`
+	Phase     int       `json:"phase,omitempty"`
+	PhaseName string    `json:"phase_name,omitempty"`
+	Trait     string    `json:"trait,omitempty"`
+	Completed int       `json:"completed,omitempty"`
+	Total     int       `json:"total,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Terminal reports whether the event ends a job's stream.
+func (e Event) Terminal() bool {
+	return e.Type == RunCompleted || e.Type == RunFailed
+}
+
+// Reporter receives progress events. Implementations must be safe for concurrent use.
+type Reporter interface {
+	Report(Event)
+}
+
+// ReporterFunc adapts a function to the Reporter interface.
+type ReporterFunc func(Event)
+
+// Report calls f(ev).
+func (f ReporterFunc) Report(ev Event) {
+	f(ev)
+}
+
+// Emit sends ev to r, stamping the time if unset. A nil reporter is a no-op.
+func Emit(r Reporter, ev Event) {
+	if r == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	r.Report(ev)
+}
+
+// subscriberBuffer is the per-subscriber channel capacity; slow subscribers drop events beyond it.
+const subscriberBuffer = 64
+
+// FinishedJobRetention is how long a finished job's history stays available to late
+// subscribers before the broker forgets it.
+const FinishedJobRetention = 15 * time.Minute
+
+// job holds the event history and live subscribers for one job.
+type job struct {
+	history     []Event
+	subscribers map[chan Event]struct{}
+	done        bool
+}
+
+// Broker fans progress events out to subscribers by job ID. It keeps each job's
+// history so subscribers that connect mid-run receive the events they missed. Only jobs
+// registered with Start are tracked, and each is forgotten FinishedJobRetention after it
+// finishes.
+type Broker struct {
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+// NewBroker creates an empty broker.
+func NewBroker() *Broker {
+	return &Broker{jobs: make(map[string]*job)}
+}
+
+// Start registers a job, so its events are kept and can be subscribed to.
+func (b *Broker) Start(jobID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.jobs[jobID]; !ok {
+		b.jobs[jobID] = &job{subscribers: make(map[chan Event]struct{})}
+	}
+}
+
+// Reporter returns a Reporter that publishes events for the given job.
+func (b *Broker) Reporter(jobID string) Reporter {
+	return ReporterFunc(func(ev Event) {
+		ev.JobID = jobID
+		b.Publish(ev)
+	})
+}
+
+// Publish records ev in the job's history and delivers it to all subscribers.
+// Terminal events close the job's subscriber channels. Events of jobs that were never
+// started, or were forgotten, are dropped.
+func (b *Broker) Publish(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	j, ok := b.jobs[ev.JobID]
+	if !ok || j.done {
+		return
+	}
+	j.history = append(j.history, ev)
+	for ch := range j.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			// Subscriber is not keeping up; drop rather than block the pipeline.
+		}
+	}
+	if ev.Terminal() {
+		j.done = true
+		for ch := range j.subscribers {
+			close(ch)
+		}
+		j.subscribers = nil
+		time.AfterFunc(FinishedJobRetention, func() { b.forget(ev.JobID, j) })
+	}
+}
+
+// Subscribe returns a channel that replays the job's history and then receives live
+// events until the job finishes. The returned cancel function must be called when the
+// subscriber goes away. False when the job is unknown.
+func (b *Broker) Subscribe(jobID string) (<-chan Event, func(), bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	j, ok := b.jobs[jobID]
+	if !ok {
+		return nil, func() {}, false
+	}
+	ch := make(chan Event, len(j.history)+subscriberBuffer)
+	for _, ev := range j.history {
+		ch <- ev
+	}
+	if j.done {
+		close(ch)
+		return ch, func() {}, true
+	}
+	j.subscribers[ch] = struct{}{}
+
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := j.subscribers[ch]; ok {
+			delete(j.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, cancel, true
+}
+
+// Forget drops all retained state for a job.
+func (b *Broker) Forget(jobID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if j, ok := b.jobs[jobID]; ok {
+		for ch := range j.subscribers {
+			close(ch)
+		}
+		j.subscribers = nil
+		delete(b.jobs, jobID)
+	}
+}
+
+// forget drops a finished job, unless it has since been forgotten and started again.
+func (b *Broker) forget(jobID string, j *job) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.jobs[jobID] == j {
+		delete(b.jobs, jobID)
+	}
+}
@@ -0,0 +1,122 @@
+package progress
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+func TestEmit_NilReporterIsNoop(t *testing.T) {
+	Emit(nil, Event{Type: PhaseStarted})
+}
+
+func TestBroker_ReplaysHistoryToLateSubscribers(t *testing.T) {
+	b := NewBroker()
+	b.Start("job-1")
+	r := b.Reporter("job-1")
+	Emit(r, Event{Type: PhaseStarted, Phase: 1})
+	Emit(r, Event{Type: PhaseCompleted, Phase: 1})
+
+	events, cancel, ok := b.Subscribe("job-1")
+	require.True(t, ok)
+	defer cancel()
+
+	first := <-events
+	second := <-events
+	assert.Equal(t, PhaseStarted, first.Type)
+	assert.Equal(t, "job-1", first.JobID)
+	assert.Equal(t, PhaseCompleted, second.Type)
+	assert.False(t, first.Time.IsZero())
+}
+
+func TestBroker_TerminalEventClosesSubscribers(t *testing.T) {
+	b := NewBroker()
+	b.Start("job-2")
+	events, cancel, _ := b.Subscribe("job-2")
+	defer cancel()
+
+	r := b.Reporter("job-2")
+	Emit(r, Event{Type: TraitCompleted, Trait: "height", Completed: 1, Total: 1})
+	Emit(r, Event{Type: RunCompleted})
+
+	var got []EventType
+	for ev := range events {
+		got = append(got, ev.Type)
+	}
+	assert.Equal(t, []EventType{TraitCompleted, RunCompleted}, got)
+
+	// Events after completion are ignored; a new subscriber sees only the history.
+	Emit(r, Event{Type: TraitCompleted, Trait: "late"})
+	replay, cancelReplay, _ := b.Subscribe("job-2")
+	defer cancelReplay()
+	count := 0
+	for range replay {
+		count++
+	}
+	assert.Equal(t, 2, count)
+}
+
+func TestBroker_ForgetDropsJob(t *testing.T) {
+	b := NewBroker()
+	b.Start("job-3")
+	events, _, _ := b.Subscribe("job-3")
+	b.Forget("job-3")
+	_, open := <-events
+	assert.False(t, open)
+}
+
+func TestBroker_UnknownJobs(t *testing.T) {
+	b := NewBroker()
+	Emit(b.Reporter("nobody"), Event{Type: PhaseStarted})
+	_, cancel, ok := b.Subscribe("nobody")
+	cancel()
+	assert.False(t, ok)
+	assert.Empty(t, b.jobs, "publishing and subscribing to unknown jobs tracks nothing")
+}
+
+func TestSSEHandler_StreamsEventsUntilCompletion(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	b := NewBroker()
+	b.Start("job-4")
+	r := b.Reporter("job-4")
+	Emit(r, Event{Type: PhaseStarted, Phase: 3, PhaseName: "processing"})
+	Emit(r, Event{Type: TraitCompleted, Trait: "bmi", Completed: 1, Total: 2})
+	Emit(r, Event{Type: RunCompleted})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /jobs/{id}/events", b.SSEHandler())
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/jobs/job-4/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	var eventLines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "event: ") {
+			eventLines = append(eventLines, strings.TrimPrefix(scanner.Text(), "event: "))
+		}
+	}
+	assert.Equal(t, []string{"phase_started", "trait_completed", "run_completed"}, eventLines)
+}
+
+func TestSSEHandler_MissingJobID(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	b := NewBroker()
+	rec := httptest.NewRecorder()
+	b.SSEHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	b.SSEHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events?job=unknown", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,73 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// SSEHandler returns an http.Handler that streams a job's progress events as
+// Server-Sent Events. The job ID is read from the "id" path value, falling back
+// to the "job" query parameter; unknown jobs are answered 404. The stream ends when the
+// job completes or fails.
+func (b *Broker) SSEHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jobID := r.PathValue("id")
+		if jobID == "" {
+			jobID = r.URL.Query().Get("job")
+		}
+		if jobID == "" {
+			http.Error(w, "missing job id", http.StatusBadRequest)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		events, cancel, ok := b.Subscribe(jobID)
+		defer cancel()
+		if !ok {
+			http.Error(w, "unknown job "+jobID, http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		logging.Debug("SSE subscriber attached to job %s", jobID)
+		for {
+			select {
+			case <-r.Context().Done():
+				logging.Debug("SSE subscriber for job %s disconnected", jobID)
+				return
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := writeSSE(w, ev); err != nil {
+					logging.Warn("failed to write SSE event for job %s: %v", jobID, err)
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	})
+}
+
+// writeSSE writes a single event in text/event-stream framing.
+func writeSSE(w http.ResponseWriter, ev Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+	return err
+}
@@ -30,7 +30,7 @@ func ValidateInputSNPs(snps []model.AnnotatedSNP) error {
 		}
 
 		// Validate contribution numerical stability
-		contribution := float64(snp.Dosage) * snp.Beta
+		contribution := snp.ScoringDosage() * snp.Beta
 		if err := invariance.AssertNumericalStability(contribution, context+" contribution"); err != nil {
 			return fmt.Errorf("invalid input SNP %s: %w", snp.RSID, err)
 		}
@@ -57,7 +57,7 @@ func ValidatePRSResult(snps []model.AnnotatedSNP, result PRSResult) error {
 	// Validate PRS calculation accuracy - verify it equals sum of contributions
 	var expectedScore float64
 	for _, snp := range snps {
-		expectedScore += float64(snp.Dosage) * snp.Beta
+		expectedScore += snp.ScoringDosage() * snp.Beta
 	}
 
 	const tolerance = 1e-12
@@ -103,6 +103,36 @@ func ValidateVariantContribution(rsid string, dosage int, beta, contribution flo
 	return nil
 }
 
+// ValidateSNPContribution validates a variant's contribution using the dosage it was scored with.
+// Additive SNPs use ValidateVariantContribution; strategy-coded SNPs are checked against their coded dosage.
+func ValidateSNPContribution(snp model.AnnotatedSNP, contribution float64) error {
+	if snp.CodedDosage == nil {
+		return ValidateVariantContribution(snp.RSID, snp.Dosage, snp.Beta, contribution)
+	}
+	if !invariance.IsValidationEnabled() {
+		return nil
+	}
+
+	context := fmt.Sprintf("variant %s", snp.RSID)
+	if err := invariance.AssertValidCodedDosage(*snp.CodedDosage, context); err != nil {
+		return fmt.Errorf("invalid variant %s: %w", snp.RSID, err)
+	}
+	if err := invariance.AssertValidBetaCoefficient(snp.Beta, context); err != nil {
+		return fmt.Errorf("invalid variant %s: %w", snp.RSID, err)
+	}
+
+	expectedContribution := *snp.CodedDosage * snp.Beta
+	const tolerance = 1e-12
+	if abs(contribution-expectedContribution) > tolerance {
+		return fmt.Errorf("variant %s contribution error: got %v, expected %v", snp.RSID, contribution, expectedContribution)
+	}
+
+	if err := invariance.AssertNumericalStability(contribution, context+" contribution"); err != nil {
+		return fmt.Errorf("invalid variant %s: %w", snp.RSID, err)
+	}
+	return nil
+}
+
 // ValidatePRSBounds validates that a PRS score falls within theoretical bounds
 // Returns error if validation fails or nil if validation passes/is disabled
 func ValidatePRSBounds(prsScore, minBound, maxBound float64) error {
@@ -0,0 +1,138 @@
+package prs
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"phite.io/polygenic-risk-calculator/internal/dosage"
+	"phite.io/polygenic-risk-calculator/internal/model"
+	reference_stats "phite.io/polygenic-risk-calculator/internal/reference/stats"
+)
+
+// Property tests of the scoring core: whichever allele a model names as the effect allele,
+// a person's normalized score must be the same. Harmonization (strand flips, swapped
+// effect/other alleles) relies on this.
+
+// site is one biallelic variant of a generated model, with a person's call at it.
+type site struct {
+	Effect, Other byte
+	Freq          float64 // effect allele frequency
+	Beta          float64
+	Genotype      string
+}
+
+// quickModel is a generated model and genotype set.
+type quickModel []site
+
+// Generate implements quick.Generator.
+func (quickModel) Generate(r *rand.Rand, size int) reflect.Value {
+	bases := []byte("ACGT")
+	m := make(quickModel, 1+r.Intn(max(size, 1)))
+	for i := range m {
+		perm := r.Perm(4)
+		s := site{
+			Effect: bases[perm[0]],
+			Other:  bases[perm[1]],
+			Freq:   0.01 + 0.98*r.Float64(),
+			Beta:   r.NormFloat64() * 0.2,
+		}
+		alleles := [2]byte{s.Effect, s.Other}
+		s.Genotype = string([]byte{alleles[r.Intn(2)], alleles[r.Intn(2)]})
+		m[i] = s
+	}
+	return reflect.ValueOf(m)
+}
+
+// swapped names the other allele as the effect allele: the weight changes sign and the
+// frequency is that of the other allele.
+func (m quickModel) swapped() quickModel {
+	out := make(quickModel, len(m))
+	for i, s := range m {
+		out[i] = site{Effect: s.Other, Other: s.Effect, Freq: 1 - s.Freq, Beta: -s.Beta, Genotype: s.Genotype}
+	}
+	return out
+}
+
+// zScore scores the genotypes with the additive coding and normalizes against the stats
+// computed from the model's frequencies.
+func (m quickModel) zScore() (float64, error) {
+	snps := make([]model.AnnotatedSNP, len(m))
+	freqs := make(map[string]float64, len(m))
+	effects := make(map[string]float64, len(m))
+	for i, s := range m {
+		id := fmt.Sprintf("rs%d", i)
+		call := dosage.Call{Genotype: s.Genotype, EffectAllele: string(s.Effect)}
+		snps[i] = model.AnnotatedSNP{RSID: id, Genotype: s.Genotype, RiskAllele: string(s.Effect), Beta: s.Beta, Dosage: call.EffectAlleleCount()}
+		freqs[id] = s.Freq
+		effects[id] = s.Beta
+	}
+	result, err := CalculatePRS(snps)
+	if err != nil {
+		return 0, err
+	}
+	stats, err := reference_stats.Compute(freqs, effects)
+	if err != nil {
+		return 0, err
+	}
+	return (result.PRSScore - stats.Mean) / stats.Std, nil
+}
+
+func TestProperty_ZScoreInvariantUnderEffectAlleleSwap(t *testing.T) {
+	prop := func(m quickModel) bool {
+		z, err := m.zScore()
+		if err != nil {
+			return false
+		}
+		zSwapped, err := m.swapped().zScore()
+		if err != nil {
+			return false
+		}
+		return math.Abs(z-zSwapped) <= 1e-9*math.Max(1, math.Abs(z))
+	}
+	if err := quick.Check(prop, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProperty_SwapIsInvolution(t *testing.T) {
+	prop := func(m quickModel) bool {
+		back := m.swapped().swapped()
+		for i, s := range m {
+			// 1 - (1 - p) can differ from p in the last bit
+			b := back[i]
+			if b.Effect != s.Effect || b.Other != s.Other || b.Beta != s.Beta || b.Genotype != s.Genotype ||
+				math.Abs(b.Freq-s.Freq) > 1e-15 {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(prop, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProperty_ScoreIsSumOfContributions(t *testing.T) {
+	prop := func(m quickModel) bool {
+		snps := make([]model.AnnotatedSNP, len(m))
+		var want float64
+		for i, s := range m {
+			call := dosage.Call{Genotype: s.Genotype, EffectAllele: string(s.Effect)}
+			d := call.EffectAlleleCount()
+			if d < 0 || d > 2 {
+				return false
+			}
+			snps[i] = model.AnnotatedSNP{RSID: fmt.Sprintf("rs%d", i), Beta: s.Beta, Dosage: d}
+			want += float64(d) * s.Beta
+		}
+		result, err := CalculatePRS(snps)
+		return err == nil && math.Abs(result.PRSScore-want) <= 1e-9*math.Max(1, math.Abs(want))
+	}
+	if err := quick.Check(prop, nil); err != nil {
+		t.Error(err)
+	}
+}
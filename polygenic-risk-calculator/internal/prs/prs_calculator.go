@@ -62,10 +62,10 @@ func CalculatePRS(snps []model.AnnotatedSNP) (PRSResult, error) {
 	contributions := make([]SNPContribution, 0, len(snps))
 
 	for _, snp := range snps {
-		contribution := float64(snp.Dosage) * snp.Beta
+		contribution := snp.ScoringDosage() * snp.Beta
 
 		// Optional runtime validation for individual contributions
-		if err := ValidateVariantContribution(snp.RSID, snp.Dosage, snp.Beta, contribution); err != nil {
+		if err := ValidateSNPContribution(snp, contribution); err != nil {
 			return PRSResult{}, &PRSCalculationError{
 				Message: "Variant contribution validation failed",
 				SNP:     &snp,
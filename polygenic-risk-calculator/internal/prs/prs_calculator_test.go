@@ -334,3 +334,29 @@ func TestPRSCalculationError(t *testing.T) {
 		t.Errorf("Error should contain phase, got: %s", errorStr2)
 	}
 }
+
+func TestCalculatePRS_WithValidationEnabled_CodedDosage(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	setupTestConfig(true, false)
+
+	// Dominant coding scores a homozygote as 1 and a probabilistic dosage may be fractional.
+	one, frac := 1.0, 1.4
+	snps := []model.AnnotatedSNP{
+		{RSID: "rs1", Genotype: "AA", RiskAllele: "A", Beta: 0.2, Dosage: 2, CodedDosage: &one},
+		{RSID: "rs2", Genotype: "AG", RiskAllele: "G", Beta: 0.5, Dosage: 1, CodedDosage: &frac},
+	}
+
+	result, err := CalculatePRS(snps)
+	if err != nil {
+		t.Fatalf("CalculatePRS should accept coded dosages: %v", err)
+	}
+	if expected := 1*0.2 + 1.4*0.5; !floatsAlmostEqual(result.PRSScore, expected) {
+		t.Errorf("PRSScore: got %v, want %v", result.PRSScore, expected)
+	}
+
+	bad := 2.5
+	_, err = CalculatePRS([]model.AnnotatedSNP{{RSID: "rs3", Beta: 0.1, Dosage: 2, CodedDosage: &bad}})
+	if err == nil {
+		t.Error("CalculatePRS should reject coded dosage outside [0,2]")
+	}
+}
@@ -0,0 +1,63 @@
+package prs
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+// ScoreScalesKey maps a trait/model ID to the linear transform that expresses its raw
+// score in the units of the original publication, e.g.
+//
+//	"prs": {"score_scales": {"systolic_bp": {"offset": 120, "scale": 2.5, "unit": "mmHg"}}}
+const ScoreScalesKey = "prs.score_scales"
+
+// ScoreScale is a linear transform from raw PRS to published units: offset + scale*raw.
+type ScoreScale struct {
+	Offset float64 `json:"offset"`
+	Scale  float64 `json:"scale"`
+	Unit   string  `json:"unit"`
+}
+
+// ScaledScore is a score expressed in published units.
+type ScaledScore struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+// Apply transforms a raw PRS into published units.
+func (s ScoreScale) Apply(raw float64) ScaledScore {
+	return ScaledScore{Value: s.Offset + s.Scale*raw, Unit: s.Unit}
+}
+
+// Validate checks that the transform is usable.
+func (s ScoreScale) Validate() error {
+	if s.Scale == 0 || math.IsNaN(s.Scale) || math.IsInf(s.Scale, 0) {
+		return fmt.Errorf("scale must be finite and nonzero, got %v", s.Scale)
+	}
+	if math.IsNaN(s.Offset) || math.IsInf(s.Offset, 0) {
+		return fmt.Errorf("offset must be finite, got %v", s.Offset)
+	}
+	if s.Unit == "" {
+		return fmt.Errorf("unit is required")
+	}
+	return nil
+}
+
+// ScoreScalesFromConfig loads per-model score scales keyed by lower-cased model ID.
+func ScoreScalesFromConfig() (map[string]ScoreScale, error) {
+	raw := make(map[string]ScoreScale)
+	if err := config.UnmarshalKey(ScoreScalesKey, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", ScoreScalesKey, err)
+	}
+	scales := make(map[string]ScoreScale, len(raw))
+	for modelID, scale := range raw {
+		if err := scale.Validate(); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", ScoreScalesKey, modelID, err)
+		}
+		scales[strings.ToLower(modelID)] = scale
+	}
+	return scales, nil
+}
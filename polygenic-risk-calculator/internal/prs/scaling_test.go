@@ -0,0 +1,37 @@
+package prs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"phite.io/polygenic-risk-calculator/internal/config"
+)
+
+func TestScoreScale_Apply(t *testing.T) {
+	scale := ScoreScale{Offset: 120, Scale: 2.5, Unit: "mmHg"}
+	assert.Equal(t, ScaledScore{Value: 125, Unit: "mmHg"}, scale.Apply(2))
+	assert.Equal(t, ScaledScore{Value: 117.5, Unit: "mmHg"}, scale.Apply(-1))
+}
+
+func TestScoreScalesFromConfig(t *testing.T) {
+	config.Set(ScoreScalesKey, map[string]interface{}{
+		"Systolic_BP": map[string]interface{}{"offset": 120, "scale": 2.5, "unit": "mmHg"},
+	})
+	defer config.Set(ScoreScalesKey, nil)
+
+	scales, err := ScoreScalesFromConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, ScoreScale{Offset: 120, Scale: 2.5, Unit: "mmHg"}, scales["systolic_bp"])
+
+	config.Set(ScoreScalesKey, map[string]interface{}{
+		"bmi": map[string]interface{}{"offset": 25, "unit": "kg/m²"},
+	})
+	_, err = ScoreScalesFromConfig()
+	assert.ErrorContains(t, err, "bmi: scale must be finite and nonzero")
+
+	config.Set(ScoreScalesKey, map[string]interface{}{
+		"bmi": map[string]interface{}{"offset": 25, "scale": 1.2},
+	})
+	_, err = ScoreScalesFromConfig()
+	assert.ErrorContains(t, err, "unit is required")
+}
@@ -11,15 +11,18 @@ import (
 
 // NormalizedPRS represents the normalized PRS result.
 type NormalizedPRS struct {
-	RawScore   float64 `json:"raw_score"`
-	ZScore     float64 `json:"z_score"`
-	Percentile float64 `json:"percentile"`
+	RawScore   float64      `json:"raw_score"`
+	ZScore     float64      `json:"z_score"`
+	Percentile float64      `json:"percentile"`
+	Exact      bool         `json:"exact_percentile,omitempty"` // percentile read off the reference quantiles rather than the normal approximation
+	Scaled     *ScaledScore `json:"scaled,omitempty"`           // raw score in published units, when the model defines a scale
 }
 
 // ReferenceStats holds reference population statistics for normalization.
 
-// NormalizePRS normalizes a raw PRS score using reference stats.
-// Returns NormalizedPRS and error if stats are missing or malformed.
+// NormalizePRS normalizes a raw PRS score using reference stats. The percentile comes from
+// the reference quantiles when the stats have them, and from the normal approximation
+// otherwise. Returns NormalizedPRS and error if stats are missing or malformed.
 func NormalizePRS(prs PRSResult, ref model.ReferenceStats) (NormalizedPRS, error) {
 	logging.Info("Normalizing PRS score: raw=%v, ref_mean=%v, ref_std=%v", prs.PRSScore, ref.Mean, ref.Std)
 	if ref.Std == 0 || math.IsNaN(ref.Mean) || math.IsNaN(ref.Std) {
@@ -27,11 +30,15 @@ func NormalizePRS(prs PRSResult, ref model.ReferenceStats) (NormalizedPRS, error
 		return NormalizedPRS{}, errors.New("invalid reference stats: std must be nonzero and values must not be NaN")
 	}
 	z := (prs.PRSScore - ref.Mean) / ref.Std
-	percentile := 100 * normCdf(z)
+	percentile, exact := ref.QuantilePercentile(prs.PRSScore)
+	if !exact {
+		percentile = 100 * normCdf(z)
+	}
 	result := NormalizedPRS{
 		RawScore:   prs.PRSScore,
 		ZScore:     z,
 		Percentile: percentile,
+		Exact:      exact,
 	}
 	logging.Info("PRS normalization complete: z=%.4f, percentile=%.2f", z, percentile)
 	return result, nil
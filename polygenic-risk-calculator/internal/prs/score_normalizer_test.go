@@ -82,3 +82,35 @@ func TestNormalizePRS(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizePRS_Quantiles(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	q := make([]float64, model.QuantileCount)
+	for i := range q {
+		q[i] = math.Pow(float64(i+1)/100, 2) // skewed: most of the population scores low
+	}
+	ref := model.ReferenceStats{Mean: 0.33, Std: 0.3, Min: 0, Max: 1, Quantiles: q}
+
+	norm, err := NormalizePRS(PRSResult{PRSScore: 0.25}, ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !norm.Exact || math.Abs(norm.Percentile-50) > 1e-9 {
+		t.Errorf("expected exact percentile 50, got %v (exact=%v)", norm.Percentile, norm.Exact)
+	}
+	if math.Abs(norm.ZScore-(0.25-0.33)/0.3) > 1e-12 {
+		t.Errorf("z-score should still use mean and std, got %v", norm.ZScore)
+	}
+
+	low, _ := NormalizePRS(PRSResult{PRSScore: -0.5}, ref)
+	high, _ := NormalizePRS(PRSResult{PRSScore: 2}, ref)
+	if !(low.Percentile > 0 && low.Percentile < 1) || !(high.Percentile > 99 && high.Percentile < 100) {
+		t.Errorf("scores beyond q1 and q99 should fall in the tails, got %v and %v", low.Percentile, high.Percentile)
+	}
+
+	ref.Quantiles = nil
+	norm, _ = NormalizePRS(PRSResult{PRSScore: 0.25}, ref)
+	if norm.Exact || math.Abs(norm.Percentile-100*normCdf((0.25-0.33)/0.3)) > 1e-9 {
+		t.Errorf("expected the normal approximation without quantiles, got %v", norm.Percentile)
+	}
+}
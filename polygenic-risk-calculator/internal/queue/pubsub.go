@@ -0,0 +1,98 @@
+package queue
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"time"
+
+	"google.golang.org/api/option"
+	pubsub "google.golang.org/api/pubsub/v1"
+)
+
+// PubSub pulls jobs from a Google Cloud Pub/Sub subscription and publishes results to a
+// topic, through the Pub/Sub REST API with application default credentials. When
+// PUBSUB_EMULATOR_HOST is set, it talks to the emulator there without credentials.
+type PubSub struct {
+	svc          *pubsub.Service
+	subscription string // projects/P/subscriptions/S
+	topic        string // projects/P/topics/T
+}
+
+// NewPubSub connects to Pub/Sub. opts are passed to the API client, e.g. to set the
+// endpoint in tests.
+func NewPubSub(ctx context.Context, subscription, topic string, opts ...option.ClientOption) (*PubSub, error) {
+	if host := os.Getenv("PUBSUB_EMULATOR_HOST"); host != "" {
+		opts = append([]option.ClientOption{option.WithEndpoint("http://" + host + "/"), option.WithoutAuthentication()}, opts...)
+	}
+	svc, err := pubsub.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Pub/Sub client: %w", err)
+	}
+	return &PubSub{svc: svc, subscription: subscription, topic: topic}, nil
+}
+
+// Pull implements Subscription.
+func (p *PubSub) Pull(ctx context.Context, max int) ([]Message, error) {
+	resp, err := p.svc.Projects.Subscriptions.Pull(p.subscription, &pubsub.PullRequest{MaxMessages: int64(max)}).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull from %s: %w", p.subscription, err)
+	}
+	msgs := make([]Message, 0, len(resp.ReceivedMessages))
+	for _, rm := range resp.ReceivedMessages {
+		if rm.Message == nil {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(rm.Message.Data)
+		if err != nil {
+			return nil, fmt.Errorf("message %s has invalid data: %w", rm.Message.MessageId, err)
+		}
+		msgs = append(msgs, Message{
+			ID:         rm.Message.MessageId,
+			AckID:      rm.AckId,
+			Data:       data,
+			Attributes: rm.Message.Attributes,
+			Attempt:    int(rm.DeliveryAttempt),
+		})
+	}
+	return msgs, nil
+}
+
+// Ack implements Subscription.
+func (p *PubSub) Ack(ctx context.Context, ackID string) error {
+	_, err := p.svc.Projects.Subscriptions.Acknowledge(p.subscription, &pubsub.AcknowledgeRequest{AckIds: []string{ackID}}).Context(ctx).Do()
+	return err
+}
+
+// Nack implements Subscription by setting the message's ack deadline to zero.
+func (p *PubSub) Nack(ctx context.Context, ackID string) error {
+	return p.modifyAckDeadline(ctx, ackID, 0)
+}
+
+// Extend implements Subscription. Pub/Sub caps ack deadlines at 600 seconds.
+func (p *PubSub) Extend(ctx context.Context, ackID string, d time.Duration) error {
+	return p.modifyAckDeadline(ctx, ackID, min(d, 600*time.Second))
+}
+
+func (p *PubSub) modifyAckDeadline(ctx context.Context, ackID string, d time.Duration) error {
+	req := &pubsub.ModifyAckDeadlineRequest{
+		AckIds:             []string{ackID},
+		AckDeadlineSeconds: int64(d / time.Second),
+		ForceSendFields:    []string{"AckDeadlineSeconds"}, // zero releases the message
+	}
+	_, err := p.svc.Projects.Subscriptions.ModifyAckDeadline(p.subscription, req).Context(ctx).Do()
+	return err
+}
+
+// Publish implements Publisher.
+func (p *PubSub) Publish(ctx context.Context, data []byte, attributes map[string]string) error {
+	req := &pubsub.PublishRequest{Messages: []*pubsub.PubsubMessage{{
+		Data:       base64.StdEncoding.EncodeToString(data),
+		Attributes: attributes,
+	}}}
+	if _, err := p.svc.Projects.Topics.Publish(p.topic, req).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", p.topic, err)
+	}
+	return nil
+}
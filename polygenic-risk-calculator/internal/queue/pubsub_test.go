@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+func TestPubSub(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests[r.URL.Path] = string(body)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/v1/projects/p/subscriptions/jobs:pull":
+			io.WriteString(w, `{"receivedMessages":[{"ackId":"ack-1","deliveryAttempt":2,
+				"message":{"messageId":"42","data":"eyJhcmdzIjpbXX0=","attributes":{"k":"v"}}}]}`)
+		case "/v1/projects/p/topics/results:publish":
+			io.WriteString(w, `{"messageIds":["7"]}`)
+		default:
+			io.WriteString(w, `{}`)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	ps, err := NewPubSub(ctx, "projects/p/subscriptions/jobs", "projects/p/topics/results",
+		option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	require.NoError(t, err)
+
+	msgs, err := ps.Pull(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []Message{{ID: "42", AckID: "ack-1", Data: []byte(`{"args":[]}`), Attributes: map[string]string{"k": "v"}, Attempt: 2}}, msgs)
+	assert.JSONEq(t, `{"maxMessages":2}`, requests["/v1/projects/p/subscriptions/jobs:pull"])
+
+	require.NoError(t, ps.Extend(ctx, "ack-1", time.Hour))
+	assert.JSONEq(t, `{"ackIds":["ack-1"],"ackDeadlineSeconds":600}`, requests["/v1/projects/p/subscriptions/jobs:modifyAckDeadline"])
+	require.NoError(t, ps.Nack(ctx, "ack-1"))
+	assert.JSONEq(t, `{"ackIds":["ack-1"],"ackDeadlineSeconds":0}`, requests["/v1/projects/p/subscriptions/jobs:modifyAckDeadline"])
+	require.NoError(t, ps.Ack(ctx, "ack-1"))
+	assert.JSONEq(t, `{"ackIds":["ack-1"]}`, requests["/v1/projects/p/subscriptions/jobs:acknowledge"])
+
+	require.NoError(t, ps.Publish(ctx, []byte("result"), map[string]string{"status": "succeeded"}))
+	var published struct {
+		Messages []struct {
+			Data       []byte            `json:"data"`
+			Attributes map[string]string `json:"attributes"`
+		} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(requests["/v1/projects/p/topics/results:publish"]), &published))
+	require.Len(t, published.Messages, 1)
+	assert.Equal(t, "result", string(published.Messages[0].Data))
+	assert.Equal(t, map[string]string{"status": "succeeded"}, published.Messages[0].Attributes)
+}
@@ -0,0 +1,239 @@
+// Package queue runs the calculator as a worker that consumes jobs from a message queue.
+// A Subscription delivers job messages at least once; Consume hands each to a Handler,
+// publishes the handler's reply to a Publisher, and acknowledges the message only once the
+// reply is published. Failed jobs are redelivered up to a limit before their error is
+// published. PubSub implements both interfaces on Google Cloud Pub/Sub.
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// Domain-specific configuration keys for the worker
+const (
+	SubscriptionKey = "worker.subscription"  // Pub/Sub subscription jobs are pulled from, as projects/P/subscriptions/S
+	ResultsTopicKey = "worker.results_topic" // Pub/Sub topic results and errors are published to, as projects/P/topics/T
+	ConcurrencyKey  = "worker.concurrency"   // Jobs scored at once (default 1)
+	MaxAttemptsKey  = "worker.max_attempts"  // Deliveries of a failing job before its error is published (default 3)
+	ResultsDirKey   = "worker.results_dir"   // Directory job --output and --output-dir paths are resolved in; unset, jobs cannot write files
+	InputsDirKey    = "worker.inputs_dir"    // Directory job --genotype-file and --snps-file paths are resolved in; unset, jobs may only read s3:// objects
+)
+
+// Message is one delivery of a job message.
+type Message struct {
+	ID         string // message ID assigned by the queue; the same on every delivery
+	AckID      string // handle of this delivery for Ack, Nack, and Extend
+	Data       []byte
+	Attributes map[string]string
+	Attempt    int // delivery attempt, from 1; 0 when the queue does not count deliveries
+}
+
+// Subscription delivers messages at least once. A pulled message is leased to the caller
+// until it is acknowledged or nacked, or its lease expires; it is then redelivered.
+type Subscription interface {
+	// Pull returns up to max messages, possibly none.
+	Pull(ctx context.Context, max int) ([]Message, error)
+	// Ack marks a message done, so it is not redelivered.
+	Ack(ctx context.Context, ackID string) error
+	// Nack releases a message for immediate redelivery.
+	Nack(ctx context.Context, ackID string) error
+	// Extend renews a message's lease for d.
+	Extend(ctx context.Context, ackID string, d time.Duration) error
+}
+
+// Publisher sends messages to a topic.
+type Publisher interface {
+	Publish(ctx context.Context, data []byte, attributes map[string]string) error
+}
+
+// Handler runs the job in m and returns the reply to publish. When it fails, it returns
+// the reply describing the failure along with the error; the reply is published once the
+// job is not retried any more.
+type Handler func(ctx context.Context, m Message) ([]byte, error)
+
+// permanentError marks a failure that retrying cannot fix.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as a failure retrying cannot fix, such as a malformed job, so its
+// reply is published on the first attempt.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+// Options controls Consume.
+type Options struct {
+	Concurrency  int           // jobs handled at once (default 1)
+	MaxAttempts  int           // deliveries of a failing job before its reply is published (default 3)
+	Lease        time.Duration // lease requested for running jobs, renewed at half of it (default 60s)
+	PollInterval time.Duration // wait after a pull returned no messages or failed (default 1s)
+}
+
+func (o Options) withDefaults() Options {
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.Lease <= 0 {
+		o.Lease = 60 * time.Second
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	return o
+}
+
+type consumer struct {
+	sub    Subscription
+	pub    Publisher
+	handle Handler
+	opts   Options
+
+	mu       sync.Mutex
+	attempts map[string]int // deliveries seen per message ID, for queues that do not count them
+}
+
+// Consume pulls messages from sub and handles up to opts.Concurrency of them at once until
+// ctx is cancelled. Jobs already running when ctx is cancelled are finished, and their
+// replies published, before Consume returns.
+func Consume(ctx context.Context, sub Subscription, pub Publisher, handle Handler, opts Options) error {
+	c := &consumer{sub: sub, pub: pub, handle: handle, opts: opts.withDefaults(), attempts: map[string]int{}}
+	slots := make(chan struct{}, c.opts.Concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	// Running jobs outlive ctx so a shutdown does not abandon them half done.
+	jobCtx := context.WithoutCancel(ctx)
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+		free := 1
+	claim:
+		for free < c.opts.Concurrency {
+			select {
+			case slots <- struct{}{}:
+				free++
+			default:
+				break claim
+			}
+		}
+
+		msgs, err := sub.Pull(ctx, free)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			logging.Warn("Failed to pull jobs, retrying in %s: %v", c.opts.PollInterval, err)
+		}
+		for range free - len(msgs) {
+			<-slots
+		}
+		for _, m := range msgs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				c.process(jobCtx, m)
+			}()
+		}
+		if len(msgs) == 0 {
+			select {
+			case <-time.After(c.opts.PollInterval):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// process handles one delivery and settles it: nacked for a retry, or acknowledged once
+// its reply is published.
+func (c *consumer) process(ctx context.Context, m Message) {
+	m.Attempt = c.attempt(m)
+	stop := c.keepLeased(ctx, m)
+	reply, err := c.handle(ctx, m)
+	stop()
+
+	if err != nil && !IsPermanent(err) && m.Attempt < c.opts.MaxAttempts {
+		logging.Warn("Job %s failed on attempt %d of %d, will retry: %v", m.ID, m.Attempt, c.opts.MaxAttempts, err)
+		if nerr := c.sub.Nack(ctx, m.AckID); nerr != nil {
+			logging.Warn("Failed to release job %s for retry: %v", m.ID, nerr)
+		}
+		return
+	}
+	status := "succeeded"
+	if err != nil {
+		status = "failed"
+		logging.Error("Job %s failed on attempt %d: %v", m.ID, m.Attempt, err)
+	}
+	if perr := c.pub.Publish(ctx, reply, map[string]string{"message_id": m.ID, "status": status}); perr != nil {
+		logging.Error("Failed to publish result of job %s, releasing it for retry: %v", m.ID, perr)
+		if nerr := c.sub.Nack(ctx, m.AckID); nerr != nil {
+			logging.Warn("Failed to release job %s for retry: %v", m.ID, nerr)
+		}
+		return
+	}
+	c.mu.Lock()
+	delete(c.attempts, m.ID)
+	c.mu.Unlock()
+	if aerr := c.sub.Ack(ctx, m.AckID); aerr != nil {
+		// The job will be redelivered and its result published again.
+		logging.Warn("Failed to acknowledge job %s: %v", m.ID, aerr)
+		return
+	}
+	logging.Info("Job %s %s", m.ID, status)
+}
+
+// attempt returns the delivery attempt of m, counting deliveries itself when the queue
+// does not.
+func (c *consumer) attempt(m Message) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if m.Attempt > 0 {
+		c.attempts[m.ID] = m.Attempt
+		return m.Attempt
+	}
+	c.attempts[m.ID]++
+	return c.attempts[m.ID]
+}
+
+// keepLeased renews the lease of m until the returned function is called.
+func (c *consumer) keepLeased(ctx context.Context, m Message) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(c.opts.Lease / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.sub.Extend(ctx, m.AckID, c.opts.Lease); err != nil {
+					logging.Warn("Failed to extend lease of job %s: %v", m.ID, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
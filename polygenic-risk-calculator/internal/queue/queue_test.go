@@ -0,0 +1,179 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// fakeQueue is an in-memory Subscription and Publisher. Nacked messages are redelivered;
+// deliveries are not counted, like a Pub/Sub subscription without a dead-letter policy.
+type fakeQueue struct {
+	mu        sync.Mutex
+	pending   []Message
+	leased    map[string]Message
+	acked     []string
+	published []string
+	seq       int
+}
+
+func newFakeQueue(data ...string) *fakeQueue {
+	q := &fakeQueue{leased: map[string]Message{}}
+	for i, d := range data {
+		q.pending = append(q.pending, Message{ID: fmt.Sprintf("m%d", i+1), Data: []byte(d)})
+	}
+	return q
+}
+
+func (q *fakeQueue) Pull(ctx context.Context, max int) ([]Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := min(max, len(q.pending))
+	msgs := q.pending[:n:n]
+	q.pending = q.pending[n:]
+	for i := range msgs {
+		q.seq++
+		msgs[i].AckID = fmt.Sprintf("ack%d", q.seq)
+		q.leased[msgs[i].AckID] = msgs[i]
+	}
+	return msgs, nil
+}
+
+func (q *fakeQueue) Ack(ctx context.Context, ackID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.acked = append(q.acked, q.leased[ackID].ID)
+	delete(q.leased, ackID)
+	return nil
+}
+
+func (q *fakeQueue) Nack(ctx context.Context, ackID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, q.leased[ackID])
+	delete(q.leased, ackID)
+	return nil
+}
+
+func (q *fakeQueue) Extend(ctx context.Context, ackID string, d time.Duration) error {
+	return nil
+}
+
+func (q *fakeQueue) Publish(ctx context.Context, data []byte, attributes map[string]string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.published = append(q.published, attributes["message_id"]+" "+attributes["status"]+" "+string(data))
+	return nil
+}
+
+func (q *fakeQueue) settled() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.acked)
+}
+
+// consumeUntil runs Consume until n messages are acknowledged.
+func consumeUntil(t *testing.T, q *fakeQueue, n int, h Handler, opts Options) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Consume(ctx, q, q, h, opts) }()
+	require.Eventually(t, func() bool { return q.settled() >= n }, 5*time.Second, time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestConsume(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	opts := Options{PollInterval: time.Millisecond}
+
+	t.Run("publishes replies and acknowledges", func(t *testing.T) {
+		q := newFakeQueue("a", "b")
+		consumeUntil(t, q, 2, func(ctx context.Context, m Message) ([]byte, error) {
+			return []byte("scored " + string(m.Data)), nil
+		}, opts)
+		assert.ElementsMatch(t, []string{"m1 succeeded scored a", "m2 succeeded scored b"}, q.published)
+		assert.ElementsMatch(t, []string{"m1", "m2"}, q.acked)
+	})
+
+	t.Run("retries failures up to MaxAttempts", func(t *testing.T) {
+		q := newFakeQueue("a")
+		var calls atomic.Int32
+		consumeUntil(t, q, 1, func(ctx context.Context, m Message) ([]byte, error) {
+			calls.Add(1)
+			return []byte(fmt.Sprintf("attempt %d", m.Attempt)), errors.New("backend unavailable")
+		}, Options{MaxAttempts: 3, PollInterval: time.Millisecond})
+		assert.Equal(t, int32(3), calls.Load())
+		assert.Equal(t, []string{"m1 failed attempt 3"}, q.published)
+	})
+
+	t.Run("succeeds on a retry", func(t *testing.T) {
+		q := newFakeQueue("a")
+		consumeUntil(t, q, 1, func(ctx context.Context, m Message) ([]byte, error) {
+			if m.Attempt == 1 {
+				return nil, errors.New("timeout")
+			}
+			return []byte("ok"), nil
+		}, opts)
+		assert.Equal(t, []string{"m1 succeeded ok"}, q.published)
+	})
+
+	t.Run("does not retry permanent failures", func(t *testing.T) {
+		q := newFakeQueue("not json")
+		var calls atomic.Int32
+		consumeUntil(t, q, 1, func(ctx context.Context, m Message) ([]byte, error) {
+			calls.Add(1)
+			return []byte("invalid job"), Permanent(errors.New("invalid job"))
+		}, opts)
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, []string{"m1 failed invalid job"}, q.published)
+	})
+
+	t.Run("bounds concurrency", func(t *testing.T) {
+		q := newFakeQueue("a", "b", "c", "d", "e", "f")
+		var running, peak atomic.Int32
+		consumeUntil(t, q, 6, func(ctx context.Context, m Message) ([]byte, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return nil, nil
+		}, Options{Concurrency: 2, PollInterval: time.Millisecond})
+		assert.Equal(t, int32(2), peak.Load())
+	})
+}
+
+func TestConsumeFinishesRunningJobsOnShutdown(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	q := newFakeQueue("a")
+	started := make(chan struct{})
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Consume(ctx, q, q, func(ctx context.Context, m Message) ([]byte, error) {
+			close(started)
+			<-release
+			return []byte("done"), nil
+		}, Options{PollInterval: time.Millisecond})
+	}()
+	<-started
+	cancel()
+	close(release)
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"m1 succeeded done"}, q.published)
+	assert.Equal(t, []string{"m1"}, q.acked)
+}
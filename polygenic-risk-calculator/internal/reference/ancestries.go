@@ -0,0 +1,109 @@
+package reference
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
+	"phite.io/polygenic-risk-calculator/internal/limits"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
+	reference_stats "phite.io/polygenic-risk-calculator/internal/reference/stats"
+)
+
+// GetReferenceStatsForAncestries computes reference stats for every trait under every given
+// ancestry in one pass: each model is loaded once per distinct weight column, and a single
+// allele frequency query selects the frequency columns of all the ancestries. Results are
+// keyed like GetReferenceStatsBatch ("ancestry|trait|model"). With a per-trait timeout,
+// traits are computed one at a time, each under its own deadline.
+func (s *ReferenceService) GetReferenceStatsForAncestries(ctx context.Context, traits []string, ancestries []*ancestry.Ancestry) (map[string]*reference_stats.ReferenceStats, []error) {
+	if s.budget.Timeout > 0 {
+		return s.eachTraitWithinBudget(ctx, traits, func(ctx context.Context, i int) (map[string]*reference_stats.ReferenceStats, []error) {
+			return s.referenceStatsForAncestries(ctx, traits[i:i+1], ancestries)
+		})
+	}
+	return s.referenceStatsForAncestries(ctx, traits, ancestries)
+}
+
+// referenceStatsForAncestries computes reference stats for traits under ancestries with one
+// allele frequency query.
+func (s *ReferenceService) referenceStatsForAncestries(ctx context.Context, traits []string, ancestries []*ancestry.Ancestry) (map[string]*reference_stats.ReferenceStats, []error) {
+	results := make(map[string]*reference_stats.ReferenceStats)
+	if len(traits) == 0 || len(ancestries) == 0 {
+		return results, nil
+	}
+	logging.Info("Getting reference stats for %d traits across %d ancestries", len(traits), len(ancestries))
+
+	var processingErrors []error
+	const errorCap = 10
+
+	// Step 1: Load each trait's model once per weight column; ancestries sharing a
+	// population (e.g. EUR and EUR_FEMALE) share a model.
+	models := make(map[string]map[string]*model.PRSModel) // trait -> weight column -> model
+	traitVariants := make(map[string][]model.Variant)
+	for _, trait := range traits {
+		if _, ok := models[trait]; ok {
+			continue
+		}
+		models[trait] = make(map[string]*model.PRSModel)
+		for _, anc := range ancestries {
+			col := anc.WeightColumn()
+			if _, ok := models[trait][col]; ok {
+				continue
+			}
+			prsModel, err := s.loadModelWithinBudget(ctx, trait, anc)
+			if err != nil {
+				err = fmt.Errorf("failed to load PRS model for trait %s: %w", trait, err)
+				processingErrors = append(processingErrors, err)
+				logging.Error("%v", err)
+				if errors.Is(err, limits.ErrLimitExceeded) || len(processingErrors) >= errorCap {
+					return nil, processingErrors
+				}
+				delete(models, trait)
+				delete(traitVariants, trait)
+				break
+			}
+			models[trait][col] = prsModel
+			traitVariants[trait] = prsModel.Variants
+		}
+	}
+
+	// Step 2: One allele frequency query for all variants and all ancestries.
+	frequencies, err := s.queryAlleleFrequenciesByAncestry(ctx, traitVariants, ancestries)
+	if err != nil {
+		err = fmt.Errorf("failed to get allele frequencies: %w", err)
+		processingErrors = append(processingErrors, err)
+		logging.Error("%v", err)
+		return results, processingErrors
+	}
+	for code, byTrait := range frequencies {
+		frequencies[code] = s.effectFrequencies(traitVariants, byTrait)
+	}
+
+	// Step 3: Compute stats for each ancestry and trait.
+	for _, anc := range ancestries {
+		for trait, byColumn := range models {
+			prsModel := byColumn[anc.WeightColumn()]
+			freqs, effects := frequencies[anc.Code()][trait], prsModel.GetEffectSizes()
+			stats, err := reference_stats.Compute(freqs, effects, s.coding(trait))
+			if err != nil {
+				err = fmt.Errorf("failed to compute %s stats for trait %s: %w", anc.Code(), trait, err)
+				processingErrors = append(processingErrors, err)
+				logging.Error("%v", err)
+				if len(processingErrors) >= errorCap {
+					return results, processingErrors
+				}
+				continue
+			}
+			stats.Ancestry = anc.Code()
+			stats.Trait = trait
+			stats.Model = s.ModelID(trait)
+			stats.Contributors = reference_stats.TopContributors(freqs, effects, s.topContributors, s.coding(trait))
+			results[fmt.Sprintf("%s|%s|%s", stats.Ancestry, stats.Trait, stats.Model)] = stats
+		}
+	}
+
+	logging.Info("Computed %d reference stats across %d ancestries with %d errors", len(results), len(ancestries), len(processingErrors))
+	return results, processingErrors
+}
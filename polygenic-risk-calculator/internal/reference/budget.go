@@ -0,0 +1,127 @@
+package reference
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/limits"
+	"phite.io/polygenic-risk-calculator/internal/model"
+	reference_stats "phite.io/polygenic-risk-calculator/internal/reference/stats"
+)
+
+// Domain-specific configuration keys for per-trait compute budgets
+const (
+	TraitTimeoutKey     = "reference.trait_timeout"      // Max time for a single trait's model load, frequency query, and stats (e.g. "30s"); empty disables
+	TraitMaxVariantsKey = "reference.trait_max_variants" // Max variants a single trait's model may contain; 0 disables
+)
+
+// ErrTraitBudgetExceeded is returned when a trait exceeds its configured compute budget.
+var ErrTraitBudgetExceeded = errors.New("trait budget exceeded")
+
+// TraitBudget limits the work spent on any single trait so one misbehaving trait
+// is skipped with an error instead of blocking or inflating the whole run.
+type TraitBudget struct {
+	Timeout     time.Duration // zero means no timeout
+	MaxVariants int           // zero means no limit
+}
+
+// traitBudgetFromConfig reads the per-trait budget from configuration. An invalid timeout
+// is an error rather than no timeout, so a typo never leaves runs unbounded.
+func traitBudgetFromConfig() (TraitBudget, error) {
+	var budget TraitBudget
+	if raw := config.GetString(TraitTimeoutKey); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			return budget, fmt.Errorf("invalid %s %q: expected a positive duration such as \"30s\"", TraitTimeoutKey, raw)
+		}
+		budget.Timeout = d
+	}
+	if n := config.GetInt(TraitMaxVariantsKey); n > 0 {
+		budget.MaxVariants = n
+	}
+	return budget, nil
+}
+
+// SetTraitBudget overrides the per-trait budget read from configuration.
+func (s *ReferenceService) SetTraitBudget(budget TraitBudget) {
+	s.budget = budget
+}
+
+// SetLimits overrides the input limits read from configuration.
+func (s *ReferenceService) SetLimits(l limits.Limits) {
+	s.limits = l
+}
+
+// traitContext returns ctx bounded by the per-trait timeout, if one is set.
+func (s *ReferenceService) traitContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.budget.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.budget.Timeout)
+}
+
+// overBudget reports err as ErrTraitBudgetExceeded when traitCtx, made from ctx by
+// traitContext, reached its deadline while ctx itself is still live.
+func (s *ReferenceService) overBudget(ctx, traitCtx context.Context, trait string, err error) error {
+	if err == nil || ctx.Err() != nil || !errors.Is(traitCtx.Err(), context.DeadlineExceeded) || errors.Is(err, ErrTraitBudgetExceeded) {
+		return err
+	}
+	return fmt.Errorf("%w: trait %s exceeded %s: %w", ErrTraitBudgetExceeded, trait, s.budget.Timeout, err)
+}
+
+// eachTraitWithinBudget computes reference stats one trait at a time, calling compute with
+// the index of each trait under its own per-trait timeout. Each trait's model load,
+// frequency query, and stats then share its deadline, which a batch's single frequency
+// query cannot. As in a batch, exceeding the run-wide limits stops the run.
+func (s *ReferenceService) eachTraitWithinBudget(ctx context.Context, traits []string, compute func(ctx context.Context, i int) (map[string]*reference_stats.ReferenceStats, []error)) (map[string]*reference_stats.ReferenceStats, []error) {
+	results := make(map[string]*reference_stats.ReferenceStats)
+	var errs []error
+	for i, trait := range traits {
+		traitCtx, cancel := s.traitContext(ctx)
+		got, traitErrs := compute(traitCtx, i)
+		for j, err := range traitErrs {
+			traitErrs[j] = s.overBudget(ctx, traitCtx, trait, err)
+		}
+		cancel()
+		maps.Copy(results, got)
+		errs = append(errs, traitErrs...)
+		for _, err := range traitErrs {
+			if errors.Is(err, limits.ErrLimitExceeded) {
+				return nil, errs
+			}
+		}
+		if ctx.Err() != nil {
+			return results, errs
+		}
+	}
+	return results, errs
+}
+
+// loadModelWithinBudget loads a trait's model, enforcing the per-trait size cap and the
+// run-wide model size limit. Unlike the budget, exceeding the limit aborts the batch. The
+// per-trait timeout is applied by callers around all of a trait's reference work.
+func (s *ReferenceService) loadModelWithinBudget(ctx context.Context, trait string, anc *ancestry.Ancestry) (*model.PRSModel, error) {
+	prsModel, err := s.LoadModel(ctx, trait, anc)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkModelSize(trait, len(prsModel.Variants)); err != nil {
+		return nil, err
+	}
+	return prsModel, nil
+}
+
+// checkModelSize enforces the per-trait variant cap and the run-wide model size limit on a
+// model of n variants. LoadModel also checks each page, so paging stops at the cap.
+func (s *ReferenceService) checkModelSize(trait string, n int) error {
+	if s.budget.MaxVariants > 0 && n > s.budget.MaxVariants {
+		return fmt.Errorf("%w: trait %s has %d variants, limit is %d", ErrTraitBudgetExceeded, trait, n, s.budget.MaxVariants)
+	}
+	return s.limits.CheckModelVariants(trait, n)
+}
@@ -2,12 +2,14 @@ package reference_cache
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"strings"
+	"time"
 
 	"phite.io/polygenic-risk-calculator/internal/ancestry"
 	"phite.io/polygenic-risk-calculator/internal/config"
 	"phite.io/polygenic-risk-calculator/internal/db"
+	"phite.io/polygenic-risk-calculator/internal/db/dbutil"
 	dbinterface "phite.io/polygenic-risk-calculator/internal/db/interface"
 	"phite.io/polygenic-risk-calculator/internal/logging"
 	reference_stats "phite.io/polygenic-risk-calculator/internal/reference/stats"
@@ -18,18 +20,25 @@ const (
 	BatchSizeKey = "cache.batch_size" // Cache batch operation size
 )
 
+// Columns are the cache table columns read and written by RepositoryCache.
+var Columns = []string{"ancestry", "trait", "model", "mean", "std", "min", "max", QuantilesColumn, CreatedAtColumn}
+
+// statsColumns are the columns read back into ReferenceStats.
+var statsColumns = []string{"mean", "std", "min", "max", "ancestry", "trait", "model", QuantilesColumn}
+
+// QuantilesColumn holds the 1st to 99th percentiles of the reference score distribution
+// as a JSON array; it is NULL for entries stored before quantiles were computed.
+const QuantilesColumn = "quantiles"
+
+// CreatedAtColumn records when a cache entry was stored; retention uses it to expire entries.
+const CreatedAtColumn = "created_at"
+
 func init() {
 	// Register required infrastructure keys (table reference)
 	config.RegisterRequiredKey(config.TableCacheTableKey)
 	// Domain-specific keys with defaults don't need to be required
 }
 
-// ReferenceStatsBackend defines the interface for any backend that can provide reference stats.
-type ReferenceStatsBackend interface {
-	GetReferenceStats(ctx context.Context, ancestry *ancestry.Ancestry, trait, model string) (*reference_stats.ReferenceStats, error)
-	Close() error
-}
-
 // StatsRequest represents a request for reference statistics.
 type StatsRequest struct {
 	Ancestry string // Still use string internally for cache storage
@@ -52,12 +61,14 @@ type Cache interface {
 	StoreBatch(ctx context.Context, entries []CacheEntry) error
 }
 
-// RepositoryCache implements both Cache and ReferenceStatsBackend using DBRepository.
+// RepositoryCache implements Cache using DBRepository.
 type RepositoryCache struct {
-	Repo      dbinterface.Repository
-	TableID   string
-	datasetID string // Add dataset ID to build fully qualified table names
-	projectID string // Add project ID for full qualification
+	Repo        dbinterface.Repository
+	TableID     string
+	FreqTableID string // allele frequency cache table; empty disables FrequencyCache
+	datasetID   string // Add dataset ID to build fully qualified table names
+	projectID   string // Add project ID for full qualification
+	local       bool   // tables live in a local DuckDB file; see NewLocalCache
 }
 
 // NewRepositoryCache creates a new cache with dependency injection
@@ -103,33 +114,59 @@ func NewRepositoryCache(repo dbinterface.Repository, params ...map[string]string
 	}
 
 	return &RepositoryCache{
-		Repo:      repo,
-		TableID:   config.GetString(config.TableCacheTableKey),
-		datasetID: datasetID,
-		projectID: projectID,
+		Repo:        repo,
+		TableID:     config.GetString(config.TableCacheTableKey),
+		FreqTableID: config.GetString(config.TableFreqCacheTableKey),
+		datasetID:   datasetID,
+		projectID:   projectID,
 	}, nil
 }
 
 // GetFullyQualifiedTableName returns the properly qualified table name (`project.dataset.table`)
-// with backticks to prevent SQL injection and parsing issues.
+// with backticks to prevent SQL injection and parsing issues; a local cache's table is
+// quoted on its own.
 // Returns an error if any component is missing.
 func (c *RepositoryCache) GetFullyQualifiedTableName() (string, error) {
+	return c.qualify(c.TableID)
+}
+
+// dialect returns the SQL dialect of the cache tables.
+func (c *RepositoryCache) dialect() dbutil.Dialect {
+	if c.local {
+		return dbutil.DuckDB
+	}
+	return dbutil.BigQuery
+}
+
+// qualify returns table qualified with the cache project and dataset, or quoted on its
+// own for a local cache.
+func (c *RepositoryCache) qualify(table string) (string, error) {
+	if c.local {
+		if err := dbutil.ValidateIdent(table); err != nil {
+			return "", err
+		}
+		return dbutil.DuckDB.QuoteIdent(table), nil
+	}
 	if c.projectID == "" {
 		return "", fmt.Errorf("project ID is required for BigQuery cache operations, got empty value")
 	}
 	if c.datasetID == "" {
 		return "", fmt.Errorf("dataset ID is required for BigQuery cache operations, got empty value")
 	}
-	if c.TableID == "" {
+	if table == "" {
 		return "", fmt.Errorf("table ID is required for BigQuery cache operations, got empty value")
 	}
 
-	fqTable := fmt.Sprintf("`%s`.`%s`.`%s`", c.projectID, c.datasetID, c.TableID)
+	if err := dbutil.ValidateIdents(c.projectID, c.datasetID, table); err != nil {
+		return "", err
+	}
+
+	fqTable := dbutil.BigQuery.QuoteParts(c.projectID, c.datasetID, table)
 	logging.Debug("Fully qualified table name: %s", fqTable)
 	return fqTable, nil
 }
 
-// GetReferenceStats implements ReferenceStatsBackend interface with ancestry objects.
+// GetReferenceStats looks up the cached stats for an ancestry object.
 func (c *RepositoryCache) GetReferenceStats(ctx context.Context, ancestry *ancestry.Ancestry, trait, model string) (*reference_stats.ReferenceStats, error) {
 	// Convert ancestry object to code for cache operations
 	ancestryCode := ancestry.Code()
@@ -147,15 +184,21 @@ func (c *RepositoryCache) Get(ctx context.Context, req StatsRequest) (*reference
 		return nil, fmt.Errorf("failed to build fully qualified table name: %w", err)
 	}
 
-	queryString := fmt.Sprintf(
-		"SELECT mean, std, min, max, ancestry, trait, model FROM %s WHERE ancestry = ? AND trait = ? AND model = ? LIMIT 1",
-		fullyQualifiedTable,
-	)
+	queryString, args, err := c.dialect().Select(statsColumns...).
+		FromQuoted(fullyQualifiedTable).
+		WhereEq("ancestry", req.Ancestry).
+		WhereEq("trait", req.Trait).
+		WhereEq("model", req.ModelID).
+		Limit(1).
+		Build()
+	if err != nil {
+		return nil, err
+	}
 
 	logging.Debug("Executing cache query: %s with params: ancestry=%s, trait=%s, modelID=%s",
 		queryString, req.Ancestry, req.Trait, req.ModelID)
 
-	results, err := c.Repo.Query(ctx, queryString, req.Ancestry, req.Trait, req.ModelID)
+	results, err := c.Repo.Query(ctx, queryString, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute cache query: %w", err)
 	}
@@ -179,6 +222,9 @@ func (c *RepositoryCache) Get(ctx context.Context, req StatsRequest) (*reference
 		Trait:    row["trait"].(string),
 		Model:    row["model"].(string),
 	}
+	if stats.Quantiles, err = quantilesFromRow(row); err != nil {
+		return nil, fmt.Errorf("invalid reference stats from cache: %w", err)
+	}
 
 	// Validate the stats before returning
 	if err := stats.Validate(); err != nil {
@@ -202,25 +248,27 @@ func (c *RepositoryCache) GetBatch(ctx context.Context, reqs []StatsRequest) (ma
 	}
 
 	// Build batch query with OR clause for optimal performance
-	var conditions []string
-	var args []interface{}
-
-	for _, req := range reqs {
-		conditions = append(conditions, "(ancestry = ? AND trait = ? AND model = ?)")
-		args = append(args, req.Ancestry, req.Trait, req.ModelID)
+	keys := make([][]interface{}, len(reqs))
+	for i, req := range reqs {
+		keys[i] = []interface{}{req.Ancestry, req.Trait, req.ModelID}
 	}
 
-	queryString := fmt.Sprintf(
-		"SELECT mean, std, min, max, ancestry, trait, model FROM %s WHERE %s",
-		fullyQualifiedTable,
-		strings.Join(conditions, " OR "),
-	)
-
 	logging.Debug("Executing batch cache query for %d requests", len(reqs))
 
-	results, err := c.Repo.Query(ctx, queryString, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute batch cache query: %w", err)
+	var results []map[string]interface{}
+	for _, chunk := range dbutil.Chunks(keys, dbutil.DefaultChunkSize/3) {
+		queryString, args, err := c.dialect().Select(statsColumns...).
+			FromQuoted(fullyQualifiedTable).
+			WhereAnyOf("ancestry = ? AND trait = ? AND model = ?", chunk).
+			Build()
+		if err != nil {
+			return nil, err
+		}
+		rows, err := c.Repo.Query(ctx, queryString, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute batch cache query: %w", err)
+		}
+		results = append(results, rows...)
 	}
 
 	// Convert results to map keyed by "ancestry|trait|model"
@@ -235,9 +283,14 @@ func (c *RepositoryCache) GetBatch(ctx context.Context, reqs []StatsRequest) (ma
 			Trait:    row["trait"].(string),
 			Model:    row["model"].(string),
 		}
+		quantiles, err := quantilesFromRow(row)
+		if err == nil {
+			stats.Quantiles = quantiles
+			err = stats.Validate()
+		}
 
 		// Validate the stats before adding to map
-		if err := stats.Validate(); err != nil {
+		if err != nil {
 			logging.Warn("Invalid reference stats from batch cache query: %v", err)
 			continue
 		}
@@ -265,6 +318,9 @@ func (c *RepositoryCache) Store(ctx context.Context, req StatsRequest, stats *re
 		"ancestry": req.Ancestry,
 		"trait":    req.Trait,
 		"model":    req.ModelID,
+
+		QuantilesColumn: quantilesValue(stats.Quantiles),
+		CreatedAtColumn: time.Now().UTC(),
 	}
 
 	if err := c.Repo.Insert(ctx, c.TableID, []map[string]interface{}{row}); err != nil {
@@ -310,6 +366,7 @@ func (c *RepositoryCache) storeBatch(ctx context.Context, entries []CacheEntry)
 	}
 
 	// Prepare rows for batch insert
+	now := time.Now().UTC()
 	rows := make([]map[string]interface{}, 0, len(entries))
 	for _, entry := range entries {
 		// Validate stats before storing
@@ -325,6 +382,9 @@ func (c *RepositoryCache) storeBatch(ctx context.Context, entries []CacheEntry)
 			"ancestry": entry.Request.Ancestry,
 			"trait":    entry.Request.Trait,
 			"model":    entry.Request.ModelID,
+
+			QuantilesColumn: quantilesValue(entry.Stats.Quantiles),
+			CreatedAtColumn: now,
 		}
 		rows = append(rows, row)
 	}
@@ -337,3 +397,52 @@ func (c *RepositoryCache) storeBatch(ctx context.Context, entries []CacheEntry)
 	logging.Debug("Stored %d stats in batch cache operation", len(entries))
 	return nil
 }
+
+// quantilesValue encodes quantiles for QuantilesColumn, nil when there are none.
+func quantilesValue(quantiles []float64) interface{} {
+	if len(quantiles) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(quantiles)
+	if err != nil {
+		return nil
+	}
+	return string(b)
+}
+
+// quantilesFromRow decodes the QuantilesColumn of a cache row; nil when it is NULL or absent.
+func quantilesFromRow(row map[string]interface{}) ([]float64, error) {
+	s, _ := row[QuantilesColumn].(string)
+	if s == "" {
+		return nil, nil
+	}
+	var quantiles []float64
+	if err := json.Unmarshal([]byte(s), &quantiles); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", QuantilesColumn, err)
+	}
+	return quantiles, nil
+}
+
+// DeleteOlderThan removes cache entries stored before cutoff, including cached allele
+// frequencies when a frequency cache table is configured. Entries written before
+// created_at was recorded have no timestamp and are left in place.
+func (c *RepositoryCache) DeleteOlderThan(ctx context.Context, cutoff time.Time) error {
+	tables := []string{c.TableID}
+	if c.frequenciesEnabled() {
+		tables = append(tables, c.FreqTableID)
+	}
+	for _, table := range tables {
+		fullyQualifiedTable, err := c.qualify(table)
+		if err != nil {
+			return fmt.Errorf("failed to build fully qualified table name: %w", err)
+		}
+
+		query := fmt.Sprintf("DELETE FROM %s WHERE %s < ?", fullyQualifiedTable, c.dialect().QuoteIdent(CreatedAtColumn))
+		if _, err := c.Repo.Query(ctx, query, cutoff.UTC()); err != nil {
+			return fmt.Errorf("failed to delete cache entries older than %s: %w", cutoff.Format(time.RFC3339), err)
+		}
+	}
+
+	logging.Info("Deleted cache entries older than %s", cutoff.Format(time.RFC3339))
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"phite.io/polygenic-risk-calculator/internal/ancestry"
@@ -705,6 +706,22 @@ func TestRepositoryCache_GetFullyQualifiedTableName(t *testing.T) {
 			expectError:   true,
 			errorContains: "project ID is required",
 		},
+		{
+			name:          "Injected table ID",
+			projectID:     "jerkytreats",
+			datasetID:     "prs_stats_cache",
+			tableID:       "cache` WHERE 1=1; DROP TABLE x; --",
+			expectError:   true,
+			errorContains: "invalid SQL identifier",
+		},
+		{
+			name:          "Injected dataset ID",
+			projectID:     "jerkytreats",
+			datasetID:     "a`.`b",
+			tableID:       "prs_stats_cache",
+			expectError:   true,
+			errorContains: "invalid SQL identifier",
+		},
 		{
 			name:        "Different valid values",
 			projectID:   "my-project-123",
@@ -740,3 +757,81 @@ func TestRepositoryCache_GetFullyQualifiedTableName(t *testing.T) {
 		})
 	}
 }
+
+func TestRepositoryCache_DeleteOlderThan(t *testing.T) {
+	defer config.ResetForTest()
+
+	var gotQuery string
+	var gotArgs []interface{}
+	repo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			gotQuery, gotArgs = query, args
+			return nil, nil
+		},
+	}
+	cache := newTestCache(repo)
+
+	cutoff := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, cache.DeleteOlderThan(context.Background(), cutoff))
+	assert.Contains(t, gotQuery, "DELETE FROM")
+	assert.Contains(t, gotQuery, CreatedAtColumn+" < ?")
+	assert.Equal(t, []interface{}{cutoff}, gotArgs)
+}
+
+func TestVariantSetHash_OrderIndependent(t *testing.T) {
+	a := VariantSetHash([]string{"1:100:A:G", "2:200:C:T"})
+	assert.Equal(t, a, VariantSetHash([]string{"2:200:C:T", "1:100:A:G"}))
+	assert.NotEqual(t, a, VariantSetHash([]string{"1:100:A:G"}))
+}
+
+func TestRepositoryCache_Frequencies_RoundTrip(t *testing.T) {
+	var stored []map[string]interface{}
+	repo := &mockRepo{
+		insertFunc: func(ctx context.Context, table string, rows []map[string]interface{}) error {
+			assert.Equal(t, "freq_cache", table)
+			stored = append(stored, rows...)
+			return nil
+		},
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			assert.Contains(t, query, "`jerkytreats`.`prs_stats_cache`.`freq_cache`")
+			return stored, nil
+		},
+	}
+	cache := newTestCache(repo)
+	cache.FreqTableID = "freq_cache"
+
+	req := FrequencyRequest{SetHash: VariantSetHash([]string{"a", "b"}), Ancestry: "EUR", Source: "gnomad:v4"}
+	freqs := map[string]float64{"a": 0.1, "b": 0.2}
+	assert.NoError(t, cache.StoreFrequencies(context.Background(), []FrequencyEntry{{Request: req, Frequencies: freqs}}))
+	assert.Len(t, stored, 2)
+
+	hits, err := cache.GetFrequencies(context.Background(), []FrequencyRequest{req})
+	assert.NoError(t, err)
+	assert.Equal(t, freqs, hits[req])
+
+	// A map missing rows is a miss rather than a partial hit.
+	stored = stored[:1]
+	hits, err = cache.GetFrequencies(context.Background(), []FrequencyRequest{req})
+	assert.NoError(t, err)
+	assert.Empty(t, hits)
+}
+
+func TestRepositoryCache_Frequencies_DisabledWithoutTable(t *testing.T) {
+	repo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			t.Fatal("unexpected query")
+			return nil, nil
+		},
+		insertFunc: func(ctx context.Context, table string, rows []map[string]interface{}) error {
+			t.Fatal("unexpected insert")
+			return nil
+		},
+	}
+	cache := newTestCache(repo)
+	req := FrequencyRequest{SetHash: "h", Ancestry: "EUR", Source: "s"}
+
+	hits, err := cache.GetFrequencies(context.Background(), []FrequencyRequest{req})
+	assert.NoError(t, err)
+	assert.Empty(t, hits)
+	assert.NoError(t, cache.StoreFrequencies(context.Background(), []FrequencyEntry{{Request: req, Frequencies: map[string]float64{"a": 0.1}}}))
+}
@@ -0,0 +1,152 @@
+package reference_cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"phite.io/polygenic-risk-calculator/internal/db/dbutil"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/utils"
+)
+
+// FrequencyColumns are the frequency cache table columns. Each cached map is stored as one
+// row per variant, and every row carries the map's size so a partially written map is
+// recognised and treated as a miss.
+var FrequencyColumns = []string{"set_hash", "ancestry", "source", "variant_id", "frequency", "variant_count", CreatedAtColumn}
+
+// FrequencyRequest identifies one cached allele frequency map: the frequencies of a
+// variant set for an ancestry, as read from one frequency source (e.g. a gnomAD release).
+type FrequencyRequest struct {
+	SetHash  string // VariantSetHash of the variant IDs looked up
+	Ancestry string
+	Source   string
+}
+
+// FrequencyEntry is a frequency map to store.
+type FrequencyEntry struct {
+	Request     FrequencyRequest
+	Frequencies map[string]float64 // variant ID -> allele frequency
+}
+
+// FrequencyCache stores allele frequency lookups so repeated runs over the same model skip
+// the frequency query.
+type FrequencyCache interface {
+	// GetFrequencies returns the complete cached maps among reqs; missing requests are absent.
+	GetFrequencies(ctx context.Context, reqs []FrequencyRequest) (map[FrequencyRequest]map[string]float64, error)
+	StoreFrequencies(ctx context.Context, entries []FrequencyEntry) error
+}
+
+// VariantSetHash returns an order-independent SHA-256 of variant IDs.
+func VariantSetHash(variantIDs []string) string {
+	ids := append([]string(nil), variantIDs...)
+	sort.Strings(ids)
+	h := sha256.New()
+	for _, id := range ids {
+		h.Write([]byte(id))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// frequenciesEnabled reports whether a frequency cache table is configured.
+func (c *RepositoryCache) frequenciesEnabled() bool {
+	return c.FreqTableID != ""
+}
+
+// GetFrequencies implements FrequencyCache. It returns no hits when no frequency cache table
+// is configured.
+func (c *RepositoryCache) GetFrequencies(ctx context.Context, reqs []FrequencyRequest) (map[FrequencyRequest]map[string]float64, error) {
+	hits := make(map[FrequencyRequest]map[string]float64)
+	if !c.frequenciesEnabled() || len(reqs) == 0 {
+		return hits, nil
+	}
+
+	table, err := c.qualify(c.FreqTableID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build fully qualified table name: %w", err)
+	}
+
+	keys := make([][]interface{}, len(reqs))
+	for i, req := range reqs {
+		keys[i] = []interface{}{req.SetHash, req.Ancestry, req.Source}
+	}
+
+	counts := make(map[FrequencyRequest]int)
+	for _, chunk := range dbutil.Chunks(keys, dbutil.DefaultChunkSize/3) {
+		query, args, err := c.dialect().Select("set_hash", "ancestry", "source", "variant_id", "frequency", "variant_count").
+			FromQuoted(table).
+			WhereAnyOf("set_hash = ? AND ancestry = ? AND source = ?", chunk).
+			Build()
+		if err != nil {
+			return nil, err
+		}
+		rows, err := c.Repo.Query(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute frequency cache query: %w", err)
+		}
+		for _, row := range rows {
+			req := FrequencyRequest{
+				SetHash:  utils.ToString(row["set_hash"]),
+				Ancestry: utils.ToString(row["ancestry"]),
+				Source:   utils.ToString(row["source"]),
+			}
+			if hits[req] == nil {
+				hits[req] = make(map[string]float64)
+			}
+			hits[req][utils.ToString(row["variant_id"])] = utils.ToFloat64(row["frequency"])
+			counts[req] = int(utils.ToInt64(row["variant_count"]))
+		}
+	}
+
+	for req, freqs := range hits {
+		if len(freqs) != counts[req] {
+			logging.Warn("Ignoring incomplete cached frequencies for set %s (%d of %d variants)", req.SetHash, len(freqs), counts[req])
+			delete(hits, req)
+		}
+	}
+	logging.Debug("Retrieved %d of %d frequency maps from cache", len(hits), len(reqs))
+	return hits, nil
+}
+
+// StoreFrequencies implements FrequencyCache. It does nothing when no frequency cache table
+// is configured. Empty maps are not stored, since they could not be told apart from a miss.
+func (c *RepositoryCache) StoreFrequencies(ctx context.Context, entries []FrequencyEntry) error {
+	if !c.frequenciesEnabled() {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	var rows []map[string]interface{}
+	for _, entry := range entries {
+		for variantID, freq := range entry.Frequencies {
+			rows = append(rows, map[string]interface{}{
+				"set_hash":      entry.Request.SetHash,
+				"ancestry":      entry.Request.Ancestry,
+				"source":        entry.Request.Source,
+				"variant_id":    variantID,
+				"frequency":     freq,
+				"variant_count": len(entry.Frequencies),
+
+				CreatedAtColumn: now,
+			})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	for _, chunk := range dbutil.Chunks(rows, frequencyInsertBatch) {
+		if err := c.Repo.Insert(ctx, c.FreqTableID, chunk); err != nil {
+			return fmt.Errorf("failed to store cached frequencies: %w", err)
+		}
+	}
+	logging.Debug("Stored %d frequency maps (%d variants) in cache", len(entries), len(rows))
+	return nil
+}
+
+// frequencyInsertBatch bounds the rows per streaming insert, well under BigQuery's request size limit.
+const frequencyInsertBatch = 5000
@@ -0,0 +1,145 @@
+package reference_cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/db"
+	"phite.io/polygenic-risk-calculator/internal/db/dbutil"
+	dbinterface "phite.io/polygenic-risk-calculator/internal/db/interface"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// Domain-specific configuration keys for the cache backend
+const (
+	BackendKey = "cache.backend" // "bigquery" (default) or "local", a DuckDB file needing no GCP access
+	PathKey    = "cache.path"    // DuckDB file of the local backend (default ~/.phite/cache.duckdb)
+)
+
+// Cache backends selectable with BackendKey.
+const (
+	BackendBigQuery = "bigquery"
+	BackendLocal    = "local"
+)
+
+// localColumnTypes are the DuckDB column types of the local cache tables.
+var localColumnTypes = map[string]string{
+	"ancestry": "VARCHAR", "trait": "VARCHAR", "model": "VARCHAR",
+	"mean": "DOUBLE", "std": "DOUBLE", "min": "DOUBLE", "max": "DOUBLE",
+	"set_hash": "VARCHAR", "source": "VARCHAR", "variant_id": "VARCHAR",
+	"frequency": "DOUBLE", "variant_count": "BIGINT",
+	QuantilesColumn: "VARCHAR", CreatedAtColumn: "TIMESTAMP",
+}
+
+// localRepos holds the open local cache files by path. A DuckDB file is locked by the
+// process that opens it, so every reference service in the process shares one connection.
+var (
+	localReposMu sync.Mutex
+	localRepos   = map[string]dbinterface.Repository{}
+)
+
+// NewFromConfig returns the cache selected by BackendKey: the BigQuery cache dataset, or a
+// local DuckDB file.
+func NewFromConfig() (*RepositoryCache, error) {
+	switch backend := config.GetString(BackendKey); backend {
+	case "", BackendBigQuery:
+		if err := config.CheckKeys(config.GCPCacheProjectKey, config.BigQueryCacheDatasetKey, config.GCPBillingProjectKey); err != nil {
+			return nil, fmt.Errorf("the BigQuery cache backend needs a cache dataset (or set %s to %s): %w", BackendKey, BackendLocal, err)
+		}
+		return NewRepositoryCache(nil, map[string]string{
+			"project_id":      config.GetString(config.GCPCacheProjectKey),      // Cache storage project
+			"dataset_id":      config.GetString(config.BigQueryCacheDatasetKey), // Cache dataset
+			"billing_project": config.GetString(config.GCPBillingProjectKey),    // User's billing project
+		})
+	case BackendLocal:
+		path, err := localPathFromConfig()
+		if err != nil {
+			return nil, err
+		}
+		return NewLocalCache(context.Background(), path)
+	default:
+		return nil, fmt.Errorf("%s: unknown cache backend %q: use %s or %s", BackendKey, backend, BackendBigQuery, BackendLocal)
+	}
+}
+
+// localPathFromConfig returns PathKey, or ~/.phite/cache.duckdb when it is not set.
+func localPathFromConfig() (string, error) {
+	if path := config.GetString(PathKey); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("%s is not set and the home directory is unknown: %w", PathKey, err)
+	}
+	return filepath.Join(home, ".phite", "cache.duckdb"), nil
+}
+
+// NewLocalCache returns a cache stored in the DuckDB file at path, creating the file and
+// its stats and frequency tables on first use. Table names come from the same keys as the
+// BigQuery cache's.
+func NewLocalCache(ctx context.Context, path string) (*RepositoryCache, error) {
+	c := &RepositoryCache{
+		TableID:     config.GetString(config.TableCacheTableKey),
+		FreqTableID: config.GetString(config.TableFreqCacheTableKey),
+		local:       true,
+	}
+	if err := dbutil.ValidateIdent(c.TableID); err != nil {
+		return nil, fmt.Errorf("%s: %w", config.TableCacheTableKey, err)
+	}
+	if c.frequenciesEnabled() {
+		if err := dbutil.ValidateIdent(c.FreqTableID); err != nil {
+			return nil, fmt.Errorf("%s: %w", config.TableFreqCacheTableKey, err)
+		}
+	}
+
+	localReposMu.Lock()
+	defer localReposMu.Unlock()
+	repo, ok := localRepos[path]
+	if !ok {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create local cache directory: %w", err)
+		}
+		var err error
+		if repo, err = db.GetRepository(ctx, "duckdb", map[string]string{"path": path}); err != nil {
+			return nil, fmt.Errorf("failed to open local cache: %w", err)
+		}
+		localRepos[path] = repo
+	}
+	c.Repo = repo
+
+	if err := createLocalTable(ctx, repo, c.TableID, Columns); err != nil {
+		return nil, err
+	}
+	if c.frequenciesEnabled() {
+		if err := createLocalTable(ctx, repo, c.FreqTableID, FrequencyColumns); err != nil {
+			return nil, err
+		}
+	}
+	logging.Info("Using local reference stats cache at %s", path)
+	return c, nil
+}
+
+// createLocalTable creates table with columns unless it exists, and adds any of columns a
+// table created by an earlier version lacks.
+func createLocalTable(ctx context.Context, repo dbinterface.Repository, table string, columns []string) error {
+	defs := make([]string, len(columns))
+	for i, col := range columns {
+		defs[i] = dbutil.DuckDB.QuoteIdent(col) + " " + localColumnTypes[col]
+	}
+	stmt := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", dbutil.DuckDB.QuoteIdent(table), strings.Join(defs, ", "))
+	if _, err := repo.Query(ctx, stmt); err != nil {
+		return fmt.Errorf("failed to create local cache table %s: %w", table, err)
+	}
+	for _, def := range defs {
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s", dbutil.DuckDB.QuoteIdent(table), def)
+		if _, err := repo.Query(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to add column to local cache table %s: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package reference_cache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	reference_stats "phite.io/polygenic-risk-calculator/internal/reference/stats"
+)
+
+func TestLocalCache_RoundTrip(t *testing.T) {
+	oldTable, oldFreq := config.GetString(config.TableCacheTableKey), config.GetString(config.TableFreqCacheTableKey)
+	defer config.Set(config.TableCacheTableKey, oldTable)
+	defer config.Set(config.TableFreqCacheTableKey, oldFreq)
+	config.Set(config.TableCacheTableKey, "reference_stats")
+	config.Set(config.TableFreqCacheTableKey, "allele_freq_cache")
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "phite", "cache.duckdb")
+	cache, err := NewLocalCache(ctx, path)
+	require.NoError(t, err)
+
+	req := StatsRequest{Ancestry: "EUR", Trait: "height", ModelID: "PGS000001"}
+	stats := &reference_stats.ReferenceStats{Mean: 0.1, Std: 0.5, Min: -1, Max: 1, Ancestry: "EUR", Trait: "height", Model: "PGS000001"}
+	got, err := cache.Get(ctx, req)
+	require.NoError(t, err)
+	assert.Nil(t, got, "a new cache is empty")
+	require.NoError(t, cache.Store(ctx, req, stats))
+
+	other := StatsRequest{Ancestry: "AFR", Trait: "height", ModelID: "PGS000001"}
+	otherStats := &reference_stats.ReferenceStats{Mean: 0.2, Std: 0.4, Min: -1, Max: 1, Ancestry: "AFR", Trait: "height", Model: "PGS000001", Quantiles: testQuantiles()}
+	require.NoError(t, cache.StoreBatch(ctx, []CacheEntry{{Request: other, Stats: otherStats}}))
+
+	// Reopening shares the process's connection and keeps the stored rows
+	reopened, err := NewLocalCache(ctx, path)
+	require.NoError(t, err)
+	assert.Same(t, cache.Repo, reopened.Repo)
+	got, err = reopened.Get(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, stats, got)
+	batch, err := reopened.GetBatch(ctx, []StatsRequest{req, other, {Ancestry: "EAS", Trait: "height", ModelID: "PGS000001"}})
+	require.NoError(t, err)
+	assert.Len(t, batch, 2)
+	assert.Equal(t, otherStats, batch["AFR|height|PGS000001"])
+
+	freqReq := FrequencyRequest{SetHash: VariantSetHash([]string{"a", "b"}), Ancestry: "EUR", Source: "gnomad:v4"}
+	freqs := map[string]float64{"a": 0.1, "b": 0.2}
+	require.NoError(t, cache.StoreFrequencies(ctx, []FrequencyEntry{{Request: freqReq, Frequencies: freqs}}))
+	hits, err := cache.GetFrequencies(ctx, []FrequencyRequest{freqReq})
+	require.NoError(t, err)
+	assert.Equal(t, freqs, hits[freqReq])
+
+	require.NoError(t, cache.DeleteOlderThan(ctx, time.Now().Add(time.Hour)))
+	got, err = cache.Get(ctx, req)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestLocalCache_AddsQuantilesColumn(t *testing.T) {
+	old := config.GetString(config.TableCacheTableKey)
+	defer config.Set(config.TableCacheTableKey, old)
+	config.Set(config.TableCacheTableKey, "reference_stats")
+
+	// A cache file created before quantiles were stored gains the column when reopened
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.duckdb")
+	cache, err := NewLocalCache(ctx, path)
+	require.NoError(t, err)
+	_, err = cache.Repo.Query(ctx, "ALTER TABLE reference_stats DROP COLUMN quantiles")
+	require.NoError(t, err)
+
+	cache, err = NewLocalCache(ctx, path)
+	require.NoError(t, err)
+	req := StatsRequest{Ancestry: "EUR", Trait: "height", ModelID: "PGS000001"}
+	stats := &reference_stats.ReferenceStats{Mean: 0.1, Std: 0.5, Min: -1, Max: 1, Ancestry: "EUR", Trait: "height", Model: "PGS000001", Quantiles: testQuantiles()}
+	require.NoError(t, cache.Store(ctx, req, stats))
+	got, err := cache.Get(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, stats, got)
+}
+
+// testQuantiles returns 99 increasing quantiles.
+func testQuantiles() []float64 {
+	q := make([]float64, 99)
+	for i := range q {
+		q[i] = -1 + float64(i)/50
+	}
+	return q
+}
+
+func TestNewFromConfig_BigQueryKeys(t *testing.T) {
+	defer config.Use(config.NewValues(map[string]any{BackendKey: BackendBigQuery}))()
+	_, err := NewFromConfig()
+	assert.ErrorContains(t, err, config.BigQueryCacheDatasetKey)
+	assert.ErrorContains(t, err, config.GCPCacheProjectKey)
+}
+
+func TestNewFromConfig_UnknownBackend(t *testing.T) {
+	old := config.GetString(BackendKey)
+	defer config.Set(BackendKey, old)
+	config.Set(BackendKey, "redis")
+	_, err := NewFromConfig()
+	assert.ErrorContains(t, err, "unknown cache backend")
+}
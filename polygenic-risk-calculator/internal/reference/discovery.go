@@ -0,0 +1,135 @@
+package reference
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
+	"phite.io/polygenic-risk-calculator/internal/db/dbutil"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
+	"phite.io/polygenic-risk-calculator/internal/utils"
+)
+
+// ModelTraits are the PRS models that share at least one variant with a set of rsids.
+type ModelTraits struct {
+	// Records holds one record per matching model variant. An rsid appears once for each
+	// model that contains it.
+	Records []model.GWASSNPRecord
+	// VariantCounts maps each matching trait to the number of distinct rsids in its full
+	// model, so coverage is measured against the model rather than the requested SNPs.
+	VariantCounts map[string]int
+}
+
+// DiscoverModelTraits enumerates traits from the model table instead of GWAS annotations:
+// every model with a variant among rsids is returned, with that variant's effect allele and
+// weight. When an ancestry is given, its weight column is preferred over beta as in LoadModel.
+func (s *ReferenceService) DiscoverModelTraits(ctx context.Context, rsids []string, anc *ancestry.Ancestry) (*ModelTraits, error) {
+	found := &ModelTraits{VariantCounts: make(map[string]int)}
+	if len(rsids) == 0 {
+		return found, nil
+	}
+
+	dialect := dbutil.DialectOf(s.modelDB)
+	for _, chunk := range dbutil.Chunks(rsids, dbutil.DefaultChunkSize) {
+		args := make([]interface{}, len(chunk))
+		for i, rsid := range chunk {
+			args[i] = rsid
+		}
+		query, args, err := dialect.Select().From(s.modelTable).WhereIn("rsid", args).Build()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build model discovery query: %w", err)
+		}
+		rows, err := s.modelDB.Query(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query model variants: %w", err)
+		}
+		for _, row := range rows {
+			record := model.GWASSNPRecord{
+				RSID:        utils.ToString(row["rsid"]),
+				RiskAllele:  utils.ToString(row["risk_allele"]),
+				OtherAllele: utils.ToString(row["other_allele"]),
+				Trait:       utils.ToString(row["trait"]),
+			}
+			record.Beta, record.WeightSource = anc.SelectWeight(row, "beta")
+			if record.Trait == "" || record.RiskAllele == "" || record.Beta == 0 {
+				logging.DebugRepeated("Skipping model variant %s: missing trait, effect allele, or weight", record.RSID)
+				continue
+			}
+			found.Records = append(found.Records, record)
+			found.VariantCounts[record.Trait] = 0
+		}
+	}
+
+	if len(found.VariantCounts) == 0 {
+		logging.Info("No models in %s share a variant with the %d requested SNPs", s.modelTable, len(rsids))
+		return found, nil
+	}
+
+	traits := make([]string, 0, len(found.VariantCounts))
+	for trait := range found.VariantCounts {
+		traits = append(traits, trait)
+	}
+	counts, err := s.CountModelVariants(ctx, traits)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(found.VariantCounts, counts)
+
+	logging.Info("Discovered %d models sharing %d variants with the requested SNPs", len(found.VariantCounts), len(found.Records))
+	return found, nil
+}
+
+// CountModelVariants returns the number of distinct rsids in each of the given models of
+// the model table. Models without rows are left out.
+func (s *ReferenceService) CountModelVariants(ctx context.Context, modelIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(modelIDs))
+	args := make([]interface{}, len(modelIDs))
+	for i, id := range modelIDs {
+		args[i] = id
+	}
+	dialect := dbutil.DialectOf(s.modelDB)
+	for _, chunk := range dbutil.Chunks(args, dbutil.DefaultChunkSize) {
+		query := fmt.Sprintf("SELECT %s, count(DISTINCT %s) AS variant_count FROM %s WHERE %s IN (%s) GROUP BY %s",
+			dialect.QuoteIdent("trait"), dialect.QuoteIdent("rsid"), dialect.QuoteIdent(s.modelTable),
+			dialect.QuoteIdent("trait"), dbutil.Placeholders(len(chunk)), dialect.QuoteIdent("trait"))
+		rows, err := s.modelDB.Query(ctx, query, chunk...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count model variants: %w", err)
+		}
+		for _, row := range rows {
+			counts[utils.ToString(row["trait"])] = int(utils.ToInt64(row["variant_count"]))
+		}
+	}
+	return counts, nil
+}
+
+// TraitModel is a trait the model table can score.
+type TraitModel struct {
+	Trait    string `json:"trait"`
+	Model    string `json:"model"`    // model ID the trait is scored with; see ModelID
+	Variants int    `json:"variants"` // distinct rsids in the model
+}
+
+// ListModelTraits returns every trait in the model table with its variant count, in name
+// order.
+func (s *ReferenceService) ListModelTraits(ctx context.Context) ([]TraitModel, error) {
+	dialect := dbutil.DialectOf(s.modelDB)
+	query := fmt.Sprintf("SELECT %s, count(DISTINCT %s) AS variant_count FROM %s GROUP BY %s ORDER BY %s",
+		dialect.QuoteIdent("trait"), dialect.QuoteIdent("rsid"), dialect.QuoteIdent(s.modelTable),
+		dialect.QuoteIdent("trait"), dialect.QuoteIdent("trait"))
+	rows, err := s.modelDB.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list model traits: %w", err)
+	}
+	traits := make([]TraitModel, 0, len(rows))
+	for _, row := range rows {
+		trait := utils.ToString(row["trait"])
+		if trait == "" {
+			continue
+		}
+		traits = append(traits, TraitModel{Trait: trait, Model: s.ModelID(trait), Variants: int(utils.ToInt64(row["variant_count"]))})
+	}
+	return traits, nil
+}
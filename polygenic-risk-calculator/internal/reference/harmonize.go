@@ -0,0 +1,81 @@
+package reference
+
+import (
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// effectFrequencies converts each trait's alt allele frequencies, keyed by variant ID, to
+// frequencies of the variants' effect alleles. A model's effect allele need not be the
+// frequency source's alt allele: when it is the ref allele the frequency is 1 - f, and when
+// it is given on the opposite strand its complement is matched instead. Palindromic
+// (A/T, C/G) variants are matched as given, since their strand cannot be told from the
+// alleles. Variants whose effect allele is neither ref nor alt on either strand are dropped,
+// so they do not skew the reference distribution. The counts are added to the service's
+// AlleleHarmonization.
+func (s *ReferenceService) effectFrequencies(traitVariants map[string][]model.Variant, freqs map[string]map[string]float64) map[string]map[string]float64 {
+	result := make(map[string]map[string]float64, len(freqs))
+	var total model.AlleleHarmonization
+	for trait, alt := range freqs {
+		out, counts := orientFrequencies(traitVariants[trait], alt)
+		result[trait] = out
+		if !counts.Empty() {
+			logging.Debug("Trait %s: %d effect alleles swapped, %d flipped, %d palindromic, %d frequencies dropped",
+				trait, counts.Swapped, counts.Flipped, counts.Ambiguous, counts.Dropped)
+		}
+		total.Add(counts)
+	}
+	if !total.Empty() {
+		logging.Info("Reference allele harmonization: %d effect alleles swapped, %d flipped, %d palindromic, %d frequencies dropped",
+			total.Swapped, total.Flipped, total.Ambiguous, total.Dropped)
+		s.allelesMu.Lock()
+		s.alleles.Add(total)
+		s.allelesMu.Unlock()
+	}
+	return result
+}
+
+// AlleleHarmonization returns the counts of effect allele frequencies the service has
+// swapped, strand-flipped, or dropped so far, one per variant per frequency lookup.
+func (s *ReferenceService) AlleleHarmonization() model.AlleleHarmonization {
+	s.allelesMu.Lock()
+	defer s.allelesMu.Unlock()
+	return s.alleles
+}
+
+// orientFrequencies returns the effect allele frequencies of variants from their alt allele
+// frequencies, as effectFrequencies describes. Variants without an effect allele, or with
+// alleles too long to complement, keep their alt allele frequency unless it is plainly ref.
+func orientFrequencies(variants []model.Variant, alt map[string]float64) (map[string]float64, model.AlleleHarmonization) {
+	out := make(map[string]float64, len(alt))
+	var counts model.AlleleHarmonization
+	for _, v := range variants {
+		f, ok := alt[v.ID]
+		if !ok {
+			continue
+		}
+		palindromic := len(v.Ref) == 1 && v.Alt == string(model.Complement(v.Ref[0]))
+		if palindromic {
+			counts.Ambiguous++
+		}
+		switch effect := v.EffectAllele; {
+		case effect == "" || effect == v.Alt:
+			out[v.ID] = f
+		case effect == v.Ref:
+			out[v.ID] = 1 - f
+			counts.Swapped++
+		case len(effect) != 1 || model.Complement(effect[0]) == effect[0]:
+			out[v.ID] = f
+		case string(model.Complement(effect[0])) == v.Alt:
+			out[v.ID] = f
+			counts.Flipped++
+		case string(model.Complement(effect[0])) == v.Ref:
+			out[v.ID] = 1 - f
+			counts.Flipped++
+			counts.Swapped++
+		default:
+			counts.Dropped++
+		}
+	}
+	return out, counts
+}
@@ -0,0 +1,33 @@
+package reference
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+func TestOrientFrequencies(t *testing.T) {
+	variants := []model.Variant{
+		{ID: "1:10:A:G", Ref: "A", Alt: "G", EffectAllele: "G"}, // effect is alt
+		{ID: "1:20:A:G", Ref: "A", Alt: "G", EffectAllele: "A"}, // effect is ref
+		{ID: "1:30:A:G", Ref: "A", Alt: "G", EffectAllele: "C"}, // alt on the opposite strand
+		{ID: "1:40:A:G", Ref: "A", Alt: "G", EffectAllele: "T"}, // ref on the opposite strand
+		{ID: "1:50:A:T", Ref: "A", Alt: "T", EffectAllele: "A"}, // palindromic
+		{ID: "1:60:A:G", Ref: "A", Alt: "G", EffectAllele: "CT"},
+		{ID: "1:70:C:T", Ref: "C", Alt: "T", EffectAllele: "C"}, // no frequency
+		{ID: "1:80:A:C", Ref: "A", Alt: "C", EffectAllele: "G"}, // G is alt C on the opposite strand
+		{ID: "1:90:A:T", Ref: "A", Alt: "T", EffectAllele: "G"}, // neither allele on either strand
+	}
+	alt := map[string]float64{
+		"1:10:A:G": 0.2, "1:20:A:G": 0.2, "1:30:A:G": 0.2, "1:40:A:G": 0.2, "1:50:A:T": 0.2,
+		"1:60:A:G": 0.2, "1:80:A:C": 0.2, "1:90:A:T": 0.2,
+	}
+
+	got, counts := orientFrequencies(variants, alt)
+	assert.InDeltaMapValues(t, map[string]float64{
+		"1:10:A:G": 0.2, "1:20:A:G": 0.8, "1:30:A:G": 0.2, "1:40:A:G": 0.8, "1:50:A:T": 0.8,
+		"1:60:A:G": 0.2, "1:80:A:C": 0.2,
+	}, got, 1e-9)
+	assert.Equal(t, model.AlleleHarmonization{Flipped: 3, Swapped: 3, Ambiguous: 2, Dropped: 1}, counts)
+}
@@ -0,0 +1,161 @@
+package reference
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// Domain-specific configuration keys for the PRS model cache
+const (
+	ModelCacheKey    = "reference.model_cache"     // Keep loaded PRS models in memory across traits and runs in this process
+	ModelCacheDirKey = "reference.model_cache_dir" // Also persist loaded models here so later processes skip the query; implies model_cache
+)
+
+// ModelCache holds loaded PRS models keyed by table, trait, and weight column. Each entry
+// records the model database's version (its modification time), and a lookup with a
+// different version misses, so editing the database invalidates every cached model.
+//
+// Cached models are shared between callers and must not be modified.
+type ModelCache struct {
+	mu      sync.Mutex
+	entries map[string]modelCacheEntry
+	dir     string // optional on-disk store; empty keeps models in memory only
+}
+
+type modelCacheEntry struct {
+	Version string
+	Model   *model.PRSModel
+}
+
+// NewModelCache creates an empty cache. When dir is non-empty, models are also written
+// there and read back on a memory miss.
+func NewModelCache(dir string) *ModelCache {
+	return &ModelCache{entries: make(map[string]modelCacheEntry), dir: dir}
+}
+
+var (
+	sharedModelCacheOnce sync.Once
+	sharedModelCache     *ModelCache
+)
+
+// modelCacheFromConfig returns the process-wide model cache, or nil when caching is disabled.
+// The cache is shared so batch runs and server requests, which each create a ReferenceService,
+// load every model once.
+func modelCacheFromConfig() *ModelCache {
+	dir := config.GetString(ModelCacheDirKey)
+	if !config.GetBool(ModelCacheKey) && dir == "" {
+		return nil
+	}
+	sharedModelCacheOnce.Do(func() {
+		sharedModelCache = NewModelCache(dir)
+	})
+	return sharedModelCache
+}
+
+func modelCacheKey(table, trait, weightColumn string) string {
+	return table + "|" + trait + "|" + weightColumn
+}
+
+// Get returns the model cached under key for version, checking the on-disk store after memory.
+func (c *ModelCache) Get(key, version string) (*model.PRSModel, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && entry.Version == version {
+		return entry.Model, true
+	}
+	if c.dir == "" {
+		return nil, false
+	}
+
+	entry, err := c.readFile(key)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logging.Warn("Ignoring unreadable cached model for %s: %v", key, err)
+		}
+		return nil, false
+	}
+	if entry.Version != version {
+		return nil, false
+	}
+	c.mu.Lock()
+	c.entries[key] = entry
+	c.mu.Unlock()
+	return entry.Model, true
+}
+
+// Put stores m under key for version, replacing any model cached for an older version.
+// A failure to write the on-disk copy is logged; the in-memory entry is still kept.
+func (c *ModelCache) Put(key, version string, m *model.PRSModel) {
+	entry := modelCacheEntry{Version: version, Model: m}
+	c.mu.Lock()
+	c.entries[key] = entry
+	c.mu.Unlock()
+	if c.dir == "" {
+		return
+	}
+	if err := c.writeFile(key, entry); err != nil {
+		logging.Warn("Failed to persist cached model for %s: %v", key, err)
+	}
+}
+
+func (c *ModelCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".gob")
+}
+
+func (c *ModelCache) readFile(key string) (modelCacheEntry, error) {
+	var entry modelCacheEntry
+	f, err := os.Open(c.path(key))
+	if err != nil {
+		return entry, err
+	}
+	defer f.Close()
+	if err := gob.NewDecoder(f).Decode(&entry); err != nil {
+		return entry, fmt.Errorf("decode: %w", err)
+	}
+	return entry, nil
+}
+
+// writeFile writes entry through a temporary file so concurrent readers never see a partial model.
+func (c *ModelCache) writeFile(key string, entry modelCacheEntry) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, "model-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := gob.NewEncoder(tmp).Encode(entry); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// modelVersion identifies the current contents of the model database file at path by its
+// modification time and size. It returns false when the file cannot be inspected, in which
+// case models are not cached because staleness could not be detected.
+func modelVersion(path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()), true
+}
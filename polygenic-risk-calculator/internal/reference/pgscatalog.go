@@ -0,0 +1,314 @@
+package reference
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/input"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// Domain-specific configuration keys for the PGS Catalog model source
+const (
+	ModelSourceKey   = "reference.model_source"    // "table" (default) loads models from the model table; "pgs_catalog" loads PGS IDs from the PGS Catalog
+	PGSCatalogURLKey = "reference.pgs_catalog_url" // PGS Catalog REST API base URL (default https://www.pgscatalog.org/rest)
+	PGSCatalogDirKey = "reference.pgs_catalog_dir" // Directory keeping downloaded scoring files (default ~/.phite/pgs_catalog)
+)
+
+// Model sources.
+const (
+	ModelSourceTable      = "table"
+	ModelSourcePGSCatalog = "pgs_catalog"
+)
+
+// DefaultPGSCatalogURL is the PGS Catalog REST API.
+const DefaultPGSCatalogURL = "https://www.pgscatalog.org/rest"
+
+// pgsBuild is the genome build of the harmonized scoring files loaded, matching the
+// GRCh38 contigs and allele frequencies models are scored against.
+const pgsBuild = "GRCh38"
+
+// pgsIDPattern matches PGS Catalog score IDs, e.g. PGS000001.
+var pgsIDPattern = regexp.MustCompile(`^PGS\d{6,}$`)
+
+// IsPGSID reports whether id is a PGS Catalog score ID.
+func IsPGSID(id string) bool {
+	return pgsIDPattern.MatchString(id)
+}
+
+// PGSCatalog downloads harmonized scoring files from the PGS Catalog. A scoring file never
+// changes once published, so a downloaded file is kept in Dir and reused without asking
+// the catalog again.
+type PGSCatalog struct {
+	BaseURL string // REST API base URL
+	Dir     string // scoring file directory
+	Client  *http.Client
+}
+
+// pgsCatalogFromConfig returns the PGS Catalog when ModelSourceKey selects it, or nil.
+func pgsCatalogFromConfig() (*PGSCatalog, error) {
+	switch source := config.GetString(ModelSourceKey); source {
+	case "", ModelSourceTable:
+		return nil, nil
+	case ModelSourcePGSCatalog:
+	default:
+		return nil, fmt.Errorf("%s: unknown model source %q: use %s or %s", ModelSourceKey, source, ModelSourceTable, ModelSourcePGSCatalog)
+	}
+	c := &PGSCatalog{BaseURL: config.GetString(PGSCatalogURLKey), Dir: config.GetString(PGSCatalogDirKey), Client: input.HTTPClient}
+	if c.BaseURL == "" {
+		c.BaseURL = DefaultPGSCatalogURL
+	}
+	if c.Dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("%s is not set and the home directory is unknown: %w", PGSCatalogDirKey, err)
+		}
+		c.Dir = filepath.Join(home, ".phite", "pgs_catalog")
+	}
+	return c, nil
+}
+
+// ScoringFile returns the local path of the harmonized GRCh38 scoring file of score id,
+// downloading it on first use.
+func (c *PGSCatalog) ScoringFile(ctx context.Context, id string) (string, error) {
+	if !IsPGSID(id) {
+		return "", fmt.Errorf("%q is not a PGS Catalog score ID", id)
+	}
+	path := filepath.Join(c.Dir, fmt.Sprintf("%s_hmPOS_%s.txt.gz", id, pgsBuild))
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	var score struct {
+		Harmonized map[string]struct {
+			Positions string `json:"positions"`
+		} `json:"ftp_harmonized_scoring_files"`
+	}
+	if err := c.get(ctx, strings.TrimRight(c.BaseURL, "/")+"/score/"+id, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&score)
+	}); err != nil {
+		return "", fmt.Errorf("failed to look up %s in the PGS Catalog: %w", id, err)
+	}
+	url := score.Harmonized[pgsBuild].Positions
+	if url == "" {
+		return "", fmt.Errorf("%s has no harmonized %s scoring file in the PGS Catalog", id, pgsBuild)
+	}
+
+	logging.Info("Downloading PGS Catalog scoring file for %s", id)
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", c.Dir, err)
+	}
+	tmp, err := os.CreateTemp(c.Dir, id+"-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	err = c.get(ctx, url, func(r io.Reader) error {
+		_, err := io.Copy(tmp, r)
+		return err
+	})
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to download the scoring file of %s: %w", id, err)
+	}
+	// Renamed into place whole, so concurrent runs never read a partial file
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// get fetches url and passes the response body to read.
+func (c *PGSCatalog) get(ctx context.Context, url string, read func(io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return read(resp.Body)
+}
+
+// Rows reads the scoring file of score id as model table rows.
+func (c *PGSCatalog) Rows(ctx context.Context, id string) ([]map[string]interface{}, error) {
+	path, err := c.ScoringFile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParsePGSScoringFile(f, id)
+}
+
+// pgsColumns maps model table columns to the scoring file columns they are read from, in
+// order of preference. Harmonized positions and rsids are preferred over the authors'.
+var pgsColumns = map[string][]string{
+	"rsid":             {"hm_rsID", "rsID"},
+	"chr":              {"hm_chr", "chr_name"},
+	"chr_pos":          {"hm_pos", "chr_position"},
+	"risk_allele":      {"effect_allele"},
+	"other_allele":     {"other_allele", "hm_inferOtherAllele"},
+	"beta":             {"effect_weight"},
+	"risk_allele_freq": {"allelefrequency_effect"},
+}
+
+// ParsePGSScoringFile reads a PGS Catalog scoring file, plain or gzipped, as model table
+// rows: each row's rsid, chr, chr_pos, risk_allele, other_allele, beta, and
+// risk_allele_freq are mapped from the file's columns, whichever of the catalog's
+// optional columns it has. The effect allele is taken as the alt allele and the other
+// allele as ref. Weights given only as odds or hazard ratios are converted to their log.
+// Variants the catalog could not harmonize (no hm_chr or hm_pos) are skipped; name labels
+// errors.
+func ParsePGSScoringFile(r io.Reader, name string) ([]map[string]interface{}, error) {
+	br, err := gunzip(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scoring file %s: %w", name, err)
+	}
+	scanner := bufio.NewScanner(br)
+	var header map[string]int
+	var rows []map[string]interface{}
+	skipped := 0
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "#") {
+			if build, ok := strings.CutPrefix(line, "#HmPOS_build="); ok && build != pgsBuild {
+				return nil, fmt.Errorf("scoring file %s is harmonized to %s, not %s", name, build, pgsBuild)
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		if header == nil {
+			header = make(map[string]int, len(cols))
+			for i, col := range cols {
+				header[col] = i
+			}
+			if _, ok := header["effect_allele"]; !ok {
+				return nil, fmt.Errorf("scoring file %s has no effect_allele column", name)
+			}
+			_, beta := header["effect_weight"]
+			_, or := header["OR"]
+			_, hr := header["HR"]
+			if !beta && !or && !hr {
+				return nil, fmt.Errorf("scoring file %s has no effect_weight, OR, or HR column", name)
+			}
+			continue
+		}
+
+		field := func(column string) string {
+			for _, col := range pgsColumns[column] {
+				if i, ok := header[col]; ok && i < len(cols) && cols[i] != "" {
+					return cols[i]
+				}
+			}
+			return ""
+		}
+		_, harmonized := header["hm_chr"]
+		if harmonized && (pgsField(header, cols, "hm_chr") == "" || pgsField(header, cols, "hm_pos") == "") {
+			skipped++
+			continue
+		}
+		pos, err := strconv.ParseInt(field("chr_pos"), 10, 64)
+		if err != nil {
+			skipped++
+			continue
+		}
+		beta, err := pgsWeight(header, cols, field("beta"))
+		if err != nil {
+			return nil, fmt.Errorf("scoring file %s: %w", name, err)
+		}
+		other := field("other_allele")
+		if strings.Contains(other, "/") {
+			other = "" // several candidate alleles inferred
+		}
+		row := map[string]interface{}{
+			"rsid":         field("rsid"),
+			"chr":          field("chr"),
+			"chr_pos":      pos,
+			"risk_allele":  field("risk_allele"),
+			"other_allele": other,
+			"ref_allele":   other,
+			"alt_allele":   field("risk_allele"),
+			"beta":         beta,
+		}
+		if freq, err := strconv.ParseFloat(field("risk_allele_freq"), 64); err == nil {
+			row["risk_allele_freq"] = freq
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read scoring file %s: %w", name, err)
+	}
+	if skipped > 0 {
+		logging.Info("Scoring file %s: skipped %d variants without a %s position", name, skipped, pgsBuild)
+	}
+	return rows, nil
+}
+
+// gunzip returns r, decompressed when it is gzip data.
+func gunzip(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return br, nil
+	}
+	return gzip.NewReader(br)
+}
+
+// pgsField returns the value of column in cols, or "".
+func pgsField(header map[string]int, cols []string, column string) string {
+	if i, ok := header[column]; ok && i < len(cols) {
+		return cols[i]
+	}
+	return ""
+}
+
+// pgsWeight returns a variant's weight: effect_weight, or the log of its OR or HR column.
+func pgsWeight(header map[string]int, cols []string, effectWeight string) (float64, error) {
+	if effectWeight != "" {
+		w, err := strconv.ParseFloat(effectWeight, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid effect_weight %q", effectWeight)
+		}
+		return w, nil
+	}
+	for _, col := range []string{"OR", "HR"} {
+		if v := pgsField(header, cols, col); v != "" {
+			ratio, err := strconv.ParseFloat(v, 64)
+			if err != nil || ratio <= 0 {
+				return 0, fmt.Errorf("invalid %s %q", col, v)
+			}
+			return math.Log(ratio), nil
+		}
+	}
+	return 0, nil // rejected as a missing weight when converted
+}
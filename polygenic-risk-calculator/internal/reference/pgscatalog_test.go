@@ -0,0 +1,128 @@
+package reference
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+const pgsScoringFile = `###PGS CATALOG SCORING FILE - see https://www.pgscatalog.org/downloads/#dl_ftp_scoring for additional information
+#pgs_id=PGS000001
+#weight_type=NR
+#HmPOS_build=GRCh38
+rsID	chr_name	chr_position	effect_allele	other_allele	effect_weight	hm_source	hm_rsID	hm_chr	hm_pos	hm_inferOtherAllele
+rs78540526	11	69331418	C	T	0.16	ENSEMBL	rs78540526	11	69564146	
+rs75915166	11	69379161	A	C	0.1	ENSEMBL	rs75915166	11	69611929	
+rs554219	11	69331642	G	C	0.31	liftover		11	69564370	
+rs1000	5	100	A		0.2	Unknown				
+`
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := io.WriteString(zw, s)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestParsePGSScoringFile(t *testing.T) {
+	rows, err := ParsePGSScoringFile(bytes.NewReader(gzipped(t, pgsScoringFile)), "PGS000001")
+	require.NoError(t, err)
+	// The unharmonized variant is skipped; harmonized positions win over the authors'
+	require.Len(t, rows, 3)
+	assert.Equal(t, map[string]interface{}{
+		"rsid": "rs78540526", "chr": "11", "chr_pos": int64(69564146), "risk_allele": "C", "other_allele": "T",
+		"ref_allele": "T", "alt_allele": "C", "beta": 0.16,
+	}, rows[0])
+	assert.Equal(t, "rs554219", rows[2]["rsid"], "falls back to the author's rsid")
+
+	// Odds ratios become log weights
+	rows, err = ParsePGSScoringFile(strings.NewReader("rsID\tchr_name\tchr_position\teffect_allele\tOR\nrs1\t1\t10\tA\t2\n"), "or")
+	require.NoError(t, err)
+	assert.InDelta(t, math.Log(2), rows[0]["beta"], 1e-12)
+
+	_, err = ParsePGSScoringFile(strings.NewReader("#HmPOS_build=GRCh37\nrsID\teffect_allele\teffect_weight\n"), "old")
+	assert.ErrorContains(t, err, "harmonized to GRCh37")
+	_, err = ParsePGSScoringFile(strings.NewReader("rsID\teffect_allele\n"), "noweight")
+	assert.ErrorContains(t, err, "no effect_weight, OR, or HR column")
+}
+
+func TestReferenceService_LoadModel_PGSCatalog(t *testing.T) {
+	var requests atomic.Int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/rest/score/PGS000001":
+			io.WriteString(w, `{"id":"PGS000001","ftp_harmonized_scoring_files":{"GRCh38":{"positions":"`+srv.URL+`/files/PGS000001_hmPOS_GRCh38.txt.gz"}}}`)
+		case "/files/PGS000001_hmPOS_GRCh38.txt.gz":
+			w.Write(gzipped(t, pgsScoringFile))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	for key, value := range map[string]string{ModelSourceKey: ModelSourcePGSCatalog, PGSCatalogURLKey: srv.URL + "/rest", PGSCatalogDirKey: t.TempDir()} {
+		old := config.GetString(key)
+		config.Set(key, value)
+		defer config.Set(key, old)
+	}
+	modelRepo := &mockRepo{queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+		t.Errorf("unexpected model table query: %s", query)
+		return nil, nil
+	}}
+	service, err := NewReferenceService(&mockRepo{}, modelRepo, &mockCache{})
+	require.NoError(t, err)
+	service.SetTraitModels(map[string]string{"breast cancer": "PGS000001"})
+
+	m, err := service.LoadModel(context.Background(), "breast cancer")
+	require.NoError(t, err)
+	assert.Equal(t, "PGS000001", m.ID)
+	require.Len(t, m.Variants, 3)
+	assert.Equal(t, "11:69564146:T:C", m.Variants[0].ID)
+	assert.Equal(t, 0.16, m.Variants[0].EffectWeight)
+
+	// The downloaded file is reused
+	_, err = service.LoadModel(context.Background(), "breast cancer")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+
+	ok, err := service.HasModel(context.Background(), "PGS000001")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, err = service.HasModel(context.Background(), "PGS999999")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestReferenceService_GetAlleleFrequenciesForTraits_SwappedAlleles(t *testing.T) {
+	gnomad := &mockRepo{queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+		return []map[string]interface{}{{"chrom": "11", "pos": int64(100), "ref": "C", "alt": "T", "AF_nfe": 0.2}}, nil
+	}}
+	service, err := NewReferenceService(gnomad, &mockRepo{}, &mockCache{})
+	require.NoError(t, err)
+	eur, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+
+	// The model takes its effect allele, C, as alt: its frequency is 1 - AF(T)
+	freqs, err := service.GetAlleleFrequenciesForTraits(context.Background(), map[string][]model.Variant{
+		"t": {{ID: "11:100:T:C", Chromosome: "11", Position: 100, Ref: "T", Alt: "C"}},
+	}, eur)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.8, freqs["t"]["11:100:T:C"], 1e-12)
+}
@@ -0,0 +1,90 @@
+package reference
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// SkipTableProbeKey disables the startup check of the allele frequency table.
+const SkipTableProbeKey = "reference.skip_table_probe"
+
+// alleleFreqBaseColumns are selected for every allele frequency lookup regardless of ancestry.
+var alleleFreqBaseColumns = []string{"chrom", "pos", "ref", "alt"}
+
+// ProbeAlleleFrequencyTable verifies, using table metadata only, that the configured allele
+// frequency table exists and carries the frequency columns of every given ancestry. With no
+// ancestries it checks the configured cohort, or every built-in gnomAD ancestry mapping.
+// It is meant to run at startup so misconfiguration fails before the first bulk query.
+func (s *ReferenceService) ProbeAlleleFrequencyTable(ctx context.Context, ancestries ...*ancestry.Ancestry) error {
+	hint := fmt.Sprintf("check %s and %s", config.TableAlleleFreqTableKey, config.BigQueryGnomadDatasetKey)
+	if config.GetString(CohortPathKey) != "" {
+		hint = fmt.Sprintf("check %s and %s", config.TableAlleleFreqTableKey, CohortPathKey)
+	}
+
+	if s.alleleFreqTable == "" {
+		return fmt.Errorf("allele frequency table is not configured: set %s", config.TableAlleleFreqTableKey)
+	}
+
+	if len(ancestries) == 0 {
+		var err error
+		if ancestries, err = configuredAncestries(); err != nil {
+			return err
+		}
+	}
+
+	logging.Info("Probing allele frequency table %q for %d ancestries", s.alleleFreqTable, len(ancestries))
+
+	if err := s.gnomadDB.ValidateTable(ctx, s.alleleFreqTable, alleleFreqBaseColumns); err != nil {
+		return fmt.Errorf("allele frequency table %q did not resolve (%s): %w", s.alleleFreqTable, hint, err)
+	}
+
+	// One metadata lookup covers the common case; only fall back to per-ancestry checks to
+	// report which ancestries are affected.
+	if err := s.gnomadDB.ValidateTable(ctx, s.alleleFreqTable, frequencyColumns(ancestries)); err == nil {
+		logging.Info("Allele frequency table %q resolved for all %d ancestries", s.alleleFreqTable, len(ancestries))
+		return nil
+	}
+
+	var failed []string
+	for _, a := range ancestries {
+		if err := s.gnomadDB.ValidateTable(ctx, s.alleleFreqTable, a.ColumnPrecedence()); err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%s)", a.Code(), strings.Join(a.ColumnPrecedence(), ", ")))
+		}
+	}
+	if len(failed) == 0 {
+		return fmt.Errorf("allele frequency table %q is missing frequency columns (%s)", s.alleleFreqTable, hint)
+	}
+	return fmt.Errorf("allele frequency table %q is missing frequency columns for %d ancestries: %s (%s)",
+		s.alleleFreqTable, len(failed), strings.Join(failed, "; "), hint)
+}
+
+// configuredAncestries returns the configured custom cohort, or every built-in gnomAD ancestry.
+func configuredAncestries() ([]*ancestry.Ancestry, error) {
+	if config.GetString(ancestry.CohortKey) == "" {
+		return ancestry.All(), nil
+	}
+	cohort, err := ancestry.NewFromConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve cohort ancestry: %w", err)
+	}
+	return []*ancestry.Ancestry{cohort}, nil
+}
+
+// frequencyColumns returns the union of the ancestries' frequency columns, in first-seen order.
+func frequencyColumns(ancestries []*ancestry.Ancestry) []string {
+	var columns []string
+	for _, a := range ancestries {
+		for _, col := range a.ColumnPrecedence() {
+			if !slices.Contains(columns, col) {
+				columns = append(columns, col)
+			}
+		}
+	}
+	return columns
+}
@@ -0,0 +1,30 @@
+package reference
+
+import (
+	"slices"
+
+	reference_cache "phite.io/polygenic-risk-calculator/internal/reference/cache"
+	"phite.io/polygenic-risk-calculator/internal/schema"
+)
+
+// ModelColumns are the PRS model table columns LoadModel depends on.
+var ModelColumns = []string{"trait", "rsid", "beta", "risk_allele", "chr", "chr_pos", "ref_allele", "alt_allele"}
+
+// SchemaChecks returns the table checks for the allele frequency ("gnomad"), model, and
+// cache tables. The cache check is only included when the cache is repository-backed.
+func (s *ReferenceService) SchemaChecks() ([]schema.Check, error) {
+	ancestries, err := configuredAncestries()
+	if err != nil {
+		return nil, err
+	}
+	freqColumns := append(slices.Clone(alleleFreqBaseColumns), frequencyColumns(ancestries)...)
+
+	checks := []schema.Check{
+		{Name: "gnomad", Table: s.alleleFreqTable, Columns: freqColumns, Repo: s.gnomadDB},
+		{Name: "model", Table: s.modelTable, Columns: ModelColumns, Repo: s.modelDB},
+	}
+	if rc, ok := s.ReferenceCache.(*reference_cache.RepositoryCache); ok {
+		checks = append(checks, schema.Check{Name: "cache", Table: rc.TableID, Columns: reference_cache.Columns, Repo: rc.Repo})
+	}
+	return checks, nil
+}
@@ -2,13 +2,21 @@ package reference
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"phite.io/polygenic-risk-calculator/internal/ancestry"
 	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/contig"
 	"phite.io/polygenic-risk-calculator/internal/db"
+	"phite.io/polygenic-risk-calculator/internal/db/dbutil"
 	dbinterface "phite.io/polygenic-risk-calculator/internal/db/interface"
+	"phite.io/polygenic-risk-calculator/internal/dosage"
+	"phite.io/polygenic-risk-calculator/internal/events"
+	"phite.io/polygenic-risk-calculator/internal/limits"
 	"phite.io/polygenic-risk-calculator/internal/logging"
 	reference_cache "phite.io/polygenic-risk-calculator/internal/reference/cache"
 	reference_stats "phite.io/polygenic-risk-calculator/internal/reference/stats"
@@ -24,6 +32,20 @@ type ReferenceService struct {
 	ReferenceCache  reference_cache.Cache
 	modelTable      string
 	alleleFreqTable string
+	budget          TraitBudget
+	limits          limits.Limits
+	models          *ModelCache       // optional; nil loads every model from modelDB
+	modelPath       string            // model database file, whose modification time versions cached models
+	pinnedVersion   string            // model release label included in reference stats cache keys
+	traitModels     map[string]string // lower-cased trait -> model ID; unmapped traits are their own model
+	dosage          *dosage.Selector  // dosage coding of each model, which its reference stats are derived under; nil is additive
+	modelChunkSize  int               // model rows read per query; 0 reads a model in one query
+	pgsCatalog      *PGSCatalog       // loads PGS IDs from the PGS Catalog; nil reads every model from the model table
+	topContributors int               // variance contributions recorded with computed stats
+	contigs         *contig.Normalizer
+	allelesMu       sync.Mutex
+	alleles         model.AlleleHarmonization // effect allele frequencies swapped, flipped, or dropped so far
+	config          config.Provider           // configuration the service was created with; nil for the process configuration
 }
 
 // ReferenceStatsRequest represents a request for reference statistics computation
@@ -32,22 +54,131 @@ type ReferenceStatsRequest struct {
 	Trait    string
 }
 
+// Domain-specific configuration keys for the reference service
+const (
+	CohortPathKey       = "reference.cohort_path"       // Custom cohort allele frequency table (.csv, .tsv, or .duckdb); replaces gnomAD when set
+	FrequencyVersionKey = "reference.frequency_version" // Version tag for cached allele frequencies; defaults to the frequency table's name
+	ModelChunkSizeKey   = "reference.model_chunk_size"  // Model rows read per query (default 100000); 0 or less reads each model in one query
+
+	FrequencyConcurrencyKey = "reference.frequency_concurrency" // Chromosome frequency queries run at once (default 4); 1 runs them one after another
+	TopContributorsKey      = "reference.top_contributors"      // Largest variance contributions recorded with computed reference stats (default 10); 0 disables
+)
+
+// defaultFrequencyConcurrency is the chromosome queries run at once when
+// FrequencyConcurrencyKey is unset.
+const defaultFrequencyConcurrency = 4
+
+// defaultModelChunkSize is the model rows read per query when ModelChunkSizeKey is unset.
+const defaultModelChunkSize = 100000
+
+// defaultTopContributors is the variance contributions recorded when TopContributorsKey
+// is unset.
+const defaultTopContributors = 10
+
+// modelOrder orders a model's rows so pages are stable across queries.
+var modelOrder = []string{"chr", "chr_pos", "rsid", "risk_allele", "ref_allele", "alt_allele"}
+
+// modelChunkSizeFromConfig returns the model rows read per query; 0 reads each model in
+// one query.
+func modelChunkSizeFromConfig() int {
+	if !config.HasKey(ModelChunkSizeKey) {
+		return defaultModelChunkSize
+	}
+	return max(config.GetInt(ModelChunkSizeKey), 0)
+}
+
+// frequencyConcurrencyFromConfig returns how many frequency queries may run at once.
+func frequencyConcurrencyFromConfig() int {
+	if !config.HasKey(FrequencyConcurrencyKey) {
+		return defaultFrequencyConcurrency
+	}
+	return max(config.GetInt(FrequencyConcurrencyKey), 1)
+}
+
+// topContributorsFromConfig returns the variance contributions recorded per trait.
+func topContributorsFromConfig() int {
+	if !config.HasKey(TopContributorsKey) {
+		return defaultTopContributors
+	}
+	return max(config.GetInt(TopContributorsKey), 0)
+}
+
 func init() {
-	// Register required infrastructure constants for reference service
+	// Register required infrastructure constants for reference service. GCP keys depend on
+	// the backends in use and are checked when the service creates them.
 	config.RegisterRequiredKey(config.TableModelTableKey)      // Model table reference
 	config.RegisterRequiredKey(config.TableAlleleFreqTableKey) // Allele frequency table reference
-	config.RegisterRequiredKey(config.GCPBillingProjectKey)    // User's billing project for gnomAD queries
-	config.RegisterRequiredKey(config.GCPCacheProjectKey)      // Cache storage project
-	config.RegisterRequiredKey(config.BigQueryCacheDatasetKey) // Cache dataset name
 }
 
 // NewReferenceService creates a new reference service with dependency injection
-// If gnomadDB or ReferenceCache are nil, they will be created using default configuration
-func NewReferenceService(gnomadDB, modelDB dbinterface.Repository, ReferenceCache reference_cache.Cache) (*ReferenceService, error) {
+// If gnomadDB or ReferenceCache are nil, they will be created using default configuration.
+// An optional config.Provider is read instead of the process configuration, and is used
+// again by pipeline runs given this service.
+func NewReferenceService(gnomadDB, modelDB dbinterface.Repository, ReferenceCache reference_cache.Cache, cfg ...config.Provider) (*ReferenceService, error) {
 	var err error
+	var provider config.Provider
+	if len(cfg) > 0 && cfg[0] != nil {
+		provider = cfg[0]
+		defer config.Use(provider)()
+	}
+
+	// Table names from config are interpolated into SQL, so reject anything that is not a
+	// plain identifier before any repository is opened.
+	for _, key := range []string{config.TableModelTableKey, config.TableAlleleFreqTableKey} {
+		if err := dbutil.ValidateConfigIdent(key, config.GetString(key)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ValidateModelVersion(config.GetString(ModelVersionKey)); err != nil {
+		return nil, fmt.Errorf("%s: %w", ModelVersionKey, err)
+	}
+
+	contigs, err := contig.FromConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	traitModels, err := TraitModelsFromConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	pgsCatalog, err := pgsCatalogFromConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err := traitBudgetFromConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	dosageSelector, err := dosage.NewSelectorFromConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid dosage configuration: %w", err)
+	}
+
+	// Create a custom cohort repository if configured; it stands in for gnomAD
+	if cohortPath := config.GetString(CohortPathKey); gnomadDB == nil && cohortPath != "" {
+		if config.GetString(ancestry.CohortKey) == "" {
+			return nil, fmt.Errorf("%s is set but %s is not: name the cohort so its reference stats are cached separately", CohortPathKey, ancestry.CohortKey)
+		}
+		gnomadDB, err = db.GetRepository(context.Background(), "cohort", map[string]string{
+			"path":  cohortPath,
+			"table": config.GetString(config.TableAlleleFreqTableKey),
+		})
+		if err != nil {
+			logging.Error("Failed to create cohort repository: %v", err)
+			return nil, fmt.Errorf("failed to create cohort repository: %w", err)
+		}
+	}
 
 	// Create gnomAD repository if not provided
 	if gnomadDB == nil {
+		if err := config.CheckKeys(config.GCPDataProjectKey, config.BigQueryGnomadDatasetKey, config.GCPBillingProjectKey); err != nil {
+			return nil, fmt.Errorf("gnomAD frequencies are read from BigQuery unless %s is set: %w", CohortPathKey, err)
+		}
 		gnomadDB, err = db.GetRepository(context.Background(), "bq", map[string]string{
 			"project_id":      config.GetString(config.GCPDataProjectKey),        // gnomAD data project
 			"dataset_id":      config.GetString(config.BigQueryGnomadDatasetKey), // gnomAD dataset
@@ -70,12 +201,7 @@ func NewReferenceService(gnomadDB, modelDB dbinterface.Repository, ReferenceCach
 
 	// Create cache if not provided
 	if ReferenceCache == nil {
-		cacheParams := map[string]string{
-			"project_id":      config.GetString(config.GCPCacheProjectKey),      // Cache storage project
-			"dataset_id":      config.GetString(config.BigQueryCacheDatasetKey), // Cache dataset
-			"billing_project": config.GetString(config.GCPBillingProjectKey),    // User's billing project
-		}
-		ReferenceCache, err = reference_cache.NewRepositoryCache(nil, cacheParams)
+		ReferenceCache, err = reference_cache.NewFromConfig()
 		if err != nil {
 			logging.Error("Failed to create cache repository: %v", err)
 			return nil, fmt.Errorf("failed to create cache repository: %w", err)
@@ -88,43 +214,170 @@ func NewReferenceService(gnomadDB, modelDB dbinterface.Repository, ReferenceCach
 		ReferenceCache:  ReferenceCache,
 		modelTable:      config.GetString(config.TableModelTableKey),
 		alleleFreqTable: config.GetString(config.TableAlleleFreqTableKey),
+		budget:          budget,
+		limits:          limits.FromConfig(),
+		models:          modelCacheFromConfig(),
+		modelPath:       config.GetString("gwas_db_path"),
+		pinnedVersion:   config.GetString(ModelVersionKey),
+		traitModels:     traitModels,
+		dosage:          dosageSelector,
+		modelChunkSize:  modelChunkSizeFromConfig(),
+		pgsCatalog:      pgsCatalog,
+		topContributors: topContributorsFromConfig(),
+		contigs:         contigs,
+		config:          provider,
 	}, nil
 }
 
-// LoadModel loads a PRS model from the configured table for a specific trait
-func (s *ReferenceService) LoadModel(ctx context.Context, trait string) (*model.PRSModel, error) {
-	query := fmt.Sprintf(
-		"SELECT * FROM %s WHERE trait = ?",
-		s.modelTable,
-	)
-
-	logging.Info("Loading PRS model for trait: %s", trait)
-	rows, err := s.modelDB.Query(ctx, query, trait)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query model for trait %s: %w", trait, err)
+// ForRun returns a service sharing s's repositories, caches, and configuration with fresh
+// allele and contig tallies, so concurrent or consecutive runs report only their own
+// variants without opening another set of clients. A trait model map set on the returned
+// service, e.g. by a panel, does not affect s.
+func (s *ReferenceService) ForRun() *ReferenceService {
+	return &ReferenceService{
+		gnomadDB:        s.gnomadDB,
+		modelDB:         s.modelDB,
+		ReferenceCache:  s.ReferenceCache,
+		modelTable:      s.modelTable,
+		alleleFreqTable: s.alleleFreqTable,
+		budget:          s.budget,
+		limits:          s.limits,
+		models:          s.models,
+		modelPath:       s.modelPath,
+		pinnedVersion:   s.pinnedVersion,
+		traitModels:     s.traitModels,
+		dosage:          s.dosage,
+		modelChunkSize:  s.modelChunkSize,
+		pgsCatalog:      s.pgsCatalog,
+		topContributors: s.topContributors,
+		contigs:         s.contigs.Fork(),
+		config:          s.config,
 	}
+}
 
-	if len(rows) == 0 {
-		return nil, fmt.Errorf("no variants found for trait: %s", trait)
+// Config returns the configuration the service was created with, or nil if it reads the
+// process configuration.
+func (s *ReferenceService) Config() config.Provider {
+	return s.config
+}
+
+// SetModelCache sets the cache LoadModel consults before querying, and the model database
+// file whose modification time versions the cached models. A nil cache disables caching.
+func (s *ReferenceService) SetModelCache(c *ModelCache, modelPath string) {
+	s.models = c
+	s.modelPath = modelPath
+}
+
+// LoadModel loads a PRS model from the configured table for a specific trait, selecting
+// the rows of the model the trait model map names for it.
+// When an ancestry is given, ancestry-specific weight columns (e.g. beta_eur) are preferred over beta where present.
+func (s *ReferenceService) LoadModel(ctx context.Context, trait string, anc ...*ancestry.Ancestry) (*model.PRSModel, error) {
+	var weightAncestry *ancestry.Ancestry
+	if len(anc) > 0 {
+		weightAncestry = anc[0]
+	}
+
+	modelName := ModelFor(s.traitModels, trait)
+	fromCatalog := s.pgsCatalog != nil && IsPGSID(modelName)
+	cacheKey := modelCacheKey(s.modelTable, modelName, weightAncestry.WeightColumn())
+	version, cacheable := modelVersion(s.modelPath)
+	if fromCatalog {
+		// Published scoring files never change
+		cacheKey = modelCacheKey(ModelSourcePGSCatalog, modelName, "")
+		version, cacheable = pgsBuild, true
+	}
+	cacheable = cacheable && s.models != nil
+	if cacheable {
+		if cached, ok := s.models.Get(cacheKey, version); ok {
+			logging.Info("Using cached PRS model for trait %s (%d variants)", trait, len(cached.Variants))
+			return cached, nil
+		}
 	}
 
+	logging.Info("Loading PRS model for trait: %s", trait)
+	var err error
 	var variants []model.Variant
-	for _, row := range rows {
-		variant, err := s.convertRowToVariant(row)
+	rowCount := 0
+	convert := func(rows []map[string]interface{}) error {
+		rowCount += len(rows)
+		for _, row := range rows {
+			variant, err := s.convertRowToVariant(row, weightAncestry)
+			if errors.Is(err, contig.ErrAltContig) {
+				// Counted in the contig report rather than logged per variant
+				continue
+			}
+			if err != nil {
+				rsid := utils.ToString(row["rsid"])
+				logging.WarnRepeated("Skipping variant %q in trait %q due to conversion error: %v", rsid, trait, err)
+				continue
+			}
+			variants = append(variants, variant)
+		}
+		// Stop paging as soon as the model is over a size cap
+		return s.checkModelSize(trait, len(variants))
+	}
+
+	builder := dbutil.DialectOf(s.modelDB).Select().From(s.modelTable).WhereEq("trait", modelName)
+	if fromCatalog {
+		var rows []map[string]interface{}
+		rows, err = s.pgsCatalog.Rows(ctx, modelName)
+		if err == nil {
+			err = convert(rows)
+		}
+	} else if s.modelChunkSize > 0 {
+		// Each page is converted before the next is read, so only one page of rows is held
+		builder.OrderBy(modelOrder...)
+		err = dbutil.Paginate(ctx, s.modelDB, builder, s.modelChunkSize, func(rows []map[string]interface{}) error {
+			if err := convert(rows); err != nil {
+				return err
+			}
+			// Report progress for models spanning more than one page
+			if rowCount > len(rows) || len(rows) == s.modelChunkSize {
+				logging.Info("Trait %s: loaded %d model rows", trait, rowCount)
+				events.Publish(ctx, events.Event{Type: events.ModelLoading, Trait: trait, Completed: rowCount})
+			}
+			return nil
+		})
+	} else {
+		var query string
+		var args []interface{}
+		query, args, err = builder.Build()
 		if err != nil {
-			rsid := utils.ToString(row["rsid"])
-			logging.Warn("Skipping variant %q in trait %q due to conversion error: %v", rsid, trait, err)
-			continue
+			return nil, fmt.Errorf("failed to build model query: %w", err)
 		}
-		variants = append(variants, variant)
+		var rows []map[string]interface{}
+		rows, err = s.modelDB.Query(ctx, query, args...)
+		if err == nil {
+			err = convert(rows)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, ErrTraitBudgetExceeded) || errors.Is(err, limits.ErrLimitExceeded) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to query model for trait %s: %w", trait, err)
+	}
+
+	if rowCount == 0 {
+		return nil, fmt.Errorf("no variants found for trait: %s", trait)
 	}
 
 	if len(variants) == 0 {
 		return nil, fmt.Errorf("no valid variants found for trait %s after filtering", trait)
 	}
 
+	if col := weightAncestry.WeightColumn(); col != "" {
+		specific := 0
+		for _, v := range variants {
+			if v.WeightSource == col {
+				specific++
+			}
+		}
+		logging.Info("Trait %s: %d/%d variants use %s weights, remainder use beta", trait, specific, len(variants), col)
+	}
+
 	prsModel := &model.PRSModel{
-		ID:       trait, // Use trait as the model identifier
+		ID:       modelName,
 		Variants: variants,
 	}
 
@@ -133,16 +386,129 @@ func (s *ReferenceService) LoadModel(ctx context.Context, trait string) (*model.
 	}
 
 	logging.Info("Successfully loaded PRS model with %d variants", len(variants))
+	if cacheable {
+		s.models.Put(cacheKey, version, prsModel)
+	}
 	return prsModel, nil
 }
 
-// GetAlleleFrequenciesForTraits retrieves allele frequencies for variants across multiple traits in a single BigQuery operation
-// This method optimizes costs by batching all variant queries together instead of making separate queries per trait
+// GetAlleleFrequenciesForTraits retrieves effect allele frequencies for variants across multiple traits in a single BigQuery operation
+// This method optimizes costs by batching all variant queries together instead of making separate queries per trait.
+// Frequencies are harmonized to each variant's effect allele; see effectFrequencies.
 func (s *ReferenceService) GetAlleleFrequenciesForTraits(ctx context.Context, traitVariants map[string][]model.Variant, ancestry *ancestry.Ancestry) (map[string]map[string]float64, error) {
+	freqs, err := s.altFrequenciesForTraits(ctx, traitVariants, ancestry)
+	if err != nil {
+		return nil, err
+	}
+	return s.effectFrequencies(traitVariants, freqs), nil
+}
+
+// altFrequenciesForTraits retrieves the alt allele frequencies of every trait's variants. When the reference cache
+// also caches frequencies, each trait's map is looked up by the hash of its variant set first, and only the traits
+// that miss are queried.
+func (s *ReferenceService) altFrequenciesForTraits(ctx context.Context, traitVariants map[string][]model.Variant, ancestry *ancestry.Ancestry) (map[string]map[string]float64, error) {
 	if len(traitVariants) == 0 {
 		return map[string]map[string]float64{}, nil
 	}
 
+	freqCache, ok := s.ReferenceCache.(reference_cache.FrequencyCache)
+	if !ok {
+		return s.queryAlleleFrequencies(ctx, traitVariants, ancestry)
+	}
+
+	source := s.frequencySource()
+	reqs := make(map[string]reference_cache.FrequencyRequest, len(traitVariants))
+	var lookups []reference_cache.FrequencyRequest
+	for trait, variants := range traitVariants {
+		ids := make([]string, len(variants))
+		for i, v := range variants {
+			ids[i] = v.ID
+		}
+		req := reference_cache.FrequencyRequest{
+			SetHash:  reference_cache.VariantSetHash(ids),
+			Ancestry: ancestry.Code(),
+			Source:   source,
+		}
+		reqs[trait] = req
+		lookups = append(lookups, req)
+	}
+
+	hits, err := freqCache.GetFrequencies(ctx, lookups)
+	if err != nil {
+		// A failed lookup only costs the query it would have saved.
+		logging.Warn("Frequency cache lookup failed, querying all traits: %v", err)
+		hits = nil
+	}
+
+	result := make(map[string]map[string]float64, len(traitVariants))
+	misses := make(map[string][]model.Variant)
+	for trait, variants := range traitVariants {
+		if freqs, found := hits[reqs[trait]]; found {
+			result[trait] = freqs
+			continue
+		}
+		misses[trait] = variants
+	}
+	logging.Info("Frequency cache: %d traits hit, %d to query", len(result), len(misses))
+	if len(misses) == 0 {
+		return result, nil
+	}
+
+	queried, err := s.queryAlleleFrequencies(ctx, misses, ancestry)
+	if err != nil {
+		return nil, err
+	}
+	var entries []reference_cache.FrequencyEntry
+	stored := make(map[reference_cache.FrequencyRequest]bool)
+	for trait, freqs := range queried {
+		result[trait] = freqs
+		// Traits sharing a variant set share one entry.
+		if req := reqs[trait]; !stored[req] {
+			stored[req] = true
+			entries = append(entries, reference_cache.FrequencyEntry{Request: req, Frequencies: freqs})
+		}
+	}
+	if err := freqCache.StoreFrequencies(ctx, entries); err != nil {
+		logging.Warn("Failed to cache allele frequencies: %v", err)
+	}
+	return result, nil
+}
+
+// frequencySource names the allele frequency data cached frequencies were read from, so
+// switching gnomAD releases or cohorts does not serve stale frequencies. FrequencyVersionKey
+// overrides it when a source is updated in place.
+func (s *ReferenceService) frequencySource() string {
+	if v := config.GetString(FrequencyVersionKey); v != "" {
+		return v
+	}
+	if cohortPath := config.GetString(CohortPathKey); cohortPath != "" {
+		return "cohort:" + cohortPath + "/" + s.alleleFreqTable
+	}
+	return "gnomad:" + config.GetString(config.GCPDataProjectKey) + "." +
+		config.GetString(config.BigQueryGnomadDatasetKey) + "." + s.alleleFreqTable
+}
+
+// queryAlleleFrequencies queries the frequency source for every trait's variants in one batch.
+func (s *ReferenceService) queryAlleleFrequencies(ctx context.Context, traitVariants map[string][]model.Variant, anc *ancestry.Ancestry) (map[string]map[string]float64, error) {
+	byAncestry, err := s.queryAlleleFrequenciesByAncestry(ctx, traitVariants, []*ancestry.Ancestry{anc})
+	if err != nil {
+		return nil, err
+	}
+	return byAncestry[anc.Code()], nil
+}
+
+// queryAlleleFrequenciesByAncestry queries the frequency source for every trait's variants
+// in one batch, selecting the frequency columns of all the given ancestries at once. The
+// result maps ancestry code -> trait -> variant ID -> frequency.
+func (s *ReferenceService) queryAlleleFrequenciesByAncestry(ctx context.Context, traitVariants map[string][]model.Variant, ancestries []*ancestry.Ancestry) (map[string]map[string]map[string]float64, error) {
+	empty := func() map[string]map[string]map[string]float64 {
+		result := make(map[string]map[string]map[string]float64, len(ancestries))
+		for _, anc := range ancestries {
+			result[anc.Code()] = map[string]map[string]float64{}
+		}
+		return result
+	}
+
 	// Collect all unique variants across all traits to avoid duplicates in the query
 	uniqueVariants := make(map[string]model.Variant)
 	for trait, variants := range traitVariants {
@@ -159,92 +525,183 @@ func (s *ReferenceService) GetAlleleFrequenciesForTraits(ctx context.Context, tr
 
 	if len(uniqueVariants) == 0 {
 		logging.Info("No variants found across all traits")
-		return map[string]map[string]float64{}, nil
+		return empty(), nil
+	}
+
+	// Get all columns needed for each ancestry's precedence logic
+	selectCols := []string{"chrom", "pos", "ref", "alt"}
+	selected := make(map[string]bool)
+	codes := make([]string, len(ancestries))
+	for i, anc := range ancestries {
+		codes[i] = anc.Code()
+		for _, col := range anc.ColumnPrecedence() {
+			if !selected[col] {
+				selected[col] = true
+				selectCols = append(selectCols, col)
+			}
+		}
 	}
 
-	// Get all columns needed for this ancestry's precedence logic
-	columns := ancestry.ColumnPrecedence()
-	selectCols := append([]string{"chrom", "pos", "ref", "alt"}, columns...)
-
-	// Build consolidated variant filters for all unique variants
-	var filters []string
-	var args []interface{}
+	// Build variant filters for all unique variants, grouped by chromosome
+	byChrom := make(map[string][][]interface{})
 	for _, v := range uniqueVariants {
 		if v.Chromosome == "" || v.Position == 0 {
-			logging.Debug("cannot build filter for variant %s, missing chrom/pos", *v.RSID)
+			logging.DebugRepeated("cannot build filter for variant %s, missing chrom/pos", *v.RSID)
 			continue
 		}
-		filters = append(filters, "(chrom = ? AND pos = ?)")
-		args = append(args, v.Chromosome, v.Position)
+		chrom := s.contigs.FrequencyName(v.Chromosome)
+		byChrom[chrom] = append(byChrom[chrom], []interface{}{chrom, v.Position})
 	}
 
-	if len(filters) == 0 {
+	if len(byChrom) == 0 {
 		logging.Info("No variants with sufficient information for allele frequency lookup")
-		return map[string]map[string]float64{}, nil
+		return empty(), nil
 	}
 
-	// Build and execute single consolidated query for all variants
-	query := fmt.Sprintf(
-		"SELECT %s FROM %s WHERE %s",
-		strings.Join(selectCols, ", "),
-		s.alleleFreqTable,
-		strings.Join(filters, " OR "),
-	)
-
-	logging.Info("Querying allele frequencies for %d unique variants across %d traits with ancestry %s",
-		len(uniqueVariants), len(traitVariants), ancestry.Code())
-	rows, err := s.gnomadDB.Query(ctx, query, args...)
+	logging.Info("Querying allele frequencies for %d unique variants on %d chromosomes across %d traits with ancestry %s",
+		len(uniqueVariants), len(byChrom), len(traitVariants), strings.Join(codes, ","))
+	rows, err := s.queryFrequencyRows(ctx, selectCols, byChrom)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query allele frequencies: %w", err)
+		return nil, err
 	}
 
-	// Process consolidated results and build frequency map
-	allFreqs := make(map[string]float64)
+	// Process consolidated results and build a frequency map per ancestry
+	allFreqs := make(map[string]map[string]float64, len(ancestries))
+	for _, code := range codes {
+		allFreqs[code] = make(map[string]float64)
+	}
 	for _, row := range rows {
-		// Let ancestry object select the best frequency from available columns
-		freq, usedCol, err := ancestry.SelectFrequency(row)
-		if err != nil {
-			// Skip variants with no frequency data available
-			logging.Debug("No frequency data available for variant in row: %v", err)
+		chrom, pos, ok := s.contigs.Resolve(contig.SourceFrequency, utils.ToString(row["chrom"]), utils.ToInt64(row["pos"]))
+		if !ok {
 			continue
 		}
-
-		chrom := utils.ToString(row["chrom"])
-		pos := utils.ToInt64(row["pos"])
 		ref := utils.ToString(row["ref"])
 		alt := utils.ToString(row["alt"])
-
 		variantID := fmt.Sprintf("%s:%d:%s:%s", chrom, pos, ref, alt)
-		allFreqs[variantID] = freq
 
-		// Log which column was used for debugging
-		logging.Debug("Used column %s for variant %s (frequency: %f)", usedCol, variantID, freq)
+		for _, anc := range ancestries {
+			// Let ancestry object select the best frequency from available columns
+			freq, usedCol, err := anc.SelectFrequency(row)
+			if err != nil {
+				// Skip variants with no frequency data available
+				logging.DebugRepeated("No frequency data available for variant in row: %v", err)
+				continue
+			}
+			allFreqs[anc.Code()][variantID] = freq
+
+			// Log which column was used for debugging
+			logging.DebugRepeated("Used column %s for variant %s (frequency: %f)", usedCol, variantID, freq)
+		}
 	}
 
 	// Partition consolidated results back to per-trait format
-	result := make(map[string]map[string]float64)
-	for trait, variants := range traitVariants {
-		traitFreqs := make(map[string]float64)
-		for _, v := range variants {
-			if freq, found := allFreqs[v.ID]; found {
-				traitFreqs[v.ID] = freq
+	result := make(map[string]map[string]map[string]float64, len(ancestries))
+	for _, code := range codes {
+		result[code] = make(map[string]map[string]float64, len(traitVariants))
+		for trait, variants := range traitVariants {
+			traitFreqs := make(map[string]float64)
+			for _, v := range variants {
+				if freq, found := allFreqs[code][v.ID]; found {
+					traitFreqs[v.ID] = freq
+				} else if freq, found := allFreqs[code][s.swappedID(v)]; found {
+					// The model's ref and alt are the frequency source's alt and ref, as
+					// for PGS Catalog models, whose effect allele is taken as alt
+					traitFreqs[v.ID] = 1 - freq
+				}
 			}
+			result[code][trait] = traitFreqs
+			logging.Debug("Partitioned %d variant frequencies for trait %s (%s)", len(traitFreqs), trait, code)
 		}
-		result[trait] = traitFreqs
-		logging.Debug("Partitioned %d variant frequencies for trait %s", len(traitFreqs), trait)
+		logging.Info("Retrieved allele frequencies for %d unique variants across %d traits using ancestry %s",
+			len(allFreqs[code]), len(traitVariants), code)
 	}
-
-	logging.Info("Retrieved allele frequencies for %d unique variants across %d traits using ancestry %s",
-		len(allFreqs), len(traitVariants), ancestry.Code())
 	return result, nil
 }
 
+// swappedID returns the variant ID of v with ref and alt exchanged.
+func (s *ReferenceService) swappedID(v model.Variant) string {
+	return fmt.Sprintf("%s:%d:%s:%s", s.contigs.Canonical(v.Chromosome), v.Position, v.Alt, v.Ref)
+}
+
+// queryFrequencyRows fetches the frequency rows of the given variant filters, keyed by
+// chromosome. Each chromosome is queried separately, split only to stay under the engine's
+// parameter limit, with up to reference.frequency_concurrency queries in flight; rows are
+// returned in chromosome order whatever order the queries finish in. The first failure
+// cancels the queries still running.
+func (s *ReferenceService) queryFrequencyRows(ctx context.Context, selectCols []string, byChrom map[string][][]interface{}) ([]map[string]interface{}, error) {
+	chroms := make([]string, 0, len(byChrom))
+	for chrom := range byChrom {
+		chroms = append(chroms, chrom)
+	}
+	sort.Strings(chroms)
+	var chunks [][][]interface{}
+	for _, chrom := range chroms {
+		chunks = append(chunks, dbutil.Chunks(byChrom[chrom], dbutil.DefaultChunkSize/2)...)
+	}
+
+	dialect := dbutil.DialectOf(s.gnomadDB)
+	queryCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		failOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	results := make([][]map[string]interface{}, len(chunks))
+	sem := make(chan struct{}, frequencyConcurrencyFromConfig())
+	for i, chunk := range chunks {
+		sem <- struct{}{}
+		if queryCtx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			query, args, err := dialect.Select(selectCols...).
+				From(s.alleleFreqTable).
+				WhereAnyOf("chrom = ? AND pos = ?", chunk).
+				Build()
+			if err != nil {
+				fail(fmt.Errorf("failed to build allele frequency query: %w", err))
+				return
+			}
+			rows, err := s.gnomadDB.Query(queryCtx, query, args...)
+			if err != nil {
+				fail(fmt.Errorf("failed to query allele frequencies for chromosome %v: %w", chunk[0][0], err))
+				return
+			}
+			results[i] = rows
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query allele frequencies: %w", err)
+	}
+
+	var rows []map[string]interface{}
+	for _, r := range results {
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
+
 // convertRowToVariant converts a database row to a Variant
-func (s *ReferenceService) convertRowToVariant(row map[string]interface{}) (model.Variant, error) {
+func (s *ReferenceService) convertRowToVariant(row map[string]interface{}, anc *ancestry.Ancestry) (model.Variant, error) {
 	// Required fields
 	rsid := utils.ToString(row["rsid"])
 
-	effectWeight := utils.ToFloat64(row["beta"])
+	effectWeight, weightSource := anc.SelectWeight(row, "beta")
 	if effectWeight == 0 {
 		// This check can be problematic if an effect weight is genuinely 0.
 		// For now, we assume it indicates a missing value.
@@ -273,8 +730,17 @@ func (s *ReferenceService) convertRowToVariant(row map[string]interface{}) (mode
 		return model.Variant{}, fmt.Errorf("missing chromosome or position for rsid %s", rsid)
 	}
 
-	// Use chrom:pos:ref:alt format for variant ID to match allele frequency lookups
-	variantID := fmt.Sprintf("%s:%d:%s:%s", chrom, pos, ref, alt)
+	canonical, canonicalPos, ok := s.contigs.Resolve(contig.SourceModel, chrom, pos)
+	if !ok {
+		return model.Variant{}, fmt.Errorf("%w: %s", contig.ErrAltContig, chrom)
+	}
+	if contig.IsAlt(chrom) {
+		// Remapped onto the primary assembly; the original contig has no frequency data
+		chrom, pos = canonical, canonicalPos
+	}
+
+	// Use chrom:pos:ref:alt format with the canonical chromosome for variant ID to match allele frequency lookups
+	variantID := fmt.Sprintf("%s:%d:%s:%s", canonical, canonicalPos, ref, alt)
 
 	// To handle duplicate variants from different studies, append study_id to the variant ID.
 	studyID := utils.ToString(row["study_id"])
@@ -292,6 +758,7 @@ func (s *ReferenceService) convertRowToVariant(row map[string]interface{}) (mode
 		EffectAllele: effectAllele,
 		OtherAllele:  otherAllele,
 		EffectFreq:   effectFreq,
+		WeightSource: weightSource,
 	}
 
 	if rsid != "" {
@@ -303,8 +770,25 @@ func (s *ReferenceService) convertRowToVariant(row map[string]interface{}) (mode
 
 // GetReferenceStatsBatch retrieves reference statistics for multiple traits in a single operation.
 // This method optimizes costs by loading all required models, and then making a single
-// consolidated query to get allele frequencies for all variants across all models.
+// consolidated query to get allele frequencies for all variants across all models. With a
+// per-trait timeout, traits are computed one at a time instead, each under its own
+// deadline, so a slow model load or frequency query skips only that trait.
 func (s *ReferenceService) GetReferenceStatsBatch(ctx context.Context, requests []ReferenceStatsRequest) (map[string]*reference_stats.ReferenceStats, []error) {
+	if s.budget.Timeout > 0 {
+		traits := make([]string, len(requests))
+		for i, req := range requests {
+			traits[i] = req.Trait
+		}
+		return s.eachTraitWithinBudget(ctx, traits, func(ctx context.Context, i int) (map[string]*reference_stats.ReferenceStats, []error) {
+			return s.referenceStatsBatch(ctx, requests[i:i+1])
+		})
+	}
+	return s.referenceStatsBatch(ctx, requests)
+}
+
+// referenceStatsBatch computes reference statistics for requests with one bulk allele
+// frequency query.
+func (s *ReferenceService) referenceStatsBatch(ctx context.Context, requests []ReferenceStatsRequest) (map[string]*reference_stats.ReferenceStats, []error) {
 	logging.Info("Getting reference stats in batch for %d traits", len(requests))
 
 	if len(requests) == 0 {
@@ -322,12 +806,12 @@ func (s *ReferenceService) GetReferenceStatsBatch(ctx context.Context, requests
 		if _, ok := traitModels[req.Trait]; ok {
 			continue // Already loaded this model
 		}
-		prsModel, err := s.LoadModel(ctx, req.Trait)
+		prsModel, err := s.loadModelWithinBudget(ctx, req.Trait, req.Ancestry)
 		if err != nil {
 			err = fmt.Errorf("failed to load PRS model for trait %s: %w", req.Trait, err)
 			processingErrors = append(processingErrors, err)
-			logging.Error(err.Error())
-			if len(processingErrors) >= errorCap {
+			logging.Error("%v", err)
+			if errors.Is(err, limits.ErrLimitExceeded) || len(processingErrors) >= errorCap {
 				return nil, processingErrors
 			}
 			continue
@@ -341,7 +825,7 @@ func (s *ReferenceService) GetReferenceStatsBatch(ctx context.Context, requests
 	if err != nil {
 		err = fmt.Errorf("failed to get allele frequencies: %w", err)
 		processingErrors = append(processingErrors, err)
-		logging.Error(err.Error())
+		logging.Error("%v", err)
 		return make(map[string]*reference_stats.ReferenceStats), processingErrors
 	}
 
@@ -353,12 +837,13 @@ func (s *ReferenceService) GetReferenceStatsBatch(ctx context.Context, requests
 			continue
 		}
 		traitFreqs := alleleFrequencies[req.Trait]
+		effects := prsModel.GetEffectSizes()
 
-		stats, err := reference_stats.Compute(traitFreqs, prsModel.GetEffectSizes())
+		stats, err := reference_stats.Compute(traitFreqs, effects, s.coding(req.Trait))
 		if err != nil {
 			err = fmt.Errorf("failed to compute stats for trait %s: %w", req.Trait, err)
 			processingErrors = append(processingErrors, err)
-			logging.Error(err.Error())
+			logging.Error("%v", err)
 			if len(processingErrors) >= errorCap {
 				return results, processingErrors
 			}
@@ -367,7 +852,8 @@ func (s *ReferenceService) GetReferenceStatsBatch(ctx context.Context, requests
 
 		stats.Ancestry = ancestryObj.Code()
 		stats.Trait = req.Trait
-		stats.Model = req.Trait // The model is identified by the trait
+		stats.Model = s.ModelID(req.Trait) // The model is identified by the trait and pinned version
+		stats.Contributors = reference_stats.TopContributors(traitFreqs, effects, s.topContributors, s.coding(req.Trait))
 
 		key := fmt.Sprintf("%s|%s|%s", stats.Ancestry, stats.Trait, stats.Model)
 		results[key] = stats
@@ -388,11 +874,11 @@ func (s *ReferenceService) GetReferenceStats(ctx context.Context, ancestry *ance
 	// Use ancestry code for cache operations
 	ancestryCode := ancestry.Code()
 
-	// Use trait as the model identifier for cache key
+	// Use trait and pinned version as the model identifier for cache key
 	stats, err := s.ReferenceCache.Get(ctx, reference_cache.StatsRequest{
 		Ancestry: ancestryCode,
 		Trait:    trait,
-		ModelID:  trait,
+		ModelID:  s.ModelID(trait),
 	})
 	if err != nil {
 		// This can happen for cache misses, log and continue
@@ -406,10 +892,32 @@ func (s *ReferenceService) GetReferenceStats(ctx context.Context, ancestry *ance
 	return s.computeAndCacheStats(ctx, ancestry, trait)
 }
 
-// computeAndCacheStats computes PRS statistics on the fly and caches the result.
+// computeAndCacheStats computes PRS statistics on the fly, within the per-trait timeout,
+// and caches the result.
 func (s *ReferenceService) computeAndCacheStats(ctx context.Context, ancestry *ancestry.Ancestry, trait string) (*reference_stats.ReferenceStats, error) {
+	traitCtx, cancel := s.traitContext(ctx)
+	defer cancel()
+	stats, err := s.computeStats(traitCtx, ancestry, trait)
+	if err != nil {
+		return nil, s.overBudget(ctx, traitCtx, trait, err)
+	}
+
+	// Cache the result using ancestry code
+	if err := s.ReferenceCache.Store(ctx, reference_cache.StatsRequest{
+		Ancestry: stats.Ancestry,
+		Trait:    trait,
+		ModelID:  stats.Model,
+	}, stats); err != nil {
+		logging.Warn("Failed to cache computed stats: %v", err)
+	}
+
+	return stats, nil
+}
+
+// computeStats loads a trait's model and allele frequencies and computes its stats.
+func (s *ReferenceService) computeStats(ctx context.Context, ancestry *ancestry.Ancestry, trait string) (*reference_stats.ReferenceStats, error) {
 	// Load the PRS model
-	prsModel, err := s.LoadModel(ctx, trait)
+	prsModel, err := s.loadModelWithinBudget(ctx, trait, ancestry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load PRS model: %w", err)
 	}
@@ -422,7 +930,8 @@ func (s *ReferenceService) computeAndCacheStats(ctx context.Context, ancestry *a
 	}
 
 	// Compute stats
-	stats, err := reference_stats.Compute(alleleFrequencies[trait], prsModel.GetEffectSizes())
+	effects := prsModel.GetEffectSizes()
+	stats, err := reference_stats.Compute(alleleFrequencies[trait], effects, s.coding(trait))
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute stats for trait %s: %w", trait, err)
 	}
@@ -430,16 +939,13 @@ func (s *ReferenceService) computeAndCacheStats(ctx context.Context, ancestry *a
 	ancestryCode := ancestry.Code()
 	stats.Ancestry = ancestryCode
 	stats.Trait = trait
-	stats.Model = trait // Use trait as the model identifier
-
-	// Cache the result using ancestry code
-	if err := s.ReferenceCache.Store(ctx, reference_cache.StatsRequest{
-		Ancestry: ancestryCode,
-		Trait:    trait,
-		ModelID:  trait,
-	}, stats); err != nil {
-		logging.Warn("Failed to cache computed stats: %v", err)
-	}
-
+	stats.Model = s.ModelID(trait)
+	stats.Contributors = reference_stats.TopContributors(alleleFrequencies[trait], effects, s.topContributors, s.coding(trait))
 	return stats, nil
 }
+
+// Contigs returns the chromosome normalizer shared by model and frequency matching, so
+// genotype parsing applies the same aliases and ALT policy and one report covers the run.
+func (s *ReferenceService) Contigs() *contig.Normalizer {
+	return s.contigs
+}
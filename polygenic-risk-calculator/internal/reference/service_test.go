@@ -4,13 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/contig"
+	"phite.io/polygenic-risk-calculator/internal/db/dbutil"
+	"phite.io/polygenic-risk-calculator/internal/db/duckdb"
+	dbinterface "phite.io/polygenic-risk-calculator/internal/db/interface"
+	"phite.io/polygenic-risk-calculator/internal/dosage"
+	"phite.io/polygenic-risk-calculator/internal/events"
+	"phite.io/polygenic-risk-calculator/internal/limits"
 	reference_cache "phite.io/polygenic-risk-calculator/internal/reference/cache"
 	reference_stats "phite.io/polygenic-risk-calculator/internal/reference/stats"
 
@@ -19,7 +31,8 @@ import (
 )
 
 type mockRepo struct {
-	queryFunc func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error)
+	queryFunc    func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error)
+	validateFunc func(ctx context.Context, table string, requiredColumns []string) error
 }
 
 func (m *mockRepo) Query(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
@@ -30,6 +43,9 @@ func (m *mockRepo) Insert(ctx context.Context, table string, rows []map[string]i
 }
 func (m *mockRepo) TestConnection(ctx context.Context, table string) error { return nil }
 func (m *mockRepo) ValidateTable(ctx context.Context, table string, requiredColumns []string) error {
+	if m.validateFunc != nil {
+		return m.validateFunc(ctx, table, requiredColumns)
+	}
 	return nil
 }
 
@@ -95,6 +111,10 @@ func TestMain(m *testing.M) {
 		"reference": {
 			"model_table": "model_table",
 			"allele_freq_table": "allele_freq_table"
+		},
+		"tables": {
+			"model_table": "model_table",
+			"allele_freq_table": "allele_freq_table"
 		}
 	}`
 	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
@@ -127,6 +147,72 @@ func TestReferenceService_LoadModel_Success(t *testing.T) {
 	assert.Equal(t, "1:1000:A:G", model.Variants[0].ID)
 }
 
+func TestReferenceService_ConfigProvider(t *testing.T) {
+	cfg := config.NewValues(map[string]any{
+		config.TableModelTableKey:      "embedded_models",
+		config.TableAlleleFreqTableKey: "embedded_freqs",
+	})
+	mockModelRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			assert.Contains(t, query, "embedded_models")
+			return []map[string]interface{}{
+				{"rsid": "rs123", "beta": 0.5, "risk_allele": "A", "chr": "1", "chr_pos": int64(1000), "ref_allele": "A", "alt_allele": "G"},
+			}, nil
+		},
+	}
+
+	service, err := NewReferenceService(&mockRepo{}, mockModelRepo, &mockCache{}, cfg)
+	require.NoError(t, err)
+	assert.Same(t, cfg, service.Config())
+	_, err = service.LoadModel(context.Background(), "Height")
+	require.NoError(t, err)
+
+	// The provider is only installed while the service is created
+	assert.Equal(t, "model_table", config.GetString(config.TableModelTableKey))
+}
+
+func TestReferenceService_ForRun(t *testing.T) {
+	mockModelRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			return []map[string]interface{}{
+				{"rsid": "rs123", "beta": 0.5, "risk_allele": "A", "chr": "1", "chr_pos": int64(1000), "ref_allele": "A", "alt_allele": "G"},
+				{"rsid": "rs456", "beta": 0.2, "risk_allele": "C", "chr": "chr1_KI270762v1_alt", "chr_pos": int64(10), "ref_allele": "C", "alt_allele": "T"},
+			}, nil
+		},
+	}
+	service, err := NewReferenceService(&mockRepo{}, mockModelRepo, &mockCache{})
+	require.NoError(t, err)
+
+	run := service.ForRun()
+	assert.Same(t, service.gnomadDB, run.gnomadDB, "runs share the service's clients")
+	_, err = run.LoadModel(context.Background(), "Height")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{contig.SourceModel: 1}, run.Contigs().Report().Dropped)
+	assert.Empty(t, service.Contigs().Report().Dropped, "tallies are per run")
+	assert.Empty(t, service.ForRun().Contigs().Report().Dropped)
+}
+
+func TestReferenceService_GCPKeysFollowBackends(t *testing.T) {
+	tables := map[string]any{
+		config.TableModelTableKey:      "model_table",
+		config.TableAlleleFreqTableKey: "allele_freq_table",
+		config.TableCacheTableKey:      "cache_table",
+	}
+
+	// Offline: a supplied frequency repository and a local cache need no GCP configuration
+	offline := config.NewValues(tables)
+	offline.Set(reference_cache.BackendKey, reference_cache.BackendLocal)
+	offline.Set(reference_cache.PathKey, filepath.Join(t.TempDir(), "cache.duckdb"))
+	_, err := NewReferenceService(&mockRepo{}, &mockRepo{}, nil, offline)
+	require.NoError(t, err)
+
+	// gnomAD frequencies are read from BigQuery, whose keys are checked before any client is opened
+	_, err = NewReferenceService(nil, &mockRepo{}, &mockCache{}, config.NewValues(tables))
+	require.Error(t, err)
+	assert.ErrorContains(t, err, config.GCPDataProjectKey)
+	assert.ErrorContains(t, err, config.GCPBillingProjectKey)
+}
+
 func TestReferenceService_LoadModel_DBError(t *testing.T) {
 	mockModelRepo := &mockRepo{
 		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
@@ -182,6 +268,70 @@ func TestReferenceService_GetAlleleFrequenciesForTraits_Success(t *testing.T) {
 	assert.Equal(t, 0.3, results["BMI"]["2:2000:C:T"])
 }
 
+// freqCache is a mockCache that also caches allele frequencies.
+type freqCache struct {
+	mockCache
+	entries map[reference_cache.FrequencyRequest]map[string]float64
+}
+
+func (c *freqCache) GetFrequencies(ctx context.Context, reqs []reference_cache.FrequencyRequest) (map[reference_cache.FrequencyRequest]map[string]float64, error) {
+	hits := make(map[reference_cache.FrequencyRequest]map[string]float64)
+	for _, req := range reqs {
+		if freqs, ok := c.entries[req]; ok {
+			hits[req] = freqs
+		}
+	}
+	return hits, nil
+}
+
+func (c *freqCache) StoreFrequencies(ctx context.Context, entries []reference_cache.FrequencyEntry) error {
+	for _, e := range entries {
+		c.entries[e.Request] = e.Frequencies
+	}
+	return nil
+}
+
+func TestReferenceService_GetAlleleFrequenciesForTraits_FrequencyCache(t *testing.T) {
+	var queries atomic.Int32 // chromosome queries run concurrently
+	mockGnomadRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			queries.Add(1)
+			return []map[string]interface{}{
+				{"chrom": "1", "pos": int64(1000), "ref": "A", "alt": "G", "AF_nfe": 0.2},
+				{"chrom": "2", "pos": int64(2000), "ref": "C", "alt": "T", "AF_nfe": 0.3},
+			}, nil
+		},
+	}
+	cache := &freqCache{entries: make(map[reference_cache.FrequencyRequest]map[string]float64)}
+	service, err := NewReferenceService(mockGnomadRepo, &mockRepo{}, cache)
+	assert.NoError(t, err)
+
+	eur, err := ancestry.New("EUR", "")
+	assert.NoError(t, err)
+	traitVariants := map[string][]model.Variant{
+		"Height": {{ID: "1:1000:A:G", Chromosome: "1", Position: 1000}},
+		"BMI":    {{ID: "2:2000:C:T", Chromosome: "2", Position: 2000}},
+	}
+
+	first, err := service.GetAlleleFrequenciesForTraits(context.Background(), traitVariants, eur)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, queries.Load(), "one query per chromosome")
+	assert.Len(t, cache.entries, 2)
+
+	second, err := service.GetAlleleFrequenciesForTraits(context.Background(), traitVariants, eur)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, queries.Load(), "cached traits must not be queried again")
+	assert.Equal(t, first, second)
+
+	// A new frequency version misses the existing entries.
+	prev := config.GetString(FrequencyVersionKey)
+	config.Set(FrequencyVersionKey, "gnomad-v5")
+	defer config.Set(FrequencyVersionKey, prev)
+	_, err = service.GetAlleleFrequenciesForTraits(context.Background(), traitVariants, eur)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, queries.Load())
+}
+
 func TestReferenceService_GetAlleleFrequenciesForTraits_UnsupportedAncestry(t *testing.T) {
 	_, err := ancestry.New("INVALID", "")
 	assert.Error(t, err)
@@ -267,6 +417,85 @@ func TestReferenceService_GetReferenceStats_CacheMissAndCompute(t *testing.T) {
 	assert.True(t, stats.Mean > 0)
 }
 
+func TestReferenceService_DosageCodedStats(t *testing.T) {
+	config.Set(dosage.TraitStrategyKey, map[string]string{"height": "dominant"})
+	defer config.Set(dosage.TraitStrategyKey, map[string]string{})
+
+	mockGnomadRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			return []map[string]interface{}{
+				{"chrom": "1", "pos": int64(123), "ref": "A", "alt": "G", "AF_nfe": 0.1},
+			}, nil
+		},
+	}
+	mockModelRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			return []map[string]interface{}{
+				{"rsid": "rs123", "beta": 0.5, "risk_allele": "G", "chr": "1", "chr_pos": int64(123), "ref_allele": "A", "alt_allele": "G"},
+			}, nil
+		},
+	}
+	var stored reference_cache.StatsRequest
+	cache := &mockCache{
+		getFunc: func(ctx context.Context, req reference_cache.StatsRequest) (*reference_stats.ReferenceStats, error) {
+			return nil, errors.New("cache miss")
+		},
+		storeFunc: func(ctx context.Context, req reference_cache.StatsRequest, stats *reference_stats.ReferenceStats) error {
+			stored = req
+			return nil
+		},
+	}
+	service, err := NewReferenceService(mockGnomadRepo, mockModelRepo, cache)
+	require.NoError(t, err)
+	eur, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+
+	stats, err := service.GetReferenceStats(context.Background(), eur, "Height")
+	require.NoError(t, err)
+	// Carriers of G (p = 0.1) score 0.5: mean β(1-q²), variance β²q²(1-q²)
+	q := 0.9
+	assert.InDelta(t, 0.5*(1-q*q), stats.Mean, 1e-12)
+	assert.InDelta(t, 0.5*q*math.Sqrt(1-q*q), stats.Std, 1e-12)
+	assert.Equal(t, "Height#dominant", stored.ModelID, "coded stats are cached apart from additive ones")
+	assert.Equal(t, "BMI", service.ModelID("BMI"))
+}
+
+func TestReferenceService_ValidateStats(t *testing.T) {
+	mockGnomadRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			return []map[string]interface{}{
+				{"chrom": "1", "pos": int64(123), "ref": "A", "alt": "G", "AF_nfe": 0.1},
+			}, nil
+		},
+	}
+	mockModelRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			return []map[string]interface{}{
+				{"rsid": "rs123", "beta": 0.5, "risk_allele": "A", "chr": "1", "chr_pos": int64(123), "ref_allele": "A", "alt_allele": "G"},
+			}, nil
+		},
+	}
+	// The cached entry was computed from the wrong frequency
+	cache := &mockCache{
+		getFunc: func(ctx context.Context, req reference_cache.StatsRequest) (*reference_stats.ReferenceStats, error) {
+			return &reference_stats.ReferenceStats{Mean: 0.9, Std: 0.3, Min: 0, Max: 1.8}, nil
+		},
+	}
+	service, err := NewReferenceService(mockGnomadRepo, mockModelRepo, cache)
+	require.NoError(t, err)
+	eur, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+
+	validations, err := service.ValidateStats(context.Background(), eur, []string{"Height"}, reference_stats.DefaultValidationOptions())
+	require.NoError(t, err)
+	require.Len(t, validations, 2)
+	assert.Equal(t, "computed", validations[0].Source)
+	assert.True(t, validations[0].OK, "%+v", validations[0].Checks)
+	assert.Equal(t, "cached", validations[1].Source)
+	assert.Equal(t, "Height", validations[1].Trait)
+	assert.False(t, validations[1].OK)
+}
+
 func TestReferenceService_GetAlleleFrequenciesForTraits_EmptyInput(t *testing.T) {
 	service, err := NewReferenceService(&mockRepo{}, &mockRepo{}, &mockCache{})
 	assert.NoError(t, err)
@@ -307,6 +536,82 @@ func TestReferenceService_GetAlleleFrequenciesForTraits_VariantDeduplication(t *
 	assert.Equal(t, 0.2, results["BMI"]["1:1000:A:G"])
 }
 
+func TestReferenceService_GetAlleleFrequenciesForTraits_ChromosomeParallel(t *testing.T) {
+	config.Set(FrequencyConcurrencyKey, 2)
+	defer config.Set(FrequencyConcurrencyKey, defaultFrequencyConcurrency)
+
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	queried := map[interface{}]int{}
+	release := make(chan struct{})
+	var releaseOnce sync.Once
+	mockGnomadRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			mu.Lock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			for i := 0; i < len(args); i += 2 {
+				queried[args[i]]++
+			}
+			if inFlight == 2 {
+				releaseOnce.Do(func() { close(release) }) // both slots taken: let every query finish
+			}
+			mu.Unlock()
+			<-release
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			var rows []map[string]interface{}
+			for i := 0; i < len(args); i += 2 {
+				rows = append(rows, map[string]interface{}{"chrom": args[i], "pos": args[i+1], "ref": "A", "alt": "G", "AF_nfe": 0.1})
+			}
+			return rows, nil
+		},
+	}
+	service, err := NewReferenceService(mockGnomadRepo, &mockRepo{}, &mockCache{})
+	require.NoError(t, err)
+	eur, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+
+	var variants []model.Variant
+	for chrom := 1; chrom <= 6; chrom++ {
+		for pos := int64(1); pos <= 3; pos++ {
+			c := fmt.Sprint(chrom)
+			variants = append(variants, model.Variant{ID: fmt.Sprintf("%s:%d:A:G", c, pos), Chromosome: c, Position: pos})
+		}
+	}
+	results, err := service.GetAlleleFrequenciesForTraits(context.Background(), map[string][]model.Variant{"Height": variants}, eur)
+	require.NoError(t, err)
+	assert.Len(t, results["Height"], len(variants))
+	assert.Equal(t, 2, maxInFlight, "queries in flight should be bounded by %s", FrequencyConcurrencyKey)
+	assert.Len(t, queried, 6)
+	for chrom, n := range queried {
+		assert.Equal(t, 3, n, "chromosome %v should be queried once with all its variants", chrom)
+	}
+}
+
+func TestReferenceService_GetAlleleFrequenciesForTraits_ChromosomeQueryFailure(t *testing.T) {
+	mockGnomadRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			if args[0] == "2" {
+				return nil, errors.New("db error")
+			}
+			return nil, nil
+		},
+	}
+	service, err := NewReferenceService(mockGnomadRepo, &mockRepo{}, &mockCache{})
+	require.NoError(t, err)
+	eur, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+
+	traitVariants := map[string][]model.Variant{
+		"Height": {{ID: "1:1000:A:G", Chromosome: "1", Position: 1000}, {ID: "2:2000:C:T", Chromosome: "2", Position: 2000}},
+	}
+	_, err = service.GetAlleleFrequenciesForTraits(context.Background(), traitVariants, eur)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "chromosome 2")
+}
+
 func TestReferenceService_GetAlleleFrequenciesForTraits_DBError(t *testing.T) {
 	mockGnomadRepo := &mockRepo{
 		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
@@ -449,3 +754,584 @@ func TestReferenceService_GetReferenceStatsBatch_PartialSuccess(t *testing.T) {
 	assert.Contains(t, results, heightKey)
 	assert.NotNil(t, results[heightKey])
 }
+
+func TestReferenceService_GetReferenceStatsBatch_TraitBudget(t *testing.T) {
+	mockGnomadRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			return []map[string]interface{}{
+				{"chrom": "1", "pos": int64(100), "ref": "A", "alt": "G", "AF_nfe": 0.1},
+			}, nil
+		},
+	}
+	mockModelRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			switch args[0].(string) {
+			case "Slow":
+				<-ctx.Done()
+				return nil, ctx.Err()
+			case "Huge":
+				return []map[string]interface{}{
+					{"rsid": "rs1", "beta": 0.1, "risk_allele": "G", "chr": "1", "chr_pos": int64(100), "ref_allele": "A", "alt_allele": "G"},
+					{"rsid": "rs2", "beta": 0.2, "risk_allele": "T", "chr": "1", "chr_pos": int64(200), "ref_allele": "C", "alt_allele": "T"},
+				}, nil
+			default:
+				return []map[string]interface{}{
+					{"rsid": "rs1", "beta": 0.1, "risk_allele": "G", "chr": "1", "chr_pos": int64(100), "ref_allele": "A", "alt_allele": "G"},
+				}, nil
+			}
+		},
+	}
+	service, err := NewReferenceService(mockGnomadRepo, mockModelRepo, &mockCache{})
+	assert.NoError(t, err)
+	service.SetTraitBudget(TraitBudget{Timeout: 20 * time.Millisecond, MaxVariants: 1})
+
+	eur, err := ancestry.New("EUR", "")
+	assert.NoError(t, err)
+
+	results, errs := service.GetReferenceStatsBatch(context.Background(), []ReferenceStatsRequest{
+		{Ancestry: eur, Trait: "Height"},
+		{Ancestry: eur, Trait: "Slow"},
+		{Ancestry: eur, Trait: "Huge"},
+	})
+
+	assert.Len(t, errs, 2)
+	for _, e := range errs {
+		assert.ErrorIs(t, e, ErrTraitBudgetExceeded)
+	}
+	assert.Len(t, results, 1)
+	assert.Contains(t, results, "EUR|Height|Height")
+}
+
+func TestReferenceService_TraitBudgetCoversFrequencyQuery(t *testing.T) {
+	// The Lagging trait's variant is on chromosome 2, whose frequency query never returns
+	mockGnomadRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			if args[0] == "2" {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return []map[string]interface{}{
+				{"chrom": "1", "pos": int64(100), "ref": "A", "alt": "G", "AF_nfe": 0.1},
+			}, nil
+		},
+	}
+	mockModelRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			chr, pos, ref, alt := "1", int64(100), "A", "G"
+			if args[0] == "Lagging" {
+				chr, pos, ref, alt = "2", int64(200), "C", "T"
+			}
+			return []map[string]interface{}{
+				{"rsid": "rs1", "beta": 0.1, "risk_allele": alt, "chr": chr, "chr_pos": pos, "ref_allele": ref, "alt_allele": alt},
+			}, nil
+		},
+	}
+	service, err := NewReferenceService(mockGnomadRepo, mockModelRepo, &mockCache{})
+	require.NoError(t, err)
+	service.SetTraitBudget(TraitBudget{Timeout: 20 * time.Millisecond})
+
+	eur, err := ancestry.New("EUR", "")
+	require.NoError(t, err)
+
+	results, errs := service.GetReferenceStatsBatch(context.Background(), []ReferenceStatsRequest{
+		{Ancestry: eur, Trait: "Height"},
+		{Ancestry: eur, Trait: "Lagging"},
+		{Ancestry: eur, Trait: "BMI"},
+	})
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrTraitBudgetExceeded)
+	assert.ErrorContains(t, errs[0], "Lagging")
+	assert.Contains(t, results, "EUR|Height|Height")
+	assert.Contains(t, results, "EUR|BMI|BMI", "traits after the slow one are still computed")
+
+	results, errs = service.GetReferenceStatsForAncestries(context.Background(), []string{"Lagging", "Height"}, []*ancestry.Ancestry{eur})
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrTraitBudgetExceeded)
+	assert.Contains(t, results, "EUR|Height|Height")
+
+	_, err = service.GetReferenceStats(context.Background(), eur, "Lagging")
+	assert.ErrorIs(t, err, ErrTraitBudgetExceeded)
+}
+
+func TestReferenceService_GetReferenceStatsBatch_ModelVariantLimit(t *testing.T) {
+	var loaded []string
+	mockModelRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			loaded = append(loaded, args[0].(string))
+			return []map[string]interface{}{
+				{"rsid": "rs1", "beta": 0.1, "risk_allele": "G", "chr": "1", "chr_pos": int64(100), "ref_allele": "A", "alt_allele": "G"},
+				{"rsid": "rs2", "beta": 0.2, "risk_allele": "T", "chr": "1", "chr_pos": int64(200), "ref_allele": "C", "alt_allele": "T"},
+			}, nil
+		},
+	}
+	service, err := NewReferenceService(&mockRepo{}, mockModelRepo, &mockCache{})
+	assert.NoError(t, err)
+	service.SetLimits(limits.Limits{MaxModelVariants: 1})
+
+	eur, err := ancestry.New("EUR", "")
+	assert.NoError(t, err)
+
+	results, errs := service.GetReferenceStatsBatch(context.Background(), []ReferenceStatsRequest{
+		{Ancestry: eur, Trait: "Height"},
+		{Ancestry: eur, Trait: "BMI"},
+	})
+
+	assert.Nil(t, results)
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], limits.ErrLimitExceeded)
+	assert.Equal(t, []string{"Height"}, loaded, "the batch stops at the first oversized model")
+}
+
+func TestTraitBudgetFromConfig(t *testing.T) {
+	config.Set(TraitTimeoutKey, "45s")
+	config.Set(TraitMaxVariantsKey, 5000)
+	defer config.Set(TraitTimeoutKey, "")
+	defer config.Set(TraitMaxVariantsKey, 0)
+
+	budget, err := traitBudgetFromConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Second, budget.Timeout)
+	assert.Equal(t, 5000, budget.MaxVariants)
+
+	config.Set(TraitTimeoutKey, "soon")
+	_, err = traitBudgetFromConfig()
+	assert.ErrorContains(t, err, TraitTimeoutKey)
+	_, err = NewReferenceService(&mockRepo{}, &mockRepo{}, &mockCache{})
+	assert.ErrorContains(t, err, TraitTimeoutKey, "an invalid budget fails the service rather than running unbounded")
+}
+
+func TestNewReferenceService_RejectsInjectedTableNames(t *testing.T) {
+	for _, key := range []string{config.TableModelTableKey, config.TableAlleleFreqTableKey} {
+		previous := config.GetString(key)
+		config.Set(key, "models; DROP TABLE cache")
+		_, err := NewReferenceService(&mockRepo{}, &mockRepo{}, &mockCache{})
+		assert.ErrorIs(t, err, dbutil.ErrInvalidIdentifier)
+		assert.ErrorContains(t, err, key)
+		config.Set(key, previous)
+	}
+}
+
+func TestReferenceService_CustomCohortFrequencies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cohort.csv")
+	assert.NoError(t, os.WriteFile(path, []byte("chrom,pos,ref,alt,cohort_af\n1,100,A,G,0.2\n1,200,C,T,0.4\n"), 0644))
+
+	config.Set(CohortPathKey, path)
+	config.Set(config.TableAlleleFreqTableKey, "cohort_freqs")
+	defer config.Set(CohortPathKey, "")
+	defer config.Set(config.TableAlleleFreqTableKey, "allele_freq_table")
+
+	// Without a cohort name, stats would collide with gnomAD cache entries.
+	_, err := NewReferenceService(nil, &mockRepo{}, &mockCache{})
+	assert.ErrorContains(t, err, ancestry.CohortKey)
+
+	config.Set(ancestry.CohortKey, "island")
+	defer config.Set(ancestry.CohortKey, "")
+	service, err := NewReferenceService(nil, &mockRepo{}, &mockCache{})
+	assert.NoError(t, err)
+
+	cohort, err := ancestry.NewCustomCohort("island", "cohort_af")
+	assert.NoError(t, err)
+	rsid := "rs1"
+	variants := map[string][]model.Variant{
+		"Height": {{ID: "1:100:A:G", Chromosome: "1", Position: 100, RSID: &rsid}},
+	}
+	freqs, err := service.GetAlleleFrequenciesForTraits(context.Background(), variants, cohort)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.2, freqs["Height"]["1:100:A:G"], 1e-9)
+}
+
+func TestReferenceService_ProbeAlleleFrequencyTable(t *testing.T) {
+	schema := map[string]bool{"chrom": true, "pos": true, "ref": true, "alt": true}
+	for _, a := range ancestry.All() {
+		for _, col := range a.ColumnPrecedence() {
+			schema[col] = true
+		}
+	}
+	gnomad := &mockRepo{validateFunc: func(ctx context.Context, table string, requiredColumns []string) error {
+		if table != "allele_freq_table" {
+			return fmt.Errorf("table %q does not exist", table)
+		}
+		for _, col := range requiredColumns {
+			if !schema[col] {
+				return fmt.Errorf("table %q is missing required columns: [%s]", table, col)
+			}
+		}
+		return nil
+	}}
+	config.Set(config.TableAlleleFreqTableKey, "allele_freq_table")
+	service, err := NewReferenceService(gnomad, &mockRepo{}, &mockCache{})
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, service.ProbeAlleleFrequencyTable(ctx))
+
+	delete(schema, "AF_nfe_male")
+	err = service.ProbeAlleleFrequencyTable(ctx)
+	assert.ErrorContains(t, err, "EUR_MALE")
+	assert.NotContains(t, err.Error(), "AFR_MALE")
+
+	service.alleleFreqTable = "gnomad_v9"
+	err = service.ProbeAlleleFrequencyTable(ctx)
+	assert.ErrorContains(t, err, "does not exist")
+	assert.ErrorContains(t, err, config.BigQueryGnomadDatasetKey)
+}
+
+func TestReferenceService_SchemaChecks(t *testing.T) {
+	config.Set(config.TableAlleleFreqTableKey, "allele_freq_table")
+	config.Set(config.TableModelTableKey, "model_table")
+
+	service, err := NewReferenceService(&mockRepo{}, &mockRepo{}, &mockCache{})
+	assert.NoError(t, err)
+
+	checks, err := service.SchemaChecks()
+	assert.NoError(t, err)
+	assert.Len(t, checks, 2, "non-repository caches are not checked")
+	assert.Equal(t, "gnomad", checks[0].Name)
+	assert.Contains(t, checks[0].Columns, "AF_nfe_male")
+	assert.Equal(t, ModelColumns, checks[1].Columns)
+
+	config.Set(ancestry.CohortKey, "island")
+	defer config.Set(ancestry.CohortKey, "")
+	checks, err = service.SchemaChecks()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"chrom", "pos", "ref", "alt", "af"}, checks[0].Columns)
+}
+
+func TestReferenceService_LoadModel_AncestryWeights(t *testing.T) {
+	mockModelRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			return []map[string]interface{}{
+				{"rsid": "rs1", "beta": 0.5, "beta_afr": 0.7, "risk_allele": "A", "chr": "1", "chr_pos": int64(1000), "ref_allele": "A", "alt_allele": "G"},
+				{"rsid": "rs2", "beta": 0.2, "beta_afr": nil, "risk_allele": "C", "chr": "1", "chr_pos": int64(2000), "ref_allele": "C", "alt_allele": "T"},
+			}, nil
+		},
+	}
+	service, err := NewReferenceService(&mockRepo{}, mockModelRepo, &mockCache{})
+	assert.NoError(t, err)
+
+	afr, err := ancestry.New("AFR", "MALE")
+	assert.NoError(t, err)
+	m, err := service.LoadModel(context.Background(), "Height", afr)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.7, m.Variants[0].EffectWeight)
+	assert.Equal(t, "beta_afr", m.Variants[0].WeightSource)
+	assert.Equal(t, 0.2, m.Variants[1].EffectWeight)
+	assert.Equal(t, "beta", m.Variants[1].WeightSource)
+
+	m, err = service.LoadModel(context.Background(), "Height")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, m.Variants[0].EffectWeight, "no ancestry uses the generic weight")
+}
+
+func TestReferenceService_LoadModel_ModelCache(t *testing.T) {
+	modelPath := filepath.Join(t.TempDir(), "gwas.duckdb")
+	assert.NoError(t, os.WriteFile(modelPath, []byte("v1"), 0644))
+
+	queries := 0
+	mockModelRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			queries++
+			return []map[string]interface{}{
+				{"rsid": "rs1", "beta": 0.5, "beta_afr": 0.7, "risk_allele": "A", "chr": "1", "chr_pos": int64(1000), "ref_allele": "A", "alt_allele": "G"},
+			}, nil
+		},
+	}
+	cacheDir := t.TempDir()
+	service, err := NewReferenceService(&mockRepo{}, mockModelRepo, &mockCache{})
+	assert.NoError(t, err)
+	service.SetModelCache(NewModelCache(cacheDir), modelPath)
+
+	ctx := context.Background()
+	first, err := service.LoadModel(ctx, "Height")
+	assert.NoError(t, err)
+	second, err := service.LoadModel(ctx, "Height")
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, 1, queries)
+
+	// Ancestry-specific weights are cached separately.
+	afr, err := ancestry.New("AFR", "")
+	assert.NoError(t, err)
+	m, err := service.LoadModel(ctx, "Height", afr)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.7, m.Variants[0].EffectWeight)
+	assert.Equal(t, 2, queries)
+
+	// A fresh process reads the persisted copy instead of querying.
+	service.SetModelCache(NewModelCache(cacheDir), modelPath)
+	m, err = service.LoadModel(ctx, "Height")
+	assert.NoError(t, err)
+	assert.Equal(t, first.Variants, m.Variants)
+	assert.Equal(t, 2, queries)
+
+	// Changing the model database invalidates both copies.
+	later := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(modelPath, later, later))
+	_, err = service.LoadModel(ctx, "Height")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, queries)
+
+	// Without a model file to version against, nothing is cached.
+	service.SetModelCache(NewModelCache(""), "")
+	_, err = service.LoadModel(ctx, "Height")
+	assert.NoError(t, err)
+	_, err = service.LoadModel(ctx, "Height")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, queries)
+}
+
+func TestReferenceService_DiscoverModelTraits(t *testing.T) {
+	mockModelRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			if strings.Contains(query, "GROUP BY") {
+				return []map[string]interface{}{
+					{"trait": "Height", "variant_count": int64(120)},
+					{"trait": "BMI", "variant_count": int64(80)},
+				}, nil
+			}
+			return []map[string]interface{}{
+				{"trait": "Height", "rsid": "rs1", "beta": 0.1, "beta_eur": 0.15, "risk_allele": "A"},
+				{"trait": "BMI", "rsid": "rs1", "beta": 0.2, "risk_allele": "A"},
+				{"trait": "BMI", "rsid": "rs2", "beta": 0.0, "risk_allele": "C"}, // no weight
+			}, nil
+		},
+	}
+	service, err := NewReferenceService(&mockRepo{}, mockModelRepo, &mockCache{})
+	assert.NoError(t, err)
+	eur, err := ancestry.New("EUR", "")
+	assert.NoError(t, err)
+
+	found, err := service.DiscoverModelTraits(context.Background(), []string{"rs1", "rs2"}, eur)
+	assert.NoError(t, err)
+	assert.Len(t, found.Records, 2, "an rsid shared by two models yields a record per model")
+	assert.Equal(t, map[string]int{"Height": 120, "BMI": 80}, found.VariantCounts)
+	for _, r := range found.Records {
+		if r.Trait == "Height" {
+			assert.Equal(t, 0.15, r.Beta)
+			assert.Equal(t, "beta_eur", r.WeightSource)
+		}
+	}
+}
+
+func TestReferenceService_ListModelTraits(t *testing.T) {
+	mockModelRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			assert.Contains(t, query, "GROUP BY")
+			return []map[string]interface{}{
+				{"trait": "BMI", "variant_count": int64(80)},
+				{"trait": "Height", "variant_count": int64(120)},
+			}, nil
+		},
+	}
+	service, err := NewReferenceService(&mockRepo{}, mockModelRepo, &mockCache{})
+	assert.NoError(t, err)
+
+	traits, err := service.ListModelTraits(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []TraitModel{{Trait: "BMI", Model: "BMI", Variants: 80}, {Trait: "Height", Model: "Height", Variants: 120}}, traits)
+}
+
+func TestReferenceService_ModelVersionKeysStats(t *testing.T) {
+	prev := config.GetString(ModelVersionKey)
+	defer config.Set(ModelVersionKey, prev)
+
+	config.Set(ModelVersionKey, "pgs-2024.1")
+	var requested reference_cache.StatsRequest
+	cache := &mockCache{
+		getFunc: func(ctx context.Context, req reference_cache.StatsRequest) (*reference_stats.ReferenceStats, error) {
+			requested = req
+			return &reference_stats.ReferenceStats{Mean: 0, Std: 1}, nil
+		},
+	}
+	service, err := NewReferenceService(&mockRepo{}, &mockRepo{}, cache)
+	assert.NoError(t, err)
+	eur, err := ancestry.New("EUR", "")
+	assert.NoError(t, err)
+
+	_, err = service.GetReferenceStats(context.Background(), eur, "Height")
+	assert.NoError(t, err)
+	assert.Equal(t, "Height@pgs-2024.1", requested.ModelID)
+	assert.Equal(t, "pgs-2024.1", service.ModelVersion().Version)
+
+	config.Set(ModelVersionKey, "v1|EUR")
+	_, err = NewReferenceService(&mockRepo{}, &mockRepo{}, cache)
+	assert.Error(t, err)
+}
+
+func TestModelID(t *testing.T) {
+	assert.Equal(t, "Height", ModelID("Height", ""))
+	assert.Equal(t, "Height@v2", ModelID("Height", "v2"))
+	assert.NoError(t, ValidateModelVersion(""))
+	assert.Error(t, ValidateModelVersion("v 2"))
+}
+
+func TestReferenceService_TraitModelMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trait_models.tsv")
+	require.NoError(t, os.WriteFile(path, []byte("trait\tmodel_id\n# comment\nHeight\tPGS000297\n"), 0o644))
+	prev := config.GetString(TraitModelMapKey)
+	defer config.Set(TraitModelMapKey, prev)
+	config.Set(TraitModelMapKey, path)
+
+	mockModelRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			assert.Equal(t, "PGS000297", args[0])
+			return []map[string]interface{}{
+				{"rsid": "rs123", "beta": 0.5, "risk_allele": "A", "chr": "1", "chr_pos": int64(1000), "ref_allele": "A", "alt_allele": "G"},
+			}, nil
+		},
+	}
+	service, err := NewReferenceService(&mockRepo{}, mockModelRepo, &mockCache{})
+	require.NoError(t, err)
+
+	prsModel, err := service.LoadModel(context.Background(), "height")
+	require.NoError(t, err)
+	assert.Equal(t, "PGS000297", prsModel.ID)
+	assert.Equal(t, "PGS000297", service.ModelID("Height"))
+	assert.Equal(t, "BMI", service.ModelID("BMI"), "unmapped traits are their own model")
+
+	for _, bad := range []string{"Height\n", "Height\tA\nheight\tB\n", "Height\tPGS|1\n"} {
+		_, err := ParseTraitModels(strings.NewReader(bad), "map.tsv")
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestReferenceService_LoadModel_NormalizesContigs(t *testing.T) {
+	mockModelRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			return []map[string]interface{}{
+				{"rsid": "rs1", "beta": 0.1, "risk_allele": "G", "chr": "chr1", "chr_pos": int64(1000), "ref_allele": "A", "alt_allele": "G"},
+				{"rsid": "rs2", "beta": 0.2, "risk_allele": "C", "chr": "chr1_KI270762v1_alt", "chr_pos": int64(50), "ref_allele": "C", "alt_allele": "T"},
+			}, nil
+		},
+	}
+	mockGnomadRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			return []map[string]interface{}{
+				{"chrom": "1", "pos": int64(1000), "ref": "A", "alt": "G", "AF_nfe": 0.2},
+			}, nil
+		},
+	}
+	service, err := NewReferenceService(mockGnomadRepo, mockModelRepo, &mockCache{})
+	assert.NoError(t, err)
+
+	prsModel, err := service.LoadModel(context.Background(), "Height")
+	assert.NoError(t, err)
+	assert.Len(t, prsModel.Variants, 1, "ALT contig variant is dropped")
+	assert.Equal(t, "1:1000:A:G", prsModel.Variants[0].ID)
+	assert.Equal(t, map[string]int{"model": 1}, service.Contigs().Report().Dropped)
+
+	// Model "chr1" and gnomAD "1" name the same variant
+	eur, err := ancestry.New("EUR", "")
+	assert.NoError(t, err)
+	freqs, err := service.GetAlleleFrequenciesForTraits(context.Background(), map[string][]model.Variant{"Height": prsModel.Variants}, eur)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.2, freqs["Height"]["1:1000:A:G"])
+}
+
+func TestReferenceService_GetReferenceStatsForAncestries(t *testing.T) {
+	var freqQueries int
+	mockGnomadRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			freqQueries++
+			assert.Contains(t, query, "AF_nfe")
+			assert.Contains(t, query, "AF_afr")
+			return []map[string]interface{}{
+				{"chrom": "1", "pos": int64(100), "ref": "A", "alt": "G", "AF_nfe": 0.1, "AF_afr": 0.4},
+			}, nil
+		},
+	}
+	var modelLoads int
+	mockModelRepo := &mockRepo{
+		queryFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+			modelLoads++
+			return []map[string]interface{}{
+				{"rsid": "rs1", "beta": 0.1, "beta_afr": 0.3, "risk_allele": "G", "chr": "1", "chr_pos": int64(100), "ref_allele": "A", "alt_allele": "G"},
+			}, nil
+		},
+	}
+	service, err := NewReferenceService(mockGnomadRepo, mockModelRepo, &mockCache{})
+	assert.NoError(t, err)
+
+	eur, err := ancestry.New("EUR", "")
+	assert.NoError(t, err)
+	afr, err := ancestry.New("AFR", "")
+	assert.NoError(t, err)
+
+	results, errs := service.GetReferenceStatsForAncestries(context.Background(), []string{"Height"}, []*ancestry.Ancestry{eur, afr})
+	assert.Empty(t, errs)
+	assert.Equal(t, 1, freqQueries, "one frequency query serves every ancestry")
+	assert.Equal(t, 2, modelLoads, "one model load per weight column")
+	assert.Contains(t, results, "EUR|Height|Height")
+	assert.Contains(t, results, "AFR|Height|Height")
+	assert.NotEqual(t, results["EUR|Height|Height"].Mean, results["AFR|Height|Height"].Mean)
+	assert.Equal(t, "AFR", results["AFR|Height|Height"].Ancestry)
+}
+
+// newModelDB returns a DuckDB model table holding a synthetic model of n variants for
+// trait "Height".
+func newModelDB(tb testing.TB, n int) dbinterface.Repository {
+	tb.Helper()
+	sqlDB, err := duckdb.OpenDB("")
+	require.NoError(tb, err)
+	tb.Cleanup(func() { sqlDB.Close() })
+	_, err = sqlDB.Exec(fmt.Sprintf(`CREATE TABLE model_table AS
+		SELECT 'Height' AS trait, 'rs' || i AS rsid, ((i %% 200) - 99.5) / 1000.0 AS beta,
+			'A' AS risk_allele, CAST(1 + i %% 22 AS VARCHAR) AS chr, CAST(i + 1 AS BIGINT) AS chr_pos,
+			'A' AS ref_allele, 'G' AS alt_allele
+		FROM range(%d) t(i)`, n))
+	require.NoError(tb, err)
+	return duckdb.NewRepository(sqlDB)
+}
+
+func TestReferenceService_LoadModel_Chunked(t *testing.T) {
+	const n = 2500
+	modelDB := newModelDB(t, n)
+	service, err := NewReferenceService(&mockRepo{}, modelDB, &mockCache{})
+	require.NoError(t, err)
+	single, err := service.LoadModel(context.Background(), "Height")
+	require.NoError(t, err)
+	require.Len(t, single.Variants, n)
+
+	bus := events.New()
+	var progress []int
+	bus.Subscribe(func(ev events.Event) { progress = append(progress, ev.Completed) }, events.ModelLoading)
+	ctx := events.WithBus(context.Background(), bus)
+
+	service.modelChunkSize = 1000
+	paged, err := service.LoadModel(ctx, "Height")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, single.Variants, paged.Variants, "paging reads every row once")
+	assert.Equal(t, []int{1000, 2000, 2500}, progress)
+
+	// Paging stops once the model is over the size cap
+	service.SetTraitBudget(TraitBudget{MaxVariants: 1500})
+	progress = nil
+	_, err = service.LoadModel(ctx, "Height")
+	assert.ErrorIs(t, err, ErrTraitBudgetExceeded)
+	assert.Equal(t, []int{1000}, progress)
+}
+
+// BenchmarkLoadModel loads PGS-scale models from DuckDB in one query and in pages. Run with
+// -benchmem to compare peak allocation:
+//
+//	go test ./internal/reference -run '^$' -bench LoadModel -benchmem
+func BenchmarkLoadModel(b *testing.B) {
+	for _, n := range []int{100000, 1000000} {
+		modelDB := newModelDB(b, n)
+		for _, chunk := range []int{0, defaultModelChunkSize} {
+			b.Run(fmt.Sprintf("variants=%d/chunk=%d", n, chunk), func(b *testing.B) {
+				service, err := NewReferenceService(&mockRepo{}, modelDB, &mockCache{})
+				require.NoError(b, err)
+				service.modelChunkSize = chunk
+				service.SetModelCache(nil, "")
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					m, err := service.LoadModel(context.Background(), "Height")
+					if err != nil || len(m.Variants) != n {
+						b.Fatalf("loaded %v variants: %v", m, err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package reference_stats
+
+// Coding is the scoring dosage of a hard call carrying 0, 1, and 2 copies of the effect
+// allele. Reference stats are the moments and quantiles of the score under the model's
+// coding, so a dominant or recessive score is normalized against its own distribution.
+type Coding [3]float64
+
+// Codings of the dosage strategies.
+var (
+	Additive  = Coding{0, 1, 2}
+	Dominant  = Coding{0, 1, 1}
+	Recessive = Coding{0, 0, 1}
+)
+
+// codingOf returns the optional coding argument, Additive when none is given.
+func codingOf(coding []Coding) Coding {
+	if len(coding) > 0 && coding[0] != (Coding{}) {
+		return coding[0]
+	}
+	return Additive
+}
+
+// genotypeProbs returns the Hardy-Weinberg probabilities of 0, 1, and 2 copies of an
+// allele of frequency p.
+func genotypeProbs(p float64) [3]float64 {
+	return [3]float64{(1 - p) * (1 - p), 2 * p * (1 - p), p * p}
+}
+
+// variantMoments returns the mean and variance of one variant's contribution beta*dosage
+// in a population in Hardy-Weinberg equilibrium. Additively coded this is 2pβ and
+// 2p(1-p)β²; dominant coding gives β(1-q²) and β²q²(1-q²) with q = 1-p.
+func (c Coding) variantMoments(p, beta float64) (mean, variance float64) {
+	if c == Additive {
+		return 2 * p * beta, 2 * p * (1 - p) * beta * beta
+	}
+	probs := genotypeProbs(p)
+	var m, m2 float64
+	for k, prob := range probs {
+		m += prob * c[k]
+		m2 += prob * c[k] * c[k]
+	}
+	return m * beta, max(m2-m*m, 0) * beta * beta
+}
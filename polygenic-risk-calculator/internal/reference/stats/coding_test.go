@@ -0,0 +1,66 @@
+package reference_stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompute_Coding(t *testing.T) {
+	p, beta := 0.3, 0.5
+	q := 1 - p
+	freqs, effects := map[string]float64{"rs1": p}, map[string]float64{"rs1": beta}
+
+	tests := []struct {
+		name     string
+		coding   Coding
+		mean     float64
+		variance float64
+	}{
+		{"additive", Additive, 2 * p * beta, 2 * p * q * beta * beta},
+		{"dominant", Dominant, beta * (1 - q*q), beta * beta * q * q * (1 - q*q)},
+		{"recessive", Recessive, beta * p * p, beta * beta * p * p * (1 - p*p)},
+		{"zero coding is additive", Coding{}, 2 * p * beta, 2 * p * q * beta * beta},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stats, err := Compute(freqs, effects, tc.coding)
+			require.NoError(t, err)
+			assert.InDelta(t, tc.mean, stats.Mean, 1e-12)
+			assert.InDelta(t, math.Sqrt(tc.variance), stats.Std, 1e-12)
+		})
+	}
+}
+
+func TestQuantiles_DominantMatchesSimulation(t *testing.T) {
+	freqs := map[string]float64{"rs1": 0.2, "rs2": 0.4, "rs3": 0.1, "rs4": 0.6}
+	effects := map[string]float64{"rs1": 0.3, "rs2": 0.5, "rs3": 0.9, "rs4": 0.2}
+	q := Quantiles(freqs, effects, Dominant)
+	require.NotNil(t, q)
+
+	cohort := simulate(freqs, effects, Dominant, 20000, rand.New(rand.NewSource(1)))
+	sampleMean, sampleStd := meanStd(cohort)
+	stats, err := Compute(freqs, effects, Dominant)
+	require.NoError(t, err)
+	assert.InDelta(t, stats.Mean, sampleMean, 0.01)
+	assert.InDelta(t, stats.Std, sampleStd, 0.01)
+
+	// The dominant score only takes sums of whole effects; its median is one of them
+	assert.InDelta(t, quantiles(cohort, []float64{0.5})[0], q[49], 0.01)
+}
+
+func TestCheckStats_Coding(t *testing.T) {
+	freqs := map[string]float64{"rs1": 0.3, "rs2": 0.6}
+	effects := map[string]float64{"rs1": 0.4, "rs2": -0.2}
+	stats, err := Compute(freqs, effects, Recessive)
+	require.NoError(t, err)
+
+	opts := ValidationOptions{Cohort: 5000, Seed: 1, Coding: Recessive}
+	assert.True(t, CheckStats(stats, freqs, effects, opts).OK)
+
+	opts.Coding = Additive
+	assert.False(t, CheckStats(stats, freqs, effects, opts).OK, "recessive stats do not match additive moments")
+}
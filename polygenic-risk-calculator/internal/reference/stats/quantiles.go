@@ -0,0 +1,148 @@
+package reference_stats
+
+import (
+	"math"
+	"sort"
+
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// The score distribution is tabulated on a grid of quantileBins bins spanning
+// quantileSpan standard deviations either side of the mean. Variants whose own standard
+// deviation is under pooledSteps grid steps join the normal term, where rounding their
+// effects to the grid would distort the variance.
+const (
+	quantileBins = 2048
+	quantileSpan = 8.0
+	pooledSteps  = 8.0
+)
+
+// Quantiles returns the 1st to 99th percentiles of the PRS in a population in
+// Hardy-Weinberg equilibrium with the given allele frequencies, each variant's dosage
+// independently Binomial(2, p). The distribution is tabulated on a grid of quantileBins
+// bins by convolving the variants one at a time, so models dominated by a few large effects
+// get their skewed, lumpy quantiles rather than the normal approximation's. The result is an
+// approximation, not the exact distribution: each genotype's score is split between the two
+// nearest bins, the quantiles are interpolated within a bin (a step of about 0.008 standard
+// deviations), and variants too small to move the score by a few grid steps are pooled into
+// one normal term, which keeps models of millions of variants fast. An optional Coding
+// replaces the additive dosage. Returns nil when the score does not vary.
+func Quantiles(alleleFreqs map[string]float64, effectSizes map[string]float64, coding ...Coding) []float64 {
+	c := codingOf(coding)
+	variants := make([]string, 0, len(alleleFreqs))
+	var mean, variance float64
+	for variant, p := range alleleFreqs {
+		beta, ok := effectSizes[variant]
+		if !ok {
+			continue
+		}
+		variants = append(variants, variant)
+		m, v := c.variantMoments(p, beta)
+		mean += m
+		variance += v
+	}
+	if !(variance > 0) || math.IsInf(variance, 0) {
+		return nil
+	}
+	sort.Strings(variants) // fixed summation order, so the quantiles are reproducible
+	std := math.Sqrt(variance)
+
+	// dist[k] is the probability that the centered score lies in bin k, whose center is
+	// lo + k*step
+	step := 2 * quantileSpan * std / (quantileBins - 1)
+	lo := -quantileSpan * std
+	dist := make([]float64, quantileBins)
+	spread(dist, 0, 1, lo, step)
+
+	var pooled float64 // variance of the variants pooled into a normal term
+	next := make([]float64, quantileBins)
+	for _, variant := range variants {
+		p, beta := alleleFreqs[variant], effectSizes[variant]
+		m, v := c.variantMoments(p, beta)
+		if v == 0 {
+			continue
+		}
+		if math.Sqrt(v) < pooledSteps*step {
+			pooled += v
+			continue
+		}
+		clear(next)
+		var outcomes [3]struct{ prob, shift float64 }
+		for copies, prob := range genotypeProbs(p) {
+			outcomes[copies].prob, outcomes[copies].shift = prob, c[copies]*beta-m
+		}
+		for k, mass := range dist {
+			if mass == 0 {
+				continue
+			}
+			for _, o := range outcomes {
+				if o.prob > 0 {
+					spread(next, lo+float64(k)*step+o.shift, mass*o.prob, lo, step)
+				}
+			}
+		}
+		dist, next = next, dist
+	}
+	if pooled > 0 {
+		dist = smooth(dist, math.Sqrt(pooled)/step)
+	}
+
+	out := make([]float64, model.QuantileCount)
+	var cum float64
+	k := 0
+	for i := range out {
+		target := float64(i+1) / 100
+		for k < quantileBins-1 && cum+dist[k] < target {
+			cum += dist[k]
+			k++
+		}
+		// Each bin's mass is spread evenly over the bin
+		frac := 0.5
+		if dist[k] > 0 {
+			frac = math.Min(1, (target-cum)/dist[k])
+		}
+		out[i] = mean + lo + (float64(k)-0.5+frac)*step
+	}
+	return out
+}
+
+// spread adds mass at x to the two grid bins either side of it, in proportion to their
+// nearness, so the grid keeps the mean exactly. Mass beyond the grid goes to its end bins.
+func spread(dist []float64, x, mass, lo, step float64) {
+	pos := (x - lo) / step
+	if pos <= 0 {
+		dist[0] += mass
+		return
+	}
+	if pos >= float64(len(dist)-1) {
+		dist[len(dist)-1] += mass
+		return
+	}
+	k := int(pos)
+	frac := pos - float64(k)
+	dist[k] += mass * (1 - frac)
+	dist[k+1] += mass * frac
+}
+
+// smooth convolves dist with a normal kernel of the given standard deviation in bins.
+func smooth(dist []float64, sd float64) []float64 {
+	width := int(math.Ceil(4 * sd))
+	kernel := make([]float64, 2*width+1)
+	var total float64
+	for i := range kernel {
+		d := float64(i - width)
+		kernel[i] = math.Exp(-d * d / (2 * sd * sd))
+		total += kernel[i]
+	}
+	out := make([]float64, len(dist))
+	for k, mass := range dist {
+		if mass == 0 {
+			continue
+		}
+		for i, w := range kernel {
+			j := min(max(k+i-width, 0), len(dist)-1)
+			out[j] += mass * w / total
+		}
+	}
+	return out
+}
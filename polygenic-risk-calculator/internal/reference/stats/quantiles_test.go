@@ -0,0 +1,127 @@
+package reference_stats
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+func TestQuantiles_SingleVariant(t *testing.T) {
+	// One variant at p = 0.5 scores 0, 1, or 2 with probabilities 1/4, 1/2, 1/4
+	q := Quantiles(map[string]float64{"rs1": 0.5}, map[string]float64{"rs1": 1})
+	require.Len(t, q, model.QuantileCount)
+	assert.InDelta(t, 0, q[9], 0.01, "q10")
+	assert.InDelta(t, 1, q[49], 0.01, "q50")
+	assert.InDelta(t, 2, q[89], 0.01, "q90")
+
+	stats := model.ReferenceStats{Mean: 1, Std: math.Sqrt(0.5), Quantiles: q}
+	pct, ok := stats.QuantilePercentile(1)
+	require.True(t, ok)
+	assert.InDelta(t, 50, pct, 1, "a score shared by half the population lies at its middle")
+}
+
+func TestQuantiles_MatchesSimulation(t *testing.T) {
+	// A few large effects make the score skewed; the grid quantiles follow the simulated
+	// cohort rather than the normal approximation
+	freqs := map[string]float64{"rs1": 0.05, "rs2": 0.1, "rs3": 0.02}
+	effects := map[string]float64{"rs1": 1.5, "rs2": 0.8, "rs3": 2}
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("small%d", i)
+		freqs[id], effects[id] = 0.3, 0.01
+	}
+	q := Quantiles(freqs, effects)
+	require.Len(t, q, model.QuantileCount)
+	assert.True(t, monotonic(q))
+
+	cohort := simulate(freqs, effects, Additive, 50000, rand.New(rand.NewSource(1)))
+	sort.Float64s(cohort)
+	for _, pct := range []int{5, 50, 90, 99} {
+		simulated := cohort[len(cohort)*pct/100]
+		assert.InDelta(t, simulated, q[pct-1], 0.02, "q%d", pct)
+	}
+}
+
+func TestQuantiles_MatchesEnumeration(t *testing.T) {
+	// Six variants have 3^6 genotypes, few enough to list every score with its
+	// Hardy-Weinberg probability and read off the exact quantiles
+	ids := []string{"rs1", "rs2", "rs3", "rs4", "rs5", "rs6"}
+	ps := []float64{0.13, 0.37, 0.21, 0.44, 0.08, 0.29}
+	betas := []float64{0.9, -0.4, 0.25, 0.6, 1.3, -0.15}
+	freqs, effects := map[string]float64{}, map[string]float64{}
+	var variance float64
+	for i, id := range ids {
+		freqs[id], effects[id] = ps[i], betas[i]
+		variance += 2 * ps[i] * (1 - ps[i]) * betas[i] * betas[i]
+	}
+
+	type outcome struct{ score, prob float64 }
+	outcomes := []outcome{{0, 1}}
+	for i := range ids {
+		var next []outcome
+		for _, o := range outcomes {
+			for copies, prob := range genotypeProbs(ps[i]) {
+				next = append(next, outcome{o.score + float64(copies)*betas[i], o.prob * prob})
+			}
+		}
+		outcomes = next
+	}
+	sort.Slice(outcomes, func(i, j int) bool { return outcomes[i].score < outcomes[j].score })
+
+	q := Quantiles(freqs, effects)
+	require.Len(t, q, model.QuantileCount)
+	step := 2 * quantileSpan * math.Sqrt(variance) / (quantileBins - 1)
+	for i := range q {
+		// The exact quantile is the lowest score whose cumulative probability reaches the
+		// target; a target on a jump of the CDF has no single answer, so it is skipped
+		target := float64(i+1) / 100
+		var cum float64
+		j := 0
+		for ; cum+outcomes[j].prob < target; j++ {
+			cum += outcomes[j].prob
+		}
+		if math.Abs(cum+outcomes[j].prob-target) < 1e-9 {
+			continue
+		}
+		assert.InDelta(t, outcomes[j].score, q[i], 3*step, "q%d", i+1)
+	}
+}
+
+func TestQuantiles_ManyVariantsApproachNormal(t *testing.T) {
+	freqs, effects := map[string]float64{}, map[string]float64{}
+	for i := 0; i < 5000; i++ {
+		id := fmt.Sprintf("rs%d", i)
+		freqs[id], effects[id] = 0.05+0.9*float64(i%97)/97, 0.01*float64(i%13-6)
+	}
+	stats, err := Compute(freqs, effects)
+	require.NoError(t, err)
+	require.Len(t, stats.Quantiles, model.QuantileCount)
+	for _, pct := range []float64{1, 25, 50, 75, 99} {
+		normal := stats.Mean + stats.Std*math.Sqrt2*math.Erfinv(2*pct/100-1)
+		assert.InDelta(t, normal, stats.Quantiles[int(pct)-1], 0.02*stats.Std, "q%v", pct)
+	}
+}
+
+func TestQuantiles_NoVariance(t *testing.T) {
+	assert.Nil(t, Quantiles(map[string]float64{"rs1": 0.3}, map[string]float64{"rs1": 0}))
+	assert.Nil(t, Quantiles(map[string]float64{"rs1": 1}, map[string]float64{"rs1": 0.5}))
+}
+
+func TestNormalizePRS_Quantiles(t *testing.T) {
+	q := make([]float64, model.QuantileCount)
+	for i := range q {
+		q[i] = float64(i + 1) // the score equals its percentile
+	}
+	s := &ReferenceStats{Mean: 50, Std: 29, Min: 0, Max: 100, Quantiles: q}
+	pct, err := s.NormalizePRS(42.5)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.425, pct, 1e-12)
+
+	s.Quantiles = q[:50]
+	assert.Error(t, s.Validate(), "a truncated quantile array is invalid")
+}
@@ -3,6 +3,7 @@ package reference_stats
 import (
 	"fmt"
 	"math"
+	"sort"
 
 	"phite.io/polygenic-risk-calculator/internal/model"
 )
@@ -22,14 +23,21 @@ func (s *ReferenceStats) Validate() error {
 	if s.Mean < s.Min || s.Mean > s.Max {
 		return fmt.Errorf("mean (%f) must be between min (%f) and max (%f)", s.Mean, s.Min, s.Max)
 	}
+	if len(s.Quantiles) > 0 && (len(s.Quantiles) != model.QuantileCount || !monotonic(s.Quantiles)) {
+		return fmt.Errorf("quantiles must be %d non-decreasing values, got %d", model.QuantileCount, len(s.Quantiles))
+	}
 	return nil
 }
 
-// NormalizePRS converts a raw PRS score to a normalized percentile.
+// NormalizePRS converts a raw PRS score to a normalized percentile, as a fraction: read
+// off the quantiles when there are any, otherwise from the normal approximation.
 func (s *ReferenceStats) NormalizePRS(rawPRS float64) (float64, error) {
 	if err := s.Validate(); err != nil {
 		return 0, fmt.Errorf("invalid reference stats: %w", err)
 	}
+	if percentile, ok := model.ReferenceStats(*s).QuantilePercentile(rawPRS); ok {
+		return percentile / 100, nil
+	}
 	zScore := (rawPRS - s.Mean) / s.Std
 	percentile := 0.5 * (1 + math.Erf(zScore/math.Sqrt(2)))
 	return percentile, nil
@@ -41,17 +49,20 @@ func (s *ReferenceStats) NormalizePRS(rawPRS float64) (float64, error) {
 // Population Mean: μ_pop = Σ_j(2*p_j*β_j)
 // Population Variance: Var(PRS) = Σ_j(2*p_j*(1-p_j)*β_j²)
 //
+// The quantiles of the same distribution are filled in by Quantiles.
+//
 // This implements the industry-standard 2025 PRS methodology with proper
-// Hardy-Weinberg equilibrium assumptions.
-func Compute(alleleFreqs map[string]float64, effectSizes map[string]float64) (*ReferenceStats, error) {
+// Hardy-Weinberg equilibrium assumptions. An optional Coding replaces the additive
+// dosage, for models scored with dominant or recessive coding; each variant then
+// contributes the mean and variance of its coded dosage instead.
+func Compute(alleleFreqs map[string]float64, effectSizes map[string]float64, coding ...Coding) (*ReferenceStats, error) {
 	if len(alleleFreqs) == 0 || len(effectSizes) == 0 {
 		return nil, fmt.Errorf("empty allele frequencies or effect sizes")
 	}
 
+	c := codingOf(coding)
 	var populationMean float64
 	var populationVariance float64
-	var minContribution float64 = math.MaxFloat64
-	var maxContribution float64 = -math.MaxFloat64
 	var validVariants int
 
 	// Calculate population parameters using CORRECT formulas
@@ -61,29 +72,22 @@ func Compute(alleleFreqs map[string]float64, effectSizes map[string]float64) (*R
 			continue // Skip variants without effect sizes
 		}
 
-		// Validate frequency bounds [0, 1]
-		if freq < 0 || freq > 1 {
+		// Validate frequency bounds [0, 1]; NaN fails both comparisons, so reject it explicitly
+		if math.IsNaN(freq) || freq < 0 || freq > 1 {
 			return nil, fmt.Errorf("invalid allele frequency %f for variant %s: must be in [0,1]", freq, variant)
 		}
+		if math.IsNaN(effect) || math.IsInf(effect, 0) {
+			return nil, fmt.Errorf("invalid effect size %f for variant %s: must be finite", effect, variant)
+		}
 
 		validVariants++
 
 		// Population mean: μ_pop = Σ_j(2*p_j*β_j)
-		contributionToMean := 2 * freq * effect
-		populationMean += contributionToMean
-
 		// Population variance: Var(PRS) = Σ_j(2*p_j*(1-p_j)*β_j²)
-		// This is the Hardy-Weinberg equilibrium variance formula
-		contributionToVariance := 2 * freq * (1 - freq) * effect * effect
+		// These are the Hardy-Weinberg equilibrium formulas for additive coding
+		contributionToMean, contributionToVariance := c.variantMoments(freq, effect)
+		populationMean += contributionToMean
 		populationVariance += contributionToVariance
-
-		// Track min/max individual contributions for bounds
-		if contributionToMean < minContribution {
-			minContribution = contributionToMean
-		}
-		if contributionToMean > maxContribution {
-			maxContribution = contributionToMean
-		}
 	}
 
 	if validVariants == 0 {
@@ -128,5 +132,44 @@ func Compute(alleleFreqs map[string]float64, effectSizes map[string]float64) (*R
 		Std:  populationStd,
 		Min:  estimatedMin,
 		Max:  estimatedMax,
+
+		Quantiles: Quantiles(alleleFreqs, effectSizes, c),
 	}, nil
 }
+
+// TopContributors returns the n variants contributing most to the population variance
+// Compute derives from the same frequencies and effect sizes, largest first, each with its
+// share of the total. One variant carrying most of the variance usually points at a wrong
+// frequency or an outsized weight.
+func TopContributors(alleleFreqs map[string]float64, effectSizes map[string]float64, n int, coding ...Coding) []model.VarianceContribution {
+	if n <= 0 {
+		return nil
+	}
+	c := codingOf(coding)
+	var total float64
+	var contributions []model.VarianceContribution
+	for variant, freq := range alleleFreqs {
+		effect, ok := effectSizes[variant]
+		if !ok {
+			continue
+		}
+		_, variance := c.variantMoments(freq, effect)
+		total += variance
+		contributions = append(contributions, model.VarianceContribution{Variant: variant, Variance: variance})
+	}
+	sort.Slice(contributions, func(i, j int) bool {
+		if contributions[i].Variance != contributions[j].Variance {
+			return contributions[i].Variance > contributions[j].Variance
+		}
+		return contributions[i].Variant < contributions[j].Variant
+	})
+	if len(contributions) > n {
+		contributions = contributions[:n]
+	}
+	for i := range contributions {
+		if total > 0 {
+			contributions[i].Share = contributions[i].Variance / total
+		}
+	}
+	return contributions
+}
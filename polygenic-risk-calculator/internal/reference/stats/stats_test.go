@@ -1,6 +1,8 @@
 package reference_stats
 
 import (
+	"fmt"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -224,6 +226,26 @@ func TestCompute(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "NaN allele frequency",
+			alleleFreqs: map[string]float64{
+				"rs1": math.NaN(),
+			},
+			effectSizes: map[string]float64{
+				"rs1": 0.1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "infinite effect size",
+			alleleFreqs: map[string]float64{
+				"rs1": 0.5,
+			},
+			effectSizes: map[string]float64{
+				"rs1": math.Inf(1),
+			},
+			wantErr: true,
+		},
 		{
 			name: "extreme values (zero variance)",
 			alleleFreqs: map[string]float64{
@@ -286,3 +308,68 @@ func TestCompute(t *testing.T) {
 		})
 	}
 }
+
+func TestTopContributors(t *testing.T) {
+	freqs := map[string]float64{"a": 0.5, "b": 0.1, "c": 0.5, "d": 0.2}
+	effects := map[string]float64{"a": 1, "b": 1, "c": -2, "e": 3}
+
+	top := TopContributors(freqs, effects, 2)
+	// Variances: a 0.5, b 0.18, c 2; d and e have no effect size or frequency
+	assert.Len(t, top, 2)
+	assert.Equal(t, "c", top[0].Variant)
+	assert.InDelta(t, 2.0, top[0].Variance, 1e-12)
+	assert.InDelta(t, 2.0/2.68, top[0].Share, 1e-12)
+	assert.Equal(t, "a", top[1].Variant)
+	assert.InDelta(t, 0.5/2.68, top[1].Share, 1e-12)
+
+	assert.Len(t, TopContributors(freqs, effects, 10), 3)
+	assert.Nil(t, TopContributors(freqs, effects, 0))
+}
+
+func TestCheckStats(t *testing.T) {
+	freqs := map[string]float64{}
+	effects := map[string]float64{}
+	for i := 0; i < 50; i++ {
+		v := fmt.Sprintf("rs%d", i)
+		freqs[v] = 0.05 + 0.9*float64(i)/50
+		effects[v] = 0.1 - 0.004*float64(i)
+	}
+	opts := DefaultValidationOptions()
+	failed := func(v Validation) []string {
+		var names []string
+		for _, c := range v.Checks {
+			if !c.OK {
+				names = append(names, c.Name)
+			}
+		}
+		return names
+	}
+
+	good, err := Compute(freqs, effects)
+	assert.NoError(t, err)
+	v := CheckStats(good, freqs, effects, opts)
+	assert.True(t, v.OK, "%+v", v.Checks)
+	assert.Len(t, v.Checks, 5)
+
+	// Stats computed from the other allele's frequencies, as a flipped mapping would produce
+	flipped := map[string]float64{}
+	for k, p := range freqs {
+		flipped[k] = 1 - p
+	}
+	wrong, err := Compute(flipped, effects)
+	assert.NoError(t, err)
+	v = CheckStats(wrong, freqs, effects, opts)
+	assert.False(t, v.OK)
+	assert.Contains(t, failed(v), CheckMoments)
+	assert.Contains(t, failed(v), CheckSimulation)
+
+	// Most model variants lack a frequency
+	sparse := map[string]float64{"rs0": freqs["rs0"], "rs1": freqs["rs1"]}
+	partial, err := Compute(sparse, effects)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{CheckCoverage}, failed(CheckStats(partial, sparse, effects, opts)))
+
+	// Impossible stats fail the bounds check
+	bad := &ReferenceStats{Mean: 5, Std: 0, Min: 0, Max: 1}
+	assert.Contains(t, failed(CheckStats(bad, freqs, effects, ValidationOptions{})), CheckBounds)
+}
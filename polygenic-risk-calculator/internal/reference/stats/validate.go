@@ -0,0 +1,196 @@
+package reference_stats
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// Names of the checks CheckStats runs.
+const (
+	CheckBounds     = "bounds"     // std positive and finite; mean within [min, max]
+	CheckMoments    = "moments"    // mean and variance match those implied by the frequencies and effects
+	CheckCoverage   = "coverage"   // enough model variants have a reference frequency
+	CheckQuantiles  = "quantiles"  // quantiles of the normal approximation and simulated cohort are monotonic
+	CheckSimulation = "simulation" // a simulated Hardy-Weinberg cohort reproduces mean and std
+)
+
+// ValidationOptions tunes CheckStats.
+type ValidationOptions struct {
+	Cohort      int     // simulated individuals; 0 skips the simulation check
+	Seed        int64   // seed of the simulated cohort, so reports are reproducible
+	MinCoverage float64 // smallest fraction of model variants with a frequency; 0 accepts any
+	Tolerance   float64 // relative tolerance of the moments check; 0 uses 1e-6
+	Coding      Coding  // dosage coding the stats were computed under; zero is Additive
+}
+
+// DefaultValidationOptions returns the options the validate-stats command uses by default.
+func DefaultValidationOptions() ValidationOptions {
+	return ValidationOptions{Cohort: 2000, Seed: 1, MinCoverage: 0.8, Tolerance: 1e-6}
+}
+
+// Check is the outcome of one statistical check.
+type Check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Validation is the outcome of every check run on one set of reference stats.
+type Validation struct {
+	Ancestry string  `json:"ancestry"`
+	Trait    string  `json:"trait"`
+	Model    string  `json:"model"`
+	Source   string  `json:"source"` // "computed" or "cached"
+	OK       bool    `json:"ok"`
+	Checks   []Check `json:"checks"`
+
+	Contributors []model.VarianceContribution `json:"contributors,omitempty"` // largest variance contributions of computed stats
+}
+
+// quantileProbs are the probabilities whose quantiles must be non-decreasing.
+var quantileProbs = []float64{0.01, 0.05, 0.25, 0.5, 0.75, 0.95, 0.99}
+
+// CheckStats checks s against the allele frequencies and effect sizes of the model it
+// describes. A wrong frequency column, a flipped effect allele, or stale cached stats
+// show up as a moments, coverage, or simulation failure.
+func CheckStats(s *ReferenceStats, freqs, effects map[string]float64, opts ValidationOptions) Validation {
+	v := Validation{Ancestry: s.Ancestry, Trait: s.Trait, Model: s.Model, OK: true}
+	add := func(name string, ok bool, format string, args ...any) {
+		v.Checks = append(v.Checks, Check{Name: name, OK: ok, Detail: fmt.Sprintf(format, args...)})
+		v.OK = v.OK && ok
+	}
+
+	if err := s.Validate(); err != nil || math.IsNaN(s.Mean) || math.IsInf(s.Std, 0) {
+		add(CheckBounds, false, "mean=%g std=%g min=%g max=%g: %v", s.Mean, s.Std, s.Min, s.Max, err)
+	} else {
+		add(CheckBounds, true, "mean %g within [%g, %g]", s.Mean, s.Min, s.Max)
+	}
+
+	coding := codingOf([]Coding{opts.Coding})
+	mean, variance, matched := moments(freqs, effects, coding)
+	tol := opts.Tolerance
+	if tol <= 0 {
+		tol = 1e-6
+	}
+	meanOK := math.Abs(s.Mean-mean) <= tol*math.Max(1, math.Abs(mean))
+	varOK := math.Abs(s.Std*s.Std-variance) <= tol*math.Max(1, variance)
+	add(CheckMoments, meanOK && varOK, "mean %g vs %g expected, variance %g vs %g expected", s.Mean, mean, s.Std*s.Std, variance)
+
+	coverage := 0.0
+	if len(effects) > 0 {
+		coverage = float64(matched) / float64(len(effects))
+	}
+	add(CheckCoverage, coverage >= opts.MinCoverage, "%d/%d model variants have a frequency (%.1f%%)", matched, len(effects), 100*coverage)
+
+	normal := make([]float64, len(quantileProbs))
+	for i, p := range quantileProbs {
+		normal[i] = s.Mean + s.Std*math.Sqrt2*math.Erfinv(2*p-1)
+	}
+	if opts.Cohort <= 0 || matched == 0 {
+		add(CheckQuantiles, monotonic(normal), "normal quantiles %v", roundAll(normal))
+		return v
+	}
+
+	cohort := simulate(freqs, effects, coding, opts.Cohort, rand.New(rand.NewSource(opts.Seed)))
+	simulated := quantiles(cohort, quantileProbs)
+	add(CheckQuantiles, monotonic(normal) && monotonic(simulated), "normal %v, simulated %v", roundAll(normal), roundAll(simulated))
+
+	// Allow four standard errors of the sample mean and sample std of a normal cohort
+	n := float64(len(cohort))
+	sampleMean, sampleStd := meanStd(cohort)
+	meanErr := 4*s.Std/math.Sqrt(n) + 1e-12
+	stdErr := 4*s.Std/math.Sqrt(2*n) + 1e-12
+	ok := math.Abs(sampleMean-s.Mean) <= meanErr && math.Abs(sampleStd-s.Std) <= stdErr
+	add(CheckSimulation, ok, "%d individuals: mean %g (expected %g ± %g), std %g (expected %g ± %g)",
+		len(cohort), sampleMean, s.Mean, meanErr, sampleStd, s.Std, stdErr)
+	return v
+}
+
+// moments returns the Hardy-Weinberg mean and variance of the score over the variants that
+// have both a frequency and an effect, and their count.
+func moments(freqs, effects map[string]float64, coding Coding) (mean, variance float64, matched int) {
+	for variant, p := range freqs {
+		beta, ok := effects[variant]
+		if !ok {
+			continue
+		}
+		matched++
+		m, v := coding.variantMoments(p, beta)
+		mean += m
+		variance += v
+	}
+	return mean, variance, matched
+}
+
+// simulate scores n individuals whose effect allele dosages are drawn independently per
+// variant from Binomial(2, p) and scored under coding.
+func simulate(freqs, effects map[string]float64, coding Coding, n int, rng *rand.Rand) []float64 {
+	variants := make([]string, 0, len(freqs))
+	for variant := range freqs {
+		if _, ok := effects[variant]; ok {
+			variants = append(variants, variant)
+		}
+	}
+	sort.Strings(variants) // fixed draw order, so a seed reproduces the cohort
+	scores := make([]float64, n)
+	for i := range scores {
+		for _, variant := range variants {
+			p := freqs[variant]
+			dosage := 0
+			if rng.Float64() < p {
+				dosage++
+			}
+			if rng.Float64() < p {
+				dosage++
+			}
+			scores[i] += coding[dosage] * effects[variant]
+		}
+	}
+	return scores
+}
+
+func meanStd(xs []float64) (float64, float64) {
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	mean := sum / float64(len(xs))
+	var ss float64
+	for _, x := range xs {
+		ss += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(ss / float64(len(xs)))
+}
+
+// quantiles returns the nearest-rank quantiles of xs at probs.
+func quantiles(xs []float64, probs []float64) []float64 {
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	out := make([]float64, len(probs))
+	for i, p := range probs {
+		idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+		out[i] = sorted[max(0, min(idx, len(sorted)-1))]
+	}
+	return out
+}
+
+func monotonic(xs []float64) bool {
+	for i := 1; i < len(xs); i++ {
+		if !(xs[i] >= xs[i-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func roundAll(xs []float64) []float64 {
+	out := make([]float64, len(xs))
+	for i, x := range xs {
+		out[i] = math.Round(x*1e4) / 1e4
+	}
+	return out
+}
@@ -0,0 +1,122 @@
+package reference
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/db/dbutil"
+	"phite.io/polygenic-risk-calculator/internal/model"
+)
+
+// Domain-specific configuration keys for trait to model mapping
+const (
+	TraitModelMapKey = "reference.trait_model_map" // TSV of trait, model ID naming the model table rows that score each trait
+)
+
+// TraitModelsFromConfig reads the trait model map named by TraitModelMapKey, or returns nil
+// when none is configured.
+func TraitModelsFromConfig() (map[string]string, error) {
+	path := config.GetString(TraitModelMapKey)
+	if path == "" {
+		return nil, nil
+	}
+	return LoadTraitModels(path)
+}
+
+// LoadTraitModels reads a trait model map from a file.
+func LoadTraitModels(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trait model map: %w", err)
+	}
+	defer f.Close()
+	return ParseTraitModels(f, path)
+}
+
+// ParseTraitModels reads a trait model map of tab-separated trait and model ID columns;
+// name labels errors. Blank lines, lines starting with #, and a header row starting with
+// "trait" are ignored. Traits are keyed lower-cased; the model ID is the value of the model
+// table's trait column to load for that trait.
+func ParseTraitModels(r io.Reader, name string) (map[string]string, error) {
+	models := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(strings.ToLower(text), "trait\t") {
+			continue
+		}
+		cols := strings.Split(text, "\t")
+		if len(cols) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected 2 tab-separated columns, got %d", name, line, len(cols))
+		}
+		trait, modelID := strings.TrimSpace(cols[0]), strings.TrimSpace(cols[1])
+		if trait == "" || modelID == "" {
+			return nil, fmt.Errorf("%s:%d: trait and model ID are required", name, line)
+		}
+		if strings.ContainsAny(modelID, "|@") {
+			return nil, fmt.Errorf("%s:%d: model ID %q must not contain '|' or '@'", name, line, modelID)
+		}
+		key := strings.ToLower(trait)
+		if _, dup := models[key]; dup {
+			return nil, fmt.Errorf("%s:%d: duplicate trait %s", name, line, trait)
+		}
+		models[key] = modelID
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read trait model map: %w", err)
+	}
+	return models, nil
+}
+
+// ModelFor returns the model ID mapped to trait in models, or the trait itself when it is
+// not mapped.
+func ModelFor(models map[string]string, trait string) string {
+	if m, ok := models[strings.ToLower(trait)]; ok {
+		return m
+	}
+	return trait
+}
+
+// AssignModels sets the Model of each record to the model its trait is mapped to in models.
+func AssignModels(records []model.GWASSNPRecord, models map[string]string) {
+	for i := range records {
+		records[i].Model = ModelFor(models, records[i].Trait)
+	}
+}
+
+// TraitModels returns the service's trait model map; nil when every trait is its own model.
+func (s *ReferenceService) TraitModels() map[string]string {
+	return s.traitModels
+}
+
+// SetTraitModels sets the trait model map, keyed by lower-cased trait.
+func (s *ReferenceService) SetTraitModels(models map[string]string) {
+	s.traitModels = models
+}
+
+// HasModel reports whether the model table holds rows for model ID id, or, with the PGS
+// Catalog as model source, whether the catalog has a scoring file for PGS ID id.
+func (s *ReferenceService) HasModel(ctx context.Context, id string) (bool, error) {
+	if s.pgsCatalog != nil && IsPGSID(id) {
+		if _, err := s.pgsCatalog.ScoringFile(ctx, id); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	query, args, err := dbutil.DialectOf(s.modelDB).Select("trait").From(s.modelTable).WhereEq("trait", id).Limit(1).Build()
+	if err != nil {
+		return false, fmt.Errorf("failed to build model query: %w", err)
+	}
+	rows, err := s.modelDB.Query(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to query model %s: %w", id, err)
+	}
+	return len(rows) > 0, nil
+}
@@ -0,0 +1,67 @@
+package reference
+
+import (
+	"context"
+	"fmt"
+
+	"phite.io/polygenic-risk-calculator/internal/ancestry"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
+	reference_cache "phite.io/polygenic-risk-calculator/internal/reference/cache"
+	reference_stats "phite.io/polygenic-risk-calculator/internal/reference/stats"
+)
+
+// ValidateStats runs the statistical checks of reference_stats.CheckStats on each trait's
+// reference stats under anc: on stats computed now from the model and a single bulk
+// frequency query, and on the cached stats when there are any. Traits whose model cannot
+// be loaded are reported as errors.
+func (s *ReferenceService) ValidateStats(ctx context.Context, anc *ancestry.Ancestry, traits []string, opts reference_stats.ValidationOptions) ([]reference_stats.Validation, error) {
+	effects := make(map[string]map[string]float64, len(traits))
+	traitVariants := make(map[string][]model.Variant, len(traits))
+	for _, trait := range traits {
+		loadCtx, cancel := s.traitContext(ctx)
+		prsModel, err := s.loadModelWithinBudget(loadCtx, trait, anc)
+		err = s.overBudget(ctx, loadCtx, trait, err)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load PRS model for trait %s: %w", trait, err)
+		}
+		effects[trait] = prsModel.GetEffectSizes()
+		traitVariants[trait] = prsModel.Variants
+	}
+
+	frequencies, err := s.GetAlleleFrequenciesForTraits(ctx, traitVariants, anc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allele frequencies: %w", err)
+	}
+
+	var validations []reference_stats.Validation
+	for _, trait := range traits {
+		freqs := frequencies[trait]
+		coded := opts
+		coded.Coding = s.coding(trait)
+		computed, err := reference_stats.Compute(freqs, effects[trait], coded.Coding)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute stats for trait %s: %w", trait, err)
+		}
+		computed.Ancestry = anc.Code()
+		computed.Trait = trait
+		computed.Model = s.ModelID(trait)
+		v := reference_stats.CheckStats(computed, freqs, effects[trait], coded)
+		v.Source = "computed"
+		v.Contributors = reference_stats.TopContributors(freqs, effects[trait], s.topContributors, coded.Coding)
+		validations = append(validations, v)
+
+		cached, err := s.ReferenceCache.Get(ctx, reference_cache.StatsRequest{Ancestry: anc.Code(), Trait: trait, ModelID: computed.Model})
+		if err != nil {
+			logging.Debug("No cached stats to validate for trait %s: %v", trait, err)
+		}
+		if cached != nil {
+			v := reference_stats.CheckStats(cached, freqs, effects[trait], coded)
+			v.Ancestry, v.Trait, v.Model = anc.Code(), trait, computed.Model
+			v.Source = "cached"
+			validations = append(validations, v)
+		}
+	}
+	return validations, nil
+}
@@ -0,0 +1,88 @@
+package reference
+
+import (
+	"fmt"
+	"regexp"
+
+	"phite.io/polygenic-risk-calculator/internal/dosage"
+
+	"phite.io/polygenic-risk-calculator/internal/integrity"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/model"
+	reference_stats "phite.io/polygenic-risk-calculator/internal/reference/stats"
+)
+
+// Domain-specific configuration keys for model versioning
+const (
+	ModelVersionKey = "reference.model_version" // Pinned model release label; reference stats are cached per version
+)
+
+var modelVersionPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// ValidateModelVersion rejects version labels that could collide with the separators of
+// cache keys. An empty version (unpinned) is valid.
+func ValidateModelVersion(version string) error {
+	if version != "" && !modelVersionPattern.MatchString(version) {
+		return fmt.Errorf("invalid model version %q: use letters, digits, '.', '_' or '-'", version)
+	}
+	return nil
+}
+
+// ModelID returns the identifier reference stats for a model are cached and reported
+// under. The model is the trait itself unless the trait model map names another (see
+// ModelFor). Without a pinned model version it is the model alone, matching entries cached
+// before versions were introduced.
+func ModelID(model, version string) string {
+	if version == "" {
+		return model
+	}
+	return model + "@" + version
+}
+
+// CodedModelID returns the identifier of a model's reference stats when it is scored under
+// strategy: modelID itself for additive coding, otherwise modelID#strategy, so dominant
+// and recessive stats are cached apart from the additive ones.
+func CodedModelID(modelID string, strategy dosage.Strategy) string {
+	if strategy == nil || strategy.Levels() == (dosage.Additive{}).Levels() {
+		return modelID
+	}
+	return modelID + "#" + strategy.Name()
+}
+
+// ModelID returns the identifier of trait's model under the service's pinned version and
+// the model's dosage coding.
+func (s *ReferenceService) ModelID(trait string) string {
+	modelName := ModelFor(s.traitModels, trait)
+	return CodedModelID(ModelID(modelName, s.pinnedVersion), s.dosage.For(modelName))
+}
+
+// coding returns the dosage coding trait's model is scored under.
+func (s *ReferenceService) coding(trait string) reference_stats.Coding {
+	return reference_stats.Coding(s.dosage.For(ModelFor(s.traitModels, trait)).Levels())
+}
+
+// Dosage returns the dosage strategy selector the service codes reference stats with.
+func (s *ReferenceService) Dosage() *dosage.Selector {
+	return s.dosage
+}
+
+// PinnedModelVersion returns the configured model version label, or "" when unpinned.
+func (s *ReferenceService) PinnedModelVersion() string {
+	return s.pinnedVersion
+}
+
+// ModelVersion returns the pinned version and the checksum of the model database file.
+// The checksum is left empty when the file cannot be read, e.g. for a non-file repository.
+func (s *ReferenceService) ModelVersion() model.ModelVersion {
+	v := model.ModelVersion{Version: s.pinnedVersion}
+	if s.modelPath == "" {
+		return v
+	}
+	sum, err := integrity.HashFile(s.modelPath)
+	if err != nil {
+		logging.Warn("Could not checksum model database %s: %v", s.modelPath, err)
+		return v
+	}
+	v.Checksum = sum
+	return v
+}
@@ -0,0 +1,163 @@
+// Package retention enforces how long cached reference stats and stored results are kept.
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// Domain-specific configuration keys for retention
+const (
+	CacheMaxAgeDaysKey   = "retention.cache_max_age_days"   // Delete cache entries older than N days (0 keeps forever)
+	ResultsMaxAgeDaysKey = "retention.results_max_age_days" // Purge stored results older than M days (0 keeps forever)
+	ResultsDirKey        = "retention.results_dir"          // Directory holding stored per-user results
+	IntervalKey          = "retention.interval"             // How often a background scheduler runs collection (e.g. "24h")
+)
+
+// CachePruner deletes cache entries stored before a cutoff.
+type CachePruner interface {
+	DeleteOlderThan(ctx context.Context, cutoff time.Time) error
+}
+
+// Policy describes what to expire. Zero ages disable the corresponding collection.
+type Policy struct {
+	CacheMaxAge   time.Duration
+	ResultsMaxAge time.Duration
+	ResultsDir    string
+}
+
+// PolicyFromConfig reads the retention policy from configuration.
+func PolicyFromConfig() Policy {
+	return Policy{
+		CacheMaxAge:   days(config.GetInt(CacheMaxAgeDaysKey)),
+		ResultsMaxAge: days(config.GetInt(ResultsMaxAgeDaysKey)),
+		ResultsDir:    config.GetString(ResultsDirKey),
+	}
+}
+
+func days(n int) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// Report summarizes one collection run.
+type Report struct {
+	CacheCutoff    time.Time `json:"cache_cutoff,omitempty"`
+	CachePruned    bool      `json:"cache_pruned"`
+	ResultsCutoff  time.Time `json:"results_cutoff,omitempty"`
+	ResultsRemoved []string  `json:"results_removed,omitempty"`
+}
+
+// Collector applies a Policy.
+type Collector struct {
+	Policy Policy
+	Cache  CachePruner // optional; cache collection is skipped when nil
+	now    func() time.Time
+}
+
+// NewCollector creates a collector for the given policy and optional cache.
+func NewCollector(policy Policy, cache CachePruner) *Collector {
+	return &Collector{Policy: policy, Cache: cache, now: time.Now}
+}
+
+// Run performs one collection pass. Both collections are attempted even if one fails.
+func (c *Collector) Run(ctx context.Context) (Report, error) {
+	var report Report
+	var errs []error
+	now := c.now()
+
+	if c.Policy.CacheMaxAge > 0 && c.Cache != nil {
+		report.CacheCutoff = now.Add(-c.Policy.CacheMaxAge)
+		if err := c.Cache.DeleteOlderThan(ctx, report.CacheCutoff); err != nil {
+			errs = append(errs, fmt.Errorf("cache retention: %w", err))
+		} else {
+			report.CachePruned = true
+		}
+	}
+
+	if c.Policy.ResultsMaxAge > 0 {
+		if c.Policy.ResultsDir == "" {
+			errs = append(errs, fmt.Errorf("results retention: %s is set but %s is not", ResultsMaxAgeDaysKey, ResultsDirKey))
+		} else {
+			report.ResultsCutoff = now.Add(-c.Policy.ResultsMaxAge)
+			removed, err := purgeResults(c.Policy.ResultsDir, report.ResultsCutoff)
+			report.ResultsRemoved = removed
+			if err != nil {
+				errs = append(errs, fmt.Errorf("results retention: %w", err))
+			}
+		}
+	}
+
+	logging.Info("Retention run complete: cache pruned=%t, %d results removed", report.CachePruned, len(report.ResultsRemoved))
+	return report, errors.Join(errs...)
+}
+
+// purgeResults removes regular files under dir last modified before cutoff.
+func purgeResults(dir string, cutoff time.Time) ([]string, error) {
+	var removed []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().Before(cutoff) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			logging.Debug("Removed expired result %s", path)
+			removed = append(removed, path)
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// Schedule runs the collector every interval until ctx is cancelled. Failed runs are
+// logged and retried at the next tick. It is intended for long-running servers.
+func Schedule(ctx context.Context, c *Collector, interval time.Duration) {
+	if interval <= 0 {
+		logging.Warn("Retention scheduler disabled: non-positive interval %s", interval)
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := c.Run(ctx); err != nil {
+				logging.Error("Scheduled retention run failed: %v", err)
+			}
+		}
+	}
+}
+
+// IntervalFromConfig returns the scheduler interval, or 0 if unset or invalid.
+func IntervalFromConfig() time.Duration {
+	raw := config.GetString(IntervalKey)
+	if raw == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		logging.Warn("Ignoring invalid %s %q: %v", IntervalKey, raw, err)
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,73 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+type fakePruner struct {
+	cutoff time.Time
+	err    error
+}
+
+func (f *fakePruner) DeleteOlderThan(ctx context.Context, cutoff time.Time) error {
+	f.cutoff = cutoff
+	return f.err
+}
+
+func TestCollector_Run(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "user1", "old.json")
+	fresh := filepath.Join(dir, "user1", "new.json")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(stale), 0755))
+	assert.NoError(t, os.WriteFile(stale, []byte("{}"), 0644))
+	assert.NoError(t, os.WriteFile(fresh, []byte("{}"), 0644))
+	assert.NoError(t, os.Chtimes(stale, now.AddDate(0, 0, -40), now.AddDate(0, 0, -40)))
+	assert.NoError(t, os.Chtimes(fresh, now.AddDate(0, 0, -1), now.AddDate(0, 0, -1)))
+
+	pruner := &fakePruner{}
+	c := NewCollector(Policy{CacheMaxAge: days(90), ResultsMaxAge: days(30), ResultsDir: dir}, pruner)
+	c.now = func() time.Time { return now }
+
+	report, err := c.Run(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, -90), pruner.cutoff)
+	assert.True(t, report.CachePruned)
+	assert.Equal(t, []string{stale}, report.ResultsRemoved)
+	assert.FileExists(t, fresh)
+	assert.NoFileExists(t, stale)
+}
+
+func TestCollector_RunContinuesAfterCacheError(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+
+	dir := t.TempDir()
+	c := NewCollector(Policy{CacheMaxAge: days(1), ResultsMaxAge: days(1), ResultsDir: dir}, &fakePruner{err: errors.New("boom")})
+	report, err := c.Run(context.Background())
+	assert.ErrorContains(t, err, "boom")
+	assert.False(t, report.CachePruned)
+	assert.False(t, report.ResultsCutoff.IsZero(), "results collection still runs")
+}
+
+func TestCollector_RunDisabled(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+
+	pruner := &fakePruner{}
+	report, err := NewCollector(Policy{}, pruner).Run(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, pruner.cutoff.IsZero())
+	assert.True(t, report.CacheCutoff.IsZero())
+
+	_, err = NewCollector(Policy{ResultsMaxAge: days(1)}, nil).Run(context.Background())
+	assert.ErrorContains(t, err, ResultsDirKey)
+}
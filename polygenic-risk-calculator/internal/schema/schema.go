@@ -0,0 +1,92 @@
+// Package schema verifies that the tables the calculator reads and writes exist and carry
+// the columns it expects, and reports the outcome in text or JSON.
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	dbinterface "phite.io/polygenic-risk-calculator/internal/db/interface"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+// Check describes one table to verify.
+type Check struct {
+	Name    string // short identifier, e.g. "gnomad", "model", "cache"
+	Table   string
+	Columns []string
+	Repo    dbinterface.Repository
+}
+
+// Result is the outcome of a single Check.
+type Result struct {
+	Name    string   `json:"name"`
+	Table   string   `json:"table"`
+	Columns []string `json:"columns"`
+	OK      bool     `json:"ok"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Report collects the results of a verification run.
+type Report struct {
+	OK      bool     `json:"ok"`
+	Results []Result `json:"results"`
+}
+
+// Verify runs every check and returns a report. Checks never short-circuit, so a single run
+// surfaces every misconfigured table.
+func Verify(ctx context.Context, checks []Check) Report {
+	report := Report{OK: true}
+	for _, c := range checks {
+		result := Result{Name: c.Name, Table: c.Table, Columns: c.Columns, OK: true}
+		switch {
+		case c.Table == "":
+			result.OK = false
+			result.Error = "table is not configured"
+		case c.Repo == nil:
+			result.OK = false
+			result.Error = "no repository available"
+		default:
+			if err := c.Repo.ValidateTable(ctx, c.Table, c.Columns); err != nil {
+				result.OK = false
+				result.Error = err.Error()
+			}
+		}
+		if !result.OK {
+			report.OK = false
+			logging.Warn("Schema check %s failed for table %q: %s", c.Name, c.Table, result.Error)
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// Write renders the report as "text" (default) or "json".
+func Write(report Report, format string, w io.Writer) error {
+	switch strings.ToLower(format) {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case "", "text":
+		for _, r := range report.Results {
+			status := "OK"
+			if !r.OK {
+				status = "FAIL"
+			}
+			if _, err := fmt.Fprintf(w, "%-4s  %-8s %s", status, r.Name, r.Table); err != nil {
+				return err
+			}
+			if r.Error != "" {
+				fmt.Fprintf(w, ": %s", r.Error)
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported schema report format: %s", format)
+	}
+}
@@ -0,0 +1,56 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"phite.io/polygenic-risk-calculator/internal/db/testutils"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+)
+
+func TestVerify(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+
+	ok := testutils.NewMockRepository()
+	broken := testutils.NewMockRepository()
+	broken.ValidateTableFunc = func(ctx context.Context, table string, requiredColumns []string) error {
+		return fmt.Errorf("table %q is missing required columns: [beta]", table)
+	}
+
+	report := Verify(context.Background(), []Check{
+		{Name: "gnomad", Table: "freqs", Columns: []string{"chrom"}, Repo: ok},
+		{Name: "model", Table: "models", Columns: []string{"beta"}, Repo: broken},
+		{Name: "cache", Table: "", Repo: ok},
+	})
+
+	assert.False(t, report.OK)
+	assert.Len(t, report.Results, 3)
+	assert.True(t, report.Results[0].OK)
+	assert.Contains(t, report.Results[1].Error, "beta")
+	assert.Equal(t, "table is not configured", report.Results[2].Error)
+	assert.Len(t, ok.ValidateTableCalls, 1, "unconfigured tables are not queried")
+}
+
+func TestWrite(t *testing.T) {
+	report := Report{OK: false, Results: []Result{
+		{Name: "gnomad", Table: "freqs", OK: true},
+		{Name: "model", Table: "models", OK: false, Error: "missing beta"},
+	}}
+
+	var text bytes.Buffer
+	assert.NoError(t, Write(report, "text", &text))
+	assert.Contains(t, text.String(), "OK    gnomad   freqs\n")
+	assert.Contains(t, text.String(), "FAIL  model    models: missing beta\n")
+
+	var out bytes.Buffer
+	assert.NoError(t, Write(report, "json", &out))
+	var decoded Report
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, report.Results[1].Error, decoded.Results[1].Error)
+
+	assert.Error(t, Write(report, "yaml", &out))
+}
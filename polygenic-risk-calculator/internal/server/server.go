@@ -0,0 +1,427 @@
+// Package server serves the risk calculator over HTTP, so it can be embedded in a web
+// service: clients upload a genotype file and SNP list and receive the JSON results.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"phite.io/polygenic-risk-calculator/internal/buildinfo"
+	"phite.io/polygenic-risk-calculator/internal/config"
+	"phite.io/polygenic-risk-calculator/internal/limits"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/output"
+	"phite.io/polygenic-risk-calculator/internal/progress"
+	"phite.io/polygenic-risk-calculator/internal/reference"
+	snpsutil "phite.io/polygenic-risk-calculator/internal/snps"
+)
+
+// Domain-specific configuration keys for the HTTP server
+const (
+	AddrKey        = "server.addr"        // Address `risk-calculator serve` listens on (default :8080)
+	ConcurrencyKey = "server.concurrency" // Requests scored at once (default 2); the rest wait for a slot
+	TimeoutKey     = "server.timeout"     // Longest a scoring request may take, waiting included (e.g. "5m"; default 10m)
+)
+
+// Defaults of the server settings.
+const (
+	DefaultAddr        = ":8080"
+	DefaultConcurrency = 2
+	DefaultTimeout     = 10 * time.Minute
+)
+
+// JobHeader is the response header of POST /v1/prs carrying the request's job ID. It is
+// sent with the response, so a synchronous request learns its ID only once it is scored.
+const JobHeader = "X-Phite-Job"
+
+// uploadSlack is the room allowed beyond the genotype file size limit for the SNP list and
+// multipart framing.
+const uploadSlack = 1 << 20
+
+// Request is one scoring request.
+type Request struct {
+	GenotypeFile string            // uploaded genotype file, removed once the request is answered
+	SNPs         []string          // SNPs to score
+	Progress     progress.Reporter // receives the run's phase and trait events for the job's stream
+}
+
+// Scorer scores a request. ctx ends when the request times out or the client goes away.
+type Scorer func(ctx context.Context, req Request) (output.OutputResult, error)
+
+// TraitLister lists the traits the calculator can score.
+type TraitLister func(ctx context.Context) ([]reference.TraitModel, error)
+
+// Server answers the calculator's HTTP endpoints:
+//
+//	POST /v1/prs                multipart form with a "genotype" file and "snps" (comma-separated)
+//	                            or a "snps_file" (.csv, .tsv, or .json); returns the JSON results,
+//	                            or with "async" set, 202 and the job's URLs
+//	GET  /v1/jobs/{id}/events   the job's progress as Server-Sent Events
+//	GET  /v1/jobs/{id}          an async job's results, 202 while it runs
+//	GET  /v1/traits             traits of the model table, with their model and variant count
+//	GET  /healthz               liveness
+//
+// Every scoring request is a job, whose ID is returned in the X-Phite-Job header. A
+// synchronous request only gets the header with its results, so clients following a job's
+// event stream submit it with "async". Only so
+// many requests are scored at once; the rest wait, and are turned away with 503 if no slot
+// frees up within the request timeout.
+type Server struct {
+	score    Scorer
+	traits   TraitLister
+	timeout  time.Duration
+	slots    chan struct{}
+	progress *progress.Broker
+
+	mu      sync.Mutex
+	jobs    map[string]*job // async jobs, kept until progress.FinishedJobRetention after they finish
+	running sync.WaitGroup  // async jobs still scoring
+}
+
+// job is the outcome of an async scoring request.
+type job struct {
+	done   bool
+	status int
+	result output.OutputResult
+	err    error
+}
+
+// New returns a server scoring up to concurrency requests at once, each within timeout
+// (zero for none).
+func New(score Scorer, traits TraitLister, concurrency int, timeout time.Duration) *Server {
+	return &Server{
+		score:    score,
+		traits:   traits,
+		timeout:  timeout,
+		slots:    make(chan struct{}, max(concurrency, 1)),
+		progress: progress.NewBroker(),
+		jobs:     make(map[string]*job),
+	}
+}
+
+// Wait blocks until the async jobs still scoring finish, or ctx ends.
+func (s *Server) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ConcurrencyFromConfig returns the requests scored at once.
+func ConcurrencyFromConfig() int {
+	if n := config.GetInt(ConcurrencyKey); n > 0 {
+		return n
+	}
+	return DefaultConcurrency
+}
+
+// TimeoutFromConfig returns the request timeout.
+func TimeoutFromConfig() (time.Duration, error) {
+	raw := config.GetString(TimeoutKey)
+	if raw == "" {
+		return DefaultTimeout, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s: invalid duration %q", TimeoutKey, raw)
+	}
+	return d, nil
+}
+
+// Handler returns the server's routes. Every response carries the build version headers
+// of buildinfo.Handler.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/prs", s.handlePRS)
+	mux.Handle("GET /v1/jobs/{id}/events", s.progress.SSEHandler())
+	mux.HandleFunc("GET /v1/jobs/{id}", s.handleJob)
+	mux.HandleFunc("GET /v1/traits", s.handleTraits)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return buildinfo.Handler(mux)
+}
+
+func (s *Server) handlePRS(w http.ResponseWriter, r *http.Request) {
+	if limit := limits.FromConfig().MaxGenotypeFileBytes; limit > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, limit+uploadSlack)
+	}
+	dir, err := os.MkdirTemp("", "phite-request-*")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	async := false
+	defer func() {
+		if !async {
+			os.RemoveAll(dir)
+		}
+	}()
+	req, err := readRequest(r, dir)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("%w: upload exceeds %d bytes (%s)", limits.ErrLimitExceeded, tooLarge.Limit, limits.MaxGenotypeFileMBKey))
+			return
+		}
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if raw := r.FormValue("async"); raw != "" {
+		if async, err = strconv.ParseBool(raw); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf(`"async": invalid value %q`, raw))
+			return
+		}
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.progress.Start(id)
+	w.Header().Set(JobHeader, id)
+
+	if async {
+		s.mu.Lock()
+		s.jobs[id] = &job{}
+		s.mu.Unlock()
+		s.running.Add(1)
+		client := r.RemoteAddr
+		go func() {
+			defer s.running.Done()
+			defer os.RemoveAll(dir)
+			result, status, err := s.run(context.Background(), id, req, client)
+			s.finish(id, &job{done: true, status: status, result: result, err: err})
+		}()
+		writeJSON(w, http.StatusAccepted, map[string]string{
+			"job_id": id,
+			"events": "/v1/jobs/" + id + "/events",
+			"result": "/v1/jobs/" + id,
+		})
+		return
+	}
+
+	result, status, err := s.run(r.Context(), id, req, r.RemoteAddr)
+	s.publishOutcome(id, err)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, status, result)
+}
+
+// run scores a job's request once a slot frees up, returning the response status.
+func (s *Server) run(ctx context.Context, id string, req Request, client string) (output.OutputResult, int, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		return output.OutputResult{}, http.StatusServiceUnavailable, errors.New("server busy: no scoring slot freed up in time")
+	}
+	start := time.Now()
+	req.Progress = s.pipelineReporter(id)
+	result, err := s.score(ctx, req)
+	switch {
+	case err == nil:
+	case errors.Is(err, limits.ErrLimitExceeded):
+		return result, http.StatusRequestEntityTooLarge, err
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return result, http.StatusGatewayTimeout, fmt.Errorf("scoring did not finish within %s", s.timeout)
+	default:
+		return result, http.StatusInternalServerError, err
+	}
+	logging.Info("Scored %d SNPs for %s in %s (job %s)", len(req.SNPs), client, time.Since(start).Round(time.Millisecond), id)
+	return result, http.StatusOK, nil
+}
+
+// pipelineReporter forwards a run's events to its job's stream, except the terminal one:
+// the server publishes that once the outcome is recorded, so a client seeing it can
+// fetch the results.
+func (s *Server) pipelineReporter(id string) progress.Reporter {
+	r := s.progress.Reporter(id)
+	return progress.ReporterFunc(func(ev progress.Event) {
+		if !ev.Terminal() {
+			r.Report(ev)
+		}
+	})
+}
+
+// publishOutcome ends the job's stream with its terminal event.
+func (s *Server) publishOutcome(id string, err error) {
+	ev := progress.Event{Type: progress.RunCompleted}
+	if err != nil {
+		ev = progress.Event{Type: progress.RunFailed, Error: err.Error()}
+	}
+	progress.Emit(s.progress.Reporter(id), ev)
+}
+
+// finish records an async job's outcome, ends its stream, and forgets it after
+// progress.FinishedJobRetention.
+func (s *Server) finish(id string, j *job) {
+	if j.err != nil && j.status >= http.StatusInternalServerError {
+		logging.Error("job %s failed: %v", id, j.err)
+	}
+	s.mu.Lock()
+	s.jobs[id] = j
+	s.mu.Unlock()
+	s.publishOutcome(id, j.err)
+	time.AfterFunc(progress.FinishedJobRetention, func() {
+		s.mu.Lock()
+		delete(s.jobs, id)
+		s.mu.Unlock()
+	})
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mu.Lock()
+	j, ok := s.jobs[id]
+	s.mu.Unlock()
+	switch {
+	case !ok:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown job %s", id))
+	case !j.done:
+		writeJSON(w, http.StatusAccepted, map[string]string{"job_id": id, "status": "running"})
+	case j.err != nil:
+		writeJSON(w, j.status, map[string]string{"error": j.err.Error()})
+	default:
+		writeJSON(w, j.status, j.result)
+	}
+}
+
+func (s *Server) handleTraits(w http.ResponseWriter, r *http.Request) {
+	traits, err := s.traits(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, traits)
+}
+
+// readRequest reads a scoring request's form, saving its uploads in dir. Upload names keep
+// their extension, which the genotype and SNP file parsers detect formats by.
+func readRequest(r *http.Request, dir string) (Request, error) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return Request{}, fmt.Errorf("expected a multipart form: %w", err)
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	var req Request
+	var err error
+	if req.GenotypeFile, err = saveUpload(r, "genotype", dir); err != nil {
+		return req, err
+	}
+	if req.GenotypeFile == "" {
+		return req, errors.New(`a "genotype" file is required`)
+	}
+	snpsFile, err := saveUpload(r, "snps_file", dir)
+	if err != nil {
+		return req, err
+	}
+	snps := r.FormValue("snps")
+	switch {
+	case snps != "" && snpsFile != "":
+		return req, errors.New(`"snps" and "snps_file" are mutually exclusive`)
+	case snps != "":
+		req.SNPs, err = snpsutil.ResolveSNPs(strings.FieldsFunc(snps, func(c rune) bool { return c == ',' || unicode.IsSpace(c) }), "")
+	default:
+		req.SNPs, err = snpsutil.ResolveSNPs(nil, snpsFile)
+	}
+	if errors.Is(err, snpsutil.ErrNoSNPsProvided) {
+		return req, errors.New(`one of "snps" or "snps_file" is required`)
+	}
+	return req, err
+}
+
+// saveUpload saves the form file field to dir, returning its path, or "" when the form has
+// no such file.
+func saveUpload(r *http.Request, field, dir string) (string, error) {
+	src, header, err := r.FormFile(field)
+	if errors.Is(err, http.ErrMissingFile) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %q: %w", field, err)
+	}
+	defer src.Close()
+
+	path := filepath.Join(dir, field+uploadExt(header.Filename))
+	dst, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to save %q: %w", field, err)
+	}
+	return path, nil
+}
+
+// uploadExt returns the extension of an uploaded file's name, including a compression
+// suffix, e.g. ".vcf.gz".
+func uploadExt(name string) string {
+	name = filepath.Base(name)
+	ext := filepath.Ext(name)
+	if ext == ".gz" || ext == ".bgz" {
+		ext = filepath.Ext(strings.TrimSuffix(name, ext)) + ext
+	}
+	return ext
+}
+
+// newJobID returns a random job ID.
+func newJobID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// writeJSON writes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		logging.Warn("failed to write response: %v", err)
+	}
+}
+
+// writeError writes err as a JSON error response.
+func writeError(w http.ResponseWriter, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		logging.Error("request failed: %v", err)
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"phite.io/polygenic-risk-calculator/internal/buildinfo"
+	"phite.io/polygenic-risk-calculator/internal/logging"
+	"phite.io/polygenic-risk-calculator/internal/output"
+	"phite.io/polygenic-risk-calculator/internal/progress"
+	"phite.io/polygenic-risk-calculator/internal/reference"
+)
+
+// multipartForm builds a scoring request body from form fields and file uploads.
+func multipartForm(t *testing.T, fields map[string]string, files map[string]string) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, value := range fields {
+		require.NoError(t, w.WriteField(name, value))
+	}
+	for name, filename := range files {
+		part, err := w.CreateFormFile(name, filename)
+		require.NoError(t, err)
+		_, err = part.Write([]byte("rsid\tchromosome\tposition\tallele1\tallele2\nrs1\t1\t100\tA\tG\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return &body, w.FormDataContentType()
+}
+
+func TestServer_PRS(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+
+	var got Request
+	score := func(ctx context.Context, req Request) (output.OutputResult, error) {
+		got = req
+		data, err := os.ReadFile(req.GenotypeFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "rs1")
+		return output.OutputResult{TraitSummaries: []output.TraitSummary{{Trait: "LDL", RiskLevel: "high"}}}, nil
+	}
+	ts := httptest.NewServer(New(score, nil, 1, time.Minute).Handler())
+	defer ts.Close()
+
+	body, contentType := multipartForm(t, map[string]string{"snps": "rs1, rs2"}, map[string]string{"genotype": "sample.txt.gz"})
+	resp, err := http.Post(ts.URL+"/v1/prs", contentType, body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var result output.OutputResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	require.Len(t, result.TraitSummaries, 1)
+	assert.Equal(t, "LDL", result.TraitSummaries[0].Trait)
+	assert.Equal(t, []string{"rs1", "rs2"}, got.SNPs)
+	assert.Regexp(t, `genotype\.txt\.gz$`, got.GenotypeFile)
+	assert.Len(t, resp.Header.Get(JobHeader), 32)
+	_, err = os.Stat(got.GenotypeFile)
+	assert.True(t, os.IsNotExist(err), "uploads are removed once answered")
+}
+
+func TestServer_PRSBadRequest(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+
+	score := func(ctx context.Context, req Request) (output.OutputResult, error) {
+		t.Fatal("invalid requests are not scored")
+		return output.OutputResult{}, nil
+	}
+	ts := httptest.NewServer(New(score, nil, 1, time.Minute).Handler())
+	defer ts.Close()
+
+	cases := map[string]struct {
+		fields map[string]string
+		files  map[string]string
+		want   string
+	}{
+		"no genotype": {fields: map[string]string{"snps": "rs1"}, want: `"genotype" file is required`},
+		"no snps":     {files: map[string]string{"genotype": "g.txt"}, want: `one of "snps" or "snps_file" is required`},
+		"both snps":   {fields: map[string]string{"snps": "rs1"}, files: map[string]string{"genotype": "g.txt", "snps_file": "s.csv"}, want: "mutually exclusive"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			body, contentType := multipartForm(t, tc.fields, tc.files)
+			resp, err := http.Post(ts.URL+"/v1/prs", contentType, body)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			var e map[string]string
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&e))
+			assert.Contains(t, e["error"], tc.want)
+		})
+	}
+}
+
+func TestServer_PRSTimeout(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+
+	score := func(ctx context.Context, req Request) (output.OutputResult, error) {
+		<-ctx.Done()
+		return output.OutputResult{}, ctx.Err()
+	}
+	ts := httptest.NewServer(New(score, nil, 1, 50*time.Millisecond).Handler())
+	defer ts.Close()
+
+	body, contentType := multipartForm(t, map[string]string{"snps": "rs1"}, map[string]string{"genotype": "g.txt"})
+	resp, err := http.Post(ts.URL+"/v1/prs", contentType, body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+}
+
+func TestServer_PRSBusy(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+
+	srv := New(nil, nil, 1, 50*time.Millisecond)
+	srv.slots <- struct{}{} // the only slot is taken
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	body, contentType := multipartForm(t, map[string]string{"snps": "rs1"}, map[string]string{"genotype": "g.txt"})
+	resp, err := http.Post(ts.URL+"/v1/prs", contentType, body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestServer_Traits(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+
+	traits := func(ctx context.Context) ([]reference.TraitModel, error) {
+		return []reference.TraitModel{{Trait: "LDL", Model: "PGS000001", Variants: 120}}, nil
+	}
+	ts := httptest.NewServer(New(nil, traits, 1, 0).Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/v1/traits")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var got []reference.TraitModel
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, []reference.TraitModel{{Trait: "LDL", Model: "PGS000001", Variants: 120}}, got)
+
+	resp, err = http.Get(ts.URL + "/healthz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+// streamTypes reads a job's event stream to its end, returning the event types.
+func streamTypes(t *testing.T, url string) []progress.EventType {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var types []progress.EventType
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var ev progress.Event
+		require.NoError(t, json.Unmarshal([]byte(data), &ev))
+		types = append(types, ev.Type)
+	}
+	return types
+}
+
+func TestServer_PRSAsync(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+
+	release := make(chan struct{})
+	score := func(ctx context.Context, req Request) (output.OutputResult, error) {
+		progress.Emit(req.Progress, progress.Event{Type: progress.PhaseStarted, Phase: 1})
+		<-release
+		progress.Emit(req.Progress, progress.Event{Type: progress.TraitCompleted, Trait: "LDL"})
+		progress.Emit(req.Progress, progress.Event{Type: progress.RunCompleted})
+		return output.OutputResult{TraitSummaries: []output.TraitSummary{{Trait: "LDL"}}}, nil
+	}
+	srv := New(score, nil, 1, time.Minute)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	body, contentType := multipartForm(t, map[string]string{"snps": "rs1", "async": "true"}, map[string]string{"genotype": "g.txt"})
+	resp, err := http.Post(ts.URL+"/v1/prs", contentType, body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var accepted map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&accepted))
+	id := accepted["job_id"]
+	assert.Equal(t, resp.Header.Get(JobHeader), id)
+	assert.Equal(t, "/v1/jobs/"+id+"/events", accepted["events"])
+
+	pending, err := http.Get(ts.URL + accepted["result"])
+	require.NoError(t, err)
+	pending.Body.Close()
+	assert.Equal(t, http.StatusAccepted, pending.StatusCode)
+
+	close(release)
+	assert.Equal(t, []progress.EventType{progress.PhaseStarted, progress.TraitCompleted, progress.RunCompleted}, streamTypes(t, ts.URL+accepted["events"]))
+
+	// The terminal event is published once the results are stored
+	done, err := http.Get(ts.URL + accepted["result"])
+	require.NoError(t, err)
+	defer done.Body.Close()
+	assert.Equal(t, http.StatusOK, done.StatusCode)
+	var result output.OutputResult
+	require.NoError(t, json.NewDecoder(done.Body).Decode(&result))
+	require.Len(t, result.TraitSummaries, 1)
+	require.NoError(t, srv.Wait(context.Background()))
+}
+
+func TestServer_UnknownJob(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+
+	ts := httptest.NewServer(New(nil, nil, 1, 0).Handler())
+	defer ts.Close()
+
+	for _, path := range []string{"/v1/jobs/nope", "/v1/jobs/nope/events"} {
+		resp, err := http.Get(ts.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+	}
+}
+
+func TestServer_VersionHeaders(t *testing.T) {
+	logging.SetSilentLoggingForTest()
+	defer func(v, c string) { buildinfo.Version, buildinfo.Commit = v, c }(buildinfo.Version, buildinfo.Commit)
+	buildinfo.Version, buildinfo.Commit = "v1.2.3", "abc123"
+
+	ts := httptest.NewServer(New(nil, nil, 1, 0).Handler())
+	defer ts.Close()
+
+	// Successful and failed responses alike
+	body, contentType := multipartForm(t, nil, nil)
+	requests := map[string]func() (*http.Response, error){
+		"healthz": func() (*http.Response, error) { return http.Get(ts.URL + "/healthz") },
+		"prs":     func() (*http.Response, error) { return http.Post(ts.URL+"/v1/prs", contentType, body) },
+		"job":     func() (*http.Response, error) { return http.Get(ts.URL + "/v1/jobs/nope") },
+		"events":  func() (*http.Response, error) { return http.Get(ts.URL + "/v1/jobs/nope/events") },
+	}
+	for name, do := range requests {
+		resp, err := do()
+		require.NoError(t, err, name)
+		resp.Body.Close()
+		assert.Equal(t, "v1.2.3", resp.Header.Get(buildinfo.HeaderVersion), name)
+		assert.Equal(t, "abc123", resp.Header.Get(buildinfo.HeaderCommit), name)
+	}
+}
+
+func TestUploadExt(t *testing.T) {
+	assert.Equal(t, ".vcf.gz", uploadExt("dir/sample.vcf.gz"))
+	assert.Equal(t, ".txt", uploadExt("sample.txt"))
+	assert.Equal(t, "", uploadExt("sample"))
+}
@@ -0,0 +1,194 @@
+// Package workflow generates task descriptors that run the calculator as a step of a WDL,
+// Nextflow, or CWL pipeline. Every descriptor runs the calculator with
+// --machine-readable-io, so the task's outputs are the results file and the I/O manifest
+// printed on stdout, with logs left on stderr.
+package workflow
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Kind is the type of a task input.
+type Kind int
+
+// Input kinds.
+const (
+	File   Kind = iota // a path the engine stages into the task
+	String             // a plain value
+	Bool               // a flag that takes no value
+)
+
+// Input is one command-line flag exposed as a task input.
+type Input struct {
+	Flag     string // flag name without dashes, e.g. "genotype-file"
+	Kind     Kind
+	Required bool
+	Doc      string
+}
+
+// Name is the input's identifier in a descriptor: the flag with dashes replaced by
+// underscores.
+func (in Input) Name() string {
+	return strings.ReplaceAll(in.Flag, "-", "_")
+}
+
+// Task describes the calculator invocation a descriptor wraps.
+type Task struct {
+	Name      string // task name, e.g. "phite_risk_calculator"
+	Command   string // executable, e.g. "risk-calculator"
+	Args      []string
+	Version   string // calculator version recorded in the descriptor
+	Container string // optional container image
+	Inputs    []Input
+	Results   string // results file the task writes and exports, e.g. "results.json"
+	Manifest  string // file stdout (the I/O manifest) is captured to, e.g. "manifest.json"
+}
+
+var renderers = map[string]func(io.Writer, Task) error{
+	"cwl":      renderCWL,
+	"nextflow": renderNextflow,
+	"wdl":      renderWDL,
+}
+
+// Engines returns the supported workflow engines in sorted order.
+func Engines() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render writes the task descriptor for engine to w.
+func Render(w io.Writer, engine string, t Task) error {
+	render, ok := renderers[strings.ToLower(engine)]
+	if !ok {
+		return fmt.Errorf("unsupported engine %q: use %s", engine, strings.Join(Engines(), ", "))
+	}
+	return render(w, t)
+}
+
+// baseCommand is the command every descriptor runs before the input flags.
+func (t Task) baseCommand() []string {
+	cmd := append([]string{t.Command}, t.Args...)
+	return append(cmd, "--output", t.Results)
+}
+
+func renderWDL(w io.Writer, t Task) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "version 1.0\n\n")
+	fmt.Fprintf(&b, "# Generated by `%s descriptor --engine wdl` (version %s).\n", t.Command, t.Version)
+	fmt.Fprintf(&b, "task %s {\n", t.Name)
+	b.WriteString("  input {\n")
+	for _, in := range t.Inputs {
+		switch {
+		case in.Kind == Bool:
+			fmt.Fprintf(&b, "    Boolean %s = false", in.Name())
+		case in.Kind == File && in.Required:
+			fmt.Fprintf(&b, "    File %s", in.Name())
+		case in.Kind == File:
+			fmt.Fprintf(&b, "    File? %s", in.Name())
+		case in.Required:
+			fmt.Fprintf(&b, "    String %s", in.Name())
+		default:
+			fmt.Fprintf(&b, "    String? %s", in.Name())
+		}
+		fmt.Fprintf(&b, " # %s\n", in.Doc)
+	}
+	b.WriteString("  }\n\n")
+	b.WriteString("  command <<<\n")
+	fmt.Fprintf(&b, "    %s", strings.Join(t.baseCommand(), " "))
+	for _, in := range t.Inputs {
+		switch {
+		case in.Kind == Bool:
+			fmt.Fprintf(&b, " \\\n      ~{if %s then \"--%s\" else \"\"}", in.Name(), in.Flag)
+		case in.Required:
+			fmt.Fprintf(&b, " \\\n      --%s '~{%s}'", in.Flag, in.Name())
+		default:
+			fmt.Fprintf(&b, " \\\n      ~{\"--%s '\" + %s + \"'\"}", in.Flag, in.Name())
+		}
+	}
+	b.WriteString("\n  >>>\n\n")
+	b.WriteString("  output {\n")
+	fmt.Fprintf(&b, "    File results = \"%s\"\n", t.Results)
+	b.WriteString("    File manifest = stdout()\n")
+	b.WriteString("  }\n")
+	if t.Container != "" {
+		fmt.Fprintf(&b, "\n  runtime {\n    docker: \"%s\"\n  }\n", t.Container)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func renderNextflow(w io.Writer, t Task) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Generated by `%s descriptor --engine nextflow` (version %s).\n", t.Command, t.Version)
+	b.WriteString("// Pass [] for an absent optional file, '' for an absent value, and false for an unset flag.\n")
+	fmt.Fprintf(&b, "process %s {\n", strings.ToUpper(t.Name))
+	if t.Container != "" {
+		fmt.Fprintf(&b, "    container '%s'\n\n", t.Container)
+	}
+	b.WriteString("    input:\n")
+	for _, in := range t.Inputs {
+		if in.Kind == File {
+			fmt.Fprintf(&b, "    path %s // %s\n", in.Name(), in.Doc)
+		} else {
+			fmt.Fprintf(&b, "    val %s // %s\n", in.Name(), in.Doc)
+		}
+	}
+	b.WriteString("\n    output:\n")
+	fmt.Fprintf(&b, "    path '%s', emit: results\n", t.Results)
+	fmt.Fprintf(&b, "    path '%s', emit: manifest\n", t.Manifest)
+	b.WriteString("\n    script:\n")
+	b.WriteString("    def args = []\n")
+	for _, in := range t.Inputs {
+		switch {
+		case in.Kind == Bool:
+			fmt.Fprintf(&b, "    if (%s) args << '--%s'\n", in.Name(), in.Flag)
+		case in.Required:
+			fmt.Fprintf(&b, "    args << \"--%s '${%s}'\"\n", in.Flag, in.Name())
+		default:
+			fmt.Fprintf(&b, "    if (%s) args << \"--%s '${%s}'\"\n", in.Name(), in.Flag, in.Name())
+		}
+	}
+	b.WriteString("    \"\"\"\n")
+	fmt.Fprintf(&b, "    %s ${args.join(' ')} > %s\n", strings.Join(t.baseCommand(), " "), t.Manifest)
+	b.WriteString("    \"\"\"\n")
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func renderCWL(w io.Writer, t Task) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by `%s descriptor --engine cwl` (version %s).\n", t.Command, t.Version)
+	b.WriteString("cwlVersion: v1.2\n")
+	b.WriteString("class: CommandLineTool\n")
+	fmt.Fprintf(&b, "id: %s\n", t.Name)
+	fmt.Fprintf(&b, "baseCommand: [%s]\n", strings.Join(t.baseCommand(), ", "))
+	if t.Container != "" {
+		fmt.Fprintf(&b, "requirements:\n  DockerRequirement:\n    dockerPull: %s\n", t.Container)
+	}
+	b.WriteString("inputs:\n")
+	for _, in := range t.Inputs {
+		typ := map[Kind]string{File: "File", String: "string", Bool: "boolean"}[in.Kind]
+		if !in.Required {
+			typ += "?"
+		}
+		fmt.Fprintf(&b, "  %s:\n", in.Name())
+		fmt.Fprintf(&b, "    type: %s\n", typ)
+		fmt.Fprintf(&b, "    doc: %q\n", in.Doc)
+		fmt.Fprintf(&b, "    inputBinding:\n      prefix: --%s\n", in.Flag)
+	}
+	b.WriteString("outputs:\n")
+	fmt.Fprintf(&b, "  results:\n    type: File\n    outputBinding:\n      glob: %s\n", t.Results)
+	b.WriteString("  manifest:\n    type: stdout\n")
+	fmt.Fprintf(&b, "stdout: %s\n", t.Manifest)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,87 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTask() Task {
+	return Task{
+		Name:      "phite_risk_calculator",
+		Command:   "risk-calculator",
+		Args:      []string{"--machine-readable-io"},
+		Version:   "v1.2.0",
+		Container: "ghcr.io/example/phite:1.2.0",
+		Inputs: []Input{
+			{Flag: "genotype-file", Kind: File, Required: true, Doc: "Genotype file"},
+			{Flag: "snps-file", Kind: File, Doc: "SNPs file"},
+			{Flag: "ancestry", Kind: String, Doc: "Population code"},
+			{Flag: "pgx", Kind: Bool, Doc: "Report pharmacogene phenotypes"},
+		},
+		Results:  "results.json",
+		Manifest: "manifest.json",
+	}
+}
+
+func render(t *testing.T, engine string) string {
+	t.Helper()
+	var b strings.Builder
+	require.NoError(t, Render(&b, engine, testTask()))
+	return b.String()
+}
+
+func TestRender_WDL(t *testing.T) {
+	out := render(t, "wdl")
+	assert.True(t, strings.HasPrefix(out, "version 1.0\n"))
+	assert.Contains(t, out, "task phite_risk_calculator {")
+	assert.Contains(t, out, "    File genotype_file # Genotype file\n")
+	assert.Contains(t, out, "    File? snps_file")
+	assert.Contains(t, out, "    String? ancestry")
+	assert.Contains(t, out, "    Boolean pgx = false")
+	assert.Contains(t, out, "risk-calculator --machine-readable-io --output results.json")
+	assert.Contains(t, out, "--genotype-file '~{genotype_file}'")
+	assert.Contains(t, out, `~{"--snps-file '" + snps_file + "'"}`)
+	assert.Contains(t, out, `~{if pgx then "--pgx" else ""}`)
+	assert.Contains(t, out, "File manifest = stdout()")
+	assert.Contains(t, out, `docker: "ghcr.io/example/phite:1.2.0"`)
+	assert.Equal(t, strings.Count(out, "{"), strings.Count(out, "}"), "unbalanced braces:\n%s", out)
+}
+
+func TestRender_Nextflow(t *testing.T) {
+	out := render(t, "Nextflow")
+	assert.Contains(t, out, "process PHITE_RISK_CALCULATOR {")
+	assert.Contains(t, out, "container 'ghcr.io/example/phite:1.2.0'")
+	assert.Contains(t, out, "    path genotype_file // Genotype file\n")
+	assert.Contains(t, out, "    val ancestry")
+	assert.Contains(t, out, `args << "--genotype-file '${genotype_file}'"`)
+	assert.Contains(t, out, `if (snps_file) args << "--snps-file '${snps_file}'"`)
+	assert.Contains(t, out, "if (pgx) args << '--pgx'")
+	assert.Contains(t, out, "risk-calculator --machine-readable-io --output results.json ${args.join(' ')} > manifest.json")
+	assert.Contains(t, out, "path 'manifest.json', emit: manifest")
+}
+
+func TestRender_CWL(t *testing.T) {
+	out := render(t, "cwl")
+	assert.Contains(t, out, "cwlVersion: v1.2\nclass: CommandLineTool\n")
+	assert.Contains(t, out, "baseCommand: [risk-calculator, --machine-readable-io, --output, results.json]")
+	assert.Contains(t, out, "  genotype_file:\n    type: File\n")
+	assert.Contains(t, out, "  snps_file:\n    type: File?\n")
+	assert.Contains(t, out, "  pgx:\n    type: boolean?\n")
+	assert.Contains(t, out, "      prefix: --ancestry\n")
+	assert.Contains(t, out, "dockerPull: ghcr.io/example/phite:1.2.0")
+	assert.Contains(t, out, "stdout: manifest.json\n")
+	assert.NotContains(t, out, "\t", "YAML must not be tab-indented")
+}
+
+func TestRender_UnsupportedEngine(t *testing.T) {
+	err := Render(&strings.Builder{}, "snakemake", testTask())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cwl, nextflow, wdl")
+}
+
+func TestInput_Name(t *testing.T) {
+	assert.Equal(t, "trait_model_map", Input{Flag: "trait-model-map"}.Name())
+}
@@ -0,0 +1,264 @@
+// Package taxonomy maps traits and genes to the PHITE Topic/Group categories used to
+// organize genetic results, so the converter and the risk calculator file results under
+// the same headings.
+//
+// A taxonomy is read from a tab-separated file with a header row. Topic and Group columns
+// are required; optional Gene(s) and Trait(s) columns hold comma-separated names filed
+// under that row's category. Other columns are ignored, so a converter input TSV
+// (Topic, Group, Gene, RS ID, ...) is itself a valid taxonomy source:
+//
+//	Topic	Group	Genes	Traits
+//	Metabolic Health	Diabetes Risk	TCF7L2,FTO	t2d,fasting_glucose
+package taxonomy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Category is a Topic and a Group within it.
+type Category struct {
+	Topic string `json:"topic"`
+	Group string `json:"group,omitempty"`
+}
+
+// Taxonomy is a set of known categories and the traits and genes filed under them.
+type Taxonomy struct {
+	groups map[string][]string // topic -> groups, in first-seen order
+	traits map[string]Category // lower-cased trait -> category
+	genes  map[string]Category // upper-cased gene -> category
+}
+
+// New returns an empty taxonomy.
+func New() *Taxonomy {
+	return &Taxonomy{
+		groups: make(map[string][]string),
+		traits: make(map[string]Category),
+		genes:  make(map[string]Category),
+	}
+}
+
+// Add registers a category. A category with an empty group registers only its topic.
+func (t *Taxonomy) Add(c Category) {
+	groups, ok := t.groups[c.Topic]
+	if !ok {
+		t.groups[c.Topic] = nil
+	}
+	if c.Group == "" {
+		return
+	}
+	for _, g := range groups {
+		if g == c.Group {
+			return
+		}
+	}
+	t.groups[c.Topic] = append(groups, c.Group)
+}
+
+// AddTrait files a trait under a category, replacing any earlier entry.
+func (t *Taxonomy) AddTrait(trait string, c Category) {
+	t.Add(c)
+	t.traits[strings.ToLower(trait)] = c
+}
+
+// AddGene files a gene under a category, replacing any earlier entry.
+func (t *Taxonomy) AddGene(gene string, c Category) {
+	t.Add(c)
+	t.genes[strings.ToUpper(gene)] = c
+}
+
+// Trait returns the category of a trait. Lookup is case-insensitive.
+func (t *Taxonomy) Trait(trait string) (Category, bool) {
+	if t == nil {
+		return Category{}, false
+	}
+	c, ok := t.traits[strings.ToLower(trait)]
+	return c, ok
+}
+
+// Gene returns the category of a gene. Lookup is case-insensitive.
+func (t *Taxonomy) Gene(gene string) (Category, bool) {
+	if t == nil {
+		return Category{}, false
+	}
+	c, ok := t.genes[strings.ToUpper(gene)]
+	return c, ok
+}
+
+// Contains reports whether the category is known. A category with an empty group
+// matches on topic alone.
+func (t *Taxonomy) Contains(c Category) bool {
+	if t == nil {
+		return false
+	}
+	groups, ok := t.groups[c.Topic]
+	if !ok || c.Group == "" {
+		return ok
+	}
+	for _, g := range groups {
+		if g == c.Group {
+			return true
+		}
+	}
+	return false
+}
+
+// Topics returns the known topics, sorted.
+func (t *Taxonomy) Topics() []string {
+	if t == nil {
+		return nil
+	}
+	topics := make([]string, 0, len(t.groups))
+	for topic := range t.groups {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Groups returns the known groups of a topic, in the order they were added.
+func (t *Taxonomy) Groups(topic string) []string {
+	if t == nil {
+		return nil
+	}
+	return append([]string(nil), t.groups[topic]...)
+}
+
+// Load reads a taxonomy file.
+func Load(path string) (*Taxonomy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open taxonomy: %w", err)
+	}
+	defer f.Close()
+
+	t, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Parse reads a taxonomy from tab-separated text with a header row.
+func Parse(r io.Reader) (*Taxonomy, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read taxonomy: %w", err)
+		}
+		return nil, fmt.Errorf("taxonomy is empty")
+	}
+
+	columns := map[string]int{"topic": -1, "group": -1, "gene": -1, "trait": -1}
+	for i, name := range strings.Split(scanner.Text(), "\t") {
+		name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), "s")
+		if idx, ok := columns[name]; ok && idx < 0 {
+			columns[name] = i
+		}
+	}
+	if columns["topic"] < 0 || columns["group"] < 0 {
+		return nil, fmt.Errorf("taxonomy header must include Topic and Group columns")
+	}
+
+	t := New()
+	lineNum := 1
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		c := Category{Topic: field(fields, columns["topic"]), Group: field(fields, columns["group"])}
+		if c.Topic == "" {
+			return nil, fmt.Errorf("taxonomy line %d: topic is empty", lineNum)
+		}
+		t.Add(c)
+		for _, gene := range list(field(fields, columns["gene"])) {
+			t.AddGene(gene, c)
+		}
+		for _, trait := range list(field(fields, columns["trait"])) {
+			t.AddTrait(trait, c)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read taxonomy: %w", err)
+	}
+	return t, nil
+}
+
+func field(fields []string, i int) string {
+	if i < 0 || i >= len(fields) {
+		return ""
+	}
+	return strings.TrimSpace(fields[i])
+}
+
+func list(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// SuggestTopic returns the known topic closest to topic, if one is near enough to be a
+// likely misspelling. Matching ignores case and surrounding or repeated whitespace.
+func (t *Taxonomy) SuggestTopic(topic string) (string, bool) {
+	return closest(t.Topics(), topic)
+}
+
+// SuggestGroup returns the known group of topic closest to group, if one is near enough
+// to be a likely misspelling.
+func (t *Taxonomy) SuggestGroup(topic, group string) (string, bool) {
+	return closest(t.Groups(topic), group)
+}
+
+// closest returns the candidate with the smallest edit distance to value, accepting
+// distances up to a quarter of value's length (at least 2).
+func closest(candidates []string, value string) (string, bool) {
+	norm := normalize(value)
+	maxDist := len([]rune(norm)) / 4
+	if maxDist < 2 {
+		maxDist = 2
+	}
+	best, bestDist := "", maxDist+1
+	for _, c := range candidates {
+		if d := levenshtein(norm, normalize(c)); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best, best != ""
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// levenshtein returns the edit distance between a and b, counting runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,111 @@
+package taxonomy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	input := "Topic\tGroup\tGenes\tTraits\n" +
+		"# comment\n" +
+		"Metabolic Health\tDiabetes Risk\tTCF7L2, FTO\tt2d,fasting_glucose\n" +
+		"Metabolic Health\tLipids\tAPOE\tLDL\n" +
+		"Cognitive\tMemory\t\t\n"
+
+	tax, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if c, ok := tax.Trait("T2D"); !ok || c != (Category{Topic: "Metabolic Health", Group: "Diabetes Risk"}) {
+		t.Errorf("Trait(T2D) = %+v, %v", c, ok)
+	}
+	if c, ok := tax.Gene("fto"); !ok || c.Group != "Diabetes Risk" {
+		t.Errorf("Gene(fto) = %+v, %v", c, ok)
+	}
+	if c, ok := tax.Trait("ldl"); !ok || c.Group != "Lipids" {
+		t.Errorf("Trait(ldl) = %+v, %v", c, ok)
+	}
+	if _, ok := tax.Trait("height"); ok {
+		t.Errorf("Trait(height) found, want missing")
+	}
+
+	if got := strings.Join(tax.Topics(), "|"); got != "Cognitive|Metabolic Health" {
+		t.Errorf("Topics() = %q", got)
+	}
+	if got := strings.Join(tax.Groups("Metabolic Health"), "|"); got != "Diabetes Risk|Lipids" {
+		t.Errorf("Groups() = %q", got)
+	}
+	if !tax.Contains(Category{Topic: "Cognitive", Group: "Memory"}) || !tax.Contains(Category{Topic: "Cognitive"}) {
+		t.Errorf("Contains() missed a known category")
+	}
+	if tax.Contains(Category{Topic: "Cognitive", Group: "Mood"}) {
+		t.Errorf("Contains() matched an unknown group")
+	}
+}
+
+func TestParse_ConverterTSV(t *testing.T) {
+	input := "Topic\tGroup\tGene\tRS ID\tAllele\tSubject Genotype\tNotes\n" +
+		"Nutrients – Vitamins and Minerals\tMTHFR\tMTHFR C677T\trs1801133\tA\tAG\tfolate metabolism\n"
+
+	tax, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if c, ok := tax.Gene("MTHFR C677T"); !ok || c.Topic != "Nutrients – Vitamins and Minerals" || c.Group != "MTHFR" {
+		t.Errorf("Gene(MTHFR C677T) = %+v, %v", c, ok)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]string{
+		"empty":         "",
+		"missing group": "Topic\tGenes\nMetabolic\tFTO\n",
+		"empty topic":   "Topic\tGroup\n\tLipids\n",
+	}
+	for name, input := range tests {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestNilTaxonomy(t *testing.T) {
+	var tax *Taxonomy
+	if _, ok := tax.Trait("t2d"); ok {
+		t.Errorf("nil taxonomy found a trait")
+	}
+	if tax.Contains(Category{Topic: "Metabolic"}) || tax.Topics() != nil {
+		t.Errorf("nil taxonomy is not empty")
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tax := New()
+	tax.Add(Category{Topic: "Nutrients – Vitamins and Minerals", Group: "MTHFR"})
+	tax.Add(Category{Topic: "Metabolic Health", Group: "Diabetes Risk"})
+	tax.Add(Category{Topic: "Metabolic Health", Group: "Lipids"})
+
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"metabolic  health", "Metabolic Health", true},
+		{"Metabolc Health", "Metabolic Health", true},
+		{"Nutrients - Vitamins and Minerals", "Nutrients – Vitamins and Minerals", true},
+		{"Cognitive", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := tax.SuggestTopic(tt.value); got != tt.want || ok != tt.ok {
+			t.Errorf("SuggestTopic(%q) = %q, %v; want %q, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if got, ok := tax.SuggestGroup("Metabolic Health", "Lipid"); !ok || got != "Lipids" {
+		t.Errorf("SuggestGroup(Lipid) = %q, %v", got, ok)
+	}
+	if _, ok := tax.SuggestGroup("Metabolic Health", "MTHFR"); ok {
+		t.Errorf("SuggestGroup matched a group from another topic")
+	}
+}